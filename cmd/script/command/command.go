@@ -1,7 +1,11 @@
 package command
 
 import (
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/conf"
 	"omiai-server/internal/data"
+	"omiai-server/internal/service/embedding"
+	"omiai-server/internal/service/proposal"
 
 	"github.com/google/wire"
 )
@@ -11,11 +15,19 @@ var ProviderSet = wire.NewSet(
 )
 
 type Script struct {
-	db *data.DB
+	db         *data.DB
+	conf       *conf.Config
+	regions    biz_omiai.ChinaRegionInterface
+	proposals  *proposal.Service
+	embeddings *embedding.Service
 }
 
-func NewScript(db *data.DB) *Script {
+func NewScript(db *data.DB, c *conf.Config, regions biz_omiai.ChinaRegionInterface, proposals *proposal.Service, embeddings *embedding.Service) *Script {
 	return &Script{
-		db: db,
+		db:         db,
+		conf:       c,
+		regions:    regions,
+		proposals:  proposals,
+		embeddings: embeddings,
 	}
 }
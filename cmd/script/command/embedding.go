@@ -0,0 +1,24 @@
+package command
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func (s *Script) Embedding() *cobra.Command {
+	return &cobra.Command{
+		Use:   "embedding",
+		Short: "Rebuild client text embeddings",
+		Long:  "Refit the embedding model on every client's remark, family description and partner requirements, then refresh all stored vectors",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			n, err := s.embeddings.Rebuild(context.Background())
+			if err != nil {
+				return err
+			}
+			fmt.Printf("model %s: %d clients embedded\n", s.embeddings.Model(), n)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,158 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/service/evaluation"
+
+	"github.com/spf13/cobra"
+)
+
+func (s *Script) Evaluate() *cobra.Command {
+	var (
+		input, export string
+		weights       []string
+		ks            []int
+		asJSON        bool
+	)
+	cmd := &cobra.Command{
+		Use:   "evaluate",
+		Short: "Replay historical match outcomes against scoring configurations",
+		Long: "Re-score every couple in match_record with each weight configuration and report AUC, precision@k " +
+			"and the average score of married versus broken-up pairs. Reads a JSON export with --input, " +
+			"otherwise the configured database (point it at a snapshot, e.g. a sqlite file).",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			configs := []*evaluation.Config{{Name: "current", Weights: s.currentWeights()}}
+			for _, w := range weights {
+				cfg, err := evaluation.ParseConfig(w)
+				if err != nil {
+					return err
+				}
+				configs = append(configs, cfg)
+			}
+
+			var (
+				snapshot *evaluation.Snapshot
+				regions  biz_omiai.ChinaRegionInterface
+				err      error
+			)
+			if input != "" {
+				// JSON 导出不含行政区划，地域维度按代码规则推断
+				f, err := os.Open(input)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				if snapshot, err = evaluation.ReadSnapshot(f); err != nil {
+					return err
+				}
+			} else {
+				if snapshot, err = s.loadSnapshot(ctx); err != nil {
+					return err
+				}
+				regions = s.regions
+			}
+
+			if export != "" {
+				f, err := os.Create(export)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				if err := evaluation.WriteSnapshot(f, snapshot); err != nil {
+					return err
+				}
+				fmt.Printf("exported %d clients, %d records, %d history rows to %s\n",
+					len(snapshot.Clients), len(snapshot.Records), len(snapshot.History), export)
+				return nil
+			}
+
+			report, err := evaluation.Evaluate(snapshot, configs, regions, ks)
+			if err != nil {
+				return err
+			}
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(report)
+			}
+			printReport(report)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&input, "input", "", "JSON export to evaluate instead of the database")
+	cmd.Flags().StringVar(&export, "export", "", "write the dataset as JSON to this path and exit")
+	cmd.Flags().StringArrayVar(&weights, "weights", nil, "extra configuration, e.g. edu:education=0.3,age=0.1 (repeatable)")
+	cmd.Flags().IntSliceVar(&ks, "k", evaluation.DefaultK, "cut-offs for precision@k")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print the report as JSON")
+	return cmd
+}
+
+// currentWeights 配置文件中的权重，未配置时为默认权重
+func (s *Script) currentWeights() map[string]float64 {
+	if s.conf != nil && s.conf.Match != nil {
+		return s.conf.Match.Weights
+	}
+	return nil
+}
+
+// loadSnapshot 读取全部情侣档案、状态记录及双方资料
+func (s *Script) loadSnapshot(ctx context.Context) (*evaluation.Snapshot, error) {
+	snapshot := &evaluation.Snapshot{}
+	if err := s.db.WithContext(ctx).Order("id").Find(&snapshot.Records).Error; err != nil {
+		return nil, err
+	}
+	if err := s.db.WithContext(ctx).Order("id").Find(&snapshot.History).Error; err != nil {
+		return nil, err
+	}
+	ids := make([]uint64, 0, len(snapshot.Records)*2)
+	for _, r := range snapshot.Records {
+		ids = append(ids, r.MaleClientID, r.FemaleClientID)
+	}
+	if len(ids) == 0 {
+		return snapshot, nil
+	}
+	if err := s.db.WithContext(ctx).Scopes(biz_omiai.ScoringPreloads).Where("id IN ?", ids).Order("id").
+		Find(&snapshot.Clients).Error; err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+func printReport(r *evaluation.Report) {
+	fmt.Printf("records=%d positive=%d (married=%d) broken=%d skipped=%d\n\n",
+		r.Records, r.Positive, r.Married, r.Negative, r.Skipped)
+
+	fmt.Printf("%-16s %8s", "config", "auc")
+	if len(r.Results) > 0 {
+		for _, p := range r.Results[0].PrecisionAtK {
+			fmt.Printf(" %8s", fmt.Sprintf("p@%d", p.K))
+		}
+	}
+	fmt.Printf(" %10s %10s %10s\n", "avg_pos", "avg_broken", "avg_married")
+	for _, res := range r.Results {
+		fmt.Printf("%-16s %8.4f", res.Name, res.AUC)
+		for _, p := range res.PrecisionAtK {
+			fmt.Printf(" %8.4f", p.Precision)
+		}
+		fmt.Printf(" %10.2f %10.2f %10.2f\n", res.AvgPositive, res.AvgNegative, res.AvgMarried)
+	}
+
+	names := make([]string, 0, len(r.DimensionAUC))
+	for name := range r.DimensionAUC {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s=%.4f", name, r.DimensionAUC[name]))
+	}
+	fmt.Printf("\ndimension auc: %s\n", strings.Join(parts, " "))
+}
@@ -0,0 +1,40 @@
+package command
+
+import (
+	"context"
+	"fmt"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+
+	"github.com/spf13/cobra"
+)
+
+func (s *Script) Propose() *cobra.Command {
+	opts := &biz_omiai.ProposalOptions{Operator: "script"}
+	var strict bool
+	cmd := &cobra.Command{
+		Use:   "propose",
+		Short: "Generate a batch of pairing proposals",
+		Long:  "Score every pair in the single pool and solve a globally balanced set of proposals for matchmakers to review",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if strict {
+				opts.RequirementMode = biz_omiai.RequirementStrict
+			}
+			batch, proposals, err := s.proposals.Generate(context.Background(), opts)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("batch %d: strategy=%s clients=%d proposals=%d avg_score=%.1f\n",
+				batch.ID, batch.Strategy, batch.ClientCount, batch.ProposalCount, batch.AvgScore)
+			for _, p := range proposals {
+				fmt.Printf("  %d <-> %d  score=%d\n", p.MaleClientID, p.FemaleClientID, p.Score)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&opts.Strategy, "strategy", biz_omiai.ProposalStrategyMaxWeight, "max_weight or stable")
+	cmd.Flags().IntVar(&opts.CandidateCap, "cap", biz_omiai.DefaultProposalCap, "max pending proposals per client")
+	cmd.Flags().IntVar(&opts.MinScore, "min-score", biz_omiai.DefaultProposalMinScore, "minimum pair score")
+	cmd.Flags().BoolVar(&strict, "strict", false, "require both sides' partner requirements to be fully met")
+	return cmd
+}
@@ -54,6 +54,9 @@ func main() {
 
 	// Add commands
 	rootCmd.AddCommand(app.Command.InsertClass())
+	rootCmd.AddCommand(app.Command.Propose())
+	rootCmd.AddCommand(app.Command.Evaluate())
+	rootCmd.AddCommand(app.Command.Embedding())
 	if err = rootCmd.Execute(); err != nil {
 		log.Fatalf("execute core service failed, %s", err.Error())
 	}
@@ -7,7 +7,10 @@ import (
 	"context"
 
 	"omiai-server/cmd/script/command"
+	"omiai-server/internal/conf"
 	"omiai-server/internal/data"
+	"omiai-server/internal/data/omiai"
+	"omiai-server/internal/service"
 
 	"github.com/google/wire"
 )
@@ -17,8 +20,9 @@ func initApp(ctx context.Context) (*InitCmd, func(), error) {
 		ProviderSet,
 		command.ProviderSet,
 		data.ProviderDataSet,
-		//service.ProviderService,
+		conf.GetConfig,
+		service.ProviderService,
 		//server.ProviderServerSet,
-		//omiai.ProviderOmiai,
+		omiai.ProviderOmiai,
 	))
 }
@@ -9,7 +9,13 @@ package main
 import (
 	"context"
 	"omiai-server/cmd/script/command"
+	"omiai-server/internal/conf"
 	"omiai-server/internal/data"
+	"omiai-server/internal/data/omiai"
+	"omiai-server/internal/service/embedding"
+	"omiai-server/internal/service/event"
+	"omiai-server/internal/service/matching"
+	"omiai-server/internal/service/proposal"
 )
 
 // Injectors from wire.go:
@@ -19,7 +25,18 @@ func initApp(ctx context.Context) (*InitCmd, func(), error) {
 	if err != nil {
 		return nil, nil, err
 	}
-	script := command.NewScript(db)
+	proposalInterface := omiai.NewProposalRepo(db)
+	eventBus := event.NewBus()
+	clientInterface := omiai.NewClientRepo(db, eventBus)
+	pairHistoryInterface := omiai.NewPairHistoryRepo(db)
+	config := conf.GetConfig()
+	chinaRegionInterface := omiai.NewChinaRegionRepo(db)
+	scorer := matching.NewScorer(config, chinaRegionInterface)
+	introductionInterface := omiai.NewIntroductionRepo(db, scorer, eventBus)
+	service := proposal.NewService(proposalInterface, clientInterface, pairHistoryInterface, introductionInterface, scorer)
+	embeddingInterface := omiai.NewEmbeddingRepo(db)
+	embeddingService := embedding.NewService(config, embeddingInterface, eventBus)
+	script := command.NewScript(db, config, chinaRegionInterface, service, embeddingService)
 	initCmd := &InitCmd{
 		Command: script,
 	}
@@ -17,8 +17,14 @@ import (
 	"omiai-server/internal/controller/client"
 	"omiai-server/internal/controller/common"
 	"omiai-server/internal/controller/dashboard"
+	duplicate2 "omiai-server/internal/controller/duplicate"
 	"omiai-server/internal/controller/match"
+	meeting2 "omiai-server/internal/controller/meeting"
+	party2 "omiai-server/internal/controller/party"
+	questionnaire2 "omiai-server/internal/controller/questionnaire"
 	"omiai-server/internal/controller/reminder"
+	segment2 "omiai-server/internal/controller/segment"
+	"omiai-server/internal/controller/tag"
 	"omiai-server/internal/controller/template"
 	"omiai-server/internal/cron"
 	"omiai-server/internal/data"
@@ -28,6 +34,20 @@ import (
 	"omiai-server/internal/server"
 	"omiai-server/internal/service/banner"
 	"omiai-server/internal/service/chat_parser"
+	"omiai-server/internal/service/daily_recommendation"
+	"omiai-server/internal/service/duplicate"
+	"omiai-server/internal/service/embedding"
+	"omiai-server/internal/service/event"
+	"omiai-server/internal/service/experiment"
+	"omiai-server/internal/service/matching"
+	"omiai-server/internal/service/meeting"
+	"omiai-server/internal/service/pair_history"
+	"omiai-server/internal/service/party"
+	"omiai-server/internal/service/proposal"
+	"omiai-server/internal/service/quality"
+	"omiai-server/internal/service/questionnaire"
+	"omiai-server/internal/service/search"
+	"omiai-server/internal/service/segment"
 )
 
 // Injectors from wire.go:
@@ -44,8 +64,10 @@ func initApp(ctx context.Context) (*app.App, func(), error) {
 		cleanup()
 		return nil, nil, err
 	}
-	clientInterface := omiai.NewClientRepo(db)
-	controller := ai.NewController(db, clientInterface)
+	eventBus := event.NewBus()
+	clientInterface := omiai.NewClientRepo(db, eventBus)
+	tagInterface := omiai.NewTagRepo(db, eventBus)
+	controller := ai.NewController(db, clientInterface, tagInterface)
 	userInterface := omiai.NewUserRepo(db)
 	authController := auth.NewController(db, userInterface)
 	bannerInterface := omiai.NewBannerRepo(db)
@@ -53,45 +75,96 @@ func initApp(ctx context.Context) (*app.App, func(), error) {
 	bannerController := banner2.NewController(db, bannerInterface, service)
 	china_regionController := china_region.NewController(db)
 	chatParser := chat_parser.NewChatParser()
-	clientController := client.NewController(db, clientInterface, chatParser)
 	config := conf.GetConfig()
+	chinaRegionInterface := omiai.NewChinaRegionRepo(db)
+	scorer := matching.NewScorer(config, chinaRegionInterface)
+	experimentInterface := omiai.NewExperimentRepo(db)
+	experimentService := experiment.NewService(config, chinaRegionInterface, experimentInterface)
+	embeddingInterface := omiai.NewEmbeddingRepo(db)
+	embeddingService := embedding.NewService(config, embeddingInterface, eventBus)
+	duplicateInterface := omiai.NewDuplicateRepo(db, eventBus)
+	duplicateService := duplicate.NewService(duplicateInterface)
+	searchInterface := omiai.NewSearchRepo(db)
+	searchService := search.NewService(searchInterface, eventBus)
+	segmentInterface := omiai.NewSegmentRepo(db)
+	reminderInterface := omiai.NewReminderRepo(db)
+	templateRepo := omiai.NewTemplateRepo(db)
+	segmentService := segment.NewService(segmentInterface, tagInterface, searchService, reminderInterface, templateRepo, eventBus)
+	clientController := client.NewController(db, clientInterface, chatParser, scorer, experimentService, embeddingService, userInterface, duplicateService, tagInterface, segmentService, segmentInterface)
 	driver, err := data.NewStorage(config)
 	if err != nil {
 		cleanup()
 		return nil, nil, err
 	}
 	commonController := common.NewController(driver)
-	templateRepo := omiai.NewTemplateRepo(db)
 	templateController := template.NewController(templateRepo)
-	reminderInterface := omiai.NewReminderRepo(db)
 	reminderController := reminder.NewController(db, reminderInterface)
-	matchInterface := omiai.NewMatchRepo(db)
-	dashboardController := dashboard.NewController(clientInterface, matchInterface, reminderInterface)
-	matchController := match.NewController(db, matchInterface, clientInterface, userInterface)
+	matchInterface := omiai.NewMatchRepo(db, scorer, eventBus)
+	qualityInterface := omiai.NewQualityRepo(db)
+	dashboardController := dashboard.NewController(clientInterface, matchInterface, reminderInterface, experimentService, qualityInterface)
+	introductionInterface := omiai.NewIntroductionRepo(db, scorer, eventBus)
+	pairHistoryInterface := omiai.NewPairHistoryRepo(db)
+	pair_historyService := pair_history.NewService(config, pairHistoryInterface, eventBus)
+	proposalInterface := omiai.NewProposalRepo(db)
+	proposalService := proposal.NewService(proposalInterface, clientInterface, pairHistoryInterface, introductionInterface, scorer)
+	dailyRecommendationInterface := omiai.NewDailyRecommendationRepo(db)
+	daily_recommendationService := daily_recommendation.NewService(dailyRecommendationInterface, clientInterface, matchInterface, pairHistoryInterface, introductionInterface, scorer, experimentService)
+	matchController := match.NewController(db, matchInterface, clientInterface, userInterface, introductionInterface, pair_historyService, proposalInterface, proposalService, experimentService, dailyRecommendationInterface, daily_recommendationService)
+	partyInterface := omiai.NewPartyRepo(db)
+	partyService := party.NewService(partyInterface, clientInterface, introductionInterface, scorer)
+	partyController := party2.NewController(partyInterface, userInterface, partyService)
+	meetingInterface := omiai.NewMeetingRepo(db)
+	meetingService := meeting.NewService(meetingInterface, introductionInterface, matchInterface, reminderInterface)
+	meetingController := meeting2.NewController(meetingInterface, userInterface, meetingService)
+	duplicateController := duplicate2.NewController(duplicateInterface, userInterface, duplicateService)
+	questionnaireInterface := omiai.NewQuestionnaireRepo(db)
+	questionnaireService := questionnaire.NewService(questionnaireInterface, eventBus)
+	questionnaireController := questionnaire2.NewController(config, clientInterface, questionnaireInterface, questionnaireService)
+	segmentController := segment2.NewController(segmentService, segmentInterface, clientInterface, tagInterface, userInterface)
+	tagController := tag.NewController(tagInterface, userInterface)
 	router := &server.Router{
-		Engine:                engine,
-		DB:                    db,
-		Redis:                 redis,
-		AIController:          controller,
-		AuthController:        authController,
-		BannerController:      bannerController,
-		ChinaRegionController: china_regionController,
-		ClientController:      clientController,
-		CommonController:      commonController,
-		TemplateController:    templateController,
-		ReminderController:    reminderController,
-		DashboardController:   dashboardController,
-		MatchController:       matchController,
+		Engine:                  engine,
+		DB:                      db,
+		Redis:                   redis,
+		AIController:            controller,
+		AuthController:          authController,
+		BannerController:        bannerController,
+		ChinaRegionController:   china_regionController,
+		ClientController:        clientController,
+		CommonController:        commonController,
+		TemplateController:      templateController,
+		ReminderController:      reminderController,
+		DashboardController:     dashboardController,
+		DuplicateController:     duplicateController,
+		MatchController:         matchController,
+		MeetingController:       meetingController,
+		PartyController:         partyController,
+		QuestionnaireController: questionnaireController,
+		TagController:           tagController,
+		SegmentController:       segmentController,
 	}
 	v2 := server.NewHTTPServer(router)
 	userProductFinalizer := cron.NewUserProductFinalizer(db)
-	candidatePreFilterService := cron.NewCandidatePreFilterService(db)
+	candidatePreFilterService := cron.NewCandidatePreFilterService(db, matchInterface, scorer, experimentService)
 	reminderService := cron.NewReminderService(db, reminderInterface, clientInterface, matchInterface)
 	reminderCronJob := cron.NewReminderCronJob(reminderService)
+	embeddingRebuildJob := cron.NewEmbeddingRebuildJob(embeddingService)
+	dailyRecommendationJob := cron.NewDailyRecommendationJob(daily_recommendationService)
+	clientPurgeJob := cron.NewClientPurgeJob(config, clientInterface)
+	duplicateScanJob := cron.NewDuplicateScanJob(duplicateService)
+	segmentRefreshJob := cron.NewSegmentRefreshJob(segmentService)
+	qualityService := quality.NewService(qualityInterface, eventBus)
+	qualityReportJob := cron.NewQualityReportJob(qualityService)
 	initCron := &cron.InitCron{
 		UserProductFinalizer:      userProductFinalizer,
 		CandidatePreFilterService: candidatePreFilterService,
 		ReminderCronJob:           reminderCronJob,
+		EmbeddingRebuildJob:       embeddingRebuildJob,
+		DailyRecommendationJob:    dailyRecommendationJob,
+		ClientPurgeJob:            clientPurgeJob,
+		DuplicateScanJob:          duplicateScanJob,
+		SegmentRefreshJob:         segmentRefreshJob,
+		QualityReportJob:          qualityReportJob,
 	}
 	dcron, err := cron.NewCron(initCron)
 	if err != nil {
@@ -0,0 +1,11 @@
+-- =============================================
+-- 候选人缓存失效
+-- candidate_cache_json 改为 {"algorithm","computed_at","candidates"} 结构，算法版本不一致时视为过期
+-- candidate_cache_ids  缓存中的候选人ID(,1,2,)，客户资料变更时据此清空缓存了该客户的其他客户
+-- =============================================
+
+ALTER TABLE `client`
+  ADD COLUMN `candidate_cache_ids` varchar(1024) NOT NULL DEFAULT '' COMMENT '缓存中的候选人ID(,1,2,)，用于缓存失效反查';
+
+-- 旧版缓存（纯数组）无法判断算法版本，直接清空，由定时任务或首次查询重新计算
+UPDATE `client` SET `candidate_cache_json` = '' WHERE `candidate_cache_json` LIKE '[%';
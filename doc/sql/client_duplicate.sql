@@ -0,0 +1,24 @@
+-- =============================================
+-- 疑似重复档案
+-- 按手机号归一化、姓名+出生年月、照片（上传文件以内容摘要命名）、工作单位打分，得分达到 50 进入审核队列
+-- 新建、导入时即时检测，每晚全量查重；合并时逐字段选择取值，匹配记录（含状态历史、回访记录）、提醒、对象关系改指向保留档案，另一份档案移入回收站
+-- =============================================
+
+CREATE TABLE IF NOT EXISTS `client_duplicate` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `client_a_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '客户A',
+  `client_b_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '客户B',
+  `score` bigint NOT NULL DEFAULT 0 COMMENT '重复可能性得分',
+  `signals` text COMMENT '判定依据(JSON)',
+  `status` tinyint NOT NULL DEFAULT 1 COMMENT '状态 1待审核 2已合并 3非同一人',
+  `survivor_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '合并后保留的客户ID',
+  `operator` varchar(64) NOT NULL DEFAULT '' COMMENT '处理人',
+  `resolved_at` datetime(3) DEFAULT NULL COMMENT '处理时间',
+  `created_at` datetime(3) DEFAULT NULL,
+  `updated_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_client_duplicate_pair` (`client_a_id`, `client_b_id`),
+  KEY `idx_client_duplicate_client_b_id` (`client_b_id`),
+  KEY `idx_client_duplicate_score` (`score`),
+  KEY `idx_client_duplicate_status` (`status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='疑似重复档案';
@@ -0,0 +1,33 @@
+-- =============================================
+-- 择偶要求展开列
+-- partner_requirements 为 JSON 文本，无法在 SQL 中做反向过滤（候选人的要求是否接受本人）
+-- 新增 req_* 列由服务端在写入客户时同步维护，本脚本负责加列与存量回填
+-- 非 JSON 的自由文本要求保持为 0/空，不参与过滤
+-- =============================================
+
+ALTER TABLE `client`
+  ADD COLUMN `req_min_age` int NOT NULL DEFAULT 0 COMMENT '择偶要求-最小年龄',
+  ADD COLUMN `req_max_age` int NOT NULL DEFAULT 0 COMMENT '择偶要求-最大年龄',
+  ADD COLUMN `req_min_height` int NOT NULL DEFAULT 0 COMMENT '择偶要求-最低身高',
+  ADD COLUMN `req_max_height` int NOT NULL DEFAULT 0 COMMENT '择偶要求-最高身高',
+  ADD COLUMN `req_min_income` int NOT NULL DEFAULT 0 COMMENT '择偶要求-最低月收入',
+  ADD COLUMN `req_education` tinyint NOT NULL DEFAULT 0 COMMENT '择偶要求-最低学历',
+  ADD COLUMN `req_marital_status` varchar(32) NOT NULL DEFAULT '' COMMENT '择偶要求-可接受婚况(,1,3,)',
+  ADD COLUMN `req_house_status` tinyint NOT NULL DEFAULT 0 COMMENT '择偶要求-房产 >=2需有房';
+
+-- 存量回填（MySQL 5.7+）
+UPDATE `client`
+SET
+  `req_min_age`    = IFNULL(JSON_EXTRACT(`partner_requirements`, '$.min_age'), 0),
+  `req_max_age`    = IFNULL(JSON_EXTRACT(`partner_requirements`, '$.max_age'), 0),
+  `req_min_height` = IFNULL(JSON_EXTRACT(`partner_requirements`, '$.min_height'), 0),
+  `req_max_height` = IFNULL(JSON_EXTRACT(`partner_requirements`, '$.max_height'), 0),
+  `req_min_income` = IFNULL(JSON_EXTRACT(`partner_requirements`, '$.min_income'), 0),
+  `req_education`  = IFNULL(JSON_EXTRACT(`partner_requirements`, '$.education'), 0),
+  `req_house_status` = IFNULL(JSON_EXTRACT(`partner_requirements`, '$.house_status'), 0),
+  `req_marital_status` = IF(
+    JSON_LENGTH(`partner_requirements`, '$.marital_status') > 0,
+    CONCAT(',', REPLACE(REPLACE(REPLACE(JSON_EXTRACT(`partner_requirements`, '$.marital_status'), '[', ''), ']', ''), ' ', ''), ','),
+    ''
+  )
+WHERE JSON_VALID(`partner_requirements`) AND LEFT(TRIM(`partner_requirements`), 1) = '{';
@@ -0,0 +1,24 @@
+-- =============================================
+-- 客户资料质量
+-- 按 QualityRules 声明的字段规则计算资料完整度（0-100）与问题列表，客户新增、修改、恢复历史版本时同步更新
+-- 每天 00:30 定时任务按当前规则全量重算（回填存量数据、年龄随日期变化），并按天汇总写入 client_quality_report
+-- 匹配评分新增 completeness 维度（候选人一方已保存的完整度），评分算法升级为 weighted-v5
+-- =============================================
+
+ALTER TABLE `client`
+  ADD COLUMN `completeness` int NOT NULL DEFAULT 0 COMMENT '资料完整度 0-100',
+  ADD COLUMN `quality_issues` text COMMENT '资料质量问题(JSON)',
+  ADD KEY `idx_client_completeness` (`completeness`);
+
+CREATE TABLE IF NOT EXISTS `client_quality_report` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `report_date` varchar(10) NOT NULL DEFAULT '' COMMENT '统计日期',
+  `total` bigint NOT NULL DEFAULT 0 COMMENT '客户数',
+  `avg_completeness` double NOT NULL DEFAULT 0 COMMENT '平均完整度',
+  `complete` bigint NOT NULL DEFAULT 0 COMMENT '资料完整的客户数',
+  `buckets` text COMMENT '完整度分布(JSON)',
+  `issues` text COMMENT '各字段问题数(JSON)',
+  `created_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_client_quality_report_report_date` (`report_date`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='资料质量日报';
@@ -0,0 +1,21 @@
+-- =============================================
+-- 客户软删除与回收站
+-- 删除客户时客户及其情侣档案、状态历史、回访记录写入相同的 deleted_at，列表与统计均排除已删除记录
+-- 从回收站恢复时按删除时间找回随客户删除的记录；超过 recycle.retention_days 的客户由定时任务彻底删除
+-- =============================================
+
+ALTER TABLE `client`
+  ADD COLUMN `deleted_at` datetime(3) DEFAULT NULL COMMENT '删除时间，非空表示在回收站',
+  ADD KEY `idx_client_deleted_at` (`deleted_at`);
+
+ALTER TABLE `match_record`
+  ADD COLUMN `deleted_at` datetime(3) DEFAULT NULL COMMENT '删除时间，随客户进入回收站',
+  ADD KEY `idx_match_record_deleted_at` (`deleted_at`);
+
+ALTER TABLE `match_status_history`
+  ADD COLUMN `deleted_at` datetime(3) DEFAULT NULL COMMENT '删除时间，随客户进入回收站',
+  ADD KEY `idx_match_status_history_deleted_at` (`deleted_at`);
+
+ALTER TABLE `follow_up_record`
+  ADD COLUMN `deleted_at` datetime(3) DEFAULT NULL COMMENT '删除时间，随客户进入回收站',
+  ADD KEY `idx_follow_up_record_deleted_at` (`deleted_at`);
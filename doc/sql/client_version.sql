@@ -0,0 +1,23 @@
+-- =============================================
+-- 客户资料变更历史
+-- 客户的新建、修改、删除及从历史版本恢复各生成一个版本，记录来源、操作人与逐字段变更
+-- 来源：admin 后台编辑 / invite 邀请页表单 / import 批量导入 / ai_tag AI标签提取 / restore 历史版本恢复
+-- snapshot 为变更后的资料（删除时为删除前的资料），可按字段或整版恢复
+-- =============================================
+
+CREATE TABLE IF NOT EXISTS `client_version` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `client_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '客户ID',
+  `version` bigint NOT NULL DEFAULT 0 COMMENT '版本号，从 1 开始',
+  `action` varchar(16) NOT NULL DEFAULT '' COMMENT '类型 create/update/delete/restore',
+  `source` varchar(16) NOT NULL DEFAULT '' COMMENT '来源 admin/invite/import/ai_tag/restore',
+  `operator` varchar(64) NOT NULL DEFAULT '' COMMENT '操作人',
+  `changes` text COMMENT '字段变更(JSON)',
+  `snapshot` text COMMENT '变更后的资料(JSON)，删除时为删除前的资料',
+  `remark` varchar(255) NOT NULL DEFAULT '' COMMENT '备注',
+  `created_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_client_version` (`client_id`, `version`),
+  KEY `idx_client_version_source` (`source`),
+  KEY `idx_client_version_created_at` (`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='客户资料变更历史';
@@ -0,0 +1,32 @@
+-- =============================================
+-- 每日推荐
+-- 每天 06:00 为单身客户从候选池挑选若干近期未推荐过的候选人，红娘采纳后发起介绍，确认匹配前不写入 match_record
+-- 原 AIMatchRepo 生成的“AI每日推荐”情侣档案为虚假记录，上线后按下方语句清理
+-- =============================================
+
+CREATE TABLE IF NOT EXISTS `daily_recommendation` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `rec_date` varchar(10) NOT NULL DEFAULT '' COMMENT '推荐日期 YYYY-MM-DD',
+  `client_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '被推荐客户ID',
+  `candidate_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '候选人ID',
+  `rank` bigint NOT NULL DEFAULT 0 COMMENT '当日排名',
+  `score` bigint NOT NULL DEFAULT 0 COMMENT '匹配得分',
+  `tags` varchar(512) NOT NULL DEFAULT '' COMMENT '匹配标签(JSON)',
+  `algorithm` varchar(32) NOT NULL DEFAULT '' COMMENT '评分算法标识',
+  `status` tinyint NOT NULL DEFAULT 1 COMMENT '状态 1待处理 2已采纳 3已跳过 4已过期',
+  `introduction_id` bigint unsigned DEFAULT NULL COMMENT '采纳后发起的介绍ID',
+  `handled_by` varchar(64) NOT NULL DEFAULT '' COMMENT '处理人',
+  `handle_remark` varchar(255) NOT NULL DEFAULT '' COMMENT '处理备注',
+  `handled_at` datetime(3) DEFAULT NULL COMMENT '处理时间',
+  `created_at` datetime(3) DEFAULT NULL,
+  `updated_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_daily_pair` (`rec_date`, `client_id`, `candidate_id`),
+  KEY `idx_daily_recommendation_rec_date` (`rec_date`),
+  KEY `idx_daily_recommendation_candidate_id` (`candidate_id`),
+  KEY `idx_daily_recommendation_status` (`status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='每日推荐';
+
+-- 清理旧的虚假情侣档案（执行前请先确认数量）
+-- SELECT COUNT(*) FROM `match_record` WHERE `remark` = 'AI每日推荐';
+-- DELETE FROM `match_record` WHERE `remark` = 'AI每日推荐';
@@ -0,0 +1,20 @@
+-- =============================================
+-- 客户资料文本向量
+-- 红娘备注、家庭情况、自由文本择偶要求与标签经向量化后存储，用于评分语义维度（semantic）与相似客户查询
+-- 默认使用离线 TF-IDF（字 + 相邻双字，特征哈希），可通过 embedding.provider=remote 切换为 OpenAI 兼容接口
+-- 评分算法升级为 weighted-v3，已有推荐记录在下次查询时自动重新生成
+-- 上线后执行 `script embedding` 生成全部向量，之后资料变更时自动刷新，每日 01:30 全量重建
+-- =============================================
+
+CREATE TABLE IF NOT EXISTS `client_embedding` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `client_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '客户ID',
+  `model` varchar(64) NOT NULL DEFAULT '' COMMENT '向量模型标识',
+  `vector` mediumtext COMMENT '向量(JSON)',
+  `text_hash` varchar(64) NOT NULL DEFAULT '' COMMENT '参与向量化的文本摘要，未变化时跳过刷新',
+  `created_at` datetime(3) DEFAULT NULL,
+  `updated_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_client_embedding_client_id` (`client_id`),
+  KEY `idx_client_embedding_model` (`model`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='客户资料文本向量';
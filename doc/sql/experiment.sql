@@ -0,0 +1,30 @@
+-- =============================================
+-- 推荐算法 A/B 实验曝光记录
+-- 实验配置见 match.experiments，分组评分器标识为 <实验key>/<分组名>，写入 recommendation.algorithm
+-- 同一实验下同一配对只保留首次曝光，之后的介绍、确认匹配、进入交往均归因到首次曝光的分组
+-- =============================================
+
+CREATE TABLE IF NOT EXISTS `experiment_exposure` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `experiment_key` varchar(32) NOT NULL DEFAULT '' COMMENT '实验标识',
+  `variant` varchar(32) NOT NULL DEFAULT '' COMMENT '实验分组',
+  `unit` varchar(16) NOT NULL DEFAULT '' COMMENT '分流单位 client/matchmaker',
+  `unit_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '分流单位ID',
+  `client_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '被推荐客户ID',
+  `candidate_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '候选人ID',
+  `matchmaker_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '查看推荐的红娘ID',
+  `algorithm` varchar(32) NOT NULL DEFAULT '' COMMENT '评分算法标识',
+  `score` int NOT NULL DEFAULT 0 COMMENT '曝光时得分',
+  `rank` int NOT NULL DEFAULT 0 COMMENT '曝光时排名',
+  `source` varchar(32) NOT NULL DEFAULT '' COMMENT '曝光来源 candidates/smart_match',
+  `created_at` datetime(3) DEFAULT NULL COMMENT '首次曝光时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_exposure_pair` (`experiment_key`, `client_id`, `candidate_id`),
+  KEY `idx_experiment_exposure_variant` (`variant`),
+  KEY `idx_experiment_exposure_candidate_id` (`candidate_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='A/B实验曝光记录';
+
+-- 各实验分组的推荐按算法分开保存，按红娘分流时同一客户可同时存在多个分组的推荐
+ALTER TABLE `recommendation`
+  DROP INDEX `idx_recommendation_pair`,
+  ADD UNIQUE KEY `idx_recommendation_pair` (`client_id`, `candidate_id`, `algorithm`);
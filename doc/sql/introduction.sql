@@ -0,0 +1,40 @@
+-- =============================================
+-- 介绍流程（候选人 -> 情侣档案之间的撮合过程）
+-- introduction           介绍记录，状态 1已提议 2已推荐给A 3A同意 4已推荐给B 5B同意 6已约见面 7已见面 8已转为情侣 9已关闭
+-- introduction_history   介绍状态变更记录
+-- 发起介绍时双方客户状态由 单身 改为 匹配中，关闭时恢复为 单身，转化时生成 match_record
+-- =============================================
+
+CREATE TABLE IF NOT EXISTS `introduction` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `client_a_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '先征询方客户ID',
+  `client_b_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '后征询方客户ID',
+  `matchmaker_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '负责红娘ID',
+  `status` tinyint NOT NULL DEFAULT 1 COMMENT '状态 1已提议 2已推荐给A 3A同意 4已推荐给B 5B同意 6已约见面 7已见面 8已转为情侣 9已关闭',
+  `match_score` int NOT NULL DEFAULT 0 COMMENT '发起时匹配得分',
+  `meeting_at` datetime(3) DEFAULT NULL COMMENT '首次见面时间',
+  `meeting_place` varchar(255) NOT NULL DEFAULT '' COMMENT '见面地点',
+  `close_reason` varchar(32) NOT NULL DEFAULT '' COMMENT '关闭原因',
+  `match_record_id` bigint unsigned DEFAULT NULL COMMENT '转化后的情侣档案ID',
+  `remark` text COMMENT '备注',
+  `closed_at` datetime(3) DEFAULT NULL COMMENT '结束时间',
+  `created_at` datetime(3) DEFAULT NULL,
+  `updated_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_introduction_client_a_id` (`client_a_id`),
+  KEY `idx_introduction_client_b_id` (`client_b_id`),
+  KEY `idx_introduction_matchmaker_id` (`matchmaker_id`),
+  KEY `idx_introduction_status` (`status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='介绍流程';
+
+CREATE TABLE IF NOT EXISTS `introduction_history` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `introduction_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '介绍ID',
+  `old_status` tinyint NOT NULL DEFAULT 0 COMMENT '旧状态',
+  `new_status` tinyint NOT NULL DEFAULT 0 COMMENT '新状态',
+  `operator` varchar(64) NOT NULL DEFAULT '' COMMENT '操作人',
+  `remark` varchar(255) NOT NULL DEFAULT '' COMMENT '备注',
+  `created_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_introduction_history_introduction_id` (`introduction_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='介绍状态变更记录';
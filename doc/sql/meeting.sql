@@ -0,0 +1,68 @@
+-- =============================================
+-- 见面预约
+-- 见面关联一个介绍（双方同意后）或情侣档案，可选场地；预约后介绍进入已约见面，标记已见面后介绍进入已见面
+-- 冲突检测：场地同一时段已满、场地未营业、客户时间重叠不可预约；红娘时间重叠、不在客户可约时间内须强制预约
+-- 预约后在 reminder_task 生成见面前 24 小时、2 小时的提醒及见面结束 2 小时后的回访提醒（meeting_id 关联）
+-- =============================================
+
+CREATE TABLE IF NOT EXISTS `venue` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `name` varchar(128) NOT NULL DEFAULT '' COMMENT '名称',
+  `address` varchar(255) NOT NULL DEFAULT '' COMMENT '地址',
+  `province_code` varchar(20) NOT NULL DEFAULT '' COMMENT '省份代码',
+  `city_code` varchar(20) NOT NULL DEFAULT '' COMMENT '城市代码',
+  `district_code` varchar(20) NOT NULL DEFAULT '' COMMENT '区县代码',
+  `capacity` bigint NOT NULL DEFAULT 1 COMMENT '同一时段可同时接待的见面数',
+  `open_time` varchar(5) NOT NULL DEFAULT '' COMMENT '营业开始 HH:MM，为空表示全天',
+  `close_time` varchar(5) NOT NULL DEFAULT '' COMMENT '营业结束 HH:MM',
+  `is_enabled` tinyint(1) NOT NULL DEFAULT 1 COMMENT '是否启用',
+  `remark` varchar(255) NOT NULL DEFAULT '' COMMENT '备注',
+  `created_at` datetime(3) DEFAULT NULL,
+  `updated_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_venue_city_code` (`city_code`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='见面场地';
+
+CREATE TABLE IF NOT EXISTS `client_availability` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `client_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '客户ID',
+  `weekday` tinyint NOT NULL DEFAULT 0 COMMENT '星期 0周日 1-6周一至周六',
+  `start_time` varchar(5) NOT NULL DEFAULT '' COMMENT '开始 HH:MM',
+  `end_time` varchar(5) NOT NULL DEFAULT '' COMMENT '结束 HH:MM',
+  PRIMARY KEY (`id`),
+  KEY `idx_client_availability_client_id` (`client_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='客户每周可约时间，未设置时视为随时可约';
+
+CREATE TABLE IF NOT EXISTS `meeting` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `introduction_id` bigint unsigned DEFAULT NULL COMMENT '关联介绍ID',
+  `match_record_id` bigint unsigned DEFAULT NULL COMMENT '关联情侣档案ID',
+  `client_a_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '客户A',
+  `client_b_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '客户B',
+  `matchmaker_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '负责红娘ID',
+  `venue_id` bigint unsigned DEFAULT NULL COMMENT '场地ID，为空表示自行约定地点',
+  `place` varchar(255) NOT NULL DEFAULT '' COMMENT '见面地点',
+  `start_at` datetime(3) DEFAULT NULL COMMENT '开始时间',
+  `end_at` datetime(3) DEFAULT NULL COMMENT '结束时间',
+  `status` tinyint NOT NULL DEFAULT 1 COMMENT '状态 1已预约 2已见面 3已取消 4爽约',
+  `remark` varchar(255) NOT NULL DEFAULT '' COMMENT '备注',
+  `feedback` text COMMENT '见面反馈',
+  `operator` varchar(64) NOT NULL DEFAULT '' COMMENT '操作人',
+  `finished_at` datetime(3) DEFAULT NULL COMMENT '完成或取消时间',
+  `created_at` datetime(3) DEFAULT NULL,
+  `updated_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_meeting_introduction_id` (`introduction_id`),
+  KEY `idx_meeting_match_record_id` (`match_record_id`),
+  KEY `idx_meeting_client_a_id` (`client_a_id`),
+  KEY `idx_meeting_client_b_id` (`client_b_id`),
+  KEY `idx_meeting_matchmaker_id` (`matchmaker_id`),
+  KEY `idx_meeting_venue_id` (`venue_id`),
+  KEY `idx_meeting_start_at` (`start_at`),
+  KEY `idx_meeting_end_at` (`end_at`),
+  KEY `idx_meeting_status` (`status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='见面预约';
+
+ALTER TABLE `reminder_task`
+  ADD COLUMN `meeting_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '关联见面ID，见面前后的系统提醒' AFTER `rule_id`,
+  ADD KEY `idx_reminder_task_meeting_id` (`meeting_id`);
@@ -0,0 +1,31 @@
+-- =============================================
+-- 配对负反馈（拒绝 / 分手 / 屏蔽 / 暂不考虑）
+-- pair_history       任一方向命中的有效记录都会把该配对从候选池中排除
+-- client.cooldown_until  分手后双方的冷静期，期间不出现在任何人的候选池
+-- 冷静期天数由配置 match.breakup_cooldown_days 控制，默认 90 天
+-- =============================================
+
+CREATE TABLE IF NOT EXISTS `pair_history` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `client_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '发起方客户ID',
+  `candidate_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '被拒绝方客户ID',
+  `kind` tinyint NOT NULL DEFAULT 0 COMMENT '类型 1拒绝 2已分手 3屏蔽 4暂不考虑 5相亲会互选',
+  `reason_code` varchar(32) NOT NULL DEFAULT '' COMMENT '原因编码',
+  `remark` varchar(255) NOT NULL DEFAULT '' COMMENT '备注',
+  `expired_at` datetime(3) DEFAULT NULL COMMENT '失效时间，为空表示永久',
+  `source_type` varchar(32) NOT NULL DEFAULT '' COMMENT '来源 manual/introduction/match_record',
+  `source_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '来源记录ID',
+  `operator` varchar(64) NOT NULL DEFAULT '' COMMENT '操作人',
+  `created_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_pair_history_client_id` (`client_id`),
+  KEY `idx_pair_history_candidate_id` (`candidate_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='配对负反馈记录';
+
+ALTER TABLE `client`
+  ADD COLUMN `cooldown_until` datetime(3) DEFAULT NULL COMMENT '分手冷静期截止时间，期间不出现在候选池';
+
+-- 历史分手记录回填（永久排除，不补冷静期）
+INSERT INTO `pair_history` (`client_id`, `candidate_id`, `kind`, `reason_code`, `source_type`, `source_id`, `operator`, `created_at`)
+SELECT `male_client_id`, `female_client_id`, 2, 'breakup', 'match_record', `id`, 'system', NOW(3)
+FROM `match_record` WHERE `status` = 6;
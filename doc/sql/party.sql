@@ -0,0 +1,67 @@
+-- =============================================
+-- 线下相亲会
+-- 报名（按总名额与男女名额，满额进入候补）→ 开始 → 签到 → 生成轮转安排 → 进入互选 → 录入会后选择 → 结束互选
+-- 轮转：人数较少的一方固定桌号，另一方每轮顺移，同一场内任意两人最多相遇一次
+-- 结束互选时双向选择的配对发起介绍或生成配对建议（proposal_batch.strategy = party），
+-- 见过面但未双向选择的配对写入 pair_history（source_type = party）
+-- =============================================
+
+CREATE TABLE IF NOT EXISTS `party` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `title` varchar(128) NOT NULL DEFAULT '' COMMENT '名称',
+  `venue` varchar(255) NOT NULL DEFAULT '' COMMENT '场地',
+  `start_at` datetime(3) DEFAULT NULL COMMENT '开始时间',
+  `end_at` datetime(3) DEFAULT NULL COMMENT '结束时间',
+  `capacity` bigint NOT NULL DEFAULT 0 COMMENT '总名额，0 表示不限',
+  `male_quota` bigint NOT NULL DEFAULT 0 COMMENT '男嘉宾名额，0 表示不限',
+  `female_quota` bigint NOT NULL DEFAULT 0 COMMENT '女嘉宾名额，0 表示不限',
+  `rounds` bigint NOT NULL DEFAULT 0 COMMENT '轮转轮数，0 表示每位嘉宾与全部异性各见一次',
+  `round_minutes` bigint NOT NULL DEFAULT 0 COMMENT '每轮分钟数',
+  `pick_limit` bigint NOT NULL DEFAULT 0 COMMENT '每人最多选择人数，0 表示不限',
+  `mutual_action` varchar(16) NOT NULL DEFAULT 'introduction' COMMENT '双向选择后的处理 introduction/proposal',
+  `status` tinyint NOT NULL DEFAULT 1 COMMENT '状态 1报名中 2进行中 3互选中 4已结束 5已取消',
+  `remark` text COMMENT '备注',
+  `matchmaker_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '负责红娘ID',
+  `created_at` datetime(3) DEFAULT NULL,
+  `updated_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_party_start_at` (`start_at`),
+  KEY `idx_party_status` (`status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='线下相亲会';
+
+CREATE TABLE IF NOT EXISTS `party_attendee` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `party_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '相亲会ID',
+  `client_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '客户ID',
+  `gender` tinyint NOT NULL DEFAULT 0 COMMENT '性别 1男 2女',
+  `number` bigint NOT NULL DEFAULT 0 COMMENT '签到号码牌',
+  `status` tinyint NOT NULL DEFAULT 1 COMMENT '状态 1已报名 2候补 3已签到 4已取消',
+  `checked_in_at` datetime(3) DEFAULT NULL COMMENT '签到时间',
+  `operator` varchar(64) NOT NULL DEFAULT '' COMMENT '操作人',
+  `created_at` datetime(3) DEFAULT NULL,
+  `updated_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_party_client` (`party_id`, `client_id`),
+  KEY `idx_party_attendee_client_id` (`client_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='相亲会报名';
+
+CREATE TABLE IF NOT EXISTS `party_seat` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `party_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '相亲会ID',
+  `round` bigint NOT NULL DEFAULT 0 COMMENT '轮次，从 1 开始',
+  `table_no` bigint NOT NULL DEFAULT 0 COMMENT '桌号，从 1 开始',
+  `male_client_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '男嘉宾客户ID',
+  `female_client_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '女嘉宾客户ID',
+  PRIMARY KEY (`id`),
+  KEY `idx_party_seat_party_id` (`party_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='相亲会轮转安排';
+
+CREATE TABLE IF NOT EXISTS `party_pick` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `party_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '相亲会ID',
+  `client_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '选择方客户ID',
+  `picked_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '被选择方客户ID',
+  `created_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_party_pick` (`party_id`, `client_id`, `picked_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='相亲会会后选择';
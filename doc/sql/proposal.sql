@@ -0,0 +1,46 @@
+-- =============================================
+-- 批量配对建议
+-- proposal_batch  一次全局求解（最大权匹配 / 稳定匹配），记录参数与结果概况
+-- proposal        批次中的配对建议，红娘逐对采纳（发起介绍）或驳回（记为拒绝）
+-- 每位客户同时待审核的建议数不超过批次的 candidate_cap（含此前批次）
+-- =============================================
+
+CREATE TABLE IF NOT EXISTS `proposal_batch` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `strategy` varchar(32) NOT NULL DEFAULT '' COMMENT '配对算法 max_weight/stable',
+  `algorithm` varchar(32) NOT NULL DEFAULT '' COMMENT '评分算法标识',
+  `candidate_cap` int NOT NULL DEFAULT 0 COMMENT '每人待审核建议上限',
+  `min_score` int NOT NULL DEFAULT 0 COMMENT '最低得分',
+  `client_count` int NOT NULL DEFAULT 0 COMMENT '参与计算的单身客户数',
+  `proposal_count` int NOT NULL DEFAULT 0 COMMENT '建议数',
+  `avg_score` double NOT NULL DEFAULT 0 COMMENT '建议平均得分',
+  `status` tinyint NOT NULL DEFAULT 1 COMMENT '状态 1审核中 2已全部审核',
+  `operator` varchar(64) NOT NULL DEFAULT '' COMMENT '发起人',
+  `created_at` datetime(3) DEFAULT NULL,
+  `updated_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='批量配对批次';
+
+CREATE TABLE IF NOT EXISTS `proposal` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `batch_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '批次ID',
+  `male_client_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '男方客户ID',
+  `female_client_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '女方客户ID',
+  `score` int NOT NULL DEFAULT 0 COMMENT '匹配得分',
+  `tags` varchar(512) NOT NULL DEFAULT '' COMMENT '匹配标签(JSON)',
+  `status` tinyint NOT NULL DEFAULT 1 COMMENT '状态 1待审核 2已采纳 3已驳回',
+  `introduction_id` bigint unsigned DEFAULT NULL COMMENT '采纳后发起的介绍ID',
+  `reviewed_by` varchar(64) NOT NULL DEFAULT '' COMMENT '审核人',
+  `review_remark` varchar(255) NOT NULL DEFAULT '' COMMENT '审核备注',
+  `reviewed_at` datetime(3) DEFAULT NULL COMMENT '审核时间',
+  `created_at` datetime(3) DEFAULT NULL,
+  `updated_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_proposal_batch_id` (`batch_id`),
+  KEY `idx_proposal_male_client_id` (`male_client_id`),
+  KEY `idx_proposal_female_client_id` (`female_client_id`),
+  KEY `idx_proposal_status` (`status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='批量配对建议';
+
+ALTER TABLE `pair_history`
+  MODIFY COLUMN `source_type` varchar(32) NOT NULL DEFAULT '' COMMENT '来源 manual/introduction/match_record/proposal';
@@ -0,0 +1,52 @@
+-- =============================================
+-- 性格与价值观测评问卷
+-- questionnaire          问卷题库（按 code 分版本，仅最新版本启用）
+-- questionnaire_sheet    答卷（凭 token 链接填写）
+-- personality_profile    客户测评画像（每个客户一条，取最近一次提交）
+-- 内置题库在首次发放问卷时由服务端自动发布为第 1 版
+-- =============================================
+
+CREATE TABLE IF NOT EXISTS `questionnaire` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `code` varchar(32) NOT NULL DEFAULT '' COMMENT '问卷编码',
+  `title` varchar(64) NOT NULL DEFAULT '' COMMENT '问卷标题',
+  `description` varchar(255) NOT NULL DEFAULT '' COMMENT '问卷说明',
+  `version` int NOT NULL DEFAULT 0 COMMENT '版本号',
+  `status` tinyint NOT NULL DEFAULT 1 COMMENT '状态 1启用 2停用',
+  `questions` text COMMENT '题目列表(JSON)',
+  `created_at` datetime(3) DEFAULT NULL,
+  `updated_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_questionnaire_code` (`code`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='测评问卷题库';
+
+CREATE TABLE IF NOT EXISTS `questionnaire_sheet` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `questionnaire_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '问卷ID',
+  `client_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '客户ID',
+  `token` varchar(64) NOT NULL COMMENT '填写链接凭证',
+  `status` tinyint NOT NULL DEFAULT 1 COMMENT '状态 1待填写 2已提交',
+  `answers` text COMMENT '答案(JSON, 题目key=>1-5)',
+  `issued_by` bigint unsigned NOT NULL DEFAULT 0 COMMENT '发放人',
+  `expired_at` datetime(3) DEFAULT NULL COMMENT '链接过期时间',
+  `submitted_at` datetime(3) DEFAULT NULL COMMENT '提交时间',
+  `created_at` datetime(3) DEFAULT NULL,
+  `updated_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_questionnaire_sheet_token` (`token`),
+  KEY `idx_questionnaire_sheet_questionnaire_id` (`questionnaire_id`),
+  KEY `idx_questionnaire_sheet_client_id` (`client_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='测评答卷';
+
+CREATE TABLE IF NOT EXISTS `personality_profile` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `client_id` bigint unsigned NOT NULL COMMENT '客户ID',
+  `questionnaire_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '问卷ID',
+  `version` int NOT NULL DEFAULT 0 COMMENT '问卷版本',
+  `sheet_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '答卷ID',
+  `scores` text COMMENT '各维度得分(JSON, 0-100)',
+  `created_at` datetime(3) DEFAULT NULL,
+  `updated_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_personality_profile_client_id` (`client_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='客户测评画像';
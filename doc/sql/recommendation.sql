@@ -0,0 +1,28 @@
+-- =============================================
+-- 候选人推荐表，取代 client.candidate_cache_json / candidate_cache_ids
+-- 每个客户保留得分前 200 名，由定时任务或首次查询生成
+-- 客户本人资料/状态变化：删除其推荐，下次查询重新生成
+-- 候选人资料变化：对应记录 status 置为 2，查询时重新评分
+-- =============================================
+
+CREATE TABLE IF NOT EXISTS `recommendation` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `client_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '被推荐客户ID',
+  `candidate_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '候选人ID',
+  `score` int NOT NULL DEFAULT 0 COMMENT '匹配得分',
+  `dimensions` text COMMENT '各维度得分明细(JSON)',
+  `tags` varchar(512) NOT NULL DEFAULT '' COMMENT '匹配标签(JSON)',
+  `algorithm` varchar(32) NOT NULL DEFAULT '' COMMENT '评分算法标识',
+  `generated_at` datetime(3) DEFAULT NULL COMMENT '计算时间',
+  `status` tinyint NOT NULL DEFAULT 1 COMMENT '状态 1有效 2待重算',
+  `created_at` datetime(3) DEFAULT NULL,
+  `updated_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_recommendation_pair` (`client_id`, `candidate_id`),
+  KEY `idx_recommendation_candidate_id` (`candidate_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='候选人推荐';
+
+-- 旧缓存列不再使用
+ALTER TABLE `client`
+  DROP COLUMN `candidate_cache_json`,
+  DROP COLUMN `candidate_cache_ids`;
@@ -0,0 +1,9 @@
+-- =============================================
+-- 地域偏好：是否接受迁居、是否接受异地恋
+-- 跨市/跨省配对时按偏好减免地域维度扣分
+-- 评分算法升级为 weighted-v2，已有推荐记录在下次查询时自动重新生成
+-- =============================================
+
+ALTER TABLE `client`
+  ADD COLUMN `relocation` tinyint NOT NULL DEFAULT 0 COMMENT '是否接受迁居 0未填 1接受 2不接受',
+  ADD COLUMN `long_distance` tinyint NOT NULL DEFAULT 0 COMMENT '是否接受异地恋 0未填 1接受 2不接受';
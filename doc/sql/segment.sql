@@ -0,0 +1,37 @@
+-- =============================================
+-- 客户分群
+-- 分群保存客户列表的筛选条件（JSON），人数与成员按当前数据实时计算；新增筛选项 no_follow_up_days（N 天未跟进）
+-- 成员快照由定时任务每小时刷新，用于比对客户进出分群并发布 segment.changed 事件
+-- 自动提醒规则新增触发类型 SegmentEnter：客户进入 segment_id 指定的分群时生成提醒
+-- =============================================
+
+CREATE TABLE IF NOT EXISTS `client_segment` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `name` varchar(64) NOT NULL DEFAULT '' COMMENT '分群名称',
+  `description` varchar(255) NOT NULL DEFAULT '' COMMENT '说明',
+  `filter` text COMMENT '筛选条件(JSON)',
+  `shared` tinyint(1) NOT NULL DEFAULT 0 COMMENT '是否共享给全部红娘',
+  `owner_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '创建人ID',
+  `owner_name` varchar(64) NOT NULL DEFAULT '' COMMENT '创建人',
+  `member_count` bigint NOT NULL DEFAULT 0 COMMENT '上次刷新时的成员数',
+  `refreshed_at` datetime(3) DEFAULT NULL COMMENT '上次刷新成员的时间',
+  `created_at` datetime(3) DEFAULT NULL,
+  `updated_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_client_segment_name` (`name`),
+  KEY `idx_client_segment_owner_id` (`owner_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='客户分群';
+
+CREATE TABLE IF NOT EXISTS `client_segment_member` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `segment_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '分群ID',
+  `client_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '客户ID',
+  `entered_at` datetime(3) DEFAULT NULL COMMENT '进入分群时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_segment_member` (`segment_id`, `client_id`),
+  KEY `idx_client_segment_member_client_id` (`client_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='客户分群成员快照';
+
+ALTER TABLE `auto_reminder_rule`
+  ADD COLUMN `segment_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '触发分群ID(SegmentEnter)' AFTER `trigger_condition`,
+  ADD KEY `idx_auto_reminder_rule_segment_id` (`segment_id`);
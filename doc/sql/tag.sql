@@ -0,0 +1,51 @@
+-- =============================================
+-- 标签体系
+-- 标签分组（性格、爱好、客户等级、风险）+ 标签字典（含同义词）+ 客户标签关联（来源 manual/ai/import 与置信度）
+-- 客户列表按标签 ID 或名称精确筛选，支持 and/or；性格、爱好分组参与评分标签维度（tag），评分算法升级为 weighted-v4
+-- client.tags 列此前未持久化（模型中忽略），无历史数据需要迁移；接口中的 tags 字段保留为标签名输入/输出
+-- AI 聊天摘要指定 client_id 时，提取的标签按字典（含同义词）写入客户，未收录的返回给红娘确认
+-- =============================================
+
+CREATE TABLE IF NOT EXISTS `tag_group` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `name` varchar(32) NOT NULL DEFAULT '' COMMENT '分组名',
+  `exclusive` tinyint(1) NOT NULL DEFAULT 0 COMMENT '是否单选，如客户等级每位客户只保留一个',
+  `scored` tinyint(1) NOT NULL DEFAULT 0 COMMENT '是否参与匹配评分的标签重合度',
+  `sort` bigint NOT NULL DEFAULT 0 COMMENT '排序',
+  `created_at` datetime(3) DEFAULT NULL,
+  `updated_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_tag_group_name` (`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='标签分组';
+
+CREATE TABLE IF NOT EXISTS `tag` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `group_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '分组ID，0为未分组',
+  `name` varchar(32) NOT NULL DEFAULT '' COMMENT '标签名',
+  `synonyms` text COMMENT '同义词(JSON)',
+  `sort` bigint NOT NULL DEFAULT 0 COMMENT '排序',
+  `created_at` datetime(3) DEFAULT NULL,
+  `updated_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_tag_name` (`name`),
+  KEY `idx_tag_group_id` (`group_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='标签字典';
+
+CREATE TABLE IF NOT EXISTS `client_tag` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `client_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '客户ID',
+  `tag_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '标签ID',
+  `source` varchar(16) NOT NULL DEFAULT '' COMMENT '来源 manual/ai/import',
+  `confidence` double NOT NULL DEFAULT 1 COMMENT '置信度 0-1，手动添加为 1',
+  `operator` varchar(64) NOT NULL DEFAULT '' COMMENT '操作人',
+  `created_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_client_tag` (`client_id`, `tag_id`),
+  KEY `idx_client_tag_tag_id` (`tag_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='客户标签';
+
+INSERT IGNORE INTO `tag_group` (`name`, `exclusive`, `scored`, `sort`, `created_at`, `updated_at`) VALUES
+  ('性格', 0, 1, 1, NOW(3), NOW(3)),
+  ('爱好', 0, 1, 2, NOW(3), NOW(3)),
+  ('客户等级', 1, 0, 3, NOW(3), NOW(3)),
+  ('风险', 0, 0, 4, NOW(3), NOW(3));
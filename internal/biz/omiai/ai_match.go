@@ -1,6 +0,0 @@
-package biz_omiai
-
-type AIMatchRepo interface {
-	GenerateDailyRecommendations() error
-	GetDailyStats() (map[string]interface{}, error)
-}
@@ -0,0 +1,80 @@
+package biz_omiai
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// birthdayRegexp 年份后依次为月、日，分隔符不限；日可省略
+var birthdayRegexp = regexp.MustCompile(`^(\d{4})\D*(\d{1,2})(?:\D*(\d{1,2}))?`)
+
+// Birthday 解析后的出生日期，Day 为 0 表示只填写到月份
+type Birthday struct {
+	Year  int
+	Month int
+	Day   int
+}
+
+// ParseBirthday 识别 1990-05、1990-05-12、1990/5/12、1990年5月12日、199005、19900512 等写法。
+// 月份超出范围或日期不存在时返回 false
+func ParseBirthday(s string) (Birthday, bool) {
+	m := birthdayRegexp.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return Birthday{}, false
+	}
+	b := Birthday{}
+	b.Year, _ = strconv.Atoi(m[1])
+	b.Month, _ = strconv.Atoi(m[2])
+	if m[3] != "" {
+		b.Day, _ = strconv.Atoi(m[3])
+	}
+	if b.Year < 1900 || b.Month < 1 || b.Month > 12 {
+		return Birthday{}, false
+	}
+	if m[3] != "" {
+		t := time.Date(b.Year, time.Month(b.Month), b.Day, 0, 0, 0, 0, time.Local)
+		if b.Day < 1 || t.Day() != b.Day {
+			return Birthday{}, false
+		}
+	}
+	return b, true
+}
+
+// MonthString 格式化为 YYYY-MM
+func (b Birthday) MonthString() string {
+	return fmt.Sprintf("%04d-%02d", b.Year, b.Month)
+}
+
+// Age 周岁；只填写到月份时生日当月即算满岁
+func (b Birthday) Age(now time.Time) int {
+	age := now.Year() - b.Year
+	if int(now.Month()) < b.Month || (b.Day > 0 && int(now.Month()) == b.Month && now.Day() < b.Day) {
+		age--
+	}
+	return age
+}
+
+// Next 今天及以后最近的一次生日，只填写到月份时无法确定返回 false。2月29日在平年按3月1日
+func (b Birthday) Next(now time.Time) (time.Time, bool) {
+	if b.Day == 0 {
+		return time.Time{}, false
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	next := time.Date(now.Year(), time.Month(b.Month), b.Day, 0, 0, 0, 0, now.Location())
+	if next.Before(today) {
+		next = time.Date(now.Year()+1, time.Month(b.Month), b.Day, 0, 0, 0, 0, now.Location())
+	}
+	return next, true
+}
+
+// BirthMonth 将各种写法的出生日期统一为 1990-05，无法识别时返回空
+func BirthMonth(birthday string) string {
+	b, ok := ParseBirthday(birthday)
+	if !ok {
+		return ""
+	}
+	return b.MonthString()
+}
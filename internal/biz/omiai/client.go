@@ -4,6 +4,8 @@ import (
 	"context"
 	"omiai-server/internal/biz"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // Client 客户档案模型
@@ -12,7 +14,7 @@ type Client struct {
 	Name              string `json:"name" gorm:"column:name;size:64;not null;comment:姓名"`
 	Gender            int8   `json:"gender" gorm:"column:gender;comment:性别 1男 2女"`
 	Phone             string `json:"phone" gorm:"column:phone;size:20;index;comment:联系电话"`
-	Birthday          string `json:"birthday" gorm:"column:birthday;size:20;comment:出生年月"` // 格式 YYYY-MM
+	Birthday          string `json:"birthday" gorm:"column:birthday;size:20;comment:出生年月"` // YYYY-MM 或 YYYY-MM-DD，其他写法由 ParseBirthday 兼容
 	Avatar            string `json:"avatar" gorm:"column:avatar;size:255;comment:头像URL"`
 	Age               int    `json:"age" gorm:"column:age;comment:年龄"`
 	Zodiac            string `json:"zodiac" gorm:"column:zodiac;size:10;comment:属相"`
@@ -30,26 +32,51 @@ type Client struct {
 	WorkCityCode      string `json:"work_city_code" gorm:"column:work_city_code;size:20;comment:工作城市代码"`
 	WorkDistrictCode  string `json:"work_district_code" gorm:"column:work_district_code;size:20;comment:工作区县代码"`
 
-	Position            string    `json:"position" gorm:"column:position;size:128;comment:职位"`
-	HouseStatus         int8      `json:"house_status" gorm:"column:house_status;comment:房产情况 1无房 2已购房 3贷款购房"`
-	HouseAddress        string    `json:"house_address" gorm:"column:house_address;size:255;comment:买房地址"`
-	HouseProvinceCode   string    `json:"house_province_code" gorm:"column:house_province_code;size:20;comment:房产省份代码"`
-	HouseCityCode       string    `json:"house_city_code" gorm:"column:house_city_code;size:20;comment:房产城市代码"`
-	HouseDistrictCode   string    `json:"house_district_code" gorm:"column:house_district_code;size:20;comment:房产区县代码"`
-	CarStatus           int8      `json:"car_status" gorm:"column:car_status;comment:车辆情况 1无车 2有车"`
-	Status              int8      `json:"status" gorm:"column:status;default:1;comment:状态 1单身 2匹配中 3已匹配 4停止服务"`
-	PartnerID           *uint64   `json:"partner_id" gorm:"column:partner_id;uniqueIndex;default:null;comment:当前匹配对象ID"`
-	Partner             *Client   `json:"partner" gorm:"foreignKey:PartnerID"`
-	ManagerID           uint64    `json:"manager_id" gorm:"column:manager_id;index;default:0;comment:归属红娘ID;-"`
-	IsPublic            bool      `json:"is_public" gorm:"column:is_public;default:true;index;comment:是否公海;-"`
-	Tags                string    `json:"tags" gorm:"column:tags;type:text;comment:标签列表(JSON);-"`
-	PartnerRequirements string    `json:"partner_requirements" gorm:"column:partner_requirements;type:text;comment:对另一半要求(JSON)"`
-	ParentsProfession   string    `json:"parents_profession" gorm:"column:parents_profession;size:255;comment:父母工作"`
-	Remark              string    `json:"remark" gorm:"column:remark;type:text;comment:红娘备注"`
-	Photos              string    `json:"photos" gorm:"column:photos;type:text;comment:照片URL列表(JSON)"`
-	CandidateCacheJSON  string    `json:"candidate_cache_json" gorm:"column:candidate_cache_json;type:text;comment:算法初筛结果缓存"`
-	CreatedAt           time.Time `json:"created_at" gorm:"column:created_at"`
-	UpdatedAt           time.Time `json:"updated_at" gorm:"column:updated_at"`
+	Position            string         `json:"position" gorm:"column:position;size:128;comment:职位"`
+	HouseStatus         int8           `json:"house_status" gorm:"column:house_status;comment:房产情况 1无房 2已购房 3贷款购房"`
+	HouseAddress        string         `json:"house_address" gorm:"column:house_address;size:255;comment:买房地址"`
+	HouseProvinceCode   string         `json:"house_province_code" gorm:"column:house_province_code;size:20;comment:房产省份代码"`
+	HouseCityCode       string         `json:"house_city_code" gorm:"column:house_city_code;size:20;comment:房产城市代码"`
+	HouseDistrictCode   string         `json:"house_district_code" gorm:"column:house_district_code;size:20;comment:房产区县代码"`
+	CarStatus           int8           `json:"car_status" gorm:"column:car_status;comment:车辆情况 1无车 2有车"`
+	Status              int8           `json:"status" gorm:"column:status;default:1;comment:状态 1单身 2匹配中 3已匹配 4停止服务"`
+	PartnerID           *uint64        `json:"partner_id" gorm:"column:partner_id;uniqueIndex;default:null;comment:当前匹配对象ID"`
+	Partner             *Client        `json:"partner" gorm:"foreignKey:PartnerID"`
+	ManagerID           uint64         `json:"manager_id" gorm:"column:manager_id;index;default:0;comment:归属红娘ID;-"`
+	IsPublic            bool           `json:"is_public" gorm:"column:is_public;default:true;index;comment:是否公海;-"`
+	Tags                string         `json:"tags" gorm:"-"` // 标签输入(JSON数组或逗号分隔)，保存时写入 client_tag
+	PartnerRequirements string         `json:"partner_requirements" gorm:"column:partner_requirements;type:text;comment:对另一半要求(JSON)"`
+	ParentsProfession   string         `json:"parents_profession" gorm:"column:parents_profession;size:255;comment:父母工作"`
+	Remark              string         `json:"remark" gorm:"column:remark;type:text;comment:红娘备注"`
+	Photos              string         `json:"photos" gorm:"column:photos;type:text;comment:照片URL列表(JSON)"`
+	CreatedAt           time.Time      `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt           time.Time      `json:"updated_at" gorm:"column:updated_at"`
+	DeletedAt           gorm.DeletedAt `json:"deleted_at" gorm:"column:deleted_at;index;comment:删除时间，非空表示在回收站"`
+
+	// 择偶要求展开列，由 SyncRequirementColumns 根据 PartnerRequirements 维护，用于 SQL 双向过滤
+	ReqMinAge        int    `json:"-" gorm:"column:req_min_age;not null;default:0;comment:择偶要求-最小年龄"`
+	ReqMaxAge        int    `json:"-" gorm:"column:req_max_age;not null;default:0;comment:择偶要求-最大年龄"`
+	ReqMinHeight     int    `json:"-" gorm:"column:req_min_height;not null;default:0;comment:择偶要求-最低身高"`
+	ReqMaxHeight     int    `json:"-" gorm:"column:req_max_height;not null;default:0;comment:择偶要求-最高身高"`
+	ReqMinIncome     int    `json:"-" gorm:"column:req_min_income;not null;default:0;comment:择偶要求-最低月收入"`
+	ReqEducation     int8   `json:"-" gorm:"column:req_education;not null;default:0;comment:择偶要求-最低学历"`
+	ReqMaritalStatus string `json:"-" gorm:"column:req_marital_status;size:32;not null;default:'';comment:择偶要求-可接受婚况(,1,3,)"`
+	ReqHouseStatus   int8   `json:"-" gorm:"column:req_house_status;not null;default:0;comment:择偶要求-房产 >=2需有房"`
+
+	// 地域偏好，放宽地域维度的异地扣分
+	Relocation   int8 `json:"relocation" gorm:"column:relocation;not null;default:0;comment:是否接受迁居 0未填 1接受 2不接受"`
+	LongDistance int8 `json:"long_distance" gorm:"column:long_distance;not null;default:0;comment:是否接受异地恋 0未填 1接受 2不接受"`
+
+	CooldownUntil *time.Time `json:"cooldown_until" gorm:"column:cooldown_until;comment:分手冷静期截止时间，期间不出现在候选池"`
+
+	// 资料质量，由 SyncQuality 按 QualityRules 维护
+	Completeness     int             `json:"completeness" gorm:"column:completeness;not null;default:0;index;comment:资料完整度 0-100"`
+	QualityIssues    string          `json:"-" gorm:"column:quality_issues;type:text;comment:资料质量问题(JSON)"`
+	QualityIssueList []*QualityIssue `json:"quality_issues,omitempty" gorm:"-"`
+
+	Profile   *PersonalityProfile `json:"profile,omitempty" gorm:"foreignKey:ClientID"`   // 测评画像，需 Preload
+	Embedding *ClientEmbedding    `json:"-" gorm:"foreignKey:ClientID"`                   // 资料文本向量，需 Preload
+	TagLinks  []*ClientTag        `json:"tag_links,omitempty" gorm:"foreignKey:ClientID"` // 标签，需 Preload(ClientTagsPreload)
 }
 
 // TableName 表名
@@ -61,19 +88,11 @@ func (c *Client) RealAge() int {
 	if c.Age > 0 {
 		return c.Age
 	}
-	if len(c.Birthday) < 7 {
+	b, ok := ParseBirthday(c.Birthday)
+	if !ok {
 		return 0
 	}
-	t, err := time.Parse("2006-01", c.Birthday[:7])
-	if err != nil {
-		return 0
-	}
-	now := time.Now()
-	age := now.Year() - t.Year()
-	if now.Month() < t.Month() {
-		age--
-	}
-	return age
+	return b.Age(time.Now())
 }
 
 // ClientInterface 定义数据层接口
@@ -92,4 +111,19 @@ type ClientInterface interface {
 	// 删除相关
 	HasActiveMatch(ctx context.Context, clientID uint64) (bool, error)
 	DeleteWithTx(ctx context.Context, id uint64) error
+
+	// 变更历史：Create、Update、Delete、DeleteWithTx 与 Restore 均按 WithClientChange 标记的来源记录版本
+	Versions(ctx context.Context, clientID uint64, offset, limit int) ([]*ClientVersion, int64, error)
+	GetVersion(ctx context.Context, clientID uint64, version int) (*ClientVersion, error)
+	// Restore 将 values 中的字段写回资料，记为一个恢复版本
+	Restore(ctx context.Context, id uint64, values map[string]interface{}, remark string) error
+
+	// 回收站：Delete 与 DeleteWithTx 相同，均为软删除，情侣档案、状态历史、回访记录随客户一并软删除
+	Trashed(ctx context.Context, clause *biz.WhereClause, offset, limit int) ([]*Client, int64, error)
+	// Undelete 从回收站恢复客户及随其删除的关联记录
+	Undelete(ctx context.Context, id uint64) error
+	// Purge 彻底删除回收站中的客户、关联记录及变更历史
+	Purge(ctx context.Context, id uint64) error
+	// PurgeExpired 彻底删除 before 之前进入回收站的客户，返回删除数量
+	PurgeExpired(ctx context.Context, before time.Time) (int, error)
 }
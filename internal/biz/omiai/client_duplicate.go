@@ -0,0 +1,274 @@
+package biz_omiai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"omiai-server/internal/biz"
+	"path"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// 疑似重复处理状态
+const (
+	DuplicateStatusPending = 1 // 待审核
+	DuplicateStatusMerged  = 2 // 已合并
+	DuplicateStatusIgnored = 3 // 非同一人
+)
+
+// 重复判定依据
+const (
+	DuplicateSignalPhone     = "phone"      // 手机号归一化后相同
+	DuplicateSignalNameBirth = "name_birth" // 姓名相同或仅差一字且出生年月相同
+	DuplicateSignalPhoto     = "photo"      // 使用了同一张照片
+	DuplicateSignalWorkUnit  = "work_unit"  // 工作单位相同
+)
+
+// DuplicateThreshold 得分达到该值的两份档案进入审核队列
+const DuplicateThreshold = 50
+
+var (
+	ErrDuplicateStatus   = errors.New("该记录已处理")
+	ErrDuplicateSurvivor = errors.New("保留的档案须为疑似重复的两份档案之一")
+	ErrDuplicateField    = errors.New("该字段不支持合并")
+	ErrDuplicateMatched  = errors.New("两份档案均有匹配对象，请先解除其中一方的匹配关系")
+	ErrDuplicatePaired   = errors.New("两份档案之间存在匹配记录，无法合并")
+)
+
+// DuplicateSignal 单项判定依据及得分
+type DuplicateSignal struct {
+	Kind   string `json:"kind"`
+	Score  int    `json:"score"`
+	Detail string `json:"detail"`
+}
+
+var (
+	nonDigitRegexp = regexp.MustCompile(`\D`)
+	blankRegexp    = regexp.MustCompile(`[\s·.•]+`)
+)
+
+// NormalizePhone 只保留数字并去掉 +86/0086 前缀
+func NormalizePhone(phone string) string {
+	p := nonDigitRegexp.ReplaceAllString(phone, "")
+	for _, prefix := range []string{"0086", "86"} {
+		if len(p) == len(prefix)+11 && strings.HasPrefix(p, prefix) {
+			return p[len(prefix):]
+		}
+	}
+	return p
+}
+
+// NormalizeName 去掉空白与间隔号
+func NormalizeName(name string) string {
+	return strings.ToLower(blankRegexp.ReplaceAllString(name, ""))
+}
+
+// NormalizeWorkUnit 去掉空白与常见的公司后缀
+func NormalizeWorkUnit(unit string) string {
+	u := NormalizeName(unit)
+	for _, suffix := range []string{"股份有限公司", "有限责任公司", "有限公司", "公司"} {
+		if strings.HasSuffix(u, suffix) && len([]rune(u)) > len([]rune(suffix))+1 {
+			return strings.TrimSuffix(u, suffix)
+		}
+	}
+	return u
+}
+
+// PhotoHashes 头像与照片的文件名；上传时以内容摘要命名，同一张照片得到相同的值
+func PhotoHashes(c *Client) []string {
+	urls := []string{c.Avatar}
+	var photos []string
+	if err := json.Unmarshal([]byte(c.Photos), &photos); err != nil {
+		photos = strings.Split(c.Photos, ",")
+	}
+	urls = append(urls, photos...)
+
+	seen := make(map[string]bool)
+	var hashes []string
+	for _, u := range urls {
+		u = strings.TrimSpace(u)
+		if i := strings.IndexAny(u, "?#"); i >= 0 {
+			u = u[:i]
+		}
+		name := path.Base(u)
+		name = strings.TrimSuffix(name, path.Ext(name))
+		if len(name) < 16 || seen[name] {
+			continue
+		}
+		seen[name] = true
+		hashes = append(hashes, name)
+	}
+	return hashes
+}
+
+// similarName 姓名相同或仅差一个字
+func similarName(a, b string) (same, similar bool) {
+	if a == "" || b == "" {
+		return false, false
+	}
+	if a == b {
+		return true, true
+	}
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) < 2 || len(rb) < 2 {
+		return false, false
+	}
+	// 编辑距离不超过 1
+	if len(ra) < len(rb) {
+		ra, rb = rb, ra
+	}
+	if len(ra)-len(rb) > 1 {
+		return false, false
+	}
+	i, j, diff := 0, 0, 0
+	for i < len(ra) && j < len(rb) {
+		if ra[i] == rb[j] {
+			i++
+			j++
+			continue
+		}
+		diff++
+		if diff > 1 {
+			return false, false
+		}
+		if len(ra) == len(rb) {
+			j++
+		}
+		i++
+	}
+	return false, diff+len(ra)-i <= 1
+}
+
+// ScoreDuplicate 两份档案为同一人的可能性，性别不同直接为 0；各项得分累加，最高 100
+func ScoreDuplicate(a, b *Client) (int, []*DuplicateSignal) {
+	if a.Gender != 0 && b.Gender != 0 && a.Gender != b.Gender {
+		return 0, nil
+	}
+	var signals []*DuplicateSignal
+	if p := NormalizePhone(a.Phone); len(p) >= 7 && p == NormalizePhone(b.Phone) {
+		signals = append(signals, &DuplicateSignal{Kind: DuplicateSignalPhone, Score: 60, Detail: p})
+	}
+	if month := BirthMonth(a.Birthday); month != "" && month == BirthMonth(b.Birthday) {
+		if same, similar := similarName(NormalizeName(a.Name), NormalizeName(b.Name)); same {
+			signals = append(signals, &DuplicateSignal{Kind: DuplicateSignalNameBirth, Score: 60, Detail: a.Name + " " + month})
+		} else if similar {
+			signals = append(signals, &DuplicateSignal{Kind: DuplicateSignalNameBirth, Score: 50, Detail: a.Name + "/" + b.Name + " " + month})
+		}
+	}
+	hashes := make(map[string]bool)
+	for _, h := range PhotoHashes(a) {
+		hashes[h] = true
+	}
+	for _, h := range PhotoHashes(b) {
+		if hashes[h] {
+			signals = append(signals, &DuplicateSignal{Kind: DuplicateSignalPhoto, Score: 50, Detail: h})
+			break
+		}
+	}
+	if u := NormalizeWorkUnit(a.WorkUnit); u != "" && u == NormalizeWorkUnit(b.WorkUnit) {
+		signals = append(signals, &DuplicateSignal{Kind: DuplicateSignalWorkUnit, Score: 15, Detail: a.WorkUnit})
+	}
+
+	score := 0
+	for _, s := range signals {
+		score += s.Score
+	}
+	if score > 100 {
+		score = 100
+	}
+	return score, signals
+}
+
+// MergeValues 合并时写入保留档案的字段值：picks 指定字段取自哪份档案，
+// 未指定的字段保留档案为空时取被合并档案的值；只返回与保留档案不同的字段
+func MergeValues(survivor, merged *Client, picks map[string]uint64) (map[string]interface{}, error) {
+	for column, id := range picks {
+		if !IsClientTrackedField(column) {
+			return nil, ErrDuplicateField
+		}
+		if id != survivor.ID && id != merged.ID {
+			return nil, ErrDuplicateSurvivor
+		}
+	}
+	keep, other := ClientSnapshot(survivor), ClientSnapshot(merged)
+	values := make(map[string]interface{})
+	for _, f := range ClientTrackedFields {
+		v := keep[f.Column]
+		if id, ok := picks[f.Column]; ok {
+			if id == merged.ID {
+				v = other[f.Column]
+			}
+		} else if reflect.ValueOf(v).IsZero() {
+			v = other[f.Column]
+		}
+		if v != keep[f.Column] {
+			values[f.Column] = v
+		}
+	}
+	return values, nil
+}
+
+// ClientDuplicate 疑似重复的两份档案，ClientAID 小于 ClientBID
+type ClientDuplicate struct {
+	ID         uint64             `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ClientAID  uint64             `json:"client_a_id" gorm:"column:client_a_id;uniqueIndex:idx_client_duplicate_pair;comment:客户A"`
+	ClientBID  uint64             `json:"client_b_id" gorm:"column:client_b_id;uniqueIndex:idx_client_duplicate_pair;index;comment:客户B"`
+	Score      int                `json:"score" gorm:"column:score;index;comment:重复可能性得分"`
+	Signals    string             `json:"-" gorm:"column:signals;type:text;comment:判定依据(JSON)"`
+	Status     int8               `json:"status" gorm:"column:status;default:1;index;comment:状态 1待审核 2已合并 3非同一人"`
+	SurvivorID uint64             `json:"survivor_id" gorm:"column:survivor_id;default:0;comment:合并后保留的客户ID"`
+	Operator   string             `json:"operator" gorm:"column:operator;size:64;comment:处理人"`
+	ResolvedAt *time.Time         `json:"resolved_at" gorm:"column:resolved_at;comment:处理时间"`
+	CreatedAt  time.Time          `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt  time.Time          `json:"updated_at" gorm:"column:updated_at"`
+	SignalList []*DuplicateSignal `json:"signals" gorm:"-"`
+
+	ClientA *Client `json:"client_a" gorm:"foreignKey:ClientAID"`
+	ClientB *Client `json:"client_b" gorm:"foreignKey:ClientBID"`
+}
+
+func (t *ClientDuplicate) TableName() string {
+	return "client_duplicate"
+}
+
+// NewClientDuplicate 按 ID 大小排列两份档案，便于唯一约束
+func NewClientDuplicate(a, b uint64, score int, signals []*DuplicateSignal) *ClientDuplicate {
+	if a > b {
+		a, b = b, a
+	}
+	raw, _ := json.Marshal(signals)
+	return &ClientDuplicate{ClientAID: a, ClientBID: b, Score: score, Signals: string(raw), SignalList: signals, Status: DuplicateStatusPending}
+}
+
+// Decode 解析判定依据供展示
+func (t *ClientDuplicate) Decode() {
+	t.SignalList = []*DuplicateSignal{}
+	if t.Signals != "" {
+		_ = json.Unmarshal([]byte(t.Signals), &t.SignalList)
+	}
+}
+
+// Other 疑似重复中的另一份档案
+func (t *ClientDuplicate) Other(id uint64) uint64 {
+	if id == t.ClientAID {
+		return t.ClientBID
+	}
+	return t.ClientAID
+}
+
+type DuplicateInterface interface {
+	// Candidates 可能与 c 重复的客户：同性别，且手机号尾号、出生年份、工作单位或照片之一相同
+	Candidates(ctx context.Context, c *Client) ([]*Client, error)
+	// Fingerprints 按 ID 顺序分批读取查重所需的字段
+	Fingerprints(ctx context.Context, afterID uint64, limit int) ([]*Client, error)
+	// Save 写入待审核的疑似重复，已处理的配对不再写入，待审核的更新得分；返回新增数量
+	Save(ctx context.Context, list []*ClientDuplicate) (int, error)
+	Select(ctx context.Context, clause *biz.WhereClause, offset, limit int) ([]*ClientDuplicate, int64, error)
+	Get(ctx context.Context, id uint64) (*ClientDuplicate, error)
+	Ignore(ctx context.Context, id uint64, operator string) error
+	// Merge 将 values 写入保留档案，匹配记录、提醒与对象关系改指向保留档案，另一份档案移入回收站
+	Merge(ctx context.Context, id, survivorID uint64, values map[string]interface{}, operator string) error
+}
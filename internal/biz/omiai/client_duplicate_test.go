@@ -0,0 +1,49 @@
+package biz_omiai
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScoreDuplicate(t *testing.T) {
+	assert.Equal(t, "13800000000", NormalizePhone("+86 138-0000-0000"))
+	assert.Equal(t, "1990-05", BirthMonth("1990年5月"))
+	assert.Equal(t, "1990-05", BirthMonth("1990/05/12"))
+	assert.Equal(t, "1990-05", BirthMonth("199005"))
+	assert.Equal(t, "", BirthMonth("1990-13"))
+
+	a := &Client{ID: 1, Name: "王小明", Gender: 1, Phone: "13800000000", Birthday: "1990-05", WorkUnit: "某某科技有限公司",
+		Photos: `["https://cdn/uploads/20260101/0123456789abcdef0123456789abcdef01234567.jpg"]`}
+
+	// 换号、姓名错一字、生日写法不同
+	score, signals := ScoreDuplicate(a, &Client{ID: 2, Name: "王晓明", Gender: 1, Phone: "13900000000", Birthday: "1990年5月", WorkUnit: "某某科技"})
+	assert.Equal(t, 65, score)
+	assert.Len(t, signals, 2)
+	assert.Equal(t, DuplicateSignalNameBirth, signals[0].Kind)
+
+	// 手机号写法不同、使用同一张照片
+	score, _ = ScoreDuplicate(a, &Client{ID: 3, Gender: 1, Phone: "138 0000 0000",
+		Avatar: "https://cdn/uploads/20260301/0123456789abcdef0123456789abcdef01234567.jpg"})
+	assert.Equal(t, 100, score)
+
+	// 性别不同、仅工作单位相同均不算重复
+	score, _ = ScoreDuplicate(a, &Client{ID: 4, Name: "王小明", Gender: 2, Birthday: "1990-05"})
+	assert.Equal(t, 0, score)
+	score, _ = ScoreDuplicate(a, &Client{ID: 5, Name: "李四", Gender: 1, WorkUnit: "某某科技有限公司"})
+	assert.Less(t, score, DuplicateThreshold)
+}
+
+func TestMergeValues(t *testing.T) {
+	survivor := &Client{ID: 1, Name: "王小明", Phone: "13800000000", Income: 8000}
+	merged := &Client{ID: 2, Name: "王晓明", Phone: "13900000000", Income: 12000, WorkUnit: "某某科技"}
+
+	values, err := MergeValues(survivor, merged, map[string]uint64{"phone": 2})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"phone": "13900000000", "work_unit": "某某科技"}, values)
+
+	_, err = MergeValues(survivor, merged, map[string]uint64{"status": 2})
+	assert.ErrorIs(t, err, ErrDuplicateField)
+	_, err = MergeValues(survivor, merged, map[string]uint64{"phone": 3})
+	assert.ErrorIs(t, err, ErrDuplicateSurvivor)
+}
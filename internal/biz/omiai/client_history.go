@@ -0,0 +1,221 @@
+package biz_omiai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// 客户资料变更来源
+const (
+	ClientSourceAdmin   = "admin"   // 后台编辑
+	ClientSourceInvite  = "invite"  // 邀请页表单
+	ClientSourceImport  = "import"  // 聊天记录导入
+	ClientSourceAITag   = "ai_tag"  // AI 标签提取
+	ClientSourceRestore = "restore" // 从历史版本恢复
+	ClientSourceMerge   = "merge"   // 合并重复档案
+)
+
+var ClientSourceText = map[string]string{
+	ClientSourceAdmin:   "后台编辑",
+	ClientSourceInvite:  "邀请页表单",
+	ClientSourceImport:  "批量导入",
+	ClientSourceAITag:   "AI标签提取",
+	ClientSourceRestore: "历史版本恢复",
+	ClientSourceMerge:   "合并重复档案",
+}
+
+// 变更类型
+const (
+	ClientActionCreate  = "create"
+	ClientActionUpdate  = "update"
+	ClientActionDelete  = "delete"
+	ClientActionRestore = "restore"
+)
+
+var (
+	ErrClientRestoreField   = errors.New("该字段不支持恢复")
+	ErrClientRestoreNoop    = errors.New("与当前资料一致，无需恢复")
+	ErrClientPhoneDuplicate = errors.New("该手机号已被其他客户使用")
+)
+
+// ClientField 记录变更历史的客户字段，列名与 json 名一致；
+// 状态、匹配对象、冷静期等由业务流程维护，不记录也不允许恢复
+type ClientField struct {
+	Column string `json:"column"`
+	Label  string `json:"label"`
+}
+
+var ClientTrackedFields = []ClientField{
+	{"name", "姓名"},
+	{"gender", "性别"},
+	{"phone", "联系电话"},
+	{"birthday", "出生年月"},
+	{"avatar", "头像"},
+	{"age", "年龄"},
+	{"zodiac", "属相"},
+	{"height", "身高"},
+	{"weight", "体重"},
+	{"education", "学历"},
+	{"marital_status", "婚姻状况"},
+	{"address", "家庭住址"},
+	{"family_description", "家庭成员描述"},
+	{"income", "月收入"},
+	{"profession", "具体工作"},
+	{"work_unit", "工作单位"},
+	{"work_city", "工作城市"},
+	{"work_province_code", "工作省份"},
+	{"work_city_code", "工作城市代码"},
+	{"work_district_code", "工作区县"},
+	{"position", "职位"},
+	{"house_status", "房产情况"},
+	{"house_address", "买房地址"},
+	{"house_province_code", "房产省份"},
+	{"house_city_code", "房产城市"},
+	{"house_district_code", "房产区县"},
+	{"car_status", "车辆情况"},
+	{"partner_requirements", "择偶要求"},
+	{"parents_profession", "父母工作"},
+	{"remark", "红娘备注"},
+	{"photos", "照片"},
+	{"relocation", "是否接受迁居"},
+	{"long_distance", "是否接受异地恋"},
+}
+
+// clientFieldIndex json 名到 Client 字段下标
+var clientFieldIndex = func() map[string]int {
+	index := make(map[string]int)
+	t := reflect.TypeOf(Client{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		index[name] = i
+	}
+	return index
+}()
+
+// IsClientTrackedField 字段是否记录变更历史
+func IsClientTrackedField(column string) bool {
+	for _, f := range ClientTrackedFields {
+		if f.Column == column {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientSnapshot 客户资料中记录历史的字段值，c 为 nil 时返回空
+func ClientSnapshot(c *Client) map[string]interface{} {
+	snapshot := make(map[string]interface{}, len(ClientTrackedFields))
+	if c == nil {
+		return snapshot
+	}
+	v := reflect.ValueOf(c).Elem()
+	for _, f := range ClientTrackedFields {
+		snapshot[f.Column] = v.Field(clientFieldIndex[f.Column]).Interface()
+	}
+	return snapshot
+}
+
+// FieldChange 单个字段的变更，Old 为空表示新建，New 为空表示删除
+type FieldChange struct {
+	Field string      `json:"field"`
+	Label string      `json:"label"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// DiffClient 逐字段比较两份资料，old 为 nil 时只列出非零值字段
+func DiffClient(old, new *Client) []*FieldChange {
+	before, after := ClientSnapshot(old), ClientSnapshot(new)
+	var changes []*FieldChange
+	for _, f := range ClientTrackedFields {
+		o, n := before[f.Column], after[f.Column]
+		switch {
+		case old == nil:
+			if n == nil || reflect.ValueOf(n).IsZero() {
+				continue
+			}
+			o = nil
+		case new == nil:
+			if o == nil || reflect.ValueOf(o).IsZero() {
+				continue
+			}
+			n = nil
+		case o == n:
+			continue
+		}
+		changes = append(changes, &FieldChange{Field: f.Column, Label: f.Label, Old: o, New: n})
+	}
+	return changes
+}
+
+// ClientVersion 客户资料版本，每次新建、修改、删除或恢复生成一个版本
+type ClientVersion struct {
+	ID         uint64         `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ClientID   uint64         `json:"client_id" gorm:"column:client_id;uniqueIndex:idx_client_version;comment:客户ID"`
+	Version    int            `json:"version" gorm:"column:version;uniqueIndex:idx_client_version;comment:版本号，从 1 开始"`
+	Action     string         `json:"action" gorm:"column:action;size:16;comment:类型 create/update/delete/restore"`
+	Source     string         `json:"source" gorm:"column:source;size:16;index;comment:来源 admin/invite/import/ai_tag/restore/merge"`
+	Operator   string         `json:"operator" gorm:"column:operator;size:64;comment:操作人"`
+	Changes    string         `json:"-" gorm:"column:changes;type:text;comment:字段变更(JSON)"`
+	Snapshot   string         `json:"-" gorm:"column:snapshot;type:text;comment:变更后的资料(JSON)，删除时为删除前的资料"`
+	Remark     string         `json:"remark" gorm:"column:remark;size:255;comment:备注"`
+	CreatedAt  time.Time      `json:"created_at" gorm:"column:created_at;index"`
+	ChangeList []*FieldChange `json:"changes" gorm:"-"`
+}
+
+func (t *ClientVersion) TableName() string {
+	return "client_version"
+}
+
+// Decode 解析字段变更供展示
+func (t *ClientVersion) Decode() {
+	t.ChangeList = []*FieldChange{}
+	if t.Changes != "" {
+		_ = json.Unmarshal([]byte(t.Changes), &t.ChangeList)
+	}
+}
+
+// Values 该版本的字段值，fields 为空时返回全部记录历史的字段
+func (t *ClientVersion) Values(fields []string) (map[string]interface{}, error) {
+	var c Client
+	if err := json.Unmarshal([]byte(t.Snapshot), &c); err != nil {
+		return nil, err
+	}
+	snapshot := ClientSnapshot(&c)
+	if len(fields) == 0 {
+		return snapshot, nil
+	}
+	values := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		if !IsClientTrackedField(field) {
+			return nil, ErrClientRestoreField
+		}
+		values[field] = snapshot[field]
+	}
+	return values, nil
+}
+
+// clientChangeKey 在 context 中传递变更来源与操作人
+type clientChangeKey struct{}
+
+type clientChange struct {
+	source   string
+	operator string
+}
+
+// WithClientChange 标记后续客户资料写入的来源与操作人，未标记时按后台编辑记录
+func WithClientChange(ctx context.Context, source, operator string) context.Context {
+	return context.WithValue(ctx, clientChangeKey{}, &clientChange{source: source, operator: operator})
+}
+
+// ClientChangeFrom 取出变更来源与操作人
+func ClientChangeFrom(ctx context.Context) (source, operator string) {
+	if c, ok := ctx.Value(clientChangeKey{}).(*clientChange); ok {
+		return c.source, c.operator
+	}
+	return ClientSourceAdmin, ""
+}
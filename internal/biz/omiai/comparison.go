@@ -0,0 +1,405 @@
+package biz_omiai
+
+import (
+	"fmt"
+	"math"
+)
+
+// 对比板块状态
+const (
+	ComparisonKnown   = "known"
+	ComparisonUnknown = "unknown"
+)
+
+// RegionLevel 地域接近程度，数值越大越近
+type RegionLevel int
+
+const (
+	RegionUnknown       RegionLevel = iota // 地区信息缺失
+	RegionCrossProvince                    // 跨省
+	RegionSameProvince                     // 同省
+	RegionSameCity                         // 同城
+	RegionSameDistrict                     // 同区县
+)
+
+var regionLevelText = map[RegionLevel]string{
+	RegionUnknown:       "未知",
+	RegionCrossProvince: "跨省",
+	RegionSameProvince:  "同省",
+	RegionSameCity:      "同城",
+	RegionSameDistrict:  "同区县",
+}
+
+func (l RegionLevel) String() string {
+	return regionLevelText[l]
+}
+
+// 直辖市省级代码，其下区县统一挂在虚拟的 xx0100 市级节点下
+var municipalityProvinces = map[string]bool{"11": true, "12": true, "31": true, "50": true}
+
+// 地域偏好取值
+const (
+	RegionPrefAccept = 1 // 接受
+	RegionPrefReject = 2 // 不接受
+)
+
+// RegionLocation 所在地的省/市/区县代码
+type RegionLocation struct {
+	ProvinceCode string `json:"province_code"`
+	CityCode     string `json:"city_code"`
+	DistrictCode string `json:"district_code"`
+}
+
+// Empty 未填写任何地区代码
+func (l RegionLocation) Empty() bool {
+	return l.ProvinceCode == "" && l.CityCode == "" && l.DistrictCode == ""
+}
+
+// Normalize 按 6 位行政区划规则补全上级，直辖市的市级统一为 xx0100
+func (l RegionLocation) Normalize() RegionLocation {
+	if len(l.DistrictCode) == 6 {
+		if l.CityCode == "" {
+			l.CityCode = l.DistrictCode[:4] + "00"
+		}
+		if l.ProvinceCode == "" {
+			l.ProvinceCode = l.DistrictCode[:2] + "0000"
+		}
+	}
+	if len(l.CityCode) == 6 && l.ProvinceCode == "" {
+		l.ProvinceCode = l.CityCode[:2] + "0000"
+	}
+	if IsMunicipality(l.ProvinceCode) {
+		l.CityCode = l.ProvinceCode[:2] + "0100"
+	}
+	return l
+}
+
+// IsMunicipality 是否为直辖市的省级代码
+func IsMunicipality(provinceCode string) bool {
+	return len(provinceCode) == 6 && municipalityProvinces[provinceCode[:2]]
+}
+
+// WorkRegion 工作所在地（原始代码）
+func (c *Client) WorkRegion() RegionLocation {
+	return RegionLocation{ProvinceCode: c.WorkProvinceCode, CityCode: c.WorkCityCode, DistrictCode: c.WorkDistrictCode}
+}
+
+// HouseRegion 房产所在地（原始代码）
+func (c *Client) HouseRegion() RegionLocation {
+	return RegionLocation{ProvinceCode: c.HouseProvinceCode, CityCode: c.HouseCityCode, DistrictCode: c.HouseDistrictCode}
+}
+
+// RegionCodes 客户所在地的省/市/区县代码，优先工作地，缺失时取房产所在地
+// 代码按 6 位行政区划规则补全上级，直辖市的市级统一为 xx0100
+func (c *Client) RegionCodes() (province, city, district string) {
+	l := c.WorkRegion()
+	if l.Empty() {
+		l = c.HouseRegion()
+	}
+	l = l.Normalize()
+	return l.ProvinceCode, l.CityCode, l.DistrictCode
+}
+
+// Proximity 两个已归一化的所在地的接近程度
+func (l RegionLocation) Proximity(o RegionLocation) RegionLevel {
+	switch {
+	case l.ProvinceCode == "" || o.ProvinceCode == "":
+		return RegionUnknown
+	case l.DistrictCode != "" && l.DistrictCode == o.DistrictCode:
+		return RegionSameDistrict
+	case l.CityCode != "" && l.CityCode == o.CityCode:
+		return RegionSameCity
+	case l.ProvinceCode == o.ProvinceCode:
+		return RegionSameProvince
+	default:
+		return RegionCrossProvince
+	}
+}
+
+// RegionProximity 根据行政区划代码判断两人的地域接近程度
+func RegionProximity(a, b *Client) RegionLevel {
+	ap, ac, ad := a.RegionCodes()
+	bp, bc, bd := b.RegionCodes()
+	return RegionLocation{ap, ac, ad}.Proximity(RegionLocation{bp, bc, bd})
+}
+
+// RegionMatch 地域评分明细，由评分器按行政区划层级解析双方所在地后给出
+type RegionMatch struct {
+	Level     RegionLevel               `json:"level"`
+	Label     string                    `json:"label"`
+	Relaxed   string                    `json:"relaxed,omitempty"` // 因接受迁居/异地恋放宽的说明
+	Locations map[uint64]RegionLocation `json:"-"`                 // 客户ID => 参与比较的所在地
+}
+
+// TagList 客户的标签名；已预加载标签时取关联的标签，否则解析 Tags 输入
+func (c *Client) TagList() []string {
+	if len(c.TagLinks) == 0 {
+		return ParseTags(c.Tags)
+	}
+	tags := make([]string, 0, len(c.TagLinks))
+	for _, link := range c.TagLinks {
+		if link.Tag != nil {
+			tags = append(tags, link.Tag.Name)
+		}
+	}
+	return tags
+}
+
+// IncomeGapRatio 收入差距比例 (高-低)/高
+func IncomeGapRatio(a, b int) float64 {
+	maxIncome := math.Max(float64(a), float64(b))
+	if maxIncome <= 0 {
+		return 0
+	}
+	minIncome := math.Min(float64(a), float64(b))
+	return (maxIncome - minIncome) / maxIncome
+}
+
+// IncomeGapBand 收入差距区间描述
+func IncomeGapBand(ratio float64) string {
+	switch {
+	case ratio <= 0.3:
+		return "差距30%以内"
+	case ratio <= 0.5:
+		return "差距30%-50%"
+	case ratio <= 0.8:
+		return "差距50%-80%"
+	default:
+		return "差距80%以上"
+	}
+}
+
+// NewComparison 根据双方档案与评分结果构建对比详情
+func NewComparison(client, candidate *Client, result *ScoreResult) *Comparison {
+	comp := &Comparison{
+		BasicInfo:    compareBasicInfo(client, candidate),
+		Region:       compareRegion(client, candidate, result),
+		Requirements: compareRequirements(client, candidate),
+		Interests:    compareTags(client, candidate),
+	}
+	comp.PersonalityRadar, comp.Values, comp.RelationshipExpectations = compareProfiles(client, candidate)
+	if result != nil {
+		comp.MatchScore = result.Score
+		comp.Dimensions = result.Dimensions
+	}
+	return comp
+}
+
+func unknownSection(reason string) map[string]interface{} {
+	return map[string]interface{}{"status": ComparisonUnknown, "reason": reason}
+}
+
+// knownOrUnknown 数值为 0 视为缺失
+func knownOrUnknown(v int) interface{} {
+	if v <= 0 {
+		return ComparisonUnknown
+	}
+	return v
+}
+
+func compareBasicInfo(c1, c2 *Client) map[string]map[string]interface{} {
+	age1, age2 := c1.RealAge(), c2.RealAge()
+	age := map[string]interface{}{"client": knownOrUnknown(age1), "candidate": knownOrUnknown(age2), "status": ComparisonUnknown}
+	if age1 > 0 && age2 > 0 {
+		age["status"] = ComparisonKnown
+		age["diff"] = fmt.Sprintf("相差%d岁", absInt(age1-age2))
+	}
+
+	height := map[string]interface{}{"client": knownOrUnknown(c1.Height), "candidate": knownOrUnknown(c2.Height), "status": ComparisonUnknown}
+	if c1.Height > 0 && c2.Height > 0 {
+		height["status"] = ComparisonKnown
+		height["diff"] = fmt.Sprintf("相差%dcm", absInt(c1.Height-c2.Height))
+	}
+
+	education := map[string]interface{}{"client": c1.Education, "candidate": c2.Education, "status": ComparisonUnknown}
+	if c1.Education > 0 && c2.Education > 0 {
+		education["status"] = ComparisonKnown
+		education["match"] = absInt(int(c1.Education)-int(c2.Education)) <= 1
+	}
+
+	income := map[string]interface{}{"client": knownOrUnknown(c1.Income), "candidate": knownOrUnknown(c2.Income), "status": ComparisonUnknown}
+	if c1.Income > 0 && c2.Income > 0 {
+		ratio := IncomeGapRatio(c1.Income, c2.Income)
+		income["status"] = ComparisonKnown
+		income["gap_ratio"] = math.Round(ratio*100) / 100
+		income["band"] = IncomeGapBand(ratio)
+		income["match"] = ratio <= 0.3
+	}
+
+	return map[string]map[string]interface{}{
+		"age":       age,
+		"height":    height,
+		"education": education,
+		"income":    income,
+	}
+}
+
+// compareRegion 优先使用评分结果中按行政区划层级解析的地域明细，缺失时按代码规则推断
+func compareRegion(c1, c2 *Client, result *ScoreResult) map[string]interface{} {
+	var match *RegionMatch
+	if result != nil {
+		for _, d := range result.Dimensions {
+			if m, ok := d.Detail.(*RegionMatch); ok {
+				match = m
+				break
+			}
+		}
+	}
+	if match == nil {
+		p1, ct1, d1 := c1.RegionCodes()
+		p2, ct2, d2 := c2.RegionCodes()
+		level := RegionProximity(c1, c2)
+		match = &RegionMatch{Level: level, Label: level.String(), Locations: map[uint64]RegionLocation{
+			c1.ID: {p1, ct1, d1},
+			c2.ID: {p2, ct2, d2},
+		}}
+	}
+	if match.Level == RegionUnknown {
+		return unknownSection("地区信息缺失")
+	}
+	out := map[string]interface{}{
+		"status":    ComparisonKnown,
+		"level":     int(match.Level),
+		"label":     match.Label,
+		"client":    match.Locations[c1.ID],
+		"candidate": match.Locations[c2.ID],
+		"relocation": map[string]int8{
+			"client":    c1.Relocation,
+			"candidate": c2.Relocation,
+		},
+		"long_distance": map[string]int8{
+			"client":    c1.LongDistance,
+			"candidate": c2.LongDistance,
+		},
+	}
+	if match.Relaxed != "" {
+		out["relaxed"] = match.Relaxed
+	}
+	return out
+}
+
+func compareRequirements(c1, c2 *Client) map[string]interface{} {
+	side := func(owner, target *Client) map[string]interface{} {
+		checked, violations := owner.ParseRequirements().Check(target)
+		if checked == 0 {
+			return unknownSection("未填写可校验的择偶要求")
+		}
+		return map[string]interface{}{
+			"status":     ComparisonKnown,
+			"checked":    checked,
+			"satisfied":  len(violations) == 0,
+			"violations": violations,
+		}
+	}
+	clientSide, candidateSide := side(c1, c2), side(c2, c1)
+	out := map[string]interface{}{
+		"status":    ComparisonUnknown,
+		"client":    clientSide,    // 本人的要求，候选人是否满足
+		"candidate": candidateSide, // 候选人的要求，本人是否满足
+	}
+	if clientSide["status"] == ComparisonKnown || candidateSide["status"] == ComparisonKnown {
+		out["status"] = ComparisonKnown
+		out["mutual"] = clientSide["satisfied"] != false && candidateSide["satisfied"] != false
+	}
+	return out
+}
+
+// compareProfiles 基于测评画像生成性格雷达、价值观与婚恋期望板块
+func compareProfiles(c1, c2 *Client) (radar, values, expectations map[string]interface{}) {
+	s1, s2 := c1.Profile.ScoreMap(), c2.Profile.ScoreMap()
+	if s1 == nil || s2 == nil {
+		reason := "双方尚未完成性格测评"
+		if s1 != nil || s2 != nil {
+			reason = "一方尚未完成性格测评"
+		}
+		return unknownSection(reason), unknownSection(reason), unknownSection(reason)
+	}
+
+	items := func(group string) map[string]map[string]interface{} {
+		out := make(map[string]map[string]interface{})
+		for _, d := range ProfileDimensions {
+			if d.Group != group {
+				continue
+			}
+			v1, ok1 := s1[d.Key]
+			v2, ok2 := s2[d.Key]
+			if !ok1 || !ok2 {
+				continue
+			}
+			out[d.Key] = map[string]interface{}{"label": d.Label, "client": v1, "candidate": v2}
+		}
+		return out
+	}
+	section := func(group string) map[string]interface{} {
+		sim, n := ProfileSimilarity(s1, s2, group)
+		if n == 0 {
+			return unknownSection("测评未覆盖该板块")
+		}
+		return map[string]interface{}{
+			"status":           ComparisonKnown,
+			"match_percentage": math.Round(sim) / 100,
+			"items":            items(group),
+		}
+	}
+
+	radar, values, expectations = section(ProfileGroupPersonality), section(ProfileGroupValues), section(ProfileGroupExpectation)
+	if values["status"] == ComparisonKnown {
+		details := make([]string, 0)
+		for _, d := range ProfileDimensions {
+			if d.Group != ProfileGroupValues {
+				continue
+			}
+			v1, ok1 := s1[d.Key]
+			v2, ok2 := s2[d.Key]
+			if !ok1 || !ok2 {
+				continue
+			}
+			switch diff := math.Abs(v1 - v2); {
+			case diff <= 15:
+				details = append(details, d.Label+"一致")
+			case diff >= 40:
+				details = append(details, d.Label+"差异较大")
+			}
+		}
+		values["details"] = details
+	}
+	return radar, values, expectations
+}
+
+func compareTags(c1, c2 *Client) map[string]interface{} {
+	tags1, tags2 := c1.TagList(), c2.TagList()
+	if len(tags1) == 0 || len(tags2) == 0 {
+		return unknownSection("标签信息缺失")
+	}
+
+	set := make(map[string]bool, len(tags1))
+	for _, t := range tags1 {
+		set[t] = true
+	}
+	union := len(set)
+	common := make([]string, 0)
+	seen := make(map[string]bool, len(tags2))
+	for _, t := range tags2 {
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		if set[t] {
+			common = append(common, t)
+		} else {
+			union++
+		}
+	}
+	return map[string]interface{}{
+		"status":             ComparisonKnown,
+		"overlap_percentage": math.Round(float64(len(common))/float64(union)*100) / 100,
+		"common_list":        common,
+	}
+}
+
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,39 @@
+package biz_omiai
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegionProximity(t *testing.T) {
+	chaoyang := &Client{WorkProvinceCode: "110000", WorkCityCode: "110100", WorkDistrictCode: "110105"}
+	haidian := &Client{WorkDistrictCode: "110108"}
+	// 旧数据直辖市的市级代码直接填省级代码
+	beijing := &Client{WorkProvinceCode: "110000", WorkCityCode: "110000"}
+	shijiazhuang := &Client{HouseProvinceCode: "130000", HouseCityCode: "130100"}
+	baoding := &Client{WorkCityCode: "130600"}
+
+	assert.Equal(t, RegionSameDistrict, RegionProximity(chaoyang, &Client{WorkDistrictCode: "110105"}))
+	assert.Equal(t, RegionSameCity, RegionProximity(chaoyang, haidian))
+	assert.Equal(t, RegionSameCity, RegionProximity(beijing, haidian))
+	assert.Equal(t, RegionSameProvince, RegionProximity(shijiazhuang, baoding))
+	assert.Equal(t, RegionCrossProvince, RegionProximity(chaoyang, baoding))
+	assert.Equal(t, RegionUnknown, RegionProximity(chaoyang, &Client{}))
+}
+
+func TestNewComparison_Unknown(t *testing.T) {
+	c1 := &Client{ID: 1, Gender: 1, Age: 30, Income: 10000, Tags: `["旅行","摄影","健身"]`}
+	c2 := &Client{ID: 2, Gender: 2, Age: 28, Tags: "旅行，读书"}
+
+	comp := NewComparison(c1, c2, nil)
+	assert.Equal(t, ComparisonKnown, comp.BasicInfo["age"]["status"])
+	assert.Equal(t, "相差2岁", comp.BasicInfo["age"]["diff"])
+	assert.Equal(t, ComparisonUnknown, comp.BasicInfo["income"]["status"])
+	assert.Equal(t, ComparisonUnknown, comp.BasicInfo["income"]["candidate"])
+	assert.Equal(t, ComparisonUnknown, comp.Region["status"])
+	assert.Equal(t, ComparisonUnknown, comp.Requirements["status"])
+	assert.Equal(t, ComparisonUnknown, comp.PersonalityRadar["status"])
+	assert.Equal(t, []string{"旅行"}, comp.Interests["common_list"])
+	assert.Equal(t, 0.25, comp.Interests["overlap_percentage"])
+}
@@ -0,0 +1,131 @@
+package biz_omiai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"time"
+
+	"omiai-server/internal/biz"
+)
+
+// DailyDateLayout 每日推荐日期格式
+const DailyDateLayout = "2006-01-02"
+
+// 每日推荐默认参数
+const (
+	DefaultDailyCount     = 3  // 每位客户每天推荐的候选人数
+	DefaultDailyFreshDays = 30 // 同一候选人再次推荐给同一客户的最短间隔
+)
+
+const (
+	DailyStatusPending  = 1 // 待处理
+	DailyStatusAccepted = 2 // 已采纳，已发起介绍
+	DailyStatusSkipped  = 3 // 已跳过
+	DailyStatusExpired  = 4 // 当天未处理，已过期
+)
+
+var DailyStatusText = map[int8]string{
+	DailyStatusPending:  "待处理",
+	DailyStatusAccepted: "已采纳",
+	DailyStatusSkipped:  "已跳过",
+	DailyStatusExpired:  "已过期",
+}
+
+var ErrDailyHandled = errors.New("该推荐已处理或已过期，请刷新后重试")
+
+// DailyRecommendation 每日推荐：每天为单身客户从候选池中挑选若干未推荐过的候选人，由红娘采纳或跳过
+// 采纳时发起介绍，确认匹配前不会生成情侣档案，也不会改变客户状态
+type DailyRecommendation struct {
+	ID             uint64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	Date           string     `json:"date" gorm:"column:rec_date;size:10;uniqueIndex:idx_daily_pair;index;comment:推荐日期 YYYY-MM-DD"`
+	ClientID       uint64     `json:"client_id" gorm:"column:client_id;uniqueIndex:idx_daily_pair;comment:被推荐客户ID"`
+	CandidateID    uint64     `json:"candidate_id" gorm:"column:candidate_id;uniqueIndex:idx_daily_pair;index;comment:候选人ID"`
+	Rank           int        `json:"rank" gorm:"column:rank;comment:当日排名"`
+	Score          int        `json:"score" gorm:"column:score;comment:匹配得分"`
+	Tags           string     `json:"-" gorm:"column:tags;size:512;comment:匹配标签(JSON)"`
+	Algorithm      string     `json:"algorithm" gorm:"column:algorithm;size:32;comment:评分算法标识"`
+	Status         int8       `json:"status" gorm:"column:status;index;default:1;comment:状态 1待处理 2已采纳 3已跳过 4已过期"`
+	IntroductionID *uint64    `json:"introduction_id" gorm:"column:introduction_id;comment:采纳后发起的介绍ID"`
+	HandledBy      string     `json:"handled_by" gorm:"column:handled_by;size:64;comment:处理人"`
+	HandleRemark   string     `json:"handle_remark" gorm:"column:handle_remark;size:255;comment:处理备注"`
+	HandledAt      *time.Time `json:"handled_at" gorm:"column:handled_at;comment:处理时间"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" gorm:"column:updated_at"`
+
+	Client    *Client `json:"client,omitempty" gorm:"foreignKey:ClientID"`
+	Candidate *Client `json:"candidate,omitempty" gorm:"foreignKey:CandidateID"`
+}
+
+func (t *DailyRecommendation) TableName() string {
+	return "daily_recommendation"
+}
+
+// TagList 解析匹配标签
+func (t *DailyRecommendation) TagList() []string {
+	var tags []string
+	_ = json.Unmarshal([]byte(t.Tags), &tags)
+	return tags
+}
+
+// DailyHandle 处理结果
+type DailyHandle struct {
+	Status         int8
+	IntroductionID *uint64
+	Operator       string
+	Remark         string
+}
+
+// DailyOptions 每日推荐生成参数
+type DailyOptions struct {
+	Date      time.Time
+	Count     int // 每位客户推荐数
+	FreshDays int // 同一候选人再次推荐的最短间隔天数
+}
+
+// DailySummary 一次生成的结果
+type DailySummary struct {
+	Date      string `json:"date"`
+	Clients   int    `json:"clients"`   // 参与的单身客户数
+	Skipped   int    `json:"skipped"`   // 当天已生成过、跳过的客户数
+	Generated int    `json:"generated"` // 新生成的推荐数
+	Expired   int64  `json:"expired"`   // 此前未处理、本次置为过期的推荐数
+}
+
+// DailyStat 某一天的推荐处理情况
+type DailyStat struct {
+	Date     string `json:"date"`
+	Clients  int64  `json:"clients"`
+	Total    int64  `json:"total"`
+	Pending  int64  `json:"pending"`
+	Accepted int64  `json:"accepted"`
+	Skipped  int64  `json:"skipped"`
+	Expired  int64  `json:"expired"`
+}
+
+// AcceptRate 采纳数占已处理数的比例（百分比），过期视为未采纳
+func (t *DailyStat) AcceptRate() float64 {
+	handled := t.Accepted + t.Skipped + t.Expired
+	if handled == 0 {
+		return 0
+	}
+	return math.Round(float64(t.Accepted)*10000/float64(handled)) / 100
+}
+
+type DailyRecommendationInterface interface {
+	// Create 保存推荐，同一天同一配对已存在时忽略
+	Create(ctx context.Context, list []*DailyRecommendation) error
+	Get(ctx context.Context, id uint64) (*DailyRecommendation, error)
+	Select(ctx context.Context, clause *biz.WhereClause, offset, limit int) ([]*DailyRecommendation, int64, error)
+	// Handle 比较并更新：仅待处理的推荐可采纳或跳过
+	Handle(ctx context.Context, id uint64, handle *DailyHandle) error
+	// Expire 将 date 之前未处理的推荐置为过期
+	Expire(ctx context.Context, date string) (int64, error)
+	// GeneratedClients date 当天已生成推荐的客户
+	GeneratedClients(ctx context.Context, date string) (map[uint64]bool, error)
+	// RecentCandidates 各客户自 since 起已推荐过的候选人
+	RecentCandidates(ctx context.Context, since string) (map[uint64]map[uint64]bool, error)
+	// Stats 按日期统计 [from, to] 内的推荐处理情况
+	Stats(ctx context.Context, from, to string) ([]*DailyStat, error)
+}
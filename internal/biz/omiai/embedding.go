@@ -0,0 +1,128 @@
+package biz_omiai
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"strings"
+	"time"
+)
+
+// Vector 稀疏向量，key 为维度下标；稠密向量按下标存储
+type Vector map[uint32]float32
+
+// Norm 向量长度
+func (v Vector) Norm() float64 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	return math.Sqrt(sum)
+}
+
+// Cosine 余弦相似度，任一向量为空时返回 0
+func (v Vector) Cosine(o Vector) float64 {
+	if len(v) == 0 || len(o) == 0 {
+		return 0
+	}
+	a, b := v, o
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	var dot float64
+	for k, x := range a {
+		if y, ok := b[k]; ok {
+			dot += float64(x) * float64(y)
+		}
+	}
+	norm := v.Norm() * o.Norm()
+	if norm == 0 {
+		return 0
+	}
+	return dot / norm
+}
+
+// ClientEmbedding 客户资料文本向量，资料变更后由向量化服务刷新
+type ClientEmbedding struct {
+	ID        uint64    `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ClientID  uint64    `json:"client_id" gorm:"column:client_id;uniqueIndex;comment:客户ID"`
+	Model     string    `json:"model" gorm:"column:model;size:64;index;comment:向量模型标识"`
+	Vector    string    `json:"-" gorm:"column:vector;type:mediumtext;comment:向量(JSON)"`
+	TextHash  string    `json:"text_hash" gorm:"column:text_hash;size:64;comment:参与向量化的文本摘要，未变化时跳过刷新"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"column:updated_at"`
+}
+
+func (t *ClientEmbedding) TableName() string {
+	return "client_embedding"
+}
+
+// Values 解析向量
+func (t *ClientEmbedding) Values() Vector {
+	if t == nil || t.Vector == "" {
+		return nil
+	}
+	v := make(Vector)
+	if err := json.Unmarshal([]byte(t.Vector), &v); err != nil {
+		return nil
+	}
+	return v
+}
+
+// SetValues 序列化向量
+func (t *ClientEmbedding) SetValues(v Vector) {
+	data, _ := json.Marshal(v)
+	t.Vector = string(data)
+}
+
+// EmbeddingText 参与向量化的资料文本：红娘备注、家庭情况、择偶要求与标签
+// 择偶要求为结构化 JSON 时已由择偶要求维度评分，不重复计入
+func (c *Client) EmbeddingText() string {
+	requirements := c.PartnerRequirements
+	if c.ParseRequirements() != nil {
+		requirements = ""
+	}
+	parts := make([]string, 0, 4)
+	for _, s := range []string{c.Remark, c.FamilyDescription, requirements, strings.Join(c.TagList(), " ")} {
+		if s = strings.TrimSpace(s); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// EmbeddingProvider 文本向量化实现
+type EmbeddingProvider interface {
+	// Name 模型标识，不同模型的向量不可比较
+	Name() string
+	Embed(ctx context.Context, texts []string) ([]Vector, error)
+}
+
+// EmbeddingCorpusFitter 需要以全部语料训练的向量化实现（如 TF-IDF），全量重建前调用
+type EmbeddingCorpusFitter interface {
+	Fit(texts []string)
+	Fitted() bool
+}
+
+// SimilarClient 相似客户
+type SimilarClient struct {
+	ClientID   uint64  `json:"client_id"`
+	Name       string  `json:"name"`
+	Gender     int8    `json:"gender"`
+	Age        int     `json:"age"`
+	Avatar     string  `json:"avatar"`
+	Status     int8    `json:"status"`
+	Similarity float64 `json:"similarity"` // 余弦相似度 0-1
+}
+
+// EmbeddingInterface 客户向量数据层接口
+type EmbeddingInterface interface {
+	Get(ctx context.Context, clientID uint64) (*ClientEmbedding, error)
+	// List 指定模型的全部向量
+	List(ctx context.Context, model string) ([]*ClientEmbedding, error)
+	// Upsert 按客户ID写入或覆盖
+	Upsert(ctx context.Context, list []*ClientEmbedding) error
+	Delete(ctx context.Context, clientIDs []uint64) error
+	// Texts 读取客户的向量化文本，ids 为空时读取全部客户
+	Texts(ctx context.Context, ids []uint64) ([]*Client, error)
+}
@@ -0,0 +1,79 @@
+package biz_omiai
+
+import (
+	"context"
+	"time"
+)
+
+// 领域事件主题
+const (
+	EventMatchStatusChanged = "match.status_changed" // 情侣状态变更，Payload 为 *MatchStatusChanged
+	EventIntroductionClosed = "introduction.closed"  // 介绍流程关闭，Payload 为 *IntroductionClosed
+	EventClientChanged      = "client.changed"       // 客户资料、状态变更或删除，Payload 为 *ClientChanged
+	EventFollowUpCreated    = "follow_up.created"    // 新增回访记录，Payload 为 *FollowUpCreated
+	EventSegmentChanged     = "segment.changed"      // 客户进入或离开分群，Payload 为 *SegmentChanged
+)
+
+// Event 领域事件，在数据库事务提交后发布
+type Event struct {
+	Topic      string
+	Payload    interface{}
+	OccurredAt time.Time
+}
+
+type EventHandler func(ctx context.Context, event *Event)
+
+// EventBus 进程内事件总线
+type EventBus interface {
+	// Publish 发布事件，订阅方的错误不会影响发布方
+	Publish(ctx context.Context, topic string, payload interface{})
+	Subscribe(topic string, handler EventHandler)
+}
+
+// MatchStatusChanged 情侣状态变更事件
+type MatchStatusChanged struct {
+	RecordID       uint64 `json:"record_id"`
+	MaleClientID   uint64 `json:"male_client_id"`
+	FemaleClientID uint64 `json:"female_client_id"`
+	OldStatus      int8   `json:"old_status"`
+	NewStatus      int8   `json:"new_status"`
+	Operator       string `json:"operator"`
+	Reason         string `json:"reason"`
+}
+
+// IntroductionClosed 介绍流程关闭事件
+type IntroductionClosed struct {
+	IntroductionID uint64 `json:"introduction_id"`
+	ClientAID      uint64 `json:"client_a_id"`
+	ClientBID      uint64 `json:"client_b_id"`
+	CloseReason    string `json:"close_reason"`
+	Operator       string `json:"operator"`
+	Remark         string `json:"remark"`
+}
+
+// 客户变更类型
+const (
+	ClientChangeProfile = "profile" // 资料、择偶要求或测评画像变更，影响双向评分
+	ClientChangeStatus  = "status"  // 单身/匹配中/已匹配等状态变更
+	ClientChangeDeleted = "deleted" // 客户被删除
+)
+
+// ClientChanged 客户变更事件
+type ClientChanged struct {
+	ClientIDs []uint64 `json:"client_ids"`
+	Change    string   `json:"change"`
+}
+
+// FollowUpCreated 新增回访记录事件，ClientIDs 为情侣双方
+type FollowUpCreated struct {
+	RecordID      uint64   `json:"record_id"`
+	MatchRecordID uint64   `json:"match_record_id"`
+	ClientIDs     []uint64 `json:"client_ids"`
+}
+
+// SegmentChanged 分群成员变更事件，刷新成员快照时发布
+type SegmentChanged struct {
+	SegmentID uint64   `json:"segment_id"`
+	Entered   []uint64 `json:"entered"`
+	Left      []uint64 `json:"left"`
+}
@@ -0,0 +1,68 @@
+package biz_omiai
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// 实验分流单位
+const (
+	ExperimentUnitClient     = "client"     // 按被推荐客户
+	ExperimentUnitMatchmaker = "matchmaker" // 按查看推荐的红娘
+)
+
+// 曝光来源
+const (
+	ExposureSourceCandidates = "candidates"  // 候选人列表
+	ExposureSourceSmartMatch = "smart_match" // 智能匹配
+	ExposureSourceDaily      = "daily"       // 每日推荐
+)
+
+// ExperimentExposure 推荐曝光记录
+// 同一实验下同一配对只保留首次曝光，之后的介绍、匹配等转化归因到首次曝光时的分组
+type ExperimentExposure struct {
+	ID            uint64    `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ExperimentKey string    `json:"experiment_key" gorm:"column:experiment_key;size:32;uniqueIndex:idx_exposure_pair;comment:实验标识"`
+	Variant       string    `json:"variant" gorm:"column:variant;size:32;index;comment:实验分组"`
+	Unit          string    `json:"unit" gorm:"column:unit;size:16;comment:分流单位 client/matchmaker"`
+	UnitID        uint64    `json:"unit_id" gorm:"column:unit_id;comment:分流单位ID"`
+	ClientID      uint64    `json:"client_id" gorm:"column:client_id;uniqueIndex:idx_exposure_pair;comment:被推荐客户ID"`
+	CandidateID   uint64    `json:"candidate_id" gorm:"column:candidate_id;uniqueIndex:idx_exposure_pair;index;comment:候选人ID"`
+	MatchmakerID  uint64    `json:"matchmaker_id" gorm:"column:matchmaker_id;comment:查看推荐的红娘ID"`
+	Algorithm     string    `json:"algorithm" gorm:"column:algorithm;size:32;comment:评分算法标识"`
+	Score         int       `json:"score" gorm:"column:score;comment:曝光时得分"`
+	Rank          int       `json:"rank" gorm:"column:rank;comment:曝光时排名"`
+	Source        string    `json:"source" gorm:"column:source;size:32;comment:曝光来源 candidates/smart_match/daily"`
+	CreatedAt     time.Time `json:"created_at" gorm:"column:created_at;comment:首次曝光时间"`
+}
+
+func (t *ExperimentExposure) TableName() string {
+	return "experiment_exposure"
+}
+
+// ExperimentFunnel 实验分组的转化漏斗，各项均为曝光后发生转化的配对数
+type ExperimentFunnel struct {
+	Variant    string `json:"variant"`
+	Clients    int64  `json:"clients"`    // 曝光涉及的客户数
+	Exposures  int64  `json:"exposures"`  // 曝光配对数
+	Introduced int64  `json:"introduced"` // 发起介绍
+	Accepted   int64  `json:"accepted"`   // 双方同意见面
+	Matched    int64  `json:"matched"`    // 确认匹配（生成情侣档案）
+	Dating     int64  `json:"dating"`     // 进入交往
+}
+
+// Rate 转化数占曝光数的比例（百分比）
+func (t *ExperimentFunnel) Rate(n int64) float64 {
+	if t.Exposures == 0 {
+		return 0
+	}
+	return math.Round(float64(n)*10000/float64(t.Exposures)) / 100
+}
+
+type ExperimentInterface interface {
+	// LogExposures 记录曝光，同一实验下已记录过的配对忽略
+	LogExposures(ctx context.Context, list []*ExperimentExposure) error
+	// Funnel 按分组统计首次曝光之后的转化，配对不区分双方顺序
+	Funnel(ctx context.Context, experimentKey string) ([]*ExperimentFunnel, error)
+}
@@ -0,0 +1,174 @@
+package biz_omiai
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 候选池硬性过滤项，命中任一项的候选人不会出现在推荐列表中
+const (
+	HardFilterSelf        = "self"         // 客户本人
+	HardFilterGender      = "gender"       // 性别相同
+	HardFilterStatus      = "status"       // 候选人不是单身
+	HardFilterCooldown    = "cooldown"     // 候选人处于分手冷静期
+	HardFilterPairHistory = "pair_history" // 存在有效的负反馈记录
+	HardFilterRequirement = "requirement"  // 超出择偶要求（宽松模式容差之外）
+)
+
+// 解释摘要中优势/不足维度的判定线与展示数量
+const (
+	explainStrengthScore = 80
+	explainWeaknessScore = 50
+	explainTopN          = 3
+)
+
+// HardFilter 命中的硬性过滤项
+type HardFilter struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ScoreExplanation 候选人得分解释，红娘据此向客户说明推荐理由
+type ScoreExplanation struct {
+	ClientID    uint64            `json:"client_id"`
+	CandidateID uint64            `json:"candidate_id"`
+	Score       int               `json:"score"`
+	Algorithm   string            `json:"algorithm"`
+	Eligible    bool              `json:"eligible"`     // 是否满足候选池硬性条件
+	HardFilters []*HardFilter     `json:"hard_filters"` // 命中的硬性过滤项
+	Violations  []string          `json:"violations"`   // 择偶要求不满足项（仅扣分，不过滤）
+	Tags        []string          `json:"tags"`
+	Dimensions  []*DimensionScore `json:"dimensions"` // 各维度得分、权重与贡献
+	Summary     string            `json:"summary"`    // 中文说明
+}
+
+// NewScoreExplanation 根据评分结果与硬性过滤结果生成得分解释
+func NewScoreExplanation(clientID, candidateID uint64, result *ScoreResult, filters []*HardFilter) *ScoreExplanation {
+	e := &ScoreExplanation{
+		ClientID:    clientID,
+		CandidateID: candidateID,
+		Score:       result.Score,
+		Algorithm:   result.Algorithm,
+		Eligible:    len(filters) == 0,
+		HardFilters: filters,
+		Violations:  result.Violations,
+		Tags:        result.Tags,
+		Dimensions:  result.Dimensions,
+	}
+	if e.HardFilters == nil {
+		e.HardFilters = []*HardFilter{}
+	}
+	if e.Violations == nil {
+		e.Violations = []string{}
+	}
+	e.Summary = e.summarize()
+	return e
+}
+
+// summarize 如：综合得分73分。优势：年龄(年龄相当)、学历(学历相同)；不足：收入(收入差距较大)
+func (e *ScoreExplanation) summarize() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "综合得分%d分", e.Score)
+	if !e.Eligible {
+		messages := make([]string, 0, len(e.HardFilters))
+		for _, f := range e.HardFilters {
+			messages = append(messages, f.Message)
+		}
+		fmt.Fprintf(&b, "，不在推荐范围内：%s", strings.Join(messages, "；"))
+	}
+	b.WriteString("。")
+
+	var strengths, weaknesses []*DimensionScore
+	for _, d := range e.Dimensions {
+		if d.Weight <= 0 {
+			continue
+		}
+		switch {
+		case d.Score >= explainStrengthScore:
+			strengths = append(strengths, d)
+		case d.Score < explainWeaknessScore:
+			weaknesses = append(weaknesses, d)
+		}
+	}
+	// 优势按贡献排序，不足按损失的分数排序
+	sort.SliceStable(strengths, func(i, j int) bool {
+		return strengths[i].Contribution > strengths[j].Contribution
+	})
+	sort.SliceStable(weaknesses, func(i, j int) bool {
+		return (100-weaknesses[i].Score)*weaknesses[i].Weight > (100-weaknesses[j].Score)*weaknesses[j].Weight
+	})
+
+	var parts []string
+	if s := describeDimensions(strengths); s != "" {
+		parts = append(parts, "优势："+s)
+	}
+	if s := describeDimensions(weaknesses); s != "" {
+		parts = append(parts, "不足："+s)
+	}
+	if len(e.Violations) > 0 {
+		parts = append(parts, "择偶要求不满足："+strings.Join(e.Violations, "、"))
+	}
+	b.WriteString(strings.Join(parts, "；"))
+	return b.String()
+}
+
+func describeDimensions(list []*DimensionScore) string {
+	if len(list) > explainTopN {
+		list = list[:explainTopN]
+	}
+	items := make([]string, 0, len(list))
+	for _, d := range list {
+		items = append(items, fmt.Sprintf("%s(%s)", d.Label, d.Reason))
+	}
+	return strings.Join(items, "、")
+}
+
+// CheckHardFilters 检查候选池中可由双方资料判断的硬性条件，与推荐生成时的 SQL 过滤一致
+// 负反馈记录需查询数据库，由数据层通过 PairHistoryFilters 补充
+func CheckHardFilters(client, candidate *Client, now time.Time) []*HardFilter {
+	var out []*HardFilter
+	if client.ID == candidate.ID {
+		return append(out, &HardFilter{Code: HardFilterSelf, Message: "候选人为客户本人"})
+	}
+	if client.Gender == candidate.Gender {
+		out = append(out, &HardFilter{Code: HardFilterGender, Message: "双方性别相同"})
+	}
+	if candidate.Status != ClientStatusSingle {
+		out = append(out, &HardFilter{Code: HardFilterStatus, Message: "对方当前不是单身状态"})
+	}
+	if candidate.CooldownUntil != nil && candidate.CooldownUntil.After(now) {
+		out = append(out, &HardFilter{
+			Code:    HardFilterCooldown,
+			Message: fmt.Sprintf("对方处于分手冷静期，%s 后恢复推荐", candidate.CooldownUntil.Format("2006-01-02")),
+		})
+	}
+	for _, v := range MutualViolationsMode(client, candidate, RequirementRelaxed) {
+		out = append(out, &HardFilter{Code: HardFilterRequirement, Message: v.Message})
+	}
+	return out
+}
+
+// PairHistoryFilters 将双方之间仍有效的负反馈记录转换为硬性过滤项
+func PairHistoryFilters(clientID uint64, history []*PairHistory, now time.Time) []*HardFilter {
+	var out []*HardFilter
+	for _, h := range history {
+		if !h.Active(now) {
+			continue
+		}
+		who := "本人"
+		if h.ClientID != clientID {
+			who = "对方"
+		}
+		message := fmt.Sprintf("%s已标记%s", who, PairKindText[h.Kind])
+		if reason := h.ReasonText(); reason != "" {
+			message += "：" + reason
+		}
+		if h.ExpiredAt != nil {
+			message += fmt.Sprintf("，%s 后恢复推荐", h.ExpiredAt.Format("2006-01-02"))
+		}
+		out = append(out, &HardFilter{Code: HardFilterPairHistory, Message: message})
+	}
+	return out
+}
@@ -0,0 +1,157 @@
+package biz_omiai
+
+import (
+	"context"
+	"errors"
+	"omiai-server/internal/biz"
+	"time"
+)
+
+// 介绍流程状态
+const (
+	IntroStatusProposed  = 1 // 已提议
+	IntroStatusSentToA   = 2 // 已推荐给A
+	IntroStatusAAccepted = 3 // A同意
+	IntroStatusSentToB   = 4 // 已推荐给B
+	IntroStatusBAccepted = 5 // B同意
+	IntroStatusScheduled = 6 // 已约首次见面
+	IntroStatusMet       = 7 // 已见面
+	IntroStatusConverted = 8 // 已转为情侣档案
+	IntroStatusClosed    = 9 // 已关闭
+)
+
+var IntroStatusText = map[int8]string{
+	IntroStatusProposed:  "已提议",
+	IntroStatusSentToA:   "已推荐给A",
+	IntroStatusAAccepted: "A同意",
+	IntroStatusSentToB:   "已推荐给B",
+	IntroStatusBAccepted: "B同意",
+	IntroStatusScheduled: "已约见面",
+	IntroStatusMet:       "已见面",
+	IntroStatusConverted: "已转为情侣",
+	IntroStatusClosed:    "已关闭",
+}
+
+// 关闭原因
+const (
+	IntroCloseADeclined   = "a_declined"  // A拒绝
+	IntroCloseBDeclined   = "b_declined"  // B拒绝
+	IntroCloseNoShow      = "no_show"     // 爽约
+	IntroCloseNotMatched  = "not_matched" // 见面后不合适
+	IntroCloseUnreachable = "unreachable" // 联系不上
+	IntroCloseCancelled   = "cancelled"   // 红娘取消
+	IntroCloseOther       = "other"       // 其他
+)
+
+var IntroCloseReasonText = map[string]string{
+	IntroCloseADeclined:   "A拒绝",
+	IntroCloseBDeclined:   "B拒绝",
+	IntroCloseNoShow:      "爽约",
+	IntroCloseNotMatched:  "见面后不合适",
+	IntroCloseUnreachable: "联系不上",
+	IntroCloseCancelled:   "红娘取消",
+	IntroCloseOther:       "其他",
+}
+
+// introTransitions 允许的状态流转，任一未结束状态均可关闭；已约见面可改约
+var introTransitions = map[int8][]int8{
+	IntroStatusProposed:  {IntroStatusSentToA, IntroStatusClosed},
+	IntroStatusSentToA:   {IntroStatusAAccepted, IntroStatusClosed},
+	IntroStatusAAccepted: {IntroStatusSentToB, IntroStatusClosed},
+	IntroStatusSentToB:   {IntroStatusBAccepted, IntroStatusClosed},
+	IntroStatusBAccepted: {IntroStatusScheduled, IntroStatusClosed},
+	IntroStatusScheduled: {IntroStatusScheduled, IntroStatusMet, IntroStatusClosed},
+	IntroStatusMet:       {IntroStatusConverted, IntroStatusClosed},
+}
+
+// OpenIntroStatuses 进行中的状态，处于其中的介绍会占用双方（客户状态为匹配中）
+var OpenIntroStatuses = []int8{
+	IntroStatusProposed, IntroStatusSentToA, IntroStatusAAccepted, IntroStatusSentToB,
+	IntroStatusBAccepted, IntroStatusScheduled, IntroStatusMet,
+}
+
+var (
+	ErrIntroInvalidTransition = errors.New("当前状态不允许该操作")
+	ErrIntroStatusChanged     = errors.New("介绍状态已被他人更新，请刷新后重试")
+	ErrIntroClientUnavailable = errors.New("客户不是单身状态，无法发起介绍")
+	ErrIntroCloseReason       = errors.New("请选择关闭原因")
+	ErrIntroMeetingTime       = errors.New("请填写见面时间")
+)
+
+// CanIntroTransition 判断介绍状态能否从 from 流转到 to
+func CanIntroTransition(from, to int8) bool {
+	for _, s := range introTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Introduction 介绍（候选人与情侣档案之间的撮合流程）
+// A 为先征询意见的一方，B 为后征询的一方
+type Introduction struct {
+	ID            uint64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ClientAID     uint64     `json:"client_a_id" gorm:"column:client_a_id;index;comment:先征询方客户ID"`
+	ClientBID     uint64     `json:"client_b_id" gorm:"column:client_b_id;index;comment:后征询方客户ID"`
+	MatchmakerID  uint64     `json:"matchmaker_id" gorm:"column:matchmaker_id;index;comment:负责红娘ID"`
+	Status        int8       `json:"status" gorm:"column:status;index;default:1;comment:状态 1已提议 2已推荐给A 3A同意 4已推荐给B 5B同意 6已约见面 7已见面 8已转为情侣 9已关闭"`
+	MatchScore    int        `json:"match_score" gorm:"column:match_score;comment:发起时匹配得分"`
+	MeetingAt     *time.Time `json:"meeting_at" gorm:"column:meeting_at;comment:首次见面时间"`
+	MeetingPlace  string     `json:"meeting_place" gorm:"column:meeting_place;size:255;comment:见面地点"`
+	CloseReason   string     `json:"close_reason" gorm:"column:close_reason;size:32;comment:关闭原因"`
+	MatchRecordID *uint64    `json:"match_record_id" gorm:"column:match_record_id;comment:转化后的情侣档案ID"`
+	Remark        string     `json:"remark" gorm:"column:remark;type:text;comment:备注"`
+	ClosedAt      *time.Time `json:"closed_at" gorm:"column:closed_at;comment:结束时间"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" gorm:"column:updated_at"`
+
+	ClientA *Client `json:"client_a,omitempty" gorm:"foreignKey:ClientAID"`
+	ClientB *Client `json:"client_b,omitempty" gorm:"foreignKey:ClientBID"`
+}
+
+func (t *Introduction) TableName() string {
+	return "introduction"
+}
+
+// IsOpen 介绍是否仍在进行中
+func (t *Introduction) IsOpen() bool {
+	return t.Status != IntroStatusConverted && t.Status != IntroStatusClosed
+}
+
+// IntroductionHistory 介绍状态变更记录
+type IntroductionHistory struct {
+	ID             uint64    `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	IntroductionID uint64    `json:"introduction_id" gorm:"column:introduction_id;index;comment:介绍ID"`
+	OldStatus      int8      `json:"old_status" gorm:"column:old_status;comment:旧状态"`
+	NewStatus      int8      `json:"new_status" gorm:"column:new_status;comment:新状态"`
+	Operator       string    `json:"operator" gorm:"column:operator;size:64;comment:操作人"`
+	Remark         string    `json:"remark" gorm:"column:remark;size:255;comment:备注"`
+	CreatedAt      time.Time `json:"created_at" gorm:"column:created_at"`
+}
+
+func (t *IntroductionHistory) TableName() string {
+	return "introduction_history"
+}
+
+// IntroductionChange 状态流转时附带的信息
+type IntroductionChange struct {
+	Operator     string
+	Remark       string
+	MeetingAt    *time.Time // 流转到已约见面时必填
+	MeetingPlace string
+	CloseReason  string // 关闭时必填
+	Scorer       Scorer // 转化时计算情侣档案匹配分的评分器，为空时使用默认评分器
+}
+
+type IntroductionInterface interface {
+	// Create 发起介绍：双方须为单身，发起后双方进入匹配中；scorer 为 nil 时使用默认评分器计算匹配分
+	Create(ctx context.Context, intro *Introduction, operator string, scorer Scorer) error
+	Get(ctx context.Context, id uint64) (*Introduction, error)
+	Select(ctx context.Context, clause *biz.WhereClause, offset, limit int) ([]*Introduction, int64, error)
+	// Transition 状态流转（比较并更新）：关闭时释放双方，转化时生成情侣档案
+	Transition(ctx context.Context, id uint64, to int8, change *IntroductionChange) (*Introduction, error)
+	History(ctx context.Context, id uint64) ([]*IntroductionHistory, error)
+	// CountByStatus 按状态统计，matchmakerID 为 0 时统计全部
+	CountByStatus(ctx context.Context, matchmakerID uint64) (map[int8]int64, error)
+}
@@ -0,0 +1,22 @@
+package biz_omiai
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanIntroTransition(t *testing.T) {
+	assert.True(t, CanIntroTransition(IntroStatusProposed, IntroStatusSentToA))
+	assert.True(t, CanIntroTransition(IntroStatusScheduled, IntroStatusScheduled))
+	assert.True(t, CanIntroTransition(IntroStatusMet, IntroStatusConverted))
+	assert.False(t, CanIntroTransition(IntroStatusProposed, IntroStatusSentToB))
+	assert.False(t, CanIntroTransition(IntroStatusSentToA, IntroStatusConverted))
+
+	// 任一进行中状态均可关闭，已结束的介绍不能再流转
+	for _, s := range OpenIntroStatuses {
+		assert.True(t, CanIntroTransition(s, IntroStatusClosed), "status %d", s)
+	}
+	assert.False(t, CanIntroTransition(IntroStatusClosed, IntroStatusProposed))
+	assert.False(t, CanIntroTransition(IntroStatusConverted, IntroStatusClosed))
+}
@@ -2,8 +2,11 @@ package biz_omiai
 
 import (
 	"context"
+	"errors"
 	"omiai-server/internal/biz"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 const (
@@ -15,6 +18,39 @@ const (
 	MatchStatusBroken       = 6 // 分手
 )
 
+var MatchStatusText = map[int8]string{
+	MatchStatusAcquaintance: "相识",
+	MatchStatusDating:       "交往",
+	MatchStatusStable:       "稳定",
+	MatchStatusEngagement:   "订婚",
+	MatchStatusMarried:      "结婚",
+	MatchStatusBroken:       "分手",
+}
+
+// matchTransitions 情侣状态只能逐级推进，分手之外的任一状态均可转为分手
+var matchTransitions = map[int8][]int8{
+	MatchStatusAcquaintance: {MatchStatusDating, MatchStatusBroken},
+	MatchStatusDating:       {MatchStatusStable, MatchStatusBroken},
+	MatchStatusStable:       {MatchStatusEngagement, MatchStatusBroken},
+	MatchStatusEngagement:   {MatchStatusMarried, MatchStatusBroken},
+	MatchStatusMarried:      {MatchStatusBroken},
+}
+
+var (
+	ErrMatchInvalidTransition = errors.New("当前情侣状态不允许变更为目标状态")
+	ErrMatchStatusChanged     = errors.New("情侣状态已被他人更新，请刷新后重试")
+)
+
+// CanMatchTransition 判断情侣状态能否从 from 流转到 to
+func CanMatchTransition(from, to int8) bool {
+	for _, s := range matchTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
 const (
 	ClientStatusSingle   = 1 // 单身
 	ClientStatusMatching = 2 // 匹配中
@@ -24,16 +60,17 @@ const (
 
 // MatchRecord 匹配成功记录 (情侣档案)
 type MatchRecord struct {
-	ID             uint64    `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
-	MaleClientID   uint64    `json:"male_client_id" gorm:"column:male_client_id;index;comment:男方ID"`
-	FemaleClientID uint64    `json:"female_client_id" gorm:"column:female_client_id;index;comment:女方ID"`
-	MatchDate      time.Time `json:"match_date" gorm:"column:match_date;comment:匹配确认时间"`
-	MatchScore     int       `json:"match_score" gorm:"column:match_score;comment:匹配得分"`
-	Status         int8      `json:"status" gorm:"column:status;default:1;comment:状态 1相识 2交往 3稳定 4订婚 5结婚 6分手"`
-	Remark         string    `json:"remark" gorm:"column:remark;type:text;comment:备注"`
-	AdminID        string    `json:"admin_id" gorm:"column:admin_id;size:64;comment:操作管理员ID"`
-	CreatedAt      time.Time `json:"created_at" gorm:"column:created_at"`
-	UpdatedAt      time.Time `json:"updated_at" gorm:"column:updated_at"`
+	ID             uint64         `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	MaleClientID   uint64         `json:"male_client_id" gorm:"column:male_client_id;index;comment:男方ID"`
+	FemaleClientID uint64         `json:"female_client_id" gorm:"column:female_client_id;index;comment:女方ID"`
+	MatchDate      time.Time      `json:"match_date" gorm:"column:match_date;comment:匹配确认时间"`
+	MatchScore     int            `json:"match_score" gorm:"column:match_score;comment:匹配得分"`
+	Status         int8           `json:"status" gorm:"column:status;default:1;comment:状态 1相识 2交往 3稳定 4订婚 5结婚 6分手"`
+	Remark         string         `json:"remark" gorm:"column:remark;type:text;comment:备注"`
+	AdminID        string         `json:"admin_id" gorm:"column:admin_id;size:64;comment:操作管理员ID"`
+	CreatedAt      time.Time      `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt      time.Time      `json:"updated_at" gorm:"column:updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"column:deleted_at;index;comment:删除时间，随客户进入回收站"`
 
 	// 关联对象 (查询时使用)
 	MaleClient   *Client `json:"male_client" gorm:"foreignKey:MaleClientID"`
@@ -46,35 +83,43 @@ func (t *MatchRecord) TableName() string {
 
 // Candidate 匹配候选人
 type Candidate struct {
-	CandidateID uint64   `json:"candidate_id"`
-	Name        string   `json:"name"`
-	Avatar      string   `json:"avatar"`
-	MatchScore  int      `json:"match_score"`
-	Tags        []string `json:"tags"`
-	Age         int      `json:"age"`
-	Height      int      `json:"height"`
-	Education   int      `json:"education"`
+	CandidateID uint64    `json:"candidate_id"`
+	Name        string    `json:"name"`
+	Avatar      string    `json:"avatar"`
+	MatchScore  int       `json:"match_score"`
+	Tags        []string  `json:"tags"`
+	Age         int       `json:"age"`
+	Height      int       `json:"height"`
+	Education   int       `json:"education"`
+	GeneratedAt time.Time `json:"generated_at"` // 推荐计算时间
+
+	Explanation *ScoreExplanation `json:"explanation,omitempty"` // 得分解释，按需返回
 }
 
-// Comparison 匹配对比详情
+// Comparison 匹配对比详情，各板块均由真实数据计算，数据缺失时 status 为 unknown
 type Comparison struct {
+	MatchScore               int                               `json:"match_score"`
+	Dimensions               []*DimensionScore                 `json:"dimensions"`
 	BasicInfo                map[string]map[string]interface{} `json:"basic_info"`
-	PersonalityRadar         map[string]map[string]int         `json:"personality_radar"`
+	Region                   map[string]interface{}            `json:"region"`
+	Requirements             map[string]interface{}            `json:"requirements"`
+	PersonalityRadar         map[string]interface{}            `json:"personality_radar"`
 	Interests                map[string]interface{}            `json:"interests"`
 	Values                   map[string]interface{}            `json:"values"`
-	RelationshipExpectations map[string]map[string]int         `json:"relationship_expectations"`
+	RelationshipExpectations map[string]interface{}            `json:"relationship_expectations"`
 }
 
 // MatchStatusHistory 状态变更记录
 type MatchStatusHistory struct {
-	ID            uint64    `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
-	MatchRecordID uint64    `json:"match_record_id" gorm:"column:match_record_id;index;comment:匹配记录ID"`
-	OldStatus     int8      `json:"old_status" gorm:"column:previous_status;comment:旧状态"`
-	NewStatus     int8      `json:"new_status" gorm:"column:current_status;comment:新状态"`
-	ChangeTime    time.Time `json:"change_time" gorm:"column:change_time;comment:变更时间"`
-	Operator      string    `json:"operator" gorm:"column:operator;size:64;comment:操作人"`
-	Reason        string    `json:"reason" gorm:"column:reason;size:255;comment:变更原因"`
-	CreatedAt     time.Time `json:"created_at" gorm:"column:created_at"`
+	ID            uint64         `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	MatchRecordID uint64         `json:"match_record_id" gorm:"column:match_record_id;index;comment:匹配记录ID"`
+	OldStatus     int8           `json:"old_status" gorm:"column:previous_status;comment:旧状态"`
+	NewStatus     int8           `json:"new_status" gorm:"column:current_status;comment:新状态"`
+	ChangeTime    time.Time      `json:"change_time" gorm:"column:change_time;comment:变更时间"`
+	Operator      string         `json:"operator" gorm:"column:operator;size:64;comment:操作人"`
+	Reason        string         `json:"reason" gorm:"column:reason;size:255;comment:变更原因"`
+	CreatedAt     time.Time      `json:"created_at" gorm:"column:created_at"`
+	DeletedAt     gorm.DeletedAt `json:"-" gorm:"column:deleted_at;index;comment:删除时间，随客户进入回收站"`
 }
 
 func (t *MatchStatusHistory) TableName() string {
@@ -83,17 +128,18 @@ func (t *MatchStatusHistory) TableName() string {
 
 // FollowUpRecord 情侣回访记录
 type FollowUpRecord struct {
-	ID             uint64    `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
-	MatchRecordID  uint64    `json:"match_record_id" gorm:"column:match_record_id;index;comment:匹配记录ID"`
-	FollowUpDate   time.Time `json:"follow_up_date" gorm:"column:follow_up_date;comment:回访时间"`
-	Method         string    `json:"method" gorm:"column:method;size:32;comment:回访方式(电话/面谈/线上)"`
-	Content        string    `json:"content" gorm:"column:content;type:text;comment:回访内容"`
-	Feedback       string    `json:"feedback" gorm:"column:feedback;type:text;comment:客户反馈"`
-	Satisfaction   int8      `json:"satisfaction" gorm:"column:satisfaction;comment:满意度 1-5"`
-	Attachments    string    `json:"attachments" gorm:"column:attachments;type:text;comment:附件列表(JSON)"`
-	NextFollowUpAt time.Time `json:"next_follow_up_at" gorm:"column:next_follow_up_at;comment:下次回访提醒时间"`
-	CreatedAt      time.Time `json:"created_at" gorm:"column:created_at"`
-	UpdatedAt      time.Time `json:"updated_at" gorm:"column:updated_at"`
+	ID             uint64         `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	MatchRecordID  uint64         `json:"match_record_id" gorm:"column:match_record_id;index;comment:匹配记录ID"`
+	FollowUpDate   time.Time      `json:"follow_up_date" gorm:"column:follow_up_date;comment:回访时间"`
+	Method         string         `json:"method" gorm:"column:method;size:32;comment:回访方式(电话/面谈/线上)"`
+	Content        string         `json:"content" gorm:"column:content;type:text;comment:回访内容"`
+	Feedback       string         `json:"feedback" gorm:"column:feedback;type:text;comment:客户反馈"`
+	Satisfaction   int8           `json:"satisfaction" gorm:"column:satisfaction;comment:满意度 1-5"`
+	Attachments    string         `json:"attachments" gorm:"column:attachments;type:text;comment:附件列表(JSON)"`
+	NextFollowUpAt time.Time      `json:"next_follow_up_at" gorm:"column:next_follow_up_at;comment:下次回访提醒时间"`
+	CreatedAt      time.Time      `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt      time.Time      `json:"updated_at" gorm:"column:updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"column:deleted_at;index;comment:删除时间，随客户进入回收站"`
 }
 
 func (t *FollowUpRecord) TableName() string {
@@ -108,13 +154,24 @@ type MatchInterface interface {
 	Delete(ctx context.Context, id uint64) error
 
 	// V2: 新增候选人与对比接口
-	GetCandidates(ctx context.Context, clientID uint64) ([]*Candidate, error)
-	Compare(ctx context.Context, clientID, candidateID uint64) (*Comparison, error)
+	// GetCandidates 分页查询推荐候选人，尚未生成推荐时实时计算
+	GetCandidates(ctx context.Context, query *CandidateQuery) ([]*Candidate, int64, error)
+	// RefreshRecommendations 用 scorer 重新生成客户的全部推荐，返回推荐数量；scorer 为 nil 时使用默认评分器
+	RefreshRecommendations(ctx context.Context, client *Client, scorer Scorer) (int, error)
+	// PruneRecommendations 删除 keep 以外的评分算法生成的推荐（实验结束或算法升级后遗留），返回删除数量
+	PruneRecommendations(ctx context.Context, keep []string) (int64, error)
+	// RecommendedTo 反查候选人被推荐给了哪些客户
+	RecommendedTo(ctx context.Context, candidateID uint64, offset, limit int) ([]*Recommendation, int64, error)
+	// Compare、Explain、ConfirmMatch 的 scorer 为客户所在 A/B 实验分组的评分器，与候选人列表一致；为 nil 时使用默认评分器
+	Compare(ctx context.Context, clientID, candidateID uint64, scorer Scorer) (*Comparison, error)
+	// Explain 实时计算候选人得分解释，并说明是否命中候选池硬性过滤条件
+	Explain(ctx context.Context, clientID, candidateID uint64, scorer Scorer) (*ScoreExplanation, error)
 
 	// V2: 直接确认匹配 (替换 ConfirmRequest)
-	ConfirmMatch(ctx context.Context, clientID, candidateID uint64, adminID, remark string) (*MatchRecord, error)
+	ConfirmMatch(ctx context.Context, clientID, candidateID uint64, adminID, remark string, scorer Scorer) (*MatchRecord, error)
 
 	// 状态管理
+	// UpdateStatus 按状态流转表变更状态：仍为 oldStatus 时才更新，结婚后双方停止服务，分手后双方恢复单身
 	UpdateStatus(ctx context.Context, recordID uint64, oldStatus, newStatus int8, operator, reason string) error
 	DissolveMatch(ctx context.Context, clientID uint64, operator, reason string) error
 	GetStatusHistory(ctx context.Context, recordID uint64) ([]*MatchStatusHistory, error)
@@ -0,0 +1,255 @@
+package biz_omiai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"omiai-server/internal/biz"
+)
+
+// 见面状态
+const (
+	MeetingStatusBooked    = 1 // 已预约
+	MeetingStatusCompleted = 2 // 已见面
+	MeetingStatusCancelled = 3 // 已取消
+	MeetingStatusNoShow    = 4 // 爽约
+)
+
+var MeetingStatusText = map[int8]string{
+	MeetingStatusBooked:    "已预约",
+	MeetingStatusCompleted: "已见面",
+	MeetingStatusCancelled: "已取消",
+	MeetingStatusNoShow:    "爽约",
+}
+
+// 见面默认参数
+const (
+	DefaultMeetingDuration = 90 * time.Minute // 未填写结束时间时的见面时长
+	MeetingFollowUpDelay   = 2 * time.Hour    // 见面结束后多久提醒红娘回访
+	MeetingSlotStep        = 30 * time.Minute // 推荐时段的间隔
+)
+
+// MeetingRemindBefore 见面前的提醒时间点，已过去的不再生成
+var MeetingRemindBefore = []time.Duration{24 * time.Hour, 2 * time.Hour}
+
+// 冲突类型
+const (
+	ConflictVenue        = "venue"        // 场地同一时段预约已满
+	ConflictVenueHours   = "venue_hours"  // 不在场地营业时间内
+	ConflictClient       = "client"       // 客户同一时段已有见面
+	ConflictMatchmaker   = "matchmaker"   // 红娘同一时段已有见面
+	ConflictAvailability = "availability" // 不在客户可约时间内
+)
+
+var (
+	ErrMeetingSource       = errors.New("见面须关联一个介绍或情侣档案")
+	ErrMeetingIntroStatus  = errors.New("介绍须在双方同意后才能预约见面")
+	ErrMeetingMatchBroken  = errors.New("情侣档案已分手，不能预约见面")
+	ErrMeetingTime         = errors.New("见面结束时间须晚于开始时间")
+	ErrMeetingStatus       = errors.New("见面状态已变更，请刷新后重试")
+	ErrMeetingConflict     = errors.New("见面时间冲突")
+	ErrVenueDisabled       = errors.New("场地已停用")
+	ErrVenueHours          = errors.New("营业时间格式应为 HH:MM，且开始早于结束")
+	ErrAvailabilityInvalid = errors.New("可约时间格式应为 HH:MM，且开始早于结束")
+)
+
+// clockLayout 营业时间与可约时间的格式
+const clockLayout = "15:04"
+
+// clockRange 校验 HH:MM 时间段，均为空表示全天
+func clockRange(start, end string) bool {
+	if start == "" && end == "" {
+		return true
+	}
+	s, err1 := time.Parse(clockLayout, start)
+	e, err2 := time.Parse(clockLayout, end)
+	return err1 == nil && err2 == nil && s.Before(e)
+}
+
+// withinClock [start, end) 是否落在当天 open 至 close 之间，跨天的时段视为不在范围内
+func withinClock(start, end time.Time, open, close string) bool {
+	if open == "" && close == "" {
+		return true
+	}
+	if start.Format(DailyDateLayout) != end.Add(-time.Nanosecond).Format(DailyDateLayout) {
+		return false
+	}
+	from, to := start.Format(clockLayout), end.Format(clockLayout)
+	if to == "00:00" {
+		to = "24:00"
+	}
+	return from >= open && to <= close
+}
+
+// Venue 见面场地
+type Venue struct {
+	ID           uint64    `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	Name         string    `json:"name" gorm:"column:name;size:128;comment:名称"`
+	Address      string    `json:"address" gorm:"column:address;size:255;comment:地址"`
+	ProvinceCode string    `json:"province_code" gorm:"column:province_code;size:20;comment:省份代码"`
+	CityCode     string    `json:"city_code" gorm:"column:city_code;size:20;index;comment:城市代码"`
+	DistrictCode string    `json:"district_code" gorm:"column:district_code;size:20;comment:区县代码"`
+	Capacity     int       `json:"capacity" gorm:"column:capacity;default:1;comment:同一时段可同时接待的见面数"`
+	OpenTime     string    `json:"open_time" gorm:"column:open_time;size:5;comment:营业开始 HH:MM，为空表示全天"`
+	CloseTime    string    `json:"close_time" gorm:"column:close_time;size:5;comment:营业结束 HH:MM"`
+	IsEnabled    bool      `json:"is_enabled" gorm:"column:is_enabled;default:true;comment:是否启用"`
+	Remark       string    `json:"remark" gorm:"column:remark;size:255;comment:备注"`
+	CreatedAt    time.Time `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt    time.Time `json:"updated_at" gorm:"column:updated_at"`
+}
+
+func (t *Venue) TableName() string {
+	return "venue"
+}
+
+// Validate 校验营业时间
+func (t *Venue) Validate() error {
+	if !clockRange(t.OpenTime, t.CloseTime) {
+		return ErrVenueHours
+	}
+	if t.Capacity <= 0 {
+		t.Capacity = 1
+	}
+	return nil
+}
+
+// Opens 场地在 [start, end) 是否营业
+func (t *Venue) Opens(start, end time.Time) bool {
+	return withinClock(start, end, t.OpenTime, t.CloseTime)
+}
+
+// ClientAvailability 客户每周可约时间，未设置时视为随时可约
+type ClientAvailability struct {
+	ID        uint64 `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ClientID  uint64 `json:"client_id" gorm:"column:client_id;index;comment:客户ID"`
+	Weekday   int8   `json:"weekday" gorm:"column:weekday;comment:星期 0周日 1-6周一至周六"`
+	StartTime string `json:"start_time" gorm:"column:start_time;size:5;comment:开始 HH:MM"`
+	EndTime   string `json:"end_time" gorm:"column:end_time;size:5;comment:结束 HH:MM"`
+}
+
+func (t *ClientAvailability) TableName() string {
+	return "client_availability"
+}
+
+// Validate 校验可约时间
+func (t *ClientAvailability) Validate() error {
+	if t.Weekday < 0 || t.Weekday > 6 || t.StartTime == "" || !clockRange(t.StartTime, t.EndTime) {
+		return ErrAvailabilityInvalid
+	}
+	return nil
+}
+
+// Available 客户在 [start, end) 是否可约，未设置可约时间时总是可约
+func Available(list []*ClientAvailability, start, end time.Time) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, a := range list {
+		if int8(start.Weekday()) == a.Weekday && withinClock(start, end, a.StartTime, a.EndTime) {
+			return true
+		}
+	}
+	return false
+}
+
+// Meeting 见面预约，关联一个介绍或情侣档案
+type Meeting struct {
+	ID             uint64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	IntroductionID *uint64    `json:"introduction_id" gorm:"column:introduction_id;index;comment:关联介绍ID"`
+	MatchRecordID  *uint64    `json:"match_record_id" gorm:"column:match_record_id;index;comment:关联情侣档案ID"`
+	ClientAID      uint64     `json:"client_a_id" gorm:"column:client_a_id;index;comment:客户A"`
+	ClientBID      uint64     `json:"client_b_id" gorm:"column:client_b_id;index;comment:客户B"`
+	MatchmakerID   uint64     `json:"matchmaker_id" gorm:"column:matchmaker_id;index;comment:负责红娘ID"`
+	VenueID        *uint64    `json:"venue_id" gorm:"column:venue_id;index;comment:场地ID，为空表示自行约定地点"`
+	Place          string     `json:"place" gorm:"column:place;size:255;comment:见面地点"`
+	StartAt        time.Time  `json:"start_at" gorm:"column:start_at;index;comment:开始时间"`
+	EndAt          time.Time  `json:"end_at" gorm:"column:end_at;index;comment:结束时间"`
+	Status         int8       `json:"status" gorm:"column:status;index;default:1;comment:状态 1已预约 2已见面 3已取消 4爽约"`
+	Remark         string     `json:"remark" gorm:"column:remark;size:255;comment:备注"`
+	Feedback       string     `json:"feedback" gorm:"column:feedback;type:text;comment:见面反馈"`
+	Operator       string     `json:"operator" gorm:"column:operator;size:64;comment:操作人"`
+	FinishedAt     *time.Time `json:"finished_at" gorm:"column:finished_at;comment:完成或取消时间"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" gorm:"column:updated_at"`
+
+	ClientA *Client `json:"client_a,omitempty" gorm:"foreignKey:ClientAID"`
+	ClientB *Client `json:"client_b,omitempty" gorm:"foreignKey:ClientBID"`
+	Venue   *Venue  `json:"venue,omitempty" gorm:"foreignKey:VenueID"`
+}
+
+func (t *Meeting) TableName() string {
+	return "meeting"
+}
+
+// HasClient 见面是否涉及该客户
+func (t *Meeting) HasClient(clientID uint64) bool {
+	return t.ClientAID == clientID || t.ClientBID == clientID
+}
+
+// Overlaps 与 [start, end) 是否有重叠
+func (t *Meeting) Overlaps(start, end time.Time) bool {
+	return t.StartAt.Before(end) && start.Before(t.EndAt)
+}
+
+// MeetingConflict 一项冲突，Hard 为 true 时不能强制预约
+type MeetingConflict struct {
+	Kind      string     `json:"kind"`
+	Hard      bool       `json:"hard"`
+	MeetingID uint64     `json:"meeting_id,omitempty"`
+	ClientID  uint64     `json:"client_id,omitempty"`
+	StartAt   *time.Time `json:"start_at,omitempty"`
+	EndAt     *time.Time `json:"end_at,omitempty"`
+	Message   string     `json:"message"`
+}
+
+// MeetingConflictError 预约时检测到的冲突
+type MeetingConflictError struct {
+	Conflicts []*MeetingConflict
+}
+
+func (e *MeetingConflictError) Error() string {
+	messages := make([]string, 0, len(e.Conflicts))
+	for _, c := range e.Conflicts {
+		messages = append(messages, c.Message)
+	}
+	return fmt.Sprintf("%s：%s", ErrMeetingConflict.Error(), strings.Join(messages, "；"))
+}
+
+func (e *MeetingConflictError) Is(target error) bool {
+	return target == ErrMeetingConflict
+}
+
+// TimeRange 可约时段
+type TimeRange struct {
+	StartAt time.Time `json:"start_at"`
+	EndAt   time.Time `json:"end_at"`
+}
+
+// MeetingCheck 预约前的冲突检测，booked 为与见面时间重叠的已预约见面，avail 为双方的可约时间
+type MeetingCheck func(booked []*Meeting, avail map[uint64][]*ClientAvailability) error
+
+type MeetingInterface interface {
+	CreateVenue(ctx context.Context, v *Venue) error
+	UpdateVenue(ctx context.Context, v *Venue) error
+	GetVenue(ctx context.Context, id uint64) (*Venue, error)
+	SelectVenues(ctx context.Context, clause *biz.WhereClause, offset, limit int) ([]*Venue, int64, error)
+
+	// Availability 各客户的可约时间
+	Availability(ctx context.Context, clientIDs []uint64) (map[uint64][]*ClientAvailability, error)
+	// SetAvailability 替换客户的全部可约时间
+	SetAvailability(ctx context.Context, clientID uint64, list []*ClientAvailability) error
+
+	// Create 在事务内锁定双方客户与场地，check 通过后写入，同一客户或场地的并发预约依次检测
+	Create(ctx context.Context, m *Meeting, check MeetingCheck) error
+	Get(ctx context.Context, id uint64) (*Meeting, error)
+	Select(ctx context.Context, clause *biz.WhereClause, offset, limit int) ([]*Meeting, int64, error)
+	// Reschedule 比较并更新：仅已预约的见面可改约，与 Create 相同在锁定后检测冲突
+	Reschedule(ctx context.Context, m *Meeting, check MeetingCheck) error
+	// Finish 比较并更新：已预约的见面标记为已见面、取消或爽约
+	Finish(ctx context.Context, id uint64, status int8, feedback, operator string) error
+	// Overlapping 与 [start, end) 重叠的已预约见面，涉及该场地、任一客户或该红娘，excludeID 为改约时的自身
+	Overlapping(ctx context.Context, start, end time.Time, venueID uint64, clientIDs []uint64, matchmakerID, excludeID uint64) ([]*Meeting, error)
+}
@@ -0,0 +1,128 @@
+package biz_omiai
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"omiai-server/internal/biz"
+)
+
+// 配对负反馈类型
+const (
+	PairKindDeclined = 1 // 拒绝，永久不再推荐
+	PairKindBrokenUp = 2 // 曾交往后分手，永久不再推荐
+	PairKindBlocked  = 3 // 屏蔽，永久不再推荐
+	PairKindSnoozed  = 4 // 暂不考虑，到期后恢复推荐
+	PairKindMutual   = 5 // 相亲会双向选择，已发起介绍或配对建议，不再重复推荐
+)
+
+var PairKindText = map[int8]string{
+	PairKindDeclined: "拒绝",
+	PairKindBrokenUp: "已分手",
+	PairKindBlocked:  "屏蔽",
+	PairKindSnoozed:  "暂不考虑",
+	PairKindMutual:   "相亲会互选",
+}
+
+// 红娘标记拒绝时可选的原因
+const (
+	PairReasonAge           = "age"            // 年龄不合适
+	PairReasonAppearance    = "appearance"     // 外形不合眼缘
+	PairReasonRegion        = "region"         // 距离太远
+	PairReasonCondition     = "condition"      // 学历收入等条件不符
+	PairReasonPersonality   = "personality"    // 性格不合
+	PairReasonFamily        = "family"         // 家庭原因
+	PairReasonNotInterested = "not_interested" // 暂无意愿
+	PairReasonOther         = "other"          // 其他
+)
+
+var PairReasonText = map[string]string{
+	PairReasonAge:           "年龄不合适",
+	PairReasonAppearance:    "外形不合眼缘",
+	PairReasonRegion:        "距离太远",
+	PairReasonCondition:     "条件不符",
+	PairReasonPersonality:   "性格不合",
+	PairReasonFamily:        "家庭原因",
+	PairReasonNotInterested: "暂无意愿",
+	PairReasonOther:         "其他",
+}
+
+// PairReasonBreakup 分手时系统写入的原因编码，介绍关闭时沿用介绍的关闭原因编码
+const PairReasonBreakup = "breakup"
+
+// 负反馈来源
+const (
+	PairSourceManual       = "manual"       // 红娘手动标记
+	PairSourceIntroduction = "introduction" // 介绍流程关闭
+	PairSourceMatchRecord  = "match_record" // 情侣分手
+	PairSourceProposal     = "proposal"     // 批量配对建议被驳回
+	PairSourceDaily        = "daily"        // 每日推荐跳过时标记不合适
+	PairSourceParty        = "party"        // 相亲会见面后未双向选择或已双向选择
+)
+
+var (
+	ErrPairKind       = errors.New("负反馈类型不正确")
+	ErrPairReason     = errors.New("请选择拒绝原因")
+	ErrPairExpiredAt  = errors.New("暂不考虑须填写晚于今天的恢复日期")
+	ErrPairSameClient = errors.New("不能对客户本人进行标记")
+	ErrPairSameGender = errors.New("仅能标记异性候选人")
+	ErrPairNotManual  = errors.New("系统生成的记录不能撤销")
+)
+
+// PairHistory 配对负反馈记录，ClientID 与 CandidateID 任一方向命中均视为该配对被排除
+type PairHistory struct {
+	ID          uint64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ClientID    uint64     `json:"client_id" gorm:"column:client_id;index;comment:发起方客户ID"`
+	CandidateID uint64     `json:"candidate_id" gorm:"column:candidate_id;index;comment:被拒绝方客户ID"`
+	Kind        int8       `json:"kind" gorm:"column:kind;comment:类型 1拒绝 2已分手 3屏蔽 4暂不考虑 5相亲会互选"`
+	ReasonCode  string     `json:"reason_code" gorm:"column:reason_code;size:32;comment:原因编码"`
+	Remark      string     `json:"remark" gorm:"column:remark;size:255;comment:备注"`
+	ExpiredAt   *time.Time `json:"expired_at" gorm:"column:expired_at;comment:失效时间，为空表示永久"`
+	SourceType  string     `json:"source_type" gorm:"column:source_type;size:32;comment:来源 manual/introduction/match_record/proposal/daily/party"`
+	SourceID    uint64     `json:"source_id" gorm:"column:source_id;comment:来源记录ID"`
+	Operator    string     `json:"operator" gorm:"column:operator;size:64;comment:操作人"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"column:created_at"`
+
+	Candidate *Client `json:"candidate,omitempty" gorm:"foreignKey:CandidateID"`
+}
+
+func (t *PairHistory) TableName() string {
+	return "pair_history"
+}
+
+// Active 记录在 at 时刻是否仍然生效
+func (t *PairHistory) Active(at time.Time) bool {
+	return t.ExpiredAt == nil || t.ExpiredAt.After(at)
+}
+
+// ReasonText 原因说明，介绍流程关闭产生的记录沿用关闭原因编码
+func (t *PairHistory) ReasonText() string {
+	if text, ok := PairReasonText[t.ReasonCode]; ok {
+		return text
+	}
+	return IntroCloseReasonText[t.ReasonCode]
+}
+
+type PairHistoryInterface interface {
+	Create(ctx context.Context, h *PairHistory) error
+	Get(ctx context.Context, id uint64) (*PairHistory, error)
+	// List 客户作为任一方的全部记录
+	List(ctx context.Context, clientID uint64) ([]*PairHistory, error)
+	Delete(ctx context.Context, id uint64) error
+	// ListActive 全部在 at 时刻仍有效的记录，批量配对时整体排除
+	ListActive(ctx context.Context, at time.Time) ([]*PairHistory, error)
+	// RecordBreakup 写入分手记录，并为双方设置冷静期
+	RecordBreakup(ctx context.Context, h *PairHistory, cooldownUntil time.Time) error
+}
+
+// AppendPairHistoryFilter 候选池排除条件：排除与 clientID 存在有效负反馈的客户，以及仍在分手冷静期的客户
+func AppendPairHistoryFilter(clause *biz.WhereClause, clientID uint64, now time.Time) {
+	if clause.Where != "" {
+		clause.Where += " AND "
+	}
+	clause.Where += "(cooldown_until IS NULL OR cooldown_until <= ?)" +
+		" AND id NOT IN (SELECT candidate_id FROM pair_history WHERE client_id = ? AND (expired_at IS NULL OR expired_at > ?))" +
+		" AND id NOT IN (SELECT client_id FROM pair_history WHERE candidate_id = ? AND (expired_at IS NULL OR expired_at > ?))"
+	clause.Args = append(clause.Args, now, clientID, now, clientID, now)
+}
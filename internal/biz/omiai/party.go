@@ -0,0 +1,207 @@
+package biz_omiai
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"omiai-server/internal/biz"
+)
+
+// 相亲会状态
+const (
+	PartyStatusOpen      = 1 // 报名中
+	PartyStatusOngoing   = 2 // 进行中，签到与轮转
+	PartyStatusPicking   = 3 // 互选中，收集会后选择
+	PartyStatusFinished  = 4 // 已结束，已生成配对
+	PartyStatusCancelled = 5 // 已取消
+)
+
+var PartyStatusText = map[int8]string{
+	PartyStatusOpen:      "报名中",
+	PartyStatusOngoing:   "进行中",
+	PartyStatusPicking:   "互选中",
+	PartyStatusFinished:  "已结束",
+	PartyStatusCancelled: "已取消",
+}
+
+// partyTransitions 允许的状态流转，结束前均可取消；互选结束由配对操作完成
+var partyTransitions = map[int8][]int8{
+	PartyStatusOpen:    {PartyStatusOngoing, PartyStatusCancelled},
+	PartyStatusOngoing: {PartyStatusPicking, PartyStatusCancelled},
+	PartyStatusPicking: {PartyStatusCancelled},
+}
+
+// CanPartyTransition 判断相亲会状态能否从 from 流转到 to
+func CanPartyTransition(from, to int8) bool {
+	for _, s := range partyTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// 报名状态
+const (
+	AttendeeStatusSignedUp  = 1 // 已报名
+	AttendeeStatusWaitlist  = 2 // 候补
+	AttendeeStatusCheckedIn = 3 // 已签到
+	AttendeeStatusCancelled = 4 // 已取消
+)
+
+var AttendeeStatusText = map[int8]string{
+	AttendeeStatusSignedUp:  "已报名",
+	AttendeeStatusWaitlist:  "候补",
+	AttendeeStatusCheckedIn: "已签到",
+	AttendeeStatusCancelled: "已取消",
+}
+
+// 双向选择后的处理方式
+const (
+	PartyMutualIntroduction = "introduction" // 直接发起介绍
+	PartyMutualProposal     = "proposal"     // 生成配对建议，由红娘审核
+)
+
+var PartyMutualText = map[string]string{
+	PartyMutualIntroduction: "直接发起介绍",
+	PartyMutualProposal:     "生成配对建议",
+}
+
+var (
+	ErrPartyInvalidTransition = errors.New("相亲会当前状态不允许该操作")
+	ErrPartyStatusChanged     = errors.New("相亲会状态已被他人更新，请刷新后重试")
+	ErrPartyQuota             = errors.New("男女名额之和不能超过总名额")
+	ErrPartyClientUnavailable = errors.New("仅单身客户可以报名")
+	ErrPartySignedUp          = errors.New("该客户已报名")
+	ErrPartyNotSignedUp       = errors.New("该客户未报名或已取消")
+	ErrPartyNotCheckedIn      = errors.New("该客户未签到")
+	ErrPartyNoAttendees       = errors.New("签到的男女嘉宾均不能为空")
+	ErrPartyNoSchedule        = errors.New("尚未生成轮转安排")
+	ErrPartyPickNotMet        = errors.New("只能选择轮转中见过的嘉宾")
+	ErrPartyPickLimit         = errors.New("选择人数超过上限")
+)
+
+// Party 线下相亲会
+type Party struct {
+	ID           uint64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	Title        string     `json:"title" gorm:"column:title;size:128;comment:名称"`
+	Venue        string     `json:"venue" gorm:"column:venue;size:255;comment:场地"`
+	StartAt      time.Time  `json:"start_at" gorm:"column:start_at;index;comment:开始时间"`
+	EndAt        *time.Time `json:"end_at" gorm:"column:end_at;comment:结束时间"`
+	Capacity     int        `json:"capacity" gorm:"column:capacity;comment:总名额，0 表示不限"`
+	MaleQuota    int        `json:"male_quota" gorm:"column:male_quota;comment:男嘉宾名额，0 表示不限"`
+	FemaleQuota  int        `json:"female_quota" gorm:"column:female_quota;comment:女嘉宾名额，0 表示不限"`
+	Rounds       int        `json:"rounds" gorm:"column:rounds;comment:轮转轮数，0 表示每位嘉宾与全部异性各见一次"`
+	RoundMinutes int        `json:"round_minutes" gorm:"column:round_minutes;comment:每轮分钟数"`
+	PickLimit    int        `json:"pick_limit" gorm:"column:pick_limit;comment:每人最多选择人数，0 表示不限"`
+	MutualAction string     `json:"mutual_action" gorm:"column:mutual_action;size:16;comment:双向选择后的处理 introduction/proposal"`
+	Status       int8       `json:"status" gorm:"column:status;index;default:1;comment:状态 1报名中 2进行中 3互选中 4已结束 5已取消"`
+	Remark       string     `json:"remark" gorm:"column:remark;type:text;comment:备注"`
+	MatchmakerID uint64     `json:"matchmaker_id" gorm:"column:matchmaker_id;comment:负责红娘ID"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" gorm:"column:updated_at"`
+}
+
+func (t *Party) TableName() string {
+	return "party"
+}
+
+// Quota 指定性别的名额，0 表示不限
+func (t *Party) Quota(gender int8) int {
+	switch gender {
+	case 1:
+		return t.MaleQuota
+	case 2:
+		return t.FemaleQuota
+	}
+	return 0
+}
+
+// PartyAttendee 相亲会报名
+type PartyAttendee struct {
+	ID          uint64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	PartyID     uint64     `json:"party_id" gorm:"column:party_id;uniqueIndex:idx_party_client;comment:相亲会ID"`
+	ClientID    uint64     `json:"client_id" gorm:"column:client_id;uniqueIndex:idx_party_client;index;comment:客户ID"`
+	Gender      int8       `json:"gender" gorm:"column:gender;comment:性别 1男 2女"`
+	Number      int        `json:"number" gorm:"column:number;comment:签到号码牌"`
+	Status      int8       `json:"status" gorm:"column:status;default:1;comment:状态 1已报名 2候补 3已签到 4已取消"`
+	CheckedInAt *time.Time `json:"checked_in_at" gorm:"column:checked_in_at;comment:签到时间"`
+	Operator    string     `json:"operator" gorm:"column:operator;size:64;comment:操作人"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" gorm:"column:updated_at"`
+
+	Client *Client `json:"client,omitempty" gorm:"foreignKey:ClientID"`
+}
+
+func (t *PartyAttendee) TableName() string {
+	return "party_attendee"
+}
+
+// PartySeat 轮转安排：某一轮某一桌的男女嘉宾
+type PartySeat struct {
+	ID             uint64 `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	PartyID        uint64 `json:"party_id" gorm:"column:party_id;index;comment:相亲会ID"`
+	Round          int    `json:"round" gorm:"column:round;comment:轮次，从 1 开始"`
+	TableNo        int    `json:"table_no" gorm:"column:table_no;comment:桌号，从 1 开始"`
+	MaleClientID   uint64 `json:"male_client_id" gorm:"column:male_client_id;comment:男嘉宾客户ID"`
+	FemaleClientID uint64 `json:"female_client_id" gorm:"column:female_client_id;comment:女嘉宾客户ID"`
+}
+
+func (t *PartySeat) TableName() string {
+	return "party_seat"
+}
+
+// PartyPick 会后选择：嘉宾 ClientID 希望与 PickedID 继续接触
+type PartyPick struct {
+	ID        uint64    `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	PartyID   uint64    `json:"party_id" gorm:"column:party_id;uniqueIndex:idx_party_pick;comment:相亲会ID"`
+	ClientID  uint64    `json:"client_id" gorm:"column:client_id;uniqueIndex:idx_party_pick;comment:选择方客户ID"`
+	PickedID  uint64    `json:"picked_id" gorm:"column:picked_id;uniqueIndex:idx_party_pick;comment:被选择方客户ID"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at"`
+}
+
+func (t *PartyPick) TableName() string {
+	return "party_pick"
+}
+
+// PartyResult 互选配对结果
+type PartyResult struct {
+	Met           int      `json:"met"`            // 轮转中见过面的配对数
+	Mutual        int      `json:"mutual"`         // 双向选择的配对数
+	Introductions []uint64 `json:"introductions"`  // 发起的介绍ID
+	ProposalBatch *uint64  `json:"proposal_batch"` // 生成的配对建议批次ID
+	Declined      int      `json:"declined"`       // 记为拒绝的配对数
+	Failed        []string `json:"failed"`         // 未能发起介绍的配对及原因
+}
+
+type PartyInterface interface {
+	Create(ctx context.Context, p *Party) error
+	Update(ctx context.Context, p *Party) error
+	Get(ctx context.Context, id uint64) (*Party, error)
+	Select(ctx context.Context, clause *biz.WhereClause, offset, limit int) ([]*Party, int64, error)
+	// Transition 比较并更新相亲会状态
+	Transition(ctx context.Context, id uint64, from, to int8) error
+	// Finish 在一个事务内将互选中的相亲会比较并更新为已结束，写入配对记录；batch 不为空时一并生成配对建议
+	Finish(ctx context.Context, id uint64, histories []*PairHistory, batch *ProposalBatch, proposals []*Proposal) error
+
+	// Attendees 报名列表，statuses 为空时返回全部
+	Attendees(ctx context.Context, partyID uint64, statuses ...int8) ([]*PartyAttendee, error)
+	GetAttendee(ctx context.Context, partyID, clientID uint64) (*PartyAttendee, error)
+	// SignUp 报名，对应性别名额已满时进入候补；取消后再次报名复用原记录
+	SignUp(ctx context.Context, party *Party, a *PartyAttendee) error
+	// CancelAttendee 取消报名，名额释放后同性别候补按报名顺序递补
+	CancelAttendee(ctx context.Context, party *Party, clientID uint64, operator string) error
+	// CheckIn 签到并分配号码牌
+	CheckIn(ctx context.Context, partyID, clientID uint64, operator string) (*PartyAttendee, error)
+
+	// Seats 轮转安排
+	Seats(ctx context.Context, partyID uint64) ([]*PartySeat, error)
+	// ReplaceSeats 替换全部轮转安排
+	ReplaceSeats(ctx context.Context, partyID uint64, seats []*PartySeat) error
+
+	// Picks 会后选择
+	Picks(ctx context.Context, partyID uint64) ([]*PartyPick, error)
+	// ReplacePicks 替换某位嘉宾的全部选择
+	ReplacePicks(ctx context.Context, partyID, clientID uint64, pickedIDs []uint64) error
+}
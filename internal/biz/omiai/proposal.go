@@ -0,0 +1,135 @@
+package biz_omiai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"omiai-server/internal/biz"
+)
+
+// 批量配对算法
+const (
+	ProposalStrategyMaxWeight = "max_weight" // 最大权匹配：总分最高，按轮次分配保证每人机会均等
+	ProposalStrategyStable    = "stable"     // 稳定匹配：男方依次发起，不存在双方都更愿意彼此的配对
+)
+
+// ProposalStrategyParty 相亲会双向选择生成的批次，不经过求解，不能用于批量配对
+const ProposalStrategyParty = "party"
+
+var ProposalStrategyText = map[string]string{
+	ProposalStrategyMaxWeight: "最大权匹配",
+	ProposalStrategyStable:    "稳定匹配",
+}
+
+// 批量配对默认参数
+const (
+	DefaultProposalCap      = 3   // 每位客户同时待审核的建议上限
+	DefaultProposalMinScore = 60  // 低于该得分的配对不进入建议
+	MaxProposalPool         = 300 // 每种性别参与计算的客户上限，两两评分与求解随人数平方、立方增长
+)
+
+const (
+	ProposalBatchStatusReviewing = 1 // 审核中
+	ProposalBatchStatusFinished  = 2 // 已全部审核
+)
+
+const (
+	ProposalStatusPending  = 1 // 待审核
+	ProposalStatusAccepted = 2 // 已采纳，已发起介绍
+	ProposalStatusRejected = 3 // 已驳回
+)
+
+var ProposalStatusText = map[int8]string{
+	ProposalStatusPending:  "待审核",
+	ProposalStatusAccepted: "已采纳",
+	ProposalStatusRejected: "已驳回",
+}
+
+var (
+	ErrProposalStrategy = errors.New("配对算法不存在")
+	ErrProposalReviewed = errors.New("该建议已审核，请刷新后重试")
+	ErrProposalEmpty    = errors.New("单身客户池中没有可配对的客户")
+)
+
+// ProposalOptions 批量配对参数
+type ProposalOptions struct {
+	Strategy        string
+	CandidateCap    int             // 每位客户同时待审核的建议上限（含此前批次未审核的）
+	MinScore        int             // 最低得分
+	RequirementMode RequirementMode // 双方择偶要求按该模式过滤，与其他推荐来源的容差一致
+	Operator        string
+}
+
+// ProposalBatch 批量配对批次，基于单身客户池（每种性别至多 MaxProposalPool 人）的两两得分矩阵全局求解
+type ProposalBatch struct {
+	ID            uint64    `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	Strategy      string    `json:"strategy" gorm:"column:strategy;size:32;comment:配对算法 max_weight/stable，相亲会生成的为 party"`
+	Algorithm     string    `json:"algorithm" gorm:"column:algorithm;size:32;comment:评分算法标识"`
+	CandidateCap  int       `json:"candidate_cap" gorm:"column:candidate_cap;comment:每人待审核建议上限"`
+	MinScore      int       `json:"min_score" gorm:"column:min_score;comment:最低得分"`
+	ClientCount   int       `json:"client_count" gorm:"column:client_count;comment:参与计算的单身客户数"`
+	ProposalCount int       `json:"proposal_count" gorm:"column:proposal_count;comment:建议数"`
+	AvgScore      float64   `json:"avg_score" gorm:"column:avg_score;comment:建议平均得分"`
+	Status        int8      `json:"status" gorm:"column:status;default:1;comment:状态 1审核中 2已全部审核"`
+	Operator      string    `json:"operator" gorm:"column:operator;size:64;comment:发起人"`
+	CreatedAt     time.Time `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt     time.Time `json:"updated_at" gorm:"column:updated_at"`
+}
+
+func (t *ProposalBatch) TableName() string {
+	return "proposal_batch"
+}
+
+// Proposal 批量配对建议，红娘逐对采纳或驳回
+type Proposal struct {
+	ID             uint64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	BatchID        uint64     `json:"batch_id" gorm:"column:batch_id;index;comment:批次ID"`
+	MaleClientID   uint64     `json:"male_client_id" gorm:"column:male_client_id;index;comment:男方客户ID"`
+	FemaleClientID uint64     `json:"female_client_id" gorm:"column:female_client_id;index;comment:女方客户ID"`
+	Score          int        `json:"score" gorm:"column:score;comment:匹配得分"`
+	Tags           string     `json:"-" gorm:"column:tags;size:512;comment:匹配标签(JSON)"`
+	Status         int8       `json:"status" gorm:"column:status;index;default:1;comment:状态 1待审核 2已采纳 3已驳回"`
+	IntroductionID *uint64    `json:"introduction_id" gorm:"column:introduction_id;comment:采纳后发起的介绍ID"`
+	ReviewedBy     string     `json:"reviewed_by" gorm:"column:reviewed_by;size:64;comment:审核人"`
+	ReviewRemark   string     `json:"review_remark" gorm:"column:review_remark;size:255;comment:审核备注"`
+	ReviewedAt     *time.Time `json:"reviewed_at" gorm:"column:reviewed_at;comment:审核时间"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" gorm:"column:updated_at"`
+
+	MaleClient   *Client `json:"male_client,omitempty" gorm:"foreignKey:MaleClientID"`
+	FemaleClient *Client `json:"female_client,omitempty" gorm:"foreignKey:FemaleClientID"`
+}
+
+func (t *Proposal) TableName() string {
+	return "proposal"
+}
+
+// TagList 解析匹配标签
+func (t *Proposal) TagList() []string {
+	var tags []string
+	_ = json.Unmarshal([]byte(t.Tags), &tags)
+	return tags
+}
+
+// ProposalReview 审核结果
+type ProposalReview struct {
+	Status         int8
+	IntroductionID *uint64
+	Operator       string
+	Remark         string
+}
+
+type ProposalInterface interface {
+	// CreateBatch 保存批次及全部建议
+	CreateBatch(ctx context.Context, batch *ProposalBatch, proposals []*Proposal) error
+	GetBatch(ctx context.Context, id uint64) (*ProposalBatch, error)
+	ListBatches(ctx context.Context, offset, limit int) ([]*ProposalBatch, int64, error)
+	Get(ctx context.Context, id uint64) (*Proposal, error)
+	Select(ctx context.Context, clause *biz.WhereClause, offset, limit int) ([]*Proposal, int64, error)
+	// Review 比较并更新：仅待审核的建议可审核，批次内无待审核建议时批次结束
+	Review(ctx context.Context, id uint64, review *ProposalReview) error
+	// PendingCounts 各客户当前待审核的建议数
+	PendingCounts(ctx context.Context) (map[uint64]int, error)
+}
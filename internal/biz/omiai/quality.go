@@ -0,0 +1,205 @@
+package biz_omiai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"time"
+)
+
+// 资料质量规则类型
+const (
+	QualityRuleRequired = "required" // 非空、非零
+	QualityRuleRange    = "range"    // 数值在 [Min, Max] 内，0 视为未填写
+	QualityRuleBirthday = "birthday" // 出生日期可识别，且周岁在 [Min, Max] 内
+	QualityRulePhone    = "phone"    // 11 位手机号
+)
+
+// 资料质量问题类型
+const (
+	QualityMissing = "missing" // 未填写
+	QualityInvalid = "invalid" // 已填写但无法识别或超出合理范围
+)
+
+// QualityRule 单个字段的资料质量规则，Field 为 Client 的 json 字段名，Weight 为该字段在完整度中的分值
+type QualityRule struct {
+	Field  string
+	Label  string
+	Weight int
+	Kind   string
+	Min    int
+	Max    int
+	Hint   string // 附加在问题说明后的影响提示
+}
+
+// QualityRules 资料质量规则，分值合计 100
+var QualityRules = []QualityRule{
+	{Field: "name", Label: "姓名", Weight: 10, Kind: QualityRuleRequired},
+	{Field: "phone", Label: "联系电话", Weight: 10, Kind: QualityRulePhone},
+	{Field: "birthday", Label: "出生年月", Weight: 10, Kind: QualityRuleBirthday, Min: 18, Max: 80, Hint: "年龄无法计算"},
+	{Field: "avatar", Label: "头像", Weight: 5, Kind: QualityRuleRequired},
+	{Field: "height", Label: "身高", Weight: 8, Kind: QualityRuleRange, Min: 130, Max: 220},
+	{Field: "weight", Label: "体重", Weight: 3, Kind: QualityRuleRange, Min: 30, Max: 200},
+	{Field: "education", Label: "学历", Weight: 8, Kind: QualityRuleRequired},
+	{Field: "marital_status", Label: "婚姻状况", Weight: 6, Kind: QualityRuleRange, Min: 1, Max: 4},
+	{Field: "income", Label: "月收入", Weight: 6, Kind: QualityRuleRequired},
+	{Field: "profession", Label: "具体工作", Weight: 6, Kind: QualityRuleRequired},
+	{Field: "work_city", Label: "工作城市", Weight: 4, Kind: QualityRuleRequired},
+	{Field: "work_city_code", Label: "工作城市行政区划", Weight: 6, Kind: QualityRuleRequired, Hint: "地域匹配按中性分计算"},
+	{Field: "house_status", Label: "房产情况", Weight: 4, Kind: QualityRuleRange, Min: 1, Max: 3},
+	{Field: "car_status", Label: "车辆情况", Weight: 3, Kind: QualityRuleRange, Min: 1, Max: 2},
+	{Field: "address", Label: "家庭住址", Weight: 3, Kind: QualityRuleRequired},
+	{Field: "family_description", Label: "家庭成员描述", Weight: 2, Kind: QualityRuleRequired},
+	{Field: "partner_requirements", Label: "择偶要求", Weight: 6, Kind: QualityRuleRequired, Hint: "无法按要求双向筛选"},
+}
+
+var mobileRegexp = regexp.MustCompile(`^1[3-9]\d{9}$`)
+
+// QualityIssue 资料质量问题
+type QualityIssue struct {
+	Field   string `json:"field"`
+	Label   string `json:"label"`
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// CheckQuality 按 QualityRules 检查资料，返回完整度百分比（通过规则的分值占比）及问题列表
+func CheckQuality(c *Client, now time.Time) (int, []*QualityIssue) {
+	v := reflect.ValueOf(c).Elem()
+	passed, total := 0, 0
+	issues := make([]*QualityIssue, 0)
+	for _, rule := range QualityRules {
+		total += rule.Weight
+		kind, msg := rule.check(v.Field(clientFieldIndex[rule.Field]), now)
+		if kind == "" {
+			passed += rule.Weight
+			continue
+		}
+		if rule.Hint != "" {
+			msg += "，" + rule.Hint
+		}
+		issues = append(issues, &QualityIssue{Field: rule.Field, Label: rule.Label, Kind: kind, Message: msg})
+	}
+	if total == 0 {
+		return 100, issues
+	}
+	return passed * 100 / total, issues
+}
+
+func (r QualityRule) check(v reflect.Value, now time.Time) (kind, msg string) {
+	if v.IsZero() {
+		return QualityMissing, "未填写" + r.Label
+	}
+	switch r.Kind {
+	case QualityRuleRange:
+		if n := int(v.Int()); n < r.Min || n > r.Max {
+			return QualityInvalid, fmt.Sprintf("%s %d 超出合理范围 %d-%d", r.Label, n, r.Min, r.Max)
+		}
+	case QualityRuleBirthday:
+		b, ok := ParseBirthday(v.String())
+		if !ok {
+			return QualityInvalid, fmt.Sprintf("无法识别的%s「%s」", r.Label, v.String())
+		}
+		if age := b.Age(now); age < r.Min || age > r.Max {
+			return QualityInvalid, fmt.Sprintf("按%s计算年龄为 %d 岁，超出合理范围 %d-%d", r.Label, age, r.Min, r.Max)
+		}
+	case QualityRulePhone:
+		if !mobileRegexp.MatchString(NormalizePhone(v.String())) {
+			return QualityInvalid, fmt.Sprintf("%s「%s」不是有效的手机号", r.Label, v.String())
+		}
+	}
+	return "", ""
+}
+
+// SyncQuality 重新计算并写入完整度与问题列表
+func (c *Client) SyncQuality(now time.Time) {
+	c.Completeness, c.QualityIssueList = CheckQuality(c, now)
+	raw, _ := json.Marshal(c.QualityIssueList)
+	c.QualityIssues = string(raw)
+}
+
+// QualityColumns 资料质量列，供 UpdateColumns 整体覆盖
+func (c *Client) QualityColumns() map[string]interface{} {
+	return map[string]interface{}{
+		"completeness":   c.Completeness,
+		"quality_issues": c.QualityIssues,
+	}
+}
+
+// DecodeQuality 解析存储的问题列表
+func (c *Client) DecodeQuality() []*QualityIssue {
+	if c.QualityIssueList == nil {
+		c.QualityIssueList = make([]*QualityIssue, 0)
+		if c.QualityIssues != "" {
+			_ = json.Unmarshal([]byte(c.QualityIssues), &c.QualityIssueList)
+		}
+	}
+	return c.QualityIssueList
+}
+
+// QualityReport 资料质量日报
+type QualityReport struct {
+	ID              uint64    `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ReportDate      string    `json:"report_date" gorm:"column:report_date;size:10;uniqueIndex;comment:统计日期"`
+	Total           int64     `json:"total" gorm:"column:total;comment:客户数"`
+	AvgCompleteness float64   `json:"avg_completeness" gorm:"column:avg_completeness;comment:平均完整度"`
+	Complete        int64     `json:"complete" gorm:"column:complete;comment:资料完整的客户数"`
+	Buckets         string    `json:"-" gorm:"column:buckets;type:text;comment:完整度分布(JSON)"`
+	Issues          string    `json:"-" gorm:"column:issues;type:text;comment:各字段问题数(JSON)"`
+	CreatedAt       time.Time `json:"created_at" gorm:"column:created_at"`
+
+	BucketList []*QualityBucket     `json:"buckets" gorm:"-"`
+	IssueList  []*QualityIssueCount `json:"issues" gorm:"-"`
+}
+
+func (t *QualityReport) TableName() string {
+	return "client_quality_report"
+}
+
+// QualityBucket 完整度区间 [Min, Max] 内的客户数
+type QualityBucket struct {
+	Min   int   `json:"min"`
+	Max   int   `json:"max"`
+	Count int64 `json:"count"`
+}
+
+// QualityIssueCount 某字段某类问题的客户数
+type QualityIssueCount struct {
+	Field string `json:"field"`
+	Label string `json:"label"`
+	Kind  string `json:"kind"`
+	Count int64  `json:"count"`
+}
+
+// Decode 解析分布与问题统计
+func (t *QualityReport) Decode() {
+	t.BucketList = make([]*QualityBucket, 0)
+	t.IssueList = make([]*QualityIssueCount, 0)
+	if t.Buckets != "" {
+		_ = json.Unmarshal([]byte(t.Buckets), &t.BucketList)
+	}
+	if t.Issues != "" {
+		_ = json.Unmarshal([]byte(t.Issues), &t.IssueList)
+	}
+}
+
+// Encode 写入分布与问题统计
+func (t *QualityReport) Encode() {
+	raw, _ := json.Marshal(t.BucketList)
+	t.Buckets = string(raw)
+	raw, _ = json.Marshal(t.IssueList)
+	t.Issues = string(raw)
+}
+
+// QualityInterface 资料质量数据接口
+type QualityInterface interface {
+	// Batch 按 ID 顺序读取 afterID 之后的客户，不含回收站
+	Batch(ctx context.Context, afterID uint64, limit int) ([]*Client, error)
+	// Save 写入完整度与问题列表，不修改 updated_at、不记录变更历史
+	Save(ctx context.Context, c *Client) error
+	// SaveReport 保存日报，同一天重复统计时覆盖
+	SaveReport(ctx context.Context, report *QualityReport) error
+	Reports(ctx context.Context, offset, limit int) ([]*QualityReport, int64, error)
+}
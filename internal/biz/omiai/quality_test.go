@@ -0,0 +1,70 @@
+package biz_omiai
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBirthday(t *testing.T) {
+	now := time.Date(2026, 5, 10, 8, 0, 0, 0, time.Local)
+
+	b, ok := ParseBirthday("1990-05")
+	assert.True(t, ok)
+	assert.Equal(t, Birthday{Year: 1990, Month: 5}, b)
+	assert.Equal(t, 36, b.Age(now))
+	_, ok = b.Next(now)
+	assert.False(t, ok)
+
+	// 各写法的年月日一致，生日未到不满岁
+	for _, s := range []string{"1990-05-12", "1990/5/12", "1990年5月12日", "19900512"} {
+		b, ok = ParseBirthday(s)
+		assert.True(t, ok, s)
+		assert.Equal(t, Birthday{Year: 1990, Month: 5, Day: 12}, b, s)
+		assert.Equal(t, 35, b.Age(now), s)
+	}
+	next, _ := b.Next(now)
+	assert.Equal(t, time.Date(2026, 5, 12, 0, 0, 0, 0, time.Local), next)
+	// 当天生日不顺延到明年
+	next, _ = Birthday{Year: 1990, Month: 5, Day: 10}.Next(now)
+	assert.Equal(t, time.Date(2026, 5, 10, 0, 0, 0, 0, time.Local), next)
+	next, _ = Birthday{Year: 1990, Month: 5, Day: 9}.Next(now)
+	assert.Equal(t, 2027, next.Year())
+
+	for _, s := range []string{"", "90-05", "1990-13", "1990-02-30", "不详"} {
+		_, ok = ParseBirthday(s)
+		assert.False(t, ok, s)
+	}
+}
+
+func TestCheckQuality(t *testing.T) {
+	now := time.Date(2026, 5, 10, 8, 0, 0, 0, time.Local)
+	c := &Client{
+		Name: "王小明", Phone: "+86 138-0000-0000", Birthday: "1990年5月", Avatar: "a.jpg", Height: 175, Weight: 70,
+		Education: 4, MaritalStatus: 1, Income: 10000, Profession: "工程师", WorkCity: "北京", WorkCityCode: "110100",
+		HouseStatus: 2, CarStatus: 1, Address: "北京", FamilyDescription: "独生子", PartnerRequirements: `{"min_age":25}`,
+	}
+	score, issues := CheckQuality(c, now)
+	assert.Equal(t, 100, score)
+	assert.Empty(t, issues)
+
+	// 身高为 0、生日无法识别、未选行政区划
+	c.Height, c.Birthday, c.WorkCityCode = 0, "九零年", ""
+	c.SyncQuality(now)
+	assert.Equal(t, 76, c.Completeness)
+	assert.Len(t, c.QualityIssueList, 3)
+	assert.Equal(t, "birthday", c.QualityIssueList[0].Field)
+	assert.Equal(t, QualityInvalid, c.QualityIssueList[0].Kind)
+	assert.Equal(t, QualityMissing, c.QualityIssueList[1].Kind)
+
+	stored := &Client{Completeness: c.Completeness, QualityIssues: c.QualityIssues}
+	assert.Equal(t, c.QualityIssueList, stored.DecodeQuality())
+
+	// 超出合理范围
+	c.Height, c.Birthday, c.WorkCityCode = 1750, "2015-01", "110100"
+	score, issues = CheckQuality(c, now)
+	assert.Equal(t, 82, score)
+	assert.Len(t, issues, 2)
+	assert.Empty(t, (&Client{}).DecodeQuality())
+}
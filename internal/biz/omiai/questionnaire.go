@@ -0,0 +1,183 @@
+package biz_omiai
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"time"
+)
+
+// 问卷编码
+const QuestionnaireCodePersonality = "personality" // 性格与价值观测评
+
+const (
+	QuestionnaireStatusActive   = 1 // 启用
+	QuestionnaireStatusInactive = 2 // 停用（历史版本）
+)
+
+const (
+	SheetStatusPending   = 1 // 待填写
+	SheetStatusSubmitted = 2 // 已提交
+)
+
+// 测评维度分组
+const (
+	ProfileGroupPersonality = "personality" // 大五人格
+	ProfileGroupValues      = "values"      // 价值观
+	ProfileGroupExpectation = "expectation" // 婚恋期望
+)
+
+// ProfileDimension 测评维度定义
+type ProfileDimension struct {
+	Key   string `json:"key"`
+	Label string `json:"label"`
+	Group string `json:"group"`
+}
+
+// ProfileDimensions 全部测评维度，题目的 Dimension 必须取自此列表
+var ProfileDimensions = []ProfileDimension{
+	{Key: "openness", Label: "开放性", Group: ProfileGroupPersonality},
+	{Key: "conscientiousness", Label: "尽责性", Group: ProfileGroupPersonality},
+	{Key: "extraversion", Label: "外向性", Group: ProfileGroupPersonality},
+	{Key: "agreeableness", Label: "宜人性", Group: ProfileGroupPersonality},
+	{Key: "neuroticism", Label: "情绪敏感度", Group: ProfileGroupPersonality},
+	{Key: "family", Label: "家庭观念", Group: ProfileGroupValues},
+	{Key: "career", Label: "事业追求", Group: ProfileGroupValues},
+	{Key: "consumption", Label: "消费观念", Group: ProfileGroupValues},
+	{Key: "tradition", Label: "传统观念", Group: ProfileGroupValues},
+	{Key: "marriage_urgency", Label: "结婚意愿", Group: ProfileGroupExpectation},
+	{Key: "children", Label: "生育意愿", Group: ProfileGroupExpectation},
+	{Key: "long_term", Label: "长期承诺", Group: ProfileGroupExpectation},
+}
+
+// ProfileDimensionByKey 按 key 查找测评维度
+func ProfileDimensionByKey(key string) (ProfileDimension, bool) {
+	for _, d := range ProfileDimensions {
+		if d.Key == key {
+			return d, true
+		}
+	}
+	return ProfileDimension{}, false
+}
+
+// Question 问卷题目，统一采用 1-5 分李克特量表
+type Question struct {
+	Key       string `json:"key"`       // 题目标识，版本内唯一
+	Text      string `json:"text"`      // 题干
+	Dimension string `json:"dimension"` // 计入的测评维度
+	Reverse   bool   `json:"reverse"`   // 反向计分
+}
+
+// Questionnaire 问卷题库，同一 Code 下每次修改生成新版本，仅最新版本启用
+type Questionnaire struct {
+	ID          uint64    `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	Code        string    `json:"code" gorm:"column:code;size:32;index;comment:问卷编码"`
+	Title       string    `json:"title" gorm:"column:title;size:64;comment:问卷标题"`
+	Description string    `json:"description" gorm:"column:description;size:255;comment:问卷说明"`
+	Version     int       `json:"version" gorm:"column:version;comment:版本号"`
+	Status      int8      `json:"status" gorm:"column:status;default:1;comment:状态 1启用 2停用"`
+	Questions   string    `json:"questions" gorm:"column:questions;type:text;comment:题目列表(JSON)"`
+	CreatedAt   time.Time `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"column:updated_at"`
+}
+
+func (t *Questionnaire) TableName() string {
+	return "questionnaire"
+}
+
+// QuestionList 解析题目列表
+func (t *Questionnaire) QuestionList() []*Question {
+	var questions []*Question
+	_ = json.Unmarshal([]byte(t.Questions), &questions)
+	return questions
+}
+
+// QuestionnaireSheet 答卷，通过 Token 生成的链接由客户本人填写
+type QuestionnaireSheet struct {
+	ID              uint64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	QuestionnaireID uint64     `json:"questionnaire_id" gorm:"column:questionnaire_id;index;comment:问卷ID"`
+	ClientID        uint64     `json:"client_id" gorm:"column:client_id;index;comment:客户ID"`
+	Token           string     `json:"token" gorm:"column:token;size:64;uniqueIndex;comment:填写链接凭证"`
+	Status          int8       `json:"status" gorm:"column:status;default:1;comment:状态 1待填写 2已提交"`
+	Answers         string     `json:"answers" gorm:"column:answers;type:text;comment:答案(JSON, 题目key=>1-5)"`
+	IssuedBy        uint64     `json:"issued_by" gorm:"column:issued_by;comment:发放人"`
+	ExpiredAt       time.Time  `json:"expired_at" gorm:"column:expired_at;comment:链接过期时间"`
+	SubmittedAt     *time.Time `json:"submitted_at" gorm:"column:submitted_at;comment:提交时间"`
+	CreatedAt       time.Time  `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt       time.Time  `json:"updated_at" gorm:"column:updated_at"`
+
+	Questionnaire *Questionnaire `json:"questionnaire,omitempty" gorm:"foreignKey:QuestionnaireID"`
+}
+
+func (t *QuestionnaireSheet) TableName() string {
+	return "questionnaire_sheet"
+}
+
+// PersonalityProfile 客户测评画像，取最近一次提交的答卷计算
+type PersonalityProfile struct {
+	ID              uint64    `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ClientID        uint64    `json:"client_id" gorm:"column:client_id;uniqueIndex;comment:客户ID"`
+	QuestionnaireID uint64    `json:"questionnaire_id" gorm:"column:questionnaire_id;comment:问卷ID"`
+	Version         int       `json:"version" gorm:"column:version;comment:问卷版本"`
+	SheetID         uint64    `json:"sheet_id" gorm:"column:sheet_id;comment:答卷ID"`
+	Scores          string    `json:"scores" gorm:"column:scores;type:text;comment:各维度得分(JSON, 0-100)"`
+	CreatedAt       time.Time `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt       time.Time `json:"updated_at" gorm:"column:updated_at"`
+}
+
+func (t *PersonalityProfile) TableName() string {
+	return "personality_profile"
+}
+
+// ScoreMap 解析各维度得分
+func (t *PersonalityProfile) ScoreMap() map[string]float64 {
+	if t == nil || t.Scores == "" {
+		return nil
+	}
+	scores := make(map[string]float64)
+	if err := json.Unmarshal([]byte(t.Scores), &scores); err != nil {
+		return nil
+	}
+	return scores
+}
+
+// QuestionnaireInterface 问卷数据层接口
+type QuestionnaireInterface interface {
+	// CreateVersion 发布新版本：版本号自增，同编码的旧版本停用
+	CreateVersion(ctx context.Context, q *Questionnaire) error
+	List(ctx context.Context, code string) ([]*Questionnaire, error)
+	Get(ctx context.Context, id uint64) (*Questionnaire, error)
+	// GetActive 无启用版本时返回 nil, nil
+	GetActive(ctx context.Context, code string) (*Questionnaire, error)
+
+	CreateSheet(ctx context.Context, sheet *QuestionnaireSheet) error
+	// GetSheetByToken 未找到时返回 nil, nil
+	GetSheetByToken(ctx context.Context, token string) (*QuestionnaireSheet, error)
+	ListSheets(ctx context.Context, clientID uint64) ([]*QuestionnaireSheet, error)
+	// SubmitSheet 提交答卷并更新客户画像，答卷已提交时返回错误
+	SubmitSheet(ctx context.Context, sheet *QuestionnaireSheet, profile *PersonalityProfile) error
+
+	// GetProfile 客户未完成测评时返回 nil, nil
+	GetProfile(ctx context.Context, clientID uint64) (*PersonalityProfile, error)
+}
+
+// ProfileSimilarity 两份画像在指定分组上的相似度 0-100 (100 - 平均分差)，n 为双方都有得分的维度数
+func ProfileSimilarity(a, b map[string]float64, group string) (similarity float64, n int) {
+	var diff float64
+	for _, d := range ProfileDimensions {
+		if d.Group != group {
+			continue
+		}
+		va, okA := a[d.Key]
+		vb, okB := b[d.Key]
+		if !okA || !okB {
+			continue
+		}
+		diff += math.Abs(va - vb)
+		n++
+	}
+	if n == 0 {
+		return 0, 0
+	}
+	return math.Round((100-diff/float64(n))*10) / 10, n
+}
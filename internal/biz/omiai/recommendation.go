@@ -0,0 +1,121 @@
+package biz_omiai
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// RecommendationLimit 每个客户保留的推荐数量上限，按得分取前 N 名
+const RecommendationLimit = 200
+
+const (
+	RecommendationStatusActive = 1 // 有效
+	RecommendationStatusStale  = 2 // 候选人资料已变更，查询时重新评分
+)
+
+// Recommendation 候选人推荐，由统一评分器为 ClientID 生成
+// 客户本人资料或状态变化时整体重新生成；候选人资料变化时仅将对应记录标记为待重算
+type Recommendation struct {
+	ID          uint64    `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ClientID    uint64    `json:"client_id" gorm:"column:client_id;uniqueIndex:idx_recommendation_pair;comment:被推荐客户ID"`
+	CandidateID uint64    `json:"candidate_id" gorm:"column:candidate_id;uniqueIndex:idx_recommendation_pair;index;comment:候选人ID"`
+	Score       int       `json:"score" gorm:"column:score;comment:匹配得分"`
+	Dimensions  string    `json:"-" gorm:"column:dimensions;type:text;comment:各维度得分明细(JSON)"`
+	Tags        string    `json:"-" gorm:"column:tags;size:512;comment:匹配标签(JSON)"`
+	Algorithm   string    `json:"algorithm" gorm:"column:algorithm;size:32;uniqueIndex:idx_recommendation_pair;comment:评分算法标识"` // 各实验分组的推荐互不覆盖
+	GeneratedAt time.Time `json:"generated_at" gorm:"column:generated_at;comment:计算时间"`
+	Status      int8      `json:"status" gorm:"column:status;default:1;comment:状态 1有效 2待重算"`
+	CreatedAt   time.Time `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"column:updated_at"`
+
+	Client    *Client `json:"client,omitempty" gorm:"foreignKey:ClientID"`
+	Candidate *Client `json:"candidate,omitempty" gorm:"foreignKey:CandidateID"`
+}
+
+func (t *Recommendation) TableName() string {
+	return "recommendation"
+}
+
+// NewRecommendation 根据评分结果生成推荐记录
+func NewRecommendation(clientID, candidateID uint64, result *ScoreResult) *Recommendation {
+	r := &Recommendation{ClientID: clientID, CandidateID: candidateID}
+	r.Apply(result)
+	return r
+}
+
+// Apply 写入评分结果并置为有效
+func (t *Recommendation) Apply(result *ScoreResult) {
+	dimensions, _ := json.Marshal(result.Dimensions)
+	tags, _ := json.Marshal(result.Tags)
+	t.Score = result.Score
+	t.Dimensions = string(dimensions)
+	t.Tags = string(tags)
+	t.Algorithm = result.Algorithm
+	t.GeneratedAt = time.Now()
+	t.Status = RecommendationStatusActive
+}
+
+// DimensionList 解析各维度得分明细
+func (t *Recommendation) DimensionList() []*DimensionScore {
+	var dimensions []*DimensionScore
+	_ = json.Unmarshal([]byte(t.Dimensions), &dimensions)
+	return dimensions
+}
+
+// TagList 解析匹配标签
+func (t *Recommendation) TagList() []string {
+	var tags []string
+	_ = json.Unmarshal([]byte(t.Tags), &tags)
+	return tags
+}
+
+// Result 还原推荐生成时的评分结果
+func (t *Recommendation) Result() *ScoreResult {
+	result := &ScoreResult{Score: t.Score, Algorithm: t.Algorithm, Tags: t.TagList(), Dimensions: t.DimensionList()}
+	for _, d := range result.Dimensions {
+		result.Violations = append(result.Violations, d.Violations...)
+	}
+	return result
+}
+
+// ToCandidate 转换为候选人列表项，需 Preload Candidate
+func (t *Recommendation) ToCandidate() *Candidate {
+	c := &Candidate{
+		CandidateID: t.CandidateID,
+		MatchScore:  t.Score,
+		Tags:        t.TagList(),
+		GeneratedAt: t.GeneratedAt,
+	}
+	if t.Candidate != nil {
+		c.Name = t.Candidate.Name
+		c.Avatar = t.Candidate.Avatar
+		c.Age = t.Candidate.RealAge()
+		c.Height = t.Candidate.Height
+		c.Education = int(t.Candidate.Education)
+	}
+	return c
+}
+
+// 候选人排序字段
+const (
+	CandidateSortScore     = "score"
+	CandidateSortAge       = "age"
+	CandidateSortHeight    = "height"
+	CandidateSortIncome    = "income"
+	CandidateSortEducation = "education"
+)
+
+// CandidateQuery 候选人列表查询条件，零值表示不限
+type CandidateQuery struct {
+	ClientID     uint64
+	MinAge       int
+	MaxAge       int
+	CityCode     string // 工作或房产所在城市
+	MinEducation int8
+	Sort         string // 默认按得分
+	Asc          bool   // 默认降序
+	Explain      bool   // 是否附带得分解释
+	Scorer       Scorer // A/B 实验分组的评分器，为空时使用默认评分器；与已生成推荐的算法不同时整体重新生成
+	Offset       int
+	Limit        int
+}
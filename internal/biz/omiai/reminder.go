@@ -6,12 +6,16 @@ import (
 	"gorm.io/gorm"
 )
 
+// ReminderTriggerSegment 客户进入分群时触发，规则的 SegmentID 指定分群
+const ReminderTriggerSegment = "SegmentEnter"
+
 // AutoReminderRule 自动提醒规则
 type AutoReminderRule struct {
 	ID               int64          `json:"id" gorm:"primaryKey;autoIncrement"`
 	Name             string         `json:"name" gorm:"size:64;not null;comment:规则名称"`
-	TriggerType      string         `json:"trigger_type" gorm:"size:32;not null;comment:触发类型(NewClient, StatusChange, NoContact)"`
+	TriggerType      string         `json:"trigger_type" gorm:"size:32;not null;comment:触发类型(NewClient, StatusChange, NoContact, SegmentEnter)"`
 	TriggerCondition string         `json:"trigger_condition" gorm:"size:255;comment:触发条件(如:status=2)"`
+	SegmentID        uint64         `json:"segment_id" gorm:"index;default:0;comment:触发分群ID(SegmentEnter)"`
 	DelayDays        int            `json:"delay_days" gorm:"default:0;comment:延迟天数"`
 	TemplateID       int64          `json:"template_id" gorm:"comment:关联的沟通模板ID"`
 	IsEnabled        bool           `json:"is_enabled" gorm:"default:true;comment:是否启用"`
@@ -25,6 +29,7 @@ type ReminderTask struct {
 	ID          int64     `json:"id" gorm:"primaryKey;autoIncrement"`
 	ClientID    int64     `json:"client_id" gorm:"not null;index;comment:关联客户ID"`
 	RuleID      int64     `json:"rule_id" gorm:"index;comment:关联规则ID"`
+	MeetingID   uint64    `json:"meeting_id" gorm:"index;default:0;comment:关联见面ID，见面前后的系统提醒"`
 	Content     string    `json:"content" gorm:"type:text;comment:提醒内容/建议话术"`
 	ScheduledAt time.Time `json:"scheduled_at" gorm:"index;comment:计划提醒时间"`
 	Status      string    `json:"status" gorm:"size:20;default:'pending';comment:状态(pending, completed, cancelled)"`
@@ -52,4 +57,6 @@ type ReminderInterface interface {
 	Delete(id int64) error
 	CountByUser(userID uint64, isDone int) (int64, error)
 	ExistsByClientAndType(clientID uint64, triggerType string, start, end time.Time) (bool, error)
+	// CancelByMeeting 取消见面关联的未完成提醒，见面改约、取消或爽约时调用
+	CancelByMeeting(meetingID uint64) error
 }
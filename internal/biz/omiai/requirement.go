@@ -0,0 +1,261 @@
+package biz_omiai
+
+import (
+	"encoding/json"
+	"fmt"
+	"omiai-server/internal/biz"
+	"strconv"
+	"strings"
+)
+
+// PartnerRequirements 择偶要求 (Client.PartnerRequirements 的 JSON 结构)
+type PartnerRequirements struct {
+	MinAge        int    `json:"min_age"`
+	MaxAge        int    `json:"max_age"`
+	MinHeight     int    `json:"min_height"`
+	MaxHeight     int    `json:"max_height"`
+	MinIncome     int    `json:"min_income"`
+	Education     int8   `json:"education"`      // 最低学历
+	MaritalStatus []int8 `json:"marital_status"` // 可接受的婚姻状况，如 [1, 3]
+	HouseStatus   int8   `json:"house_status"`   // 房产要求，>=2 表示需有房
+}
+
+// ParseRequirements 解析择偶要求，非 JSON 的自由文本返回 nil
+func (c *Client) ParseRequirements() *PartnerRequirements {
+	raw := strings.TrimSpace(c.PartnerRequirements)
+	if raw == "" || !strings.HasPrefix(raw, "{") {
+		return nil
+	}
+	var reqs PartnerRequirements
+	if err := json.Unmarshal([]byte(raw), &reqs); err != nil {
+		return nil
+	}
+	return &reqs
+}
+
+// Check 检查 target 是否满足要求，返回检查项数量与不满足项描述
+// target 对应字段缺失时不计入检查
+func (r *PartnerRequirements) Check(target *Client) (checked int, violations []string) {
+	return r.CheckMode(target, RequirementStrict)
+}
+
+// CheckMode 按过滤模式检查，宽松模式与 AppendRequirementFilter 使用相同的容差，婚况/房产不检查
+func (r *PartnerRequirements) CheckMode(target *Client, mode RequirementMode) (checked int, violations []string) {
+	if r == nil || target == nil {
+		return 0, nil
+	}
+	relaxed := mode != RequirementStrict
+	ageSlack, heightSlack, eduSlack, incomeRatio := 0, 0, int8(0), 1.0
+	if relaxed {
+		ageSlack, heightSlack, eduSlack, incomeRatio = relaxedAgeSlack, relaxedHeightSlack, relaxedEducationSlack, relaxedIncomeRatio
+	}
+
+	if age := target.RealAge(); age > 0 && (r.MinAge > 0 || r.MaxAge > 0) {
+		checked++
+		if (r.MinAge > 0 && age < r.MinAge-ageSlack) || (r.MaxAge > 0 && age > r.MaxAge+ageSlack) {
+			violations = append(violations, fmt.Sprintf("年龄不符(%d岁)", age))
+		}
+	}
+	if target.Height > 0 && (r.MinHeight > 0 || r.MaxHeight > 0) {
+		checked++
+		if (r.MinHeight > 0 && target.Height < r.MinHeight-heightSlack) || (r.MaxHeight > 0 && target.Height > r.MaxHeight+heightSlack) {
+			violations = append(violations, fmt.Sprintf("身高不符(%dcm)", target.Height))
+		}
+	}
+	if target.Income > 0 && r.MinIncome > 0 {
+		checked++
+		if float64(target.Income) < float64(r.MinIncome)*incomeRatio {
+			violations = append(violations, fmt.Sprintf("收入未达标(%d元)", target.Income))
+		}
+	}
+	if target.Education > 0 && r.Education > 0 {
+		checked++
+		if target.Education < r.Education-eduSlack {
+			violations = append(violations, "学历未达标")
+		}
+	}
+	if relaxed {
+		return checked, violations
+	}
+	if target.MaritalStatus > 0 && len(r.MaritalStatus) > 0 {
+		checked++
+		accepted := false
+		for _, s := range r.MaritalStatus {
+			if s == target.MaritalStatus {
+				accepted = true
+				break
+			}
+		}
+		if !accepted {
+			violations = append(violations, "婚姻状况不符")
+		}
+	}
+	// 房产只区分有无：要求 2(已购房)/3(贷款购房) 均视为"需有房"
+	if target.HouseStatus > 0 && r.HouseStatus >= 2 {
+		checked++
+		if target.HouseStatus < 2 {
+			violations = append(violations, "房产条件不符")
+		}
+	}
+	return checked, violations
+}
+
+// RequirementMode 择偶要求过滤模式
+type RequirementMode string
+
+const (
+	RequirementStrict  RequirementMode = "strict"  // 严格：双方要求必须全部满足
+	RequirementRelaxed RequirementMode = "relaxed" // 宽松：数值项放宽容差，婚况/房产仅提示不过滤
+)
+
+// ParseRequirementMode 解析过滤模式，默认宽松
+func ParseRequirementMode(s string) RequirementMode {
+	if RequirementMode(s) == RequirementStrict {
+		return RequirementStrict
+	}
+	return RequirementRelaxed
+}
+
+// 宽松模式容差
+const (
+	relaxedAgeSlack       = 2   // 年龄上下浮动 2 岁
+	relaxedHeightSlack    = 3   // 身高上下浮动 3cm
+	relaxedIncomeRatio    = 0.8 // 收入下限打 8 折
+	relaxedEducationSlack = 1   // 学历下限降一级
+)
+
+// 择偶要求不满足的一方
+const (
+	RequirementSideSource    = "source"    // 本人的要求，候选人不满足
+	RequirementSideCandidate = "candidate" // 候选人的要求，本人不满足
+)
+
+// RequirementViolation 择偶要求不满足项
+type RequirementViolation struct {
+	Side    string `json:"side"`    // source / candidate
+	Message string `json:"message"` // 如：对方要求：年龄不符(33岁)
+}
+
+// MutualViolations 双向校验择偶要求，说明是哪一方的要求未被满足
+func MutualViolations(source, candidate *Client) []*RequirementViolation {
+	return MutualViolationsMode(source, candidate, RequirementStrict)
+}
+
+// MutualViolationsMode 按过滤模式双向校验，结果与 AppendRequirementFilter 的过滤结果一致
+func MutualViolationsMode(source, candidate *Client, mode RequirementMode) []*RequirementViolation {
+	var out []*RequirementViolation
+	_, sourceViolations := source.ParseRequirements().CheckMode(candidate, mode)
+	for _, v := range sourceViolations {
+		out = append(out, &RequirementViolation{Side: RequirementSideSource, Message: "本人要求：" + v})
+	}
+	_, candidateViolations := candidate.ParseRequirements().CheckMode(source, mode)
+	for _, v := range candidateViolations {
+		out = append(out, &RequirementViolation{Side: RequirementSideCandidate, Message: "对方要求：" + v})
+	}
+	return out
+}
+
+// SyncRequirementColumns 将 JSON 择偶要求展开到 req_* 列，便于在 SQL 中反向过滤
+func (c *Client) SyncRequirementColumns() {
+	r := c.ParseRequirements()
+	if r == nil {
+		r = &PartnerRequirements{}
+	}
+	c.ReqMinAge, c.ReqMaxAge = r.MinAge, r.MaxAge
+	c.ReqMinHeight, c.ReqMaxHeight = r.MinHeight, r.MaxHeight
+	c.ReqMinIncome = r.MinIncome
+	c.ReqEducation = r.Education
+	c.ReqHouseStatus = r.HouseStatus
+	c.ReqMaritalStatus = ""
+	if len(r.MaritalStatus) > 0 {
+		parts := make([]string, 0, len(r.MaritalStatus))
+		for _, s := range r.MaritalStatus {
+			parts = append(parts, strconv.Itoa(int(s)))
+		}
+		// 首尾加逗号，便于 LIKE '%,1,%' 精确匹配
+		c.ReqMaritalStatus = "," + strings.Join(parts, ",") + ","
+	}
+}
+
+// RequirementColumns req_* 列的当前值，用于整列覆盖更新
+func (c *Client) RequirementColumns() map[string]interface{} {
+	return map[string]interface{}{
+		"req_min_age":        c.ReqMinAge,
+		"req_max_age":        c.ReqMaxAge,
+		"req_min_height":     c.ReqMinHeight,
+		"req_max_height":     c.ReqMaxHeight,
+		"req_min_income":     c.ReqMinIncome,
+		"req_education":      c.ReqEducation,
+		"req_marital_status": c.ReqMaritalStatus,
+		"req_house_status":   c.ReqHouseStatus,
+	}
+}
+
+// AppendRequirementFilter 追加双向择偶要求 SQL 条件：
+// 正向用 source 的要求约束候选人字段，反向用候选人的 req_* 列约束 source。
+// 与 Check 一致，任一方字段缺失(0/NULL)时该项不参与过滤。
+func AppendRequirementFilter(clause *biz.WhereClause, source *Client, mode RequirementMode) {
+	relaxed := mode != RequirementStrict
+	add := func(where string, args ...interface{}) {
+		if clause.Where != "" {
+			clause.Where += " AND "
+		}
+		clause.Where += where
+		clause.Args = append(clause.Args, args...)
+	}
+
+	ageSlack, heightSlack, eduSlack, incomeRatio := 0, 0, int8(0), 1.0
+	if relaxed {
+		ageSlack, heightSlack, eduSlack, incomeRatio = relaxedAgeSlack, relaxedHeightSlack, relaxedEducationSlack, relaxedIncomeRatio
+	}
+
+	// 1. 正向：候选人满足 source 的要求
+	if r := source.ParseRequirements(); r != nil {
+		if r.MinAge > 0 {
+			add("(age IS NULL OR age = 0 OR age >= ?)", r.MinAge-ageSlack)
+		}
+		if r.MaxAge > 0 {
+			add("(age IS NULL OR age = 0 OR age <= ?)", r.MaxAge+ageSlack)
+		}
+		if r.MinHeight > 0 {
+			add("(height IS NULL OR height = 0 OR height >= ?)", r.MinHeight-heightSlack)
+		}
+		if r.MaxHeight > 0 {
+			add("(height IS NULL OR height = 0 OR height <= ?)", r.MaxHeight+heightSlack)
+		}
+		if r.MinIncome > 0 {
+			add("(income IS NULL OR income = 0 OR income >= ?)", int(float64(r.MinIncome)*incomeRatio))
+		}
+		if r.Education > 0 {
+			add("(education IS NULL OR education = 0 OR education >= ?)", r.Education-eduSlack)
+		}
+		if !relaxed && len(r.MaritalStatus) > 0 {
+			add("(marital_status IS NULL OR marital_status = 0 OR marital_status IN ?)", r.MaritalStatus)
+		}
+		if !relaxed && r.HouseStatus >= 2 {
+			add("(house_status IS NULL OR house_status = 0 OR house_status >= 2)")
+		}
+	}
+
+	// 2. 反向：source 满足候选人的要求
+	if age := source.RealAge(); age > 0 {
+		add("(req_min_age = 0 OR req_min_age <= ?)", age+ageSlack)
+		add("(req_max_age = 0 OR req_max_age >= ?)", age-ageSlack)
+	}
+	if source.Height > 0 {
+		add("(req_min_height = 0 OR req_min_height <= ?)", source.Height+heightSlack)
+		add("(req_max_height = 0 OR req_max_height >= ?)", source.Height-heightSlack)
+	}
+	if source.Income > 0 {
+		add("(req_min_income = 0 OR req_min_income * ? <= ?)", incomeRatio, source.Income)
+	}
+	if source.Education > 0 {
+		add("(req_education = 0 OR req_education <= ?)", source.Education+eduSlack)
+	}
+	if !relaxed && source.MaritalStatus > 0 {
+		add("(req_marital_status = '' OR req_marital_status LIKE ?)", fmt.Sprintf("%%,%d,%%", source.MaritalStatus))
+	}
+	if !relaxed && source.HouseStatus == 1 {
+		add("req_house_status < 2")
+	}
+}
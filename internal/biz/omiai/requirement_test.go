@@ -0,0 +1,63 @@
+package biz_omiai
+
+import (
+	"testing"
+
+	"omiai-server/internal/biz"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestAppendRequirementFilter(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	assert.NoError(t, db.AutoMigrate(&Client{}))
+
+	source := &Client{ID: 1, Gender: 1, Age: 32, Height: 175, Income: 12000, Education: 3, MaritalStatus: 1, HouseStatus: 1,
+		PartnerRequirements: `{"min_age":25,"max_age":30,"min_height":160,"marital_status":[1]}`}
+	seeds := []*Client{
+		{ID: 2, Name: "ok", Gender: 2, Age: 28, Height: 165, MaritalStatus: 1},
+		{ID: 3, Name: "too old", Gender: 2, Age: 36, Height: 165, MaritalStatus: 1},
+		{ID: 4, Name: "slightly old", Gender: 2, Age: 31, Height: 165, MaritalStatus: 1},
+		{ID: 5, Name: "divorced", Gender: 2, Age: 27, Height: 165, MaritalStatus: 3},
+		{ID: 6, Name: "wants taller", Gender: 2, Age: 27, Height: 165, MaritalStatus: 1, PartnerRequirements: `{"min_height":180}`},
+		{ID: 7, Name: "wants house", Gender: 2, Age: 27, Height: 165, MaritalStatus: 1, PartnerRequirements: `{"house_status":2}`},
+		{ID: 8, Name: "unknown age", Gender: 2, Height: 165, MaritalStatus: 1},
+	}
+	for _, c := range seeds {
+		c.SyncRequirementColumns()
+		assert.NoError(t, db.Create(c).Error)
+	}
+
+	ids := func(mode RequirementMode) []uint64 {
+		clause := &biz.WhereClause{Where: "gender = ?", Args: []interface{}{2}}
+		AppendRequirementFilter(clause, source, mode)
+		var out []uint64
+		assert.NoError(t, db.Model(&Client{}).Where(clause.Where, clause.Args...).Order("id").Pluck("id", &out).Error)
+		return out
+	}
+
+	assert.Equal(t, []uint64{2, 8}, ids(RequirementStrict))
+	// 宽松模式：年龄容差 2 岁，婚况与房产仅提示不过滤
+	assert.Equal(t, []uint64{2, 4, 5, 7, 8}, ids(RequirementRelaxed))
+
+	// 内存校验与 SQL 过滤结果一致
+	for _, mode := range []RequirementMode{RequirementStrict, RequirementRelaxed} {
+		var passed []uint64
+		for _, c := range seeds {
+			if len(MutualViolationsMode(source, c, mode)) == 0 {
+				passed = append(passed, c.ID)
+			}
+		}
+		assert.Equal(t, ids(mode), passed, mode)
+	}
+
+	violations := MutualViolations(source, seeds[4])
+	assert.Len(t, violations, 1)
+	assert.Equal(t, RequirementSideCandidate, violations[0].Side)
+	assert.Equal(t, "对方要求：身高不符(175cm)", violations[0].Message)
+}
@@ -0,0 +1,30 @@
+package biz_omiai
+
+// DimensionScore 单个评分维度的结果
+type DimensionScore struct {
+	Name         string      `json:"name"`                 // 维度标识，如 age/education
+	Label        string      `json:"label"`                // 维度中文名
+	Score        float64     `json:"score"`                // 维度原始得分 0-100
+	Weight       float64     `json:"weight"`               // 归一化后的权重
+	Contribution float64     `json:"contribution"`         // 对总分的贡献 = Score * Weight
+	Reason       string      `json:"reason"`               // 中文说明
+	Tags         []string    `json:"tags,omitempty"`       // 该维度产生的匹配标签
+	Violations   []string    `json:"violations,omitempty"` // 该维度发现的择偶要求不满足项
+	Detail       interface{} `json:"-"`                    // 维度自定义明细，如地域维度的 *RegionMatch
+}
+
+// ScoreResult 一对客户的综合评分结果
+type ScoreResult struct {
+	Score      int               `json:"score"`      // 总分 0-100
+	Algorithm  string            `json:"algorithm"`  // 算法标识
+	Tags       []string          `json:"tags"`       // 匹配标签
+	Violations []string          `json:"violations"` // 择偶要求不满足项
+	Dimensions []*DimensionScore `json:"dimensions"` // 各维度明细
+}
+
+// Scorer 匹配评分器，所有候选人来源统一通过它计算得分
+// 评分与参数顺序无关：Score(a, b) 与 Score(b, a) 结果一致
+type Scorer interface {
+	Name() string
+	Score(client, candidate *Client) *ScoreResult
+}
@@ -0,0 +1,64 @@
+package biz_omiai
+
+import (
+	"context"
+	"time"
+)
+
+// SearchField 参与全文检索的字段，Weight 越大命中时排名越靠前
+type SearchField struct {
+	Name   string  `json:"name"`
+	Label  string  `json:"label"`
+	Weight float64 `json:"-"`
+}
+
+// 检索字段名，除 follow_up、tags 外与客户字段的列名一致
+const (
+	SearchFieldFollowUp = "follow_up"
+	SearchFieldTags     = "tags"
+)
+
+var SearchFields = []SearchField{
+	{"name", "姓名", 5},
+	{SearchFieldTags, "标签", 3},
+	{"profession", "具体工作", 3},
+	{"work_unit", "工作单位", 3},
+	{"position", "职位", 2},
+	{"work_city", "工作城市", 2},
+	{"address", "家庭住址", 2},
+	{"house_address", "买房地址", 1},
+	{"parents_profession", "父母工作", 2},
+	{"family_description", "家庭成员描述", 1},
+	{"remark", "红娘备注", 1},
+	{"partner_requirements", "择偶要求", 1},
+	{SearchFieldFollowUp, "回访记录", 1},
+}
+
+// SearchDocument 一位客户参与检索的文本，字段名 => 文本
+type SearchDocument struct {
+	ClientID uint64
+	Fields   map[string]string
+}
+
+// SearchHit 检索结果，按 Score 倒序
+type SearchHit struct {
+	ClientID   uint64             `json:"client_id"`
+	Score      float64            `json:"score"`
+	Highlights []*SearchHighlight `json:"highlights"`
+}
+
+// SearchHighlight 命中字段的摘要，关键词以 <em> 标出，其余文本已做 HTML 转义
+type SearchHighlight struct {
+	Field   string `json:"field"`
+	Label   string `json:"label"`
+	Snippet string `json:"snippet"`
+}
+
+type SearchInterface interface {
+	// Documents 读取客户的检索文本，afterID 之后按 ID 顺序分批；回收站中的客户不返回
+	Documents(ctx context.Context, afterID uint64, limit int) ([]*SearchDocument, error)
+	// DocumentsByIDs 读取指定客户的检索文本，不存在或在回收站中的客户不返回
+	DocumentsByIDs(ctx context.Context, ids []uint64) ([]*SearchDocument, error)
+	// Changed since 之后资料变更、删除或新增回访记录的客户
+	Changed(ctx context.Context, since time.Time) ([]uint64, error)
+}
@@ -0,0 +1,177 @@
+package biz_omiai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"omiai-server/internal/biz"
+)
+
+var (
+	ErrSegmentExists    = errors.New("分群名称已存在")
+	ErrSegmentForbidden = errors.New("仅创建人或管理员可修改该分群")
+)
+
+// ClientFilter 客户筛选条件，客户列表与分群共用；分群保存其 JSON，每次按当前数据求值
+type ClientFilter struct {
+	Q              string   `json:"q,omitempty" form:"q"` // 全文检索关键词，空格分隔需全部命中
+	Name           string   `json:"name,omitempty" form:"name"`
+	Phone          string   `json:"phone,omitempty" form:"phone"`
+	Gender         int8     `json:"gender,omitempty" form:"gender"`
+	MinAge         int      `json:"min_age,omitempty" form:"min_age"`
+	MaxAge         int      `json:"max_age,omitempty" form:"max_age"`
+	MinHeight      int      `json:"min_height,omitempty" form:"min_height"`
+	MaxHeight      int      `json:"max_height,omitempty" form:"max_height"`
+	MinIncome      int      `json:"min_income,omitempty" form:"min_income"`
+	Education      int8     `json:"education,omitempty" form:"education"` // 最低学历
+	Address        string   `json:"address,omitempty" form:"address"`
+	Profession     string   `json:"profession,omitempty" form:"profession"`
+	WorkCity       string   `json:"work_city,omitempty" form:"work_city"`
+	Status         int8     `json:"status,omitempty" form:"status"`
+	MaritalStatus  int8     `json:"marital_status,omitempty" form:"marital_status"`
+	HouseStatus    int8     `json:"house_status,omitempty" form:"house_status"`
+	CarStatus      int8     `json:"car_status,omitempty" form:"car_status"`
+	Tags           string   `json:"tags,omitempty" form:"tags"`                                          // 标签名或同义词，逗号分隔，精确匹配
+	TagIDs         []uint64 `json:"tag_ids,omitempty" form:"tag_ids"`                                    // 标签ID
+	TagMode        string   `json:"tag_mode,omitempty" form:"tag_mode" binding:"omitempty,oneof=and or"` // and 包含全部标签，默认 or 包含任一
+	NoFollowUpDays int      `json:"no_follow_up_days,omitempty" form:"no_follow_up_days" binding:"omitempty,min=1,max=3650"`
+}
+
+// Clause 按资料字段生成查询条件；标签名称与关键词需查询标签字典和检索索引，由调用方追加
+func (f *ClientFilter) Clause(now time.Time) *biz.WhereClause {
+	clause := &biz.WhereClause{
+		OrderBy: "created_at desc",
+		Where:   "1=1",
+		Args:    []interface{}{},
+	}
+	like := func(column, value string) {
+		if value != "" {
+			clause.Where += fmt.Sprintf(" AND %s LIKE ?", column)
+			clause.Args = append(clause.Args, "%"+value+"%")
+		}
+	}
+	cmp := func(column, op string, value int) {
+		if value > 0 {
+			clause.Where += fmt.Sprintf(" AND %s %s ?", column, op)
+			clause.Args = append(clause.Args, value)
+		}
+	}
+
+	like("name", f.Name)
+	like("phone", f.Phone)
+	like("address", f.Address)
+	like("profession", f.Profession)
+	like("work_city", f.WorkCity)
+	cmp("gender", "=", int(f.Gender))
+	cmp("height", ">=", f.MinHeight)
+	cmp("height", "<=", f.MaxHeight)
+	cmp("income", ">=", f.MinIncome)
+	cmp("education", ">=", int(f.Education)) // 学历取值越大越高
+	cmp("marital_status", "=", int(f.MaritalStatus))
+	cmp("house_status", "=", int(f.HouseStatus))
+	cmp("car_status", "=", int(f.CarStatus))
+	cmp("status", "=", int(f.Status))
+
+	// 年龄按生日换算：满 MinAge 岁即生日不晚于 MinAge 年前的今天，不超过 MaxAge 岁即生日晚于 MaxAge+1 年前的今天
+	if f.MinAge > 0 {
+		clause.Where += " AND birthday <= ?"
+		clause.Args = append(clause.Args, now.AddDate(-f.MinAge, 0, 0).Format("2006-01-02"))
+	}
+	if f.MaxAge > 0 {
+		clause.Where += " AND birthday > ?"
+		clause.Args = append(clause.Args, now.AddDate(-f.MaxAge-1, 0, 0).Format("2006-01-02"))
+	}
+
+	// N 天未跟进：录入已满 N 天，且 N 天内双方所在的情侣档案都没有回访记录
+	if f.NoFollowUpDays > 0 {
+		since := now.AddDate(0, 0, -f.NoFollowUpDays)
+		clause.Where += " AND created_at <= ? AND id NOT IN (SELECT m.male_client_id FROM match_record m JOIN follow_up_record f ON f.match_record_id = m.id" +
+			" WHERE f.follow_up_date >= ? AND f.deleted_at IS NULL UNION SELECT m.female_client_id FROM match_record m JOIN follow_up_record f" +
+			" ON f.match_record_id = m.id WHERE f.follow_up_date >= ? AND f.deleted_at IS NULL)"
+		clause.Args = append(clause.Args, since, since, since)
+	}
+	return clause
+}
+
+// ClientSegment 保存的客户分群，成员按筛选条件动态计算；
+// 成员快照仅用于比对进出分群的客户，定时刷新
+type ClientSegment struct {
+	ID          uint64        `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	Name        string        `json:"name" gorm:"column:name;size:64;uniqueIndex;comment:分群名称"`
+	Description string        `json:"description" gorm:"column:description;size:255;comment:说明"`
+	Filter      string        `json:"-" gorm:"column:filter;type:text;comment:筛选条件(JSON)"`
+	Shared      bool          `json:"shared" gorm:"column:shared;default:false;comment:是否共享给全部红娘"`
+	OwnerID     uint64        `json:"owner_id" gorm:"column:owner_id;index;comment:创建人ID"`
+	OwnerName   string        `json:"owner_name" gorm:"column:owner_name;size:64;comment:创建人"`
+	MemberCount int64         `json:"member_count" gorm:"column:member_count;default:0;comment:上次刷新时的成员数"`
+	RefreshedAt *time.Time    `json:"refreshed_at" gorm:"column:refreshed_at;comment:上次刷新成员的时间"`
+	CreatedAt   time.Time     `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt   time.Time     `json:"updated_at" gorm:"column:updated_at"`
+	Conditions  *ClientFilter `json:"filter" gorm:"-"`
+	Count       *int64        `json:"count,omitempty" gorm:"-"` // 实时人数
+}
+
+func (t *ClientSegment) TableName() string {
+	return "client_segment"
+}
+
+// Decode 解析筛选条件
+func (t *ClientSegment) Decode() {
+	t.Conditions = &ClientFilter{}
+	if t.Filter != "" {
+		_ = json.Unmarshal([]byte(t.Filter), t.Conditions)
+	}
+}
+
+// SetConditions 写入筛选条件
+func (t *ClientSegment) SetConditions(f *ClientFilter) {
+	if f == nil {
+		f = &ClientFilter{}
+	}
+	t.Conditions = f
+	raw, _ := json.Marshal(f)
+	t.Filter = string(raw)
+}
+
+// Visible 共享的分群所有人可见，私有分群仅创建人与管理员可见
+func (t *ClientSegment) Visible(userID uint64, admin bool) bool {
+	return admin || t.Shared || t.OwnerID == userID
+}
+
+// Editable 仅创建人与管理员可修改、删除
+func (t *ClientSegment) Editable(userID uint64, admin bool) bool {
+	return admin || t.OwnerID == userID
+}
+
+// ClientSegmentMember 分群成员快照
+type ClientSegmentMember struct {
+	ID        uint64    `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	SegmentID uint64    `json:"segment_id" gorm:"column:segment_id;uniqueIndex:idx_segment_member;comment:分群ID"`
+	ClientID  uint64    `json:"client_id" gorm:"column:client_id;uniqueIndex:idx_segment_member;index;comment:客户ID"`
+	EnteredAt time.Time `json:"entered_at" gorm:"column:entered_at;comment:进入分群时间"`
+}
+
+func (t *ClientSegmentMember) TableName() string {
+	return "client_segment_member"
+}
+
+type SegmentInterface interface {
+	Select(ctx context.Context, clause *biz.WhereClause, offset, limit int) ([]*ClientSegment, int64, error)
+	Get(ctx context.Context, id uint64) (*ClientSegment, error)
+	All(ctx context.Context) ([]*ClientSegment, error)
+	Create(ctx context.Context, s *ClientSegment) error
+	// Update 修改名称、说明、筛选条件与共享设置
+	Update(ctx context.Context, s *ClientSegment) error
+	// Delete 删除分群及成员快照
+	Delete(ctx context.Context, id uint64) error
+
+	// Count 满足条件的客户数
+	Count(ctx context.Context, clause *biz.WhereClause) (int64, error)
+	// ClientIDs 满足条件的全部客户ID
+	ClientIDs(ctx context.Context, clause *biz.WhereClause) ([]uint64, error)
+	// SaveMembers 以 clientIDs 替换成员快照并更新成员数，返回新进入与离开的客户
+	SaveMembers(ctx context.Context, segmentID uint64, clientIDs []uint64, at time.Time) (entered, left []uint64, err error)
+}
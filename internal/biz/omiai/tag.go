@@ -0,0 +1,238 @@
+package biz_omiai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"omiai-server/internal/biz"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 标签来源
+const (
+	TagSourceManual = "manual" // 红娘手动添加
+	TagSourceAI     = "ai"     // AI 从聊天记录中提取
+	TagSourceImport = "import" // 批量导入
+)
+
+var TagSourceText = map[string]string{
+	TagSourceManual: "手动添加",
+	TagSourceAI:     "AI提取",
+	TagSourceImport: "批量导入",
+}
+
+// 标签筛选方式
+const (
+	TagMatchAny = "or"  // 包含任一标签
+	TagMatchAll = "and" // 包含全部标签
+)
+
+// TagConfidenceAI AI 提取的标签默认置信度，模型未给出置信度时使用
+const TagConfidenceAI = 0.7
+
+var (
+	ErrTagExists     = errors.New("标签名或同义词已被其他标签使用")
+	ErrTagMergeSelf  = errors.New("不能合并到自身")
+	ErrTagGroupInUse = errors.New("分组下仍有标签，不能删除")
+)
+
+// TagGroup 标签分组，如性格、爱好、客户等级、风险
+type TagGroup struct {
+	ID        uint64    `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	Name      string    `json:"name" gorm:"column:name;size:32;uniqueIndex;comment:分组名"`
+	Exclusive bool      `json:"exclusive" gorm:"column:exclusive;not null;default:false;comment:是否单选，如客户等级每位客户只保留一个"`
+	Scored    bool      `json:"scored" gorm:"column:scored;not null;default:false;comment:是否参与匹配评分的标签重合度"`
+	Sort      int       `json:"sort" gorm:"column:sort;not null;default:0;comment:排序"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"column:updated_at"`
+}
+
+func (t *TagGroup) TableName() string {
+	return "tag_group"
+}
+
+// Tag 标签字典，同义词在打标签时归一到该标签
+type Tag struct {
+	ID          uint64    `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	GroupID     uint64    `json:"group_id" gorm:"column:group_id;index;not null;default:0;comment:分组ID，0为未分组"`
+	Name        string    `json:"name" gorm:"column:name;size:32;uniqueIndex;comment:标签名"`
+	Synonyms    string    `json:"-" gorm:"column:synonyms;type:text;comment:同义词(JSON)"`
+	Sort        int       `json:"sort" gorm:"column:sort;not null;default:0;comment:排序"`
+	CreatedAt   time.Time `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"column:updated_at"`
+	SynonymList []string  `json:"synonyms" gorm:"-"`
+	ClientCount int64     `json:"client_count" gorm:"-"`
+
+	Group *TagGroup `json:"group,omitempty" gorm:"foreignKey:GroupID"`
+}
+
+func (t *Tag) TableName() string {
+	return "tag"
+}
+
+// Decode 解析同义词供展示
+func (t *Tag) Decode() {
+	t.SynonymList = []string{}
+	if t.Synonyms != "" {
+		_ = json.Unmarshal([]byte(t.Synonyms), &t.SynonymList)
+	}
+}
+
+// SetSynonyms 归一化并去重后写入同义词，与标签名相同的不保留
+func (t *Tag) SetSynonyms(list []string) {
+	seen := map[string]bool{NormalizeTagName(t.Name): true}
+	t.SynonymList = []string{}
+	for _, s := range list {
+		key := NormalizeTagName(s)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		t.SynonymList = append(t.SynonymList, strings.TrimSpace(s))
+	}
+	raw, _ := json.Marshal(t.SynonymList)
+	t.Synonyms = string(raw)
+}
+
+// Keys 标签名与同义词归一化后的值
+func (t *Tag) Keys() []string {
+	if t.SynonymList == nil {
+		t.Decode()
+	}
+	keys := []string{NormalizeTagName(t.Name)}
+	for _, s := range t.SynonymList {
+		keys = append(keys, NormalizeTagName(s))
+	}
+	return keys
+}
+
+// Scored 标签所在分组是否参与匹配评分，需预加载分组
+func (t *Tag) Scored() bool {
+	return t.Group != nil && t.Group.Scored
+}
+
+// ClientTag 客户与标签的关联
+type ClientTag struct {
+	ID         uint64    `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ClientID   uint64    `json:"client_id" gorm:"column:client_id;uniqueIndex:idx_client_tag;comment:客户ID"`
+	TagID      uint64    `json:"tag_id" gorm:"column:tag_id;uniqueIndex:idx_client_tag;index;comment:标签ID"`
+	Source     string    `json:"source" gorm:"column:source;size:16;comment:来源 manual/ai/import"`
+	Confidence float64   `json:"confidence" gorm:"column:confidence;not null;default:1;comment:置信度 0-1，手动添加为 1"`
+	Operator   string    `json:"operator" gorm:"column:operator;size:64;comment:操作人"`
+	CreatedAt  time.Time `json:"created_at" gorm:"column:created_at"`
+
+	Tag *Tag `json:"tag,omitempty" gorm:"foreignKey:TagID"`
+}
+
+func (t *ClientTag) TableName() string {
+	return "client_tag"
+}
+
+// ClientTagsPreload 读取客户时预加载标签及其分组
+const ClientTagsPreload = "TagLinks.Tag.Group"
+
+// ScoringPreloads 预加载评分所需的性格画像、资料向量与标签，用法 db.Scopes(ScoringPreloads)
+func ScoringPreloads(db *gorm.DB) *gorm.DB {
+	return db.Preload("Profile").Preload("Embedding").Preload(ClientTagsPreload)
+}
+
+// NormalizeTagName 去掉首尾空白与 # 号，英文统一小写
+func NormalizeTagName(name string) string {
+	return strings.ToLower(strings.Trim(strings.TrimSpace(name), "#＃ "))
+}
+
+// ParseTags 解析标签文本，兼容 JSON 数组与逗号/顿号/空格分隔
+func ParseTags(raw string) []string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil
+	}
+	var tags []string
+	if strings.HasPrefix(raw, "[") && json.Unmarshal([]byte(raw), &tags) == nil {
+		return tags
+	}
+	for _, t := range strings.FieldsFunc(raw, func(r rune) bool {
+		return r == ',' || r == '，' || r == '、' || r == ' '
+	}) {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// TagSourceFrom 按客户资料的变更来源确定标签来源
+func TagSourceFrom(ctx context.Context) string {
+	switch source, _ := ClientChangeFrom(ctx); source {
+	case ClientSourceImport:
+		return TagSourceImport
+	case ClientSourceAITag:
+		return TagSourceAI
+	default:
+		return TagSourceManual
+	}
+}
+
+// AppendTagFilter 按标签 ID 精确筛选客户，mode 为 and 时须包含全部标签
+func AppendTagFilter(clause *biz.WhereClause, tagIDs []uint64, mode string) {
+	if len(tagIDs) == 0 {
+		return
+	}
+	if mode == TagMatchAll {
+		clause.Where += " AND id IN (SELECT client_id FROM client_tag WHERE tag_id IN ? GROUP BY client_id HAVING COUNT(DISTINCT tag_id) = ?)"
+		clause.Args = append(clause.Args, tagIDs, len(tagIDs))
+		return
+	}
+	clause.Where += " AND id IN (SELECT client_id FROM client_tag WHERE tag_id IN ?)"
+	clause.Args = append(clause.Args, tagIDs)
+}
+
+// TagsText 标签名的 JSON 数组，与 Tags 输入的格式一致，供编辑表单回填
+func (c *Client) TagsText() string {
+	tags := c.TagList()
+	if tags == nil {
+		tags = []string{}
+	}
+	raw, _ := json.Marshal(tags)
+	return string(raw)
+}
+
+// ScoredTags 客户参与匹配评分的标签名，需预加载 ClientTagsPreload
+func (c *Client) ScoredTags() map[uint64]string {
+	tags := make(map[uint64]string)
+	for _, link := range c.TagLinks {
+		if link.Tag != nil && link.Tag.Scored() {
+			tags[link.TagID] = link.Tag.Name
+		}
+	}
+	return tags
+}
+
+type TagInterface interface {
+	Groups(ctx context.Context) ([]*TagGroup, error)
+	SaveGroup(ctx context.Context, g *TagGroup) error
+	DeleteGroup(ctx context.Context, id uint64) error
+
+	// Select 标签列表，附带使用该标签的客户数
+	Select(ctx context.Context, clause *biz.WhereClause, offset, limit int) ([]*Tag, int64, error)
+	Get(ctx context.Context, id uint64) (*Tag, error)
+	// Create 新建标签，标签名与同义词不能与已有标签重复
+	Create(ctx context.Context, t *Tag) error
+	Update(ctx context.Context, t *Tag) error
+	// Delete 删除标签及其客户关联
+	Delete(ctx context.Context, id uint64) error
+	// Merge 将 source 的客户关联转到 target，source 的名称与同义词并入 target 的同义词后删除 source
+	Merge(ctx context.Context, sourceID, targetID uint64) (*Tag, error)
+	// Resolve 按标签名或同义词查找标签，create 为 true 时为未收录的名称新建未分组标签；
+	// 返回找到的标签与未收录的名称
+	Resolve(ctx context.Context, names []string, create bool) ([]*Tag, []string, error)
+
+	ClientTags(ctx context.Context, clientID uint64) ([]*ClientTag, error)
+	// SetClientTags 将客户的标签替换为 tagIDs，保留已有关联的来源与置信度
+	SetClientTags(ctx context.Context, clientID uint64, tagIDs []uint64, source, operator string) error
+	// AddClientTags 为客户追加标签，已有关联不变
+	AddClientTags(ctx context.Context, clientID uint64, tagIDs []uint64, source string, confidence float64, operator string) error
+}
@@ -21,21 +21,60 @@ var globalConfig = new(Config)
 
 // Config Global conf .
 type Config struct {
-	Domain   *Domain           `json:"domain,omitempty"`
-	Debug    bool              `json:"debug,omitempty"`
-	Cron     bool              `json:"cron,omitempty"`
-	Env      string            `json:"env"`
-	Log      *Logger           `json:"log,omitempty"`
-	Server   *Server           `json:"server,omitempty"`
-	Database *Database         `json:"database,omitempty"`
-	Cache    *Cache            `json:"cache"`
-	Runtime  *Runtime          `json:"runtime"`
-	Redis    *Redis            `json:"redis"`
-	Queue    *Queue            `json:"queue"`
-	Track    track.ManagerConf `json:"track"`
-	Storage  *Storage          `json:"storage"`
-	CronConf *Cron             `json:"cron_conf" mapstructure:"cron_conf"`
-	LLM      *LLM              `json:"llm" mapstructure:"llm"`
+	Domain    *Domain           `json:"domain,omitempty"`
+	Debug     bool              `json:"debug,omitempty"`
+	Cron      bool              `json:"cron,omitempty"`
+	Env       string            `json:"env"`
+	Log       *Logger           `json:"log,omitempty"`
+	Server    *Server           `json:"server,omitempty"`
+	Database  *Database         `json:"database,omitempty"`
+	Cache     *Cache            `json:"cache"`
+	Runtime   *Runtime          `json:"runtime"`
+	Redis     *Redis            `json:"redis"`
+	Queue     *Queue            `json:"queue"`
+	Track     track.ManagerConf `json:"track"`
+	Storage   *Storage          `json:"storage"`
+	CronConf  *Cron             `json:"cron_conf" mapstructure:"cron_conf"`
+	LLM       *LLM              `json:"llm" mapstructure:"llm"`
+	Match     *Match            `json:"match" mapstructure:"match"`
+	Embedding *Embedding        `json:"embedding" mapstructure:"embedding"`
+	Recycle   *Recycle          `json:"recycle" mapstructure:"recycle"`
+}
+
+// Recycle 回收站配置
+type Recycle struct {
+	RetentionDays int `json:"retention_days" mapstructure:"retention_days"` // 删除的客户在回收站保留的天数，到期由定时任务彻底删除
+}
+
+// Embedding 客户资料文本向量化配置
+type Embedding struct {
+	Provider string `json:"provider"`                       // tfidf(默认，离线计算)/remote
+	Endpoint string `json:"endpoint"`                       // remote: OpenAI 兼容的 embeddings 接口地址
+	APIKey   string `json:"api_key" mapstructure:"api_key"` // remote: 接口密钥
+	Model    string `json:"model"`                          // remote: 模型名称
+	Timeout  int    `json:"timeout"`                        // remote: 请求超时（秒），默认 10
+}
+
+// Match 匹配算法相关配置
+type Match struct {
+	Weights             map[string]float64 `json:"weights"`                                                    // 评分维度权重，key 为维度标识，未配置的维度使用默认权重
+	BreakupCooldownDays int                `json:"breakup_cooldown_days" mapstructure:"breakup_cooldown_days"` // 分手后双方暂停推荐的天数
+	Experiments         []*Experiment      `json:"experiments"`                                                // 推荐算法 A/B 实验，同一时间仅第一个启用的实验生效
+}
+
+// Experiment 推荐算法 A/B 实验
+type Experiment struct {
+	Key      string               `json:"key"`      // 实验标识，用于归因统计，上线后不要修改
+	Enabled  bool                 `json:"enabled"`  // 是否启用
+	Unit     string               `json:"unit"`     // 分流单位 client(默认)/matchmaker
+	Variants []*ExperimentVariant `json:"variants"` // 实验分组
+}
+
+// ExperimentVariant 实验分组
+type ExperimentVariant struct {
+	Name    string             `json:"name"`    // 分组名，如 control/treatment
+	Traffic int                `json:"traffic"` // 流量占比（相对值）
+	Weights map[string]float64 `json:"weights"` // 覆盖 match.weights 中的对应维度，为空时与线上权重一致
 }
 
 type VolcanoEngine struct {
@@ -1,6 +1,8 @@
 package ai
 
 import (
+	"strings"
+
 	biz_omiai "omiai-server/internal/biz/omiai"
 	"omiai-server/internal/data"
 	aiservice "omiai-server/internal/service/ai"
@@ -14,18 +16,27 @@ import (
 type Controller struct {
 	db         *data.DB
 	clientRepo biz_omiai.ClientInterface
+	tagRepo    biz_omiai.TagInterface
 	aiAnalyzer *aiservice.AIAnalyzer
 }
 
 // NewController 创建AI控制器
-func NewController(db *data.DB, clientRepo biz_omiai.ClientInterface) *Controller {
+func NewController(db *data.DB, clientRepo biz_omiai.ClientInterface, tagRepo biz_omiai.TagInterface) *Controller {
 	return &Controller{
 		db:         db,
 		clientRepo: clientRepo,
+		tagRepo:    tagRepo,
 		aiAnalyzer: aiservice.NewAIAnalyzer(),
 	}
 }
 
+// chatSummaryResponse 聊天摘要；指定客户时提取的标签按标签字典写入客户
+type chatSummaryResponse struct {
+	*aiservice.ChatSummaryResult
+	AppliedTags   []string `json:"applied_tags,omitempty"`   // 已写入客户的标签
+	UnmatchedTags []string `json:"unmatched_tags,omitempty"` // 标签字典未收录，需红娘确认后添加
+}
+
 // AnalyzeMatch AI匹配分析
 func (c *Controller) AnalyzeMatch(ctx *gin.Context) {
 	var req validates.AIAnalyzeValidate
@@ -99,6 +110,7 @@ func (c *Controller) GetIceBreaker(ctx *gin.Context) {
 func (c *Controller) ChatSummary(ctx *gin.Context) {
 	var req struct {
 		ChatContent string `json:"chat_content" binding:"required"`
+		ClientID    uint64 `json:"client_id"` // 可选，提取的标签写入该客户
 	}
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		response.ValidateError(ctx, err, response.ValidateCommonError)
@@ -110,8 +122,31 @@ func (c *Controller) ChatSummary(ctx *gin.Context) {
 		response.ErrorResponse(ctx, response.FuncCommonError, "生成聊天摘要失败: "+err.Error())
 		return
 	}
+	resp := &chatSummaryResponse{ChatSummaryResult: result}
+	if req.ClientID == 0 || len(result.ExtractedTags) == 0 {
+		response.SuccessResponse(ctx, "生成成功", resp)
+		return
+	}
 
-	response.SuccessResponse(ctx, "生成成功", result)
+	// 只写入标签字典已收录的标签（含同义词），避免模型生成的措辞污染字典
+	tags, missing, err := c.tagRepo.Resolve(ctx, result.ExtractedTags, false)
+	if err != nil {
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "匹配标签失败")
+		return
+	}
+	ids := make([]uint64, 0, len(tags))
+	for _, t := range tags {
+		ids = append(ids, t.ID)
+		resp.AppliedTags = append(resp.AppliedTags, t.Name)
+	}
+	resp.UnmatchedTags = missing
+	if len(ids) > 0 {
+		if err := c.tagRepo.AddClientTags(ctx, req.ClientID, ids, biz_omiai.TagSourceAI, biz_omiai.TagConfidenceAI, "AI"); err != nil {
+			response.ErrorResponse(ctx, response.DBUpdateCommonError, "写入客户标签失败")
+			return
+		}
+	}
+	response.SuccessResponse(ctx, "生成成功", resp)
 }
 
 // convertToProfile 将Client转换为AI分析用的Profile
@@ -141,7 +176,7 @@ func convertToProfile(client *biz_omiai.Client) *aiservice.ClientProfile {
 		FamilyDescription:   client.FamilyDescription,
 		PartnerRequirements: client.PartnerRequirements,
 		Remark:              client.Remark,
-		Tags:                client.Tags,
+		Tags:                strings.Join(client.TagList(), "、"),
 	}
 }
 
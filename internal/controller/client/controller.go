@@ -1,17 +1,53 @@
 package client
 
 import (
+	"context"
+	"fmt"
+
 	biz_omiai "omiai-server/internal/biz/omiai"
 	"omiai-server/internal/data"
 	"omiai-server/internal/service/chat_parser"
+	"omiai-server/internal/service/duplicate"
+	"omiai-server/internal/service/embedding"
+	"omiai-server/internal/service/experiment"
+	"omiai-server/internal/service/segment"
+
+	"github.com/gin-gonic/gin"
 )
 
 type Controller struct {
 	db                *data.DB
 	client            biz_omiai.ClientInterface
 	chatParserService *chat_parser.ChatParser
+	scorer            biz_omiai.Scorer
+	experiments       *experiment.Service
+	embeddings        *embedding.Service
+	user              biz_omiai.UserInterface
+	duplicates        *duplicate.Service
+	tags              biz_omiai.TagInterface
+	segments          *segment.Service
+	segmentRepo       biz_omiai.SegmentInterface
+}
+
+func NewController(db *data.DB, client biz_omiai.ClientInterface, chatParserService *chat_parser.ChatParser, scorer biz_omiai.Scorer,
+	experiments *experiment.Service, embeddings *embedding.Service, user biz_omiai.UserInterface, duplicates *duplicate.Service,
+	tags biz_omiai.TagInterface, segments *segment.Service, segmentRepo biz_omiai.SegmentInterface) *Controller {
+	return &Controller{db: db, client: client, chatParserService: chatParserService, scorer: scorer, experiments: experiments,
+		embeddings: embeddings, user: user, duplicates: duplicates, tags: tags, segments: segments, segmentRepo: segmentRepo}
+}
+
+func (c *Controller) operatorName(ctx *gin.Context) string {
+	id := ctx.GetUint64("user_id")
+	if id == 0 {
+		return "Admin"
+	}
+	if user, err := c.user.GetByID(ctx, id); err == nil && user != nil {
+		return user.Nickname
+	}
+	return fmt.Sprintf("User:%d", id)
 }
 
-func NewController(db *data.DB, client biz_omiai.ClientInterface, chatParserService *chat_parser.ChatParser) *Controller {
-	return &Controller{db: db, client: client, chatParserService: chatParserService}
+// changeContext 标记本次请求写入客户资料的来源与操作人，用于记录变更历史
+func (c *Controller) changeContext(ctx *gin.Context, source string) context.Context {
+	return biz_omiai.WithClientChange(ctx, source, c.operatorName(ctx))
 }
@@ -1,6 +1,8 @@
 package client
 
 import (
+	"context"
+
 	biz_omiai "omiai-server/internal/biz/omiai"
 	"omiai-server/internal/validates"
 	"omiai-server/pkg/response"
@@ -60,6 +62,8 @@ func (c *Controller) Create(ctx *gin.Context) {
 		HouseCityCode:       req.HouseCityCode,
 		HouseDistrictCode:   req.HouseDistrictCode,
 		CarStatus:           req.CarStatus,
+		Relocation:          req.Relocation,
+		LongDistance:        req.LongDistance,
 		PartnerRequirements: req.PartnerRequirements,
 		Remark:              req.Remark,
 		Photos:              req.Photos,
@@ -113,11 +117,22 @@ func (c *Controller) Create(ctx *gin.Context) {
 	// 由于 Tags 目前是 string，建议存储 JSON 数组
 	// TODO: 需要引入 encoding/json 处理 Tags 字段的合并
 
-	if err := c.client.Create(ctx, client); err != nil {
+	// 未登录时来自邀请页表单，由客户本人填写
+	var changeCtx context.Context
+	if ctx.GetUint64("user_id") == 0 {
+		changeCtx = biz_omiai.WithClientChange(ctx, biz_omiai.ClientSourceInvite, req.Name)
+	} else {
+		changeCtx = c.changeContext(ctx, biz_omiai.ClientSourceAdmin)
+	}
+	if err := c.client.Create(changeCtx, client); err != nil {
 		log.WithContext(ctx).Errorf("Client Create failed: %v", err)
 		response.ErrorResponse(ctx, response.DBInsertCommonError, "创建客户档案失败")
 		return
 	}
+	// 疑似重复的档案进入审核队列，不阻止提交
+	if _, err := c.duplicates.Detect(ctx, client); err != nil {
+		log.WithContext(ctx).Errorf("Client Create detect duplicate client:%d err:%v", client.ID, err)
+	}
 
 	response.SuccessResponse(ctx, "创建成功", client)
 }
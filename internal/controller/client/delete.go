@@ -49,7 +49,7 @@ func (c *Controller) Delete(ctx *gin.Context) {
 	}
 
 	// 执行删除（使用事务）
-	if err := c.client.DeleteWithTx(ctx, id); err != nil {
+	if err := c.client.DeleteWithTx(c.changeContext(ctx, biz_omiai.ClientSourceAdmin), id); err != nil {
 		response.ErrorResponse(ctx, response.DBDeleteCommonError, "删除客户失败")
 		return
 	}
@@ -59,11 +59,17 @@ func (c *Controller) Detail(ctx *gin.Context) {
 		HouseCityCode:       client.HouseCityCode,
 		HouseDistrictCode:   client.HouseDistrictCode,
 		CarStatus:           client.CarStatus,
+		Relocation:          client.Relocation,
+		LongDistance:        client.LongDistance,
 		Status:              client.Status,
 		PartnerID:           partnerID,
 		PartnerRequirements: client.PartnerRequirements,
 		Remark:              client.Remark,
 		Photos:              client.Photos,
+		Tags:                client.TagsText(),
+		TagLinks:            client.TagLinks,
+		Completeness:        client.Completeness,
+		QualityIssues:       client.DecodeQuality(),
 		CreatedAt:           client.CreatedAt,
 		UpdatedAt:           client.UpdatedAt,
 	}
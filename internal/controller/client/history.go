@@ -0,0 +1,111 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/validates"
+	"omiai-server/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iWuxc/go-wit/log"
+	"gorm.io/gorm"
+)
+
+// versionView 资料版本，附带来源说明
+type versionView struct {
+	*biz_omiai.ClientVersion
+	SourceText string `json:"source_text"`
+}
+
+// History 客户资料变更历史，按版本倒序
+func (c *Controller) History(ctx *gin.Context) {
+	var req validates.ClientDetailValidate
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		response.ValidateError(ctx, err, response.ParamsCommonError)
+		return
+	}
+	var query validates.ClientHistoryValidate
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		response.ValidateError(ctx, err, response.ParamsCommonError)
+		return
+	}
+
+	list, total, err := c.client.Versions(ctx, req.ID, query.Offset(), query.Limit())
+	if err != nil {
+		log.WithContext(ctx).Errorf("History client:%d err:%v", req.ID, err)
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "查询变更历史失败")
+		return
+	}
+	views := make([]*versionView, 0, len(list))
+	for _, v := range list {
+		views = append(views, &versionView{ClientVersion: v, SourceText: biz_omiai.ClientSourceText[v.Source]})
+	}
+	response.SuccessResponse(ctx, "ok", map[string]interface{}{
+		"list":   views,
+		"total":  total,
+		"fields": biz_omiai.ClientTrackedFields,
+	})
+}
+
+// Restore 将资料恢复为某个历史版本，可只恢复部分字段
+func (c *Controller) Restore(ctx *gin.Context) {
+	var uri validates.ClientDetailValidate
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		response.ValidateError(ctx, err, response.ParamsCommonError)
+		return
+	}
+	var req validates.ClientRestoreValidate
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+
+	version, err := c.client.GetVersion(ctx, uri.ID, req.Version)
+	if err != nil {
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "历史版本不存在")
+		return
+	}
+	values, err := version.Values(req.Fields)
+	if err != nil {
+		c.restoreError(ctx, err)
+		return
+	}
+	// 手机号须保持唯一
+	if phone, ok := values["phone"].(string); ok && phone != "" {
+		existing, err := c.client.GetByPhone(ctx, phone)
+		if err != nil {
+			response.ErrorResponse(ctx, response.DBSelectCommonError, "系统错误")
+			return
+		}
+		if existing != nil && existing.ID != uri.ID {
+			c.restoreError(ctx, biz_omiai.ErrClientPhoneDuplicate)
+			return
+		}
+	}
+
+	remark := fmt.Sprintf("恢复至版本 %d", req.Version)
+	if len(req.Fields) > 0 {
+		remark = fmt.Sprintf("从版本 %d 恢复 %d 个字段", req.Version, len(values))
+	}
+	if err := c.client.Restore(c.changeContext(ctx, biz_omiai.ClientSourceRestore), uri.ID, values, remark); err != nil {
+		c.restoreError(ctx, err)
+		return
+	}
+	response.SuccessResponse(ctx, "恢复成功", nil)
+}
+
+func (c *Controller) restoreError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, biz_omiai.ErrClientRestoreField),
+		errors.Is(err, biz_omiai.ErrClientRestoreNoop),
+		errors.Is(err, biz_omiai.ErrClientPhoneDuplicate):
+		response.ErrorResponse(ctx, response.FuncCommonError, err.Error())
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "客户档案不存在，无法恢复")
+	default:
+		log.WithContext(ctx).Errorf("Restore client err:%v", err)
+		response.ErrorResponse(ctx, response.DBUpdateCommonError, "恢复失败")
+	}
+}
@@ -48,8 +48,10 @@ func (c *Controller) ImportBatch(ctx *gin.Context) {
 
 	// currentUserID := ctx.GetUint64("current_user_id")
 
+	changeCtx := c.changeContext(ctx, biz_omiai.ClientSourceImport)
 	successCount := 0
 	failCount := 0
+	duplicateCount := 0
 	errors := []string{}
 
 	// Transaction support ideally, but for simplicity looping
@@ -145,18 +147,25 @@ func (c *Controller) ImportBatch(ctx *gin.Context) {
 		// Correct approach: The error is "Unknown column". The column is missing in DB.
 		// I should check internal/biz/omiai/client.go again.
 
-		if err := c.client.Create(ctx, client); err != nil {
+		if err := c.client.Create(changeCtx, client); err != nil {
 			log.Errorf("Import create failed: %v", err)
 			failCount++
 			errors = append(errors, "写入失败: "+record.Name)
 		} else {
 			successCount++
+			// 疑似重复的档案进入审核队列
+			if list, err := c.duplicates.Detect(ctx, client); err != nil {
+				log.WithContext(ctx).Errorf("Import detect duplicate client:%d err:%v", client.ID, err)
+			} else if len(list) > 0 {
+				duplicateCount++
+			}
 		}
 	}
 
 	response.SuccessResponse(ctx, "导入完成", map[string]interface{}{
-		"success_count": successCount,
-		"fail_count":    failCount,
-		"errors":        errors,
+		"success_count":   successCount,
+		"fail_count":      failCount,
+		"duplicate_count": duplicateCount, // 疑似与已有档案重复，待审核
+		"errors":          errors,
 	})
 }
@@ -1,12 +1,12 @@
 package client
 
 import (
-	"omiai-server/internal/biz"
+	biz_omiai "omiai-server/internal/biz/omiai"
 	"omiai-server/internal/validates"
 	"omiai-server/pkg/response"
-	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/iWuxc/go-wit/log"
 )
 
 func (c *Controller) List(ctx *gin.Context) {
@@ -23,71 +23,11 @@ func (c *Controller) List(ctx *gin.Context) {
 	}
 	offset := (req.Page - 1) * req.PageSize
 
-	clause := &biz.WhereClause{
-		OrderBy: "created_at desc",
-		Where:   "1=1",
-		Args:    []interface{}{},
-	}
-
-	if req.Name != "" {
-		clause.Where += " AND name LIKE ?"
-		clause.Args = append(clause.Args, "%"+req.Name+"%")
-	}
-	if req.Phone != "" {
-		clause.Where += " AND phone LIKE ?"
-		clause.Args = append(clause.Args, "%"+req.Phone+"%")
-	}
-	if req.Gender != 0 {
-		clause.Where += " AND gender = ?"
-		clause.Args = append(clause.Args, req.Gender)
-	}
-
-	// Range Filters
-	if req.MinHeight > 0 {
-		clause.Where += " AND height >= ?"
-		clause.Args = append(clause.Args, req.MinHeight)
-	}
-	if req.MaxHeight > 0 {
-		clause.Where += " AND height <= ?"
-		clause.Args = append(clause.Args, req.MaxHeight)
-	}
-	if req.MinIncome > 0 {
-		clause.Where += " AND income >= ?"
-		clause.Args = append(clause.Args, req.MinIncome)
-	}
-	if req.Education > 0 {
-		clause.Where += " AND education >= ?" // Assuming higher value = higher education
-		clause.Args = append(clause.Args, req.Education)
-	}
-	if req.Address != "" {
-		clause.Where += " AND address LIKE ?"
-		clause.Args = append(clause.Args, "%"+req.Address+"%")
-	}
-	if req.Profession != "" {
-		clause.Where += " AND profession LIKE ?"
-		clause.Args = append(clause.Args, "%"+req.Profession+"%")
-	}
-	if req.WorkCity != "" {
-		clause.Where += " AND work_city LIKE ?"
-		clause.Args = append(clause.Args, "%"+req.WorkCity+"%")
-	}
-	if req.MaritalStatus > 0 {
-		clause.Where += " AND marital_status = ?"
-		clause.Args = append(clause.Args, req.MaritalStatus)
-	}
-	if req.HouseStatus > 0 {
-		clause.Where += " AND house_status = ?"
-		clause.Args = append(clause.Args, req.HouseStatus)
-	}
-	if req.CarStatus > 0 {
-		clause.Where += " AND car_status = ?"
-		clause.Args = append(clause.Args, req.CarStatus)
-	}
-
-	// Phase 1: 状态筛选
-	if req.Status > 0 {
-		clause.Where += " AND status = ?"
-		clause.Args = append(clause.Args, req.Status)
+	clause, hits, err := c.segments.Clause(ctx, &req.ClientFilter)
+	if err != nil {
+		log.WithContext(ctx).Errorf("Client list filter err:%v", err)
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "获取客户列表失败")
+		return
 	}
 
 	// 单人模式：移除 Scope 权限过滤，默认返回所有客户
@@ -104,34 +44,31 @@ func (c *Controller) List(ctx *gin.Context) {
 		}
 	*/
 
-	// Phase 1: 标签筛选 (JSON 数组包含)
-	// MySQL 5.7+ 支持 JSON_CONTAINS(tags, '"tag_name"')
-	// 这里假设 tags 存的是 ["tag1", "tag2"] 字符串
-	if req.Tags != "" {
-		// 简单实现：LIKE
-		clause.Where += " AND tags LIKE ?"
-		clause.Args = append(clause.Args, "%"+req.Tags+"%")
+	// 限定分群：同时满足分群条件与本次条件
+	if req.SegmentID > 0 {
+		seg, err := c.segmentRepo.Get(ctx, req.SegmentID)
+		role, _ := ctx.Get("role")
+		if err != nil || !seg.Visible(ctx.GetUint64("user_id"), role == biz_omiai.RoleAdmin) {
+			response.ErrorResponse(ctx, response.DBSelectCommonError, "分群不存在")
+			return
+		}
+		segClause, segHits, err := c.segments.Clause(ctx, seg.Conditions)
+		if err != nil {
+			log.WithContext(ctx).Errorf("Client list segment:%d err:%v", seg.ID, err)
+			response.ErrorResponse(ctx, response.DBSelectCommonError, "获取客户列表失败")
+			return
+		}
+		clause.Where += " AND (" + segClause.Where + ")"
+		clause.Args = append(clause.Args, segClause.Args...)
+		if hits == nil {
+			hits = segHits
+		}
 	}
 
-	// Age Filter (Birthday based)
-	now := time.Now()
-	if req.MinAge > 0 {
-		// MinAge 25 means born BEFORE (Now - 25 years)
-		// e.g. 2023 - 25 = 1998. Born in 1998 is 25. Born in 1997 is 26.
-		// So birthday <= 1998-MM-DD
-		targetDate := now.AddDate(-req.MinAge, 0, 0).Format("2006-01-02")
-		clause.Where += " AND birthday <= ?"
-		clause.Args = append(clause.Args, targetDate)
-	}
-	if req.MaxAge > 0 {
-		// MaxAge 30 means born AFTER (Now - 30 years - 1 year?)
-		// e.g. 2023 - 30 = 1993. Born in 1993 is 30. Born in 1992 is 31.
-		// So birthday >= 1993-01-01 (approx)
-		// Actually, simpler: Age = Year(Now) - Year(Birth).
-		// MaxAge 30 -> Year(Birth) >= Year(Now) - 30
-		targetDate := now.AddDate(-req.MaxAge-1, 0, 0).Format("2006-01-02")
-		clause.Where += " AND birthday > ?"
-		clause.Args = append(clause.Args, targetDate)
+	// 全文检索：命中的客户再叠加其余筛选条件，按相关度排序分页
+	if hits != nil {
+		c.searchList(ctx, hits, clause, offset, req.PageSize)
+		return
 	}
 
 	list, err := c.client.Select(ctx, clause, nil, offset, req.PageSize)
@@ -140,7 +77,13 @@ func (c *Controller) List(ctx *gin.Context) {
 		return
 	}
 
-	respList := make([]*ClientResponse, 0)
+	response.SuccessResponse(ctx, "ok", map[string]interface{}{
+		"list": listResponse(list),
+	})
+}
+
+func listResponse(list []*biz_omiai.Client) []*ClientResponse {
+	respList := make([]*ClientResponse, 0, len(list))
 	for _, v := range list {
 		client := &ClientResponse{
 			ID:                  v.ID,
@@ -169,17 +112,17 @@ func (c *Controller) List(ctx *gin.Context) {
 			// Phase 1 Response
 			ManagerID: v.ManagerID,
 			IsPublic:  v.IsPublic,
-			Tags:      v.Tags,
+			Tags:      v.TagsText(),
 			CreatedAt: v.CreatedAt,
 			UpdatedAt: v.UpdatedAt,
+
+			Completeness:  v.Completeness,
+			QualityIssues: v.DecodeQuality(),
 		}
 		if v.Avatar == "" {
 			client.Avatar = "https://api.dicebear.com/7.x/avataaars/svg?seed=" + v.Name
 		}
 		respList = append(respList, client)
 	}
-
-	response.SuccessResponse(ctx, "ok", map[string]interface{}{
-		"list": respList,
-	})
+	return respList
 }
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"omiai-server/internal/biz"
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iWuxc/go-wit/log"
+)
+
+// searchList 全文检索客户，clause 已限定在命中客户内，按相关度分页并附带高亮摘要
+func (c *Controller) searchList(ctx *gin.Context, hits []*biz_omiai.SearchHit, clause *biz.WhereClause, offset, limit int) {
+	if len(hits) == 0 {
+		response.SuccessResponse(ctx, "ok", map[string]interface{}{"list": []*ClientResponse{}, "total": 0})
+		return
+	}
+	matched, err := c.client.Select(ctx, clause, []string{"id"}, 0, len(hits))
+	if err != nil {
+		log.WithContext(ctx).Errorf("Client search filter err:%v", err)
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "获取客户列表失败")
+		return
+	}
+	kept := make(map[uint64]bool, len(matched))
+	for _, v := range matched {
+		kept[v.ID] = true
+	}
+	ranked := make([]*biz_omiai.SearchHit, 0, len(matched))
+	for _, h := range hits {
+		if kept[h.ClientID] {
+			ranked = append(ranked, h)
+		}
+	}
+
+	total := len(ranked)
+	if offset > total {
+		offset = total
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	page := ranked[offset:end]
+	respList := make([]*ClientResponse, 0, len(page))
+	if len(page) > 0 {
+		pageIDs := make([]uint64, 0, len(page))
+		for _, h := range page {
+			pageIDs = append(pageIDs, h.ClientID)
+		}
+		list, err := c.client.Select(ctx, &biz.WhereClause{Where: "id IN ?", Args: []interface{}{pageIDs}}, nil, 0, len(pageIDs))
+		if err != nil {
+			log.WithContext(ctx).Errorf("Client search page err:%v", err)
+			response.ErrorResponse(ctx, response.DBSelectCommonError, "获取客户列表失败")
+			return
+		}
+		byID := make(map[uint64]*ClientResponse, len(list))
+		for _, v := range listResponse(list) {
+			byID[v.ID] = v
+		}
+		for _, h := range page {
+			if v := byID[h.ClientID]; v != nil {
+				v.SearchScore = h.Score
+				v.Highlights = h.Highlights
+				respList = append(respList, v)
+			}
+		}
+	}
+	response.SuccessResponse(ctx, "ok", map[string]interface{}{
+		"list":  respList,
+		"total": total,
+	})
+}
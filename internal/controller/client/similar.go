@@ -0,0 +1,38 @@
+package client
+
+import (
+	"omiai-server/internal/validates"
+	"omiai-server/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iWuxc/go-wit/log"
+)
+
+// Similar 资料文本（备注、家庭情况、择偶要求等）与该客户最相似的客户
+func (c *Controller) Similar(ctx *gin.Context) {
+	var req validates.ClientDetailValidate
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		response.ValidateError(ctx, err, response.ParamsCommonError)
+		return
+	}
+	var query validates.ClientSimilarValidate
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		response.ValidateError(ctx, err, response.ParamsCommonError)
+		return
+	}
+
+	if _, err := c.client.Get(ctx, req.ID); err != nil {
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "客户档案不存在")
+		return
+	}
+	list, err := c.embeddings.Similar(ctx, req.ID, query.Gender, query.Limit)
+	if err != nil {
+		log.WithContext(ctx).Errorf("Similar client:%d err:%v", req.ID, err)
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "查询相似客户失败")
+		return
+	}
+	response.SuccessResponse(ctx, "ok", map[string]interface{}{
+		"model": c.embeddings.Model(),
+		"list":  list,
+	})
+}
@@ -0,0 +1,88 @@
+package client
+
+import (
+	"errors"
+
+	"omiai-server/internal/biz"
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/validates"
+	"omiai-server/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iWuxc/go-wit/log"
+	"gorm.io/gorm"
+)
+
+// Trash 回收站客户列表，按删除时间倒序
+func (c *Controller) Trash(ctx *gin.Context) {
+	var req validates.ClientTrashValidate
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		response.ValidateError(ctx, err, response.ParamsCommonError)
+		return
+	}
+
+	clause := &biz.WhereClause{Where: "1 = 1"}
+	if req.Name != "" {
+		clause.Where += " AND name LIKE ?"
+		clause.Args = append(clause.Args, "%"+req.Name+"%")
+	}
+	if req.Phone != "" {
+		clause.Where += " AND phone LIKE ?"
+		clause.Args = append(clause.Args, "%"+req.Phone+"%")
+	}
+	list, total, err := c.client.Trashed(ctx, clause, req.Offset(), req.Limit())
+	if err != nil {
+		log.WithContext(ctx).Errorf("Trash err:%v", err)
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "查询回收站失败")
+		return
+	}
+	response.SuccessResponse(ctx, "ok", map[string]interface{}{
+		"list":  list,
+		"total": total,
+	})
+}
+
+// Undelete 从回收站恢复客户，随客户删除的情侣档案一并恢复
+func (c *Controller) Undelete(ctx *gin.Context) {
+	var req validates.ClientDetailValidate
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		response.ValidateError(ctx, err, response.ParamsCommonError)
+		return
+	}
+	if err := c.client.Undelete(c.changeContext(ctx, biz_omiai.ClientSourceRestore), req.ID); err != nil {
+		c.trashError(ctx, err, response.DBUpdateCommonError, "恢复失败")
+		return
+	}
+	response.SuccessResponse(ctx, "恢复成功", nil)
+}
+
+// Purge 彻底删除回收站中的客户，仅管理员可操作
+func (c *Controller) Purge(ctx *gin.Context) {
+	if role, _ := ctx.Get("role"); role != biz_omiai.RoleAdmin {
+		response.ErrorResponse(ctx, response.FuncCommonError, "权限不足，仅管理员可彻底删除")
+		return
+	}
+	var req validates.ClientDetailValidate
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		response.ValidateError(ctx, err, response.ParamsCommonError)
+		return
+	}
+	if err := c.client.Purge(ctx, req.ID); err != nil {
+		c.trashError(ctx, err, response.DBDeleteCommonError, "彻底删除失败")
+		return
+	}
+	log.WithContext(ctx).Infof("Purge client:%d operator:%s", req.ID, c.operatorName(ctx))
+	response.SuccessResponse(ctx, "已彻底删除", nil)
+}
+
+func (c *Controller) trashError(ctx *gin.Context, err error, code response.Code, msg string) {
+	switch {
+	case errors.Is(err, biz_omiai.ErrClientPhoneDuplicate):
+		response.ErrorResponse(ctx, response.FuncCommonError, "该手机号已被其他客户使用，请先修改后再恢复")
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "回收站中不存在该客户")
+	default:
+		log.WithContext(ctx).Errorf("%s err:%v", msg, err)
+		response.ErrorResponse(ctx, code, msg)
+	}
+}
@@ -52,6 +52,8 @@ func (c *Controller) Update(ctx *gin.Context) {
 		HouseCityCode:       req.HouseCityCode,
 		HouseDistrictCode:   req.HouseDistrictCode,
 		CarStatus:           req.CarStatus,
+		Relocation:          req.Relocation,
+		LongDistance:        req.LongDistance,
 		PartnerRequirements: req.PartnerRequirements,
 		Remark:              req.Remark,
 		Photos:              req.Photos,
@@ -60,7 +62,7 @@ func (c *Controller) Update(ctx *gin.Context) {
 	// 重新计算年龄
 	client.Age = client.RealAge()
 
-	if err := c.client.Update(ctx, client); err != nil {
+	if err := c.client.Update(c.changeContext(ctx, biz_omiai.ClientSourceAdmin), client); err != nil {
 		log.Errorf("Failed to update client: %v", err)
 		response.ErrorResponse(ctx, response.DBUpdateCommonError, "更新客户档案失败")
 		return
@@ -1,6 +1,8 @@
 package common
 
 import (
+	"bytes"
+	"crypto/sha1"
 	"fmt"
 	"io"
 	"mime"
@@ -130,7 +132,12 @@ func (c *Controller) Upload(ctx *gin.Context) {
 	}
 
 	// 5. Generate New Filename/Key
-	key := fmt.Sprintf("uploads/%s/%s%s", time.Now().Format("20060102"), uuid.New().String(), finalExt)
+	// 图片以内容摘要命名，同一张照片重复上传得到相同的文件名，用于重复档案检测
+	name := uuid.New().String()
+	if buf, ok := uploadReader.(*bytes.Buffer); ok {
+		name = fmt.Sprintf("%x", sha1.Sum(buf.Bytes()))
+	}
+	key := fmt.Sprintf("uploads/%s/%s%s", time.Now().Format("20060102"), name, finalExt)
 
 	log.Infof("Uploading file: %s, Content-Type: %s", key, contentType)
 
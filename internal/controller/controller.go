@@ -9,8 +9,14 @@ import (
 	"omiai-server/internal/controller/client"
 	"omiai-server/internal/controller/common"
 	"omiai-server/internal/controller/dashboard"
+	"omiai-server/internal/controller/duplicate"
 	"omiai-server/internal/controller/match"
+	"omiai-server/internal/controller/meeting"
+	"omiai-server/internal/controller/party"
+	"omiai-server/internal/controller/questionnaire"
 	"omiai-server/internal/controller/reminder"
+	"omiai-server/internal/controller/segment"
+	"omiai-server/internal/controller/tag"
 	"omiai-server/internal/controller/template"
 
 	"github.com/google/wire"
@@ -25,7 +31,13 @@ var ProviderController = wire.NewSet(
 	client.NewController,
 	common.NewController,
 	dashboard.NewController,
+	duplicate.NewController,
 	match.NewController,
+	meeting.NewController,
+	party.NewController,
+	questionnaire.NewController,
 	reminder.NewController,
+	segment.NewController,
+	tag.NewController,
 	template.NewController,
 )
@@ -1,23 +1,33 @@
 package dashboard
 
 import (
+	"errors"
+
 	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/service/experiment"
+	"omiai-server/internal/validates"
 	"omiai-server/pkg/response"
 
 	"github.com/gin-gonic/gin"
+	"github.com/iWuxc/go-wit/log"
 )
 
 type Controller struct {
-	client   biz_omiai.ClientInterface
-	match    biz_omiai.MatchInterface
-	reminder biz_omiai.ReminderInterface
+	client      biz_omiai.ClientInterface
+	match       biz_omiai.MatchInterface
+	reminder    biz_omiai.ReminderInterface
+	experiments *experiment.Service
+	quality     biz_omiai.QualityInterface
 }
 
-func NewController(client biz_omiai.ClientInterface, match biz_omiai.MatchInterface, reminder biz_omiai.ReminderInterface) *Controller {
+func NewController(client biz_omiai.ClientInterface, match biz_omiai.MatchInterface, reminder biz_omiai.ReminderInterface,
+	experiments *experiment.Service, quality biz_omiai.QualityInterface) *Controller {
 	return &Controller{
-		client:   client,
-		match:    match,
-		reminder: reminder,
+		client:      client,
+		match:       match,
+		reminder:    reminder,
+		experiments: experiments,
+		quality:     quality,
 	}
 }
 
@@ -114,3 +124,45 @@ func (c *Controller) GetTodos(ctx *gin.Context) {
 
 	response.SuccessResponse(ctx, "ok", todos)
 }
+
+// Experiment 推荐算法 A/B 实验结果：各分组曝光后的转化漏斗，key 为空时取生效中的实验
+func (c *Controller) Experiment(ctx *gin.Context) {
+	key, results, err := c.experiments.Results(ctx, ctx.Query("key"))
+	if err != nil {
+		if errors.Is(err, experiment.ErrExperimentNotFound) {
+			response.ErrorResponse(ctx, response.ParamsCommonError, err.Error())
+			return
+		}
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "获取实验结果失败")
+		return
+	}
+	response.SuccessResponse(ctx, "ok", map[string]interface{}{
+		"key":      key,
+		"active":   key == c.experiments.Active(),
+		"variants": results,
+	})
+}
+
+// Quality 资料质量日报，最新的在前；附带当前生效的质量规则
+func (c *Controller) Quality(ctx *gin.Context) {
+	var req validates.Paginate
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+	list, total, err := c.quality.Reports(ctx, req.Offset(), req.Limit())
+	if err != nil {
+		log.WithContext(ctx).Errorf("Dashboard Quality err:%v", err)
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "获取资料质量日报失败")
+		return
+	}
+	rules := make([]map[string]interface{}, 0, len(biz_omiai.QualityRules))
+	for _, r := range biz_omiai.QualityRules {
+		rules = append(rules, map[string]interface{}{"field": r.Field, "label": r.Label, "weight": r.Weight})
+	}
+	response.SuccessResponse(ctx, "ok", map[string]interface{}{
+		"list":  list,
+		"total": total,
+		"rules": rules,
+	})
+}
@@ -0,0 +1,31 @@
+package duplicate
+
+import (
+	"fmt"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/service/duplicate"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Controller struct {
+	duplicate  biz_omiai.DuplicateInterface
+	user       biz_omiai.UserInterface
+	duplicates *duplicate.Service
+}
+
+func NewController(duplicateRepo biz_omiai.DuplicateInterface, user biz_omiai.UserInterface, duplicates *duplicate.Service) *Controller {
+	return &Controller{duplicate: duplicateRepo, user: user, duplicates: duplicates}
+}
+
+func (c *Controller) operatorName(ctx *gin.Context) string {
+	id := ctx.GetUint64("user_id")
+	if id == 0 {
+		return "Admin"
+	}
+	if user, err := c.user.GetByID(ctx, id); err == nil && user != nil {
+		return user.Nickname
+	}
+	return fmt.Sprintf("User:%d", id)
+}
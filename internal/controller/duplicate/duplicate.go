@@ -0,0 +1,133 @@
+package duplicate
+
+import (
+	"errors"
+
+	"omiai-server/internal/biz"
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/validates"
+	"omiai-server/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iWuxc/go-wit/log"
+	"gorm.io/gorm"
+)
+
+// fieldCompare 合并时逐字段对比两份档案
+type fieldCompare struct {
+	Field string      `json:"field"`
+	Label string      `json:"label"`
+	A     interface{} `json:"a"`
+	B     interface{} `json:"b"`
+	Same  bool        `json:"same"`
+}
+
+// List 疑似重复审核队列，按得分倒序
+func (c *Controller) List(ctx *gin.Context) {
+	var req validates.DuplicateListValidate
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+	if req.Status == 0 {
+		req.Status = biz_omiai.DuplicateStatusPending
+	}
+
+	clause := &biz.WhereClause{Where: "status = ?", Args: []interface{}{req.Status}}
+	if req.ClientID > 0 {
+		clause.Where += " AND (client_a_id = ? OR client_b_id = ?)"
+		clause.Args = append(clause.Args, req.ClientID, req.ClientID)
+	}
+	if req.MinScore > 0 {
+		clause.Where += " AND score >= ?"
+		clause.Args = append(clause.Args, req.MinScore)
+	}
+	list, total, err := c.duplicate.Select(ctx, clause, req.Offset(), req.Limit())
+	if err != nil {
+		log.WithContext(ctx).Errorf("Duplicate List err:%v", err)
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "查询失败")
+		return
+	}
+	response.SuccessResponse(ctx, "ok", map[string]interface{}{
+		"list":  list,
+		"total": total,
+	})
+}
+
+// Detail 疑似重复详情，附逐字段对比供选择合并后的取值
+func (c *Controller) Detail(ctx *gin.Context) {
+	var req validates.DuplicateDetailValidate
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		response.ValidateError(ctx, err, response.ParamsCommonError)
+		return
+	}
+	d, err := c.duplicate.Get(ctx, req.ID)
+	if err != nil {
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "记录不存在")
+		return
+	}
+	a, b := biz_omiai.ClientSnapshot(d.ClientA), biz_omiai.ClientSnapshot(d.ClientB)
+	fields := make([]*fieldCompare, 0, len(biz_omiai.ClientTrackedFields))
+	for _, f := range biz_omiai.ClientTrackedFields {
+		fields = append(fields, &fieldCompare{Field: f.Column, Label: f.Label, A: a[f.Column], B: b[f.Column], Same: a[f.Column] == b[f.Column]})
+	}
+	response.SuccessResponse(ctx, "ok", map[string]interface{}{
+		"duplicate": d,
+		"fields":    fields,
+	})
+}
+
+// Merge 合并两份档案，另一份档案移入回收站
+func (c *Controller) Merge(ctx *gin.Context) {
+	var req validates.DuplicateMergeValidate
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+	if err := c.duplicates.Merge(ctx, req.ID, req.SurvivorID, req.Fields, c.operatorName(ctx)); err != nil {
+		duplicateError(ctx, err, "合并失败")
+		return
+	}
+	response.SuccessResponse(ctx, "合并成功", nil)
+}
+
+// Ignore 标记为非同一人
+func (c *Controller) Ignore(ctx *gin.Context) {
+	var req validates.DuplicateIgnoreValidate
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+	if err := c.duplicates.Ignore(ctx, req.ID, c.operatorName(ctx)); err != nil {
+		duplicateError(ctx, err, "操作失败")
+		return
+	}
+	response.SuccessResponse(ctx, "已标记为非同一人", nil)
+}
+
+// Scan 立即执行一次全量查重
+func (c *Controller) Scan(ctx *gin.Context) {
+	summary, err := c.duplicates.Scan(ctx)
+	if err != nil {
+		log.WithContext(ctx).Errorf("Duplicate Scan err:%v", err)
+		response.ErrorResponse(ctx, response.FuncCommonError, "查重失败")
+		return
+	}
+	response.SuccessResponse(ctx, "查重完成", summary)
+}
+
+func duplicateError(ctx *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, biz_omiai.ErrDuplicateStatus),
+		errors.Is(err, biz_omiai.ErrDuplicateSurvivor),
+		errors.Is(err, biz_omiai.ErrDuplicateField),
+		errors.Is(err, biz_omiai.ErrDuplicateMatched),
+		errors.Is(err, biz_omiai.ErrDuplicatePaired):
+		response.ErrorResponse(ctx, response.FuncCommonError, err.Error())
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "记录或客户档案不存在")
+	default:
+		log.WithContext(ctx).Errorf("%s err:%v", msg, err)
+		response.ErrorResponse(ctx, response.DBUpdateCommonError, msg)
+	}
+}
@@ -1,19 +1,30 @@
 package match
 
 import (
+	"errors"
+	"time"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/service/experiment"
 	"omiai-server/internal/validates"
 	"omiai-server/pkg/response"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-// GetCandidates 获取匹配候选人列表
+// GetCandidates 获取匹配候选人列表，支持分页、筛选与排序
 func (c *Controller) GetCandidates(ctx *gin.Context) {
 	var req validates.GetCandidatesValidate
 	if err := ctx.ShouldBindUri(&req); err != nil {
 		response.ValidateError(ctx, err, response.ParamsCommonError)
 		return
 	}
+	var query validates.CandidateListValidate
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
 
 	// Check if client exists
 	client, err := c.client.Get(ctx, req.ClientID)
@@ -22,13 +33,61 @@ func (c *Controller) GetCandidates(ctx *gin.Context) {
 		return
 	}
 
-	candidates, err := c.match.GetCandidates(ctx, req.ClientID)
+	matchmakerID := ctx.GetUint64("user_id")
+	assignment := c.experiments.Assign(req.ClientID, matchmakerID)
+	candidates, total, err := c.match.GetCandidates(ctx, &biz_omiai.CandidateQuery{
+		ClientID:     req.ClientID,
+		MinAge:       query.MinAge,
+		MaxAge:       query.MaxAge,
+		CityCode:     query.CityCode,
+		MinEducation: query.MinEducation,
+		Sort:         query.Sort,
+		Asc:          query.Order == "asc",
+		Explain:      query.Explain,
+		Scorer:       assignment.ScorerOr(nil),
+		Offset:       query.Offset(),
+		Limit:        query.Limit(),
+	})
 	if err != nil {
 		response.ErrorResponse(ctx, response.DBSelectCommonError, "获取候选人失败")
 		return
 	}
 
-	response.SuccessResponse(ctx, "获取成功", candidates)
+	exposed := make([]experiment.Exposed, 0, len(candidates))
+	for _, cand := range candidates {
+		exposed = append(exposed, experiment.Exposed{CandidateID: cand.CandidateID, Score: cand.MatchScore})
+	}
+	c.experiments.LogExposures(ctx, assignment, biz_omiai.ExposureSourceCandidates, req.ClientID, matchmakerID, query.Offset(), exposed)
+
+	response.SuccessResponse(ctx, "获取成功", map[string]interface{}{
+		"list":       candidates,
+		"total":      total,
+		"experiment": assignment,
+	})
+}
+
+// RecommendedTo 反查客户当前出现在哪些客户的推荐列表中
+func (c *Controller) RecommendedTo(ctx *gin.Context) {
+	var req validates.GetCandidatesValidate
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		response.ValidateError(ctx, err, response.ParamsCommonError)
+		return
+	}
+	var page validates.Paginate
+	if err := ctx.ShouldBindQuery(&page); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+
+	list, total, err := c.match.RecommendedTo(ctx, req.ClientID, page.Offset(), page.Limit())
+	if err != nil {
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "查询失败")
+		return
+	}
+	response.SuccessResponse(ctx, "ok", map[string]interface{}{
+		"list":  list,
+		"total": total,
+	})
 }
 
 // Compare 匹配对比详情
@@ -39,7 +98,7 @@ func (c *Controller) Compare(ctx *gin.Context) {
 		return
 	}
 
-	comparison, err := c.match.Compare(ctx, req.ClientID, req.CandidateID)
+	comparison, err := c.match.Compare(ctx, req.ClientID, req.CandidateID, c.scorer(ctx, req.ClientID))
 	if err != nil {
 		response.ErrorResponse(ctx, response.DBSelectCommonError, "获取对比详情失败")
 		return
@@ -47,3 +106,131 @@ func (c *Controller) Compare(ctx *gin.Context) {
 
 	response.SuccessResponse(ctx, "获取成功", comparison)
 }
+
+// ExplainCandidate 候选人得分解释：各维度得分、权重、贡献，以及未进入推荐的原因
+func (c *Controller) ExplainCandidate(ctx *gin.Context) {
+	var req validates.CompareValidate
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		response.ValidateError(ctx, err, response.ParamsCommonError)
+		return
+	}
+
+	explanation, err := c.match.Explain(ctx, req.ClientID, req.CandidateID, c.scorer(ctx, req.ClientID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.ErrorResponse(ctx, response.DBSelectCommonError, "客户或候选人不存在")
+			return
+		}
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "获取得分解释失败")
+		return
+	}
+	response.SuccessResponse(ctx, "获取成功", explanation)
+}
+
+// RejectCandidate 标记候选人为拒绝/屏蔽/暂不考虑，之后各推荐来源不再推荐该配对
+func (c *Controller) RejectCandidate(ctx *gin.Context) {
+	var uri validates.CompareValidate
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		response.ValidateError(ctx, err, response.ParamsCommonError)
+		return
+	}
+	var req validates.CandidateRejectValidate
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+
+	client, err := c.client.Get(ctx, uri.ClientID)
+	if err != nil || client == nil {
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "客户档案不存在")
+		return
+	}
+	candidate, err := c.client.Get(ctx, uri.CandidateID)
+	if err != nil || candidate == nil {
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "候选人不存在")
+		return
+	}
+	if client.Gender == candidate.Gender {
+		response.ErrorResponse(ctx, response.ParamsCommonError, biz_omiai.ErrPairSameGender.Error())
+		return
+	}
+
+	h := &biz_omiai.PairHistory{
+		ClientID:    uri.ClientID,
+		CandidateID: uri.CandidateID,
+		Kind:        req.Kind,
+		ReasonCode:  req.ReasonCode,
+		Remark:      req.Remark,
+		Operator:    c.operatorName(ctx),
+	}
+	if req.ExpiredAt != "" {
+		expiredAt, err := parseTime(req.ExpiredAt)
+		if err != nil {
+			response.ErrorResponse(ctx, response.ParamsCommonError, "恢复日期格式错误")
+			return
+		}
+		h.ExpiredAt = &expiredAt
+	}
+
+	if err := c.pairs.Reject(ctx, h); err != nil {
+		c.pairHistoryError(ctx, err, response.DBInsertCommonError, "标记失败")
+		return
+	}
+	response.SuccessResponse(ctx, "标记成功", h)
+}
+
+// ListPairHistory 客户的负反馈记录（含被他人拒绝）
+func (c *Controller) ListPairHistory(ctx *gin.Context) {
+	var req validates.GetCandidatesValidate
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		response.ValidateError(ctx, err, response.ParamsCommonError)
+		return
+	}
+
+	list, err := c.pairs.List(ctx, req.ClientID)
+	if err != nil {
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "查询失败")
+		return
+	}
+	now := time.Now()
+	items := make([]map[string]interface{}, 0, len(list))
+	for _, h := range list {
+		items = append(items, map[string]interface{}{
+			"record":      h,
+			"kind_text":   biz_omiai.PairKindText[h.Kind],
+			"reason_text": h.ReasonText(),
+			"active":      h.Active(now),
+		})
+	}
+	response.SuccessResponse(ctx, "ok", items)
+}
+
+// RevokePairHistory 撤销手动标记，恢复推荐
+func (c *Controller) RevokePairHistory(ctx *gin.Context) {
+	var req validates.PairHistoryRevokeValidate
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		response.ValidateError(ctx, err, response.ParamsCommonError)
+		return
+	}
+
+	if err := c.pairs.Revoke(ctx, req.ID); err != nil {
+		c.pairHistoryError(ctx, err, response.DBDeleteCommonError, "撤销失败")
+		return
+	}
+	response.SuccessResponse(ctx, "撤销成功", nil)
+}
+
+func (c *Controller) pairHistoryError(ctx *gin.Context, err error, code response.Code, fallback string) {
+	switch {
+	case errors.Is(err, biz_omiai.ErrPairKind),
+		errors.Is(err, biz_omiai.ErrPairReason),
+		errors.Is(err, biz_omiai.ErrPairExpiredAt),
+		errors.Is(err, biz_omiai.ErrPairSameClient),
+		errors.Is(err, biz_omiai.ErrPairNotManual):
+		response.ErrorResponse(ctx, response.ParamsCommonError, err.Error())
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "记录不存在")
+	default:
+		response.ErrorResponse(ctx, code, fallback)
+	}
+}
@@ -3,15 +3,29 @@ package match
 import (
 	biz_omiai "omiai-server/internal/biz/omiai"
 	"omiai-server/internal/data"
+	"omiai-server/internal/service/daily_recommendation"
+	"omiai-server/internal/service/experiment"
+	"omiai-server/internal/service/pair_history"
+	"omiai-server/internal/service/proposal"
 )
 
 type Controller struct {
-	db     *data.DB
-	match  biz_omiai.MatchInterface
-	client biz_omiai.ClientInterface
-	user   biz_omiai.UserInterface
+	db           *data.DB
+	match        biz_omiai.MatchInterface
+	client       biz_omiai.ClientInterface
+	user         biz_omiai.UserInterface
+	intro        biz_omiai.IntroductionInterface
+	pairs        *pair_history.Service
+	proposal     biz_omiai.ProposalInterface
+	proposals    *proposal.Service
+	experiments  *experiment.Service
+	daily        biz_omiai.DailyRecommendationInterface
+	dailyService *daily_recommendation.Service
 }
 
-func NewController(db *data.DB, match biz_omiai.MatchInterface, client biz_omiai.ClientInterface, user biz_omiai.UserInterface) *Controller {
-	return &Controller{db: db, match: match, client: client, user: user}
+func NewController(db *data.DB, match biz_omiai.MatchInterface, client biz_omiai.ClientInterface, user biz_omiai.UserInterface,
+	intro biz_omiai.IntroductionInterface, pairs *pair_history.Service, proposalRepo biz_omiai.ProposalInterface, proposals *proposal.Service,
+	experiments *experiment.Service, dailyRepo biz_omiai.DailyRecommendationInterface, dailyService *daily_recommendation.Service) *Controller {
+	return &Controller{db: db, match: match, client: client, user: user, intro: intro, pairs: pairs, proposal: proposalRepo, proposals: proposals,
+		experiments: experiments, daily: dailyRepo, dailyService: dailyService}
 }
@@ -0,0 +1,137 @@
+package match
+
+import (
+	"errors"
+	"time"
+
+	"omiai-server/internal/biz"
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/validates"
+	"omiai-server/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// dailyView 每日推荐，附带标签与状态说明
+type dailyView struct {
+	*biz_omiai.DailyRecommendation
+	Tags       []string `json:"tags"`
+	StatusText string   `json:"status_text"`
+}
+
+// ListDaily 某天的每日推荐，可按客户、处理状态筛选
+func (c *Controller) ListDaily(ctx *gin.Context) {
+	var req validates.DailyListValidate
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+	if req.Date == "" {
+		req.Date = time.Now().Format(biz_omiai.DailyDateLayout)
+	}
+
+	clause := &biz.WhereClause{Where: "rec_date = ?", Args: []interface{}{req.Date}}
+	if req.ClientID > 0 {
+		clause.Where += " AND client_id = ?"
+		clause.Args = append(clause.Args, req.ClientID)
+	}
+	if req.Status > 0 {
+		clause.Where += " AND status = ?"
+		clause.Args = append(clause.Args, req.Status)
+	}
+	list, total, err := c.daily.Select(ctx, clause, req.Offset(), req.Limit())
+	if err != nil {
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "查询失败")
+		return
+	}
+
+	views := make([]*dailyView, 0, len(list))
+	for _, d := range list {
+		views = append(views, &dailyView{DailyRecommendation: d, Tags: d.TagList(), StatusText: biz_omiai.DailyStatusText[d.Status]})
+	}
+	response.SuccessResponse(ctx, "ok", map[string]interface{}{
+		"date":  req.Date,
+		"list":  views,
+		"total": total,
+	})
+}
+
+// GenerateDaily 手动生成今天的每日推荐，当天已生成过的客户跳过
+func (c *Controller) GenerateDaily(ctx *gin.Context) {
+	var req validates.DailyGenerateValidate
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+
+	summary, err := c.dailyService.Generate(ctx, &biz_omiai.DailyOptions{Count: req.Count, FreshDays: req.FreshDays})
+	if err != nil {
+		response.ErrorResponse(ctx, response.DBInsertCommonError, "生成每日推荐失败")
+		return
+	}
+	response.SuccessResponse(ctx, "生成成功", summary)
+}
+
+// AcceptDaily 采纳推荐，以该配对发起介绍
+func (c *Controller) AcceptDaily(ctx *gin.Context) {
+	var req validates.DailyAcceptValidate
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+
+	intro, err := c.dailyService.Accept(ctx, req.ID, ctx.GetUint64("user_id"), c.operatorName(ctx), req.Remark)
+	if err != nil {
+		c.dailyError(ctx, err, response.DBUpdateCommonError, "采纳失败")
+		return
+	}
+	response.SuccessResponse(ctx, "已发起介绍", intro)
+}
+
+// SkipDaily 跳过推荐
+func (c *Controller) SkipDaily(ctx *gin.Context) {
+	var req validates.DailySkipValidate
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+
+	if err := c.dailyService.Skip(ctx, req.ID, req.ReasonCode, c.operatorName(ctx), req.Remark); err != nil {
+		c.dailyError(ctx, err, response.DBUpdateCommonError, "跳过失败")
+		return
+	}
+	response.SuccessResponse(ctx, "已跳过", nil)
+}
+
+// DailyStats 最近若干天每日推荐的生成与处理情况
+func (c *Controller) DailyStats(ctx *gin.Context) {
+	var req validates.DailyStatsValidate
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+
+	list, total, err := c.dailyService.Stats(ctx, req.Days, time.Now())
+	if err != nil {
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "查询失败")
+		return
+	}
+	response.SuccessResponse(ctx, "ok", map[string]interface{}{
+		"list":  list,
+		"total": total,
+	})
+}
+
+func (c *Controller) dailyError(ctx *gin.Context, err error, code response.Code, fallback string) {
+	switch {
+	case errors.Is(err, biz_omiai.ErrDailyHandled),
+		errors.Is(err, biz_omiai.ErrPairReason):
+		response.ErrorResponse(ctx, response.FuncCommonError, err.Error())
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "推荐记录不存在")
+	default:
+		// 采纳时发起介绍失败（如客户已不是单身）沿用介绍流程的提示
+		c.introError(ctx, err, code, fallback)
+	}
+}
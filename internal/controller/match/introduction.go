@@ -0,0 +1,212 @@
+package match
+
+import (
+	"errors"
+	"fmt"
+
+	"omiai-server/internal/biz"
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/validates"
+	"omiai-server/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CreateIntroduction 发起介绍，双方进入匹配中
+func (c *Controller) CreateIntroduction(ctx *gin.Context) {
+	var req validates.IntroductionCreateValidate
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+
+	a, err := c.client.Get(ctx, req.ClientAID)
+	if err != nil || a == nil {
+		response.ErrorResponse(ctx, response.ParamsCommonError, "A方客户不存在")
+		return
+	}
+	b, err := c.client.Get(ctx, req.ClientBID)
+	if err != nil || b == nil {
+		response.ErrorResponse(ctx, response.ParamsCommonError, "B方客户不存在")
+		return
+	}
+	if a.Gender == b.Gender {
+		response.ErrorResponse(ctx, response.ParamsCommonError, "双方性别相同，无法介绍")
+		return
+	}
+
+	intro := &biz_omiai.Introduction{
+		ClientAID:    req.ClientAID,
+		ClientBID:    req.ClientBID,
+		MatchmakerID: ctx.GetUint64("user_id"),
+		Remark:       req.Remark,
+	}
+	if err := c.intro.Create(ctx, intro, c.operatorName(ctx), c.scorer(ctx, req.ClientAID)); err != nil {
+		c.introError(ctx, err, response.DBInsertCommonError, "发起介绍失败")
+		return
+	}
+	response.SuccessResponse(ctx, "发起介绍成功", intro)
+}
+
+// TransitionIntroduction 推进介绍流程
+func (c *Controller) TransitionIntroduction(ctx *gin.Context) {
+	var req validates.IntroductionTransitionValidate
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+
+	change := &biz_omiai.IntroductionChange{
+		Operator:     c.operatorName(ctx),
+		Remark:       req.Remark,
+		MeetingPlace: req.MeetingPlace,
+		CloseReason:  req.CloseReason,
+	}
+	if req.CloseReason != "" {
+		if _, ok := biz_omiai.IntroCloseReasonText[req.CloseReason]; !ok {
+			response.ErrorResponse(ctx, response.ParamsCommonError, "关闭原因不存在")
+			return
+		}
+	}
+	if req.MeetingAt != "" {
+		meetingAt, err := parseTime(req.MeetingAt)
+		if err != nil {
+			response.ErrorResponse(ctx, response.ParamsCommonError, "见面时间格式错误")
+			return
+		}
+		change.MeetingAt = &meetingAt
+	}
+	// 转化生成的情侣档案与发起方的候选人列表使用同一分组评分器
+	if req.Status == biz_omiai.IntroStatusConverted {
+		if intro, err := c.intro.Get(ctx, req.ID); err == nil {
+			change.Scorer = c.scorer(ctx, intro.ClientAID)
+		}
+	}
+
+	intro, err := c.intro.Transition(ctx, req.ID, req.Status, change)
+	if err != nil {
+		c.introError(ctx, err, response.DBUpdateCommonError, "更新介绍状态失败")
+		return
+	}
+	response.SuccessResponse(ctx, "操作成功", intro)
+}
+
+// ListIntroductions 介绍流程列表，可按红娘、客户、状态筛选
+func (c *Controller) ListIntroductions(ctx *gin.Context) {
+	var req validates.IntroductionListValidate
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+
+	clause := &biz.WhereClause{Where: "1=1"}
+	matchmakerID := req.MatchmakerID
+	if req.Mine {
+		matchmakerID = ctx.GetUint64("user_id")
+	}
+	if matchmakerID > 0 {
+		clause.Where += " AND matchmaker_id = ?"
+		clause.Args = append(clause.Args, matchmakerID)
+	}
+	if req.ClientID > 0 {
+		clause.Where += " AND (client_a_id = ? OR client_b_id = ?)"
+		clause.Args = append(clause.Args, req.ClientID, req.ClientID)
+	}
+	if req.Status > 0 {
+		clause.Where += " AND status = ?"
+		clause.Args = append(clause.Args, req.Status)
+	} else if req.OnlyOpen {
+		clause.Where += " AND status IN ?"
+		clause.Args = append(clause.Args, biz_omiai.OpenIntroStatuses)
+	}
+
+	list, total, err := c.intro.Select(ctx, clause, req.Offset(), req.Limit())
+	if err != nil {
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "查询失败")
+		return
+	}
+	response.SuccessResponse(ctx, "ok", map[string]interface{}{
+		"list":  list,
+		"total": total,
+	})
+}
+
+// GetIntroduction 介绍详情及状态变更记录
+func (c *Controller) GetIntroduction(ctx *gin.Context) {
+	var req validates.IntroductionDetailValidate
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		response.ValidateError(ctx, err, response.ParamsCommonError)
+		return
+	}
+
+	intro, err := c.intro.Get(ctx, req.ID)
+	if err != nil {
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "介绍记录不存在")
+		return
+	}
+	history, err := c.intro.History(ctx, req.ID)
+	if err != nil {
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "查询失败")
+		return
+	}
+	response.SuccessResponse(ctx, "ok", map[string]interface{}{
+		"introduction": intro,
+		"history":      history,
+	})
+}
+
+// IntroductionStats 介绍漏斗统计，mine=true 时仅统计当前红娘
+func (c *Controller) IntroductionStats(ctx *gin.Context) {
+	var matchmakerID uint64
+	if ctx.Query("mine") == "true" {
+		matchmakerID = ctx.GetUint64("user_id")
+	}
+	counts, err := c.intro.CountByStatus(ctx, matchmakerID)
+	if err != nil {
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "统计失败")
+		return
+	}
+
+	funnel := make([]map[string]interface{}, 0, len(biz_omiai.IntroStatusText))
+	for status := int8(biz_omiai.IntroStatusProposed); status <= biz_omiai.IntroStatusClosed; status++ {
+		funnel = append(funnel, map[string]interface{}{
+			"status": status,
+			"label":  biz_omiai.IntroStatusText[status],
+			"count":  counts[status],
+		})
+	}
+	response.SuccessResponse(ctx, "ok", funnel)
+}
+
+func (c *Controller) introError(ctx *gin.Context, err error, code response.Code, fallback string) {
+	switch {
+	case errors.Is(err, biz_omiai.ErrIntroInvalidTransition),
+		errors.Is(err, biz_omiai.ErrIntroStatusChanged),
+		errors.Is(err, biz_omiai.ErrIntroClientUnavailable),
+		errors.Is(err, biz_omiai.ErrIntroCloseReason),
+		errors.Is(err, biz_omiai.ErrIntroMeetingTime):
+		response.ErrorResponse(ctx, response.FuncCommonError, err.Error())
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "介绍记录或客户不存在")
+	default:
+		response.ErrorResponse(ctx, code, fallback)
+	}
+}
+
+// scorer 客户在当前 A/B 实验中的分组评分器，与候选人列表的分流方式一致；未分组时为 nil
+func (c *Controller) scorer(ctx *gin.Context, clientID uint64) biz_omiai.Scorer {
+	return c.experiments.Assign(clientID, ctx.GetUint64("user_id")).ScorerOr(nil)
+}
+
+// operatorName 当前操作人昵称，取不到时使用用户ID
+func (c *Controller) operatorName(ctx *gin.Context) string {
+	id := ctx.GetUint64("user_id")
+	if id == 0 {
+		return "Admin"
+	}
+	if user, err := c.user.GetByID(ctx, id); err == nil && user != nil {
+		return user.Nickname
+	}
+	return fmt.Sprintf("User:%d", id)
+}
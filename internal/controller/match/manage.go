@@ -1,6 +1,7 @@
 package match
 
 import (
+	"errors"
 	"fmt"
 	"omiai-server/internal/biz"
 	biz_omiai "omiai-server/internal/biz/omiai"
@@ -113,7 +114,7 @@ func (c *Controller) Dissolve(ctx *gin.Context) {
 	}
 
 	if err := c.match.DissolveMatch(ctx, req.ClientID, operator, req.Reason); err != nil {
-		response.ErrorResponse(ctx, response.DBUpdateCommonError, err.Error())
+		c.matchStatusError(ctx, err, err.Error())
 		return
 	}
 
@@ -161,13 +162,25 @@ func (c *Controller) UpdateStatus(ctx *gin.Context) {
 	}
 
 	if err := c.match.UpdateStatus(ctx, record.ID, record.Status, req.Status, operator, req.Reason); err != nil {
-		response.ErrorResponse(ctx, response.DBUpdateCommonError, "更新状态失败")
+		c.matchStatusError(ctx, err, "更新状态失败")
 		return
 	}
 
 	response.SuccessResponse(ctx, "更新成功", nil)
 }
 
+// matchStatusError 状态流转错误返回专用错误码，便于前端提示刷新或禁用操作
+func (c *Controller) matchStatusError(ctx *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, biz_omiai.ErrMatchInvalidTransition):
+		response.ErrorResponse(ctx, response.MatchInvalidTransition, err.Error())
+	case errors.Is(err, biz_omiai.ErrMatchStatusChanged):
+		response.ErrorResponse(ctx, response.MatchStatusConflict, err.Error())
+	default:
+		response.ErrorResponse(ctx, response.DBUpdateCommonError, fallback)
+	}
+}
+
 func (c *Controller) GetStatusHistory(ctx *gin.Context) {
 	idStr := ctx.Query("match_record_id")
 	if idStr == "" {
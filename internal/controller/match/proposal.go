@@ -0,0 +1,146 @@
+package match
+
+import (
+	"errors"
+
+	"omiai-server/internal/biz"
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/validates"
+	"omiai-server/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// proposalView 配对建议，附带标签与状态说明
+type proposalView struct {
+	*biz_omiai.Proposal
+	Tags       []string `json:"tags"`
+	StatusText string   `json:"status_text"`
+}
+
+// GenerateProposals 对单身客户池批量求解，生成一批待审核的配对建议
+func (c *Controller) GenerateProposals(ctx *gin.Context) {
+	var req validates.ProposalGenerateValidate
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+
+	batch, _, err := c.proposals.Generate(ctx, &biz_omiai.ProposalOptions{
+		Strategy:        req.Strategy,
+		CandidateCap:    req.CandidateCap,
+		MinScore:        req.MinScore,
+		RequirementMode: biz_omiai.ParseRequirementMode(req.RequirementMode),
+		Operator:        c.operatorName(ctx),
+	})
+	if err != nil {
+		c.proposalError(ctx, err, response.DBInsertCommonError, "生成配对建议失败")
+		return
+	}
+	response.SuccessResponse(ctx, "生成成功", batch)
+}
+
+// ListProposalBatches 配对建议批次列表
+func (c *Controller) ListProposalBatches(ctx *gin.Context) {
+	var req validates.Paginate
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+
+	list, total, err := c.proposal.ListBatches(ctx, req.Offset(), req.Limit())
+	if err != nil {
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "查询失败")
+		return
+	}
+	response.SuccessResponse(ctx, "ok", map[string]interface{}{
+		"list":  list,
+		"total": total,
+	})
+}
+
+// GetProposalBatch 批次详情及其中的配对建议，可按审核状态筛选
+func (c *Controller) GetProposalBatch(ctx *gin.Context) {
+	var req validates.ProposalBatchDetailValidate
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		response.ValidateError(ctx, err, response.ParamsCommonError)
+		return
+	}
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+
+	batch, err := c.proposal.GetBatch(ctx, req.ID)
+	if err != nil {
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "批次不存在")
+		return
+	}
+	clause := &biz.WhereClause{Where: "batch_id = ?", Args: []interface{}{req.ID}}
+	if req.Status > 0 {
+		clause.Where += " AND status = ?"
+		clause.Args = append(clause.Args, req.Status)
+	}
+	list, total, err := c.proposal.Select(ctx, clause, req.Offset(), req.Limit())
+	if err != nil {
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "查询失败")
+		return
+	}
+
+	views := make([]*proposalView, 0, len(list))
+	for _, p := range list {
+		views = append(views, &proposalView{Proposal: p, Tags: p.TagList(), StatusText: biz_omiai.ProposalStatusText[p.Status]})
+	}
+	response.SuccessResponse(ctx, "ok", map[string]interface{}{
+		"batch": batch,
+		"list":  views,
+		"total": total,
+	})
+}
+
+// AcceptProposal 采纳建议，以该配对发起介绍
+func (c *Controller) AcceptProposal(ctx *gin.Context) {
+	var req validates.ProposalAcceptValidate
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+
+	intro, err := c.proposals.Accept(ctx, req.ID, ctx.GetUint64("user_id"), c.operatorName(ctx), req.Remark)
+	if err != nil {
+		c.proposalError(ctx, err, response.DBUpdateCommonError, "采纳失败")
+		return
+	}
+	response.SuccessResponse(ctx, "已发起介绍", intro)
+}
+
+// RejectProposal 驳回建议，该配对之后不再被推荐
+func (c *Controller) RejectProposal(ctx *gin.Context) {
+	var req validates.ProposalRejectValidate
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+
+	if err := c.proposals.Reject(ctx, req.ID, req.ReasonCode, c.operatorName(ctx), req.Remark); err != nil {
+		c.proposalError(ctx, err, response.DBUpdateCommonError, "驳回失败")
+		return
+	}
+	response.SuccessResponse(ctx, "已驳回", nil)
+}
+
+func (c *Controller) proposalError(ctx *gin.Context, err error, code response.Code, fallback string) {
+	switch {
+	case errors.Is(err, biz_omiai.ErrProposalStrategy),
+		errors.Is(err, biz_omiai.ErrProposalReviewed),
+		errors.Is(err, biz_omiai.ErrProposalEmpty),
+		errors.Is(err, biz_omiai.ErrPairReason):
+		response.ErrorResponse(ctx, response.FuncCommonError, err.Error())
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "配对建议不存在")
+	default:
+		// 采纳时发起介绍失败（如客户已不是单身）沿用介绍流程的提示
+		c.introError(ctx, err, code, fallback)
+	}
+}
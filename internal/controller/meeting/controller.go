@@ -0,0 +1,42 @@
+package meeting
+
+import (
+	"fmt"
+	"time"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/service/meeting"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Controller struct {
+	meeting  biz_omiai.MeetingInterface
+	user     biz_omiai.UserInterface
+	meetings *meeting.Service
+}
+
+func NewController(meetingRepo biz_omiai.MeetingInterface, user biz_omiai.UserInterface, meetings *meeting.Service) *Controller {
+	return &Controller{meeting: meetingRepo, user: user, meetings: meetings}
+}
+
+func (c *Controller) operatorName(ctx *gin.Context) string {
+	id := ctx.GetUint64("user_id")
+	if id == 0 {
+		return "Admin"
+	}
+	if user, err := c.user.GetByID(ctx, id); err == nil && user != nil {
+		return user.Nickname
+	}
+	return fmt.Sprintf("User:%d", id)
+}
+
+func parseTime(val string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, val); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04:05", val, time.Local); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02 15:04", val, time.Local)
+}
@@ -0,0 +1,247 @@
+package meeting
+
+import (
+	"errors"
+	"time"
+
+	"omiai-server/internal/biz"
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/service/meeting"
+	"omiai-server/internal/validates"
+	"omiai-server/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// List 见面列表
+func (c *Controller) List(ctx *gin.Context) {
+	var req validates.MeetingListValidate
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+
+	clause := &biz.WhereClause{Where: "1=1"}
+	if req.Status > 0 {
+		clause.Where += " AND status = ?"
+		clause.Args = append(clause.Args, req.Status)
+	}
+	if req.ClientID > 0 {
+		clause.Where += " AND (client_a_id = ? OR client_b_id = ?)"
+		clause.Args = append(clause.Args, req.ClientID, req.ClientID)
+	}
+	if req.VenueID > 0 {
+		clause.Where += " AND venue_id = ?"
+		clause.Args = append(clause.Args, req.VenueID)
+	}
+	if req.IntroductionID > 0 {
+		clause.Where += " AND introduction_id = ?"
+		clause.Args = append(clause.Args, req.IntroductionID)
+	}
+	if req.MatchRecordID > 0 {
+		clause.Where += " AND match_record_id = ?"
+		clause.Args = append(clause.Args, req.MatchRecordID)
+	}
+	if req.Mine {
+		clause.Where += " AND matchmaker_id = ?"
+		clause.Args = append(clause.Args, ctx.GetUint64("user_id"))
+	}
+	if req.StartDate != "" {
+		if t, err := time.ParseInLocation(biz_omiai.DailyDateLayout, req.StartDate, time.Local); err == nil {
+			clause.Where += " AND start_at >= ?"
+			clause.Args = append(clause.Args, t)
+		}
+	}
+	if req.EndDate != "" {
+		if t, err := time.ParseInLocation(biz_omiai.DailyDateLayout, req.EndDate, time.Local); err == nil {
+			clause.Where += " AND start_at < ?"
+			clause.Args = append(clause.Args, t.AddDate(0, 0, 1))
+		}
+	}
+	list, total, err := c.meeting.Select(ctx, clause, req.Offset(), req.Limit())
+	if err != nil {
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "查询失败")
+		return
+	}
+	response.SuccessResponse(ctx, "ok", map[string]interface{}{
+		"list":  list,
+		"total": total,
+	})
+}
+
+// Detail 见面详情
+func (c *Controller) Detail(ctx *gin.Context) {
+	var req validates.MeetingDetailValidate
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		response.ValidateError(ctx, err, response.ParamsCommonError)
+		return
+	}
+	m, err := c.meeting.Get(ctx, req.ID)
+	if err != nil {
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "见面不存在")
+		return
+	}
+	response.SuccessResponse(ctx, "ok", map[string]interface{}{
+		"meeting":     m,
+		"status_text": biz_omiai.MeetingStatusText[m.Status],
+	})
+}
+
+// Book 预约见面，存在冲突时返回冲突明细
+func (c *Controller) Book(ctx *gin.Context) {
+	var req validates.MeetingBookValidate
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+	startAt, endAt, ok := bindRange(ctx, req.StartAt, req.EndAt)
+	if !ok {
+		return
+	}
+	m := &biz_omiai.Meeting{
+		VenueID:      optionalID(req.VenueID),
+		Place:        req.Place,
+		StartAt:      startAt,
+		EndAt:        endAt,
+		Remark:       req.Remark,
+		MatchmakerID: ctx.GetUint64("user_id"),
+		Operator:     c.operatorName(ctx),
+	}
+	m.IntroductionID = optionalID(req.IntroductionID)
+	m.MatchRecordID = optionalID(req.MatchRecordID)
+	if err := c.meetings.Book(ctx, m, req.Force); err != nil {
+		c.meetingError(ctx, err, response.DBInsertCommonError, "预约见面失败")
+		return
+	}
+	response.SuccessResponse(ctx, "预约成功", m)
+}
+
+// Reschedule 改约见面
+func (c *Controller) Reschedule(ctx *gin.Context) {
+	var req validates.MeetingRescheduleValidate
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+	startAt, endAt, ok := bindRange(ctx, req.StartAt, req.EndAt)
+	if !ok {
+		return
+	}
+	m, err := c.meetings.Reschedule(ctx, &biz_omiai.Meeting{
+		ID:       req.ID,
+		VenueID:  optionalID(req.VenueID),
+		Place:    req.Place,
+		StartAt:  startAt,
+		EndAt:    endAt,
+		Remark:   req.Remark,
+		Operator: c.operatorName(ctx),
+	}, req.Force)
+	if err != nil {
+		c.meetingError(ctx, err, response.DBUpdateCommonError, "改约失败")
+		return
+	}
+	response.SuccessResponse(ctx, "改约成功", m)
+}
+
+// Complete 标记已见面并记录反馈
+func (c *Controller) Complete(ctx *gin.Context) {
+	c.finish(ctx, biz_omiai.MeetingStatusCompleted, "已记录见面结果")
+}
+
+// Cancel 取消见面
+func (c *Controller) Cancel(ctx *gin.Context) {
+	c.finish(ctx, biz_omiai.MeetingStatusCancelled, "已取消见面")
+}
+
+// NoShow 标记爽约
+func (c *Controller) NoShow(ctx *gin.Context) {
+	c.finish(ctx, biz_omiai.MeetingStatusNoShow, "已标记爽约")
+}
+
+func (c *Controller) finish(ctx *gin.Context, status int8, msg string) {
+	var req validates.MeetingFinishValidate
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+	if err := c.meetings.Finish(ctx, req.ID, status, req.Feedback, c.operatorName(ctx)); err != nil {
+		c.meetingError(ctx, err, response.DBUpdateCommonError, "操作失败")
+		return
+	}
+	response.SuccessResponse(ctx, msg, nil)
+}
+
+// Slots 推荐某天双方均可约且无冲突的时段
+func (c *Controller) Slots(ctx *gin.Context) {
+	var req validates.MeetingSlotsValidate
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+	date, err := time.ParseInLocation(biz_omiai.DailyDateLayout, req.Date, time.Local)
+	if err != nil {
+		response.ErrorResponse(ctx, response.ParamsCommonError, "日期格式应为 YYYY-MM-DD")
+		return
+	}
+	slots, err := c.meetings.Slots(ctx, &meeting.SlotQuery{
+		ClientIDs:    []uint64{req.ClientAID, req.ClientBID},
+		VenueID:      req.VenueID,
+		MatchmakerID: ctx.GetUint64("user_id"),
+		Date:         date,
+		Duration:     time.Duration(req.Minutes) * time.Minute,
+	})
+	if err != nil {
+		c.meetingError(ctx, err, response.DBSelectCommonError, "查询可约时段失败")
+		return
+	}
+	response.SuccessResponse(ctx, "ok", slots)
+}
+
+func bindRange(ctx *gin.Context, start, end string) (time.Time, time.Time, bool) {
+	startAt, err := parseTime(start)
+	if err != nil {
+		response.ErrorResponse(ctx, response.ParamsCommonError, "开始时间格式错误")
+		return time.Time{}, time.Time{}, false
+	}
+	var endAt time.Time
+	if end != "" {
+		if endAt, err = parseTime(end); err != nil {
+			response.ErrorResponse(ctx, response.ParamsCommonError, "结束时间格式错误")
+			return time.Time{}, time.Time{}, false
+		}
+	}
+	return startAt, endAt, true
+}
+
+func optionalID(id uint64) *uint64 {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
+
+func (c *Controller) meetingError(ctx *gin.Context, err error, code response.Code, fallback string) {
+	var conflict *biz_omiai.MeetingConflictError
+	switch {
+	case errors.As(err, &conflict):
+		response.ErrorResponseWithData(ctx, response.FuncCommonError, err.Error(), map[string]interface{}{
+			"conflicts": conflict.Conflicts,
+		})
+	case errors.Is(err, biz_omiai.ErrMeetingSource),
+		errors.Is(err, biz_omiai.ErrMeetingIntroStatus),
+		errors.Is(err, biz_omiai.ErrMeetingMatchBroken),
+		errors.Is(err, biz_omiai.ErrMeetingTime),
+		errors.Is(err, biz_omiai.ErrMeetingStatus),
+		errors.Is(err, biz_omiai.ErrVenueDisabled),
+		errors.Is(err, biz_omiai.ErrVenueHours),
+		errors.Is(err, biz_omiai.ErrAvailabilityInvalid),
+		errors.Is(err, biz_omiai.ErrIntroInvalidTransition),
+		errors.Is(err, biz_omiai.ErrIntroStatusChanged):
+		response.ErrorResponse(ctx, response.FuncCommonError, err.Error())
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "见面、场地、介绍或情侣档案不存在")
+	default:
+		response.ErrorResponse(ctx, code, fallback)
+	}
+}
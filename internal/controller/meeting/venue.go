@@ -0,0 +1,136 @@
+package meeting
+
+import (
+	"omiai-server/internal/biz"
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/validates"
+	"omiai-server/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListVenues 场地列表
+func (c *Controller) ListVenues(ctx *gin.Context) {
+	var req validates.VenueListValidate
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+
+	clause := &biz.WhereClause{Where: "1=1"}
+	if req.Name != "" {
+		clause.Where += " AND name LIKE ?"
+		clause.Args = append(clause.Args, "%"+req.Name+"%")
+	}
+	if req.CityCode != "" {
+		clause.Where += " AND city_code = ?"
+		clause.Args = append(clause.Args, req.CityCode)
+	}
+	if req.IsEnabled != nil {
+		clause.Where += " AND is_enabled = ?"
+		clause.Args = append(clause.Args, *req.IsEnabled)
+	}
+	list, total, err := c.meeting.SelectVenues(ctx, clause, req.Offset(), req.Limit())
+	if err != nil {
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "查询失败")
+		return
+	}
+	response.SuccessResponse(ctx, "ok", map[string]interface{}{
+		"list":  list,
+		"total": total,
+	})
+}
+
+// CreateVenue 新增场地
+func (c *Controller) CreateVenue(ctx *gin.Context) {
+	var req validates.VenueSaveValidate
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+	v := bindVenue(&req)
+	if err := c.meetings.CreateVenue(ctx, v); err != nil {
+		c.meetingError(ctx, err, response.DBInsertCommonError, "新增场地失败")
+		return
+	}
+	response.SuccessResponse(ctx, "创建成功", v)
+}
+
+// UpdateVenue 修改场地
+func (c *Controller) UpdateVenue(ctx *gin.Context) {
+	var req validates.VenueSaveValidate
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+	if req.ID == 0 {
+		response.ErrorResponse(ctx, response.ParamsCommonError, "缺少场地ID")
+		return
+	}
+	if err := c.meetings.UpdateVenue(ctx, bindVenue(&req)); err != nil {
+		c.meetingError(ctx, err, response.DBUpdateCommonError, "修改场地失败")
+		return
+	}
+	response.SuccessResponse(ctx, "修改成功", nil)
+}
+
+func bindVenue(req *validates.VenueSaveValidate) *biz_omiai.Venue {
+	v := &biz_omiai.Venue{
+		ID:           req.ID,
+		Name:         req.Name,
+		Address:      req.Address,
+		ProvinceCode: req.ProvinceCode,
+		CityCode:     req.CityCode,
+		DistrictCode: req.DistrictCode,
+		Capacity:     req.Capacity,
+		OpenTime:     req.OpenTime,
+		CloseTime:    req.CloseTime,
+		IsEnabled:    true,
+		Remark:       req.Remark,
+	}
+	if req.IsEnabled != nil {
+		v.IsEnabled = *req.IsEnabled
+	}
+	return v
+}
+
+// GetAvailability 客户的每周可约时间
+func (c *Controller) GetAvailability(ctx *gin.Context) {
+	var req validates.AvailabilityGetValidate
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+	avail, err := c.meeting.Availability(ctx, []uint64{req.ClientID})
+	if err != nil {
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "查询失败")
+		return
+	}
+	list := avail[req.ClientID]
+	if list == nil {
+		list = []*biz_omiai.ClientAvailability{}
+	}
+	response.SuccessResponse(ctx, "ok", list)
+}
+
+// SetAvailability 设置客户的每周可约时间，覆盖此前的设置
+func (c *Controller) SetAvailability(ctx *gin.Context) {
+	var req validates.AvailabilitySetValidate
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+	list := make([]*biz_omiai.ClientAvailability, 0, len(req.Items))
+	for _, item := range req.Items {
+		list = append(list, &biz_omiai.ClientAvailability{
+			Weekday:   item.Weekday,
+			StartTime: item.StartTime,
+			EndTime:   item.EndTime,
+		})
+	}
+	if err := c.meetings.SetAvailability(ctx, req.ClientID, list); err != nil {
+		c.meetingError(ctx, err, response.DBUpdateCommonError, "保存可约时间失败")
+		return
+	}
+	response.SuccessResponse(ctx, "保存成功", list)
+}
@@ -0,0 +1,42 @@
+package party
+
+import (
+	"fmt"
+	"time"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/service/party"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Controller struct {
+	party   biz_omiai.PartyInterface
+	user    biz_omiai.UserInterface
+	parties *party.Service
+}
+
+func NewController(partyRepo biz_omiai.PartyInterface, user biz_omiai.UserInterface, parties *party.Service) *Controller {
+	return &Controller{party: partyRepo, user: user, parties: parties}
+}
+
+func (c *Controller) operatorName(ctx *gin.Context) string {
+	id := ctx.GetUint64("user_id")
+	if id == 0 {
+		return "Admin"
+	}
+	if user, err := c.user.GetByID(ctx, id); err == nil && user != nil {
+		return user.Nickname
+	}
+	return fmt.Sprintf("User:%d", id)
+}
+
+func parseTime(val string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, val); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04:05", val, time.Local); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02 15:04", val, time.Local)
+}
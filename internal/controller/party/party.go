@@ -0,0 +1,302 @@
+package party
+
+import (
+	"errors"
+
+	"omiai-server/internal/biz"
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/validates"
+	"omiai-server/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// attendeeView 报名记录，附带状态说明
+type attendeeView struct {
+	*biz_omiai.PartyAttendee
+	StatusText string `json:"status_text"`
+}
+
+// Create 创建相亲会
+func (c *Controller) Create(ctx *gin.Context) {
+	var req validates.PartySaveValidate
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+	p, ok := c.bindParty(ctx, &req)
+	if !ok {
+		return
+	}
+	p.MatchmakerID = ctx.GetUint64("user_id")
+	if err := c.parties.Create(ctx, p); err != nil {
+		c.partyError(ctx, err, response.DBInsertCommonError, "创建相亲会失败")
+		return
+	}
+	response.SuccessResponse(ctx, "创建成功", p)
+}
+
+// Update 修改相亲会
+func (c *Controller) Update(ctx *gin.Context) {
+	var req validates.PartySaveValidate
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+	if req.ID == 0 {
+		response.ErrorResponse(ctx, response.ParamsCommonError, "缺少相亲会ID")
+		return
+	}
+	p, ok := c.bindParty(ctx, &req)
+	if !ok {
+		return
+	}
+	if err := c.parties.Update(ctx, p); err != nil {
+		c.partyError(ctx, err, response.DBUpdateCommonError, "修改相亲会失败")
+		return
+	}
+	response.SuccessResponse(ctx, "修改成功", nil)
+}
+
+func (c *Controller) bindParty(ctx *gin.Context, req *validates.PartySaveValidate) (*biz_omiai.Party, bool) {
+	startAt, err := parseTime(req.StartAt)
+	if err != nil {
+		response.ErrorResponse(ctx, response.ParamsCommonError, "开始时间格式错误")
+		return nil, false
+	}
+	p := &biz_omiai.Party{
+		ID:           req.ID,
+		Title:        req.Title,
+		Venue:        req.Venue,
+		StartAt:      startAt,
+		Capacity:     req.Capacity,
+		MaleQuota:    req.MaleQuota,
+		FemaleQuota:  req.FemaleQuota,
+		Rounds:       req.Rounds,
+		RoundMinutes: req.RoundMinutes,
+		PickLimit:    req.PickLimit,
+		MutualAction: req.MutualAction,
+		Remark:       req.Remark,
+	}
+	if req.EndAt != "" {
+		endAt, err := parseTime(req.EndAt)
+		if err != nil || endAt.Before(startAt) {
+			response.ErrorResponse(ctx, response.ParamsCommonError, "结束时间格式错误或早于开始时间")
+			return nil, false
+		}
+		p.EndAt = &endAt
+	}
+	return p, true
+}
+
+// List 相亲会列表
+func (c *Controller) List(ctx *gin.Context) {
+	var req validates.PartyListValidate
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+
+	clause := &biz.WhereClause{Where: "1=1"}
+	if req.Status > 0 {
+		clause.Where += " AND status = ?"
+		clause.Args = append(clause.Args, req.Status)
+	}
+	if req.Title != "" {
+		clause.Where += " AND title LIKE ?"
+		clause.Args = append(clause.Args, "%"+req.Title+"%")
+	}
+	list, total, err := c.party.Select(ctx, clause, req.Offset(), req.Limit())
+	if err != nil {
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "查询失败")
+		return
+	}
+	response.SuccessResponse(ctx, "ok", map[string]interface{}{
+		"list":  list,
+		"total": total,
+	})
+}
+
+// Detail 相亲会详情：报名与签到情况、轮转安排及会后选择
+func (c *Controller) Detail(ctx *gin.Context) {
+	var req validates.PartyDetailValidate
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		response.ValidateError(ctx, err, response.ParamsCommonError)
+		return
+	}
+
+	p, err := c.party.Get(ctx, req.ID)
+	if err != nil {
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "相亲会不存在")
+		return
+	}
+	attendees, err := c.party.Attendees(ctx, req.ID)
+	if err != nil {
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "查询失败")
+		return
+	}
+	seats, err := c.party.Seats(ctx, req.ID)
+	if err != nil {
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "查询失败")
+		return
+	}
+	picks, err := c.party.Picks(ctx, req.ID)
+	if err != nil {
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "查询失败")
+		return
+	}
+
+	views := make([]*attendeeView, 0, len(attendees))
+	counts := make(map[int8]map[int8]int)
+	for _, a := range attendees {
+		views = append(views, &attendeeView{PartyAttendee: a, StatusText: biz_omiai.AttendeeStatusText[a.Status]})
+		if counts[a.Gender] == nil {
+			counts[a.Gender] = make(map[int8]int)
+		}
+		counts[a.Gender][a.Status]++
+	}
+	response.SuccessResponse(ctx, "ok", map[string]interface{}{
+		"party":       p,
+		"status_text": biz_omiai.PartyStatusText[p.Status],
+		"attendees":   views,
+		"counts":      counts,
+		"seats":       seats,
+		"picks":       picks,
+	})
+}
+
+// Transition 开始活动、进入互选或取消
+func (c *Controller) Transition(ctx *gin.Context) {
+	var req validates.PartyTransitionValidate
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+
+	p, err := c.parties.Transition(ctx, req.ID, req.Status)
+	if err != nil {
+		c.partyError(ctx, err, response.DBUpdateCommonError, "操作失败")
+		return
+	}
+	response.SuccessResponse(ctx, "操作成功", p)
+}
+
+// SignUp 客户报名，名额已满时进入候补
+func (c *Controller) SignUp(ctx *gin.Context) {
+	var req validates.PartyAttendeeValidate
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+
+	a, err := c.parties.SignUp(ctx, req.ID, req.ClientID, c.operatorName(ctx))
+	if err != nil {
+		c.partyError(ctx, err, response.DBInsertCommonError, "报名失败")
+		return
+	}
+	msg := "报名成功"
+	if a.Status == biz_omiai.AttendeeStatusWaitlist {
+		msg = "名额已满，已加入候补"
+	}
+	response.SuccessResponse(ctx, msg, a)
+}
+
+// CancelSignUp 取消报名
+func (c *Controller) CancelSignUp(ctx *gin.Context) {
+	var req validates.PartyAttendeeValidate
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+
+	if err := c.parties.Cancel(ctx, req.ID, req.ClientID, c.operatorName(ctx)); err != nil {
+		c.partyError(ctx, err, response.DBUpdateCommonError, "取消报名失败")
+		return
+	}
+	response.SuccessResponse(ctx, "已取消报名", nil)
+}
+
+// CheckIn 到场签到
+func (c *Controller) CheckIn(ctx *gin.Context) {
+	var req validates.PartyAttendeeValidate
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+
+	a, err := c.parties.CheckIn(ctx, req.ID, req.ClientID, c.operatorName(ctx))
+	if err != nil {
+		c.partyError(ctx, err, response.DBUpdateCommonError, "签到失败")
+		return
+	}
+	response.SuccessResponse(ctx, "签到成功", a)
+}
+
+// Schedule 按已签到嘉宾生成轮转安排
+func (c *Controller) Schedule(ctx *gin.Context) {
+	var req validates.PartyIDValidate
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+
+	seats, err := c.parties.Schedule(ctx, req.ID)
+	if err != nil {
+		c.partyError(ctx, err, response.DBInsertCommonError, "生成轮转安排失败")
+		return
+	}
+	response.SuccessResponse(ctx, "生成成功", seats)
+}
+
+// SubmitPicks 录入嘉宾的会后选择
+func (c *Controller) SubmitPicks(ctx *gin.Context) {
+	var req validates.PartyPicksValidate
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+
+	if err := c.parties.SubmitPicks(ctx, req.ID, req.ClientID, req.PickedIDs); err != nil {
+		c.partyError(ctx, err, response.DBInsertCommonError, "保存选择失败")
+		return
+	}
+	response.SuccessResponse(ctx, "保存成功", nil)
+}
+
+// Finalize 结束互选，双向选择的配对发起介绍或生成配对建议
+func (c *Controller) Finalize(ctx *gin.Context) {
+	var req validates.PartyIDValidate
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+
+	result, err := c.parties.Finalize(ctx, req.ID, ctx.GetUint64("user_id"), c.operatorName(ctx))
+	if err != nil {
+		c.partyError(ctx, err, response.DBUpdateCommonError, "生成配对失败")
+		return
+	}
+	response.SuccessResponse(ctx, "互选结束", result)
+}
+
+func (c *Controller) partyError(ctx *gin.Context, err error, code response.Code, fallback string) {
+	switch {
+	case errors.Is(err, biz_omiai.ErrPartyInvalidTransition),
+		errors.Is(err, biz_omiai.ErrPartyStatusChanged),
+		errors.Is(err, biz_omiai.ErrPartyQuota),
+		errors.Is(err, biz_omiai.ErrPartyClientUnavailable),
+		errors.Is(err, biz_omiai.ErrPartySignedUp),
+		errors.Is(err, biz_omiai.ErrPartyNotSignedUp),
+		errors.Is(err, biz_omiai.ErrPartyNotCheckedIn),
+		errors.Is(err, biz_omiai.ErrPartyNoAttendees),
+		errors.Is(err, biz_omiai.ErrPartyNoSchedule),
+		errors.Is(err, biz_omiai.ErrPartyPickNotMet),
+		errors.Is(err, biz_omiai.ErrPartyPickLimit):
+		response.ErrorResponse(ctx, response.FuncCommonError, err.Error())
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "相亲会或客户不存在")
+	default:
+		response.ErrorResponse(ctx, code, fallback)
+	}
+}
@@ -0,0 +1,18 @@
+package questionnaire
+
+import (
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/conf"
+	"omiai-server/internal/service/questionnaire"
+)
+
+type Controller struct {
+	conf          *conf.Config
+	client        biz_omiai.ClientInterface
+	questionnaire biz_omiai.QuestionnaireInterface
+	service       *questionnaire.Service
+}
+
+func NewController(conf *conf.Config, client biz_omiai.ClientInterface, repo biz_omiai.QuestionnaireInterface, service *questionnaire.Service) *Controller {
+	return &Controller{conf: conf, client: client, questionnaire: repo, service: service}
+}
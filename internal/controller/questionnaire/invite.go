@@ -0,0 +1,77 @@
+package questionnaire
+
+import (
+	"errors"
+
+	"omiai-server/internal/service/questionnaire"
+	"omiai-server/internal/validates"
+	"omiai-server/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// questionView 填写页展示的题目，不暴露维度与计分方式
+type questionView struct {
+	Key  string `json:"key"`
+	Text string `json:"text"`
+}
+
+// Open 客户通过链接打开问卷（无需登录）
+func (c *Controller) Open(ctx *gin.Context) {
+	var req validates.QuestionnaireTokenValidate
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		response.ValidateError(ctx, err, response.ParamsCommonError)
+		return
+	}
+
+	sheet, err := c.service.Open(ctx, req.Token)
+	if err != nil {
+		c.sheetError(ctx, err)
+		return
+	}
+
+	questions := sheet.Questionnaire.QuestionList()
+	views := make([]questionView, 0, len(questions))
+	for _, q := range questions {
+		views = append(views, questionView{Key: q.Key, Text: q.Text})
+	}
+	response.SuccessResponse(ctx, "ok", map[string]interface{}{
+		"title":       sheet.Questionnaire.Title,
+		"description": sheet.Questionnaire.Description,
+		"expired_at":  sheet.ExpiredAt,
+		"questions":   views,
+	})
+}
+
+// Submit 客户提交问卷（无需登录）
+func (c *Controller) Submit(ctx *gin.Context) {
+	var uri validates.QuestionnaireTokenValidate
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		response.ValidateError(ctx, err, response.ParamsCommonError)
+		return
+	}
+	var req validates.QuestionnaireSubmitValidate
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+
+	if _, err := c.service.Submit(ctx, uri.Token, req.Answers); err != nil {
+		c.sheetError(ctx, err)
+		return
+	}
+	response.SuccessResponse(ctx, "提交成功", nil)
+}
+
+func (c *Controller) sheetError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, questionnaire.ErrSheetNotFound),
+		errors.Is(err, questionnaire.ErrSheetExpired),
+		errors.Is(err, questionnaire.ErrSheetSubmitted):
+		response.ErrorResponse(ctx, response.FuncCommonError, err.Error())
+	case errors.Is(err, questionnaire.ErrInvalidAnswer):
+		response.ErrorResponse(ctx, response.ParamsCommonError, err.Error())
+	default:
+		response.ErrorResponse(ctx, response.ServiceCommonError, "问卷提交失败，请稍后重试")
+	}
+}
@@ -0,0 +1,128 @@
+package questionnaire
+
+import (
+	"encoding/json"
+	"strings"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/service/questionnaire"
+	"omiai-server/internal/validates"
+	"omiai-server/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// List 问卷版本列表
+func (c *Controller) List(ctx *gin.Context) {
+	list, err := c.questionnaire.List(ctx, ctx.Query("code"))
+	if err != nil {
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "获取问卷列表失败")
+		return
+	}
+	response.SuccessResponse(ctx, "ok", list)
+}
+
+// Create 发布新版本问卷，同编码的旧版本自动停用，已发放的答卷仍按原版本计分
+func (c *Controller) Create(ctx *gin.Context) {
+	var req validates.QuestionnaireCreateValidate
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+	if err := questionnaire.ValidateQuestions(req.Questions); err != nil {
+		response.ErrorResponse(ctx, response.ParamsCommonError, err.Error())
+		return
+	}
+
+	code := req.Code
+	if code == "" {
+		code = biz_omiai.QuestionnaireCodePersonality
+	}
+	questions, _ := json.Marshal(req.Questions)
+	q := &biz_omiai.Questionnaire{
+		Code:        code,
+		Title:       req.Title,
+		Description: req.Description,
+		Questions:   string(questions),
+	}
+	if err := c.questionnaire.CreateVersion(ctx, q); err != nil {
+		response.ErrorResponse(ctx, response.DBInsertCommonError, "发布问卷失败")
+		return
+	}
+	response.SuccessResponse(ctx, "发布成功", q)
+}
+
+// Issue 为客户生成问卷填写链接
+func (c *Controller) Issue(ctx *gin.Context) {
+	var req validates.QuestionnaireIssueValidate
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+	if client, err := c.client.Get(ctx, req.ClientID); err != nil || client == nil {
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "客户档案不存在")
+		return
+	}
+
+	sheet, err := c.service.Issue(ctx, req.ClientID, req.QuestionnaireID, ctx.GetUint64("user_id"), req.ExpireDays)
+	if err != nil {
+		response.ErrorResponse(ctx, response.DBInsertCommonError, "生成问卷链接失败")
+		return
+	}
+
+	response.SuccessResponse(ctx, "生成成功", map[string]interface{}{
+		"token":      sheet.Token,
+		"link":       c.link(sheet.Token),
+		"expired_at": sheet.ExpiredAt,
+		"title":      sheet.Questionnaire.Title,
+		"version":    sheet.Questionnaire.Version,
+	})
+}
+
+// Profile 客户测评画像及答卷记录
+func (c *Controller) Profile(ctx *gin.Context) {
+	var req validates.QuestionnaireProfileValidate
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		response.ValidateError(ctx, err, response.ParamsCommonError)
+		return
+	}
+
+	profile, err := c.questionnaire.GetProfile(ctx, req.ClientID)
+	if err != nil {
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "获取测评画像失败")
+		return
+	}
+	sheets, err := c.questionnaire.ListSheets(ctx, req.ClientID)
+	if err != nil {
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "获取答卷记录失败")
+		return
+	}
+
+	data := map[string]interface{}{
+		"status": biz_omiai.ComparisonUnknown,
+		"sheets": sheets,
+	}
+	if scores := profile.ScoreMap(); scores != nil {
+		dimensions := make([]map[string]interface{}, 0, len(scores))
+		for _, d := range biz_omiai.ProfileDimensions {
+			if v, ok := scores[d.Key]; ok {
+				dimensions = append(dimensions, map[string]interface{}{
+					"key": d.Key, "label": d.Label, "group": d.Group, "score": v,
+				})
+			}
+		}
+		data["status"] = biz_omiai.ComparisonKnown
+		data["version"] = profile.Version
+		data["updated_at"] = profile.UpdatedAt
+		data["dimensions"] = dimensions
+	}
+	response.SuccessResponse(ctx, "ok", data)
+}
+
+func (c *Controller) link(token string) string {
+	h5 := ""
+	if c.conf != nil && c.conf.Domain != nil {
+		h5 = strings.TrimRight(c.conf.Domain.H5, "/")
+	}
+	return h5 + "/questionnaire/" + token
+}
@@ -0,0 +1,39 @@
+package segment
+
+import (
+	"fmt"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/service/segment"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Controller struct {
+	segments *segment.Service
+	repo     biz_omiai.SegmentInterface
+	client   biz_omiai.ClientInterface
+	tag      biz_omiai.TagInterface
+	user     biz_omiai.UserInterface
+}
+
+func NewController(segments *segment.Service, repo biz_omiai.SegmentInterface, client biz_omiai.ClientInterface, tag biz_omiai.TagInterface,
+	user biz_omiai.UserInterface) *Controller {
+	return &Controller{segments: segments, repo: repo, client: client, tag: tag, user: user}
+}
+
+func (c *Controller) operatorName(ctx *gin.Context) string {
+	id := ctx.GetUint64("user_id")
+	if id == 0 {
+		return "Admin"
+	}
+	if user, err := c.user.GetByID(ctx, id); err == nil && user != nil {
+		return user.Nickname
+	}
+	return fmt.Sprintf("User:%d", id)
+}
+
+func isAdmin(ctx *gin.Context) bool {
+	role, _ := ctx.Get("role")
+	return role == biz_omiai.RoleAdmin
+}
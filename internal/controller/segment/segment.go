@@ -0,0 +1,317 @@
+package segment
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"omiai-server/internal/biz"
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/validates"
+	"omiai-server/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iWuxc/go-wit/log"
+	"gorm.io/gorm"
+)
+
+// exportBatch 导出时每批读取的客户数
+const exportBatch = 200
+
+// List 可见的分群，附带实时人数
+func (c *Controller) List(ctx *gin.Context) {
+	var req validates.SegmentListValidate
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+	userID := ctx.GetUint64("user_id")
+	clause := &biz.WhereClause{Where: "1 = 1"}
+	if req.Mine {
+		clause.Where += " AND owner_id = ?"
+		clause.Args = append(clause.Args, userID)
+	} else if !isAdmin(ctx) {
+		clause.Where += " AND (shared = ? OR owner_id = ?)"
+		clause.Args = append(clause.Args, true, userID)
+	}
+	if req.Keyword != "" {
+		clause.Where += " AND name LIKE ?"
+		clause.Args = append(clause.Args, "%"+req.Keyword+"%")
+	}
+	list, total, err := c.repo.Select(ctx, clause, req.Offset(), req.Limit())
+	if err != nil {
+		log.WithContext(ctx).Errorf("Segment List err:%v", err)
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "查询失败")
+		return
+	}
+	for _, seg := range list {
+		if n, err := c.segments.Count(ctx, seg); err == nil {
+			seg.Count = &n
+		} else {
+			log.WithContext(ctx).Errorf("Segment List count %d err:%v", seg.ID, err)
+		}
+	}
+	response.SuccessResponse(ctx, "ok", map[string]interface{}{
+		"list":  list,
+		"total": total,
+	})
+}
+
+func (c *Controller) Detail(ctx *gin.Context) {
+	seg, ok := c.visible(ctx)
+	if !ok {
+		return
+	}
+	n, err := c.segments.Count(ctx, seg)
+	if err != nil {
+		segmentError(ctx, err, "查询失败")
+		return
+	}
+	seg.Count = &n
+	response.SuccessResponse(ctx, "ok", seg)
+}
+
+// Preview 保存前按筛选条件预览人数
+func (c *Controller) Preview(ctx *gin.Context) {
+	var req validates.SegmentPreviewValidate
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+	n, err := c.segments.Count(ctx, &biz_omiai.ClientSegment{Conditions: &req.Filter})
+	if err != nil {
+		segmentError(ctx, err, "查询失败")
+		return
+	}
+	response.SuccessResponse(ctx, "ok", map[string]interface{}{"count": n})
+}
+
+func (c *Controller) Create(ctx *gin.Context) {
+	var req validates.SegmentCreateValidate
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+	seg := &biz_omiai.ClientSegment{
+		Name:        strings.TrimSpace(req.Name),
+		Description: req.Description,
+		Shared:      req.Shared,
+		OwnerID:     ctx.GetUint64("user_id"),
+		OwnerName:   c.operatorName(ctx),
+	}
+	seg.SetConditions(&req.Filter)
+	if err := c.repo.Create(ctx, seg); err != nil {
+		segmentError(ctx, err, "创建失败")
+		return
+	}
+	// 建立初始成员快照，之后的进出才会触发事件
+	if _, err := c.segments.Refresh(ctx, seg); err != nil {
+		log.WithContext(ctx).Errorf("Segment Create refresh %d err:%v", seg.ID, err)
+	}
+	response.SuccessResponse(ctx, "创建成功", seg)
+}
+
+func (c *Controller) Update(ctx *gin.Context) {
+	var req validates.SegmentUpdateValidate
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+	seg, err := c.repo.Get(ctx, req.ID)
+	if err != nil {
+		segmentError(ctx, err, "修改失败")
+		return
+	}
+	if !seg.Editable(ctx.GetUint64("user_id"), isAdmin(ctx)) {
+		segmentError(ctx, biz_omiai.ErrSegmentForbidden, "修改失败")
+		return
+	}
+	seg.Name = strings.TrimSpace(req.Name)
+	seg.Description = req.Description
+	seg.Shared = req.Shared
+	seg.SetConditions(&req.Filter)
+	if err := c.repo.Update(ctx, seg); err != nil {
+		segmentError(ctx, err, "修改失败")
+		return
+	}
+	response.SuccessResponse(ctx, "修改成功", seg)
+}
+
+func (c *Controller) Delete(ctx *gin.Context) {
+	seg, ok := c.editable(ctx)
+	if !ok {
+		return
+	}
+	if err := c.repo.Delete(ctx, seg.ID); err != nil {
+		segmentError(ctx, err, "删除失败")
+		return
+	}
+	log.WithContext(ctx).Infof("Delete segment:%d operator:%s", seg.ID, c.operatorName(ctx))
+	response.SuccessResponse(ctx, "删除成功", nil)
+}
+
+// ClientIDs 分群当前的全部客户ID，供批量操作与活动邀约选择对象
+func (c *Controller) ClientIDs(ctx *gin.Context) {
+	seg, ok := c.visible(ctx)
+	if !ok {
+		return
+	}
+	ids, err := c.segments.ClientIDs(ctx, seg)
+	if err != nil {
+		segmentError(ctx, err, "查询失败")
+		return
+	}
+	response.SuccessResponse(ctx, "ok", map[string]interface{}{
+		"client_ids": ids,
+		"total":      len(ids),
+	})
+}
+
+// Refresh 立即刷新成员快照，返回本次进入与离开的客户
+func (c *Controller) Refresh(ctx *gin.Context) {
+	seg, ok := c.visible(ctx)
+	if !ok {
+		return
+	}
+	change, err := c.segments.Refresh(ctx, seg)
+	if err != nil {
+		segmentError(ctx, err, "刷新失败")
+		return
+	}
+	response.SuccessResponse(ctx, "刷新成功", change)
+}
+
+// AddTags 为分群当前的全部客户追加标签，已有标签保持不变
+func (c *Controller) AddTags(ctx *gin.Context) {
+	seg, ok := c.visible(ctx)
+	if !ok {
+		return
+	}
+	var req validates.ClientTagSetValidate
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+	tagIDs := req.TagIDs
+	if len(req.Names) > 0 {
+		tags, _, err := c.tag.Resolve(ctx, req.Names, true)
+		if err != nil {
+			segmentError(ctx, err, "保存失败")
+			return
+		}
+		for _, t := range tags {
+			tagIDs = append(tagIDs, t.ID)
+		}
+	}
+	if len(tagIDs) == 0 {
+		response.ErrorResponse(ctx, response.ParamsCommonError, "请选择标签")
+		return
+	}
+	ids, err := c.segments.ClientIDs(ctx, seg)
+	if err != nil {
+		segmentError(ctx, err, "保存失败")
+		return
+	}
+	operator := c.operatorName(ctx)
+	done := 0
+	for _, id := range ids {
+		if err := c.tag.AddClientTags(ctx, id, tagIDs, biz_omiai.TagSourceManual, 1, operator); err != nil {
+			log.WithContext(ctx).Errorf("Segment AddTags segment:%d client:%d err:%v", seg.ID, id, err)
+			continue
+		}
+		done++
+	}
+	log.WithContext(ctx).Infof("Segment AddTags segment:%d tags:%v clients:%d operator:%s", seg.ID, tagIDs, done, operator)
+	response.SuccessResponse(ctx, "保存成功", map[string]interface{}{"total": len(ids), "updated": done})
+}
+
+// Export 导出分群当前的全部客户为 CSV
+func (c *Controller) Export(ctx *gin.Context) {
+	seg, ok := c.visible(ctx)
+	if !ok {
+		return
+	}
+	ids, err := c.segments.ClientIDs(ctx, seg)
+	if err != nil {
+		segmentError(ctx, err, "导出失败")
+		return
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("\xEF\xBB\xBF") // BOM，便于 Excel 识别 UTF-8
+	w := csv.NewWriter(&buf)
+	_ = w.Write([]string{"ID", "姓名", "性别", "年龄", "联系电话", "工作城市", "具体工作", "标签", "录入时间"})
+	for start := 0; start < len(ids); start += exportBatch {
+		end := start + exportBatch
+		if end > len(ids) {
+			end = len(ids)
+		}
+		list, err := c.client.Select(ctx, &biz.WhereClause{Where: "id IN ?", Args: []interface{}{ids[start:end]}, OrderBy: "created_at desc"}, nil, 0, end-start)
+		if err != nil {
+			segmentError(ctx, err, "导出失败")
+			return
+		}
+		for _, v := range list {
+			gender := "男"
+			if v.Gender == 2 {
+				gender = "女"
+			}
+			_ = w.Write([]string{
+				strconv.FormatUint(v.ID, 10), v.Name, gender, strconv.Itoa(v.RealAge()), v.Phone,
+				v.WorkCity, v.Profession, strings.Join(v.TagList(), "、"), v.CreatedAt.Format("2006-01-02 15:04"),
+			})
+		}
+	}
+	w.Flush()
+	log.WithContext(ctx).Infof("Segment Export segment:%d clients:%d operator:%s", seg.ID, len(ids), c.operatorName(ctx))
+	filename := fmt.Sprintf("segment_%d_%s.csv", seg.ID, time.Now().Format("20060102"))
+	ctx.Header("Content-Disposition", "attachment; filename="+filename)
+	ctx.Data(200, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+// visible 读取路径中的分群，不存在或无权查看时写入错误响应
+func (c *Controller) visible(ctx *gin.Context) (*biz_omiai.ClientSegment, bool) {
+	var req validates.SegmentIDValidate
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		response.ValidateError(ctx, err, response.ParamsCommonError)
+		return nil, false
+	}
+	seg, err := c.repo.Get(ctx, req.ID)
+	if err == nil && !seg.Visible(ctx.GetUint64("user_id"), isAdmin(ctx)) {
+		err = gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		segmentError(ctx, err, "查询失败")
+		return nil, false
+	}
+	return seg, true
+}
+
+func (c *Controller) editable(ctx *gin.Context) (*biz_omiai.ClientSegment, bool) {
+	seg, ok := c.visible(ctx)
+	if !ok {
+		return nil, false
+	}
+	if !seg.Editable(ctx.GetUint64("user_id"), isAdmin(ctx)) {
+		segmentError(ctx, biz_omiai.ErrSegmentForbidden, "")
+		return nil, false
+	}
+	return seg, true
+}
+
+func segmentError(ctx *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, biz_omiai.ErrSegmentExists),
+		errors.Is(err, biz_omiai.ErrSegmentForbidden):
+		response.ErrorResponse(ctx, response.FuncCommonError, err.Error())
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "分群不存在")
+	default:
+		log.WithContext(ctx).Errorf("%s err:%v", msg, err)
+		response.ErrorResponse(ctx, response.DBUpdateCommonError, msg)
+	}
+}
@@ -0,0 +1,35 @@
+package tag
+
+import (
+	"fmt"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Controller struct {
+	tag  biz_omiai.TagInterface
+	user biz_omiai.UserInterface
+}
+
+func NewController(tag biz_omiai.TagInterface, user biz_omiai.UserInterface) *Controller {
+	return &Controller{tag: tag, user: user}
+}
+
+func (c *Controller) operatorName(ctx *gin.Context) string {
+	id := ctx.GetUint64("user_id")
+	if id == 0 {
+		return "Admin"
+	}
+	if user, err := c.user.GetByID(ctx, id); err == nil && user != nil {
+		return user.Nickname
+	}
+	return fmt.Sprintf("User:%d", id)
+}
+
+// isAdmin 删除、合并标签与调整分组影响全部客户，仅管理员可操作
+func isAdmin(ctx *gin.Context) bool {
+	role, _ := ctx.Get("role")
+	return role == biz_omiai.RoleAdmin
+}
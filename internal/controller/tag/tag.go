@@ -0,0 +1,234 @@
+package tag
+
+import (
+	"errors"
+
+	"omiai-server/internal/biz"
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/validates"
+	"omiai-server/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iWuxc/go-wit/log"
+	"gorm.io/gorm"
+)
+
+// Groups 标签分组
+func (c *Controller) Groups(ctx *gin.Context) {
+	list, err := c.tag.Groups(ctx)
+	if err != nil {
+		log.WithContext(ctx).Errorf("Tag Groups err:%v", err)
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "查询失败")
+		return
+	}
+	response.SuccessResponse(ctx, "ok", list)
+}
+
+// SaveGroup 新建或修改标签分组
+func (c *Controller) SaveGroup(ctx *gin.Context) {
+	if !isAdmin(ctx) {
+		response.ErrorResponse(ctx, response.FuncCommonError, "权限不足，仅管理员可调整标签分组")
+		return
+	}
+	var req validates.TagGroupSaveValidate
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+	g := &biz_omiai.TagGroup{ID: req.ID, Name: req.Name, Exclusive: req.Exclusive, Scored: req.Scored, Sort: req.Sort}
+	if err := c.tag.SaveGroup(ctx, g); err != nil {
+		tagError(ctx, err, "保存失败")
+		return
+	}
+	response.SuccessResponse(ctx, "保存成功", g)
+}
+
+// DeleteGroup 删除空的标签分组
+func (c *Controller) DeleteGroup(ctx *gin.Context) {
+	if !isAdmin(ctx) {
+		response.ErrorResponse(ctx, response.FuncCommonError, "权限不足，仅管理员可调整标签分组")
+		return
+	}
+	var req validates.TagIDValidate
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		response.ValidateError(ctx, err, response.ParamsCommonError)
+		return
+	}
+	if err := c.tag.DeleteGroup(ctx, req.ID); err != nil {
+		tagError(ctx, err, "删除失败")
+		return
+	}
+	response.SuccessResponse(ctx, "删除成功", nil)
+}
+
+// List 标签字典，附带使用该标签的客户数
+func (c *Controller) List(ctx *gin.Context) {
+	var req validates.TagListValidate
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+	clause := &biz.WhereClause{Where: "1 = 1"}
+	if req.GroupID != nil {
+		clause.Where += " AND group_id = ?"
+		clause.Args = append(clause.Args, *req.GroupID)
+	}
+	if req.Keyword != "" {
+		clause.Where += " AND (name LIKE ? OR synonyms LIKE ?)"
+		clause.Args = append(clause.Args, "%"+req.Keyword+"%", "%"+req.Keyword+"%")
+	}
+	list, total, err := c.tag.Select(ctx, clause, req.Offset(), req.Limit())
+	if err != nil {
+		log.WithContext(ctx).Errorf("Tag List err:%v", err)
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "查询失败")
+		return
+	}
+	response.SuccessResponse(ctx, "ok", map[string]interface{}{
+		"list":  list,
+		"total": total,
+	})
+}
+
+func (c *Controller) Detail(ctx *gin.Context) {
+	var req validates.TagIDValidate
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		response.ValidateError(ctx, err, response.ParamsCommonError)
+		return
+	}
+	t, err := c.tag.Get(ctx, req.ID)
+	if err != nil {
+		tagError(ctx, err, "查询失败")
+		return
+	}
+	response.SuccessResponse(ctx, "ok", t)
+}
+
+func (c *Controller) Create(ctx *gin.Context) {
+	var req validates.TagCreateValidate
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+	t := &biz_omiai.Tag{GroupID: req.GroupID, Name: req.Name, Sort: req.Sort}
+	t.SetSynonyms(req.Synonyms)
+	if err := c.tag.Create(ctx, t); err != nil {
+		tagError(ctx, err, "创建失败")
+		return
+	}
+	response.SuccessResponse(ctx, "创建成功", t)
+}
+
+func (c *Controller) Update(ctx *gin.Context) {
+	var req validates.TagUpdateValidate
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+	t := &biz_omiai.Tag{ID: req.ID, GroupID: req.GroupID, Name: req.Name, Sort: req.Sort}
+	t.SetSynonyms(req.Synonyms)
+	if err := c.tag.Update(ctx, t); err != nil {
+		tagError(ctx, err, "修改失败")
+		return
+	}
+	response.SuccessResponse(ctx, "修改成功", t)
+}
+
+// Delete 删除标签，已打上该标签的客户一并移除
+func (c *Controller) Delete(ctx *gin.Context) {
+	if !isAdmin(ctx) {
+		response.ErrorResponse(ctx, response.FuncCommonError, "权限不足，仅管理员可删除标签")
+		return
+	}
+	var req validates.TagIDValidate
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		response.ValidateError(ctx, err, response.ParamsCommonError)
+		return
+	}
+	if err := c.tag.Delete(ctx, req.ID); err != nil {
+		tagError(ctx, err, "删除失败")
+		return
+	}
+	log.WithContext(ctx).Infof("Delete tag:%d operator:%s", req.ID, c.operatorName(ctx))
+	response.SuccessResponse(ctx, "删除成功", nil)
+}
+
+// Merge 合并标签，源标签的名称与同义词并入目标标签
+func (c *Controller) Merge(ctx *gin.Context) {
+	if !isAdmin(ctx) {
+		response.ErrorResponse(ctx, response.FuncCommonError, "权限不足，仅管理员可合并标签")
+		return
+	}
+	var req validates.TagMergeValidate
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+	t, err := c.tag.Merge(ctx, req.SourceID, req.TargetID)
+	if err != nil {
+		tagError(ctx, err, "合并失败")
+		return
+	}
+	log.WithContext(ctx).Infof("Merge tag:%d into:%d operator:%s", req.SourceID, req.TargetID, c.operatorName(ctx))
+	response.SuccessResponse(ctx, "合并成功", t)
+}
+
+// ClientTags 客户的标签，含来源与置信度
+func (c *Controller) ClientTags(ctx *gin.Context) {
+	var req validates.TagIDValidate
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		response.ValidateError(ctx, err, response.ParamsCommonError)
+		return
+	}
+	list, err := c.tag.ClientTags(ctx, req.ID)
+	if err != nil {
+		log.WithContext(ctx).Errorf("ClientTags err:%v", err)
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "查询失败")
+		return
+	}
+	response.SuccessResponse(ctx, "ok", list)
+}
+
+// SetClientTags 替换客户的全部标签，保留的标签不改变来源与置信度
+func (c *Controller) SetClientTags(ctx *gin.Context) {
+	var uri validates.TagIDValidate
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		response.ValidateError(ctx, err, response.ParamsCommonError)
+		return
+	}
+	var req validates.ClientTagSetValidate
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(ctx, err, response.ValidateCommonError)
+		return
+	}
+	ids := req.TagIDs
+	if len(req.Names) > 0 {
+		tags, _, err := c.tag.Resolve(ctx, req.Names, true)
+		if err != nil {
+			tagError(ctx, err, "保存失败")
+			return
+		}
+		for _, t := range tags {
+			ids = append(ids, t.ID)
+		}
+	}
+	if err := c.tag.SetClientTags(ctx, uri.ID, ids, biz_omiai.TagSourceManual, c.operatorName(ctx)); err != nil {
+		tagError(ctx, err, "保存失败")
+		return
+	}
+	list, _ := c.tag.ClientTags(ctx, uri.ID)
+	response.SuccessResponse(ctx, "保存成功", list)
+}
+
+func tagError(ctx *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, biz_omiai.ErrTagExists),
+		errors.Is(err, biz_omiai.ErrTagMergeSelf),
+		errors.Is(err, biz_omiai.ErrTagGroupInUse):
+		response.ErrorResponse(ctx, response.FuncCommonError, err.Error())
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		response.ErrorResponse(ctx, response.DBSelectCommonError, "标签或客户不存在")
+	default:
+		log.WithContext(ctx).Errorf("%s err:%v", msg, err)
+		response.ErrorResponse(ctx, response.DBUpdateCommonError, msg)
+	}
+}
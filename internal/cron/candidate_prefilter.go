@@ -2,11 +2,9 @@ package cron
 
 import (
 	"context"
-	"encoding/json"
-	"math/rand"
 	biz_omiai "omiai-server/internal/biz/omiai"
 	"omiai-server/internal/data"
-	"sort"
+	"omiai-server/internal/service/experiment"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,11 +12,14 @@ import (
 )
 
 type CandidatePreFilterService struct {
-	db *data.DB
+	db          *data.DB
+	match       biz_omiai.MatchInterface
+	scorer      biz_omiai.Scorer
+	experiments *experiment.Service
 }
 
-func NewCandidatePreFilterService(db *data.DB) *CandidatePreFilterService {
-	return &CandidatePreFilterService{db: db}
+func NewCandidatePreFilterService(db *data.DB, match biz_omiai.MatchInterface, scorer biz_omiai.Scorer, experiments *experiment.Service) *CandidatePreFilterService {
+	return &CandidatePreFilterService{db: db, match: match, scorer: scorer, experiments: experiments}
 }
 
 func (s *CandidatePreFilterService) JobName() string {
@@ -53,115 +54,29 @@ func (s *CandidatePreFilterService) Run() {
 func (s *CandidatePreFilterService) Execute(ctx context.Context) {
 	// 1. Get all single clients
 	var clients []*biz_omiai.Client
-	if err := s.db.WithContext(ctx).Where("status = ?", biz_omiai.ClientStatusSingle).Find(&clients).Error; err != nil {
+	if err := s.db.WithContext(ctx).Scopes(biz_omiai.ScoringPreloads).Where("status = ?", biz_omiai.ClientStatusSingle).Find(&clients).Error; err != nil {
 		log.WithContext(ctx).Errorf("Failed to fetch clients: %v", err)
 		return
 	}
 
-	// 2. Pre-calculate candidates for each client
-	for _, client := range clients {
-		if client.Age == 0 {
-			client.Age = client.RealAge()
-		}
-
-		targetGender := 1
-		if client.Gender == 1 {
-			targetGender = 2
-		}
-
-		// Find potential matches (Status Single, Opposite Gender)
-		var potentialMatches []*biz_omiai.Client
-		if err := s.db.WithContext(ctx).Where("gender = ? AND status = ?", targetGender, biz_omiai.ClientStatusSingle).
-			Limit(100).Find(&potentialMatches).Error; err != nil {
-			log.WithContext(ctx).Errorf("Failed to fetch matches for client %d: %v", client.ID, err)
-			continue
-		}
-
-		var candidates []*biz_omiai.Candidate
-		for _, match := range potentialMatches {
-			if match.Age == 0 {
-				match.Age = match.RealAge()
-			}
-
-			// Scoring Logic
-			score := 60
-			tags := []string{}
-
-			// 1. Age Score (Max 20)
-			ageGap := abs(match.Age - client.Age)
-			if ageGap <= 3 {
-				score += 20
-				tags = append(tags, "年龄相仿")
-			} else if ageGap <= 5 {
-				score += 10
-			} else if ageGap > 10 {
-				score -= 10
-			}
-
-			// 2. Education Score (Max 15)
-			if match.Education >= client.Education {
-				score += 15
-				tags = append(tags, "学历相当")
-			} else if match.Education < client.Education-1 {
-				score -= 5
-			}
-
-			// 3. Height Score (Max 5)
-			if client.Gender == 1 { // Male Client prefers Female < Male
-				if match.Height > 0 && client.Height > 0 && match.Height < client.Height && match.Height > client.Height-20 {
-					score += 5
-				}
-			} else { // Female Client prefers Male > Female
-				if match.Height > 0 && client.Height > 0 && match.Height > client.Height {
-					score += 5
-				}
-			}
-
-			// 4. Random Jitter (0-5)
-			score += rand.Intn(6)
-
-			// Cap score
-			if score > 99 {
-				score = 99
-			}
-			if score < 40 {
-				score = 40
-			}
-
-			if len(tags) == 0 {
-				tags = append(tags, "缘分推荐")
-			}
-
-			candidates = append(candidates, &biz_omiai.Candidate{
-				CandidateID: match.ID,
-				Name:        match.Name,
-				Avatar:      match.Avatar,
-				MatchScore:  score,
-				Tags:        tags,
-				Age:         match.Age,
-				Height:      match.Height,
-				Education:   int(match.Education),
-			})
-		}
+	// 2. 清理已下线算法（实验结束、算法升级）遗留的推荐
+	keep := append([]string{s.scorer.Name()}, s.experiments.Algorithms()...)
+	if n, err := s.match.PruneRecommendations(ctx, keep); err != nil {
+		log.WithContext(ctx).Errorf("Failed to prune recommendations: %v", err)
+	} else if n > 0 {
+		log.WithContext(ctx).Infof("Pruned %d recommendations not in %v", n, keep)
+	}
 
-		sort.Slice(candidates, func(i, j int) bool {
-			return candidates[i].MatchScore > candidates[j].MatchScore
-		})
-		if len(candidates) > 20 {
-			candidates = candidates[:20]
+	// 3. Regenerate recommendations for each client
+	// 按客户分流的实验使用客户所在分组的评分器，避免查询时再次重新生成；
+	// 按红娘分流时预生成默认算法的推荐，各分组的推荐按算法分开保存，互不覆盖
+	for _, client := range clients {
+		var scorer biz_omiai.Scorer
+		if a := s.experiments.Assign(client.ID, 0); a != nil {
+			scorer = a.Scorer
 		}
-
-		if bytes, err := json.Marshal(candidates); err == nil {
-			if err := s.db.WithContext(ctx).Model(client).Update("candidate_cache_json", string(bytes)).Error; err != nil {
-				log.WithContext(ctx).Errorf("Failed to update cache for client %d: %v", client.ID, err)
-			}
+		if _, err := s.match.RefreshRecommendations(ctx, client, scorer); err != nil {
+			log.WithContext(ctx).Errorf("Failed to refresh recommendations for client %d: %v", client.ID, err)
 		}
 	}
 }
-
-func abs(n int) int {
-	if n < 0 {
-		return -n
-	}
-	return n
-}
@@ -2,12 +2,15 @@ package cron
 
 import (
 	"context"
-	"encoding/json"
 	"os"
 	"testing"
 
 	biz_omiai "omiai-server/internal/biz/omiai"
 	"omiai-server/internal/data"
+	"omiai-server/internal/data/omiai"
+	"omiai-server/internal/service/event"
+	"omiai-server/internal/service/experiment"
+	"omiai-server/internal/service/matching"
 
 	logger "github.com/iWuxc/go-wit/log"
 	"github.com/sirupsen/logrus"
@@ -27,7 +30,7 @@ func setupTestDB(t *testing.T) *data.DB {
 	assert.NoError(t, err)
 
 	// Migrate schemas
-	err = db.AutoMigrate(&biz_omiai.Client{})
+	err = db.AutoMigrate(&biz_omiai.Client{}, &biz_omiai.PersonalityProfile{}, &biz_omiai.ClientEmbedding{}, &biz_omiai.ClientTag{}, &biz_omiai.Tag{}, &biz_omiai.TagGroup{}, &biz_omiai.PairHistory{}, &biz_omiai.Recommendation{})
 	assert.NoError(t, err)
 
 	return &data.DB{DB: db}
@@ -35,7 +38,7 @@ func setupTestDB(t *testing.T) *data.DB {
 
 func TestCandidatePreFilterService_Run(t *testing.T) {
 	db := setupTestDB(t)
-	service := NewCandidatePreFilterService(db)
+	service := NewCandidatePreFilterService(db, omiai.NewMatchRepo(db, matching.NewScorer(nil, nil), event.NewBus()), matching.NewScorer(nil, nil), experiment.NewService(nil, nil, nil))
 
 	// Seed data
 	// Client A: Male, 30, Bachelor
@@ -91,15 +94,16 @@ func TestCandidatePreFilterService_Run(t *testing.T) {
 	service.Execute(ctx)
 
 	// Verify results
-	var updatedClientA biz_omiai.Client
-	err := db.First(&updatedClientA, clientA.ID).Error
+	var recommendations []*biz_omiai.Recommendation
+	err := db.Preload("Candidate").Where("client_id = ?", clientA.ID).Find(&recommendations).Error
 	assert.NoError(t, err)
 
-	assert.NotEmpty(t, updatedClientA.CandidateCacheJSON)
-
 	var candidates []*biz_omiai.Candidate
-	err = json.Unmarshal([]byte(updatedClientA.CandidateCacheJSON), &candidates)
-	assert.NoError(t, err)
+	for _, rec := range recommendations {
+		assert.Equal(t, matching.AlgorithmName, rec.Algorithm)
+		assert.False(t, rec.GeneratedAt.IsZero())
+		candidates = append(candidates, rec.ToCandidate())
+	}
 
 	// Expect Match B, C, E
 	assert.GreaterOrEqual(t, len(candidates), 3)
@@ -0,0 +1,60 @@
+package cron
+
+import (
+	"context"
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/conf"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iWuxc/go-wit/redis"
+)
+
+// DefaultRecycleRetentionDays 未配置 recycle.retention_days 时回收站的保留天数
+const DefaultRecycleRetentionDays = 30
+
+// ClientPurgeJob 每天清理回收站中超过保留期的客户
+type ClientPurgeJob struct {
+	client    biz_omiai.ClientInterface
+	retention time.Duration
+}
+
+func NewClientPurgeJob(c *conf.Config, client biz_omiai.ClientInterface) *ClientPurgeJob {
+	days := DefaultRecycleRetentionDays
+	if c != nil && c.Recycle != nil && c.Recycle.RetentionDays > 0 {
+		days = c.Recycle.RetentionDays
+	}
+	return &ClientPurgeJob{client: client, retention: time.Duration(days) * 24 * time.Hour}
+}
+
+func (j *ClientPurgeJob) JobName() string {
+	return "ClientPurgeJob"
+}
+
+func (j *ClientPurgeJob) Schedule() string {
+	// Daily at 3:30 AM
+	return "0 30 3 * * *"
+}
+
+func (j *ClientPurgeJob) Run() {
+	ctx := context.WithValue(context.Background(), "request_id", uuid.NewString())
+
+	lockKey := "lock:ClientPurgeJob"
+	lockRet := redis.GetRedis().GetClient().SetNX(ctx, lockKey, 1, time.Minute*30)
+	if lockRet.Err() != nil {
+		log.WithContext(ctx).Errorf("【定时任务-%s】 lockRet err:%s", j.JobName(), lockRet.Err().Error())
+		return
+	}
+	if !lockRet.Val() {
+		return
+	}
+	defer func() {
+		_ = redis.GetRedis().Delete(ctx, lockKey)
+	}()
+
+	purged, err := j.client.PurgeExpired(ctx, time.Now().Add(-j.retention))
+	if err != nil {
+		log.WithContext(ctx).Errorf("【定时任务-%s】 purge err:%v", j.JobName(), err)
+	}
+	log.WithContext(ctx).Infof("%s end, purged=%d", j.JobName(), purged)
+}
@@ -25,6 +25,12 @@ var (
 		NewCandidatePreFilterService,
 		NewReminderService,
 		NewReminderCronJob,
+		NewEmbeddingRebuildJob,
+		NewDailyRecommendationJob,
+		NewClientPurgeJob,
+		NewDuplicateScanJob,
+		NewSegmentRefreshJob,
+		NewQualityReportJob,
 	)
 )
 
@@ -32,6 +38,12 @@ type InitCron struct {
 	*UserProductFinalizer
 	*CandidatePreFilterService
 	*ReminderCronJob
+	*EmbeddingRebuildJob
+	*DailyRecommendationJob
+	*ClientPurgeJob
+	*DuplicateScanJob
+	*SegmentRefreshJob
+	*QualityReportJob
 }
 
 func jobs(cron *InitCron) []api.CronJobInterface {
@@ -39,6 +51,12 @@ func jobs(cron *InitCron) []api.CronJobInterface {
 		//cron.UserProductFinalizer,
 		cron.CandidatePreFilterService,
 		cron.ReminderCronJob,
+		cron.EmbeddingRebuildJob,
+		cron.DailyRecommendationJob,
+		cron.ClientPurgeJob,
+		cron.DuplicateScanJob,
+		cron.SegmentRefreshJob,
+		cron.QualityReportJob,
 	}
 }
 func NewCron(initCron *InitCron) (*dcron.Dcron, error) {
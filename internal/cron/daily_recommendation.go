@@ -0,0 +1,54 @@
+package cron
+
+import (
+	"context"
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/service/daily_recommendation"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iWuxc/go-wit/redis"
+)
+
+// DailyRecommendationJob 每天早上为单身客户生成当天的推荐，在候选人预筛之后执行
+type DailyRecommendationJob struct {
+	daily *daily_recommendation.Service
+}
+
+func NewDailyRecommendationJob(daily *daily_recommendation.Service) *DailyRecommendationJob {
+	return &DailyRecommendationJob{daily: daily}
+}
+
+func (j *DailyRecommendationJob) JobName() string {
+	return "DailyRecommendationJob"
+}
+
+func (j *DailyRecommendationJob) Schedule() string {
+	// Daily at 6:00 AM
+	return "0 0 6 * * *"
+}
+
+func (j *DailyRecommendationJob) Run() {
+	ctx := context.WithValue(context.Background(), "request_id", uuid.NewString())
+
+	lockKey := "lock:DailyRecommendationJob"
+	lockRet := redis.GetRedis().GetClient().SetNX(ctx, lockKey, 1, time.Minute*30)
+	if lockRet.Err() != nil {
+		log.WithContext(ctx).Errorf("【定时任务-%s】 lockRet err:%s", j.JobName(), lockRet.Err().Error())
+		return
+	}
+	if !lockRet.Val() {
+		return
+	}
+	defer func() {
+		_ = redis.GetRedis().Delete(ctx, lockKey)
+	}()
+
+	summary, err := j.daily.Generate(ctx, &biz_omiai.DailyOptions{})
+	if err != nil {
+		log.WithContext(ctx).Errorf("【定时任务-%s】 generate err:%v", j.JobName(), err)
+		return
+	}
+	log.WithContext(ctx).Infof("%s end, date=%s clients=%d skipped=%d generated=%d expired=%d", j.JobName(),
+		summary.Date, summary.Clients, summary.Skipped, summary.Generated, summary.Expired)
+}
@@ -0,0 +1,52 @@
+package cron
+
+import (
+	"context"
+	"omiai-server/internal/service/duplicate"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iWuxc/go-wit/redis"
+)
+
+// DuplicateScanJob 每天夜间全量查重，疑似重复的档案进入审核队列
+type DuplicateScanJob struct {
+	duplicates *duplicate.Service
+}
+
+func NewDuplicateScanJob(duplicates *duplicate.Service) *DuplicateScanJob {
+	return &DuplicateScanJob{duplicates: duplicates}
+}
+
+func (j *DuplicateScanJob) JobName() string {
+	return "DuplicateScanJob"
+}
+
+func (j *DuplicateScanJob) Schedule() string {
+	// Daily at 1:00 AM
+	return "0 0 1 * * *"
+}
+
+func (j *DuplicateScanJob) Run() {
+	ctx := context.WithValue(context.Background(), "request_id", uuid.NewString())
+
+	lockKey := "lock:DuplicateScanJob"
+	lockRet := redis.GetRedis().GetClient().SetNX(ctx, lockKey, 1, time.Minute*30)
+	if lockRet.Err() != nil {
+		log.WithContext(ctx).Errorf("【定时任务-%s】 lockRet err:%s", j.JobName(), lockRet.Err().Error())
+		return
+	}
+	if !lockRet.Val() {
+		return
+	}
+	defer func() {
+		_ = redis.GetRedis().Delete(ctx, lockKey)
+	}()
+
+	summary, err := j.duplicates.Scan(ctx)
+	if err != nil {
+		log.WithContext(ctx).Errorf("【定时任务-%s】 scan err:%v", j.JobName(), err)
+		return
+	}
+	log.WithContext(ctx).Infof("%s end, clients=%d pairs=%d created=%d", j.JobName(), summary.Clients, summary.Pairs, summary.Created)
+}
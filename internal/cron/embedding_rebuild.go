@@ -0,0 +1,52 @@
+package cron
+
+import (
+	"context"
+	"omiai-server/internal/service/embedding"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iWuxc/go-wit/redis"
+)
+
+// EmbeddingRebuildJob 每日以全部客户文本重新训练向量模型并刷新向量，在候选人预筛之前执行
+type EmbeddingRebuildJob struct {
+	embeddings *embedding.Service
+}
+
+func NewEmbeddingRebuildJob(embeddings *embedding.Service) *EmbeddingRebuildJob {
+	return &EmbeddingRebuildJob{embeddings: embeddings}
+}
+
+func (j *EmbeddingRebuildJob) JobName() string {
+	return "EmbeddingRebuildJob"
+}
+
+func (j *EmbeddingRebuildJob) Schedule() string {
+	// Daily at 1:30 AM
+	return "0 30 1 * * *"
+}
+
+func (j *EmbeddingRebuildJob) Run() {
+	ctx := context.WithValue(context.Background(), "request_id", uuid.NewString())
+
+	lockKey := "lock:EmbeddingRebuildJob"
+	lockRet := redis.GetRedis().GetClient().SetNX(ctx, lockKey, 1, time.Minute*30)
+	if lockRet.Err() != nil {
+		log.WithContext(ctx).Errorf("【定时任务-%s】 lockRet err:%s", j.JobName(), lockRet.Err().Error())
+		return
+	}
+	if !lockRet.Val() {
+		return
+	}
+	defer func() {
+		_ = redis.GetRedis().Delete(ctx, lockKey)
+	}()
+
+	n, err := j.embeddings.Rebuild(ctx)
+	if err != nil {
+		log.WithContext(ctx).Errorf("【定时任务-%s】 rebuild err:%v", j.JobName(), err)
+		return
+	}
+	log.WithContext(ctx).Infof("%s end, model=%s clients=%d", j.JobName(), j.embeddings.Model(), n)
+}
@@ -0,0 +1,52 @@
+package cron
+
+import (
+	"context"
+	"omiai-server/internal/service/quality"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iWuxc/go-wit/redis"
+)
+
+// QualityReportJob 每天凌晨重算全部客户的资料完整度并生成资料质量日报，先于候选人预筛选执行
+type QualityReportJob struct {
+	quality *quality.Service
+}
+
+func NewQualityReportJob(quality *quality.Service) *QualityReportJob {
+	return &QualityReportJob{quality: quality}
+}
+
+func (j *QualityReportJob) JobName() string {
+	return "QualityReportJob"
+}
+
+func (j *QualityReportJob) Schedule() string {
+	// Daily at 00:30
+	return "0 30 0 * * *"
+}
+
+func (j *QualityReportJob) Run() {
+	ctx := context.WithValue(context.Background(), "request_id", uuid.NewString())
+
+	lockKey := "lock:QualityReportJob"
+	lockRet := redis.GetRedis().GetClient().SetNX(ctx, lockKey, 1, time.Hour)
+	if lockRet.Err() != nil {
+		log.WithContext(ctx).Errorf("【定时任务-%s】 lockRet err:%s", j.JobName(), lockRet.Err().Error())
+		return
+	}
+	if !lockRet.Val() {
+		return
+	}
+	defer func() {
+		_ = redis.GetRedis().Delete(ctx, lockKey)
+	}()
+
+	report, err := j.quality.Report(ctx)
+	if err != nil {
+		log.WithContext(ctx).Errorf("【定时任务-%s】 report err:%v", j.JobName(), err)
+		return
+	}
+	log.WithContext(ctx).Infof("%s end, clients=%d avg=%.1f complete=%d", j.JobName(), report.Total, report.AvgCompleteness, report.Complete)
+}
@@ -126,27 +126,19 @@ func (s *ReminderService) generateBirthdayReminders(ctx context.Context) error {
 		return err
 	}
 
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 	for _, client := range clients {
-		if len(client.Birthday) < 10 {
+		// 只填写到月份的生日无法确定提醒日期
+		birthday, ok := biz_omiai.ParseBirthday(client.Birthday)
+		if !ok {
 			continue
 		}
-
-		// 解析生日 MM-DD
-		birthMonth := client.Birthday[5:7]
-		birthDay := client.Birthday[8:10]
-		currentYear := now.Year()
-
-		birthdayThisYear, err := time.Parse("2006-01-02", fmt.Sprintf("%d-%s-%s", currentYear, birthMonth, birthDay))
-		if err != nil {
+		next, ok := birthday.Next(now)
+		if !ok {
 			continue
 		}
 
-		// 如果今年的生日已过，看明年的
-		if birthdayThisYear.Before(now) {
-			birthdayThisYear = birthdayThisYear.AddDate(1, 0, 0)
-		}
-
-		daysUntil := int(birthdayThisYear.Sub(now).Hours() / 24)
+		daysUntil := int(next.Sub(today).Hours() / 24)
 		if daysUntil > 3 {
 			continue
 		}
@@ -177,7 +169,7 @@ func (s *ReminderService) generateBirthdayReminders(ctx context.Context) error {
 			ClientID:    int64(client.ID),
 			RuleID:      0,
 			Content:     fmt.Sprintf("记得给%s发送生日祝福哦，维护客户关系的好时机", client.Name),
-			ScheduledAt: birthdayThisYear.AddDate(0, 0, -daysUntil), // 今天提醒
+			ScheduledAt: now, // 今天提醒
 			Status:      "pending",
 		}
 
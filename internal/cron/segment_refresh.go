@@ -0,0 +1,52 @@
+package cron
+
+import (
+	"context"
+	"omiai-server/internal/service/segment"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iWuxc/go-wit/redis"
+)
+
+// SegmentRefreshJob 每小时刷新分群成员快照，发布客户进出分群事件
+type SegmentRefreshJob struct {
+	segments *segment.Service
+}
+
+func NewSegmentRefreshJob(segments *segment.Service) *SegmentRefreshJob {
+	return &SegmentRefreshJob{segments: segments}
+}
+
+func (j *SegmentRefreshJob) JobName() string {
+	return "SegmentRefreshJob"
+}
+
+func (j *SegmentRefreshJob) Schedule() string {
+	// Hourly at minute 10
+	return "0 10 * * * *"
+}
+
+func (j *SegmentRefreshJob) Run() {
+	ctx := context.WithValue(context.Background(), "request_id", uuid.NewString())
+
+	lockKey := "lock:SegmentRefreshJob"
+	lockRet := redis.GetRedis().GetClient().SetNX(ctx, lockKey, 1, time.Minute*30)
+	if lockRet.Err() != nil {
+		log.WithContext(ctx).Errorf("【定时任务-%s】 lockRet err:%s", j.JobName(), lockRet.Err().Error())
+		return
+	}
+	if !lockRet.Val() {
+		return
+	}
+	defer func() {
+		_ = redis.GetRedis().Delete(ctx, lockKey)
+	}()
+
+	done, err := j.segments.RefreshAll(ctx)
+	if err != nil {
+		log.WithContext(ctx).Errorf("【定时任务-%s】 refresh err:%v", j.JobName(), err)
+		return
+	}
+	log.WithContext(ctx).Infof("%s end, segments=%d", j.JobName(), done)
+}
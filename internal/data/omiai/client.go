@@ -2,6 +2,7 @@ package omiai
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"omiai-server/internal/biz"
 	biz_omiai "omiai-server/internal/biz/omiai"
@@ -9,22 +10,24 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var _ biz_omiai.ClientInterface = (*ClientRepo)(nil)
 
 type ClientRepo struct {
-	db *data.DB
-	m  *biz_omiai.Client
+	db     *data.DB
+	m      *biz_omiai.Client
+	events biz_omiai.EventBus
 }
 
-func NewClientRepo(db *data.DB) biz_omiai.ClientInterface {
-	return &ClientRepo{db: db, m: new(biz_omiai.Client)}
+func NewClientRepo(db *data.DB, events biz_omiai.EventBus) biz_omiai.ClientInterface {
+	return &ClientRepo{db: db, m: new(biz_omiai.Client), events: events}
 }
 
 func (c *ClientRepo) Select(ctx context.Context, clause *biz.WhereClause, fields []string, offset, limit int) ([]*biz_omiai.Client, error) {
 	var clientList []*biz_omiai.Client
-	err := c.db.Model(c.m).WithContext(ctx).Select(fields).Where(clause.Where, clause.Args...).Order(clause.OrderBy).Offset(offset).Limit(limit).Find(&clientList).Error
+	err := c.db.Model(c.m).WithContext(ctx).Scopes(biz_omiai.ScoringPreloads).Select(fields).Where(clause.Where, clause.Args...).Order(clause.OrderBy).Offset(offset).Limit(limit).Find(&clientList).Error
 	if err != nil && err != gorm.ErrRecordNotFound {
 		return nil, fmt.Errorf("ClientRepo:Select where:%v err:%w", clause, err)
 	}
@@ -32,15 +35,183 @@ func (c *ClientRepo) Select(ctx context.Context, clause *biz.WhereClause, fields
 }
 
 func (c *ClientRepo) Create(ctx context.Context, client *biz_omiai.Client) error {
-	return c.db.WithContext(ctx).Model(c.m).Create(client).Error
+	client.SyncRequirementColumns()
+	client.SyncQuality(time.Now())
+	err := c.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.WithContext(ctx).Model(c.m).Create(client).Error; err != nil {
+			return err
+		}
+		if err := saveClientTags(ctx, tx, client.ID, client.Tags, false); err != nil {
+			return err
+		}
+		return c.record(ctx, tx, client.ID, biz_omiai.ClientActionCreate, nil, client, "")
+	})
+	if err == nil {
+		c.publish(ctx, client.ID, biz_omiai.ClientChangeProfile)
+	}
+	return err
 }
 
 func (c *ClientRepo) Update(ctx context.Context, client *biz_omiai.Client) error {
-	return c.db.WithContext(ctx).Model(client).Updates(client).Error
+	err := c.db.Transaction(func(tx *gorm.DB) error {
+		old, err := c.lock(ctx, tx, client.ID)
+		if err != nil {
+			return err
+		}
+		if err := tx.WithContext(ctx).Model(client).Updates(client).Error; err != nil {
+			return err
+		}
+		// 择偶要求有变更时整列覆盖 req_* 列，避免 Updates 跳过零值导致旧要求残留
+		if client.PartnerRequirements != "" {
+			client.SyncRequirementColumns()
+			if err := tx.WithContext(ctx).Model(client).Updates(client.RequirementColumns()).Error; err != nil {
+				return err
+			}
+		}
+		// 填写了标签时整体替换，"[]" 表示清空
+		if client.Tags != "" {
+			if err := saveClientTags(ctx, tx, client.ID, client.Tags, true); err != nil {
+				return err
+			}
+		}
+		var updated biz_omiai.Client
+		if err := tx.WithContext(ctx).First(&updated, client.ID).Error; err != nil {
+			return err
+		}
+		if err := syncClientQuality(ctx, tx, &updated); err != nil {
+			return err
+		}
+		return c.record(ctx, tx, client.ID, biz_omiai.ClientActionUpdate, old, &updated, "")
+	})
+	if err == nil {
+		c.publish(ctx, client.ID, biz_omiai.ClientChangeProfile)
+	}
+	return err
 }
 
+// Delete 将客户移入回收站，与 DeleteWithTx 相同
 func (c *ClientRepo) Delete(ctx context.Context, id uint64) error {
-	return c.db.WithContext(ctx).Model(c.m).Delete(&biz_omiai.Client{}, id).Error
+	return c.DeleteWithTx(ctx, id)
+}
+
+// Restore 恢复字段值，择偶要求恢复时同步 req_* 列
+func (c *ClientRepo) Restore(ctx context.Context, id uint64, values map[string]interface{}, remark string) error {
+	err := c.db.Transaction(func(tx *gorm.DB) error {
+		old, err := c.lock(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if err := tx.WithContext(ctx).Model(&biz_omiai.Client{ID: id}).Updates(values).Error; err != nil {
+			return err
+		}
+		var restored biz_omiai.Client
+		if err := tx.WithContext(ctx).First(&restored, id).Error; err != nil {
+			return err
+		}
+		if _, ok := values["partner_requirements"]; ok {
+			restored.SyncRequirementColumns()
+			if err := tx.WithContext(ctx).Model(&restored).Updates(restored.RequirementColumns()).Error; err != nil {
+				return err
+			}
+		}
+		if err := syncClientQuality(ctx, tx, &restored); err != nil {
+			return err
+		}
+		if len(biz_omiai.DiffClient(old, &restored)) == 0 {
+			return biz_omiai.ErrClientRestoreNoop
+		}
+		return c.record(ctx, tx, id, biz_omiai.ClientActionRestore, old, &restored, remark)
+	})
+	if err == nil {
+		c.publish(ctx, id, biz_omiai.ClientChangeProfile)
+	}
+	return err
+}
+
+// syncClientQuality 按保存后的资料重新计算完整度，不影响 updated_at
+func syncClientQuality(ctx context.Context, tx *gorm.DB, c *biz_omiai.Client) error {
+	c.SyncQuality(time.Now())
+	return tx.WithContext(ctx).Model(c).UpdateColumns(c.QualityColumns()).Error
+}
+
+// lock 锁定并读取变更前的资料，串行化同一客户的版本号分配
+func (c *ClientRepo) lock(ctx context.Context, tx *gorm.DB, id uint64) (*biz_omiai.Client, error) {
+	var old biz_omiai.Client
+	if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&old, id).Error; err != nil {
+		return nil, err
+	}
+	return &old, nil
+}
+
+// record 写入一个版本，修改时没有字段变化则不记录（如仅认领、释放）
+func (c *ClientRepo) record(ctx context.Context, tx *gorm.DB, id uint64, action string, old, new *biz_omiai.Client, remark string) error {
+	changes := biz_omiai.DiffClient(old, new)
+	if action == biz_omiai.ClientActionUpdate && len(changes) == 0 {
+		return nil
+	}
+	current := new
+	if current == nil {
+		current = old
+	}
+	changesJSON, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+	snapshotJSON, err := json.Marshal(biz_omiai.ClientSnapshot(current))
+	if err != nil {
+		return err
+	}
+
+	var version int
+	if err := tx.WithContext(ctx).Model(&biz_omiai.ClientVersion{}).Where("client_id = ?", id).
+		Select("COALESCE(MAX(version), 0)").Scan(&version).Error; err != nil {
+		return err
+	}
+	source, operator := biz_omiai.ClientChangeFrom(ctx)
+	if action == biz_omiai.ClientActionRestore {
+		source = biz_omiai.ClientSourceRestore
+	}
+	return tx.WithContext(ctx).Create(&biz_omiai.ClientVersion{
+		ClientID: id,
+		Version:  version + 1,
+		Action:   action,
+		Source:   source,
+		Operator: operator,
+		Changes:  string(changesJSON),
+		Snapshot: string(snapshotJSON),
+		Remark:   remark,
+	}).Error
+}
+
+func (c *ClientRepo) Versions(ctx context.Context, clientID uint64, offset, limit int) ([]*biz_omiai.ClientVersion, int64, error) {
+	var (
+		list  []*biz_omiai.ClientVersion
+		total int64
+	)
+	db := c.db.WithContext(ctx).Model(&biz_omiai.ClientVersion{}).Where("client_id = ?", clientID)
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("ClientRepo:Versions count client:%d err:%w", clientID, err)
+	}
+	if err := db.Order("version desc").Offset(offset).Limit(limit).Find(&list).Error; err != nil {
+		return nil, 0, fmt.Errorf("ClientRepo:Versions client:%d err:%w", clientID, err)
+	}
+	for _, v := range list {
+		v.Decode()
+	}
+	return list, total, nil
+}
+
+func (c *ClientRepo) GetVersion(ctx context.Context, clientID uint64, version int) (*biz_omiai.ClientVersion, error) {
+	var v biz_omiai.ClientVersion
+	if err := c.db.WithContext(ctx).Where("client_id = ? AND version = ?", clientID, version).First(&v).Error; err != nil {
+		return nil, err
+	}
+	v.Decode()
+	return &v, nil
+}
+
+func (c *ClientRepo) publish(ctx context.Context, id uint64, change string) {
+	c.events.Publish(ctx, biz_omiai.EventClientChanged, &biz_omiai.ClientChanged{ClientIDs: []uint64{id}, Change: change})
 }
 
 // HasActiveMatch 检查客户是否有未解除的匹配关系
@@ -56,46 +227,264 @@ func (c *ClientRepo) HasActiveMatch(ctx context.Context, clientID uint64) (bool,
 	return count > 0, nil
 }
 
-// DeleteWithTx 使用事务删除客户，并处理关联数据
+// DeleteWithTx 使用事务将客户移入回收站，情侣档案及其状态历史、回访记录一并软删除；
+// 关联记录与客户使用同一删除时间，从回收站恢复时据此找回
 func (c *ClientRepo) DeleteWithTx(ctx context.Context, id uint64) error {
-	return c.db.Transaction(func(tx *gorm.DB) error {
-		// 1. 删除客户的跟进记录（如果有）
-		if err := tx.WithContext(ctx).Where("match_record_id IN (SELECT id FROM match_record WHERE male_client_id = ? OR female_client_id = ?)", id, id).
-			Delete(&biz_omiai.FollowUpRecord{}).Error; err != nil {
+	err := c.db.Transaction(func(tx *gorm.DB) error {
+		old, err := c.lock(ctx, tx, id)
+		if err != nil {
 			return err
 		}
+		now := time.Now().Truncate(time.Second)
 
-		// 2. 删除客户的匹配状态历史记录
-		if err := tx.WithContext(ctx).Where("match_record_id IN (SELECT id FROM match_record WHERE male_client_id = ? OR female_client_id = ?)", id, id).
-			Delete(&biz_omiai.MatchStatusHistory{}).Error; err != nil {
+		// 1. 软删除客户的匹配记录及其状态历史、回访记录
+		var recordIDs []uint64
+		if err := tx.WithContext(ctx).Model(&biz_omiai.MatchRecord{}).Where("male_client_id = ? OR female_client_id = ?", id, id).
+			Pluck("id", &recordIDs).Error; err != nil {
 			return err
 		}
-
-		// 3. 删除客户的匹配记录
-		if err := tx.WithContext(ctx).Where("male_client_id = ? OR female_client_id = ?", id, id).
-			Delete(&biz_omiai.MatchRecord{}).Error; err != nil {
+		if err := c.stamp(ctx, tx, recordIDs, nil, now); err != nil {
 			return err
 		}
 
-		// 4. 如果客户有 partner，清除 partner 的 partner_id
+		// 2. 如果客户有 partner，清除 partner 的 partner_id
 		if err := tx.WithContext(ctx).Model(&biz_omiai.Client{}).
 			Where("partner_id = ?", id).
 			Update("partner_id", nil).Error; err != nil {
 			return err
 		}
 
-		// 5. 最后删除客户
-		if err := tx.WithContext(ctx).Model(c.m).Delete(&biz_omiai.Client{}, id).Error; err != nil {
+		// 3. 最后将客户移入回收站，保留删除前的资料版本
+		if err := tx.WithContext(ctx).Model(&biz_omiai.Client{ID: id}).Update("deleted_at", now).Error; err != nil {
 			return err
 		}
 
+		return c.record(ctx, tx, id, biz_omiai.ClientActionDelete, old, nil, "")
+	})
+	if err == nil {
+		c.publish(ctx, id, biz_omiai.ClientChangeDeleted)
+	}
+	return err
+}
+
+// stamp 将匹配记录及其状态历史、回访记录的删除时间由 from 改为 to，from 为 nil 表示未删除的记录
+func (c *ClientRepo) stamp(ctx context.Context, tx *gorm.DB, recordIDs []uint64, from *time.Time, to interface{}) error {
+	if len(recordIDs) == 0 {
 		return nil
+	}
+	for _, m := range []struct {
+		model  interface{}
+		column string
+	}{
+		{&biz_omiai.FollowUpRecord{}, "match_record_id"},
+		{&biz_omiai.MatchStatusHistory{}, "match_record_id"},
+		{&biz_omiai.MatchRecord{}, "id"},
+	} {
+		db := tx.WithContext(ctx).Unscoped().Model(m.model).Where(m.column+" IN ?", recordIDs)
+		if from == nil {
+			db = db.Where("deleted_at IS NULL")
+		} else {
+			db = db.Where("deleted_at = ?", *from)
+		}
+		if err := db.UpdateColumn("deleted_at", to).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// trashed 锁定并读取回收站中的客户
+func (c *ClientRepo) trashed(ctx context.Context, tx *gorm.DB, id uint64) (*biz_omiai.Client, error) {
+	var client biz_omiai.Client
+	if err := tx.WithContext(ctx).Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("deleted_at IS NOT NULL").First(&client, id).Error; err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+func (c *ClientRepo) Trashed(ctx context.Context, clause *biz.WhereClause, offset, limit int) ([]*biz_omiai.Client, int64, error) {
+	var (
+		list  []*biz_omiai.Client
+		total int64
+	)
+	db := c.db.WithContext(ctx).Unscoped().Model(c.m).Where("deleted_at IS NOT NULL").Where(clause.Where, clause.Args...)
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("ClientRepo:Trashed count where:%v err:%w", clause, err)
+	}
+	if err := db.Order("deleted_at desc").Order("id desc").Offset(offset).Limit(limit).Find(&list).Error; err != nil {
+		return nil, 0, fmt.Errorf("ClientRepo:Trashed where:%v err:%w", clause, err)
+	}
+	return list, total, nil
+}
+
+// Undelete 恢复客户及与其同时删除的匹配记录；对方仍在回收站的记录改用对方的删除时间，随对方恢复
+func (c *ClientRepo) Undelete(ctx context.Context, id uint64) error {
+	err := c.db.Transaction(func(tx *gorm.DB) error {
+		client, err := c.trashed(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		deletedAt := client.DeletedAt.Time
+		if client.Phone != "" {
+			var count int64
+			if err := tx.WithContext(ctx).Model(c.m).Where("phone = ? AND id != ?", client.Phone, id).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return biz_omiai.ErrClientPhoneDuplicate
+			}
+		}
+
+		var records []*biz_omiai.MatchRecord
+		if err := tx.WithContext(ctx).Unscoped().Where("(male_client_id = ? OR female_client_id = ?) AND deleted_at = ?", id, id, deletedAt).
+			Find(&records).Error; err != nil {
+			return err
+		}
+		var restore []uint64
+		for _, r := range records {
+			other := r.MaleClientID
+			if other == id {
+				other = r.FemaleClientID
+			}
+			var o biz_omiai.Client
+			err := tx.WithContext(ctx).Unscoped().Select("id", "deleted_at").First(&o, other).Error
+			if err != nil && err != gorm.ErrRecordNotFound {
+				return err
+			}
+			if err == nil && o.DeletedAt.Valid {
+				if err := c.stamp(ctx, tx, []uint64{r.ID}, &deletedAt, o.DeletedAt.Time); err != nil {
+					return err
+				}
+				continue
+			}
+			restore = append(restore, r.ID)
+		}
+		if err := c.stamp(ctx, tx, restore, &deletedAt, nil); err != nil {
+			return err
+		}
+
+		if err := tx.WithContext(ctx).Unscoped().Model(&biz_omiai.Client{ID: id}).UpdateColumn("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		client.DeletedAt = gorm.DeletedAt{}
+		return c.record(ctx, tx, id, biz_omiai.ClientActionRestore, nil, client, "从回收站恢复")
 	})
+	if err == nil {
+		c.publish(ctx, id, biz_omiai.ClientChangeProfile)
+	}
+	return err
+}
+
+// clientRefs 以客户 ID 关联的表及列，Purge 时一并删除
+var clientRefs = []struct {
+	model   interface{}
+	columns []string
+}{
+	{&biz_omiai.ClientVersion{}, []string{"client_id"}},
+	{&biz_omiai.ClientTag{}, []string{"client_id"}},
+	{&biz_omiai.ClientEmbedding{}, []string{"client_id"}},
+	{&biz_omiai.PersonalityProfile{}, []string{"client_id"}},
+	{&biz_omiai.QuestionnaireSheet{}, []string{"client_id"}},
+	{&biz_omiai.ClientAvailability{}, []string{"client_id"}},
+	{&biz_omiai.ClientSegmentMember{}, []string{"client_id"}},
+	{&biz_omiai.ReminderTask{}, []string{"client_id"}},
+	{&biz_omiai.Recommendation{}, []string{"client_id", "candidate_id"}},
+	{&biz_omiai.DailyRecommendation{}, []string{"client_id", "candidate_id"}},
+	{&biz_omiai.PairHistory{}, []string{"client_id", "candidate_id"}},
+	{&biz_omiai.ExperimentExposure{}, []string{"client_id", "candidate_id"}},
+	{&biz_omiai.ClientDuplicate{}, []string{"client_a_id", "client_b_id"}},
+	{&biz_omiai.Proposal{}, []string{"male_client_id", "female_client_id"}},
+	{&biz_omiai.PartyAttendee{}, []string{"client_id"}},
+	{&biz_omiai.PartySeat{}, []string{"male_client_id", "female_client_id"}},
+	{&biz_omiai.PartyPick{}, []string{"client_id", "picked_id"}},
+}
+
+// Purge 彻底删除回收站中的客户，连同其匹配记录、状态历史、回访记录、变更历史，
+// 以及引荐、见面、推荐、相亲会等所有以客户 ID 关联的记录
+func (c *ClientRepo) Purge(ctx context.Context, id uint64) error {
+	return c.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := c.trashed(ctx, tx, id); err != nil {
+			return err
+		}
+
+		// 1. 匹配记录及其状态历史、回访记录
+		var recordIDs []uint64
+		if err := tx.WithContext(ctx).Unscoped().Model(&biz_omiai.MatchRecord{}).Where("male_client_id = ? OR female_client_id = ?", id, id).
+			Pluck("id", &recordIDs).Error; err != nil {
+			return err
+		}
+		if len(recordIDs) > 0 {
+			for _, m := range []interface{}{&biz_omiai.FollowUpRecord{}, &biz_omiai.MatchStatusHistory{}} {
+				if err := tx.WithContext(ctx).Unscoped().Where("match_record_id IN ?", recordIDs).Delete(m).Error; err != nil {
+					return err
+				}
+			}
+			if err := tx.WithContext(ctx).Unscoped().Delete(&biz_omiai.MatchRecord{}, recordIDs).Error; err != nil {
+				return err
+			}
+		}
+
+		// 2. 引荐及其状态历史，见面及见面提醒
+		var introIDs, meetingIDs []uint64
+		if err := tx.WithContext(ctx).Unscoped().Model(&biz_omiai.Introduction{}).Where("client_a_id = ? OR client_b_id = ?", id, id).
+			Pluck("id", &introIDs).Error; err != nil {
+			return err
+		}
+		if err := tx.WithContext(ctx).Unscoped().Model(&biz_omiai.Meeting{}).Where("client_a_id = ? OR client_b_id = ?", id, id).
+			Pluck("id", &meetingIDs).Error; err != nil {
+			return err
+		}
+		if len(meetingIDs) > 0 {
+			if err := tx.WithContext(ctx).Unscoped().Where("meeting_id IN ?", meetingIDs).Delete(&biz_omiai.ReminderTask{}).Error; err != nil {
+				return err
+			}
+			if err := tx.WithContext(ctx).Unscoped().Delete(&biz_omiai.Meeting{}, meetingIDs).Error; err != nil {
+				return err
+			}
+		}
+		if len(introIDs) > 0 {
+			if err := tx.WithContext(ctx).Unscoped().Where("introduction_id IN ?", introIDs).Delete(&biz_omiai.IntroductionHistory{}).Error; err != nil {
+				return err
+			}
+			if err := tx.WithContext(ctx).Unscoped().Delete(&biz_omiai.Introduction{}, introIDs).Error; err != nil {
+				return err
+			}
+		}
+
+		// 3. 其余以客户 ID 关联的记录
+		for _, ref := range clientRefs {
+			q := tx.WithContext(ctx).Unscoped().Where(ref.columns[0]+" = ?", id)
+			for _, col := range ref.columns[1:] {
+				q = q.Or(col+" = ?", id)
+			}
+			if err := q.Delete(ref.model).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.WithContext(ctx).Unscoped().Model(&biz_omiai.Client{}).Where("partner_id = ?", id).UpdateColumn("partner_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.WithContext(ctx).Unscoped().Delete(&biz_omiai.Client{}, id).Error
+	})
+}
+
+func (c *ClientRepo) PurgeExpired(ctx context.Context, before time.Time) (int, error) {
+	var ids []uint64
+	if err := c.db.WithContext(ctx).Unscoped().Model(c.m).Where("deleted_at < ?", before).Pluck("id", &ids).Error; err != nil {
+		return 0, fmt.Errorf("ClientRepo:PurgeExpired before:%s err:%w", before, err)
+	}
+	for i, id := range ids {
+		if err := c.Purge(ctx, id); err != nil {
+			return i, fmt.Errorf("ClientRepo:PurgeExpired client:%d err:%w", id, err)
+		}
+	}
+	return len(ids), nil
 }
 
 func (c *ClientRepo) Get(ctx context.Context, id uint64) (*biz_omiai.Client, error) {
 	var client biz_omiai.Client
-	err := c.db.WithContext(ctx).Model(c.m).Preload("Partner").First(&client, id).Error
+	err := c.db.WithContext(ctx).Model(c.m).Preload("Partner").Scopes(biz_omiai.ScoringPreloads).First(&client, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,209 @@
+package omiai
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"omiai-server/internal/biz"
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/data"
+	"omiai-server/internal/service/event"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestClientRepo_Versions(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	assert.NoError(t, db.AutoMigrate(&biz_omiai.Client{}, &biz_omiai.PersonalityProfile{}, &biz_omiai.ClientEmbedding{}, &biz_omiai.ClientTag{}, &biz_omiai.Tag{}, &biz_omiai.TagGroup{}, &biz_omiai.ClientVersion{},
+		&biz_omiai.MatchRecord{}, &biz_omiai.MatchStatusHistory{}, &biz_omiai.FollowUpRecord{}))
+	repo := NewClientRepo(&data.DB{DB: db}, event.NewBus())
+
+	ctx := biz_omiai.WithClientChange(context.Background(), biz_omiai.ClientSourceInvite, "张三")
+	c := &biz_omiai.Client{Name: "张三", Gender: 1, Phone: "13800000000", Income: 8000, MaritalStatus: 1}
+	assert.NoError(t, repo.Create(ctx, c))
+
+	// 仅记录有变化的字段；无变化的修改不生成版本
+	ctx = biz_omiai.WithClientChange(context.Background(), biz_omiai.ClientSourceAdmin, "红娘")
+	assert.NoError(t, repo.Update(ctx, &biz_omiai.Client{ID: c.ID, Income: 12000, MaritalStatus: 3}))
+	assert.NoError(t, repo.Update(ctx, &biz_omiai.Client{ID: c.ID, Income: 12000}))
+
+	list, total, err := repo.Versions(ctx, c.ID, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Equal(t, 2, list[0].Version)
+	assert.Equal(t, biz_omiai.ClientSourceAdmin, list[0].Source)
+	assert.Equal(t, "红娘", list[0].Operator)
+	assert.Len(t, list[0].ChangeList, 2)
+	assert.Equal(t, "income", list[0].ChangeList[1].Field)
+	assert.EqualValues(t, 8000, list[0].ChangeList[1].Old)
+	assert.EqualValues(t, 12000, list[0].ChangeList[1].New)
+	assert.Equal(t, biz_omiai.ClientActionCreate, list[1].Action)
+	assert.Equal(t, biz_omiai.ClientSourceInvite, list[1].Source)
+	assert.Len(t, list[1].ChangeList, 5)
+
+	// 恢复单个字段
+	v1, err := repo.GetVersion(ctx, c.ID, 1)
+	assert.NoError(t, err)
+	values, err := v1.Values([]string{"income"})
+	assert.NoError(t, err)
+	assert.NoError(t, repo.Restore(ctx, c.ID, values, "从版本 1 恢复 1 个字段"))
+	got, err := repo.Get(ctx, c.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 8000, got.Income)
+	assert.Equal(t, int8(3), got.MaritalStatus)
+	assert.ErrorIs(t, repo.Restore(ctx, c.ID, values, ""), biz_omiai.ErrClientRestoreNoop)
+	_, err = v1.Values([]string{"status"})
+	assert.ErrorIs(t, err, biz_omiai.ErrClientRestoreField)
+
+	// 恢复整个版本
+	values, err = v1.Values(nil)
+	assert.NoError(t, err)
+	assert.NoError(t, repo.Restore(ctx, c.ID, values, "恢复至版本 1"))
+	got, _ = repo.Get(ctx, c.ID)
+	assert.Equal(t, int8(1), got.MaritalStatus)
+
+	// 删除保留删除前的资料
+	assert.NoError(t, repo.DeleteWithTx(ctx, c.ID))
+	v, err := repo.GetVersion(ctx, c.ID, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, biz_omiai.ClientActionDelete, v.Action)
+	assert.Equal(t, biz_omiai.ClientSourceAdmin, v.Source)
+	values, err = v.Values(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "13800000000", values["phone"])
+}
+
+func TestClientRepo_Trash(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	assert.NoError(t, db.AutoMigrate(purgeModels()...))
+	repo := NewClientRepo(&data.DB{DB: db}, event.NewBus())
+	ctx := context.Background()
+
+	a := &biz_omiai.Client{Name: "男", Gender: 1, Phone: "13800000001"}
+	b := &biz_omiai.Client{Name: "女B", Gender: 2, Phone: "13800000002"}
+	c := &biz_omiai.Client{Name: "女C", Gender: 2, Phone: "13800000003"}
+	for _, client := range []*biz_omiai.Client{a, b, c} {
+		assert.NoError(t, repo.Create(ctx, client))
+	}
+	ab := &biz_omiai.MatchRecord{MaleClientID: a.ID, FemaleClientID: b.ID, Status: biz_omiai.MatchStatusBroken}
+	ac := &biz_omiai.MatchRecord{MaleClientID: a.ID, FemaleClientID: c.ID, Status: biz_omiai.MatchStatusBroken}
+	assert.NoError(t, db.Create([]*biz_omiai.MatchRecord{ab, ac}).Error)
+	assert.NoError(t, db.Create(&biz_omiai.FollowUpRecord{MatchRecordID: ab.ID, Content: "回访"}).Error)
+	assert.NoError(t, db.Create(&biz_omiai.MatchStatusHistory{MatchRecordID: ab.ID, NewStatus: biz_omiai.MatchStatusBroken}).Error)
+	count := func(model interface{}) int64 {
+		var n int64
+		db.Model(model).Count(&n)
+		return n
+	}
+
+	// 删除后客户及其情侣档案、回访、状态历史均不再出现在查询中
+	assert.NoError(t, repo.DeleteWithTx(ctx, a.ID))
+	_, err = repo.Get(ctx, a.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	assert.Equal(t, int64(2), count(&biz_omiai.Client{}))
+	assert.Equal(t, int64(0), count(&biz_omiai.MatchRecord{}))
+	assert.Equal(t, int64(0), count(&biz_omiai.FollowUpRecord{}))
+	assert.Equal(t, int64(0), count(&biz_omiai.MatchStatusHistory{}))
+	list, total, err := repo.Trashed(ctx, &biz.WhereClause{Where: "name LIKE ?", Args: []interface{}{"男%"}}, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.True(t, list[0].DeletedAt.Valid)
+
+	// 对方仍在回收站的情侣档案随对方恢复
+	assert.NoError(t, repo.DeleteWithTx(ctx, c.ID))
+	assert.NoError(t, repo.Undelete(ctx, a.ID))
+	assert.ErrorIs(t, repo.Undelete(ctx, a.ID), gorm.ErrRecordNotFound)
+	var records []*biz_omiai.MatchRecord
+	assert.NoError(t, db.Find(&records).Error)
+	assert.Len(t, records, 1)
+	assert.Equal(t, ab.ID, records[0].ID)
+	assert.Equal(t, int64(1), count(&biz_omiai.FollowUpRecord{}))
+	assert.Equal(t, int64(1), count(&biz_omiai.MatchStatusHistory{}))
+	assert.NoError(t, repo.Undelete(ctx, c.ID))
+	assert.Equal(t, int64(2), count(&biz_omiai.MatchRecord{}))
+	versions, _, err := repo.Versions(ctx, a.ID, 0, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, biz_omiai.ClientActionRestore, versions[0].Action)
+
+	// 手机号已被新客户使用时不可恢复
+	assert.NoError(t, repo.DeleteWithTx(ctx, a.ID))
+	d := &biz_omiai.Client{Name: "新", Gender: 1, Phone: a.Phone}
+	assert.NoError(t, repo.Create(ctx, d))
+	assert.ErrorIs(t, repo.Undelete(ctx, a.ID), biz_omiai.ErrClientPhoneDuplicate)
+
+	// 彻底删除只处理回收站中的客户，连同变更历史一并删除
+	assert.ErrorIs(t, repo.Purge(ctx, b.ID), gorm.ErrRecordNotFound)
+	n, err := repo.PurgeExpired(ctx, time.Now().Add(time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	var left int64
+	db.Model(&biz_omiai.ClientVersion{}).Where("client_id = ?", a.ID).Count(&left)
+	assert.Equal(t, int64(0), left)
+	db.Unscoped().Model(&biz_omiai.MatchRecord{}).Count(&left)
+	assert.Equal(t, int64(0), left)
+	db.Unscoped().Model(&biz_omiai.FollowUpRecord{}).Count(&left)
+	assert.Equal(t, int64(0), left)
+	db.Unscoped().Model(&biz_omiai.Client{}).Where("id = ?", a.ID).Count(&left)
+	assert.Equal(t, int64(0), left)
+}
+
+func TestClientRepo_PurgeRefs(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	assert.NoError(t, db.AutoMigrate(purgeModels()...))
+	repo := NewClientRepo(&data.DB{DB: db}, event.NewBus())
+	ctx := context.Background()
+
+	a := &biz_omiai.Client{Name: "甲", Gender: 1, Phone: "13800000001"}
+	b := &biz_omiai.Client{Name: "乙", Gender: 2, Phone: "13800000002"}
+	assert.NoError(t, repo.Create(ctx, a))
+	assert.NoError(t, repo.Create(ctx, b))
+	intro := &biz_omiai.Introduction{ClientAID: b.ID, ClientBID: a.ID}
+	assert.NoError(t, db.Create(intro).Error)
+	assert.NoError(t, db.Create(&biz_omiai.IntroductionHistory{IntroductionID: intro.ID}).Error)
+	meeting := &biz_omiai.Meeting{IntroductionID: &intro.ID, ClientAID: b.ID, ClientBID: a.ID}
+	assert.NoError(t, db.Create(meeting).Error)
+	assert.NoError(t, db.Create(&biz_omiai.ReminderTask{ClientID: int64(b.ID), MeetingID: meeting.ID}).Error)
+	assert.NoError(t, db.Create(&biz_omiai.ReminderTask{ClientID: int64(b.ID)}).Error)
+	assert.NoError(t, db.Create(&biz_omiai.PairHistory{ClientID: b.ID, CandidateID: a.ID}).Error)
+	assert.NoError(t, db.Create(&biz_omiai.Recommendation{ClientID: a.ID, CandidateID: b.ID}).Error)
+	assert.NoError(t, db.Create(&biz_omiai.PartyPick{ClientID: b.ID, PickedID: a.ID}).Error)
+
+	// Delete 与 DeleteWithTx 相同；彻底删除后不留下任何以该客户关联的记录，对方其余记录保留
+	assert.NoError(t, repo.Delete(ctx, a.ID))
+	assert.NoError(t, repo.Purge(ctx, a.ID))
+	for _, m := range []interface{}{&biz_omiai.Introduction{}, &biz_omiai.IntroductionHistory{}, &biz_omiai.Meeting{},
+		&biz_omiai.PairHistory{}, &biz_omiai.Recommendation{}, &biz_omiai.PartyPick{}} {
+		var left int64
+		db.Unscoped().Model(m).Count(&left)
+		assert.Equal(t, int64(0), left, "%T", m)
+	}
+	var left int64
+	db.Model(&biz_omiai.ClientVersion{}).Where("client_id = ?", a.ID).Count(&left)
+	assert.Equal(t, int64(0), left)
+	var tasks []*biz_omiai.ReminderTask
+	assert.NoError(t, db.Find(&tasks).Error)
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, uint64(0), tasks[0].MeetingID)
+}
+
+// purgeModels Purge 涉及的全部表
+func purgeModels() []interface{} {
+	models := []interface{}{&biz_omiai.Client{}, &biz_omiai.Tag{}, &biz_omiai.TagGroup{}, &biz_omiai.MatchRecord{}, &biz_omiai.MatchStatusHistory{}, &biz_omiai.FollowUpRecord{},
+		&biz_omiai.Introduction{}, &biz_omiai.IntroductionHistory{}, &biz_omiai.Meeting{}}
+	for _, ref := range clientRefs {
+		models = append(models, ref.model)
+	}
+	return models
+}
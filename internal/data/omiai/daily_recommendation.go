@@ -0,0 +1,134 @@
+package omiai
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"omiai-server/internal/biz"
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/data"
+
+	"gorm.io/gorm/clause"
+)
+
+var _ biz_omiai.DailyRecommendationInterface = (*DailyRecommendationRepo)(nil)
+
+type DailyRecommendationRepo struct {
+	db *data.DB
+}
+
+func NewDailyRecommendationRepo(db *data.DB) biz_omiai.DailyRecommendationInterface {
+	return &DailyRecommendationRepo{db: db}
+}
+
+func (r *DailyRecommendationRepo) Create(ctx context.Context, list []*biz_omiai.DailyRecommendation) error {
+	if len(list) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(list, 100).Error
+}
+
+func (r *DailyRecommendationRepo) Get(ctx context.Context, id uint64) (*biz_omiai.DailyRecommendation, error) {
+	var d biz_omiai.DailyRecommendation
+	if err := r.db.WithContext(ctx).Preload("Client").Preload("Candidate").First(&d, id).Error; err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (r *DailyRecommendationRepo) Select(ctx context.Context, clause *biz.WhereClause, offset, limit int) ([]*biz_omiai.DailyRecommendation, int64, error) {
+	var (
+		list  []*biz_omiai.DailyRecommendation
+		total int64
+	)
+	db := r.db.WithContext(ctx).Model(&biz_omiai.DailyRecommendation{}).Where(clause.Where, clause.Args...)
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("DailyRecommendationRepo:Select count where:%v err:%w", clause, err)
+	}
+	orderBy := clause.OrderBy
+	if orderBy == "" {
+		orderBy = "rec_date desc, client_id, `rank`"
+	}
+	if err := db.Preload("Client").Preload("Candidate").Order(orderBy).Offset(offset).Limit(limit).Find(&list).Error; err != nil {
+		return nil, 0, fmt.Errorf("DailyRecommendationRepo:Select where:%v err:%w", clause, err)
+	}
+	return list, total, nil
+}
+
+func (r *DailyRecommendationRepo) Handle(ctx context.Context, id uint64, handle *biz_omiai.DailyHandle) error {
+	res := r.db.WithContext(ctx).Model(&biz_omiai.DailyRecommendation{}).
+		Where("id = ? AND status = ?", id, biz_omiai.DailyStatusPending).
+		Updates(map[string]interface{}{
+			"status":          handle.Status,
+			"introduction_id": handle.IntroductionID,
+			"handled_by":      handle.Operator,
+			"handle_remark":   handle.Remark,
+			"handled_at":      time.Now(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return biz_omiai.ErrDailyHandled
+	}
+	return nil
+}
+
+func (r *DailyRecommendationRepo) Expire(ctx context.Context, date string) (int64, error) {
+	res := r.db.WithContext(ctx).Model(&biz_omiai.DailyRecommendation{}).
+		Where("rec_date < ? AND status = ?", date, biz_omiai.DailyStatusPending).
+		Update("status", biz_omiai.DailyStatusExpired)
+	return res.RowsAffected, res.Error
+}
+
+func (r *DailyRecommendationRepo) GeneratedClients(ctx context.Context, date string) (map[uint64]bool, error) {
+	var ids []uint64
+	if err := r.db.WithContext(ctx).Model(&biz_omiai.DailyRecommendation{}).
+		Where("rec_date = ?", date).Distinct().Pluck("client_id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("DailyRecommendationRepo:GeneratedClients date:%s err:%w", date, err)
+	}
+	out := make(map[uint64]bool, len(ids))
+	for _, id := range ids {
+		out[id] = true
+	}
+	return out, nil
+}
+
+func (r *DailyRecommendationRepo) RecentCandidates(ctx context.Context, since string) (map[uint64]map[uint64]bool, error) {
+	var rows []struct {
+		ClientID    uint64
+		CandidateID uint64
+	}
+	if err := r.db.WithContext(ctx).Model(&biz_omiai.DailyRecommendation{}).Select("client_id, candidate_id").
+		Where("rec_date >= ?", since).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("DailyRecommendationRepo:RecentCandidates since:%s err:%w", since, err)
+	}
+	out := make(map[uint64]map[uint64]bool)
+	for _, row := range rows {
+		if out[row.ClientID] == nil {
+			out[row.ClientID] = make(map[uint64]bool)
+		}
+		out[row.ClientID][row.CandidateID] = true
+	}
+	return out, nil
+}
+
+func (r *DailyRecommendationRepo) Stats(ctx context.Context, from, to string) ([]*biz_omiai.DailyStat, error) {
+	countIf := func(status int8) string {
+		return fmt.Sprintf("SUM(CASE WHEN status = %d THEN 1 ELSE 0 END)", status)
+	}
+	var list []*biz_omiai.DailyStat
+	err := r.db.WithContext(ctx).Model(&biz_omiai.DailyRecommendation{}).
+		Select("rec_date AS date, COUNT(DISTINCT client_id) AS clients, COUNT(*) AS total, "+
+			countIf(biz_omiai.DailyStatusPending)+" AS pending, "+
+			countIf(biz_omiai.DailyStatusAccepted)+" AS accepted, "+
+			countIf(biz_omiai.DailyStatusSkipped)+" AS skipped, "+
+			countIf(biz_omiai.DailyStatusExpired)+" AS expired").
+		Where("rec_date >= ? AND rec_date <= ?", from, to).
+		Group("rec_date").Order("rec_date").Scan(&list).Error
+	if err != nil {
+		return nil, fmt.Errorf("DailyRecommendationRepo:Stats err:%w", err)
+	}
+	return list, nil
+}
@@ -0,0 +1,438 @@
+package omiai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"omiai-server/internal/biz"
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/data"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var _ biz_omiai.DuplicateInterface = (*DuplicateRepo)(nil)
+
+// duplicateFields 查重所需的客户字段
+var duplicateFields = []string{"id", "name", "gender", "phone", "birthday", "avatar", "photos", "work_unit"}
+
+type DuplicateRepo struct {
+	db      *data.DB
+	clients *ClientRepo
+}
+
+func NewDuplicateRepo(db *data.DB, events biz_omiai.EventBus) biz_omiai.DuplicateInterface {
+	return &DuplicateRepo{db: db, clients: &ClientRepo{db: db, m: new(biz_omiai.Client), events: events}}
+}
+
+func (r *DuplicateRepo) Candidates(ctx context.Context, c *biz_omiai.Client) ([]*biz_omiai.Client, error) {
+	var (
+		conds []string
+		args  []interface{}
+	)
+	if p := biz_omiai.NormalizePhone(c.Phone); len(p) >= 8 {
+		conds = append(conds, "phone LIKE ?")
+		args = append(args, "%"+p[len(p)-8:])
+	}
+	if month := biz_omiai.BirthMonth(c.Birthday); month != "" {
+		conds = append(conds, "birthday LIKE ?")
+		args = append(args, month[:4]+"%")
+	}
+	if unit := biz_omiai.NormalizeWorkUnit(c.WorkUnit); unit != "" {
+		conds = append(conds, "work_unit LIKE ?")
+		args = append(args, "%"+unit+"%")
+	}
+	for _, h := range biz_omiai.PhotoHashes(c) {
+		conds = append(conds, "avatar LIKE ? OR photos LIKE ?")
+		args = append(args, "%"+h+"%", "%"+h+"%")
+	}
+	if len(conds) == 0 {
+		return nil, nil
+	}
+
+	db := r.db.WithContext(ctx).Select(duplicateFields).Where("id != ?", c.ID).Where("("+strings.Join(conds, " OR ")+")", args...)
+	if c.Gender != 0 {
+		db = db.Where("gender = ?", c.Gender)
+	}
+	var list []*biz_omiai.Client
+	if err := db.Order("id").Limit(500).Find(&list).Error; err != nil {
+		return nil, fmt.Errorf("DuplicateRepo:Candidates client:%d err:%w", c.ID, err)
+	}
+	return list, nil
+}
+
+func (r *DuplicateRepo) Fingerprints(ctx context.Context, afterID uint64, limit int) ([]*biz_omiai.Client, error) {
+	var list []*biz_omiai.Client
+	if err := r.db.WithContext(ctx).Select(duplicateFields).Where("id > ?", afterID).Order("id").Limit(limit).Find(&list).Error; err != nil {
+		return nil, fmt.Errorf("DuplicateRepo:Fingerprints after:%d err:%w", afterID, err)
+	}
+	return list, nil
+}
+
+func (r *DuplicateRepo) Save(ctx context.Context, list []*biz_omiai.ClientDuplicate) (int, error) {
+	created := 0
+	for _, d := range list {
+		var existing biz_omiai.ClientDuplicate
+		err := r.db.WithContext(ctx).Where("client_a_id = ? AND client_b_id = ?", d.ClientAID, d.ClientBID).First(&existing).Error
+		switch {
+		case err == gorm.ErrRecordNotFound:
+			if err := r.db.WithContext(ctx).Create(d).Error; err != nil {
+				return created, err
+			}
+			created++
+		case err != nil:
+			return created, err
+		case existing.Status == biz_omiai.DuplicateStatusPending && (existing.Score != d.Score || existing.Signals != d.Signals):
+			if err := r.db.WithContext(ctx).Model(&existing).Updates(map[string]interface{}{
+				"score":   d.Score,
+				"signals": d.Signals,
+			}).Error; err != nil {
+				return created, err
+			}
+		}
+	}
+	return created, nil
+}
+
+func (r *DuplicateRepo) Select(ctx context.Context, clause *biz.WhereClause, offset, limit int) ([]*biz_omiai.ClientDuplicate, int64, error) {
+	var (
+		list  []*biz_omiai.ClientDuplicate
+		total int64
+	)
+	db := r.db.WithContext(ctx).Model(&biz_omiai.ClientDuplicate{}).Where(clause.Where, clause.Args...)
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("DuplicateRepo:Select count where:%v err:%w", clause, err)
+	}
+	orderBy := clause.OrderBy
+	if orderBy == "" {
+		orderBy = "score desc, id desc"
+	}
+	if err := db.Preload("ClientA").Preload("ClientB").Order(orderBy).Offset(offset).Limit(limit).Find(&list).Error; err != nil {
+		return nil, 0, fmt.Errorf("DuplicateRepo:Select where:%v err:%w", clause, err)
+	}
+	for _, d := range list {
+		d.Decode()
+	}
+	return list, total, nil
+}
+
+func (r *DuplicateRepo) Get(ctx context.Context, id uint64) (*biz_omiai.ClientDuplicate, error) {
+	var d biz_omiai.ClientDuplicate
+	if err := r.db.WithContext(ctx).Preload("ClientA").Preload("ClientB").First(&d, id).Error; err != nil {
+		return nil, err
+	}
+	d.Decode()
+	return &d, nil
+}
+
+func (r *DuplicateRepo) Ignore(ctx context.Context, id uint64, operator string) error {
+	res := r.db.WithContext(ctx).Model(&biz_omiai.ClientDuplicate{}).
+		Where("id = ? AND status = ?", id, biz_omiai.DuplicateStatusPending).
+		Updates(map[string]interface{}{
+			"status":      biz_omiai.DuplicateStatusIgnored,
+			"operator":    operator,
+			"resolved_at": time.Now(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return biz_omiai.ErrDuplicateStatus
+	}
+	return nil
+}
+
+// Merge 在一个事务内完成合并：回访记录与状态历史挂在匹配记录下，随匹配记录一并转移；
+// 被合并档案进行中的介绍关闭、见面取消，其余关联记录改指向保留档案
+func (r *DuplicateRepo) Merge(ctx context.Context, id, survivorID uint64, values map[string]interface{}, operator string) error {
+	ctx = biz_omiai.WithClientChange(ctx, biz_omiai.ClientSourceMerge, operator)
+	var (
+		mergedID uint64
+		released []uint64
+	)
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var d biz_omiai.ClientDuplicate
+		if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&d, id).Error; err != nil {
+			return err
+		}
+		if d.Status != biz_omiai.DuplicateStatusPending {
+			return biz_omiai.ErrDuplicateStatus
+		}
+		if survivorID != d.ClientAID && survivorID != d.ClientBID {
+			return biz_omiai.ErrDuplicateSurvivor
+		}
+		mergedID = d.Other(survivorID)
+		survivor, err := r.clients.lock(ctx, tx, survivorID)
+		if err != nil {
+			return err
+		}
+		merged, err := r.clients.lock(ctx, tx, mergedID)
+		if err != nil {
+			return err
+		}
+
+		var paired int64
+		if err := tx.WithContext(ctx).Unscoped().Model(&biz_omiai.MatchRecord{}).
+			Where("(male_client_id = ? AND female_client_id = ?) OR (male_client_id = ? AND female_client_id = ?)", survivorID, mergedID, mergedID, survivorID).
+			Count(&paired).Error; err != nil {
+			return err
+		}
+		if paired > 0 {
+			return biz_omiai.ErrDuplicatePaired
+		}
+		if survivor.PartnerID != nil && merged.PartnerID != nil {
+			return biz_omiai.ErrDuplicateMatched
+		}
+
+		// 1. 结束被合并档案进行中的介绍与见面，释放对方
+		released, err = r.closeOpen(ctx, tx, mergedID, survivorID, operator)
+		if err != nil {
+			return err
+		}
+
+		// 2. 匹配记录（含回收站中的）、提醒、拒绝记录、方案与相亲会记录改指向保留档案，标签并入保留档案
+		for _, column := range []string{"male_client_id", "female_client_id"} {
+			if err := tx.WithContext(ctx).Unscoped().Model(&biz_omiai.MatchRecord{}).Where(column+" = ?", mergedID).
+				UpdateColumn(column, survivorID).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.WithContext(ctx).Unscoped().Model(&biz_omiai.ReminderTask{}).Where("client_id = ?", mergedID).
+			UpdateColumn("client_id", survivorID).Error; err != nil {
+			return err
+		}
+		if err := r.repoint(ctx, tx, mergedID, survivorID); err != nil {
+			return err
+		}
+		// 单选分组保留最后一个，保留档案的标签排在后面以优先保留
+		var mergedTags, survivorTags []uint64
+		if err := tx.WithContext(ctx).Model(&biz_omiai.ClientTag{}).Where("client_id = ?", mergedID).Pluck("tag_id", &mergedTags).Error; err != nil {
+			return err
+		}
+		if err := tx.WithContext(ctx).Model(&biz_omiai.ClientTag{}).Where("client_id = ?", survivorID).Pluck("tag_id", &survivorTags).Error; err != nil {
+			return err
+		}
+		tagIDs := append(mergedTags, survivorTags...)
+		if err := addClientTags(ctx, tx, survivorID, tagIDs, biz_omiai.TagSourceManual, 1, operator); err != nil {
+			return err
+		}
+
+		// 3. 被合并档案的匹配对象转给保留档案
+		updates := make(map[string]interface{}, len(values)+2)
+		for k, v := range values {
+			updates[k] = v
+		}
+		if merged.PartnerID != nil {
+			if err := tx.WithContext(ctx).Model(&biz_omiai.Client{ID: mergedID}).Update("partner_id", nil).Error; err != nil {
+				return err
+			}
+			updates["partner_id"] = *merged.PartnerID
+			updates["status"] = merged.Status
+		}
+		if err := tx.WithContext(ctx).Model(&biz_omiai.Client{}).Where("partner_id = ?", mergedID).
+			Update("partner_id", survivorID).Error; err != nil {
+			return err
+		}
+
+		// 4. 写入保留档案
+		if len(updates) > 0 {
+			if err := tx.WithContext(ctx).Model(&biz_omiai.Client{ID: survivorID}).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		var after biz_omiai.Client
+		if err := tx.WithContext(ctx).First(&after, survivorID).Error; err != nil {
+			return err
+		}
+		if _, ok := values["partner_requirements"]; ok {
+			after.SyncRequirementColumns()
+			if err := tx.WithContext(ctx).Model(&after).Updates(after.RequirementColumns()).Error; err != nil {
+				return err
+			}
+		}
+		if err := syncClientQuality(ctx, tx, &after); err != nil {
+			return err
+		}
+		if err := r.clients.record(ctx, tx, survivorID, biz_omiai.ClientActionUpdate, survivor, &after, fmt.Sprintf("合并客户 #%d", mergedID)); err != nil {
+			return err
+		}
+
+		// 5. 被合并档案移入回收站
+		if err := tx.WithContext(ctx).Model(&biz_omiai.Client{ID: mergedID}).Update("deleted_at", time.Now().Truncate(time.Second)).Error; err != nil {
+			return err
+		}
+		if err := r.clients.record(ctx, tx, mergedID, biz_omiai.ClientActionDelete, merged, nil, fmt.Sprintf("合并至客户 #%d", survivorID)); err != nil {
+			return err
+		}
+
+		// 6. 标记已合并，被合并档案的其他待审核记录不再有效
+		if err := tx.WithContext(ctx).Model(&d).Updates(map[string]interface{}{
+			"status":      biz_omiai.DuplicateStatusMerged,
+			"survivor_id": survivorID,
+			"operator":    operator,
+			"resolved_at": time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+		return tx.WithContext(ctx).Where("status = ? AND (client_a_id = ? OR client_b_id = ?)", biz_omiai.DuplicateStatusPending, mergedID, mergedID).
+			Delete(&biz_omiai.ClientDuplicate{}).Error
+	})
+	if err != nil {
+		return err
+	}
+	r.clients.publish(ctx, mergedID, biz_omiai.ClientChangeDeleted)
+	r.clients.publish(ctx, survivorID, biz_omiai.ClientChangeProfile)
+	if len(released) > 0 {
+		r.clients.events.Publish(ctx, biz_omiai.EventClientChanged, &biz_omiai.ClientChanged{ClientIDs: released, Change: biz_omiai.ClientChangeStatus})
+	}
+	return nil
+}
+
+// closeOpen 关闭被合并档案进行中的介绍、取消已预约的见面及其提醒，返回因此恢复单身的对方客户
+func (r *DuplicateRepo) closeOpen(ctx context.Context, tx *gorm.DB, mergedID, survivorID uint64, operator string) ([]uint64, error) {
+	now := time.Now()
+	remark := fmt.Sprintf("客户 #%d 已合并至 #%d", mergedID, survivorID)
+
+	var intros []*biz_omiai.Introduction
+	if err := tx.WithContext(ctx).Where("(client_a_id = ? OR client_b_id = ?) AND status IN ?", mergedID, mergedID, biz_omiai.OpenIntroStatuses).
+		Find(&intros).Error; err != nil {
+		return nil, err
+	}
+	var others []uint64
+	for _, intro := range intros {
+		if err := tx.WithContext(ctx).Model(intro).Updates(map[string]interface{}{
+			"status":       biz_omiai.IntroStatusClosed,
+			"close_reason": biz_omiai.IntroCloseCancelled,
+			"closed_at":    now,
+		}).Error; err != nil {
+			return nil, err
+		}
+		if err := tx.WithContext(ctx).Create(&biz_omiai.IntroductionHistory{
+			IntroductionID: intro.ID,
+			OldStatus:      intro.Status,
+			NewStatus:      biz_omiai.IntroStatusClosed,
+			Operator:       operator,
+			Remark:         biz_omiai.IntroCloseReasonText[biz_omiai.IntroCloseCancelled] + " " + remark,
+		}).Error; err != nil {
+			return nil, err
+		}
+		if other := intro.ClientAID + intro.ClientBID - mergedID; other != survivorID {
+			others = append(others, other)
+		}
+	}
+	var released []uint64
+	if len(others) > 0 {
+		if err := tx.WithContext(ctx).Model(&biz_omiai.Client{}).Where("id IN ? AND status = ?", others, biz_omiai.ClientStatusMatching).
+			Pluck("id", &released).Error; err != nil {
+			return nil, err
+		}
+		if err := tx.WithContext(ctx).Model(&biz_omiai.Client{}).Where("id IN ?", released).
+			Update("status", biz_omiai.ClientStatusSingle).Error; err != nil {
+			return nil, err
+		}
+	}
+	// 与保留档案之间的介绍关闭后，保留档案同样恢复单身
+	if len(intros) > len(others) {
+		if err := tx.WithContext(ctx).Model(&biz_omiai.Client{}).Where("id = ? AND status = ?", survivorID, biz_omiai.ClientStatusMatching).
+			Update("status", biz_omiai.ClientStatusSingle).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	var meetingIDs []uint64
+	if err := tx.WithContext(ctx).Model(&biz_omiai.Meeting{}).Where("(client_a_id = ? OR client_b_id = ?) AND status = ?", mergedID, mergedID, biz_omiai.MeetingStatusBooked).
+		Pluck("id", &meetingIDs).Error; err != nil {
+		return nil, err
+	}
+	if len(meetingIDs) > 0 {
+		if err := tx.WithContext(ctx).Model(&biz_omiai.Meeting{}).Where("id IN ?", meetingIDs).Updates(map[string]interface{}{
+			"status":      biz_omiai.MeetingStatusCancelled,
+			"feedback":    remark,
+			"operator":    operator,
+			"finished_at": now,
+		}).Error; err != nil {
+			return nil, err
+		}
+		if err := tx.WithContext(ctx).Model(&biz_omiai.ReminderTask{}).Where("meeting_id IN ? AND status = ?", meetingIDs, "pending").
+			Update("status", "cancelled").Error; err != nil {
+			return nil, err
+		}
+	}
+	return released, nil
+}
+
+// repoint 拒绝记录、方案与相亲会记录改指向保留档案；两份档案之间的记录删除，合并后重复的报名、选择保留保留档案的一条
+func (r *DuplicateRepo) repoint(ctx context.Context, tx *gorm.DB, mergedID, survivorID uint64) error {
+	pairs := []struct {
+		model interface{}
+		a, b  string
+	}{
+		{&biz_omiai.PairHistory{}, "client_id", "candidate_id"},
+		{&biz_omiai.Proposal{}, "male_client_id", "female_client_id"},
+		{&biz_omiai.PartySeat{}, "male_client_id", "female_client_id"},
+	}
+	for _, p := range pairs {
+		if err := tx.WithContext(ctx).Where("("+p.a+" = ? AND "+p.b+" = ?) OR ("+p.a+" = ? AND "+p.b+" = ?)", mergedID, survivorID, survivorID, mergedID).
+			Delete(p.model).Error; err != nil {
+			return err
+		}
+		for _, column := range []string{p.a, p.b} {
+			if err := tx.WithContext(ctx).Model(p.model).Where(column+" = ?", mergedID).UpdateColumn(column, survivorID).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	// 报名：两份档案报名了同一场相亲会时保留保留档案的报名
+	var parties []uint64
+	if err := tx.WithContext(ctx).Model(&biz_omiai.PartyAttendee{}).Where("client_id = ?", survivorID).Pluck("party_id", &parties).Error; err != nil {
+		return err
+	}
+	if len(parties) > 0 {
+		if err := tx.WithContext(ctx).Where("client_id = ? AND party_id IN ?", mergedID, parties).Delete(&biz_omiai.PartyAttendee{}).Error; err != nil {
+			return err
+		}
+	}
+	if err := tx.WithContext(ctx).Model(&biz_omiai.PartyAttendee{}).Where("client_id = ?", mergedID).UpdateColumn("client_id", survivorID).Error; err != nil {
+		return err
+	}
+
+	// 会后选择：按改指向后的 (相亲会, 选择方, 被选择方) 去重，保留档案的选择优先
+	var picks []*biz_omiai.PartyPick
+	if err := tx.WithContext(ctx).Where("client_id IN ? OR picked_id IN ?", []uint64{survivorID, mergedID}, []uint64{survivorID, mergedID}).
+		Order("id").Find(&picks).Error; err != nil {
+		return err
+	}
+	to := func(id uint64) uint64 {
+		if id == mergedID {
+			return survivorID
+		}
+		return id
+	}
+	seen := make(map[[3]uint64]bool, len(picks))
+	for _, p := range picks {
+		if p.ClientID != mergedID && p.PickedID != mergedID {
+			seen[[3]uint64{p.PartyID, p.ClientID, p.PickedID}] = true
+		}
+	}
+	for _, p := range picks {
+		if p.ClientID != mergedID && p.PickedID != mergedID {
+			continue
+		}
+		key := [3]uint64{p.PartyID, to(p.ClientID), to(p.PickedID)}
+		if key[1] == key[2] || seen[key] {
+			if err := tx.WithContext(ctx).Delete(p).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		seen[key] = true
+		if err := tx.WithContext(ctx).Model(p).UpdateColumns(map[string]interface{}{"client_id": key[1], "picked_id": key[2]}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
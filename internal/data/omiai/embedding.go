@@ -0,0 +1,70 @@
+package omiai
+
+import (
+	"context"
+	"fmt"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/data"
+
+	"gorm.io/gorm/clause"
+)
+
+var _ biz_omiai.EmbeddingInterface = (*EmbeddingRepo)(nil)
+
+// embeddingTextFields 向量化所需的客户字段
+var embeddingTextFields = []string{"id", "name", "gender", "age", "birthday", "avatar", "status",
+	"remark", "family_description", "partner_requirements"}
+
+type EmbeddingRepo struct {
+	db *data.DB
+}
+
+func NewEmbeddingRepo(db *data.DB) biz_omiai.EmbeddingInterface {
+	return &EmbeddingRepo{db: db}
+}
+
+func (r *EmbeddingRepo) Get(ctx context.Context, clientID uint64) (*biz_omiai.ClientEmbedding, error) {
+	var e biz_omiai.ClientEmbedding
+	if err := r.db.WithContext(ctx).Where("client_id = ?", clientID).First(&e).Error; err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *EmbeddingRepo) List(ctx context.Context, model string) ([]*biz_omiai.ClientEmbedding, error) {
+	var list []*biz_omiai.ClientEmbedding
+	if err := r.db.WithContext(ctx).Where("model = ?", model).Find(&list).Error; err != nil {
+		return nil, fmt.Errorf("EmbeddingRepo:List model:%s err:%w", model, err)
+	}
+	return list, nil
+}
+
+func (r *EmbeddingRepo) Upsert(ctx context.Context, list []*biz_omiai.ClientEmbedding) error {
+	if len(list) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "client_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"model", "vector", "text_hash", "updated_at"}),
+	}).CreateInBatches(list, 100).Error
+}
+
+func (r *EmbeddingRepo) Delete(ctx context.Context, clientIDs []uint64) error {
+	if len(clientIDs) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Where("client_id IN ?", clientIDs).Delete(&biz_omiai.ClientEmbedding{}).Error
+}
+
+func (r *EmbeddingRepo) Texts(ctx context.Context, ids []uint64) ([]*biz_omiai.Client, error) {
+	var list []*biz_omiai.Client
+	db := r.db.WithContext(ctx).Model(&biz_omiai.Client{}).Select(embeddingTextFields).Preload("TagLinks.Tag")
+	if len(ids) > 0 {
+		db = db.Where("id IN ?", ids)
+	}
+	if err := db.Order("id").Find(&list).Error; err != nil {
+		return nil, fmt.Errorf("EmbeddingRepo:Texts err:%w", err)
+	}
+	return list, nil
+}
@@ -0,0 +1,67 @@
+package omiai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/data"
+
+	"gorm.io/gorm/clause"
+)
+
+var _ biz_omiai.ExperimentInterface = (*ExperimentRepo)(nil)
+
+type ExperimentRepo struct {
+	db *data.DB
+}
+
+func NewExperimentRepo(db *data.DB) biz_omiai.ExperimentInterface {
+	return &ExperimentRepo{db: db}
+}
+
+func (r *ExperimentRepo) LogExposures(ctx context.Context, list []*biz_omiai.ExperimentExposure) error {
+	if len(list) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(list, 100).Error
+}
+
+// 曝光配对与介绍、情侣档案的关联条件，双方顺序不限
+const (
+	exposureIntroPair = "((i.client_a_id = e.client_id AND i.client_b_id = e.candidate_id) OR (i.client_a_id = e.candidate_id AND i.client_b_id = e.client_id))" +
+		" AND i.created_at >= e.created_at"
+	exposureMatchPair = "((m.male_client_id = e.client_id AND m.female_client_id = e.candidate_id) OR (m.male_client_id = e.candidate_id AND m.female_client_id = e.client_id))" +
+		" AND m.created_at >= e.created_at AND m.deleted_at IS NULL"
+)
+
+func (r *ExperimentRepo) Funnel(ctx context.Context, experimentKey string) ([]*biz_omiai.ExperimentFunnel, error) {
+	countIf := func(cond string) string {
+		return "SUM(CASE WHEN EXISTS (" + cond + ") THEN 1 ELSE 0 END)"
+	}
+	// 进入交往：当前处于交往至结婚，或曾流转到交往后分手
+	dating := fmt.Sprintf("SELECT 1 FROM match_record m WHERE %s AND (m.status IN (%d, %d, %d, %d)"+
+		" OR EXISTS (SELECT 1 FROM match_status_history sh WHERE sh.match_record_id = m.id AND sh.current_status = %d AND sh.deleted_at IS NULL))",
+		exposureMatchPair, biz_omiai.MatchStatusDating, biz_omiai.MatchStatusStable, biz_omiai.MatchStatusEngagement,
+		biz_omiai.MatchStatusMarried, biz_omiai.MatchStatusDating)
+
+	columns := []string{
+		"e.variant AS variant",
+		"COUNT(DISTINCT e.client_id) AS clients",
+		"COUNT(*) AS exposures",
+		countIf("SELECT 1 FROM introduction i WHERE "+exposureIntroPair) + " AS introduced",
+		countIf(fmt.Sprintf("SELECT 1 FROM introduction i JOIN introduction_history h ON h.introduction_id = i.id WHERE %s AND h.new_status = %d",
+			exposureIntroPair, biz_omiai.IntroStatusBAccepted)) + " AS accepted",
+		countIf("SELECT 1 FROM match_record m WHERE "+exposureMatchPair) + " AS matched",
+		countIf(dating) + " AS dating",
+	}
+
+	var list []*biz_omiai.ExperimentFunnel
+	err := r.db.WithContext(ctx).Table("experiment_exposure AS e").Select(strings.Join(columns, ", ")).
+		Where("e.experiment_key = ?", experimentKey).Group("e.variant").Order("e.variant").Scan(&list).Error
+	if err != nil {
+		return nil, fmt.Errorf("ExperimentRepo:Funnel key:%s err:%w", experimentKey, err)
+	}
+	return list, nil
+}
@@ -0,0 +1,238 @@
+package omiai
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"omiai-server/internal/biz"
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/data"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var _ biz_omiai.IntroductionInterface = (*IntroductionRepo)(nil)
+
+type IntroductionRepo struct {
+	db     *data.DB
+	scorer biz_omiai.Scorer
+	events biz_omiai.EventBus
+}
+
+func NewIntroductionRepo(db *data.DB, scorer biz_omiai.Scorer, events biz_omiai.EventBus) biz_omiai.IntroductionInterface {
+	return &IntroductionRepo{db: db, scorer: scorer, events: events}
+}
+
+func (r *IntroductionRepo) Create(ctx context.Context, intro *biz_omiai.Introduction, operator string, scorer biz_omiai.Scorer) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var clients []*biz_omiai.Client
+		if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Scopes(biz_omiai.ScoringPreloads).
+			Where("id IN ?", []uint64{intro.ClientAID, intro.ClientBID}).Find(&clients).Error; err != nil {
+			return err
+		}
+		if len(clients) != 2 {
+			return gorm.ErrRecordNotFound
+		}
+		byID := make(map[uint64]*biz_omiai.Client, len(clients))
+		for _, c := range clients {
+			if c.Status != biz_omiai.ClientStatusSingle {
+				return biz_omiai.ErrIntroClientUnavailable
+			}
+			byID[c.ID] = c
+		}
+
+		intro.Status = biz_omiai.IntroStatusProposed
+		// 按 ID 取双方，A 为客户、B 为候选人，不依赖查询结果的顺序
+		intro.MatchScore = r.scorerOr(scorer).Score(byID[intro.ClientAID], byID[intro.ClientBID]).Score
+		if err := tx.WithContext(ctx).Create(intro).Error; err != nil {
+			return err
+		}
+		if err := r.setClientStatus(ctx, tx, intro, biz_omiai.ClientStatusSingle, biz_omiai.ClientStatusMatching); err != nil {
+			return err
+		}
+		return tx.WithContext(ctx).Create(&biz_omiai.IntroductionHistory{
+			IntroductionID: intro.ID,
+			NewStatus:      biz_omiai.IntroStatusProposed,
+			Operator:       operator,
+			Remark:         intro.Remark,
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+	r.publishClientStatus(ctx, intro)
+	return nil
+}
+
+func (r *IntroductionRepo) Get(ctx context.Context, id uint64) (*biz_omiai.Introduction, error) {
+	var intro biz_omiai.Introduction
+	if err := r.db.WithContext(ctx).Preload("ClientA").Preload("ClientB").First(&intro, id).Error; err != nil {
+		return nil, err
+	}
+	return &intro, nil
+}
+
+func (r *IntroductionRepo) Select(ctx context.Context, clause *biz.WhereClause, offset, limit int) ([]*biz_omiai.Introduction, int64, error) {
+	var (
+		list  []*biz_omiai.Introduction
+		total int64
+	)
+	db := r.db.WithContext(ctx).Model(&biz_omiai.Introduction{}).Where(clause.Where, clause.Args...)
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("IntroductionRepo:Select count where:%v err:%w", clause, err)
+	}
+	orderBy := clause.OrderBy
+	if orderBy == "" {
+		orderBy = "updated_at desc"
+	}
+	if err := db.Preload("ClientA").Preload("ClientB").Order(orderBy).Offset(offset).Limit(limit).Find(&list).Error; err != nil {
+		return nil, 0, fmt.Errorf("IntroductionRepo:Select where:%v err:%w", clause, err)
+	}
+	return list, total, nil
+}
+
+func (r *IntroductionRepo) Transition(ctx context.Context, id uint64, to int8, change *biz_omiai.IntroductionChange) (*biz_omiai.Introduction, error) {
+	if change == nil {
+		change = &biz_omiai.IntroductionChange{}
+	}
+	if to == biz_omiai.IntroStatusClosed && change.CloseReason == "" {
+		return nil, biz_omiai.ErrIntroCloseReason
+	}
+	if to == biz_omiai.IntroStatusScheduled && change.MeetingAt == nil {
+		return nil, biz_omiai.ErrIntroMeetingTime
+	}
+
+	var intro biz_omiai.Introduction
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.WithContext(ctx).First(&intro, id).Error; err != nil {
+			return err
+		}
+		from := intro.Status
+		if !biz_omiai.CanIntroTransition(from, to) {
+			return biz_omiai.ErrIntroInvalidTransition
+		}
+
+		now := time.Now()
+		updates := map[string]interface{}{"status": to}
+		switch to {
+		case biz_omiai.IntroStatusScheduled:
+			updates["meeting_at"] = change.MeetingAt
+			updates["meeting_place"] = change.MeetingPlace
+		case biz_omiai.IntroStatusClosed:
+			updates["close_reason"] = change.CloseReason
+			updates["closed_at"] = now
+			if err := r.setClientStatus(ctx, tx, &intro, biz_omiai.ClientStatusMatching, biz_omiai.ClientStatusSingle); err != nil {
+				return err
+			}
+		case biz_omiai.IntroStatusConverted:
+			record, err := r.convert(ctx, tx, &intro, change)
+			if err != nil {
+				return err
+			}
+			updates["match_record_id"] = record.ID
+			updates["closed_at"] = now
+		}
+
+		// 比较并更新：状态在读取后被修改则放弃本次操作
+		res := tx.WithContext(ctx).Model(&biz_omiai.Introduction{}).Where("id = ? AND status = ?", id, from).Updates(updates)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return biz_omiai.ErrIntroStatusChanged
+		}
+
+		remark := change.Remark
+		if to == biz_omiai.IntroStatusClosed {
+			remark = biz_omiai.IntroCloseReasonText[change.CloseReason] + " " + remark
+		}
+		return tx.WithContext(ctx).Create(&biz_omiai.IntroductionHistory{
+			IntroductionID: id,
+			OldStatus:      from,
+			NewStatus:      to,
+			Operator:       change.Operator,
+			Remark:         remark,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	if to == biz_omiai.IntroStatusClosed || to == biz_omiai.IntroStatusConverted {
+		r.publishClientStatus(ctx, &intro)
+	}
+	if to == biz_omiai.IntroStatusClosed {
+		r.events.Publish(ctx, biz_omiai.EventIntroductionClosed, &biz_omiai.IntroductionClosed{
+			IntroductionID: id,
+			ClientAID:      intro.ClientAID,
+			ClientBID:      intro.ClientBID,
+			CloseReason:    change.CloseReason,
+			Operator:       change.Operator,
+			Remark:         change.Remark,
+		})
+	}
+	return r.Get(ctx, id)
+}
+
+// convert 见面后确认交往，生成情侣档案
+func (r *IntroductionRepo) convert(ctx context.Context, tx *gorm.DB, intro *biz_omiai.Introduction, change *biz_omiai.IntroductionChange) (*biz_omiai.MatchRecord, error) {
+	var a, b biz_omiai.Client
+	if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Scopes(biz_omiai.ScoringPreloads).First(&a, intro.ClientAID).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Scopes(biz_omiai.ScoringPreloads).First(&b, intro.ClientBID).Error; err != nil {
+		return nil, err
+	}
+	if a.Status != biz_omiai.ClientStatusMatching || b.Status != biz_omiai.ClientStatusMatching {
+		return nil, fmt.Errorf("introduction %d: clients are no longer in matching status", intro.ID)
+	}
+	return createMatchRecordTx(ctx, tx, &a, &b, r.scorerOr(change.Scorer).Score(&a, &b).Score, change.Operator, change.Remark)
+}
+
+// scorerOr 未指定评分器（无 A/B 实验分组）时使用默认评分器
+func (r *IntroductionRepo) scorerOr(scorer biz_omiai.Scorer) biz_omiai.Scorer {
+	if scorer != nil {
+		return scorer
+	}
+	return r.scorer
+}
+
+func (r *IntroductionRepo) publishClientStatus(ctx context.Context, intro *biz_omiai.Introduction) {
+	r.events.Publish(ctx, biz_omiai.EventClientChanged, &biz_omiai.ClientChanged{
+		ClientIDs: []uint64{intro.ClientAID, intro.ClientBID},
+		Change:    biz_omiai.ClientChangeStatus,
+	})
+}
+
+// setClientStatus 仅更新仍处于 from 状态的一方，避免覆盖其他流程已修改的状态
+func (r *IntroductionRepo) setClientStatus(ctx context.Context, tx *gorm.DB, intro *biz_omiai.Introduction, from, to int8) error {
+	return tx.WithContext(ctx).Model(&biz_omiai.Client{}).
+		Where("id IN ? AND status = ?", []uint64{intro.ClientAID, intro.ClientBID}, from).
+		Update("status", to).Error
+}
+
+func (r *IntroductionRepo) History(ctx context.Context, id uint64) ([]*biz_omiai.IntroductionHistory, error) {
+	var list []*biz_omiai.IntroductionHistory
+	err := r.db.WithContext(ctx).Where("introduction_id = ?", id).Order("id asc").Find(&list).Error
+	return list, err
+}
+
+func (r *IntroductionRepo) CountByStatus(ctx context.Context, matchmakerID uint64) (map[int8]int64, error) {
+	var rows []struct {
+		Status int8
+		Total  int64
+	}
+	db := r.db.WithContext(ctx).Model(&biz_omiai.Introduction{})
+	if matchmakerID > 0 {
+		db = db.Where("matchmaker_id = ?", matchmakerID)
+	}
+	if err := db.Select("status, COUNT(*) AS total").Group("status").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make(map[int8]int64, len(rows))
+	for _, row := range rows {
+		out[row.Status] = row.Total
+	}
+	return out, nil
+}
@@ -0,0 +1,46 @@
+package omiai
+
+import (
+	"context"
+	"testing"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/data"
+	"omiai-server/internal/service/event"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// directedScorer 与参数顺序相关的评分器，用于校验调用方传参的方向
+type directedScorer struct{}
+
+func (directedScorer) Name() string { return "directed" }
+
+func (directedScorer) Score(client, candidate *biz_omiai.Client) *biz_omiai.ScoreResult {
+	return &biz_omiai.ScoreResult{Score: int(client.ID*10 + candidate.ID)}
+}
+
+func TestIntroductionRepo_CreateScore(t *testing.T) {
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	assert.NoError(t, db.AutoMigrate(&biz_omiai.Client{}, &biz_omiai.PersonalityProfile{}, &biz_omiai.ClientEmbedding{}, &biz_omiai.ClientTag{},
+		&biz_omiai.Tag{}, &biz_omiai.TagGroup{}, &biz_omiai.Introduction{}, &biz_omiai.IntroductionHistory{}))
+
+	d := &data.DB{DB: db}
+	repo := NewIntroductionRepo(d, directedScorer{}, event.NewBus())
+	b := &biz_omiai.Client{Name: "b", Gender: 2, Status: biz_omiai.ClientStatusSingle}
+	a := &biz_omiai.Client{Name: "a", Gender: 1, Status: biz_omiai.ClientStatusSingle}
+	assert.NoError(t, db.Create(b).Error)
+	assert.NoError(t, db.Create(a).Error)
+	assert.Greater(t, a.ID, b.ID)
+
+	// A 的 ID 大于 B 时匹配分仍为 Score(A, B)
+	intro := &biz_omiai.Introduction{ClientAID: a.ID, ClientBID: b.ID}
+	assert.NoError(t, repo.Create(ctx, intro, "op", nil))
+	assert.Equal(t, directedScorer{}.Score(a, b).Score, intro.MatchScore)
+}
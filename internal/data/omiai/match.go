@@ -2,7 +2,6 @@ package omiai
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"omiai-server/internal/biz"
 	biz_omiai "omiai-server/internal/biz/omiai"
@@ -10,6 +9,7 @@ import (
 	"sort"
 	"time"
 
+	"github.com/iWuxc/go-wit/log"
 	"github.com/iWuxc/go-wit/redis"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -18,11 +18,17 @@ import (
 var _ biz_omiai.MatchInterface = (*MatchRepo)(nil)
 
 type MatchRepo struct {
-	db *data.DB
+	db     *data.DB
+	scorer biz_omiai.Scorer
+	events biz_omiai.EventBus
 }
 
-func NewMatchRepo(db *data.DB) biz_omiai.MatchInterface {
-	return &MatchRepo{db: db}
+// NewMatchRepo 创建仓库并订阅客户、情侣变更事件以维护候选人缓存
+func NewMatchRepo(db *data.DB, scorer biz_omiai.Scorer, events biz_omiai.EventBus) biz_omiai.MatchInterface {
+	r := &MatchRepo{db: db, scorer: scorer, events: events}
+	events.Subscribe(biz_omiai.EventClientChanged, r.onClientChanged)
+	events.Subscribe(biz_omiai.EventMatchStatusChanged, r.onMatchStatusChanged)
+	return r
 }
 
 func (r *MatchRepo) Select(ctx context.Context, clause *biz.WhereClause, offset, limit int) ([]*biz_omiai.MatchRecord, error) {
@@ -42,7 +48,7 @@ func (r *MatchRepo) Select(ctx context.Context, clause *biz.WhereClause, offset,
 }
 
 func (r *MatchRepo) Create(ctx context.Context, record *biz_omiai.MatchRecord) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
 		// 1. Create Match Record
 		if err := tx.WithContext(ctx).Create(record).Error; err != nil {
 			return err
@@ -54,6 +60,10 @@ func (r *MatchRepo) Create(ctx context.Context, record *biz_omiai.MatchRecord) e
 		}
 		return nil
 	})
+	if err == nil {
+		r.publishClientStatus(ctx, record.MaleClientID, record.FemaleClientID)
+	}
+	return err
 }
 
 func (r *MatchRepo) Update(ctx context.Context, record *biz_omiai.MatchRecord) error {
@@ -70,8 +80,8 @@ func (r *MatchRepo) Get(ctx context.Context, id uint64) (*biz_omiai.MatchRecord,
 }
 
 func (r *MatchRepo) Delete(ctx context.Context, id uint64) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
-		var record biz_omiai.MatchRecord
+	var record biz_omiai.MatchRecord
+	err := r.db.Transaction(func(tx *gorm.DB) error {
 		if err := tx.First(&record, id).Error; err != nil {
 			return err
 		}
@@ -86,130 +96,290 @@ func (r *MatchRepo) Delete(ctx context.Context, id uint64) error {
 		}
 		return nil
 	})
+	if err == nil {
+		r.publishClientStatus(ctx, record.MaleClientID, record.FemaleClientID)
+	}
+	return err
+}
+
+// candidateSortColumns 候选人列表可排序字段
+var candidateSortColumns = map[string]string{
+	biz_omiai.CandidateSortScore:     "recommendation.score",
+	biz_omiai.CandidateSortAge:       "client.age",
+	biz_omiai.CandidateSortHeight:    "client.height",
+	biz_omiai.CandidateSortIncome:    "client.income",
+	biz_omiai.CandidateSortEducation: "client.education",
 }
 
 // V2: GetCandidates 获取候选人列表
-func (r *MatchRepo) GetCandidates(ctx context.Context, clientID uint64) ([]*biz_omiai.Candidate, error) {
+func (r *MatchRepo) GetCandidates(ctx context.Context, query *biz_omiai.CandidateQuery) ([]*biz_omiai.Candidate, int64, error) {
 	var client biz_omiai.Client
-	if err := r.db.WithContext(ctx).First(&client, clientID).Error; err != nil {
-		return nil, err
+	if err := r.db.WithContext(ctx).Scopes(biz_omiai.ScoringPreloads).First(&client, query.ClientID).Error; err != nil {
+		return nil, 0, err
+	}
+	if client.Age == 0 {
+		client.Age = client.RealAge()
+	}
+	scorer := r.scorerOr(query.Scorer)
+
+	// 1. 当前算法尚未生成推荐时实时计算
+	var generated int64
+	if err := r.db.WithContext(ctx).Model(&biz_omiai.Recommendation{}).
+		Where("client_id = ? AND algorithm = ?", client.ID, scorer.Name()).Count(&generated).Error; err != nil {
+		return nil, 0, err
+	}
+	if generated == 0 {
+		if _, err := r.RefreshRecommendations(ctx, &client, scorer); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	// 2. 候选人资料变更过的推荐重新评分
+	if err := r.rescoreStale(ctx, &client, scorer); err != nil {
+		return nil, 0, err
+	}
+
+	// 3. 读取时剔除已匹配、已删除，或新增拒绝、冷静期记录的候选人
+	pool := &biz.WhereClause{Where: "status = ?", Args: []interface{}{biz_omiai.ClientStatusSingle}}
+	biz_omiai.AppendPairHistoryFilter(pool, client.ID, time.Now())
+	if query.MinAge > 0 {
+		pool.Where += " AND age >= ?"
+		pool.Args = append(pool.Args, query.MinAge)
+	}
+	if query.MaxAge > 0 {
+		pool.Where += " AND age <= ?"
+		pool.Args = append(pool.Args, query.MaxAge)
+	}
+	if query.CityCode != "" {
+		pool.Where += " AND (work_city_code = ? OR house_city_code = ?)"
+		pool.Args = append(pool.Args, query.CityCode, query.CityCode)
+	}
+	if query.MinEducation > 0 {
+		pool.Where += " AND education >= ?"
+		pool.Args = append(pool.Args, query.MinEducation)
+	}
+
+	args := append([]interface{}{client.ID, scorer.Name(), biz_omiai.RecommendationStatusActive}, pool.Args...)
+	db := r.db.WithContext(ctx).Model(&biz_omiai.Recommendation{}).
+		Joins("JOIN client ON client.id = recommendation.candidate_id").
+		Where("recommendation.client_id = ? AND recommendation.algorithm = ? AND recommendation.status = ? AND recommendation.candidate_id IN (SELECT id FROM client WHERE deleted_at IS NULL AND "+pool.Where+")", args...)
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("MatchRepo:GetCandidates count err:%w", err)
 	}
 
-	// 1. Try Cache
-	if client.CandidateCacheJSON != "" {
-		var candidates []*biz_omiai.Candidate
-		if err := json.Unmarshal([]byte(client.CandidateCacheJSON), &candidates); err == nil && len(candidates) > 0 {
-			return candidates, nil
+	column, ok := candidateSortColumns[query.Sort]
+	if !ok {
+		column = candidateSortColumns[biz_omiai.CandidateSortScore]
+	}
+	direction := " desc"
+	if query.Asc {
+		direction = " asc"
+	}
+
+	var list []*biz_omiai.Recommendation
+	if err := db.Select("recommendation.*").Preload("Candidate").
+		Order(column + direction).Order("recommendation.score desc").Order("recommendation.id").
+		Offset(query.Offset).Limit(query.Limit).Find(&list).Error; err != nil {
+		return nil, 0, fmt.Errorf("MatchRepo:GetCandidates err:%w", err)
+	}
+
+	candidates := make([]*biz_omiai.Candidate, 0, len(list))
+	for _, rec := range list {
+		c := rec.ToCandidate()
+		if query.Explain {
+			c.Explanation = biz_omiai.NewScoreExplanation(client.ID, rec.CandidateID, rec.Result(), nil)
 		}
+		candidates = append(candidates, c)
 	}
+	return candidates, total, nil
+}
 
-	// 2. Fallback: Calculate Real-time
+// RefreshRecommendations 按双向择偶要求筛选候选池，统一评分后整体替换客户在该算法下的推荐，其他实验分组的推荐不受影响
+func (r *MatchRepo) RefreshRecommendations(ctx context.Context, client *biz_omiai.Client, scorer biz_omiai.Scorer) (int, error) {
+	scorer = r.scorerOr(scorer)
+	if client.Age == 0 {
+		client.Age = client.RealAge()
+	}
 	targetGender := 1
 	if client.Gender == 1 {
 		targetGender = 2
 	}
 
+	clause := &biz.WhereClause{
+		Where: "gender = ? AND status = ?",
+		Args:  []interface{}{targetGender, biz_omiai.ClientStatusSingle},
+	}
+	biz_omiai.AppendRequirementFilter(clause, client, biz_omiai.RequirementRelaxed)
+	biz_omiai.AppendPairHistoryFilter(clause, client.ID, time.Now())
+
 	var potentialMatches []*biz_omiai.Client
-	if err := r.db.WithContext(ctx).Where("gender = ? AND status = ?", targetGender, biz_omiai.ClientStatusSingle).
-		Limit(100).Find(&potentialMatches).Error; err != nil {
-		return nil, err
+	if err := r.db.WithContext(ctx).Scopes(biz_omiai.ScoringPreloads).Where(clause.Where, clause.Args...).Find(&potentialMatches).Error; err != nil {
+		return 0, err
 	}
 
-	var candidates []*biz_omiai.Candidate
+	recommendations := make([]*biz_omiai.Recommendation, 0, len(potentialMatches))
 	for _, match := range potentialMatches {
-		// 使用匹配算法计算真实匹配度
-		calculator := NewMatchCalculator(&client, match)
-		score := calculator.Calculate()
-		tags := calculator.GetMatchTags()
-
-		candidates = append(candidates, &biz_omiai.Candidate{
-			CandidateID: match.ID,
-			Name:        match.Name,
-			Avatar:      match.Avatar,
-			MatchScore:  score,
-			Tags:        tags,
-			Age:         match.RealAge(),
-			Height:      match.Height,
-			Education:   int(match.Education),
-		})
+		if match.Age == 0 {
+			match.Age = match.RealAge()
+		}
+		// 使用统一评分器计算匹配度
+		result := scorer.Score(client, match)
+		recommendations = append(recommendations, biz_omiai.NewRecommendation(client.ID, match.ID, result))
 	}
 
 	// Sort by score desc
-	sort.Slice(candidates, func(i, j int) bool {
-		return candidates[i].MatchScore > candidates[j].MatchScore
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		return recommendations[i].Score > recommendations[j].Score
 	})
-	if len(candidates) > 20 {
-		candidates = candidates[:20]
+	if len(recommendations) > biz_omiai.RecommendationLimit {
+		recommendations = recommendations[:biz_omiai.RecommendationLimit]
 	}
 
-	// 3. Update Cache (Lazy Load)
-	if bytes, err := json.Marshal(candidates); err == nil {
-		// Ignore error on update
-		r.db.WithContext(ctx).Model(&client).Update("candidate_cache_json", string(bytes))
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.WithContext(ctx).Where("client_id = ? AND algorithm = ?", client.ID, scorer.Name()).Delete(&biz_omiai.Recommendation{}).Error; err != nil {
+			return err
+		}
+		if len(recommendations) == 0 {
+			return nil
+		}
+		return tx.WithContext(ctx).CreateInBatches(recommendations, 100).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(recommendations), nil
+}
+
+// rescoreStale 对待重算的推荐重新评分，不再满足筛选条件的候选人直接移除
+func (r *MatchRepo) rescoreStale(ctx context.Context, client *biz_omiai.Client, scorer biz_omiai.Scorer) error {
+	var stale []*biz_omiai.Recommendation
+	if err := r.db.WithContext(ctx).Where("client_id = ? AND algorithm = ? AND status = ?", client.ID, scorer.Name(), biz_omiai.RecommendationStatusStale).
+		Find(&stale).Error; err != nil || len(stale) == 0 {
+		return err
+	}
+
+	ids := make([]uint64, 0, len(stale))
+	for _, rec := range stale {
+		ids = append(ids, rec.CandidateID)
+	}
+	targetGender := 1
+	if client.Gender == 1 {
+		targetGender = 2
 	}
+	clause := &biz.WhereClause{Where: "id IN ? AND gender = ? AND status = ?", Args: []interface{}{ids, targetGender, biz_omiai.ClientStatusSingle}}
+	biz_omiai.AppendRequirementFilter(clause, client, biz_omiai.RequirementRelaxed)
 
-	return candidates, nil
+	var candidates []*biz_omiai.Client
+	if err := r.db.WithContext(ctx).Scopes(biz_omiai.ScoringPreloads).Where(clause.Where, clause.Args...).Find(&candidates).Error; err != nil {
+		return err
+	}
+	byID := make(map[uint64]*biz_omiai.Client, len(candidates))
+	for _, c := range candidates {
+		if c.Age == 0 {
+			c.Age = c.RealAge()
+		}
+		byID[c.ID] = c
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, rec := range stale {
+			candidate, ok := byID[rec.CandidateID]
+			if !ok {
+				if err := tx.WithContext(ctx).Delete(rec).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			rec.Apply(scorer.Score(client, candidate))
+			if err := tx.WithContext(ctx).Model(rec).Select("score", "dimensions", "tags", "algorithm", "generated_at", "status").
+				Updates(rec).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// scorerOr 未指定评分器（无 A/B 实验分组）时使用默认评分器
+func (r *MatchRepo) scorerOr(scorer biz_omiai.Scorer) biz_omiai.Scorer {
+	if scorer != nil {
+		return scorer
+	}
+	return r.scorer
+}
+
+func (r *MatchRepo) PruneRecommendations(ctx context.Context, keep []string) (int64, error) {
+	res := r.db.WithContext(ctx).Where("algorithm NOT IN ?", keep).Delete(&biz_omiai.Recommendation{})
+	if res.Error != nil {
+		return 0, fmt.Errorf("MatchRepo:PruneRecommendations keep:%v err:%w", keep, res.Error)
+	}
+	return res.RowsAffected, nil
+}
+
+// RecommendedTo 反查候选人当前出现在哪些客户的推荐中，同一客户有多个实验分组的推荐时只计一次
+func (r *MatchRepo) RecommendedTo(ctx context.Context, candidateID uint64, offset, limit int) ([]*biz_omiai.Recommendation, int64, error) {
+	var (
+		list  []*biz_omiai.Recommendation
+		total int64
+	)
+	db := r.db.WithContext(ctx).Model(&biz_omiai.Recommendation{}).
+		Where("id IN (SELECT MIN(id) FROM recommendation WHERE candidate_id = ? GROUP BY client_id)", candidateID)
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("MatchRepo:RecommendedTo count err:%w", err)
+	}
+	if err := db.Preload("Client").Order("score desc").Order("id").Offset(offset).Limit(limit).Find(&list).Error; err != nil {
+		return nil, 0, fmt.Errorf("MatchRepo:RecommendedTo err:%w", err)
+	}
+	return list, total, nil
 }
 
 // V2: Compare 比较详情
-func (r *MatchRepo) Compare(ctx context.Context, clientID, candidateID uint64) (*biz_omiai.Comparison, error) {
+func (r *MatchRepo) Compare(ctx context.Context, clientID, candidateID uint64, scorer biz_omiai.Scorer) (*biz_omiai.Comparison, error) {
 	var c1, c2 biz_omiai.Client
-	if err := r.db.WithContext(ctx).First(&c1, clientID).Error; err != nil {
+	if err := r.db.WithContext(ctx).Scopes(biz_omiai.ScoringPreloads).First(&c1, clientID).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.WithContext(ctx).Scopes(biz_omiai.ScoringPreloads).First(&c2, candidateID).Error; err != nil {
 		return nil, err
 	}
-	if err := r.db.WithContext(ctx).First(&c2, candidateID).Error; err != nil {
+
+	result := r.scorerOr(scorer).Score(&c1, &c2)
+
+	return biz_omiai.NewComparison(&c1, &c2, result), nil
+}
+
+// Explain 实时评分，硬性过滤项与推荐生成时的筛选条件一致
+func (r *MatchRepo) Explain(ctx context.Context, clientID, candidateID uint64, scorer biz_omiai.Scorer) (*biz_omiai.ScoreExplanation, error) {
+	var client, candidate biz_omiai.Client
+	if err := r.db.WithContext(ctx).Scopes(biz_omiai.ScoringPreloads).First(&client, clientID).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.WithContext(ctx).Scopes(biz_omiai.ScoringPreloads).First(&candidate, candidateID).Error; err != nil {
 		return nil, err
 	}
+	for _, c := range []*biz_omiai.Client{&client, &candidate} {
+		if c.Age == 0 {
+			c.Age = c.RealAge()
+		}
+	}
+
+	now := time.Now()
+	var history []*biz_omiai.PairHistory
+	if err := r.db.WithContext(ctx).
+		Where("(client_id = ? AND candidate_id = ?) OR (client_id = ? AND candidate_id = ?)", clientID, candidateID, candidateID, clientID).
+		Order("id").Find(&history).Error; err != nil {
+		return nil, fmt.Errorf("MatchRepo:Explain pair history err:%w", err)
+	}
 
-	// Build Comparison Data
-	comp := &biz_omiai.Comparison{
-		BasicInfo: map[string]map[string]interface{}{
-			"age": {
-				"client":    c1.RealAge(),
-				"candidate": c2.RealAge(),
-				"diff":      fmt.Sprintf("相差%d岁", c1.RealAge()-c2.RealAge()),
-			},
-			"height": {
-				"client":    c1.Height,
-				"candidate": c2.Height,
-				"diff":      fmt.Sprintf("相差%dcm", c1.Height-c2.Height),
-			},
-			"education": {
-				"client":    c1.Education, // Value 1-5
-				"candidate": c2.Education,
-				"match":     c1.Education == c2.Education,
-			},
-			"income": {
-				"client":    c1.Income,
-				"candidate": c2.Income,
-				"match":     true, // Simple placeholder
-			},
-		},
-		PersonalityRadar: map[string]map[string]int{
-			"openness":          {"client": 80, "candidate": 75},
-			"conscientiousness": {"client": 60, "candidate": 85},
-			"extraversion":      {"client": 70, "candidate": 65},
-			"agreeableness":     {"client": 90, "candidate": 90},
-			"neuroticism":       {"client": 40, "candidate": 30},
-		},
-		Interests: map[string]interface{}{
-			"overlap_percentage": 0.8,
-			"common_list":        []string{"旅行", "摄影"},
-		},
-		Values: map[string]interface{}{
-			"match_percentage": 0.9,
-			"details":          []string{"家庭观念一致"},
-		},
-		RelationshipExpectations: map[string]map[string]int{
-			"short_term": {"client": 2, "candidate": 1},
-			"long_term":  {"client": 5, "candidate": 5},
-		},
-	}
-	return comp, nil
+	filters := biz_omiai.CheckHardFilters(&client, &candidate, now)
+	filters = append(filters, biz_omiai.PairHistoryFilters(clientID, history, now)...)
+	return biz_omiai.NewScoreExplanation(clientID, candidateID, r.scorerOr(scorer).Score(&client, &candidate), filters), nil
 }
 
 // V2: ConfirmMatch 直接确认匹配
-func (r *MatchRepo) ConfirmMatch(ctx context.Context, clientID, candidateID uint64, adminID, remark string) (*biz_omiai.MatchRecord, error) {
+func (r *MatchRepo) ConfirmMatch(ctx context.Context, clientID, candidateID uint64, adminID, remark string, scorer biz_omiai.Scorer) (*biz_omiai.MatchRecord, error) {
 	// 0. Distributed Lock using Redis
 	lockKey := fmt.Sprintf("lock:match:client:%d:%d", clientID, candidateID)
 	// Try to acquire lock for 10 seconds
@@ -222,18 +392,18 @@ func (r *MatchRepo) ConfirmMatch(ctx context.Context, clientID, candidateID uint
 	}
 	defer redis.GetRedis().GetClient().Del(ctx, lockKey)
 
-	return r.confirmMatchDB(ctx, clientID, candidateID, adminID, remark)
+	return r.confirmMatchDB(ctx, clientID, candidateID, adminID, remark, r.scorerOr(scorer))
 }
 
-func (r *MatchRepo) confirmMatchDB(ctx context.Context, clientID, candidateID uint64, adminID, remark string) (*biz_omiai.MatchRecord, error) {
+func (r *MatchRepo) confirmMatchDB(ctx context.Context, clientID, candidateID uint64, adminID, remark string, scorer biz_omiai.Scorer) (*biz_omiai.MatchRecord, error) {
 	var matchRecord *biz_omiai.MatchRecord
 	err := r.db.Transaction(func(tx *gorm.DB) error {
 		// 1. Get Clients and Verify Status (Double Check)
 		var c1, c2 biz_omiai.Client
-		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&c1, clientID).Error; err != nil {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Scopes(biz_omiai.ScoringPreloads).First(&c1, clientID).Error; err != nil {
 			return err
 		}
-		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&c2, candidateID).Error; err != nil {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Scopes(biz_omiai.ScoringPreloads).First(&c2, candidateID).Error; err != nil {
 			return err
 		}
 
@@ -241,74 +411,83 @@ func (r *MatchRepo) confirmMatchDB(ctx context.Context, clientID, candidateID ui
 			return fmt.Errorf("one or both clients are already matched")
 		}
 
-		maleID, femaleID := clientID, candidateID
-		if c1.Gender == 2 { // If c1 is female
-			maleID, femaleID = candidateID, clientID
-		}
-
-		// 2. Create Match Record
-		matchRecord = &biz_omiai.MatchRecord{
-			MaleClientID:   maleID,
-			FemaleClientID: femaleID,
-			MatchDate:      time.Now(),
-			Status:         biz_omiai.MatchStatusAcquaintance,
-			MatchScore:     85, // Mock score
-			AdminID:        adminID,
-			Remark:         remark,
-		}
-		if err := tx.WithContext(ctx).Create(matchRecord).Error; err != nil {
-			return err
-		}
-
-		// 3. Update Client Statuses and Partner ID
-		// Update Client 1
-		if err := tx.WithContext(ctx).Model(&biz_omiai.Client{}).Where("id = ?", clientID).
-			Updates(map[string]interface{}{
-				"status":     biz_omiai.ClientStatusMatched,
-				"partner_id": candidateID,
-			}).Error; err != nil {
-			return err
-		}
-		// Update Client 2
-		if err := tx.WithContext(ctx).Model(&biz_omiai.Client{}).Where("id = ?", candidateID).
-			Updates(map[string]interface{}{
-				"status":     biz_omiai.ClientStatusMatched,
-				"partner_id": clientID,
-			}).Error; err != nil {
+		record, err := createMatchRecordTx(ctx, tx, &c1, &c2, scorer.Score(&c1, &c2).Score, adminID, remark)
+		if err != nil {
 			return err
 		}
+		matchRecord = record
 		return nil
 	})
-	return matchRecord, err
+	if err != nil {
+		return nil, err
+	}
+	r.publishClientStatus(ctx, clientID, candidateID)
+	return matchRecord, nil
+}
+
+// createMatchRecordTx 在事务中生成情侣档案，并将双方置为已匹配、互相绑定 partner_id
+func createMatchRecordTx(ctx context.Context, tx *gorm.DB, c1, c2 *biz_omiai.Client, score int, adminID, remark string) (*biz_omiai.MatchRecord, error) {
+	maleID, femaleID := c1.ID, c2.ID
+	if c1.Gender == 2 { // If c1 is female
+		maleID, femaleID = c2.ID, c1.ID
+	}
+
+	matchRecord := &biz_omiai.MatchRecord{
+		MaleClientID:   maleID,
+		FemaleClientID: femaleID,
+		MatchDate:      time.Now(),
+		Status:         biz_omiai.MatchStatusAcquaintance,
+		MatchScore:     score,
+		AdminID:        adminID,
+		Remark:         remark,
+	}
+	if err := tx.WithContext(ctx).Create(matchRecord).Error; err != nil {
+		return nil, err
+	}
+
+	if err := tx.WithContext(ctx).Model(&biz_omiai.Client{}).Where("id = ?", c1.ID).
+		Updates(map[string]interface{}{
+			"status":     biz_omiai.ClientStatusMatched,
+			"partner_id": c2.ID,
+		}).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.WithContext(ctx).Model(&biz_omiai.Client{}).Where("id = ?", c2.ID).
+		Updates(map[string]interface{}{
+			"status":     biz_omiai.ClientStatusMatched,
+			"partner_id": c1.ID,
+		}).Error; err != nil {
+		return nil, err
+	}
+	return matchRecord, nil
 }
 
 // UpdateStatus 更新匹配状态并记录历史
 func (r *MatchRepo) UpdateStatus(ctx context.Context, recordID uint64, oldStatus, newStatus int8, operator, reason string) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
-		// 1. Update Match Status
-		if err := tx.WithContext(ctx).Model(&biz_omiai.MatchRecord{}).Where("id = ?", recordID).Update("status", newStatus).Error; err != nil {
-			return err
-		}
-
-		// 2. Create History Record
-		history := &biz_omiai.MatchStatusHistory{
-			MatchRecordID: recordID,
-			OldStatus:     oldStatus,
-			NewStatus:     newStatus,
-			ChangeTime:    time.Now(),
-			Operator:      operator,
-			Reason:        reason,
-		}
-		if err := tx.WithContext(ctx).Create(history).Error; err != nil {
+	if !biz_omiai.CanMatchTransition(oldStatus, newStatus) {
+		return biz_omiai.ErrMatchInvalidTransition
+	}
+	var record biz_omiai.MatchRecord
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.WithContext(ctx).First(&record, recordID).Error; err != nil {
 			return err
 		}
-		return nil
+		return r.transitionTx(ctx, tx, &record, oldStatus, newStatus, operator, reason)
 	})
+	if err != nil {
+		return err
+	}
+	r.publishStatusChanged(ctx, &record, oldStatus, newStatus, operator, reason)
+	return nil
 }
 
 // DissolveMatch 解除匹配关系
 func (r *MatchRepo) DissolveMatch(ctx context.Context, clientID uint64, operator, reason string) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
+	var (
+		matchRecord biz_omiai.MatchRecord
+		oldStatus   int8
+	)
+	err := r.db.Transaction(func(tx *gorm.DB) error {
 		// 1. Get Client and verify status
 		var client biz_omiai.Client
 		if err := tx.First(&client, clientID).Error; err != nil {
@@ -321,45 +500,76 @@ func (r *MatchRepo) DissolveMatch(ctx context.Context, clientID uint64, operator
 		partnerID := *client.PartnerID
 
 		// 2. Find Active Match Record
-		var matchRecord biz_omiai.MatchRecord
 		if err := tx.Where("((male_client_id = ? AND female_client_id = ?) OR (male_client_id = ? AND female_client_id = ?)) AND status != ?",
 			clientID, partnerID, partnerID, clientID, biz_omiai.MatchStatusBroken).
 			First(&matchRecord).Error; err != nil {
 			return fmt.Errorf("active match record not found: %v", err)
 		}
 
-		// 3. Update Match Record Status
-		oldStatus := matchRecord.Status
-		newStatus := biz_omiai.MatchStatusBroken
-		if err := tx.Model(&matchRecord).Updates(map[string]interface{}{
-			"status": newStatus,
-		}).Error; err != nil {
+		// 3. Transition to Broken, which frees both clients
+		oldStatus = matchRecord.Status
+		return r.transitionTx(ctx, tx, &matchRecord, oldStatus, biz_omiai.MatchStatusBroken, operator, reason)
+	})
+	if err != nil {
+		return err
+	}
+	r.publishStatusChanged(ctx, &matchRecord, oldStatus, biz_omiai.MatchStatusBroken, operator, reason)
+	return nil
+}
+
+// transitionTx 比较并更新情侣状态，执行流转副作用并写入状态历史
+func (r *MatchRepo) transitionTx(ctx context.Context, tx *gorm.DB, record *biz_omiai.MatchRecord, from, to int8, operator, reason string) error {
+	if !biz_omiai.CanMatchTransition(from, to) {
+		return biz_omiai.ErrMatchInvalidTransition
+	}
+
+	res := tx.WithContext(ctx).Model(&biz_omiai.MatchRecord{}).
+		Where("id = ? AND status = ?", record.ID, from).
+		Update("status", to)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return biz_omiai.ErrMatchStatusChanged
+	}
+	record.Status = to
+
+	clientIDs := []uint64{record.MaleClientID, record.FemaleClientID}
+	switch to {
+	case biz_omiai.MatchStatusMarried:
+		if err := tx.WithContext(ctx).Model(&biz_omiai.Client{}).Where("id IN ?", clientIDs).
+			Update("status", biz_omiai.ClientStatusStopped).Error; err != nil {
 			return err
 		}
-
-		// 4. Update Clients Status
-		if err := tx.Model(&biz_omiai.Client{}).Where("id IN ?", []uint64{clientID, partnerID}).
+	case biz_omiai.MatchStatusBroken:
+		if err := tx.WithContext(ctx).Model(&biz_omiai.Client{}).Where("id IN ?", clientIDs).
 			Updates(map[string]interface{}{
 				"status":     biz_omiai.ClientStatusSingle,
 				"partner_id": nil,
 			}).Error; err != nil {
 			return err
 		}
+	}
 
-		// 5. Create History Record
-		history := &biz_omiai.MatchStatusHistory{
-			MatchRecordID: matchRecord.ID,
-			OldStatus:     oldStatus,
-			NewStatus:     int8(newStatus),
-			ChangeTime:    time.Now(),
-			Operator:      operator,
-			Reason:        reason,
-		}
-		if err := tx.Create(history).Error; err != nil {
-			return err
-		}
+	return tx.WithContext(ctx).Create(&biz_omiai.MatchStatusHistory{
+		MatchRecordID: record.ID,
+		OldStatus:     from,
+		NewStatus:     to,
+		ChangeTime:    time.Now(),
+		Operator:      operator,
+		Reason:        reason,
+	}).Error
+}
 
-		return nil
+func (r *MatchRepo) publishStatusChanged(ctx context.Context, record *biz_omiai.MatchRecord, from, to int8, operator, reason string) {
+	r.events.Publish(ctx, biz_omiai.EventMatchStatusChanged, &biz_omiai.MatchStatusChanged{
+		RecordID:       record.ID,
+		MaleClientID:   record.MaleClientID,
+		FemaleClientID: record.FemaleClientID,
+		OldStatus:      from,
+		NewStatus:      to,
+		Operator:       operator,
+		Reason:         reason,
 	})
 }
 
@@ -371,7 +581,18 @@ func (r *MatchRepo) GetStatusHistory(ctx context.Context, recordID uint64) ([]*b
 }
 
 func (r *MatchRepo) CreateFollowUp(ctx context.Context, record *biz_omiai.FollowUpRecord) error {
-	return r.db.WithContext(ctx).Create(record).Error
+	if err := r.db.WithContext(ctx).Create(record).Error; err != nil {
+		return err
+	}
+	var m biz_omiai.MatchRecord
+	if err := r.db.WithContext(ctx).Select("id", "male_client_id", "female_client_id").First(&m, record.MatchRecordID).Error; err == nil {
+		r.events.Publish(ctx, biz_omiai.EventFollowUpCreated, &biz_omiai.FollowUpCreated{
+			RecordID:      record.ID,
+			MatchRecordID: m.ID,
+			ClientIDs:     []uint64{m.MaleClientID, m.FemaleClientID},
+		})
+	}
+	return nil
 }
 
 func (r *MatchRepo) SelectFollowUps(ctx context.Context, matchRecordID uint64) ([]*biz_omiai.FollowUpRecord, error) {
@@ -396,7 +617,7 @@ func (r *MatchRepo) GetReminders(ctx context.Context) ([]*biz_omiai.MatchRecord,
 	var list []*biz_omiai.MatchRecord
 	now := time.Now()
 	err := r.db.WithContext(ctx).
-		Joins("JOIN follow_up_record ON follow_up_record.match_record_id = match_record.id").
+		Joins("JOIN follow_up_record ON follow_up_record.match_record_id = match_record.id AND follow_up_record.deleted_at IS NULL").
 		Where("match_record.status NOT IN (?)", []int{biz_omiai.MatchStatusBroken}). // Not broken
 		Where("follow_up_record.next_follow_up_at <= ?", now).
 		Group("match_record.id").
@@ -440,3 +661,52 @@ func (r *MatchRepo) Stats(ctx context.Context) (map[string]interface{}, error) {
 
 	return stats, nil
 }
+
+// publishClientStatus 通知客户状态变更
+func (r *MatchRepo) publishClientStatus(ctx context.Context, clientIDs ...uint64) {
+	r.events.Publish(ctx, biz_omiai.EventClientChanged, &biz_omiai.ClientChanged{
+		ClientIDs: clientIDs,
+		Change:    biz_omiai.ClientChangeStatus,
+	})
+}
+
+func (r *MatchRepo) onClientChanged(ctx context.Context, event *biz_omiai.Event) {
+	e, ok := event.Payload.(*biz_omiai.ClientChanged)
+	if !ok || len(e.ClientIDs) == 0 {
+		return
+	}
+	if err := r.invalidateRecommendations(ctx, e.ClientIDs, e.Change); err != nil {
+		log.WithContext(ctx).Errorf("MatchRepo: invalidate recommendations of %v err:%v", e.ClientIDs, err)
+	}
+}
+
+func (r *MatchRepo) onMatchStatusChanged(ctx context.Context, event *biz_omiai.Event) {
+	e, ok := event.Payload.(*biz_omiai.MatchStatusChanged)
+	if !ok || (e.NewStatus != biz_omiai.MatchStatusBroken && e.NewStatus != biz_omiai.MatchStatusMarried) {
+		return
+	}
+	ids := []uint64{e.MaleClientID, e.FemaleClientID}
+	if err := r.invalidateRecommendations(ctx, ids, biz_omiai.ClientChangeStatus); err != nil {
+		log.WithContext(ctx).Errorf("MatchRepo: invalidate recommendations of match %d err:%v", e.RecordID, err)
+	}
+}
+
+// invalidateRecommendations 客户变更后维护推荐：
+// 本人的推荐整体删除，下次查询重新生成；资料变更时其作为候选人的推荐标记待重算，
+// 仅状态变更时其他客户的推荐在读取时按状态剔除；删除时一并移除
+func (r *MatchRepo) invalidateRecommendations(ctx context.Context, clientIDs []uint64, change string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		own := tx.WithContext(ctx).Where("client_id IN ?", clientIDs)
+		if change == biz_omiai.ClientChangeDeleted {
+			own = own.Or("candidate_id IN ?", clientIDs)
+		}
+		if err := own.Delete(&biz_omiai.Recommendation{}).Error; err != nil {
+			return err
+		}
+		if change != biz_omiai.ClientChangeProfile {
+			return nil
+		}
+		return tx.WithContext(ctx).Model(&biz_omiai.Recommendation{}).Where("candidate_id IN ?", clientIDs).
+			Update("status", biz_omiai.RecommendationStatusStale).Error
+	})
+}
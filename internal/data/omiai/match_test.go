@@ -0,0 +1,290 @@
+package omiai
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/data"
+	"omiai-server/internal/service/event"
+	"omiai-server/internal/service/matching"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupMatchRepo(t *testing.T) (*data.DB, *MatchRepo, *[]*biz_omiai.MatchStatusChanged) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	assert.NoError(t, db.AutoMigrate(&biz_omiai.Client{}, &biz_omiai.MatchRecord{}, &biz_omiai.MatchStatusHistory{}))
+
+	bus := event.NewBus()
+	var events []*biz_omiai.MatchStatusChanged
+	bus.Subscribe(biz_omiai.EventMatchStatusChanged, func(ctx context.Context, e *biz_omiai.Event) {
+		events = append(events, e.Payload.(*biz_omiai.MatchStatusChanged))
+	})
+
+	d := &data.DB{DB: db}
+	return d, NewMatchRepo(d, nil, bus).(*MatchRepo), &events
+}
+
+func seedCouple(t *testing.T, db *data.DB, status int8) *biz_omiai.MatchRecord {
+	male := &biz_omiai.Client{Name: "male", Gender: 1, Status: biz_omiai.ClientStatusMatched}
+	female := &biz_omiai.Client{Name: "female", Gender: 2, Status: biz_omiai.ClientStatusMatched}
+	assert.NoError(t, db.Create(male).Error)
+	assert.NoError(t, db.Create(female).Error)
+	assert.NoError(t, db.Model(male).Update("partner_id", female.ID).Error)
+	assert.NoError(t, db.Model(female).Update("partner_id", male.ID).Error)
+
+	record := &biz_omiai.MatchRecord{MaleClientID: male.ID, FemaleClientID: female.ID, Status: status}
+	assert.NoError(t, db.Create(record).Error)
+	return record
+}
+
+func TestMatchRepo_UpdateStatus(t *testing.T) {
+	ctx := context.Background()
+	db, repo, events := setupMatchRepo(t)
+	record := seedCouple(t, db, biz_omiai.MatchStatusAcquaintance)
+
+	// 不允许跳级
+	err := repo.UpdateStatus(ctx, record.ID, biz_omiai.MatchStatusAcquaintance, biz_omiai.MatchStatusMarried, "op", "")
+	assert.ErrorIs(t, err, biz_omiai.ErrMatchInvalidTransition)
+
+	// 存储状态与请求的旧状态不一致时拒绝
+	err = repo.UpdateStatus(ctx, record.ID, biz_omiai.MatchStatusDating, biz_omiai.MatchStatusStable, "op", "")
+	assert.ErrorIs(t, err, biz_omiai.ErrMatchStatusChanged)
+
+	for _, to := range []int8{biz_omiai.MatchStatusDating, biz_omiai.MatchStatusStable, biz_omiai.MatchStatusEngagement, biz_omiai.MatchStatusMarried} {
+		assert.NoError(t, repo.UpdateStatus(ctx, record.ID, to-1, to, "op", ""))
+	}
+
+	var clients []*biz_omiai.Client
+	assert.NoError(t, db.Find(&clients).Error)
+	for _, c := range clients {
+		assert.Equal(t, int8(biz_omiai.ClientStatusStopped), c.Status)
+	}
+
+	var histories int64
+	db.Model(&biz_omiai.MatchStatusHistory{}).Where("match_record_id = ?", record.ID).Count(&histories)
+	assert.Equal(t, int64(4), histories)
+	assert.Len(t, *events, 4)
+	assert.Equal(t, int8(biz_omiai.MatchStatusMarried), (*events)[3].NewStatus)
+}
+
+func TestMatchRepo_UpdateStatusBroken(t *testing.T) {
+	ctx := context.Background()
+	db, repo, events := setupMatchRepo(t)
+	record := seedCouple(t, db, biz_omiai.MatchStatusStable)
+
+	assert.NoError(t, repo.UpdateStatus(ctx, record.ID, biz_omiai.MatchStatusStable, biz_omiai.MatchStatusBroken, "op", "性格不合"))
+
+	var clients []*biz_omiai.Client
+	assert.NoError(t, db.Find(&clients).Error)
+	for _, c := range clients {
+		assert.Equal(t, int8(biz_omiai.ClientStatusSingle), c.Status)
+		assert.Nil(t, c.PartnerID)
+	}
+	assert.Len(t, *events, 1)
+
+	// 已分手的记录不能再流转
+	err := repo.UpdateStatus(ctx, record.ID, biz_omiai.MatchStatusBroken, biz_omiai.MatchStatusBroken, "op", "")
+	assert.ErrorIs(t, err, biz_omiai.ErrMatchInvalidTransition)
+}
+
+func TestMatchRepo_Recommendations(t *testing.T) {
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	assert.NoError(t, db.AutoMigrate(&biz_omiai.Client{}, &biz_omiai.PersonalityProfile{}, &biz_omiai.ClientEmbedding{}, &biz_omiai.ClientTag{}, &biz_omiai.Tag{}, &biz_omiai.TagGroup{}, &biz_omiai.PairHistory{}, &biz_omiai.Recommendation{}))
+
+	bus := event.NewBus()
+	repo := NewMatchRepo(&data.DB{DB: db}, matching.NewScorer(nil, nil), bus)
+
+	a := &biz_omiai.Client{Name: "a", Gender: 1, Age: 30, Status: biz_omiai.ClientStatusSingle}
+	b := &biz_omiai.Client{Name: "b", Gender: 2, Age: 28, Education: 3, WorkCityCode: "110100", Status: biz_omiai.ClientStatusSingle}
+	c := &biz_omiai.Client{Name: "c", Gender: 2, Age: 29, Education: 4, Status: biz_omiai.ClientStatusSingle}
+	for _, client := range []*biz_omiai.Client{a, b, c} {
+		assert.NoError(t, db.Create(client).Error)
+	}
+	countOf := func(where string, args ...interface{}) int64 {
+		var n int64
+		assert.NoError(t, db.Model(&biz_omiai.Recommendation{}).Where(where, args...).Count(&n).Error)
+		return n
+	}
+
+	// 首次查询实时生成，分页返回总数
+	list, total, err := repo.GetCandidates(ctx, &biz_omiai.CandidateQuery{ClientID: a.ID, Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Len(t, list, 1)
+	assert.Equal(t, int64(2), countOf("client_id = ?", a.ID))
+
+	// 筛选与排序
+	list, total, err = repo.GetCandidates(ctx, &biz_omiai.CandidateQuery{ClientID: a.ID, CityCode: "110100"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, b.ID, list[0].CandidateID)
+	list, _, err = repo.GetCandidates(ctx, &biz_omiai.CandidateQuery{ClientID: a.ID, Sort: biz_omiai.CandidateSortEducation})
+	assert.NoError(t, err)
+	assert.Equal(t, c.ID, list[0].CandidateID)
+	list, _, err = repo.GetCandidates(ctx, &biz_omiai.CandidateQuery{ClientID: a.ID, Sort: biz_omiai.CandidateSortAge, Asc: true})
+	assert.NoError(t, err)
+	assert.Equal(t, b.ID, list[0].CandidateID)
+
+	// 反查：b 出现在 a 的推荐中
+	recs, total, err := repo.RecommendedTo(ctx, b.ID, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, a.ID, recs[0].ClientID)
+
+	// 候选人已匹配：读取时剔除，推荐本身保留
+	assert.NoError(t, db.Model(c).UpdateColumn("status", biz_omiai.ClientStatusMatched).Error)
+	list, total, err = repo.GetCandidates(ctx, &biz_omiai.CandidateQuery{ClientID: a.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, b.ID, list[0].CandidateID)
+	assert.Equal(t, int64(1), countOf("client_id = ? AND candidate_id = ?", a.ID, c.ID))
+
+	// 状态变更只删除本人的推荐
+	bus.Publish(ctx, biz_omiai.EventClientChanged, &biz_omiai.ClientChanged{ClientIDs: []uint64{c.ID}, Change: biz_omiai.ClientChangeStatus})
+	assert.Equal(t, int64(2), countOf("client_id = ?", a.ID))
+
+	// 资料变更：作为候选人的推荐标记待重算，查询时重新评分，不再满足条件则移除
+	assert.NoError(t, db.Model(b).UpdateColumn("gender", 1).Error)
+	bus.Publish(ctx, biz_omiai.EventClientChanged, &biz_omiai.ClientChanged{ClientIDs: []uint64{b.ID}, Change: biz_omiai.ClientChangeProfile})
+	assert.Equal(t, int64(1), countOf("client_id = ? AND status = ?", a.ID, biz_omiai.RecommendationStatusStale))
+	list, total, err = repo.GetCandidates(ctx, &biz_omiai.CandidateQuery{ClientID: a.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+	assert.Empty(t, list)
+	assert.Equal(t, int64(0), countOf("candidate_id = ?", b.ID))
+
+	// 删除客户一并移除双向推荐
+	bus.Publish(ctx, biz_omiai.EventClientChanged, &biz_omiai.ClientChanged{ClientIDs: []uint64{c.ID}, Change: biz_omiai.ClientChangeDeleted})
+	assert.Equal(t, int64(0), countOf("candidate_id = ?", c.ID))
+}
+
+func TestMatchRepo_Explain(t *testing.T) {
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	assert.NoError(t, db.AutoMigrate(&biz_omiai.Client{}, &biz_omiai.PersonalityProfile{}, &biz_omiai.ClientEmbedding{}, &biz_omiai.ClientTag{}, &biz_omiai.Tag{}, &biz_omiai.TagGroup{}, &biz_omiai.PairHistory{}, &biz_omiai.Recommendation{}))
+	repo := NewMatchRepo(&data.DB{DB: db}, matching.NewScorer(nil, nil), event.NewBus())
+
+	a := &biz_omiai.Client{Name: "a", Gender: 1, Age: 30, Education: 3, Status: biz_omiai.ClientStatusSingle,
+		PartnerRequirements: `{"min_age":25,"max_age":28}`}
+	b := &biz_omiai.Client{Name: "b", Gender: 2, Age: 29, Education: 3, Status: biz_omiai.ClientStatusSingle}
+	for _, client := range []*biz_omiai.Client{a, b} {
+		client.SyncRequirementColumns()
+		assert.NoError(t, db.Create(client).Error)
+	}
+
+	// 年龄在宽松容差内：进入推荐，仅扣分
+	e, err := repo.Explain(ctx, a.ID, b.ID, nil)
+	assert.NoError(t, err)
+	assert.True(t, e.Eligible)
+	assert.Empty(t, e.HardFilters)
+	assert.Equal(t, []string{"男方要求：年龄不符(29岁)"}, e.Violations)
+	var sum float64
+	for _, d := range e.Dimensions {
+		sum += d.Contribution
+	}
+	assert.InDelta(t, float64(e.Score), sum, 0.5)
+	assert.Contains(t, e.Summary, "择偶要求不满足：男方要求：年龄不符(29岁)")
+
+	// 列表按需附带解释，与单独查询一致
+	list, _, err := repo.GetCandidates(ctx, &biz_omiai.CandidateQuery{ClientID: a.ID})
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.Nil(t, list[0].Explanation)
+	list, _, err = repo.GetCandidates(ctx, &biz_omiai.CandidateQuery{ClientID: a.ID, Explain: true})
+	assert.NoError(t, err)
+	assert.Equal(t, e.Summary, list[0].Explanation.Summary)
+	assert.Equal(t, e.Violations, list[0].Explanation.Violations)
+
+	// 实验分组评分器：列表、解释与对比得分一致
+	variant := matching.NewWeightedScorer("variant", map[string]float64{matching.DimensionEducation: 1})
+	list, _, err = repo.GetCandidates(ctx, &biz_omiai.CandidateQuery{ClientID: a.ID, Scorer: variant})
+	assert.NoError(t, err)
+	ve, err := repo.Explain(ctx, a.ID, b.ID, variant)
+	assert.NoError(t, err)
+	assert.Equal(t, "variant", ve.Algorithm)
+	assert.Equal(t, list[0].MatchScore, ve.Score)
+	assert.NotEqual(t, e.Score, ve.Score)
+	cmp, err := repo.Compare(ctx, a.ID, b.ID, variant)
+	assert.NoError(t, err)
+	assert.Equal(t, ve.Score, cmp.MatchScore)
+
+	// 负反馈与冷静期：说明未进入推荐的原因
+	until := time.Now().AddDate(0, 1, 0)
+	assert.NoError(t, db.Model(b).UpdateColumn("cooldown_until", until).Error)
+	assert.NoError(t, db.Create(&biz_omiai.PairHistory{ClientID: b.ID, CandidateID: a.ID, Kind: biz_omiai.PairKindDeclined,
+		ReasonCode: biz_omiai.PairReasonAge}).Error)
+	e, err = repo.Explain(ctx, a.ID, b.ID, nil)
+	assert.NoError(t, err)
+	assert.False(t, e.Eligible)
+	codes := make([]string, 0, len(e.HardFilters))
+	for _, f := range e.HardFilters {
+		codes = append(codes, f.Code)
+	}
+	assert.Equal(t, []string{biz_omiai.HardFilterCooldown, biz_omiai.HardFilterPairHistory}, codes)
+	assert.Equal(t, "对方已标记拒绝：年龄不合适", e.HardFilters[1].Message)
+	assert.Contains(t, e.Summary, "不在推荐范围内")
+
+	_, err = repo.Explain(ctx, a.ID, 999, nil)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestMatchRepo_RecommendationsByAlgorithm(t *testing.T) {
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	assert.NoError(t, db.AutoMigrate(&biz_omiai.Client{}, &biz_omiai.PersonalityProfile{}, &biz_omiai.ClientEmbedding{}, &biz_omiai.ClientTag{}, &biz_omiai.Tag{}, &biz_omiai.TagGroup{}, &biz_omiai.PairHistory{}, &biz_omiai.Recommendation{}))
+	def := matching.NewScorer(nil, nil)
+	repo := NewMatchRepo(&data.DB{DB: db}, def, event.NewBus())
+
+	a := &biz_omiai.Client{Name: "a", Gender: 1, Age: 30, Education: 3, Status: biz_omiai.ClientStatusSingle}
+	b := &biz_omiai.Client{Name: "b", Gender: 2, Age: 28, Education: 5, Status: biz_omiai.ClientStatusSingle}
+	for _, client := range []*biz_omiai.Client{a, b} {
+		assert.NoError(t, db.Create(client).Error)
+	}
+	countOf := func(algorithm string) int64 {
+		var n int64
+		db.Model(&biz_omiai.Recommendation{}).Where("client_id = ? AND algorithm = ?", a.ID, algorithm).Count(&n)
+		return n
+	}
+
+	// 默认算法与实验分组的推荐并存，刷新默认算法不覆盖分组推荐
+	variant := matching.NewWeightedScorer("exp/education", map[string]float64{matching.DimensionEducation: 1})
+	list, _, err := repo.GetCandidates(ctx, &biz_omiai.CandidateQuery{ClientID: a.ID, Scorer: variant})
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	_, err = repo.RefreshRecommendations(ctx, a, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), countOf(def.Name()))
+	assert.Equal(t, int64(1), countOf("exp/education"))
+	again, _, err := repo.GetCandidates(ctx, &biz_omiai.CandidateQuery{ClientID: a.ID, Scorer: variant})
+	assert.NoError(t, err)
+	assert.Equal(t, list[0].MatchScore, again[0].MatchScore)
+
+	recommended, total, err := repo.RecommendedTo(ctx, b.ID, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Len(t, recommended, 1)
+
+	// 实验下线后清理遗留推荐
+	n, err := repo.PruneRecommendations(ctx, []string{def.Name()})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	assert.Equal(t, int64(0), countOf("exp/education"))
+}
@@ -0,0 +1,226 @@
+package omiai
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"omiai-server/internal/biz"
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/data"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var _ biz_omiai.MeetingInterface = (*MeetingRepo)(nil)
+
+type MeetingRepo struct {
+	db *data.DB
+}
+
+func NewMeetingRepo(db *data.DB) biz_omiai.MeetingInterface {
+	return &MeetingRepo{db: db}
+}
+
+func (r *MeetingRepo) CreateVenue(ctx context.Context, v *biz_omiai.Venue) error {
+	return r.db.WithContext(ctx).Create(v).Error
+}
+
+func (r *MeetingRepo) UpdateVenue(ctx context.Context, v *biz_omiai.Venue) error {
+	return r.db.WithContext(ctx).Model(&biz_omiai.Venue{ID: v.ID}).Select(
+		"name", "address", "province_code", "city_code", "district_code",
+		"capacity", "open_time", "close_time", "is_enabled", "remark",
+	).Updates(v).Error
+}
+
+func (r *MeetingRepo) GetVenue(ctx context.Context, id uint64) (*biz_omiai.Venue, error) {
+	var v biz_omiai.Venue
+	if err := r.db.WithContext(ctx).First(&v, id).Error; err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func (r *MeetingRepo) SelectVenues(ctx context.Context, clause *biz.WhereClause, offset, limit int) ([]*biz_omiai.Venue, int64, error) {
+	var (
+		list  []*biz_omiai.Venue
+		total int64
+	)
+	db := r.db.WithContext(ctx).Model(&biz_omiai.Venue{}).Where(clause.Where, clause.Args...)
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("MeetingRepo:SelectVenues count where:%v err:%w", clause, err)
+	}
+	orderBy := clause.OrderBy
+	if orderBy == "" {
+		orderBy = "id desc"
+	}
+	if err := db.Order(orderBy).Offset(offset).Limit(limit).Find(&list).Error; err != nil {
+		return nil, 0, fmt.Errorf("MeetingRepo:SelectVenues where:%v err:%w", clause, err)
+	}
+	return list, total, nil
+}
+
+func (r *MeetingRepo) Availability(ctx context.Context, clientIDs []uint64) (map[uint64][]*biz_omiai.ClientAvailability, error) {
+	return availability(ctx, r.db.DB, clientIDs)
+}
+
+func availability(ctx context.Context, db *gorm.DB, clientIDs []uint64) (map[uint64][]*biz_omiai.ClientAvailability, error) {
+	out := make(map[uint64][]*biz_omiai.ClientAvailability, len(clientIDs))
+	if len(clientIDs) == 0 {
+		return out, nil
+	}
+	var list []*biz_omiai.ClientAvailability
+	if err := db.WithContext(ctx).Where("client_id IN ?", clientIDs).Order("weekday, start_time").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	for _, a := range list {
+		out[a.ClientID] = append(out[a.ClientID], a)
+	}
+	return out, nil
+}
+
+func (r *MeetingRepo) SetAvailability(ctx context.Context, clientID uint64, list []*biz_omiai.ClientAvailability) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.WithContext(ctx).Where("client_id = ?", clientID).Delete(&biz_omiai.ClientAvailability{}).Error; err != nil {
+			return err
+		}
+		if len(list) == 0 {
+			return nil
+		}
+		for _, a := range list {
+			a.ID = 0
+			a.ClientID = clientID
+		}
+		return tx.WithContext(ctx).Create(&list).Error
+	})
+}
+
+func (r *MeetingRepo) Create(ctx context.Context, m *biz_omiai.Meeting, check biz_omiai.MeetingCheck) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := r.check(ctx, tx, m, check); err != nil {
+			return err
+		}
+		return tx.WithContext(ctx).Create(m).Error
+	})
+}
+
+// check 按客户 ID、场地的顺序加锁，再读取重叠的已预约见面与可约时间交给 check 判断
+func (r *MeetingRepo) check(ctx context.Context, tx *gorm.DB, m *biz_omiai.Meeting, check biz_omiai.MeetingCheck) error {
+	clientIDs := []uint64{m.ClientAID, m.ClientBID}
+	var locked []uint64
+	if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Model(&biz_omiai.Client{}).
+		Where("id IN ?", clientIDs).Order("id").Pluck("id", &locked).Error; err != nil {
+		return err
+	}
+	var venueID uint64
+	if m.VenueID != nil {
+		venueID = *m.VenueID
+		var venue biz_omiai.Venue
+		if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&venue, venueID).Error; err != nil {
+			return err
+		}
+	}
+	booked, err := overlapping(ctx, tx, m.StartAt, m.EndAt, venueID, clientIDs, m.MatchmakerID, m.ID)
+	if err != nil {
+		return err
+	}
+	avail, err := availability(ctx, tx, clientIDs)
+	if err != nil {
+		return err
+	}
+	return check(booked, avail)
+}
+
+func (r *MeetingRepo) Get(ctx context.Context, id uint64) (*biz_omiai.Meeting, error) {
+	var m biz_omiai.Meeting
+	if err := r.db.WithContext(ctx).Preload("ClientA").Preload("ClientB").Preload("Venue").First(&m, id).Error; err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *MeetingRepo) Select(ctx context.Context, clause *biz.WhereClause, offset, limit int) ([]*biz_omiai.Meeting, int64, error) {
+	var (
+		list  []*biz_omiai.Meeting
+		total int64
+	)
+	db := r.db.WithContext(ctx).Model(&biz_omiai.Meeting{}).Where(clause.Where, clause.Args...)
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("MeetingRepo:Select count where:%v err:%w", clause, err)
+	}
+	orderBy := clause.OrderBy
+	if orderBy == "" {
+		orderBy = "start_at desc"
+	}
+	if err := db.Preload("ClientA").Preload("ClientB").Preload("Venue").Order(orderBy).Offset(offset).Limit(limit).Find(&list).Error; err != nil {
+		return nil, 0, fmt.Errorf("MeetingRepo:Select where:%v err:%w", clause, err)
+	}
+	return list, total, nil
+}
+
+func (r *MeetingRepo) Reschedule(ctx context.Context, m *biz_omiai.Meeting, check biz_omiai.MeetingCheck) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := r.check(ctx, tx, m, check); err != nil {
+			return err
+		}
+		res := tx.WithContext(ctx).Model(&biz_omiai.Meeting{}).
+			Where("id = ? AND status = ?", m.ID, biz_omiai.MeetingStatusBooked).
+			Updates(map[string]interface{}{
+				"venue_id": m.VenueID,
+				"place":    m.Place,
+				"start_at": m.StartAt,
+				"end_at":   m.EndAt,
+				"remark":   m.Remark,
+				"operator": m.Operator,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return biz_omiai.ErrMeetingStatus
+		}
+		return nil
+	})
+}
+
+func (r *MeetingRepo) Finish(ctx context.Context, id uint64, status int8, feedback, operator string) error {
+	res := r.db.WithContext(ctx).Model(&biz_omiai.Meeting{}).
+		Where("id = ? AND status = ?", id, biz_omiai.MeetingStatusBooked).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"feedback":    feedback,
+			"operator":    operator,
+			"finished_at": time.Now(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return biz_omiai.ErrMeetingStatus
+	}
+	return nil
+}
+
+func (r *MeetingRepo) Overlapping(ctx context.Context, start, end time.Time, venueID uint64, clientIDs []uint64, matchmakerID, excludeID uint64) ([]*biz_omiai.Meeting, error) {
+	return overlapping(ctx, r.db.DB, start, end, venueID, clientIDs, matchmakerID, excludeID)
+}
+
+func overlapping(ctx context.Context, tx *gorm.DB, start, end time.Time, venueID uint64, clientIDs []uint64, matchmakerID, excludeID uint64) ([]*biz_omiai.Meeting, error) {
+	var list []*biz_omiai.Meeting
+	db := tx.WithContext(ctx).Where("status = ? AND start_at < ? AND end_at > ? AND id <> ?",
+		biz_omiai.MeetingStatusBooked, end, start, excludeID)
+
+	scope := tx.WithContext(ctx).Where("1=0")
+	if venueID > 0 {
+		scope = scope.Or("venue_id = ?", venueID)
+	}
+	if len(clientIDs) > 0 {
+		scope = scope.Or("client_a_id IN ? OR client_b_id IN ?", clientIDs, clientIDs)
+	}
+	if matchmakerID > 0 {
+		scope = scope.Or("matchmaker_id = ?", matchmakerID)
+	}
+	err := db.Where(scope).Order("start_at").Find(&list).Error
+	return list, err
+}
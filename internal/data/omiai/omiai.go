@@ -10,5 +10,18 @@ var ProviderOmiai = wire.NewSet(
 	NewReminderRepo,
 	NewChinaRegionRepo,
 	NewTemplateRepo,
-	NewAIMatchRepo,
+	NewDailyRecommendationRepo,
+	NewQuestionnaireRepo,
+	NewIntroductionRepo,
+	NewPairHistoryRepo,
+	NewProposalRepo,
+	NewExperimentRepo,
+	NewEmbeddingRepo,
+	NewPartyRepo,
+	NewMeetingRepo,
+	NewDuplicateRepo,
+	NewTagRepo,
+	NewSearchRepo,
+	NewSegmentRepo,
+	NewQualityRepo,
 )
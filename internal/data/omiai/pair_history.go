@@ -0,0 +1,62 @@
+package omiai
+
+import (
+	"context"
+	"time"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/data"
+
+	"gorm.io/gorm"
+)
+
+var _ biz_omiai.PairHistoryInterface = (*PairHistoryRepo)(nil)
+
+type PairHistoryRepo struct {
+	db *data.DB
+}
+
+func NewPairHistoryRepo(db *data.DB) biz_omiai.PairHistoryInterface {
+	return &PairHistoryRepo{db: db}
+}
+
+func (r *PairHistoryRepo) Create(ctx context.Context, h *biz_omiai.PairHistory) error {
+	return r.db.WithContext(ctx).Create(h).Error
+}
+
+func (r *PairHistoryRepo) Get(ctx context.Context, id uint64) (*biz_omiai.PairHistory, error) {
+	var h biz_omiai.PairHistory
+	if err := r.db.WithContext(ctx).First(&h, id).Error; err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+func (r *PairHistoryRepo) List(ctx context.Context, clientID uint64) ([]*biz_omiai.PairHistory, error) {
+	var list []*biz_omiai.PairHistory
+	err := r.db.WithContext(ctx).Preload("Candidate").
+		Where("client_id = ? OR candidate_id = ?", clientID, clientID).
+		Order("id desc").Find(&list).Error
+	return list, err
+}
+
+func (r *PairHistoryRepo) Delete(ctx context.Context, id uint64) error {
+	return r.db.WithContext(ctx).Delete(&biz_omiai.PairHistory{}, id).Error
+}
+
+func (r *PairHistoryRepo) ListActive(ctx context.Context, at time.Time) ([]*biz_omiai.PairHistory, error) {
+	var list []*biz_omiai.PairHistory
+	err := r.db.WithContext(ctx).Where("expired_at IS NULL OR expired_at > ?", at).Find(&list).Error
+	return list, err
+}
+
+func (r *PairHistoryRepo) RecordBreakup(ctx context.Context, h *biz_omiai.PairHistory, cooldownUntil time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.WithContext(ctx).Create(h).Error; err != nil {
+			return err
+		}
+		return tx.WithContext(ctx).Model(&biz_omiai.Client{}).
+			Where("id IN ?", []uint64{h.ClientID, h.CandidateID}).
+			Update("cooldown_until", cooldownUntil).Error
+	})
+}
@@ -0,0 +1,304 @@
+package omiai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"omiai-server/internal/biz"
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/data"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var _ biz_omiai.PartyInterface = (*PartyRepo)(nil)
+
+type PartyRepo struct {
+	db *data.DB
+}
+
+func NewPartyRepo(db *data.DB) biz_omiai.PartyInterface {
+	return &PartyRepo{db: db}
+}
+
+func (r *PartyRepo) Create(ctx context.Context, p *biz_omiai.Party) error {
+	return r.db.WithContext(ctx).Create(p).Error
+}
+
+func (r *PartyRepo) Update(ctx context.Context, p *biz_omiai.Party) error {
+	return r.db.WithContext(ctx).Model(&biz_omiai.Party{ID: p.ID}).Select(
+		"title", "venue", "start_at", "end_at", "capacity", "male_quota", "female_quota",
+		"rounds", "round_minutes", "pick_limit", "mutual_action", "remark",
+	).Updates(p).Error
+}
+
+func (r *PartyRepo) Get(ctx context.Context, id uint64) (*biz_omiai.Party, error) {
+	var p biz_omiai.Party
+	if err := r.db.WithContext(ctx).First(&p, id).Error; err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *PartyRepo) Select(ctx context.Context, clause *biz.WhereClause, offset, limit int) ([]*biz_omiai.Party, int64, error) {
+	var (
+		list  []*biz_omiai.Party
+		total int64
+	)
+	db := r.db.WithContext(ctx).Model(&biz_omiai.Party{}).Where(clause.Where, clause.Args...)
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("PartyRepo:Select count where:%v err:%w", clause, err)
+	}
+	orderBy := clause.OrderBy
+	if orderBy == "" {
+		orderBy = "start_at desc"
+	}
+	if err := db.Order(orderBy).Offset(offset).Limit(limit).Find(&list).Error; err != nil {
+		return nil, 0, fmt.Errorf("PartyRepo:Select where:%v err:%w", clause, err)
+	}
+	return list, total, nil
+}
+
+func (r *PartyRepo) Transition(ctx context.Context, id uint64, from, to int8) error {
+	res := r.db.WithContext(ctx).Model(&biz_omiai.Party{}).Where("id = ? AND status = ?", id, from).Update("status", to)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return biz_omiai.ErrPartyStatusChanged
+	}
+	return nil
+}
+
+func (r *PartyRepo) Finish(ctx context.Context, id uint64, histories []*biz_omiai.PairHistory, batch *biz_omiai.ProposalBatch, proposals []*biz_omiai.Proposal) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.WithContext(ctx).Model(&biz_omiai.Party{}).Where("id = ? AND status = ?", id, biz_omiai.PartyStatusPicking).
+			Update("status", biz_omiai.PartyStatusFinished)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return biz_omiai.ErrPartyStatusChanged
+		}
+		if len(histories) > 0 {
+			if err := tx.WithContext(ctx).CreateInBatches(histories, 100).Error; err != nil {
+				return err
+			}
+		}
+		if batch == nil {
+			return nil
+		}
+		return createProposalBatchTx(ctx, tx, batch, proposals)
+	})
+}
+
+func (r *PartyRepo) Attendees(ctx context.Context, partyID uint64, statuses ...int8) ([]*biz_omiai.PartyAttendee, error) {
+	var list []*biz_omiai.PartyAttendee
+	db := r.db.WithContext(ctx).Preload("Client").Where("party_id = ?", partyID)
+	if len(statuses) > 0 {
+		db = db.Where("status IN ?", statuses)
+	}
+	err := db.Order("id").Find(&list).Error
+	return list, err
+}
+
+func (r *PartyRepo) GetAttendee(ctx context.Context, partyID, clientID uint64) (*biz_omiai.PartyAttendee, error) {
+	var a biz_omiai.PartyAttendee
+	if err := r.db.WithContext(ctx).Where("party_id = ? AND client_id = ?", partyID, clientID).First(&a).Error; err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *PartyRepo) SignUp(ctx context.Context, party *biz_omiai.Party, a *biz_omiai.PartyAttendee) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// 锁定相亲会，串行化同一场的报名与取消，避免超出名额
+		if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&biz_omiai.Party{}, party.ID).Error; err != nil {
+			return err
+		}
+		var old biz_omiai.PartyAttendee
+		err := tx.WithContext(ctx).Where("party_id = ? AND client_id = ?", party.ID, a.ClientID).First(&old).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err == nil && old.Status != biz_omiai.AttendeeStatusCancelled {
+			return biz_omiai.ErrPartySignedUp
+		}
+
+		full, err := r.full(ctx, tx, party, a.Gender)
+		if err != nil {
+			return err
+		}
+		a.PartyID = party.ID
+		a.Status = biz_omiai.AttendeeStatusSignedUp
+		if full {
+			a.Status = biz_omiai.AttendeeStatusWaitlist
+		}
+		if old.ID == 0 {
+			return tx.WithContext(ctx).Create(a).Error
+		}
+		// 重新报名排在候补队尾
+		a.ID = old.ID
+		a.CreatedAt = time.Now()
+		return tx.WithContext(ctx).Model(&old).Updates(map[string]interface{}{
+			"gender":     a.Gender,
+			"status":     a.Status,
+			"operator":   a.Operator,
+			"created_at": a.CreatedAt,
+		}).Error
+	})
+}
+
+func (r *PartyRepo) CancelAttendee(ctx context.Context, party *biz_omiai.Party, clientID uint64, operator string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&biz_omiai.Party{}, party.ID).Error; err != nil {
+			return err
+		}
+		var a biz_omiai.PartyAttendee
+		err := tx.WithContext(ctx).Where("party_id = ? AND client_id = ? AND status <> ?", party.ID, clientID, biz_omiai.AttendeeStatusCancelled).
+			First(&a).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return biz_omiai.ErrPartyNotSignedUp
+		}
+		if err != nil {
+			return err
+		}
+		if err := tx.WithContext(ctx).Model(&a).Updates(map[string]interface{}{
+			"status":   biz_omiai.AttendeeStatusCancelled,
+			"operator": operator,
+		}).Error; err != nil {
+			return err
+		}
+		if a.Status == biz_omiai.AttendeeStatusWaitlist {
+			return nil
+		}
+
+		// 释放的名额由同性别候补按报名顺序递补
+		var next biz_omiai.PartyAttendee
+		err = tx.WithContext(ctx).Where("party_id = ? AND gender = ? AND status = ?", party.ID, a.Gender, biz_omiai.AttendeeStatusWaitlist).
+			Order("created_at, id").First(&next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		full, err := r.full(ctx, tx, party, a.Gender)
+		if err != nil || full {
+			return err
+		}
+		return tx.WithContext(ctx).Model(&next).Update("status", biz_omiai.AttendeeStatusSignedUp).Error
+	})
+}
+
+// full 总名额或该性别名额是否已满，已报名与已签到均占用名额
+func (r *PartyRepo) full(ctx context.Context, tx *gorm.DB, party *biz_omiai.Party, gender int8) (bool, error) {
+	occupied := []int8{biz_omiai.AttendeeStatusSignedUp, biz_omiai.AttendeeStatusCheckedIn}
+	if party.Capacity > 0 {
+		var total int64
+		if err := tx.WithContext(ctx).Model(&biz_omiai.PartyAttendee{}).
+			Where("party_id = ? AND status IN ?", party.ID, occupied).Count(&total).Error; err != nil {
+			return false, err
+		}
+		if int(total) >= party.Capacity {
+			return true, nil
+		}
+	}
+	if quota := party.Quota(gender); quota > 0 {
+		var total int64
+		if err := tx.WithContext(ctx).Model(&biz_omiai.PartyAttendee{}).
+			Where("party_id = ? AND gender = ? AND status IN ?", party.ID, gender, occupied).Count(&total).Error; err != nil {
+			return false, err
+		}
+		if int(total) >= quota {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *PartyRepo) CheckIn(ctx context.Context, partyID, clientID uint64, operator string) (*biz_omiai.PartyAttendee, error) {
+	var a biz_omiai.PartyAttendee
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&biz_omiai.Party{}, partyID).Error; err != nil {
+			return err
+		}
+		err := tx.WithContext(ctx).Where("party_id = ? AND client_id = ? AND status IN ?", partyID, clientID,
+			[]int8{biz_omiai.AttendeeStatusSignedUp, biz_omiai.AttendeeStatusWaitlist, biz_omiai.AttendeeStatusCheckedIn}).First(&a).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return biz_omiai.ErrPartyNotSignedUp
+		}
+		if err != nil {
+			return err
+		}
+		if a.Status == biz_omiai.AttendeeStatusCheckedIn {
+			return nil
+		}
+
+		var number int
+		if err := tx.WithContext(ctx).Model(&biz_omiai.PartyAttendee{}).Where("party_id = ?", partyID).
+			Select("COALESCE(MAX(number), 0)").Scan(&number).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		a.Status = biz_omiai.AttendeeStatusCheckedIn
+		a.Number = number + 1
+		a.CheckedInAt = &now
+		return tx.WithContext(ctx).Model(&a).Updates(map[string]interface{}{
+			"status":        a.Status,
+			"number":        a.Number,
+			"checked_in_at": a.CheckedInAt,
+			"operator":      operator,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *PartyRepo) Seats(ctx context.Context, partyID uint64) ([]*biz_omiai.PartySeat, error) {
+	var list []*biz_omiai.PartySeat
+	err := r.db.WithContext(ctx).Where("party_id = ?", partyID).Order("round, table_no").Find(&list).Error
+	return list, err
+}
+
+func (r *PartyRepo) ReplaceSeats(ctx context.Context, partyID uint64, seats []*biz_omiai.PartySeat) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.WithContext(ctx).Where("party_id = ?", partyID).Delete(&biz_omiai.PartySeat{}).Error; err != nil {
+			return err
+		}
+		if len(seats) == 0 {
+			return nil
+		}
+		for _, s := range seats {
+			s.PartyID = partyID
+		}
+		return tx.WithContext(ctx).CreateInBatches(seats, 200).Error
+	})
+}
+
+func (r *PartyRepo) Picks(ctx context.Context, partyID uint64) ([]*biz_omiai.PartyPick, error) {
+	var list []*biz_omiai.PartyPick
+	err := r.db.WithContext(ctx).Where("party_id = ?", partyID).Order("client_id, id").Find(&list).Error
+	return list, err
+}
+
+func (r *PartyRepo) ReplacePicks(ctx context.Context, partyID, clientID uint64, pickedIDs []uint64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.WithContext(ctx).Where("party_id = ? AND client_id = ?", partyID, clientID).Delete(&biz_omiai.PartyPick{}).Error; err != nil {
+			return err
+		}
+		if len(pickedIDs) == 0 {
+			return nil
+		}
+		list := make([]*biz_omiai.PartyPick, 0, len(pickedIDs))
+		for _, id := range pickedIDs {
+			list = append(list, &biz_omiai.PartyPick{PartyID: partyID, ClientID: clientID, PickedID: id})
+		}
+		return tx.WithContext(ctx).Create(&list).Error
+	})
+}
@@ -0,0 +1,144 @@
+package omiai
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"omiai-server/internal/biz"
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/data"
+
+	"gorm.io/gorm"
+)
+
+var _ biz_omiai.ProposalInterface = (*ProposalRepo)(nil)
+
+type ProposalRepo struct {
+	db *data.DB
+}
+
+func NewProposalRepo(db *data.DB) biz_omiai.ProposalInterface {
+	return &ProposalRepo{db: db}
+}
+
+func (r *ProposalRepo) CreateBatch(ctx context.Context, batch *biz_omiai.ProposalBatch, proposals []*biz_omiai.Proposal) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return createProposalBatchTx(ctx, tx, batch, proposals)
+	})
+}
+
+func createProposalBatchTx(ctx context.Context, tx *gorm.DB, batch *biz_omiai.ProposalBatch, proposals []*biz_omiai.Proposal) error {
+	if err := tx.WithContext(ctx).Create(batch).Error; err != nil {
+		return err
+	}
+	if len(proposals) == 0 {
+		return nil
+	}
+	for _, p := range proposals {
+		p.BatchID = batch.ID
+	}
+	return tx.WithContext(ctx).CreateInBatches(proposals, 100).Error
+}
+
+func (r *ProposalRepo) GetBatch(ctx context.Context, id uint64) (*biz_omiai.ProposalBatch, error) {
+	var batch biz_omiai.ProposalBatch
+	if err := r.db.WithContext(ctx).First(&batch, id).Error; err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+func (r *ProposalRepo) ListBatches(ctx context.Context, offset, limit int) ([]*biz_omiai.ProposalBatch, int64, error) {
+	var (
+		list  []*biz_omiai.ProposalBatch
+		total int64
+	)
+	db := r.db.WithContext(ctx).Model(&biz_omiai.ProposalBatch{})
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("ProposalRepo:ListBatches count err:%w", err)
+	}
+	if err := db.Order("id desc").Offset(offset).Limit(limit).Find(&list).Error; err != nil {
+		return nil, 0, fmt.Errorf("ProposalRepo:ListBatches err:%w", err)
+	}
+	return list, total, nil
+}
+
+func (r *ProposalRepo) Get(ctx context.Context, id uint64) (*biz_omiai.Proposal, error) {
+	var p biz_omiai.Proposal
+	if err := r.db.WithContext(ctx).Preload("MaleClient").Preload("FemaleClient").First(&p, id).Error; err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *ProposalRepo) Select(ctx context.Context, clause *biz.WhereClause, offset, limit int) ([]*biz_omiai.Proposal, int64, error) {
+	var (
+		list  []*biz_omiai.Proposal
+		total int64
+	)
+	db := r.db.WithContext(ctx).Model(&biz_omiai.Proposal{}).Where(clause.Where, clause.Args...)
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("ProposalRepo:Select count where:%v err:%w", clause, err)
+	}
+	orderBy := clause.OrderBy
+	if orderBy == "" {
+		orderBy = "score desc, id"
+	}
+	if err := db.Preload("MaleClient").Preload("FemaleClient").Order(orderBy).Offset(offset).Limit(limit).Find(&list).Error; err != nil {
+		return nil, 0, fmt.Errorf("ProposalRepo:Select where:%v err:%w", clause, err)
+	}
+	return list, total, nil
+}
+
+func (r *ProposalRepo) Review(ctx context.Context, id uint64, review *biz_omiai.ProposalReview) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var p biz_omiai.Proposal
+		if err := tx.WithContext(ctx).First(&p, id).Error; err != nil {
+			return err
+		}
+		res := tx.WithContext(ctx).Model(&biz_omiai.Proposal{}).
+			Where("id = ? AND status = ?", id, biz_omiai.ProposalStatusPending).
+			Updates(map[string]interface{}{
+				"status":          review.Status,
+				"introduction_id": review.IntroductionID,
+				"reviewed_by":     review.Operator,
+				"review_remark":   review.Remark,
+				"reviewed_at":     time.Now(),
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return biz_omiai.ErrProposalReviewed
+		}
+
+		var pending int64
+		if err := tx.WithContext(ctx).Model(&biz_omiai.Proposal{}).
+			Where("batch_id = ? AND status = ?", p.BatchID, biz_omiai.ProposalStatusPending).Count(&pending).Error; err != nil {
+			return err
+		}
+		if pending > 0 {
+			return nil
+		}
+		return tx.WithContext(ctx).Model(&biz_omiai.ProposalBatch{}).Where("id = ?", p.BatchID).
+			Update("status", biz_omiai.ProposalBatchStatusFinished).Error
+	})
+}
+
+func (r *ProposalRepo) PendingCounts(ctx context.Context) (map[uint64]int, error) {
+	var rows []struct {
+		MaleClientID   uint64
+		FemaleClientID uint64
+	}
+	if err := r.db.WithContext(ctx).Model(&biz_omiai.Proposal{}).Select("male_client_id, female_client_id").
+		Where("status = ?", biz_omiai.ProposalStatusPending).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make(map[uint64]int)
+	for _, row := range rows {
+		out[row.MaleClientID]++
+		out[row.FemaleClientID]++
+	}
+	return out, nil
+}
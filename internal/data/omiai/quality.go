@@ -0,0 +1,59 @@
+package omiai
+
+import (
+	"context"
+	"fmt"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/data"
+
+	"gorm.io/gorm/clause"
+)
+
+var _ biz_omiai.QualityInterface = (*QualityRepo)(nil)
+
+type QualityRepo struct {
+	db *data.DB
+}
+
+func NewQualityRepo(db *data.DB) biz_omiai.QualityInterface {
+	return &QualityRepo{db: db}
+}
+
+func (r *QualityRepo) Batch(ctx context.Context, afterID uint64, limit int) ([]*biz_omiai.Client, error) {
+	var list []*biz_omiai.Client
+	if err := r.db.WithContext(ctx).Where("id > ?", afterID).Order("id").Limit(limit).Find(&list).Error; err != nil {
+		return nil, fmt.Errorf("QualityRepo:Batch after:%d err:%w", afterID, err)
+	}
+	return list, nil
+}
+
+func (r *QualityRepo) Save(ctx context.Context, c *biz_omiai.Client) error {
+	return r.db.WithContext(ctx).Model(&biz_omiai.Client{ID: c.ID}).UpdateColumns(c.QualityColumns()).Error
+}
+
+func (r *QualityRepo) SaveReport(ctx context.Context, report *biz_omiai.QualityReport) error {
+	report.Encode()
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "report_date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"total", "avg_completeness", "complete", "buckets", "issues", "created_at"}),
+	}).Create(report).Error
+}
+
+func (r *QualityRepo) Reports(ctx context.Context, offset, limit int) ([]*biz_omiai.QualityReport, int64, error) {
+	var (
+		list  []*biz_omiai.QualityReport
+		total int64
+	)
+	db := r.db.WithContext(ctx).Model(&biz_omiai.QualityReport{})
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("QualityRepo:Reports count err:%w", err)
+	}
+	if err := db.Order("report_date desc").Offset(offset).Limit(limit).Find(&list).Error; err != nil {
+		return nil, 0, fmt.Errorf("QualityRepo:Reports err:%w", err)
+	}
+	for _, v := range list {
+		v.Decode()
+	}
+	return list, total, nil
+}
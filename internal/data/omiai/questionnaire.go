@@ -0,0 +1,137 @@
+package omiai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/data"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var _ biz_omiai.QuestionnaireInterface = (*QuestionnaireRepo)(nil)
+
+type QuestionnaireRepo struct {
+	db *data.DB
+}
+
+func NewQuestionnaireRepo(db *data.DB) biz_omiai.QuestionnaireInterface {
+	return &QuestionnaireRepo{db: db}
+}
+
+func (r *QuestionnaireRepo) CreateVersion(ctx context.Context, q *biz_omiai.Questionnaire) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var latest int
+		if err := tx.WithContext(ctx).Model(&biz_omiai.Questionnaire{}).Where("code = ?", q.Code).
+			Select("COALESCE(MAX(version), 0)").Scan(&latest).Error; err != nil {
+			return err
+		}
+		if err := tx.WithContext(ctx).Model(&biz_omiai.Questionnaire{}).Where("code = ? AND status = ?", q.Code, biz_omiai.QuestionnaireStatusActive).
+			Update("status", biz_omiai.QuestionnaireStatusInactive).Error; err != nil {
+			return err
+		}
+		q.Version = latest + 1
+		q.Status = biz_omiai.QuestionnaireStatusActive
+		return tx.WithContext(ctx).Create(q).Error
+	})
+}
+
+func (r *QuestionnaireRepo) List(ctx context.Context, code string) ([]*biz_omiai.Questionnaire, error) {
+	var list []*biz_omiai.Questionnaire
+	db := r.db.WithContext(ctx).Model(&biz_omiai.Questionnaire{})
+	if code != "" {
+		db = db.Where("code = ?", code)
+	}
+	if err := db.Order("code asc, version desc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *QuestionnaireRepo) Get(ctx context.Context, id uint64) (*biz_omiai.Questionnaire, error) {
+	var q biz_omiai.Questionnaire
+	if err := r.db.WithContext(ctx).First(&q, id).Error; err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+func (r *QuestionnaireRepo) GetActive(ctx context.Context, code string) (*biz_omiai.Questionnaire, error) {
+	var q biz_omiai.Questionnaire
+	err := r.db.WithContext(ctx).Where("code = ? AND status = ?", code, biz_omiai.QuestionnaireStatusActive).
+		Order("version desc").First(&q).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &q, nil
+}
+
+func (r *QuestionnaireRepo) CreateSheet(ctx context.Context, sheet *biz_omiai.QuestionnaireSheet) error {
+	return r.db.WithContext(ctx).Create(sheet).Error
+}
+
+func (r *QuestionnaireRepo) GetSheetByToken(ctx context.Context, token string) (*biz_omiai.QuestionnaireSheet, error) {
+	var sheet biz_omiai.QuestionnaireSheet
+	err := r.db.WithContext(ctx).Preload("Questionnaire").Where("token = ?", token).First(&sheet).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &sheet, nil
+}
+
+func (r *QuestionnaireRepo) ListSheets(ctx context.Context, clientID uint64) ([]*biz_omiai.QuestionnaireSheet, error) {
+	var list []*biz_omiai.QuestionnaireSheet
+	if err := r.db.WithContext(ctx).Where("client_id = ?", clientID).Order("id desc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *QuestionnaireRepo) SubmitSheet(ctx context.Context, sheet *biz_omiai.QuestionnaireSheet, profile *biz_omiai.PersonalityProfile) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		// 仅待填写状态可提交，防止重复提交覆盖
+		res := tx.WithContext(ctx).Model(&biz_omiai.QuestionnaireSheet{}).
+			Where("id = ? AND status = ?", sheet.ID, biz_omiai.SheetStatusPending).
+			Updates(map[string]interface{}{
+				"status":       biz_omiai.SheetStatusSubmitted,
+				"answers":      sheet.Answers,
+				"submitted_at": now,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return fmt.Errorf("questionnaire sheet %d already submitted", sheet.ID)
+		}
+		sheet.Status = biz_omiai.SheetStatusSubmitted
+		sheet.SubmittedAt = &now
+
+		return tx.WithContext(ctx).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "client_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"questionnaire_id", "version", "sheet_id", "scores", "updated_at"}),
+		}).Create(profile).Error
+	})
+}
+
+func (r *QuestionnaireRepo) GetProfile(ctx context.Context, clientID uint64) (*biz_omiai.PersonalityProfile, error) {
+	var profile biz_omiai.PersonalityProfile
+	err := r.db.WithContext(ctx).Where("client_id = ?", clientID).First(&profile).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &profile, nil
+}
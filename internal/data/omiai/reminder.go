@@ -159,3 +159,12 @@ func (r *ReminderRepo) ExistsByClientAndType(clientID uint64, triggerType string
 
 	return count > 0, err
 }
+
+func (r *ReminderRepo) CancelByMeeting(meetingID uint64) error {
+	return r.db.DB.Model(&biz_omiai.ReminderTask{}).
+		Where("meeting_id = ? AND status = ?", meetingID, "pending").
+		Updates(map[string]interface{}{
+			"status":     "cancelled",
+			"updated_at": time.Now(),
+		}).Error
+}
@@ -0,0 +1,130 @@
+package omiai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/data"
+)
+
+var _ biz_omiai.SearchInterface = (*SearchRepo)(nil)
+
+// searchClientFields 全文检索读取的客户字段
+var searchClientFields = func() []string {
+	fields := []string{"id"}
+	for _, f := range biz_omiai.SearchFields {
+		if f.Name != biz_omiai.SearchFieldFollowUp && f.Name != biz_omiai.SearchFieldTags {
+			fields = append(fields, f.Name)
+		}
+	}
+	return fields
+}()
+
+type SearchRepo struct {
+	db *data.DB
+}
+
+func NewSearchRepo(db *data.DB) biz_omiai.SearchInterface {
+	return &SearchRepo{db: db}
+}
+
+func (r *SearchRepo) Documents(ctx context.Context, afterID uint64, limit int) ([]*biz_omiai.SearchDocument, error) {
+	var list []*biz_omiai.Client
+	if err := r.db.WithContext(ctx).Select(searchClientFields).Preload("TagLinks.Tag").
+		Where("id > ?", afterID).Order("id").Limit(limit).Find(&list).Error; err != nil {
+		return nil, fmt.Errorf("SearchRepo:Documents after:%d err:%w", afterID, err)
+	}
+	return r.documents(ctx, list)
+}
+
+func (r *SearchRepo) DocumentsByIDs(ctx context.Context, ids []uint64) ([]*biz_omiai.SearchDocument, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var list []*biz_omiai.Client
+	if err := r.db.WithContext(ctx).Select(searchClientFields).Preload("TagLinks.Tag").
+		Where("id IN ?", ids).Find(&list).Error; err != nil {
+		return nil, fmt.Errorf("SearchRepo:DocumentsByIDs err:%w", err)
+	}
+	return r.documents(ctx, list)
+}
+
+func (r *SearchRepo) Changed(ctx context.Context, since time.Time) ([]uint64, error) {
+	var ids, more []uint64
+	if err := r.db.WithContext(ctx).Unscoped().Model(&biz_omiai.Client{}).
+		Where("updated_at > ? OR deleted_at > ?", since, since).Pluck("id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("SearchRepo:Changed clients err:%w", err)
+	}
+	if err := r.db.WithContext(ctx).Model(&biz_omiai.ClientTag{}).Where("created_at > ?", since).Pluck("client_id", &more).Error; err != nil {
+		return nil, fmt.Errorf("SearchRepo:Changed tags err:%w", err)
+	}
+	ids = append(ids, more...)
+	var couples []*biz_omiai.MatchRecord
+	if err := r.db.WithContext(ctx).Model(&biz_omiai.MatchRecord{}).Select("match_record.male_client_id", "match_record.female_client_id").
+		Joins("JOIN follow_up_record ON follow_up_record.match_record_id = match_record.id").
+		Where("follow_up_record.updated_at > ?", since).Find(&couples).Error; err != nil {
+		return nil, fmt.Errorf("SearchRepo:Changed follow ups err:%w", err)
+	}
+	for _, m := range couples {
+		ids = append(ids, m.MaleClientID, m.FemaleClientID)
+	}
+	return ids, nil
+}
+
+// documents 拼装检索文本，回访记录挂在情侣档案下，同时计入双方
+func (r *SearchRepo) documents(ctx context.Context, clients []*biz_omiai.Client) ([]*biz_omiai.SearchDocument, error) {
+	if len(clients) == 0 {
+		return nil, nil
+	}
+	ids := make([]uint64, 0, len(clients))
+	docs := make(map[uint64]*biz_omiai.SearchDocument, len(clients))
+	list := make([]*biz_omiai.SearchDocument, 0, len(clients))
+	for _, c := range clients {
+		snapshot := biz_omiai.ClientSnapshot(c)
+		doc := &biz_omiai.SearchDocument{ClientID: c.ID, Fields: make(map[string]string, len(biz_omiai.SearchFields))}
+		for _, f := range searchClientFields[1:] {
+			if v, ok := snapshot[f].(string); ok && v != "" {
+				doc.Fields[f] = v
+			}
+		}
+		if tags := c.TagList(); len(tags) > 0 {
+			doc.Fields[biz_omiai.SearchFieldTags] = strings.Join(tags, " ")
+		}
+		ids = append(ids, c.ID)
+		docs[c.ID] = doc
+		list = append(list, doc)
+	}
+
+	var rows []struct {
+		MaleClientID   uint64
+		FemaleClientID uint64
+		Content        string
+		Feedback       string
+	}
+	if err := r.db.WithContext(ctx).Model(&biz_omiai.FollowUpRecord{}).
+		Select("match_record.male_client_id, match_record.female_client_id, follow_up_record.content, follow_up_record.feedback").
+		Joins("JOIN match_record ON match_record.id = follow_up_record.match_record_id AND match_record.deleted_at IS NULL").
+		Where("match_record.male_client_id IN ? OR match_record.female_client_id IN ?", ids, ids).
+		Order("follow_up_record.id").Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("SearchRepo:documents follow ups err:%w", err)
+	}
+	notes := make(map[uint64][]string)
+	for _, row := range rows {
+		text := strings.TrimSpace(strings.TrimSpace(row.Content) + " " + strings.TrimSpace(row.Feedback))
+		if text == "" {
+			continue
+		}
+		for _, id := range []uint64{row.MaleClientID, row.FemaleClientID} {
+			if _, ok := docs[id]; ok {
+				notes[id] = append(notes[id], text)
+			}
+		}
+	}
+	for id, texts := range notes {
+		docs[id].Fields[biz_omiai.SearchFieldFollowUp] = strings.Join(texts, "\n")
+	}
+	return list, nil
+}
@@ -0,0 +1,186 @@
+package omiai
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"omiai-server/internal/biz"
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/data"
+
+	"gorm.io/gorm"
+)
+
+var _ biz_omiai.SegmentInterface = (*SegmentRepo)(nil)
+
+type SegmentRepo struct {
+	db *data.DB
+}
+
+func NewSegmentRepo(db *data.DB) biz_omiai.SegmentInterface {
+	return &SegmentRepo{db: db}
+}
+
+func (r *SegmentRepo) Select(ctx context.Context, clause *biz.WhereClause, offset, limit int) ([]*biz_omiai.ClientSegment, int64, error) {
+	var (
+		list  []*biz_omiai.ClientSegment
+		total int64
+	)
+	db := r.db.WithContext(ctx).Model(&biz_omiai.ClientSegment{}).Where(clause.Where, clause.Args...)
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("SegmentRepo:Select count where:%v err:%w", clause, err)
+	}
+	orderBy := clause.OrderBy
+	if orderBy == "" {
+		orderBy = "id desc"
+	}
+	if err := db.Order(orderBy).Offset(offset).Limit(limit).Find(&list).Error; err != nil {
+		return nil, 0, fmt.Errorf("SegmentRepo:Select where:%v err:%w", clause, err)
+	}
+	for _, s := range list {
+		s.Decode()
+	}
+	return list, total, nil
+}
+
+func (r *SegmentRepo) Get(ctx context.Context, id uint64) (*biz_omiai.ClientSegment, error) {
+	var s biz_omiai.ClientSegment
+	if err := r.db.WithContext(ctx).First(&s, id).Error; err != nil {
+		return nil, err
+	}
+	s.Decode()
+	return &s, nil
+}
+
+func (r *SegmentRepo) All(ctx context.Context) ([]*biz_omiai.ClientSegment, error) {
+	var list []*biz_omiai.ClientSegment
+	if err := r.db.WithContext(ctx).Order("id").Find(&list).Error; err != nil {
+		return nil, fmt.Errorf("SegmentRepo:All err:%w", err)
+	}
+	for _, s := range list {
+		s.Decode()
+	}
+	return list, nil
+}
+
+func (r *SegmentRepo) Create(ctx context.Context, s *biz_omiai.ClientSegment) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkSegmentName(ctx, tx, s); err != nil {
+			return err
+		}
+		return tx.WithContext(ctx).Create(s).Error
+	})
+}
+
+func (r *SegmentRepo) Update(ctx context.Context, s *biz_omiai.ClientSegment) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkSegmentName(ctx, tx, s); err != nil {
+			return err
+		}
+		// 共享可能改为 false，按列保存
+		res := tx.WithContext(ctx).Model(s).Select("name", "description", "filter", "shared").Updates(s)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+func (r *SegmentRepo) Delete(ctx context.Context, id uint64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.WithContext(ctx).Where("segment_id = ?", id).Delete(&biz_omiai.ClientSegmentMember{}).Error; err != nil {
+			return err
+		}
+		res := tx.WithContext(ctx).Delete(&biz_omiai.ClientSegment{}, id)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+func (r *SegmentRepo) Count(ctx context.Context, clause *biz.WhereClause) (int64, error) {
+	var n int64
+	if err := r.db.WithContext(ctx).Model(&biz_omiai.Client{}).Where(clause.Where, clause.Args...).Count(&n).Error; err != nil {
+		return 0, fmt.Errorf("SegmentRepo:Count where:%v err:%w", clause, err)
+	}
+	return n, nil
+}
+
+func (r *SegmentRepo) ClientIDs(ctx context.Context, clause *biz.WhereClause) ([]uint64, error) {
+	var ids []uint64
+	db := r.db.WithContext(ctx).Model(&biz_omiai.Client{}).Where(clause.Where, clause.Args...)
+	if clause.OrderBy != "" {
+		db = db.Order(clause.OrderBy)
+	}
+	if err := db.Pluck("id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("SegmentRepo:ClientIDs where:%v err:%w", clause, err)
+	}
+	return ids, nil
+}
+
+func (r *SegmentRepo) SaveMembers(ctx context.Context, segmentID uint64, clientIDs []uint64, at time.Time) (entered, left []uint64, err error) {
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		var seg biz_omiai.ClientSegment
+		if err := tx.WithContext(ctx).Select("id").First(&seg, segmentID).Error; err != nil {
+			return err
+		}
+		var old []uint64
+		if err := tx.WithContext(ctx).Model(&biz_omiai.ClientSegmentMember{}).Where("segment_id = ?", segmentID).Order("client_id").Pluck("client_id", &old).Error; err != nil {
+			return err
+		}
+		current := make(map[uint64]bool, len(clientIDs))
+		for _, id := range clientIDs {
+			current[id] = true
+		}
+		previous := make(map[uint64]bool, len(old))
+		for _, id := range old {
+			previous[id] = true
+			if !current[id] {
+				left = append(left, id)
+			}
+		}
+		members := make([]*biz_omiai.ClientSegmentMember, 0)
+		for _, id := range clientIDs {
+			if !previous[id] {
+				previous[id] = true
+				entered = append(entered, id)
+				members = append(members, &biz_omiai.ClientSegmentMember{SegmentID: segmentID, ClientID: id, EnteredAt: at})
+			}
+		}
+		if len(left) > 0 {
+			if err := tx.WithContext(ctx).Where("segment_id = ? AND client_id IN ?", segmentID, left).Delete(&biz_omiai.ClientSegmentMember{}).Error; err != nil {
+				return err
+			}
+		}
+		if len(members) > 0 {
+			if err := tx.WithContext(ctx).CreateInBatches(members, 500).Error; err != nil {
+				return err
+			}
+		}
+		return tx.WithContext(ctx).Model(&biz_omiai.ClientSegment{}).Where("id = ?", segmentID).
+			UpdateColumns(map[string]interface{}{"member_count": len(current), "refreshed_at": at}).Error
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("SegmentRepo:SaveMembers segment:%d err:%w", segmentID, err)
+	}
+	return entered, left, nil
+}
+
+func checkSegmentName(ctx context.Context, tx *gorm.DB, s *biz_omiai.ClientSegment) error {
+	var n int64
+	if err := tx.WithContext(ctx).Model(&biz_omiai.ClientSegment{}).Where("name = ? AND id <> ?", s.Name, s.ID).Count(&n).Error; err != nil {
+		return err
+	}
+	if n > 0 {
+		return biz_omiai.ErrSegmentExists
+	}
+	return nil
+}
@@ -0,0 +1,430 @@
+package omiai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"omiai-server/internal/biz"
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/data"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var _ biz_omiai.TagInterface = (*TagRepo)(nil)
+
+type TagRepo struct {
+	db     *data.DB
+	events biz_omiai.EventBus
+}
+
+func NewTagRepo(db *data.DB, events biz_omiai.EventBus) biz_omiai.TagInterface {
+	return &TagRepo{db: db, events: events}
+}
+
+func (r *TagRepo) Groups(ctx context.Context) ([]*biz_omiai.TagGroup, error) {
+	var list []*biz_omiai.TagGroup
+	if err := r.db.WithContext(ctx).Order("sort, id").Find(&list).Error; err != nil {
+		return nil, fmt.Errorf("TagRepo:Groups err:%w", err)
+	}
+	return list, nil
+}
+
+func (r *TagRepo) SaveGroup(ctx context.Context, g *biz_omiai.TagGroup) error {
+	if g.ID == 0 {
+		return r.db.WithContext(ctx).Create(g).Error
+	}
+	// 单选、参与评分可能改为 false，整行保存
+	return r.db.WithContext(ctx).Select("name", "exclusive", "scored", "sort").Updates(g).Error
+}
+
+func (r *TagRepo) DeleteGroup(ctx context.Context, id uint64) error {
+	var n int64
+	if err := r.db.WithContext(ctx).Model(&biz_omiai.Tag{}).Where("group_id = ?", id).Count(&n).Error; err != nil {
+		return err
+	}
+	if n > 0 {
+		return biz_omiai.ErrTagGroupInUse
+	}
+	return r.db.WithContext(ctx).Delete(&biz_omiai.TagGroup{}, id).Error
+}
+
+func (r *TagRepo) Select(ctx context.Context, clause *biz.WhereClause, offset, limit int) ([]*biz_omiai.Tag, int64, error) {
+	var (
+		list  []*biz_omiai.Tag
+		total int64
+	)
+	db := r.db.WithContext(ctx).Model(&biz_omiai.Tag{}).Where(clause.Where, clause.Args...)
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("TagRepo:Select count where:%v err:%w", clause, err)
+	}
+	orderBy := clause.OrderBy
+	if orderBy == "" {
+		orderBy = "group_id, sort, id"
+	}
+	if err := db.Preload("Group").Order(orderBy).Offset(offset).Limit(limit).Find(&list).Error; err != nil {
+		return nil, 0, fmt.Errorf("TagRepo:Select where:%v err:%w", clause, err)
+	}
+	if len(list) == 0 {
+		return list, total, nil
+	}
+
+	ids := make([]uint64, 0, len(list))
+	for _, t := range list {
+		t.Decode()
+		ids = append(ids, t.ID)
+	}
+	// 回收站中的客户不计入
+	var counts []struct {
+		TagID uint64
+		N     int64
+	}
+	if err := r.db.WithContext(ctx).Model(&biz_omiai.ClientTag{}).
+		Select("client_tag.tag_id, COUNT(*) AS n").
+		Joins("JOIN client ON client.id = client_tag.client_id AND client.deleted_at IS NULL").
+		Where("client_tag.tag_id IN ?", ids).Group("client_tag.tag_id").
+		Scan(&counts).Error; err != nil {
+		return nil, 0, fmt.Errorf("TagRepo:Select count clients err:%w", err)
+	}
+	byID := make(map[uint64]int64, len(counts))
+	for _, c := range counts {
+		byID[c.TagID] = c.N
+	}
+	for _, t := range list {
+		t.ClientCount = byID[t.ID]
+	}
+	return list, total, nil
+}
+
+func (r *TagRepo) Get(ctx context.Context, id uint64) (*biz_omiai.Tag, error) {
+	var t biz_omiai.Tag
+	if err := r.db.WithContext(ctx).Preload("Group").First(&t, id).Error; err != nil {
+		return nil, err
+	}
+	t.Decode()
+	return &t, nil
+}
+
+func (r *TagRepo) Create(ctx context.Context, t *biz_omiai.Tag) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkTagKeys(ctx, tx, t); err != nil {
+			return err
+		}
+		return tx.WithContext(ctx).Create(t).Error
+	})
+}
+
+func (r *TagRepo) Update(ctx context.Context, t *biz_omiai.Tag) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkTagKeys(ctx, tx, t); err != nil {
+			return err
+		}
+		res := tx.WithContext(ctx).Model(t).Select("group_id", "name", "synonyms", "sort").Updates(t)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	if err == nil {
+		r.publishTag(ctx, t.ID)
+	}
+	return err
+}
+
+func (r *TagRepo) Delete(ctx context.Context, id uint64) error {
+	clientIDs, err := r.taggedClients(ctx, id)
+	if err != nil {
+		return err
+	}
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.WithContext(ctx).Where("tag_id = ?", id).Delete(&biz_omiai.ClientTag{}).Error; err != nil {
+			return err
+		}
+		return tx.WithContext(ctx).Delete(&biz_omiai.Tag{}, id).Error
+	})
+	if err != nil {
+		return err
+	}
+	r.publish(ctx, clientIDs)
+	return nil
+}
+
+func (r *TagRepo) Merge(ctx context.Context, sourceID, targetID uint64) (*biz_omiai.Tag, error) {
+	if sourceID == targetID {
+		return nil, biz_omiai.ErrTagMergeSelf
+	}
+	clientIDs, err := r.taggedClients(ctx, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	var target biz_omiai.Tag
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		var source biz_omiai.Tag
+		if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&source, sourceID).Error; err != nil {
+			return err
+		}
+		if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&target, targetID).Error; err != nil {
+			return err
+		}
+		source.Decode()
+		target.Decode()
+		target.SetSynonyms(append(append(target.SynonymList, source.Name), source.SynonymList...))
+
+		// 已有目标标签的客户保留原关联，其余改指向目标标签；MySQL 不允许更新语句的子查询读同一张表，先取出客户 ID
+		var tagged []uint64
+		if err := tx.WithContext(ctx).Model(&biz_omiai.ClientTag{}).Where("tag_id = ?", targetID).Pluck("client_id", &tagged).Error; err != nil {
+			return err
+		}
+		db := tx.WithContext(ctx).Model(&biz_omiai.ClientTag{}).Where("tag_id = ?", sourceID)
+		if len(tagged) > 0 {
+			db = db.Where("client_id NOT IN ?", tagged)
+		}
+		if err := db.UpdateColumn("tag_id", targetID).Error; err != nil {
+			return err
+		}
+		if err := tx.WithContext(ctx).Where("tag_id = ?", sourceID).Delete(&biz_omiai.ClientTag{}).Error; err != nil {
+			return err
+		}
+		if err := tx.WithContext(ctx).Delete(&source).Error; err != nil {
+			return err
+		}
+		return tx.WithContext(ctx).Model(&target).Update("synonyms", target.Synonyms).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.publish(ctx, clientIDs)
+	return &target, nil
+}
+
+func (r *TagRepo) Resolve(ctx context.Context, names []string, create bool) ([]*biz_omiai.Tag, []string, error) {
+	var (
+		tags    []*biz_omiai.Tag
+		missing []string
+	)
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		tags, missing, err = resolveTags(ctx, tx, names, create)
+		return err
+	})
+	return tags, missing, err
+}
+
+func (r *TagRepo) ClientTags(ctx context.Context, clientID uint64) ([]*biz_omiai.ClientTag, error) {
+	var list []*biz_omiai.ClientTag
+	if err := r.db.WithContext(ctx).Preload("Tag.Group").Where("client_id = ?", clientID).Order("id").Find(&list).Error; err != nil {
+		return nil, fmt.Errorf("TagRepo:ClientTags client:%d err:%w", clientID, err)
+	}
+	return list, nil
+}
+
+func (r *TagRepo) SetClientTags(ctx context.Context, clientID uint64, tagIDs []uint64, source, operator string) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.WithContext(ctx).Select("id").First(&biz_omiai.Client{}, clientID).Error; err != nil {
+			return err
+		}
+		return setClientTags(ctx, tx, clientID, tagIDs, source, operator)
+	})
+	if err == nil {
+		r.publish(ctx, []uint64{clientID})
+	}
+	return err
+}
+
+func (r *TagRepo) AddClientTags(ctx context.Context, clientID uint64, tagIDs []uint64, source string, confidence float64, operator string) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.WithContext(ctx).Select("id").First(&biz_omiai.Client{}, clientID).Error; err != nil {
+			return err
+		}
+		return addClientTags(ctx, tx, clientID, tagIDs, source, confidence, operator)
+	})
+	if err == nil {
+		r.publish(ctx, []uint64{clientID})
+	}
+	return err
+}
+
+func (r *TagRepo) taggedClients(ctx context.Context, tagID uint64) ([]uint64, error) {
+	var ids []uint64
+	if err := r.db.WithContext(ctx).Model(&biz_omiai.ClientTag{}).Where("tag_id = ?", tagID).Pluck("client_id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("TagRepo:taggedClients tag:%d err:%w", tagID, err)
+	}
+	return ids, nil
+}
+
+// publishTag 标签改名或改分组后，使用该标签的客户评分随之变化
+func (r *TagRepo) publishTag(ctx context.Context, tagID uint64) {
+	if ids, err := r.taggedClients(ctx, tagID); err == nil {
+		r.publish(ctx, ids)
+	}
+}
+
+func (r *TagRepo) publish(ctx context.Context, clientIDs []uint64) {
+	if len(clientIDs) > 0 {
+		r.events.Publish(ctx, biz_omiai.EventClientChanged, &biz_omiai.ClientChanged{ClientIDs: clientIDs, Change: biz_omiai.ClientChangeProfile})
+	}
+}
+
+// checkTagKeys 标签名与同义词不能与其他标签的名称或同义词重复
+func checkTagKeys(ctx context.Context, tx *gorm.DB, t *biz_omiai.Tag) error {
+	var others []*biz_omiai.Tag
+	if err := tx.WithContext(ctx).Where("id != ?", t.ID).Find(&others).Error; err != nil {
+		return err
+	}
+	used := make(map[string]bool)
+	for _, o := range others {
+		for _, key := range o.Keys() {
+			used[key] = true
+		}
+	}
+	for _, key := range t.Keys() {
+		if used[key] {
+			return biz_omiai.ErrTagExists
+		}
+	}
+	return nil
+}
+
+// resolveTags 按标签名或同义词查找标签，create 时为未收录的名称新建未分组标签
+func resolveTags(ctx context.Context, tx *gorm.DB, names []string, create bool) ([]*biz_omiai.Tag, []string, error) {
+	if len(names) == 0 {
+		return nil, nil, nil
+	}
+	var all []*biz_omiai.Tag
+	if err := tx.WithContext(ctx).Find(&all).Error; err != nil {
+		return nil, nil, err
+	}
+	index := make(map[string]*biz_omiai.Tag)
+	for _, t := range all {
+		for _, key := range t.Keys() {
+			index[key] = t
+		}
+	}
+
+	var (
+		tags    []*biz_omiai.Tag
+		missing []string
+	)
+	seen := make(map[uint64]bool)
+	for _, name := range names {
+		key := biz_omiai.NormalizeTagName(name)
+		if key == "" {
+			continue
+		}
+		t, ok := index[key]
+		if !ok {
+			if !create {
+				missing = append(missing, name)
+				continue
+			}
+			t = &biz_omiai.Tag{Name: strings.Trim(strings.TrimSpace(name), "#＃ ")}
+			t.SetSynonyms(nil)
+			if err := tx.WithContext(ctx).Create(t).Error; err != nil {
+				return nil, nil, err
+			}
+			index[key] = t
+		}
+		if !seen[t.ID] {
+			seen[t.ID] = true
+			tags = append(tags, t)
+		}
+	}
+	return tags, missing, nil
+}
+
+// exclusiveTags 单选分组内只保留最后一个标签，返回保留的标签 ID 与涉及的单选分组
+func exclusiveTags(ctx context.Context, tx *gorm.DB, tagIDs []uint64) ([]uint64, map[uint64]uint64, error) {
+	if len(tagIDs) == 0 {
+		return nil, nil, nil
+	}
+	var tags []*biz_omiai.Tag
+	if err := tx.WithContext(ctx).Preload("Group").Where("id IN ?", tagIDs).Find(&tags).Error; err != nil {
+		return nil, nil, err
+	}
+	byID := make(map[uint64]*biz_omiai.Tag, len(tags))
+	for _, t := range tags {
+		byID[t.ID] = t
+	}
+	groups := make(map[uint64]uint64) // 单选分组 => 保留的标签
+	for _, id := range tagIDs {
+		if t, ok := byID[id]; ok && t.Group != nil && t.Group.Exclusive {
+			groups[t.GroupID] = id
+		}
+	}
+	var kept []uint64
+	seen := make(map[uint64]bool)
+	for _, id := range tagIDs {
+		t, ok := byID[id]
+		if !ok || seen[id] {
+			continue
+		}
+		if keep, exclusive := groups[t.GroupID]; exclusive && keep != id {
+			continue
+		}
+		seen[id] = true
+		kept = append(kept, id)
+	}
+	return kept, groups, nil
+}
+
+// addClientTags 追加标签，单选分组中客户原有的其他标签被替换
+func addClientTags(ctx context.Context, tx *gorm.DB, clientID uint64, tagIDs []uint64, source string, confidence float64, operator string) error {
+	kept, groups, err := exclusiveTags(ctx, tx, tagIDs)
+	if err != nil {
+		return err
+	}
+	for groupID, tagID := range groups {
+		if err := tx.WithContext(ctx).
+			Where("client_id = ? AND tag_id != ? AND tag_id IN (?)", clientID, tagID,
+				tx.Model(&biz_omiai.Tag{}).Select("id").Where("group_id = ?", groupID)).
+			Delete(&biz_omiai.ClientTag{}).Error; err != nil {
+			return err
+		}
+	}
+	links := make([]*biz_omiai.ClientTag, 0, len(kept))
+	for _, id := range kept {
+		links = append(links, &biz_omiai.ClientTag{ClientID: clientID, TagID: id, Source: source, Confidence: confidence, Operator: operator})
+	}
+	if len(links) == 0 {
+		return nil
+	}
+	return tx.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&links).Error
+}
+
+// setClientTags 替换客户的全部标签，已有关联保留原来源与置信度
+func setClientTags(ctx context.Context, tx *gorm.DB, clientID uint64, tagIDs []uint64, source, operator string) error {
+	kept, _, err := exclusiveTags(ctx, tx, tagIDs)
+	if err != nil {
+		return err
+	}
+	db := tx.WithContext(ctx).Where("client_id = ?", clientID)
+	if len(kept) > 0 {
+		db = db.Where("tag_id NOT IN ?", kept)
+	}
+	if err := db.Delete(&biz_omiai.ClientTag{}).Error; err != nil {
+		return err
+	}
+	return addClientTags(ctx, tx, clientID, kept, source, 1, operator)
+}
+
+// saveClientTags 保存客户资料时写入 Tags 输入的标签，未收录的名称新建为未分组标签
+func saveClientTags(ctx context.Context, tx *gorm.DB, clientID uint64, raw string, replace bool) error {
+	tags, _, err := resolveTags(ctx, tx, biz_omiai.ParseTags(raw), true)
+	if err != nil {
+		return err
+	}
+	ids := make([]uint64, 0, len(tags))
+	for _, t := range tags {
+		ids = append(ids, t.ID)
+	}
+	_, operator := biz_omiai.ClientChangeFrom(ctx)
+	if replace {
+		return setClientTags(ctx, tx, clientID, ids, biz_omiai.TagSourceFrom(ctx), operator)
+	}
+	return addClientTags(ctx, tx, clientID, ids, biz_omiai.TagSourceFrom(ctx), 1, operator)
+}
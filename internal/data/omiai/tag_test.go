@@ -0,0 +1,97 @@
+package omiai
+
+import (
+	"context"
+	"testing"
+
+	"omiai-server/internal/biz"
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/data"
+	"omiai-server/internal/service/event"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestTagRepo(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	assert.NoError(t, db.AutoMigrate(&biz_omiai.Client{}, &biz_omiai.PersonalityProfile{}, &biz_omiai.ClientEmbedding{}, &biz_omiai.ClientVersion{},
+		&biz_omiai.ClientTag{}, &biz_omiai.Tag{}, &biz_omiai.TagGroup{}))
+	bus := event.NewBus()
+	clients := NewClientRepo(&data.DB{DB: db}, bus)
+	repo := NewTagRepo(&data.DB{DB: db}, bus)
+	ctx := context.Background()
+
+	hobby := &biz_omiai.TagGroup{Name: "爱好", Scored: true}
+	level := &biz_omiai.TagGroup{Name: "客户等级", Exclusive: true}
+	assert.NoError(t, repo.SaveGroup(ctx, hobby))
+	assert.NoError(t, repo.SaveGroup(ctx, level))
+	climb := &biz_omiai.Tag{GroupID: hobby.ID, Name: "爬山"}
+	climb.SetSynonyms([]string{"登山", "徒步"})
+	assert.NoError(t, repo.Create(ctx, climb))
+	dup := &biz_omiai.Tag{Name: "户外"}
+	dup.SetSynonyms([]string{"登山"})
+	assert.ErrorIs(t, repo.Create(ctx, dup), biz_omiai.ErrTagExists)
+	levelA := &biz_omiai.Tag{GroupID: level.ID, Name: "A级"}
+	levelB := &biz_omiai.Tag{GroupID: level.ID, Name: "B级"}
+	assert.NoError(t, repo.Create(ctx, levelA))
+	assert.NoError(t, repo.Create(ctx, levelB))
+
+	// 保存资料时同义词归一到标签，未收录的名称新建为未分组标签
+	a := &biz_omiai.Client{Name: "甲", Gender: 1, Phone: "13800000001", Tags: `["#登山", "摄影"]`}
+	b := &biz_omiai.Client{Name: "乙", Gender: 2, Phone: "13800000002", Tags: "爬山，A级"}
+	ctxImport := biz_omiai.WithClientChange(ctx, biz_omiai.ClientSourceImport, "")
+	assert.NoError(t, clients.Create(ctx, a))
+	assert.NoError(t, clients.Create(ctxImport, b))
+	got, err := clients.Get(ctx, a.ID)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"爬山", "摄影"}, got.TagList())
+	assert.Equal(t, map[uint64]string{climb.ID: "爬山"}, got.ScoredTags())
+	links, err := repo.ClientTags(ctx, b.ID)
+	assert.NoError(t, err)
+	assert.Len(t, links, 2)
+	assert.Equal(t, biz_omiai.TagSourceImport, links[0].Source)
+
+	// 单选分组只保留最后添加的标签
+	assert.NoError(t, repo.AddClientTags(ctx, b.ID, []uint64{levelB.ID}, biz_omiai.TagSourceAI, biz_omiai.TagConfidenceAI, "AI"))
+	got, _ = clients.Get(ctx, b.ID)
+	assert.ElementsMatch(t, []string{"爬山", "B级"}, got.TagList())
+
+	// 精确筛选：or 包含任一，and 包含全部
+	photo, _, err := repo.Resolve(ctx, []string{"摄影"}, false)
+	assert.NoError(t, err)
+	filter := func(ids []uint64, mode string) []uint64 {
+		clause := &biz.WhereClause{Where: "1 = 1", OrderBy: "id"}
+		biz_omiai.AppendTagFilter(clause, ids, mode)
+		list, err := clients.Select(ctx, clause, nil, 0, 10)
+		assert.NoError(t, err)
+		var out []uint64
+		for _, c := range list {
+			out = append(out, c.ID)
+		}
+		return out
+	}
+	assert.Equal(t, []uint64{a.ID, b.ID}, filter([]uint64{climb.ID, photo[0].ID}, biz_omiai.TagMatchAny))
+	assert.Equal(t, []uint64{a.ID}, filter([]uint64{climb.ID, photo[0].ID}, biz_omiai.TagMatchAll))
+
+	// 合并：客户关联转到目标标签，源标签名成为同义词
+	merged, err := repo.Merge(ctx, photo[0].ID, climb.ID)
+	assert.NoError(t, err)
+	assert.Contains(t, merged.SynonymList, "摄影")
+	tags, missing, err := repo.Resolve(ctx, []string{"摄影", "钓鱼"}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, climb.ID, tags[0].ID)
+	assert.Equal(t, []string{"钓鱼"}, missing)
+	list, _, err := repo.Select(ctx, &biz.WhereClause{Where: "id = ?", Args: []interface{}{climb.ID}}, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), list[0].ClientCount)
+
+	// 修改资料时整体替换标签
+	assert.NoError(t, clients.Update(ctx, &biz_omiai.Client{ID: a.ID, Tags: "[]"}))
+	links, _ = repo.ClientTags(ctx, a.ID)
+	assert.Empty(t, links)
+}
@@ -9,8 +9,14 @@ import (
 	"omiai-server/internal/controller/client"
 	"omiai-server/internal/controller/common"
 	"omiai-server/internal/controller/dashboard"
+	"omiai-server/internal/controller/duplicate"
 	"omiai-server/internal/controller/match"
+	"omiai-server/internal/controller/meeting"
+	"omiai-server/internal/controller/party"
+	"omiai-server/internal/controller/questionnaire"
 	"omiai-server/internal/controller/reminder"
+	"omiai-server/internal/controller/segment"
+	"omiai-server/internal/controller/tag"
 	"omiai-server/internal/controller/template"
 	"omiai-server/internal/data"
 	"omiai-server/internal/middleware"
@@ -22,18 +28,24 @@ import (
 // Router .
 type Router struct {
 	*gin.Engine
-	DB                    *data.DB
-	Redis                 *redis.Redis
-	AIController          *ai.Controller
-	AuthController        *auth.Controller
-	BannerController      *banner.Controller
-	ChinaRegionController *china_region.Controller
-	ClientController      *client.Controller
-	CommonController      *common.Controller
-	TemplateController    *template.Controller
-	ReminderController    *reminder.Controller
-	DashboardController   *dashboard.Controller
-	MatchController       *match.Controller
+	DB                      *data.DB
+	Redis                   *redis.Redis
+	AIController            *ai.Controller
+	AuthController          *auth.Controller
+	BannerController        *banner.Controller
+	ChinaRegionController   *china_region.Controller
+	ClientController        *client.Controller
+	CommonController        *common.Controller
+	TemplateController      *template.Controller
+	ReminderController      *reminder.Controller
+	DashboardController     *dashboard.Controller
+	DuplicateController     *duplicate.Controller
+	MatchController         *match.Controller
+	MeetingController       *meeting.Controller
+	PartyController         *party.Controller
+	QuestionnaireController *questionnaire.Controller
+	TagController           *tag.Controller
+	SegmentController       *segment.Controller
 }
 
 func (r *Router) Register() http.Handler {
@@ -51,6 +63,9 @@ func (r *Router) Register() http.Handler {
 		g.POST("/invite/common/upload", r.CommonController.Upload)
 		// 邀请页面表单提交接口（不需要鉴权）
 		g.POST("/invite/clients/create", r.ClientController.Create)
+		// 邀请页面性格测评问卷（不需要鉴权，凭链接 token 填写）
+		g.GET("/invite/questionnaire/:token", r.QuestionnaireController.Open)
+		g.POST("/invite/questionnaire/:token/submit", r.QuestionnaireController.Submit)
 
 		// 需要登录的接口
 		authGroup := g.Group("", middleware.Authorization(r.DB, r.Redis), middleware.AuditLog())
@@ -60,8 +75,18 @@ func (r *Router) Register() http.Handler {
 			r.client(authGroup.Group("clients")) // Renamed from "client" to "clients" for V2
 			r.common(authGroup.Group("common"))
 			r.dashboard(authGroup.Group("dashboard"))
+			r.duplicate(authGroup.Group("duplicates"))
 			r.match(authGroup.Group("couples")) // Renamed from "match" to "couples" for V2
+			r.introduction(authGroup.Group("introductions"))
+			r.proposal(authGroup.Group("proposals"))
+			r.daily(authGroup.Group("daily_recommendations"))
+			r.party(authGroup.Group("parties"))
+			r.venue(authGroup.Group("venues"))
+			r.meeting(authGroup.Group("meetings"))
+			r.questionnaire(authGroup.Group("questionnaires"))
 			r.reminder(authGroup.Group("reminders"))
+			r.segment(authGroup.Group("segments"))
+			r.tag(authGroup.Group("tags"))
 			r.template(authGroup.Group("templates"))
 			// 认证相关接口（需要登录）
 			authGroup.GET("/auth/codes", r.AuthController.GetAccessCodes)
@@ -102,6 +127,8 @@ func (r *Router) common(g *gin.RouterGroup) {
 func (r *Router) dashboard(g *gin.RouterGroup) {
 	g.GET("/stats", r.DashboardController.Stats)
 	g.GET("/todos", r.DashboardController.GetTodos)
+	g.GET("/experiment", r.DashboardController.Experiment)
+	g.GET("/quality", r.DashboardController.Quality)
 }
 
 func (r *Router) match(g *gin.RouterGroup) {
@@ -118,6 +145,99 @@ func (r *Router) match(g *gin.RouterGroup) {
 	g.GET("/stats", r.MatchController.Stats)
 }
 
+func (r *Router) introduction(g *gin.RouterGroup) {
+	g.GET("/list", r.MatchController.ListIntroductions)
+	g.GET("/detail/:id", r.MatchController.GetIntroduction)
+	g.GET("/stats", r.MatchController.IntroductionStats)
+	g.POST("/create", r.MatchController.CreateIntroduction)
+	g.POST("/transition", r.MatchController.TransitionIntroduction)
+}
+
+func (r *Router) proposal(g *gin.RouterGroup) {
+	g.POST("/generate", r.MatchController.GenerateProposals)
+	g.GET("/batches", r.MatchController.ListProposalBatches)
+	g.GET("/batches/:id", r.MatchController.GetProposalBatch)
+	g.POST("/accept", r.MatchController.AcceptProposal)
+	g.POST("/reject", r.MatchController.RejectProposal)
+}
+
+func (r *Router) daily(g *gin.RouterGroup) {
+	g.GET("/list", r.MatchController.ListDaily)
+	g.GET("/stats", r.MatchController.DailyStats)
+	g.POST("/generate", r.MatchController.GenerateDaily)
+	g.POST("/accept", r.MatchController.AcceptDaily)
+	g.POST("/skip", r.MatchController.SkipDaily)
+}
+
+func (r *Router) party(g *gin.RouterGroup) {
+	g.GET("/list", r.PartyController.List)
+	g.GET("/detail/:id", r.PartyController.Detail)
+	g.POST("/create", r.PartyController.Create)
+	g.POST("/update", r.PartyController.Update)
+	g.POST("/status", r.PartyController.Transition)
+	g.POST("/signup", r.PartyController.SignUp)
+	g.POST("/cancel_signup", r.PartyController.CancelSignUp)
+	g.POST("/checkin", r.PartyController.CheckIn)
+	g.POST("/schedule", r.PartyController.Schedule)
+	g.POST("/picks", r.PartyController.SubmitPicks)
+	g.POST("/finalize", r.PartyController.Finalize)
+}
+
+func (r *Router) venue(g *gin.RouterGroup) {
+	g.GET("/list", r.MeetingController.ListVenues)
+	g.POST("/create", r.MeetingController.CreateVenue)
+	g.POST("/update", r.MeetingController.UpdateVenue)
+}
+
+// duplicate 疑似重复档案审核与合并
+func (r *Router) duplicate(g *gin.RouterGroup) {
+	g.GET("/list", r.DuplicateController.List)
+	g.GET("/detail/:id", r.DuplicateController.Detail)
+	g.POST("/merge", r.DuplicateController.Merge)
+	g.POST("/ignore", r.DuplicateController.Ignore)
+	g.POST("/scan", r.DuplicateController.Scan)
+}
+
+// tag 标签分组与标签字典，删除、合并与分组调整仅管理员可操作
+func (r *Router) tag(g *gin.RouterGroup) {
+	g.GET("/groups", r.TagController.Groups)
+	g.POST("/groups/save", r.TagController.SaveGroup)
+	g.DELETE("/groups/:id", r.TagController.DeleteGroup)
+	g.GET("/list", r.TagController.List)
+	g.GET("/detail/:id", r.TagController.Detail)
+	g.POST("/create", r.TagController.Create)
+	g.POST("/update", r.TagController.Update)
+	g.DELETE("/delete/:id", r.TagController.Delete)
+	g.POST("/merge", r.TagController.Merge)
+}
+
+func (r *Router) segment(g *gin.RouterGroup) {
+	g.GET("/list", r.SegmentController.List)
+	g.GET("/detail/:id", r.SegmentController.Detail)
+	g.POST("/preview", r.SegmentController.Preview)
+	g.POST("/create", r.SegmentController.Create)
+	g.POST("/update", r.SegmentController.Update)
+	g.DELETE("/delete/:id", r.SegmentController.Delete)
+	// 分群作为批量操作、导出与活动邀约的对象；成员列表使用 /clients/list?segment_id=
+	g.GET("/:id/client_ids", r.SegmentController.ClientIDs)
+	g.GET("/:id/export", r.SegmentController.Export)
+	g.POST("/:id/tags", r.SegmentController.AddTags)
+	g.POST("/:id/refresh", r.SegmentController.Refresh)
+}
+
+func (r *Router) meeting(g *gin.RouterGroup) {
+	g.GET("/list", r.MeetingController.List)
+	g.GET("/detail/:id", r.MeetingController.Detail)
+	g.GET("/slots", r.MeetingController.Slots)
+	g.POST("/book", r.MeetingController.Book)
+	g.POST("/reschedule", r.MeetingController.Reschedule)
+	g.POST("/complete", r.MeetingController.Complete)
+	g.POST("/cancel", r.MeetingController.Cancel)
+	g.POST("/no_show", r.MeetingController.NoShow)
+	g.GET("/availability", r.MeetingController.GetAvailability)
+	g.POST("/availability", r.MeetingController.SetAvailability)
+}
+
 func (r *Router) banner(g *gin.RouterGroup) {
 	g.GET("/list", r.BannerController.List)
 	g.GET("/detail", r.BannerController.Detail) // demo
@@ -136,7 +256,24 @@ func (r *Router) client(g *gin.RouterGroup) {
 	g.GET("/match/:id", r.ClientController.MatchV2) // Upgrade to V2
 	// V2: New Candidates & Compare Interfaces
 	g.GET("/:id/candidates", r.MatchController.GetCandidates)
+	g.GET("/:id/recommended_to", r.MatchController.RecommendedTo)
 	g.GET("/:id/compare/:candidateId", r.MatchController.Compare)
+	g.GET("/:id/similar", r.ClientController.Similar)
+	g.GET("/:id/candidates/:candidateId/explain", r.MatchController.ExplainCandidate)
+	// 候选人负反馈：拒绝/屏蔽/暂不考虑后不再推荐该配对
+	g.POST("/:id/candidates/:candidateId/reject", r.MatchController.RejectCandidate)
+	g.GET("/:id/pair_history", r.MatchController.ListPairHistory)
+	g.DELETE("/pair_history/:id", r.MatchController.RevokePairHistory)
+	// 资料变更历史与恢复
+	g.GET("/:id/history", r.ClientController.History)
+	g.POST("/:id/history/restore", r.ClientController.Restore)
+	// 回收站：删除的客户保留至定时任务到期清理，管理员可提前彻底删除
+	g.GET("/trash", r.ClientController.Trash)
+	g.POST("/trash/:id/restore", r.ClientController.Undelete)
+	g.DELETE("/trash/:id", r.ClientController.Purge)
+	// 客户标签：替换时保留已有标签的来源与置信度
+	g.GET("/:id/tags", r.TagController.ClientTags)
+	g.POST("/:id/tags", r.TagController.SetClientTags)
 
 	// Phase 1: Claim/Release (Hidden for Single Mode but kept for compatibility)
 	g.POST("/claim", r.ClientController.Claim)
@@ -147,6 +284,13 @@ func (r *Router) client(g *gin.RouterGroup) {
 	g.POST("/import/batch", r.ClientController.ImportBatch)
 }
 
+func (r *Router) questionnaire(g *gin.RouterGroup) {
+	g.GET("/list", r.QuestionnaireController.List)
+	g.POST("/create", r.QuestionnaireController.Create)
+	g.POST("/issue", r.QuestionnaireController.Issue)
+	g.GET("/profile/:client_id", r.QuestionnaireController.Profile)
+}
+
 func (r *Router) reminder(g *gin.RouterGroup) {
 	g.GET("/list", r.ReminderController.List)
 	g.GET("/today", r.ReminderController.TodayList)
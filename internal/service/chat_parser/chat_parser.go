@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	biz_omiai "omiai-server/internal/biz/omiai"
 	"omiai-server/internal/conf"
 	"strings"
 	"time"
@@ -225,16 +226,11 @@ func (p *ChatParser) Parse(content string) ([]ImportRecord, error) {
 }
 
 func calculateAge(birthday string) int {
-	if len(birthday) < 7 {
-		return 0
-	}
-	birthYear := 0
-	fmt.Sscanf(birthday[:4], "%d", &birthYear)
-	if birthYear == 0 {
+	b, ok := biz_omiai.ParseBirthday(birthday)
+	if !ok {
 		return 0
 	}
-	currentYear := time.Now().Year()
-	return currentYear - birthYear
+	return b.Age(time.Now())
 }
 
 func buildParsePrompt() string {
@@ -0,0 +1,238 @@
+package daily_recommendation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"omiai-server/internal/biz"
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/service/experiment"
+
+	"github.com/iWuxc/go-wit/log"
+)
+
+// maxStatsDays 统计查询的最大天数
+const maxStatsDays = 90
+
+type Service struct {
+	repo        biz_omiai.DailyRecommendationInterface
+	client      biz_omiai.ClientInterface
+	match       biz_omiai.MatchInterface
+	pairs       biz_omiai.PairHistoryInterface
+	intro       biz_omiai.IntroductionInterface
+	scorer      biz_omiai.Scorer
+	experiments *experiment.Service
+}
+
+func NewService(repo biz_omiai.DailyRecommendationInterface, client biz_omiai.ClientInterface, match biz_omiai.MatchInterface,
+	pairs biz_omiai.PairHistoryInterface, intro biz_omiai.IntroductionInterface, scorer biz_omiai.Scorer,
+	experiments *experiment.Service) *Service {
+	return &Service{repo: repo, client: client, match: match, pairs: pairs, intro: intro, scorer: scorer, experiments: experiments}
+}
+
+// Generate 为每位单身客户从候选池挑选得分最高、近期未推荐过的候选人
+// 候选池已排除负反馈配对、冷静期客户与不满足择偶要求的候选人；当天已生成过的客户跳过，此前未处理的推荐置为过期
+func (s *Service) Generate(ctx context.Context, opts *biz_omiai.DailyOptions) (*biz_omiai.DailySummary, error) {
+	if opts.Date.IsZero() {
+		opts.Date = time.Now()
+	}
+	if opts.Count <= 0 {
+		opts.Count = biz_omiai.DefaultDailyCount
+	}
+	if opts.FreshDays <= 0 {
+		opts.FreshDays = biz_omiai.DefaultDailyFreshDays
+	}
+	date := opts.Date.Format(biz_omiai.DailyDateLayout)
+	summary := &biz_omiai.DailySummary{Date: date}
+
+	expired, err := s.repo.Expire(ctx, date)
+	if err != nil {
+		return nil, err
+	}
+	summary.Expired = expired
+
+	clients, err := s.client.Select(ctx, &biz.WhereClause{
+		Where:   "status = ? AND (cooldown_until IS NULL OR cooldown_until <= ?)",
+		Args:    []interface{}{biz_omiai.ClientStatusSingle, opts.Date},
+		OrderBy: "id",
+	}, []string{"id"}, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	generated, err := s.repo.GeneratedClients(ctx, date)
+	if err != nil {
+		return nil, err
+	}
+	since := opts.Date.AddDate(0, 0, -opts.FreshDays).Format(biz_omiai.DailyDateLayout)
+	recent, err := s.repo.RecentCandidates(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+
+	summary.Clients = len(clients)
+	for _, c := range clients {
+		if generated[c.ID] {
+			summary.Skipped++
+			continue
+		}
+		n, err := s.generateFor(ctx, c.ID, date, opts.Count, recent[c.ID])
+		if err != nil {
+			// 单个客户失败不影响其他客户
+			log.WithContext(ctx).Errorf("daily recommendation: client %d err:%v", c.ID, err)
+			continue
+		}
+		summary.Generated += n
+	}
+	return summary, nil
+}
+
+func (s *Service) generateFor(ctx context.Context, clientID uint64, date string, count int, recent map[uint64]bool) (int, error) {
+	assignment := s.experiments.Assign(clientID, 0)
+	// 多取近期已推荐过的数量，过滤后仍能取满
+	candidates, _, err := s.match.GetCandidates(ctx, &biz_omiai.CandidateQuery{
+		ClientID: clientID,
+		Scorer:   assignment.ScorerOr(nil),
+		Limit:    count + len(recent),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	algorithm := assignment.ScorerOr(s.scorer).Name()
+	list := make([]*biz_omiai.DailyRecommendation, 0, count)
+	exposed := make([]experiment.Exposed, 0, count)
+	for _, c := range candidates {
+		if recent[c.CandidateID] {
+			continue
+		}
+		tags, _ := json.Marshal(c.Tags)
+		list = append(list, &biz_omiai.DailyRecommendation{
+			Date:        date,
+			ClientID:    clientID,
+			CandidateID: c.CandidateID,
+			Rank:        len(list) + 1,
+			Score:       c.MatchScore,
+			Tags:        string(tags),
+			Algorithm:   algorithm,
+			Status:      biz_omiai.DailyStatusPending,
+		})
+		exposed = append(exposed, experiment.Exposed{CandidateID: c.CandidateID, Score: c.MatchScore})
+		if len(list) == count {
+			break
+		}
+	}
+	if err := s.repo.Create(ctx, list); err != nil {
+		return 0, err
+	}
+	s.experiments.LogExposures(ctx, assignment, biz_omiai.ExposureSourceDaily, clientID, 0, 0, exposed)
+	return len(list), nil
+}
+
+// Accept 采纳推荐：以该配对发起介绍，客户为先征询方，双方须仍为单身
+func (s *Service) Accept(ctx context.Context, id, matchmakerID uint64, operator, remark string) (*biz_omiai.Introduction, error) {
+	d, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if d.Status != biz_omiai.DailyStatusPending {
+		return nil, biz_omiai.ErrDailyHandled
+	}
+
+	intro := &biz_omiai.Introduction{
+		ClientAID:    d.ClientID,
+		ClientBID:    d.CandidateID,
+		MatchmakerID: matchmakerID,
+		Remark:       remark,
+	}
+	// 与生成推荐时相同的分组评分器，介绍的匹配分与推荐得分一致
+	if err := s.intro.Create(ctx, intro, operator, s.experiments.Assign(d.ClientID, 0).ScorerOr(nil)); err != nil {
+		return nil, err
+	}
+	err = s.repo.Handle(ctx, id, &biz_omiai.DailyHandle{
+		Status:         biz_omiai.DailyStatusAccepted,
+		IntroductionID: &intro.ID,
+		Operator:       operator,
+		Remark:         remark,
+	})
+	if err != nil {
+		// 推荐已被他人处理，撤回刚发起的介绍
+		if _, closeErr := s.intro.Transition(ctx, intro.ID, biz_omiai.IntroStatusClosed, &biz_omiai.IntroductionChange{
+			Operator:    operator,
+			CloseReason: biz_omiai.IntroCloseCancelled,
+			Remark:      fmt.Sprintf("每日推荐 %d 处理冲突", id),
+		}); closeErr != nil {
+			log.WithContext(ctx).Errorf("daily recommendation: close introduction %d err:%v", intro.ID, closeErr)
+		}
+		return nil, err
+	}
+	return intro, nil
+}
+
+// Skip 跳过推荐，近期不再推荐该候选人；填写原因时记录为拒绝，之后各推荐来源均不再推荐该配对
+func (s *Service) Skip(ctx context.Context, id uint64, reasonCode, operator, remark string) error {
+	if reasonCode != "" {
+		if _, ok := biz_omiai.PairReasonText[reasonCode]; !ok {
+			return biz_omiai.ErrPairReason
+		}
+	}
+	d, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Handle(ctx, id, &biz_omiai.DailyHandle{
+		Status:   biz_omiai.DailyStatusSkipped,
+		Operator: operator,
+		Remark:   remark,
+	}); err != nil {
+		return err
+	}
+	if reasonCode == "" {
+		return nil
+	}
+	return s.pairs.Create(ctx, &biz_omiai.PairHistory{
+		ClientID:    d.ClientID,
+		CandidateID: d.CandidateID,
+		Kind:        biz_omiai.PairKindDeclined,
+		ReasonCode:  reasonCode,
+		Remark:      remark,
+		SourceType:  biz_omiai.PairSourceDaily,
+		SourceID:    id,
+		Operator:    operator,
+	})
+}
+
+// DailyStatView 某一天的处理情况及采纳率
+type DailyStatView struct {
+	*biz_omiai.DailyStat
+	AcceptRate float64 `json:"accept_rate"`
+}
+
+// Stats 最近 days 天（含今天）的推荐处理情况，没有推荐的日期不返回
+func (s *Service) Stats(ctx context.Context, days int, now time.Time) ([]*DailyStatView, *DailyStatView, error) {
+	if days <= 0 {
+		days = 7
+	}
+	if days > maxStatsDays {
+		days = maxStatsDays
+	}
+	from := now.AddDate(0, 0, 1-days).Format(biz_omiai.DailyDateLayout)
+	list, err := s.repo.Stats(ctx, from, now.Format(biz_omiai.DailyDateLayout))
+	if err != nil {
+		return nil, nil, err
+	}
+	// 合计中 Clients 为各天推荐客户数之和
+	total := &biz_omiai.DailyStat{Date: from}
+	out := make([]*DailyStatView, 0, len(list))
+	for _, d := range list {
+		out = append(out, &DailyStatView{DailyStat: d, AcceptRate: d.AcceptRate()})
+		total.Clients += d.Clients
+		total.Total += d.Total
+		total.Pending += d.Pending
+		total.Accepted += d.Accepted
+		total.Skipped += d.Skipped
+		total.Expired += d.Expired
+	}
+	return out, &DailyStatView{DailyStat: total, AcceptRate: total.AcceptRate()}, nil
+}
@@ -0,0 +1,120 @@
+package daily_recommendation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"omiai-server/internal/biz"
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/data"
+	"omiai-server/internal/data/omiai"
+	"omiai-server/internal/service/event"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// tableScorer 按预设得分表评分，未列出的配对 10 分
+type tableScorer map[[2]uint64]int
+
+func (s tableScorer) Name() string { return "table" }
+
+func (s tableScorer) Score(client, candidate *biz_omiai.Client) *biz_omiai.ScoreResult {
+	score, ok := s[[2]uint64{client.ID, candidate.ID}]
+	if !ok {
+		score = 10
+	}
+	return &biz_omiai.ScoreResult{Score: score, Algorithm: s.Name(), Tags: []string{"测试"}}
+}
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	assert.NoError(t, db.AutoMigrate(&biz_omiai.Client{}, &biz_omiai.PersonalityProfile{}, &biz_omiai.ClientEmbedding{}, &biz_omiai.ClientTag{}, &biz_omiai.Tag{}, &biz_omiai.TagGroup{}, &biz_omiai.PairHistory{},
+		&biz_omiai.Recommendation{}, &biz_omiai.MatchRecord{}, &biz_omiai.Introduction{}, &biz_omiai.IntroductionHistory{},
+		&biz_omiai.DailyRecommendation{}))
+
+	// 1 为男方，2 至 5 为女方
+	for i := 1; i <= 5; i++ {
+		gender := int8(2)
+		if i == 1 {
+			gender = 1
+		}
+		assert.NoError(t, db.Create(&biz_omiai.Client{ID: uint64(i), Gender: gender, Status: biz_omiai.ClientStatusSingle}).Error)
+	}
+	scorer := tableScorer{{1, 2}: 90, {1, 3}: 80, {1, 4}: 70, {1, 5}: 60}
+
+	d := &data.DB{DB: db}
+	bus := event.NewBus()
+	repo := omiai.NewDailyRecommendationRepo(d)
+	pairs := omiai.NewPairHistoryRepo(d)
+	svc := NewService(repo, omiai.NewClientRepo(d, bus), omiai.NewMatchRepo(d, scorer, bus), pairs,
+		omiai.NewIntroductionRepo(d, scorer, bus), scorer, nil)
+	forClient := func(date string, clientID uint64) []*biz_omiai.DailyRecommendation {
+		list, _, err := repo.Select(ctx, &biz.WhereClause{Where: "rec_date = ? AND client_id = ?", Args: []interface{}{date, clientID}}, 0, 0)
+		assert.NoError(t, err)
+		return list
+	}
+
+	day1 := time.Date(2024, 5, 1, 6, 0, 0, 0, time.Local)
+	summary, err := svc.Generate(ctx, &biz_omiai.DailyOptions{Date: day1, Count: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, summary.Clients)
+	// 男方推荐得分最高的 2、3，每位女方推荐男方 1
+	assert.Equal(t, 6, summary.Generated)
+	first := forClient("2024-05-01", 1)
+	assert.Len(t, first, 2)
+	assert.Equal(t, uint64(2), first[0].CandidateID)
+	assert.Equal(t, 1, first[0].Rank)
+	assert.Equal(t, []string{"测试"}, first[0].TagList())
+
+	// 同一天再次生成跳过已生成的客户
+	summary, err = svc.Generate(ctx, &biz_omiai.DailyOptions{Date: day1, Count: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, summary.Skipped)
+	assert.Zero(t, summary.Generated)
+
+	// 次日：前一天未处理的推荐过期，近期推荐过的候选人不再推荐
+	day2 := day1.AddDate(0, 0, 1)
+	summary, err = svc.Generate(ctx, &biz_omiai.DailyOptions{Date: day2, Count: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6), summary.Expired)
+	assert.Equal(t, 2, summary.Generated)
+	second := forClient("2024-05-02", 1)
+	assert.Len(t, second, 2)
+	assert.Equal(t, uint64(4), second[0].CandidateID)
+	assert.Equal(t, uint64(5), second[1].CandidateID)
+
+	_, err = svc.Accept(ctx, first[0].ID, 7, "红娘", "")
+	assert.ErrorIs(t, err, biz_omiai.ErrDailyHandled)
+
+	// 采纳发起介绍，不生成情侣档案
+	intro, err := svc.Accept(ctx, second[0].ID, 7, "红娘", "")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), intro.ClientAID)
+	assert.Equal(t, uint64(4), intro.ClientBID)
+	var records int64
+	db.Model(&biz_omiai.MatchRecord{}).Count(&records)
+	assert.Zero(t, records)
+
+	// 填写原因的跳过记录为拒绝
+	assert.ErrorIs(t, svc.Skip(ctx, second[1].ID, "unknown", "红娘", ""), biz_omiai.ErrPairReason)
+	assert.NoError(t, svc.Skip(ctx, second[1].ID, biz_omiai.PairReasonRegion, "红娘", "异地"))
+	history, err := pairs.List(ctx, 1)
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+	assert.Equal(t, biz_omiai.PairSourceDaily, history[0].SourceType)
+
+	stats, total, err := svc.Stats(ctx, 7, day2)
+	assert.NoError(t, err)
+	assert.Len(t, stats, 2)
+	assert.Equal(t, int64(1), total.Accepted)
+	assert.Equal(t, int64(1), total.Skipped)
+	assert.Equal(t, int64(6), total.Expired)
+	assert.Equal(t, 12.5, total.AcceptRate)
+}
@@ -0,0 +1,151 @@
+package duplicate
+
+import (
+	"context"
+	"fmt"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+
+	"github.com/iWuxc/go-wit/log"
+	"gorm.io/gorm"
+)
+
+const (
+	// scanBatch 全量查重每批读取的客户数
+	scanBatch = 1000
+	// maxBucket 同一出生年月或工作单位下的档案过多时不再两两比较，避免全量查重退化为平方级
+	maxBucket = 500
+)
+
+type Service struct {
+	repo biz_omiai.DuplicateInterface
+}
+
+func NewService(repo biz_omiai.DuplicateInterface) *Service {
+	return &Service{repo: repo}
+}
+
+// ScanSummary 全量查重结果
+type ScanSummary struct {
+	Clients int `json:"clients"` // 参与查重的客户数
+	Pairs   int `json:"pairs"`   // 达到阈值的疑似重复
+	Created int `json:"created"` // 新进入审核队列的疑似重复
+}
+
+// Detect 查找与 c 疑似重复的档案并写入审核队列，用于新建和导入后
+func (s *Service) Detect(ctx context.Context, c *biz_omiai.Client) ([]*biz_omiai.ClientDuplicate, error) {
+	candidates, err := s.repo.Candidates(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	var list []*biz_omiai.ClientDuplicate
+	for _, candidate := range candidates {
+		if score, signals := biz_omiai.ScoreDuplicate(c, candidate); score >= biz_omiai.DuplicateThreshold {
+			list = append(list, biz_omiai.NewClientDuplicate(c.ID, candidate.ID, score, signals))
+		}
+	}
+	if len(list) == 0 {
+		return nil, nil
+	}
+	if _, err := s.repo.Save(ctx, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Scan 全量查重：按手机号、出生年月、照片与工作单位分桶，只比较同一桶内的档案
+func (s *Service) Scan(ctx context.Context) (*ScanSummary, error) {
+	var clients []*biz_omiai.Client
+	for afterID := uint64(0); ; {
+		batch, err := s.repo.Fingerprints(ctx, afterID, scanBatch)
+		if err != nil {
+			return nil, err
+		}
+		clients = append(clients, batch...)
+		if len(batch) < scanBatch {
+			break
+		}
+		afterID = batch[len(batch)-1].ID
+	}
+
+	buckets := make(map[string][]*biz_omiai.Client)
+	for _, c := range clients {
+		var keys []string
+		if p := biz_omiai.NormalizePhone(c.Phone); len(p) >= 7 {
+			keys = append(keys, "phone:"+p)
+		}
+		if month := biz_omiai.BirthMonth(c.Birthday); month != "" {
+			keys = append(keys, fmt.Sprintf("birth:%d:%s", c.Gender, month))
+		}
+		for _, h := range biz_omiai.PhotoHashes(c) {
+			keys = append(keys, "photo:"+h)
+		}
+		if unit := biz_omiai.NormalizeWorkUnit(c.WorkUnit); unit != "" {
+			keys = append(keys, fmt.Sprintf("unit:%d:%s", c.Gender, unit))
+		}
+		for _, key := range keys {
+			buckets[key] = append(buckets[key], c)
+		}
+	}
+
+	seen := make(map[[2]uint64]bool)
+	var list []*biz_omiai.ClientDuplicate
+	for key, bucket := range buckets {
+		if len(bucket) > maxBucket {
+			log.WithContext(ctx).Warnf("duplicate scan skip bucket:%s size:%d", key, len(bucket))
+			continue
+		}
+		for i := 0; i < len(bucket); i++ {
+			for j := i + 1; j < len(bucket); j++ {
+				a, b := bucket[i], bucket[j]
+				pair := [2]uint64{a.ID, b.ID}
+				if seen[pair] {
+					continue
+				}
+				seen[pair] = true
+				if score, signals := biz_omiai.ScoreDuplicate(a, b); score >= biz_omiai.DuplicateThreshold {
+					list = append(list, biz_omiai.NewClientDuplicate(a.ID, b.ID, score, signals))
+				}
+			}
+		}
+	}
+
+	created, err := s.repo.Save(ctx, list)
+	if err != nil {
+		return nil, err
+	}
+	return &ScanSummary{Clients: len(clients), Pairs: len(list), Created: created}, nil
+}
+
+// Merge 合并两份档案，picks 指定字段取自哪份档案，未指定时保留档案为空的字段取另一份的值
+func (s *Service) Merge(ctx context.Context, id, survivorID uint64, picks map[string]uint64, operator string) error {
+	d, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if d.Status != biz_omiai.DuplicateStatusPending {
+		return biz_omiai.ErrDuplicateStatus
+	}
+	// 任一档案已删除时预加载为空
+	if d.ClientA == nil || d.ClientB == nil {
+		return gorm.ErrRecordNotFound
+	}
+	survivor, merged := d.ClientA, d.ClientB
+	switch survivorID {
+	case d.ClientAID:
+	case d.ClientBID:
+		survivor, merged = merged, survivor
+	default:
+		return biz_omiai.ErrDuplicateSurvivor
+	}
+	values, err := biz_omiai.MergeValues(survivor, merged, picks)
+	if err != nil {
+		return err
+	}
+	return s.repo.Merge(ctx, id, survivorID, values, operator)
+}
+
+// Ignore 标记为非同一人，之后不再进入审核队列
+func (s *Service) Ignore(ctx context.Context, id uint64, operator string) error {
+	return s.repo.Ignore(ctx, id, operator)
+}
@@ -0,0 +1,162 @@
+package duplicate
+
+import (
+	"context"
+	"testing"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/data"
+	"omiai-server/internal/data/omiai"
+	"omiai-server/internal/service/event"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// models 合并涉及的全部表
+var models = []interface{}{&biz_omiai.Client{}, &biz_omiai.PersonalityProfile{}, &biz_omiai.ClientEmbedding{}, &biz_omiai.ClientTag{}, &biz_omiai.Tag{}, &biz_omiai.TagGroup{}, &biz_omiai.ClientVersion{},
+	&biz_omiai.ClientDuplicate{}, &biz_omiai.MatchRecord{}, &biz_omiai.MatchStatusHistory{}, &biz_omiai.FollowUpRecord{}, &biz_omiai.ReminderTask{},
+	&biz_omiai.Introduction{}, &biz_omiai.IntroductionHistory{}, &biz_omiai.Meeting{}, &biz_omiai.PairHistory{}, &biz_omiai.Proposal{},
+	&biz_omiai.PartyAttendee{}, &biz_omiai.PartySeat{}, &biz_omiai.PartyPick{}}
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	assert.NoError(t, db.AutoMigrate(models...))
+
+	d := &data.DB{DB: db}
+	clients := omiai.NewClientRepo(d, event.NewBus())
+	repo := omiai.NewDuplicateRepo(d, event.NewBus())
+	svc := NewService(repo)
+
+	old := &biz_omiai.Client{Name: "王小明", Gender: 1, Phone: "13800000000", Birthday: "1990-05", Income: 8000, Status: biz_omiai.ClientStatusMatched}
+	other := &biz_omiai.Client{Name: "李四", Gender: 1, Phone: "13700000000", Birthday: "1990-05"}
+	partner := &biz_omiai.Client{Name: "赵六", Gender: 2, Phone: "13600000000"}
+	for _, c := range []*biz_omiai.Client{old, other, partner} {
+		assert.NoError(t, clients.Create(ctx, c))
+	}
+	assert.NoError(t, db.Model(old).Update("partner_id", partner.ID).Error)
+	assert.NoError(t, db.Model(partner).Updates(map[string]interface{}{"partner_id": old.ID, "status": biz_omiai.ClientStatusMatched}).Error)
+	record := &biz_omiai.MatchRecord{MaleClientID: old.ID, FemaleClientID: partner.ID, Status: biz_omiai.MatchStatusDating}
+	assert.NoError(t, db.Create(record).Error)
+	assert.NoError(t, db.Create(&biz_omiai.ReminderTask{ClientID: int64(old.ID), Content: "回访"}).Error)
+
+	// 新建时换号、姓名错一字、生日写法不同仍被检出
+	dup := &biz_omiai.Client{Name: "王晓明", Gender: 1, Phone: "13900000000", Birthday: "1990年5月", WorkUnit: "某某科技"}
+	assert.NoError(t, clients.Create(ctx, dup))
+	list, err := svc.Detect(ctx, dup)
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.Equal(t, old.ID, list[0].ClientAID)
+
+	// 全量查重不重复写入
+	summary, err := svc.Scan(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, summary.Clients)
+	assert.Equal(t, 1, summary.Pairs)
+	assert.Equal(t, 0, summary.Created)
+
+	// 保留新档案，手机号取新档案，空字段以外默认保留新档案的值，收入取旧档案
+	assert.ErrorIs(t, svc.Merge(ctx, list[0].ID, other.ID, nil, "红娘"), biz_omiai.ErrDuplicateSurvivor)
+	assert.NoError(t, svc.Merge(ctx, list[0].ID, dup.ID, map[string]uint64{"income": old.ID}, "红娘"))
+	assert.ErrorIs(t, svc.Merge(ctx, list[0].ID, dup.ID, nil, "红娘"), biz_omiai.ErrDuplicateStatus)
+
+	got, err := clients.Get(ctx, dup.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "13900000000", got.Phone)
+	assert.Equal(t, 8000, got.Income)
+	assert.Equal(t, "某某科技", got.WorkUnit)
+	assert.Equal(t, int8(biz_omiai.ClientStatusMatched), got.Status)
+	assert.Equal(t, partner.ID, *got.PartnerID)
+	got, _ = clients.Get(ctx, partner.ID)
+	assert.Equal(t, dup.ID, *got.PartnerID)
+	_, err = clients.Get(ctx, old.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	var moved biz_omiai.MatchRecord
+	assert.NoError(t, db.First(&moved, record.ID).Error)
+	assert.Equal(t, dup.ID, moved.MaleClientID)
+	var task biz_omiai.ReminderTask
+	assert.NoError(t, db.First(&task).Error)
+	assert.Equal(t, int64(dup.ID), task.ClientID)
+
+	versions, _, err := clients.Versions(ctx, dup.ID, 0, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, biz_omiai.ClientSourceMerge, versions[0].Source)
+	assert.Equal(t, "红娘", versions[0].Operator)
+}
+
+func TestService_MergeOpen(t *testing.T) {
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	assert.NoError(t, db.AutoMigrate(models...))
+
+	d := &data.DB{DB: db}
+	bus := event.NewBus()
+	var changed []uint64
+	bus.Subscribe(biz_omiai.EventClientChanged, func(ctx context.Context, e *biz_omiai.Event) {
+		changed = append(changed, e.Payload.(*biz_omiai.ClientChanged).ClientIDs...)
+	})
+	clients := omiai.NewClientRepo(d, bus)
+	svc := NewService(omiai.NewDuplicateRepo(d, bus))
+
+	merged := &biz_omiai.Client{Name: "王小明", Gender: 1, Phone: "13800000000", Status: biz_omiai.ClientStatusMatching}
+	survivor := &biz_omiai.Client{Name: "王晓明", Gender: 1, Phone: "13900000000"}
+	other := &biz_omiai.Client{Name: "赵六", Gender: 2, Phone: "13600000000", Status: biz_omiai.ClientStatusMatching}
+	for _, c := range []*biz_omiai.Client{merged, survivor, other} {
+		assert.NoError(t, clients.Create(ctx, c))
+	}
+	intro := &biz_omiai.Introduction{ClientAID: merged.ID, ClientBID: other.ID, Status: biz_omiai.IntroStatusScheduled}
+	assert.NoError(t, db.Create(intro).Error)
+	meeting := &biz_omiai.Meeting{IntroductionID: &intro.ID, ClientAID: merged.ID, ClientBID: other.ID, Status: biz_omiai.MeetingStatusBooked}
+	assert.NoError(t, db.Create(meeting).Error)
+	assert.NoError(t, db.Create(&biz_omiai.ReminderTask{ClientID: int64(other.ID), MeetingID: meeting.ID, Status: "pending"}).Error)
+	assert.NoError(t, db.Create(&biz_omiai.PairHistory{ClientID: other.ID, CandidateID: merged.ID}).Error)
+	assert.NoError(t, db.Create(&biz_omiai.Proposal{MaleClientID: merged.ID, FemaleClientID: other.ID}).Error)
+	assert.NoError(t, db.Create([]*biz_omiai.PartyAttendee{{PartyID: 1, ClientID: merged.ID}, {PartyID: 1, ClientID: survivor.ID}, {PartyID: 2, ClientID: merged.ID}}).Error)
+	assert.NoError(t, db.Create([]*biz_omiai.PartyPick{{PartyID: 1, ClientID: merged.ID, PickedID: other.ID}, {PartyID: 1, ClientID: survivor.ID, PickedID: other.ID}, {PartyID: 2, ClientID: other.ID, PickedID: merged.ID}}).Error)
+	dup := &biz_omiai.ClientDuplicate{ClientAID: merged.ID, ClientBID: survivor.ID, Status: biz_omiai.DuplicateStatusPending}
+	assert.NoError(t, db.Create(dup).Error)
+
+	// 被合并档案进行中的介绍关闭、见面取消，对方恢复单身；其余记录改指向保留档案
+	changed = nil
+	assert.NoError(t, svc.Merge(ctx, dup.ID, survivor.ID, nil, "红娘"))
+	assert.ElementsMatch(t, []uint64{merged.ID, survivor.ID, other.ID}, changed)
+
+	assert.NoError(t, db.First(intro, intro.ID).Error)
+	assert.Equal(t, int8(biz_omiai.IntroStatusClosed), intro.Status)
+	assert.Equal(t, biz_omiai.IntroCloseCancelled, intro.CloseReason)
+	assert.NoError(t, db.First(meeting, meeting.ID).Error)
+	assert.Equal(t, int8(biz_omiai.MeetingStatusCancelled), meeting.Status)
+	var task biz_omiai.ReminderTask
+	assert.NoError(t, db.First(&task).Error)
+	assert.Equal(t, "cancelled", task.Status)
+	got, err := clients.Get(ctx, other.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int8(biz_omiai.ClientStatusSingle), got.Status)
+
+	var history biz_omiai.PairHistory
+	assert.NoError(t, db.First(&history).Error)
+	assert.Equal(t, survivor.ID, history.CandidateID)
+	var proposal biz_omiai.Proposal
+	assert.NoError(t, db.First(&proposal).Error)
+	assert.Equal(t, survivor.ID, proposal.MaleClientID)
+	var attendees []*biz_omiai.PartyAttendee
+	assert.NoError(t, db.Order("party_id").Find(&attendees).Error)
+	assert.Len(t, attendees, 2)
+	for _, a := range attendees {
+		assert.Equal(t, survivor.ID, a.ClientID)
+	}
+	var picks []*biz_omiai.PartyPick
+	assert.NoError(t, db.Order("party_id").Find(&picks).Error)
+	assert.Len(t, picks, 2)
+	assert.Equal(t, survivor.ID, picks[0].ClientID)
+	assert.Equal(t, survivor.ID, picks[1].PickedID)
+}
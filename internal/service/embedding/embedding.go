@@ -0,0 +1,267 @@
+package embedding
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/conf"
+
+	"github.com/iWuxc/go-wit/log"
+	"gorm.io/gorm"
+)
+
+const (
+	ProviderTFIDF  = "tfidf"
+	ProviderRemote = "remote"
+)
+
+// DefaultSimilarLimit 相似客户默认返回数量
+const DefaultSimilarLimit = 10
+
+// NewProvider 按配置创建向量化实现，未配置或远程接口配置不完整时使用离线 TF-IDF
+func NewProvider(c *conf.Config) biz_omiai.EmbeddingProvider {
+	if c == nil || c.Embedding == nil || c.Embedding.Provider != ProviderRemote {
+		return NewTFIDFProvider()
+	}
+	e := c.Embedding
+	if e.Endpoint == "" || e.Model == "" {
+		log.Warn("embedding: remote endpoint or model not configured, using tfidf provider")
+		return NewTFIDFProvider()
+	}
+	return NewRemoteProvider(e.Endpoint, e.APIKey, e.Model, time.Duration(e.Timeout)*time.Second)
+}
+
+type Service struct {
+	repo     biz_omiai.EmbeddingInterface
+	provider biz_omiai.EmbeddingProvider
+	// fitMu 串行化语料训练，避免并发刷新重复加载全部客户文本
+	fitMu sync.Mutex
+}
+
+// NewService 创建服务并订阅客户变更事件：资料变更刷新向量，删除客户时清理向量
+func NewService(c *conf.Config, repo biz_omiai.EmbeddingInterface, events biz_omiai.EventBus) *Service {
+	s := &Service{repo: repo, provider: NewProvider(c)}
+	events.Subscribe(biz_omiai.EventClientChanged, s.onClientChanged)
+	return s
+}
+
+// Model 当前向量模型标识
+func (s *Service) Model() string {
+	return s.provider.Name()
+}
+
+// Rebuild 以全部客户文本重新训练并刷新所有向量，返回处理的客户数
+func (s *Service) Rebuild(ctx context.Context) (int, error) {
+	clients, err := s.repo.Texts(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	if fitter, ok := s.provider.(biz_omiai.EmbeddingCorpusFitter); ok {
+		s.fitMu.Lock()
+		fitter.Fit(texts(clients))
+		s.fitMu.Unlock()
+	}
+	if err := s.save(ctx, clients); err != nil {
+		return 0, err
+	}
+	return len(clients), nil
+}
+
+// Refresh 刷新指定客户的向量，文本与模型均未变化的客户跳过
+func (s *Service) Refresh(ctx context.Context, clientIDs []uint64) error {
+	if len(clientIDs) == 0 {
+		return nil
+	}
+	if err := s.ensureFitted(ctx); err != nil {
+		return err
+	}
+	clients, err := s.repo.Texts(ctx, clientIDs)
+	if err != nil {
+		return err
+	}
+	changed := make([]*biz_omiai.Client, 0, len(clients))
+	for _, c := range clients {
+		old, err := s.repo.Get(ctx, c.ID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if old != nil && old.Model == s.Model() && old.TextHash == textHash(c.EmbeddingText()) {
+			continue
+		}
+		changed = append(changed, c)
+	}
+	return s.save(ctx, changed)
+}
+
+// ensureFitted 进程启动后首次刷新前以全部客户文本训练，之后由每日全量重建更新
+func (s *Service) ensureFitted(ctx context.Context) error {
+	fitter, ok := s.provider.(biz_omiai.EmbeddingCorpusFitter)
+	if !ok {
+		return nil
+	}
+	s.fitMu.Lock()
+	defer s.fitMu.Unlock()
+	if fitter.Fitted() {
+		return nil
+	}
+	clients, err := s.repo.Texts(ctx, nil)
+	if err != nil {
+		return err
+	}
+	fitter.Fit(texts(clients))
+	return nil
+}
+
+func (s *Service) save(ctx context.Context, clients []*biz_omiai.Client) error {
+	if len(clients) == 0 {
+		return nil
+	}
+	docs := texts(clients)
+	vectors, err := s.provider.Embed(ctx, docs)
+	if err != nil {
+		return err
+	}
+	list := make([]*biz_omiai.ClientEmbedding, 0, len(clients))
+	for i, c := range clients {
+		e := &biz_omiai.ClientEmbedding{ClientID: c.ID, Model: s.Model(), TextHash: textHash(docs[i])}
+		e.SetValues(vectors[i])
+		list = append(list, e)
+	}
+	return s.repo.Upsert(ctx, list)
+}
+
+// Similar 与指定客户资料文本最相似的客户，gender 为 0 时不限性别
+func (s *Service) Similar(ctx context.Context, clientID uint64, gender int8, limit int) ([]*biz_omiai.SimilarClient, error) {
+	if limit <= 0 {
+		limit = DefaultSimilarLimit
+	}
+	target, err := s.current(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	vector := target.Values()
+	out := make([]*biz_omiai.SimilarClient, 0, limit)
+	if len(vector) == 0 {
+		return out, nil
+	}
+
+	all, err := s.repo.List(ctx, s.Model())
+	if err != nil {
+		return nil, err
+	}
+	type ranked struct {
+		clientID   uint64
+		similarity float64
+	}
+	var candidates []ranked
+	for _, e := range all {
+		if e.ClientID == clientID {
+			continue
+		}
+		if sim := vector.Cosine(e.Values()); sim > 0 {
+			candidates = append(candidates, ranked{clientID: e.ClientID, similarity: sim})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].similarity != candidates[j].similarity {
+			return candidates[i].similarity > candidates[j].similarity
+		}
+		return candidates[i].clientID < candidates[j].clientID
+	})
+
+	// 按相似度分批加载客户资料，过滤性别后取满 limit 个
+	batch := limit * 2
+	for start := 0; start < len(candidates) && len(out) < limit; start += batch {
+		end := start + batch
+		if end > len(candidates) {
+			end = len(candidates)
+		}
+		ids := make([]uint64, 0, end-start)
+		for _, c := range candidates[start:end] {
+			ids = append(ids, c.clientID)
+		}
+		clients, err := s.repo.Texts(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		byID := make(map[uint64]*biz_omiai.Client, len(clients))
+		for _, c := range clients {
+			byID[c.ID] = c
+		}
+		for _, r := range candidates[start:end] {
+			c := byID[r.clientID]
+			if c == nil || (gender != 0 && c.Gender != gender) {
+				continue
+			}
+			out = append(out, &biz_omiai.SimilarClient{
+				ClientID:   c.ID,
+				Name:       c.Name,
+				Gender:     c.Gender,
+				Age:        c.RealAge(),
+				Avatar:     c.Avatar,
+				Status:     c.Status,
+				Similarity: round4(r.similarity),
+			})
+			if len(out) == limit {
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
+// current 客户当前模型的向量，缺失或模型已更换时先刷新
+func (s *Service) current(ctx context.Context, clientID uint64) (*biz_omiai.ClientEmbedding, error) {
+	e, err := s.repo.Get(ctx, clientID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if e != nil && e.Model == s.Model() {
+		return e, nil
+	}
+	if err := s.Refresh(ctx, []uint64{clientID}); err != nil {
+		return nil, err
+	}
+	return s.repo.Get(ctx, clientID)
+}
+
+func (s *Service) onClientChanged(ctx context.Context, event *biz_omiai.Event) {
+	payload, ok := event.Payload.(*biz_omiai.ClientChanged)
+	if !ok {
+		return
+	}
+	var err error
+	switch payload.Change {
+	case biz_omiai.ClientChangeProfile:
+		err = s.Refresh(ctx, payload.ClientIDs)
+	case biz_omiai.ClientChangeDeleted:
+		err = s.repo.Delete(ctx, payload.ClientIDs)
+	}
+	if err != nil {
+		log.WithContext(ctx).Errorf("embedding: %s clients %v err:%v", payload.Change, payload.ClientIDs, err)
+	}
+}
+
+func texts(clients []*biz_omiai.Client) []string {
+	out := make([]string, len(clients))
+	for i, c := range clients {
+		out[i] = c.EmbeddingText()
+	}
+	return out
+}
+
+func textHash(text string) string {
+	sum := sha1.Sum([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+func round4(v float64) float64 {
+	return math.Round(v*10000) / 10000
+}
@@ -0,0 +1,105 @@
+package embedding
+
+import (
+	"context"
+	"testing"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/data"
+	"omiai-server/internal/data/omiai"
+	"omiai-server/internal/service/event"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestTFIDF(t *testing.T) {
+	docs := []string{
+		"喜欢旅行和摄影，性格开朗，周末经常去爬山",
+		"性格开朗，爱好旅行摄影，经常周末爬山",
+		"父母都是退休教师，家里有一个弟弟",
+		"在国企上班，工作稳定，不抽烟不喝酒",
+	}
+	p := NewTFIDFProvider()
+	assert.False(t, p.Fitted())
+	p.Fit(docs)
+	assert.True(t, p.Fitted())
+
+	vectors, err := p.Embed(context.Background(), append(docs, ""))
+	assert.NoError(t, err)
+	assert.InDelta(t, 1, vectors[0].Norm(), 1e-4)
+	assert.InDelta(t, 1, vectors[0].Cosine(vectors[0]), 1e-4)
+	assert.Greater(t, vectors[0].Cosine(vectors[1]), 0.4)
+	assert.Less(t, vectors[0].Cosine(vectors[2]), 0.1)
+	assert.Greater(t, vectors[0].Cosine(vectors[1]), vectors[0].Cosine(vectors[3]))
+	assert.Empty(t, vectors[4])
+	assert.Zero(t, vectors[0].Cosine(vectors[4]))
+}
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	assert.NoError(t, db.AutoMigrate(&biz_omiai.Client{}, &biz_omiai.ClientEmbedding{}, &biz_omiai.ClientTag{}, &biz_omiai.Tag{}, &biz_omiai.TagGroup{},
+		&biz_omiai.PersonalityProfile{}, &biz_omiai.ClientVersion{}))
+
+	clients := []*biz_omiai.Client{
+		{ID: 1, Name: "甲", Gender: 1, Remark: "喜欢旅行和摄影，性格开朗", PartnerRequirements: "温柔体贴，爱好旅行"},
+		{ID: 2, Name: "乙", Gender: 2, Remark: "性格开朗，爱好旅行摄影"},
+		{ID: 3, Name: "丙", Gender: 1, Remark: "喜欢摄影，周末去旅行"},
+		{ID: 4, Name: "丁", Gender: 2, FamilyDescription: "父母退休，独生女"},
+		{ID: 5, Name: "戊", Gender: 2, PartnerRequirements: `{"min_age":25}`},
+	}
+	assert.NoError(t, db.Create(clients).Error)
+
+	events := event.NewBus()
+	repo := omiai.NewEmbeddingRepo(&data.DB{DB: db})
+	s := NewService(nil, repo, events)
+	assert.Equal(t, TFIDFModel, s.Model())
+
+	n, err := s.Rebuild(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, n)
+	e5, err := repo.Get(ctx, 5)
+	assert.NoError(t, err)
+	assert.Empty(t, e5.Values(), "结构化择偶要求不参与向量化")
+
+	list, err := s.Similar(ctx, 1, 0, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, list, 2) {
+		assert.ElementsMatch(t, []uint64{2, 3}, []uint64{list[0].ClientID, list[1].ClientID})
+		assert.GreaterOrEqual(t, list[0].Similarity, list[1].Similarity)
+	}
+	list, err = s.Similar(ctx, 1, 2, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, uint64(2), list[0].ClientID)
+	}
+
+	// 资料变更后刷新向量，未变化的客户不重写
+	before, _ := repo.Get(ctx, 2)
+	assert.NoError(t, db.Model(&biz_omiai.Client{}).Where("id = ?", 4).Update("family_description", "喜欢旅行和摄影，性格开朗").Error)
+	events.Publish(ctx, biz_omiai.EventClientChanged, &biz_omiai.ClientChanged{ClientIDs: []uint64{2, 4}, Change: biz_omiai.ClientChangeProfile})
+	after, _ := repo.Get(ctx, 2)
+	assert.Equal(t, before.UpdatedAt, after.UpdatedAt)
+	list, err = s.Similar(ctx, 1, 2, 1)
+	assert.NoError(t, err)
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, uint64(4), list[0].ClientID)
+	}
+
+	events.Publish(ctx, biz_omiai.EventClientChanged, &biz_omiai.ClientChanged{ClientIDs: []uint64{4}, Change: biz_omiai.ClientChangeDeleted})
+	_, err = repo.Get(ctx, 4)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	// 新建客户即生成向量，无需等待夜间重建
+	created := &biz_omiai.Client{Name: "己", Gender: 2, Remark: "喜欢旅行和摄影，性格开朗"}
+	assert.NoError(t, omiai.NewClientRepo(&data.DB{DB: db}, events).Create(ctx, created))
+	e, err := repo.Get(ctx, created.ID)
+	if assert.NoError(t, err) {
+		assert.NotEmpty(t, e.Values())
+	}
+}
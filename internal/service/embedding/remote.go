@@ -0,0 +1,138 @@
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+)
+
+const (
+	defaultRemoteTimeout = 10 * time.Second
+	// remoteBatchSize 单次请求的文本数
+	remoteBatchSize = 32
+)
+
+var _ biz_omiai.EmbeddingProvider = (*RemoteProvider)(nil)
+
+// RemoteProvider 调用 OpenAI 兼容的 /embeddings 接口
+type RemoteProvider struct {
+	Endpoint string
+	APIKey   string
+	Model    string
+	client   *http.Client
+}
+
+func NewRemoteProvider(endpoint, apiKey, model string, timeout time.Duration) *RemoteProvider {
+	if timeout <= 0 {
+		timeout = defaultRemoteTimeout
+	}
+	return &RemoteProvider{Endpoint: endpoint, APIKey: apiKey, Model: model, client: &http.Client{Timeout: timeout}}
+}
+
+// Name 模型标识写入 client_embedding.model，更换模型后旧向量不再参与比较
+func (p *RemoteProvider) Name() string {
+	name := "remote:" + p.Model
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+type remoteRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type remoteResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (p *RemoteProvider) Embed(ctx context.Context, texts []string) ([]biz_omiai.Vector, error) {
+	out := make([]biz_omiai.Vector, 0, len(texts))
+	for start := 0; start < len(texts); start += remoteBatchSize {
+		end := start + remoteBatchSize
+		if end > len(texts) {
+			end = len(texts)
+		}
+		batch, err := p.embedBatch(ctx, texts[start:end])
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, batch...)
+	}
+	return out, nil
+}
+
+func (p *RemoteProvider) embedBatch(ctx context.Context, texts []string) ([]biz_omiai.Vector, error) {
+	out := make([]biz_omiai.Vector, len(texts))
+	// 空文本不请求接口，返回空向量
+	var input []string
+	var index []int
+	for i, t := range texts {
+		if t == "" {
+			out[i] = biz_omiai.Vector{}
+			continue
+		}
+		input = append(input, t)
+		index = append(index, i)
+	}
+	if len(input) == 0 {
+		return out, nil
+	}
+
+	body, _ := json.Marshal(&remoteRequest{Model: p.Model, Input: input})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.APIKey)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("embedding: request %s err:%w", p.Endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("embedding: read response err:%w", err)
+	}
+	var res remoteResponse
+	if err := json.Unmarshal(data, &res); err != nil {
+		return nil, fmt.Errorf("embedding: decode response status:%d err:%w", resp.StatusCode, err)
+	}
+	if res.Error != nil {
+		return nil, fmt.Errorf("embedding: status:%d err:%s", resp.StatusCode, res.Error.Message)
+	}
+	if resp.StatusCode != http.StatusOK || len(res.Data) != len(input) {
+		return nil, fmt.Errorf("embedding: status:%d, %d vectors for %d texts", resp.StatusCode, len(res.Data), len(input))
+	}
+
+	for _, d := range res.Data {
+		if d.Index < 0 || d.Index >= len(index) {
+			return nil, fmt.Errorf("embedding: index %d out of range", d.Index)
+		}
+		v := make(biz_omiai.Vector, len(d.Embedding))
+		for i, x := range d.Embedding {
+			if x != 0 {
+				v[uint32(i)] = x
+			}
+		}
+		out[index[d.Index]] = v
+	}
+	return out, nil
+}
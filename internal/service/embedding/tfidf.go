@@ -0,0 +1,169 @@
+package embedding
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strings"
+	"sync"
+	"unicode"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+)
+
+// TFIDFModel 离线向量模型标识，特征规则变化时需升级以触发全量重建
+const TFIDFModel = "tfidf-ngram-v1"
+
+const (
+	// tfidfBuckets 特征哈希空间，字与词组经哈希映射到固定维度，无需维护词表
+	tfidfBuckets = 1 << 18
+	// unigramWeight 单字特征权重，中文单字区分度低于双字词组
+	unigramWeight = 0.5
+)
+
+// stopChars 不计入单字特征的常见虚词
+var stopChars = map[string]bool{
+	"的": true, "了": true, "和": true, "是": true, "在": true, "有": true, "我": true, "也": true,
+	"就": true, "都": true, "而": true, "及": true, "与": true, "或": true, "很": true, "要": true,
+	"不": true, "人": true, "一": true, "个": true, "能": true, "对": true, "比": true, "较": true,
+}
+
+var _ biz_omiai.EmbeddingProvider = (*TFIDFProvider)(nil)
+var _ biz_omiai.EmbeddingCorpusFitter = (*TFIDFProvider)(nil)
+
+// TFIDFProvider 基于字与相邻双字的 TF-IDF 向量，适合中文短文本，无需分词与外部服务
+// 未训练时各特征 IDF 为 1，退化为词频向量
+type TFIDFProvider struct {
+	mu  sync.RWMutex
+	idf map[uint32]float64
+	n   int
+}
+
+func NewTFIDFProvider() *TFIDFProvider {
+	return &TFIDFProvider{}
+}
+
+func (p *TFIDFProvider) Name() string {
+	return TFIDFModel
+}
+
+// Fit 以全部客户文本统计文档频率
+func (p *TFIDFProvider) Fit(texts []string) {
+	df := make(map[uint32]int)
+	for _, text := range texts {
+		for f := range features(text) {
+			df[f]++
+		}
+	}
+	n := len(texts)
+	idf := make(map[uint32]float64, len(df))
+	for f, c := range df {
+		idf[f] = math.Log(float64(n+1)/float64(c+1)) + 1
+	}
+
+	p.mu.Lock()
+	p.idf, p.n = idf, n
+	p.mu.Unlock()
+}
+
+func (p *TFIDFProvider) Fitted() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.idf != nil
+}
+
+func (p *TFIDFProvider) Embed(_ context.Context, texts []string) ([]biz_omiai.Vector, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	out := make([]biz_omiai.Vector, len(texts))
+	for i, text := range texts {
+		out[i] = p.embed(text)
+	}
+	return out, nil
+}
+
+func (p *TFIDFProvider) embed(text string) biz_omiai.Vector {
+	tf := features(text)
+	v := make(biz_omiai.Vector, len(tf))
+	var norm float64
+	for f, count := range tf {
+		w := 1 + math.Log(count)
+		if p.idf != nil {
+			idf, ok := p.idf[f]
+			if !ok {
+				// 语料中未出现过的特征按只出现一次计
+				idf = math.Log(float64(p.n+1)/2) + 1
+			}
+			w *= idf
+		}
+		v[f] = float32(w)
+		norm += w * w
+	}
+	if norm == 0 {
+		return v
+	}
+	norm = math.Sqrt(norm)
+	for f, w := range v {
+		v[f] = float32(float64(w) / norm)
+	}
+	return v
+}
+
+// features 文本特征及加权词频：标点与空白切分片段，片段内汉字逐字、字母数字按词，
+// 再取相邻两项组成双字特征
+func features(text string) map[uint32]float64 {
+	tf := make(map[uint32]float64)
+	for _, segment := range segments(strings.ToLower(text)) {
+		for i, unit := range segment {
+			if !stopChars[unit] {
+				tf[hashFeature("1:"+unit)] += unigramWeight
+			}
+			if i > 0 {
+				tf[hashFeature("2:"+segment[i-1]+unit)]++
+			}
+		}
+	}
+	return tf
+}
+
+// segments 按标点与空白切分，每个片段由汉字或连续的字母数字组成
+func segments(text string) [][]string {
+	var (
+		out     [][]string
+		current []string
+		word    strings.Builder
+	)
+	flushWord := func() {
+		if word.Len() > 0 {
+			current = append(current, word.String())
+			word.Reset()
+		}
+	}
+	flushSegment := func() {
+		flushWord()
+		if len(current) > 0 {
+			out = append(out, current)
+			current = nil
+		}
+	}
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flushWord()
+			current = append(current, string(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word.WriteRune(r)
+		default:
+			flushSegment()
+		}
+	}
+	flushSegment()
+	return out
+}
+
+func hashFeature(s string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(s))
+	return h.Sum32() % tfidfBuckets
+}
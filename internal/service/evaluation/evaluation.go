@@ -0,0 +1,298 @@
+package evaluation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/service/matching"
+)
+
+// DefaultK 默认计算的 precision@k
+var DefaultK = []int{5, 10, 20}
+
+var ErrNoOutcomes = errors.New("正负样本不足，无法计算排序指标：至少需要一对走到交往的情侣和一对分手的情侣")
+
+// Snapshot 评估数据集：客户资料（含测评画像）、情侣档案及其状态变更记录，可由数据库导出为 JSON
+type Snapshot struct {
+	Clients []*biz_omiai.Client             `json:"clients"`
+	Records []*biz_omiai.MatchRecord        `json:"records"`
+	History []*biz_omiai.MatchStatusHistory `json:"history"`
+}
+
+// ReadSnapshot 读取 JSON 导出的数据集
+func ReadSnapshot(r io.Reader) (*Snapshot, error) {
+	var s Snapshot
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, fmt.Errorf("evaluation: decode snapshot err:%w", err)
+	}
+	return &s, nil
+}
+
+// WriteSnapshot 导出数据集为 JSON
+func WriteSnapshot(w io.Writer, s *Snapshot) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// Config 待评估的评分配置，Weights 中未出现的维度使用默认权重
+type Config struct {
+	Name    string             `json:"name"`
+	Weights map[string]float64 `json:"weights"`
+}
+
+// ParseConfig 解析命令行配置，格式 name:age=0.2,education=0.3
+func ParseConfig(s string) (*Config, error) {
+	name, spec, _ := strings.Cut(s, ":")
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("配置缺少名称: %q", s)
+	}
+	cfg := &Config{Name: name, Weights: make(map[string]float64)}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("权重格式错误: %q", item)
+		}
+		if _, known := matching.DefaultWeights[key]; !known {
+			return nil, fmt.Errorf("评分维度不存在: %s", key)
+		}
+		w, err := strconv.ParseFloat(value, 64)
+		if err != nil || w < 0 {
+			return nil, fmt.Errorf("权重格式错误: %q", item)
+		}
+		cfg.Weights[key] = w
+	}
+	return cfg, nil
+}
+
+// Outcome 一对情侣的最终结果
+type Outcome struct {
+	RecordID uint64 `json:"record_id"`
+	Positive bool   `json:"positive"` // 走到交往及以上且未分手
+	Married  bool   `json:"married"`
+	Broken   bool   `json:"broken"`
+}
+
+// Outcomes 从情侣档案与状态记录推导样本标签：
+// 分手为负样本；曾走到交往及以上且未分手为正样本；仍处于相识阶段的尚无结论，不参与评估
+func Outcomes(records []*biz_omiai.MatchRecord, history []*biz_omiai.MatchStatusHistory) []*Outcome {
+	reached := make(map[uint64]int8, len(records))
+	for _, h := range history {
+		if h.NewStatus != biz_omiai.MatchStatusBroken && h.NewStatus > reached[h.MatchRecordID] {
+			reached[h.MatchRecordID] = h.NewStatus
+		}
+	}
+
+	out := make([]*Outcome, 0, len(records))
+	for _, r := range records {
+		switch {
+		case r.Status == biz_omiai.MatchStatusBroken:
+			out = append(out, &Outcome{RecordID: r.ID, Broken: true})
+		case r.Status >= biz_omiai.MatchStatusDating || reached[r.ID] >= biz_omiai.MatchStatusDating:
+			out = append(out, &Outcome{RecordID: r.ID, Positive: true, Married: r.Status == biz_omiai.MatchStatusMarried})
+		}
+	}
+	return out
+}
+
+// PrecisionAtK 得分前 K 名中正样本的比例
+type PrecisionAtK struct {
+	K         int     `json:"k"`
+	Precision float64 `json:"precision"`
+}
+
+// ConfigResult 单个评分配置的评估结果
+type ConfigResult struct {
+	Name         string             `json:"name"`
+	Weights      map[string]float64 `json:"weights"` // 生效中的权重（未归一化）
+	AUC          float64            `json:"auc"`
+	PrecisionAtK []*PrecisionAtK    `json:"precision_at_k"`
+	AvgPositive  float64            `json:"avg_positive"` // 正样本平均分
+	AvgNegative  float64            `json:"avg_negative"` // 分手平均分
+	AvgMarried   float64            `json:"avg_married"`  // 结婚平均分
+}
+
+// Report 评估报告
+type Report struct {
+	Records      int                `json:"records"`       // 情侣档案总数
+	Positive     int                `json:"positive"`      // 正样本数
+	Negative     int                `json:"negative"`      // 负样本数（分手）
+	Married      int                `json:"married"`       // 其中已结婚
+	Skipped      int                `json:"skipped"`       // 尚无结论或客户资料缺失
+	DimensionAUC map[string]float64 `json:"dimension_auc"` // 各维度原始得分单独排序的 AUC，用于判断维度区分度
+	Results      []*ConfigResult    `json:"results"`
+}
+
+// sample 一个已评分的样本
+type sample struct {
+	outcome *Outcome
+	male    *biz_omiai.Client
+	female  *biz_omiai.Client
+	score   float64
+}
+
+// Evaluate 用各评分配置重新为历史情侣打分，计算排序指标
+// 地域维度按 regions 解析地区代码，regions 为 nil 时按代码规则推断
+func Evaluate(s *Snapshot, configs []*Config, regions biz_omiai.ChinaRegionInterface, ks []int) (*Report, error) {
+	if len(ks) == 0 {
+		ks = DefaultK
+	}
+	clients := make(map[uint64]*biz_omiai.Client, len(s.Clients))
+	for _, c := range s.Clients {
+		clients[c.ID] = c
+	}
+	records := make(map[uint64]*biz_omiai.MatchRecord, len(s.Records))
+	for _, r := range s.Records {
+		records[r.ID] = r
+	}
+
+	report := &Report{Records: len(s.Records), DimensionAUC: make(map[string]float64)}
+	var samples []*sample
+	for _, o := range Outcomes(s.Records, s.History) {
+		r := records[o.RecordID]
+		male, female := clients[r.MaleClientID], clients[r.FemaleClientID]
+		if male == nil || female == nil {
+			continue
+		}
+		samples = append(samples, &sample{outcome: o, male: male, female: female})
+		if o.Positive {
+			report.Positive++
+		} else {
+			report.Negative++
+		}
+		if o.Married {
+			report.Married++
+		}
+	}
+	report.Skipped = report.Records - len(samples)
+	if report.Positive == 0 || report.Negative == 0 {
+		return report, ErrNoOutcomes
+	}
+
+	index := matching.NewRegionIndex(regions)
+
+	// 维度区分度与权重无关，按默认权重计算一次
+	dimScores := make(map[string][]float64)
+	baseline := matching.NewWeightedScorer(matching.AlgorithmName, nil, matching.DefaultDimensions(index)...)
+	for i, sp := range samples {
+		for _, d := range baseline.Score(sp.male, sp.female).Dimensions {
+			if dimScores[d.Name] == nil {
+				dimScores[d.Name] = make([]float64, len(samples))
+			}
+			dimScores[d.Name][i] = d.Score
+		}
+	}
+	for name, scores := range dimScores {
+		report.DimensionAUC[name] = round4(auc(samples, scores))
+	}
+
+	for _, cfg := range configs {
+		scorer := matching.NewWeightedScorer(cfg.Name, cfg.Weights, matching.DefaultDimensions(index)...)
+		scores := make([]float64, len(samples))
+		for i, sp := range samples {
+			scores[i] = float64(scorer.Score(sp.male, sp.female).Score)
+		}
+		report.Results = append(report.Results, evaluateScores(cfg.Name, scorer.Weights(), samples, scores, ks))
+	}
+	return report, nil
+}
+
+func evaluateScores(name string, weights map[string]float64, samples []*sample, scores []float64, ks []int) *ConfigResult {
+	res := &ConfigResult{Name: name, Weights: weights, AUC: round4(auc(samples, scores))}
+
+	var pos, neg, married []float64
+	for i, sp := range samples {
+		switch {
+		case sp.outcome.Positive:
+			pos = append(pos, scores[i])
+			if sp.outcome.Married {
+				married = append(married, scores[i])
+			}
+		default:
+			neg = append(neg, scores[i])
+		}
+	}
+	res.AvgPositive, res.AvgNegative, res.AvgMarried = mean(pos), mean(neg), mean(married)
+
+	// 同分时按档案 ID 排序，保证结果可复现
+	order := make([]int, len(samples))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		if scores[order[i]] != scores[order[j]] {
+			return scores[order[i]] > scores[order[j]]
+		}
+		return samples[order[i]].outcome.RecordID < samples[order[j]].outcome.RecordID
+	})
+	for _, k := range ks {
+		if k <= 0 {
+			continue
+		}
+		if k > len(order) {
+			k = len(order)
+		}
+		hits := 0
+		for _, idx := range order[:k] {
+			if samples[idx].outcome.Positive {
+				hits++
+			}
+		}
+		res.PrecisionAtK = append(res.PrecisionAtK, &PrecisionAtK{K: k, Precision: round4(float64(hits) / float64(k))})
+	}
+	return res
+}
+
+// auc 正样本得分高于负样本的概率（Mann-Whitney U），同分记 0.5
+func auc(samples []*sample, scores []float64) float64 {
+	var pos, neg []float64
+	for i, sp := range samples {
+		if sp.outcome.Positive {
+			pos = append(pos, scores[i])
+		} else {
+			neg = append(neg, scores[i])
+		}
+	}
+	if len(pos) == 0 || len(neg) == 0 {
+		return 0
+	}
+	var wins float64
+	for _, p := range pos {
+		for _, n := range neg {
+			switch {
+			case p > n:
+				wins++
+			case p == n:
+				wins += 0.5
+			}
+		}
+	}
+	return wins / float64(len(pos)*len(neg))
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return math.Round(sum/float64(len(values))*100) / 100
+}
+
+func round4(v float64) float64 {
+	return math.Round(v*10000) / 10000
+}
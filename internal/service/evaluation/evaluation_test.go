@@ -0,0 +1,100 @@
+package evaluation
+
+import (
+	"bytes"
+	"testing"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/service/matching"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := ParseConfig("edu:education=0.3, age=0")
+	assert.NoError(t, err)
+	assert.Equal(t, "edu", cfg.Name)
+	assert.Equal(t, map[string]float64{matching.DimensionEducation: 0.3, matching.DimensionAge: 0}, cfg.Weights)
+
+	cfg, err = ParseConfig("baseline")
+	assert.NoError(t, err)
+	assert.Empty(t, cfg.Weights)
+
+	for _, bad := range []string{":age=1", "x:unknown=1", "x:age", "x:age=-1"} {
+		_, err = ParseConfig(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestOutcomes(t *testing.T) {
+	records := []*biz_omiai.MatchRecord{
+		{ID: 1, Status: biz_omiai.MatchStatusMarried},
+		{ID: 2, Status: biz_omiai.MatchStatusBroken},
+		{ID: 3, Status: biz_omiai.MatchStatusAcquaintance},
+		{ID: 4, Status: biz_omiai.MatchStatusAcquaintance},
+	}
+	// 4 曾走到交往，状态被回退后仍计为正样本
+	history := []*biz_omiai.MatchStatusHistory{
+		{MatchRecordID: 2, NewStatus: biz_omiai.MatchStatusDating},
+		{MatchRecordID: 2, NewStatus: biz_omiai.MatchStatusBroken},
+		{MatchRecordID: 4, NewStatus: biz_omiai.MatchStatusDating},
+	}
+	out := Outcomes(records, history)
+	assert.Len(t, out, 3)
+	assert.Equal(t, &Outcome{RecordID: 1, Positive: true, Married: true}, out[0])
+	assert.Equal(t, &Outcome{RecordID: 2, Broken: true}, out[1])
+	assert.Equal(t, &Outcome{RecordID: 4, Positive: true}, out[2])
+}
+
+func TestEvaluate(t *testing.T) {
+	// 成功的情侣学历相同、年龄相当；分手的情侣学历差距大
+	s := &Snapshot{
+		Clients: []*biz_omiai.Client{
+			{ID: 1, Gender: 1, Age: 30, Education: 3}, {ID: 2, Gender: 2, Age: 28, Education: 3},
+			{ID: 3, Gender: 1, Age: 31, Education: 4}, {ID: 4, Gender: 2, Age: 29, Education: 4},
+			{ID: 5, Gender: 1, Age: 30, Education: 5}, {ID: 6, Gender: 2, Age: 28, Education: 1},
+			{ID: 7, Gender: 1, Age: 30, Education: 1}, {ID: 8, Gender: 2, Age: 28, Education: 5},
+		},
+		Records: []*biz_omiai.MatchRecord{
+			{ID: 1, MaleClientID: 1, FemaleClientID: 2, Status: biz_omiai.MatchStatusMarried},
+			{ID: 2, MaleClientID: 3, FemaleClientID: 4, Status: biz_omiai.MatchStatusDating},
+			{ID: 3, MaleClientID: 5, FemaleClientID: 6, Status: biz_omiai.MatchStatusBroken},
+			{ID: 4, MaleClientID: 7, FemaleClientID: 8, Status: biz_omiai.MatchStatusBroken},
+			{ID: 5, MaleClientID: 7, FemaleClientID: 99, Status: biz_omiai.MatchStatusBroken},
+		},
+	}
+
+	// JSON 导出往返后结果一致
+	var buf bytes.Buffer
+	assert.NoError(t, WriteSnapshot(&buf, s))
+	s, err := ReadSnapshot(&buf)
+	assert.NoError(t, err)
+
+	configs := []*Config{
+		{Name: "current"},
+		{Name: "no-edu", Weights: map[string]float64{matching.DimensionEducation: 0}},
+	}
+	report, err := Evaluate(s, configs, nil, []int{1, 2, 10})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, report.Records)
+	assert.Equal(t, 2, report.Positive)
+	assert.Equal(t, 2, report.Negative)
+	assert.Equal(t, 1, report.Married)
+	assert.Equal(t, 1, report.Skipped)
+	assert.Equal(t, 1.0, report.DimensionAUC[matching.DimensionEducation])
+	assert.Equal(t, 0.5, report.DimensionAUC[matching.DimensionAge])
+
+	current := report.Results[0]
+	assert.Equal(t, 1.0, current.AUC)
+	assert.Equal(t, []*PrecisionAtK{{K: 1, Precision: 1}, {K: 2, Precision: 1}, {K: 4, Precision: 0.5}}, current.PrecisionAtK)
+	assert.Greater(t, current.AvgMarried, current.AvgNegative)
+
+	// 去掉学历维度后失去区分度
+	noEdu := report.Results[1]
+	assert.NotContains(t, noEdu.Weights, matching.DimensionEducation)
+	assert.Equal(t, 0.5, noEdu.AUC)
+	assert.Equal(t, noEdu.AvgPositive, noEdu.AvgNegative)
+
+	_, err = Evaluate(&Snapshot{Clients: s.Clients, Records: s.Records[:2]}, configs, nil, nil)
+	assert.ErrorIs(t, err, ErrNoOutcomes)
+}
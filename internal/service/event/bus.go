@@ -0,0 +1,50 @@
+package event
+
+import (
+	"context"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+
+	"github.com/iWuxc/go-wit/log"
+)
+
+var _ biz_omiai.EventBus = (*Bus)(nil)
+
+// Bus 同步执行订阅方的进程内事件总线，单个订阅方 panic 不影响其他订阅方
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]biz_omiai.EventHandler
+}
+
+func NewBus() biz_omiai.EventBus {
+	return &Bus{handlers: make(map[string][]biz_omiai.EventHandler)}
+}
+
+func (b *Bus) Subscribe(topic string, handler biz_omiai.EventHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[topic] = append(b.handlers[topic], handler)
+}
+
+func (b *Bus) Publish(ctx context.Context, topic string, payload interface{}) {
+	b.mu.RLock()
+	handlers := b.handlers[topic]
+	b.mu.RUnlock()
+
+	event := &biz_omiai.Event{Topic: topic, Payload: payload, OccurredAt: time.Now()}
+	for _, h := range handlers {
+		b.dispatch(ctx, h, event)
+	}
+}
+
+func (b *Bus) dispatch(ctx context.Context, h biz_omiai.EventHandler, event *biz_omiai.Event) {
+	defer func() {
+		if err := recover(); err != nil {
+			log.WithContext(ctx).Errorf("event(%s) handler panic: %v\n%s", event.Topic, err, debug.Stack())
+		}
+	}()
+	h(ctx, event)
+}
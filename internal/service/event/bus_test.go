@@ -0,0 +1,28 @@
+package event
+
+import (
+	"context"
+	"testing"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBus(t *testing.T) {
+	bus := NewBus()
+	var got []*biz_omiai.MatchStatusChanged
+	bus.Subscribe(biz_omiai.EventMatchStatusChanged, func(ctx context.Context, e *biz_omiai.Event) {
+		panic("boom")
+	})
+	bus.Subscribe(biz_omiai.EventMatchStatusChanged, func(ctx context.Context, e *biz_omiai.Event) {
+		got = append(got, e.Payload.(*biz_omiai.MatchStatusChanged))
+	})
+
+	bus.Publish(context.Background(), biz_omiai.EventMatchStatusChanged, &biz_omiai.MatchStatusChanged{RecordID: 1})
+	bus.Publish(context.Background(), "unknown", nil)
+
+	// 前一个订阅方 panic 不影响后续订阅方
+	assert.Len(t, got, 1)
+	assert.Equal(t, uint64(1), got[0].RecordID)
+}
@@ -0,0 +1,244 @@
+package experiment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/conf"
+	"omiai-server/internal/service/matching"
+
+	"github.com/iWuxc/go-wit/log"
+)
+
+// maxAlgorithmLen 分组评分器标识写入 recommendation.algorithm，长度受列宽限制
+const maxAlgorithmLen = 32
+
+var ErrExperimentNotFound = errors.New("实验不存在")
+
+// Assignment 分流结果，Scorer 为该分组使用的评分器
+type Assignment struct {
+	Experiment string           `json:"experiment"`
+	Variant    string           `json:"variant"`
+	Unit       string           `json:"unit"`
+	UnitID     uint64           `json:"unit_id"`
+	Scorer     biz_omiai.Scorer `json:"-"`
+}
+
+// ScorerOr 分组评分器，未分组时返回 def
+func (a *Assignment) ScorerOr(def biz_omiai.Scorer) biz_omiai.Scorer {
+	if a == nil {
+		return def
+	}
+	return a.Scorer
+}
+
+// Exposed 一条曝光的候选人
+type Exposed struct {
+	CandidateID uint64
+	Score       int
+}
+
+type variant struct {
+	name    string
+	traffic int
+	scorer  biz_omiai.Scorer
+}
+
+type experiment struct {
+	key      string
+	unit     string
+	variants []*variant
+	total    int
+}
+
+type Service struct {
+	repo   biz_omiai.ExperimentInterface
+	active *experiment
+	keys   map[string]bool
+}
+
+// NewService 加载第一个启用的实验，配置有误时记录错误并不启用实验
+func NewService(c *conf.Config, regions biz_omiai.ChinaRegionInterface, repo biz_omiai.ExperimentInterface) *Service {
+	s := &Service{repo: repo, keys: make(map[string]bool)}
+	if c == nil || c.Match == nil {
+		return s
+	}
+	index := matching.NewRegionIndex(regions)
+	for _, cfg := range c.Match.Experiments {
+		if cfg == nil || cfg.Key == "" {
+			continue
+		}
+		s.keys[cfg.Key] = true
+		if !cfg.Enabled || s.active != nil {
+			continue
+		}
+		exp, err := compile(cfg, c.Match.Weights, index)
+		if err != nil {
+			log.Errorf("experiment %s disabled: %v", cfg.Key, err)
+			continue
+		}
+		s.active = exp
+	}
+	return s
+}
+
+// compile 校验实验配置并为各分组创建评分器，分组权重覆盖线上权重中的对应维度
+func compile(cfg *conf.Experiment, base map[string]float64, index *matching.RegionIndex) (*experiment, error) {
+	unit := cfg.Unit
+	if unit == "" {
+		unit = biz_omiai.ExperimentUnitClient
+	}
+	if unit != biz_omiai.ExperimentUnitClient && unit != biz_omiai.ExperimentUnitMatchmaker {
+		return nil, fmt.Errorf("unknown unit %q", cfg.Unit)
+	}
+	if len(cfg.Variants) < 2 {
+		return nil, errors.New("at least two variants are required")
+	}
+
+	exp := &experiment{key: cfg.Key, unit: unit}
+	seen := make(map[string]bool, len(cfg.Variants))
+	for _, v := range cfg.Variants {
+		if v == nil || v.Name == "" || seen[v.Name] {
+			return nil, errors.New("variant names must be non-empty and unique")
+		}
+		seen[v.Name] = true
+		if v.Traffic < 0 {
+			return nil, fmt.Errorf("variant %s: negative traffic", v.Name)
+		}
+		algorithm := cfg.Key + "/" + v.Name
+		if len(algorithm) > maxAlgorithmLen {
+			return nil, fmt.Errorf("variant %s: %q exceeds %d characters", v.Name, algorithm, maxAlgorithmLen)
+		}
+		weights := make(map[string]float64, len(base)+len(v.Weights))
+		for k, w := range base {
+			weights[k] = w
+		}
+		for k, w := range v.Weights {
+			if _, ok := matching.DefaultWeights[k]; !ok {
+				return nil, fmt.Errorf("variant %s: unknown dimension %s", v.Name, k)
+			}
+			weights[k] = w
+		}
+		exp.variants = append(exp.variants, &variant{
+			name:    v.Name,
+			traffic: v.Traffic,
+			scorer:  matching.NewWeightedScorer(algorithm, weights, matching.DefaultDimensions(index)...),
+		})
+		exp.total += v.Traffic
+	}
+	if exp.total == 0 {
+		return nil, errors.New("total traffic is zero")
+	}
+	return exp, nil
+}
+
+// Active 生效中的实验标识，无实验时为空
+func (s *Service) Active() string {
+	if s == nil || s.active == nil {
+		return ""
+	}
+	return s.active.key
+}
+
+// Algorithms 生效中实验各分组评分器的标识
+func (s *Service) Algorithms() []string {
+	if s == nil || s.active == nil {
+		return nil
+	}
+	names := make([]string, 0, len(s.active.variants))
+	for _, v := range s.active.variants {
+		names = append(names, v.scorer.Name())
+	}
+	return names
+}
+
+// Assign 按实验标识与分流单位ID哈希稳定分组
+// 无生效实验，或按红娘分流但没有红娘ID时返回 nil，调用方使用默认评分器
+func (s *Service) Assign(clientID, matchmakerID uint64) *Assignment {
+	if s == nil || s.active == nil {
+		return nil
+	}
+	exp := s.active
+	unitID := clientID
+	if exp.unit == biz_omiai.ExperimentUnitMatchmaker {
+		unitID = matchmakerID
+	}
+	if unitID == 0 {
+		return nil
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(exp.key + ":" + strconv.FormatUint(unitID, 10)))
+	bucket := int(h.Sum32() % uint32(exp.total))
+	for _, v := range exp.variants {
+		if bucket < v.traffic {
+			return &Assignment{Experiment: exp.key, Variant: v.name, Unit: exp.unit, UnitID: unitID, Scorer: v.scorer}
+		}
+		bucket -= v.traffic
+	}
+	return nil
+}
+
+// LogExposures 记录本次返回给红娘的推荐，rankOffset 为分页偏移；失败只记录日志，不影响推荐结果
+func (s *Service) LogExposures(ctx context.Context, a *Assignment, source string, clientID, matchmakerID uint64, rankOffset int, items []Exposed) {
+	if a == nil || len(items) == 0 {
+		return
+	}
+	list := make([]*biz_omiai.ExperimentExposure, 0, len(items))
+	for i, item := range items {
+		list = append(list, &biz_omiai.ExperimentExposure{
+			ExperimentKey: a.Experiment,
+			Variant:       a.Variant,
+			Unit:          a.Unit,
+			UnitID:        a.UnitID,
+			ClientID:      clientID,
+			CandidateID:   item.CandidateID,
+			MatchmakerID:  matchmakerID,
+			Algorithm:     a.Scorer.Name(),
+			Score:         item.Score,
+			Rank:          rankOffset + i + 1,
+			Source:        source,
+		})
+	}
+	if err := s.repo.LogExposures(ctx, list); err != nil {
+		log.WithContext(ctx).Errorf("experiment %s: log %d exposures of client %d err:%v", a.Experiment, len(list), clientID, err)
+	}
+}
+
+// VariantResult 分组转化漏斗及转化率（百分比，分母为曝光配对数）
+type VariantResult struct {
+	*biz_omiai.ExperimentFunnel
+	IntroducedRate float64 `json:"introduced_rate"`
+	AcceptedRate   float64 `json:"accepted_rate"`
+	MatchedRate    float64 `json:"matched_rate"`
+	DatingRate     float64 `json:"dating_rate"`
+}
+
+// Results 实验结果，key 为空时取生效中的实验
+func (s *Service) Results(ctx context.Context, key string) (string, []*VariantResult, error) {
+	if key == "" {
+		key = s.Active()
+	}
+	if key == "" || !s.keys[key] {
+		return key, nil, ErrExperimentNotFound
+	}
+	funnels, err := s.repo.Funnel(ctx, key)
+	if err != nil {
+		return key, nil, err
+	}
+	out := make([]*VariantResult, 0, len(funnels))
+	for _, f := range funnels {
+		out = append(out, &VariantResult{
+			ExperimentFunnel: f,
+			IntroducedRate:   f.Rate(f.Introduced),
+			AcceptedRate:     f.Rate(f.Accepted),
+			MatchedRate:      f.Rate(f.Matched),
+			DatingRate:       f.Rate(f.Dating),
+		})
+	}
+	return key, out, nil
+}
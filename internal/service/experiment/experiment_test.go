@@ -0,0 +1,119 @@
+package experiment
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/conf"
+	"omiai-server/internal/data"
+	"omiai-server/internal/data/omiai"
+	"omiai-server/internal/service/matching"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func experimentConfig(experiments ...*conf.Experiment) *conf.Config {
+	return &conf.Config{Match: &conf.Match{Experiments: experiments}}
+}
+
+func TestAssign(t *testing.T) {
+	s := NewService(experimentConfig(
+		&conf.Experiment{Key: "off", Variants: []*conf.ExperimentVariant{{Name: "a", Traffic: 1}, {Name: "b", Traffic: 1}}},
+		&conf.Experiment{Key: "w1", Enabled: true, Variants: []*conf.ExperimentVariant{
+			{Name: "control", Traffic: 50},
+			{Name: "edu", Traffic: 50, Weights: map[string]float64{matching.DimensionEducation: 0.5}},
+		}},
+		&conf.Experiment{Key: "w2", Enabled: true, Variants: []*conf.ExperimentVariant{{Name: "a", Traffic: 1}, {Name: "b", Traffic: 1}}},
+	), nil, nil)
+	assert.Equal(t, "w1", s.Active())
+
+	counts := map[string]int{}
+	for id := uint64(1); id <= 1000; id++ {
+		a := s.Assign(id, 0)
+		assert.Equal(t, a, s.Assign(id, 99), "按客户分流与红娘无关")
+		counts[a.Variant]++
+		assert.Equal(t, "w1/"+a.Variant, a.Scorer.Name())
+	}
+	assert.InDelta(t, 500, counts["control"], 60)
+	assert.InDelta(t, 500, counts["edu"], 60)
+
+	// 按红娘分流
+	s = NewService(experimentConfig(&conf.Experiment{Key: "mm", Enabled: true, Unit: biz_omiai.ExperimentUnitMatchmaker,
+		Variants: []*conf.ExperimentVariant{{Name: "a", Traffic: 1}, {Name: "b", Traffic: 0}}}), nil, nil)
+	assert.Nil(t, s.Assign(1, 0))
+	a := s.Assign(1, 7)
+	assert.Equal(t, "a", a.Variant)
+	assert.Equal(t, uint64(7), a.UnitID)
+
+	// 配置有误时不启用实验
+	for _, bad := range []*conf.Experiment{
+		{Key: "one", Enabled: true, Variants: []*conf.ExperimentVariant{{Name: "a", Traffic: 1}}},
+		{Key: "dup", Enabled: true, Variants: []*conf.ExperimentVariant{{Name: "a", Traffic: 1}, {Name: "a", Traffic: 1}}},
+		{Key: "dim", Enabled: true, Variants: []*conf.ExperimentVariant{{Name: "a", Traffic: 1}, {Name: "b", Traffic: 1, Weights: map[string]float64{"x": 1}}}},
+		{Key: "unit", Enabled: true, Unit: "city", Variants: []*conf.ExperimentVariant{{Name: "a", Traffic: 1}, {Name: "b", Traffic: 1}}},
+		{Key: "a_very_long_experiment_key_name", Enabled: true, Variants: []*conf.ExperimentVariant{{Name: "control", Traffic: 1}, {Name: "b", Traffic: 1}}},
+	} {
+		s = NewService(experimentConfig(bad), nil, nil)
+		assert.Nil(t, s.Assign(1, 1), bad.Key)
+	}
+	var none *Service
+	assert.Nil(t, none.Assign(1, 1))
+}
+
+func TestResults(t *testing.T) {
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	assert.NoError(t, db.AutoMigrate(&biz_omiai.ExperimentExposure{}, &biz_omiai.Introduction{}, &biz_omiai.IntroductionHistory{},
+		&biz_omiai.MatchRecord{}, &biz_omiai.MatchStatusHistory{}))
+
+	s := NewService(experimentConfig(&conf.Experiment{Key: "w1", Enabled: true, Variants: []*conf.ExperimentVariant{
+		{Name: "a", Traffic: 1}, {Name: "b", Traffic: 1},
+	}}), nil, omiai.NewExperimentRepo(&data.DB{DB: db}))
+
+	assignment := func(variant string) *Assignment {
+		for id := uint64(1); ; id++ {
+			if a := s.Assign(id, 0); a.Variant == variant {
+				return a
+			}
+		}
+	}
+	a, b := assignment("a"), assignment("b")
+
+	// 分组 a：客户 1 曝光 10、11；重复曝光只记录一次
+	s.LogExposures(ctx, a, biz_omiai.ExposureSourceCandidates, 1, 9, 0, []Exposed{{CandidateID: 10, Score: 80}, {CandidateID: 11, Score: 70}})
+	s.LogExposures(ctx, b, biz_omiai.ExposureSourceSmartMatch, 1, 9, 0, []Exposed{{CandidateID: 10, Score: 60}})
+	// 分组 b：客户 2 曝光 20
+	s.LogExposures(ctx, b, biz_omiai.ExposureSourceCandidates, 2, 9, 0, []Exposed{{CandidateID: 20, Score: 75}})
+
+	// 转化：1-10 介绍后双方同意、确认匹配并进入交往（反向记录也归因）；1-11 仅介绍；2-20 介绍发生在曝光之前，不计入
+	later := time.Now().Add(time.Minute)
+	earlier := time.Now().Add(-time.Hour)
+	intro := &biz_omiai.Introduction{ClientAID: 10, ClientBID: 1, Status: biz_omiai.IntroStatusConverted, CreatedAt: later}
+	assert.NoError(t, db.Create(intro).Error)
+	assert.NoError(t, db.Create(&biz_omiai.IntroductionHistory{IntroductionID: intro.ID, NewStatus: biz_omiai.IntroStatusBAccepted}).Error)
+	assert.NoError(t, db.Create(&biz_omiai.Introduction{ClientAID: 1, ClientBID: 11, Status: biz_omiai.IntroStatusClosed, CreatedAt: later}).Error)
+	assert.NoError(t, db.Create(&biz_omiai.Introduction{ClientAID: 2, ClientBID: 20, CreatedAt: earlier}).Error)
+	record := &biz_omiai.MatchRecord{MaleClientID: 1, FemaleClientID: 10, Status: biz_omiai.MatchStatusBroken, CreatedAt: later}
+	assert.NoError(t, db.Create(record).Error)
+	assert.NoError(t, db.Create(&biz_omiai.MatchStatusHistory{MatchRecordID: record.ID, OldStatus: 1, NewStatus: biz_omiai.MatchStatusDating}).Error)
+
+	key, results, err := s.Results(ctx, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "w1", key)
+	assert.Len(t, results, 2)
+	assert.Equal(t, &biz_omiai.ExperimentFunnel{Variant: "a", Clients: 1, Exposures: 2, Introduced: 2, Accepted: 1, Matched: 1, Dating: 1},
+		results[0].ExperimentFunnel)
+	assert.Equal(t, 100.0, results[0].IntroducedRate)
+	assert.Equal(t, 50.0, results[0].DatingRate)
+	assert.Equal(t, &biz_omiai.ExperimentFunnel{Variant: "b", Clients: 1, Exposures: 1}, results[1].ExperimentFunnel)
+
+	_, _, err = s.Results(ctx, "unknown")
+	assert.ErrorIs(t, err, ErrExperimentNotFound)
+}
@@ -0,0 +1,442 @@
+package matching
+
+import (
+	"fmt"
+	"math"
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"sort"
+	"strings"
+)
+
+// 内置评分维度标识
+const (
+	DimensionAge          = "age"
+	DimensionHeight       = "height"
+	DimensionMarital      = "marital"
+	DimensionEducation    = "education"
+	DimensionIncome       = "income"
+	DimensionAsset        = "asset"
+	DimensionRequirement  = "requirement"
+	DimensionPersonality  = "personality"
+	DimensionRegion       = "region"
+	DimensionSemantic     = "semantic"
+	DimensionTag          = "tag"
+	DimensionCompleteness = "completeness"
+)
+
+// neutralScore 数据缺失时的中性得分
+const neutralScore = 50.0
+
+const defaultTag = "可以尝试"
+
+// Pair 参与评分的一对客户，按性别归位以保证评分与参数顺序无关
+type Pair struct {
+	Male   *biz_omiai.Client
+	Female *biz_omiai.Client
+}
+
+// NewPair 按性别归位；性别相同或缺失时按 ID 排序
+func NewPair(a, b *biz_omiai.Client) *Pair {
+	switch {
+	case a.Gender == 1 && b.Gender == 2:
+		return &Pair{Male: a, Female: b}
+	case a.Gender == 2 && b.Gender == 1:
+		return &Pair{Male: b, Female: a}
+	case a.ID > b.ID:
+		return &Pair{Male: b, Female: a}
+	default:
+		return &Pair{Male: a, Female: b}
+	}
+}
+
+// Dimension 评分维度，Evaluate 返回 0-100 的原始得分及说明
+type Dimension interface {
+	Name() string
+	Label() string
+	Evaluate(p *Pair) *biz_omiai.DimensionScore
+}
+
+// DefaultDimensions 内置维度列表，regions 为空时地域维度按代码规则推断层级
+func DefaultDimensions(regions *RegionIndex) []Dimension {
+	return []Dimension{
+		ageDimension{},
+		heightDimension{},
+		maritalDimension{},
+		educationDimension{},
+		incomeDimension{},
+		assetDimension{},
+		requirementDimension{},
+		personalityDimension{},
+		regionDimension{index: regions},
+		semanticDimension{},
+		tagDimension{},
+		completenessDimension{},
+	}
+}
+
+func unknown(reason string) *biz_omiai.DimensionScore {
+	return &biz_omiai.DimensionScore{Score: neutralScore, Reason: reason}
+}
+
+// ageDimension 年龄：传统观念男大女小，最佳年龄差 2-5 岁
+type ageDimension struct{}
+
+func (ageDimension) Name() string  { return DimensionAge }
+func (ageDimension) Label() string { return "年龄" }
+
+func (ageDimension) Evaluate(p *Pair) *biz_omiai.DimensionScore {
+	maleAge, femaleAge := p.Male.RealAge(), p.Female.RealAge()
+	if maleAge == 0 || femaleAge == 0 {
+		return unknown("年龄信息缺失")
+	}
+
+	gap := maleAge - femaleAge
+	ds := &biz_omiai.DimensionScore{}
+	switch {
+	case gap >= 2 && gap <= 5:
+		ds.Score, ds.Reason = 100, fmt.Sprintf("男方大%d岁，年龄差理想", gap)
+	case gap >= 0 && gap < 2:
+		ds.Score, ds.Reason = 85, "年龄相当"
+	case gap > 5 && gap <= 8:
+		ds.Score, ds.Reason = 70, fmt.Sprintf("男方大%d岁，年龄差略大", gap)
+	case gap > 8:
+		ds.Score, ds.Reason = 40, fmt.Sprintf("男方大%d岁，年龄差较大", gap)
+	case gap >= -2:
+		ds.Score, ds.Reason = 60, fmt.Sprintf("女方大%d岁", -gap)
+	default:
+		ds.Score, ds.Reason = 30, fmt.Sprintf("女方大%d岁，年龄差较大", -gap)
+	}
+	if abs(gap) <= 3 {
+		ds.Tags = append(ds.Tags, "年龄相仿")
+	}
+	return ds
+}
+
+// heightDimension 身高：男高女低，最佳身高差 10-20cm
+type heightDimension struct{}
+
+func (heightDimension) Name() string  { return DimensionHeight }
+func (heightDimension) Label() string { return "身高" }
+
+func (heightDimension) Evaluate(p *Pair) *biz_omiai.DimensionScore {
+	if p.Male.Height == 0 || p.Female.Height == 0 {
+		return unknown("身高信息缺失")
+	}
+
+	diff := p.Male.Height - p.Female.Height
+	ds := &biz_omiai.DimensionScore{}
+	switch {
+	case diff >= 10 && diff <= 20:
+		ds.Score, ds.Reason = 100, fmt.Sprintf("男方高%dcm，身高差理想", diff)
+		ds.Tags = append(ds.Tags, "身高般配")
+	case diff > 0 && diff < 10:
+		ds.Score, ds.Reason = 80, fmt.Sprintf("男方高%dcm", diff)
+	case diff > 20:
+		ds.Score, ds.Reason = 70, fmt.Sprintf("男方高%dcm，身高差较大", diff)
+	default:
+		ds.Score, ds.Reason = 35, "男方不高于女方"
+	}
+	return ds
+}
+
+// maritalDimension 婚史：婚史相同最佳
+type maritalDimension struct{}
+
+func (maritalDimension) Name() string  { return DimensionMarital }
+func (maritalDimension) Label() string { return "婚史" }
+
+func (maritalDimension) Evaluate(p *Pair) *biz_omiai.DimensionScore {
+	if p.Male.MaritalStatus == 0 || p.Female.MaritalStatus == 0 {
+		return unknown("婚姻状况缺失")
+	}
+	if p.Male.MaritalStatus == p.Female.MaritalStatus {
+		return &biz_omiai.DimensionScore{Score: 100, Reason: "婚史相同", Tags: []string{"婚史相同"}}
+	}
+	if p.Male.MaritalStatus == 1 || p.Female.MaritalStatus == 1 {
+		return &biz_omiai.DimensionScore{Score: 40, Reason: "一方未婚一方有婚史"}
+	}
+	return &biz_omiai.DimensionScore{Score: 70, Reason: "双方均有婚史"}
+}
+
+// educationDimension 学历：学历差越小越好
+type educationDimension struct{}
+
+func (educationDimension) Name() string  { return DimensionEducation }
+func (educationDimension) Label() string { return "学历" }
+
+func (educationDimension) Evaluate(p *Pair) *biz_omiai.DimensionScore {
+	if p.Male.Education == 0 || p.Female.Education == 0 {
+		return unknown("学历信息缺失")
+	}
+
+	diff := abs(int(p.Male.Education) - int(p.Female.Education))
+	ds := &biz_omiai.DimensionScore{}
+	switch diff {
+	case 0:
+		ds.Score, ds.Reason = 100, "学历相同"
+	case 1:
+		ds.Score, ds.Reason = 80, "学历相差一级"
+	case 2:
+		ds.Score, ds.Reason = 55, "学历相差两级"
+	default:
+		ds.Score, ds.Reason = 30, "学历差距较大"
+	}
+	if diff <= 1 {
+		ds.Tags = append(ds.Tags, "学历相当")
+	}
+	return ds
+}
+
+// incomeDimension 收入：按收入差距比例评分
+type incomeDimension struct{}
+
+func (incomeDimension) Name() string  { return DimensionIncome }
+func (incomeDimension) Label() string { return "收入" }
+
+func (incomeDimension) Evaluate(p *Pair) *biz_omiai.DimensionScore {
+	if p.Male.Income == 0 || p.Female.Income == 0 {
+		return unknown("收入信息缺失")
+	}
+
+	gapRatio := biz_omiai.IncomeGapRatio(p.Male.Income, p.Female.Income)
+	ds := &biz_omiai.DimensionScore{}
+	switch {
+	case gapRatio <= 0.3:
+		ds.Score, ds.Reason = 100, "收入差距30%以内"
+		ds.Tags = append(ds.Tags, "收入匹配")
+	case gapRatio <= 0.5:
+		ds.Score, ds.Reason = 80, "收入差距50%以内"
+	case gapRatio <= 0.8:
+		ds.Score, ds.Reason = 50, "收入差距较大"
+	default:
+		ds.Score, ds.Reason = 25, "收入差距悬殊"
+	}
+	return ds
+}
+
+// assetDimension 房车条件
+type assetDimension struct{}
+
+func (assetDimension) Name() string  { return DimensionAsset }
+func (assetDimension) Label() string { return "房车" }
+
+func (assetDimension) Evaluate(p *Pair) *biz_omiai.DimensionScore {
+	ds := &biz_omiai.DimensionScore{}
+
+	maleHouse, femaleHouse := p.Male.HouseStatus >= 2, p.Female.HouseStatus >= 2
+	switch {
+	case maleHouse && femaleHouse:
+		ds.Score, ds.Reason = 60, "双方有房"
+		ds.Tags = append(ds.Tags, "都有房产")
+	case maleHouse || femaleHouse:
+		ds.Score, ds.Reason = 40, "一方有房"
+	default:
+		ds.Score, ds.Reason = 20, "双方无房"
+	}
+
+	maleCar, femaleCar := p.Male.CarStatus == 2, p.Female.CarStatus == 2
+	switch {
+	case maleCar && femaleCar:
+		ds.Score, ds.Reason = ds.Score+40, ds.Reason+"，双方有车"
+	case maleCar || femaleCar:
+		ds.Score, ds.Reason = ds.Score+25, ds.Reason+"，一方有车"
+	default:
+		ds.Score, ds.Reason = ds.Score+10, ds.Reason+"，双方无车"
+	}
+	return ds
+}
+
+// requirementDimension 双向择偶要求满足度
+type requirementDimension struct{}
+
+func (requirementDimension) Name() string  { return DimensionRequirement }
+func (requirementDimension) Label() string { return "择偶要求" }
+
+func (requirementDimension) Evaluate(p *Pair) *biz_omiai.DimensionScore {
+	maleChecked, maleViolations := p.Male.ParseRequirements().Check(p.Female)
+	femaleChecked, femaleViolations := p.Female.ParseRequirements().Check(p.Male)
+
+	total := maleChecked + femaleChecked
+	if total == 0 {
+		return unknown("双方未填写可校验的择偶要求")
+	}
+
+	ds := &biz_omiai.DimensionScore{}
+	for _, v := range maleViolations {
+		ds.Violations = append(ds.Violations, "男方要求："+v)
+	}
+	for _, v := range femaleViolations {
+		ds.Violations = append(ds.Violations, "女方要求："+v)
+	}
+
+	failed := len(maleViolations) + len(femaleViolations)
+	ds.Score = round2(100 * float64(total-failed) / float64(total))
+	if failed == 0 {
+		ds.Reason = "双方择偶要求均满足"
+		ds.Tags = append(ds.Tags, "互相满足择偶要求")
+	} else {
+		ds.Reason = fmt.Sprintf("%d项择偶要求中有%d项不满足", total, failed)
+	}
+	return ds
+}
+
+// personalityDimension 性格与价值观：基于测评画像的相似度，价值观与婚恋期望权重加倍
+type personalityDimension struct{}
+
+func (personalityDimension) Name() string  { return DimensionPersonality }
+func (personalityDimension) Label() string { return "性格价值观" }
+
+func (personalityDimension) Evaluate(p *Pair) *biz_omiai.DimensionScore {
+	male, female := p.Male.Profile.ScoreMap(), p.Female.Profile.ScoreMap()
+	if male == nil || female == nil {
+		return unknown("尚未完成性格测评")
+	}
+
+	groups := []struct {
+		name   string
+		weight float64
+		tag    string
+	}{
+		{biz_omiai.ProfileGroupPersonality, 1, ""},
+		{biz_omiai.ProfileGroupValues, 2, "价值观契合"},
+		{biz_omiai.ProfileGroupExpectation, 2, "婚恋期望一致"},
+	}
+
+	ds := &biz_omiai.DimensionScore{}
+	var sum, total float64
+	for _, g := range groups {
+		sim, n := biz_omiai.ProfileSimilarity(male, female, g.name)
+		if n == 0 {
+			continue
+		}
+		sum += sim * g.weight
+		total += g.weight
+		if g.tag != "" && sim >= 80 {
+			ds.Tags = append(ds.Tags, g.tag)
+		}
+	}
+	if total == 0 {
+		return unknown("测评维度不一致，无法比较")
+	}
+	ds.Score = round2(sum / total)
+	ds.Reason = fmt.Sprintf("测评相似度%.0f%%", ds.Score)
+	return ds
+}
+
+// 语义维度：资料文本余弦相似度达到 semanticSaturation 即满分，不同人的资料文本相似度普遍不高
+const (
+	semanticSaturation = 0.4
+	semanticFloor      = 30.0
+	semanticTagAt      = 0.25
+)
+
+// semanticDimension 资料文本：红娘备注、家庭情况、择偶要求等自由文本的向量相似度
+type semanticDimension struct{}
+
+func (semanticDimension) Name() string  { return DimensionSemantic }
+func (semanticDimension) Label() string { return "资料文本" }
+
+func (semanticDimension) Evaluate(p *Pair) *biz_omiai.DimensionScore {
+	male, female := p.Male.Embedding, p.Female.Embedding
+	if male == nil || female == nil || male.Model != female.Model {
+		return unknown("资料文本尚未向量化")
+	}
+	a, b := male.Values(), female.Values()
+	if len(a) == 0 || len(b) == 0 {
+		return unknown("备注、家庭情况等文本资料缺失")
+	}
+
+	sim := a.Cosine(b)
+	ds := &biz_omiai.DimensionScore{
+		Score:  round2(semanticFloor + (100-semanticFloor)*math.Min(1, sim/semanticSaturation)),
+		Reason: fmt.Sprintf("资料文本相似度%.0f%%", sim*100),
+	}
+	if sim >= semanticTagAt {
+		ds.Tags = append(ds.Tags, "资料描述相近")
+	}
+	return ds
+}
+
+// 标签维度：共同标签数达到 tagSaturation 即满分，没有共同标签时给 tagFloor
+const (
+	tagSaturation = 3
+	tagFloor      = 40.0
+)
+
+// tagDimension 标签重合度：只比较参与评分的分组（如性格、爱好）中的标签
+type tagDimension struct{}
+
+func (tagDimension) Name() string  { return DimensionTag }
+func (tagDimension) Label() string { return "标签重合" }
+
+func (tagDimension) Evaluate(p *Pair) *biz_omiai.DimensionScore {
+	male, female := p.Male.ScoredTags(), p.Female.ScoredTags()
+	if len(male) == 0 || len(female) == 0 {
+		return unknown("性格、爱好等标签缺失")
+	}
+	var common []string
+	for id, name := range male {
+		if _, ok := female[id]; ok {
+			common = append(common, name)
+		}
+	}
+	if len(common) == 0 {
+		return &biz_omiai.DimensionScore{Score: tagFloor, Reason: "没有共同标签"}
+	}
+	sort.Strings(common)
+	ds := &biz_omiai.DimensionScore{
+		Score:  round2(tagFloor + (100-tagFloor)*math.Min(1, float64(len(common))/tagSaturation)),
+		Reason: "共同标签：" + strings.Join(common, "、"),
+	}
+	if len(common) >= 2 {
+		ds.Tags = append(ds.Tags, "兴趣相投")
+	}
+	return ds
+}
+
+// completenessDimension 资料完整度：取双方完整度的平均值，资料残缺的候选人排名靠后。
+// 读取保存资料时计算的完整度，同一配对的得分不随评分时间变化，也与参数顺序无关
+type completenessDimension struct{}
+
+func (completenessDimension) Name() string  { return DimensionCompleteness }
+func (completenessDimension) Label() string { return "资料完整度" }
+
+func (completenessDimension) Evaluate(p *Pair) *biz_omiai.DimensionScore {
+	male, female := p.Male.Completeness, p.Female.Completeness
+	if male <= 0 && female <= 0 {
+		return unknown("资料完整度尚未计算")
+	}
+	if male >= 100 && female >= 100 {
+		return &biz_omiai.DimensionScore{Score: 100, Reason: "双方资料完整"}
+	}
+	ds := &biz_omiai.DimensionScore{
+		Score:  round2((completeness(male) + completeness(female)) / 2),
+		Reason: fmt.Sprintf("资料完整度 男方%s、女方%s", completenessText(male), completenessText(female)),
+	}
+	if (male > 0 && male < 60) || (female > 0 && female < 60) {
+		ds.Tags = append(ds.Tags, "资料待完善")
+	}
+	return ds
+}
+
+// completeness 单方完整度得分，尚未计算时按中性分
+func completeness(n int) float64 {
+	if n <= 0 {
+		return neutralScore
+	}
+	return float64(min(n, 100))
+}
+
+func completenessText(n int) string {
+	if n <= 0 {
+		return "未计算"
+	}
+	return fmt.Sprintf("%d%%", n)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,42 @@
+package matching
+
+// GetMatchLevel 获取匹配等级
+func GetMatchLevel(score int) string {
+	if score >= 85 {
+		return "perfect" // 完美匹配
+	} else if score >= 70 {
+		return "good" // 良好匹配
+	} else if score >= 55 {
+		return "average" // 一般匹配
+	} else {
+		return "poor" // 不太匹配
+	}
+}
+
+// GetLevelText 获取匹配等级文本
+func GetLevelText(level string) string {
+	switch level {
+	case "perfect":
+		return "完美匹配"
+	case "good":
+		return "非常合适"
+	case "average":
+		return "可以尝试"
+	default:
+		return "不太合适"
+	}
+}
+
+// GetLevelColor 获取匹配等级颜色
+func GetLevelColor(level string) string {
+	switch level {
+	case "perfect":
+		return "#52c41a" // 绿色
+	case "good":
+		return "#1890ff" // 蓝色
+	case "average":
+		return "#faad14" // 橙色
+	default:
+		return "#ff4d4f" // 红色
+	}
+}
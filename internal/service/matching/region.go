@@ -0,0 +1,154 @@
+package matching
+
+import (
+	"fmt"
+	"sync"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+)
+
+// 地域维度各层级得分
+var regionLevelScore = map[biz_omiai.RegionLevel]float64{
+	biz_omiai.RegionSameDistrict:  100,
+	biz_omiai.RegionSameCity:      85,
+	biz_omiai.RegionSameProvince:  60,
+	biz_omiai.RegionCrossProvince: 25,
+}
+
+// 地域偏好对异地扣分的减免比例
+const (
+	relocationRelief       = 0.6  // 任一方接受迁居
+	bothLongDistanceRelief = 0.5  // 双方接受异地恋
+	oneLongDistanceRelief  = 0.25 // 一方接受异地恋
+)
+
+// RegionIndex 按 china_region 的省-市-区县层级归一化地区代码，结果常驻内存
+// 行政区划数据缺失或查询失败时按 6 位代码规则推断
+type RegionIndex struct {
+	regions biz_omiai.ChinaRegionInterface
+	cache   sync.Map // code => biz_omiai.RegionLocation
+}
+
+func NewRegionIndex(regions biz_omiai.ChinaRegionInterface) *RegionIndex {
+	return &RegionIndex{regions: regions}
+}
+
+// Locate 归一化所在地：以最细一级代码沿父级向上补全，直辖市的市级统一为 xx0100
+func (x *RegionIndex) Locate(l biz_omiai.RegionLocation) biz_omiai.RegionLocation {
+	code := l.DistrictCode
+	if code == "" {
+		code = l.CityCode
+	}
+	if code == "" {
+		code = l.ProvinceCode
+	}
+	if code == "" {
+		return l
+	}
+	if x == nil || x.regions == nil {
+		return l.Normalize()
+	}
+	if cached, ok := x.cache.Load(code); ok {
+		return cached.(biz_omiai.RegionLocation)
+	}
+
+	path, err := x.regions.GetFullPath(code)
+	if err != nil || len(path) == 0 {
+		// 查询失败不缓存，下次重试
+		return l.Normalize()
+	}
+	var out biz_omiai.RegionLocation
+	for _, r := range path {
+		switch r.Level {
+		case 1:
+			out.ProvinceCode = r.Code
+		case 2:
+			out.CityCode = r.Code
+		case 3:
+			out.DistrictCode = r.Code
+		}
+	}
+	out = out.Normalize()
+	x.cache.Store(code, out)
+	return out
+}
+
+// regionDimension 地域：同区县 > 同城 > 同省 > 跨省，工作地与房产所在地取最近的一组
+// 接受迁居或异地恋时按比例减免异地扣分
+type regionDimension struct {
+	index *RegionIndex
+}
+
+func (regionDimension) Name() string  { return DimensionRegion }
+func (regionDimension) Label() string { return "地域" }
+
+func (d regionDimension) Evaluate(p *Pair) *biz_omiai.DimensionScore {
+	match := &biz_omiai.RegionMatch{Level: biz_omiai.RegionUnknown}
+	for _, ml := range d.locations(p.Male) {
+		for _, fl := range d.locations(p.Female) {
+			if level := ml.Proximity(fl); level > match.Level {
+				match.Level = level
+				match.Locations = map[uint64]biz_omiai.RegionLocation{p.Male.ID: ml, p.Female.ID: fl}
+			}
+		}
+	}
+	match.Label = match.Level.String()
+	if match.Level == biz_omiai.RegionUnknown {
+		ds := unknown("地区信息缺失")
+		ds.Detail = match
+		return ds
+	}
+
+	ds := &biz_omiai.DimensionScore{Score: regionLevelScore[match.Level], Reason: match.Label, Detail: match}
+	switch match.Level {
+	case biz_omiai.RegionSameDistrict:
+		ds.Tags = append(ds.Tags, "同区县", "同城")
+	case biz_omiai.RegionSameCity:
+		ds.Tags = append(ds.Tags, "同城")
+	}
+	if match.Level >= biz_omiai.RegionSameCity {
+		return ds
+	}
+
+	relief, why := regionRelief(p.Male, p.Female)
+	if relief > 0 {
+		ds.Score = round2(ds.Score + (100-ds.Score)*relief)
+		match.Relaxed = why
+		ds.Reason = fmt.Sprintf("%s，%s", match.Label, why)
+		ds.Tags = append(ds.Tags, "可接受异地")
+	}
+	return ds
+}
+
+// locations 客户已填写的工作地与房产所在地（归一化后）
+func (d regionDimension) locations(c *biz_omiai.Client) []biz_omiai.RegionLocation {
+	var out []biz_omiai.RegionLocation
+	for _, l := range []biz_omiai.RegionLocation{c.WorkRegion(), c.HouseRegion()} {
+		if l.Empty() {
+			continue
+		}
+		if l = d.index.Locate(l); l.ProvinceCode != "" {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// regionRelief 地域偏好带来的减免比例及说明
+func regionRelief(male, female *biz_omiai.Client) (float64, string) {
+	switch {
+	case male.Relocation == biz_omiai.RegionPrefAccept && female.Relocation == biz_omiai.RegionPrefAccept:
+		return relocationRelief, "双方均接受迁居"
+	case male.Relocation == biz_omiai.RegionPrefAccept:
+		return relocationRelief, "男方接受迁居"
+	case female.Relocation == biz_omiai.RegionPrefAccept:
+		return relocationRelief, "女方接受迁居"
+	case male.LongDistance == biz_omiai.RegionPrefAccept && female.LongDistance == biz_omiai.RegionPrefAccept:
+		return bothLongDistanceRelief, "双方均接受异地恋"
+	case male.LongDistance == biz_omiai.RegionPrefAccept:
+		return oneLongDistanceRelief, "男方接受异地恋"
+	case female.LongDistance == biz_omiai.RegionPrefAccept:
+		return oneLongDistanceRelief, "女方接受异地恋"
+	}
+	return 0, ""
+}
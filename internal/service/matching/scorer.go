@@ -0,0 +1,119 @@
+package matching
+
+import (
+	"math"
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/conf"
+)
+
+// AlgorithmName 统一评分算法标识
+const AlgorithmName = "weighted-v5"
+
+// DefaultWeights 默认维度权重，可通过配置 match.weights 覆盖
+var DefaultWeights = map[string]float64{
+	DimensionAge:          0.20,
+	DimensionHeight:       0.10,
+	DimensionMarital:      0.10,
+	DimensionEducation:    0.15,
+	DimensionIncome:       0.15,
+	DimensionAsset:        0.10,
+	DimensionRequirement:  0.20,
+	DimensionPersonality:  0.10,
+	DimensionRegion:       0.10,
+	DimensionSemantic:     0.05,
+	DimensionTag:          0.05,
+	DimensionCompleteness: 0.05,
+}
+
+var _ biz_omiai.Scorer = (*WeightedScorer)(nil)
+
+// WeightedScorer 按维度加权求和的评分器
+type WeightedScorer struct {
+	name       string
+	dimensions []Dimension
+	weights    map[string]float64
+}
+
+// NewScorer 根据全局配置创建统一评分器，地域维度按 china_region 的层级解析地区代码
+func NewScorer(c *conf.Config, regions biz_omiai.ChinaRegionInterface) biz_omiai.Scorer {
+	var weights map[string]float64
+	if c != nil && c.Match != nil {
+		weights = c.Match.Weights
+	}
+	return NewWeightedScorer(AlgorithmName, weights, DefaultDimensions(NewRegionIndex(regions))...)
+}
+
+// NewWeightedScorer 使用指定权重创建评分器，weights 中未出现的维度使用默认权重，权重为 0 的维度不参与计算
+func NewWeightedScorer(name string, weights map[string]float64, dimensions ...Dimension) *WeightedScorer {
+	if len(dimensions) == 0 {
+		dimensions = DefaultDimensions(nil)
+	}
+	merged := make(map[string]float64, len(dimensions))
+	for _, d := range dimensions {
+		w, ok := weights[d.Name()]
+		if !ok {
+			w = DefaultWeights[d.Name()]
+		}
+		if w > 0 {
+			merged[d.Name()] = w
+		}
+	}
+	return &WeightedScorer{name: name, dimensions: dimensions, weights: merged}
+}
+
+func (s *WeightedScorer) Name() string {
+	return s.name
+}
+
+// Weights 返回生效中的维度权重（未归一化）
+func (s *WeightedScorer) Weights() map[string]float64 {
+	out := make(map[string]float64, len(s.weights))
+	for k, v := range s.weights {
+		out[k] = v
+	}
+	return out
+}
+
+// Score 计算综合得分：各维度得分按归一化权重加权求和
+func (s *WeightedScorer) Score(client, candidate *biz_omiai.Client) *biz_omiai.ScoreResult {
+	pair := NewPair(client, candidate)
+
+	var total float64
+	for _, d := range s.dimensions {
+		total += s.weights[d.Name()]
+	}
+
+	result := &biz_omiai.ScoreResult{Algorithm: s.name}
+	if total <= 0 {
+		result.Tags = []string{defaultTag}
+		return result
+	}
+
+	var sum float64
+	for _, d := range s.dimensions {
+		w, ok := s.weights[d.Name()]
+		if !ok {
+			continue
+		}
+		ds := d.Evaluate(pair)
+		ds.Name = d.Name()
+		ds.Label = d.Label()
+		ds.Weight = round2(w / total)
+		ds.Contribution = round2(ds.Score * w / total)
+		sum += ds.Score * w / total
+
+		result.Dimensions = append(result.Dimensions, ds)
+		result.Tags = append(result.Tags, ds.Tags...)
+		result.Violations = append(result.Violations, ds.Violations...)
+	}
+
+	result.Score = int(math.Round(math.Max(0, math.Min(100, sum))))
+	if len(result.Tags) == 0 {
+		result.Tags = []string{defaultTag}
+	}
+	return result
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,225 @@
+package matching
+
+import (
+	"testing"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWeightedScorer_Symmetric(t *testing.T) {
+	scorer := NewWeightedScorer(AlgorithmName, nil)
+
+	male := &biz_omiai.Client{ID: 1, Gender: 1, Age: 32, Height: 178, Education: 3, MaritalStatus: 1, Income: 15000, HouseStatus: 2, CarStatus: 2}
+	female := &biz_omiai.Client{ID: 2, Gender: 2, Age: 29, Height: 163, Education: 3, MaritalStatus: 1, Income: 12000, HouseStatus: 1, CarStatus: 1}
+
+	ab := scorer.Score(male, female)
+	ba := scorer.Score(female, male)
+
+	assert.Equal(t, ab.Score, ba.Score)
+	assert.Equal(t, ab.Tags, ba.Tags)
+	assert.Equal(t, ab.Score, scorer.Score(male, female).Score, "score must be reproducible")
+	assert.Contains(t, ab.Tags, "年龄相仿")
+	assert.Contains(t, ab.Tags, "身高般配")
+	assert.Len(t, ab.Dimensions, len(DefaultDimensions(nil)))
+}
+
+func TestWeightedScorer_Weights(t *testing.T) {
+	male := &biz_omiai.Client{ID: 1, Gender: 1, Age: 30, Education: 5}
+	female := &biz_omiai.Client{ID: 2, Gender: 2, Age: 28, Education: 1}
+
+	// 仅按年龄评分：年龄差 2 岁为理想区间
+	ageOnly := NewWeightedScorer("age-only", map[string]float64{
+		DimensionHeight: 0, DimensionMarital: 0, DimensionEducation: 0,
+		DimensionIncome: 0, DimensionAsset: 0, DimensionRequirement: 0, DimensionPersonality: 0, DimensionRegion: 0, DimensionSemantic: 0, DimensionTag: 0, DimensionCompleteness: 0,
+	})
+	result := ageOnly.Score(male, female)
+	assert.Equal(t, 100, result.Score)
+	assert.Len(t, result.Dimensions, 1)
+	assert.Equal(t, 1.0, result.Dimensions[0].Weight)
+
+	// 仅按学历评分：学历差 4 级
+	eduOnly := NewWeightedScorer("edu-only", map[string]float64{
+		DimensionAge: 0, DimensionHeight: 0, DimensionMarital: 0,
+		DimensionIncome: 0, DimensionAsset: 0, DimensionRequirement: 0, DimensionPersonality: 0, DimensionRegion: 0, DimensionSemantic: 0, DimensionTag: 0, DimensionCompleteness: 0,
+	})
+	assert.Equal(t, 30, eduOnly.Score(male, female).Score)
+}
+
+func TestRequirementDimension_Violations(t *testing.T) {
+	male := &biz_omiai.Client{ID: 1, Gender: 1, Age: 35, Height: 170,
+		PartnerRequirements: `{"min_age":25,"max_age":30}`}
+	female := &biz_omiai.Client{ID: 2, Gender: 2, Age: 33, Height: 165,
+		PartnerRequirements: `{"min_height":175}`}
+
+	ds := requirementDimension{}.Evaluate(NewPair(female, male))
+	assert.Equal(t, 0.0, ds.Score)
+	assert.Equal(t, []string{"男方要求：年龄不符(33岁)", "女方要求：身高不符(170cm)"}, ds.Violations)
+
+	// 自由文本的择偶要求不参与校验
+	male.PartnerRequirements = "温柔大气"
+	female.PartnerRequirements = ""
+	ds = requirementDimension{}.Evaluate(NewPair(male, female))
+	assert.Equal(t, neutralScore, ds.Score)
+	assert.Empty(t, ds.Violations)
+}
+
+func TestPersonalityDimension(t *testing.T) {
+	male := &biz_omiai.Client{ID: 1, Gender: 1}
+	female := &biz_omiai.Client{ID: 2, Gender: 2}
+
+	ds := personalityDimension{}.Evaluate(NewPair(male, female))
+	assert.Equal(t, neutralScore, ds.Score, "profiles missing")
+
+	male.Profile = &biz_omiai.PersonalityProfile{Scores: `{"openness":80,"family":90,"children":100}`}
+	female.Profile = &biz_omiai.PersonalityProfile{Scores: `{"openness":40,"family":80,"children":90}`}
+	ds = personalityDimension{}.Evaluate(NewPair(female, male))
+	// 性格 60 * 1 + 价值观 90 * 2 + 婚恋期望 90 * 2
+	assert.Equal(t, 84.0, ds.Score)
+	assert.Equal(t, []string{"价值观契合", "婚恋期望一致"}, ds.Tags)
+}
+
+func TestSemanticDimension(t *testing.T) {
+	male := &biz_omiai.Client{ID: 1, Gender: 1}
+	female := &biz_omiai.Client{ID: 2, Gender: 2}
+
+	ds := semanticDimension{}.Evaluate(NewPair(male, female))
+	assert.Equal(t, neutralScore, ds.Score, "embeddings missing")
+
+	embed := func(v biz_omiai.Vector) *biz_omiai.ClientEmbedding {
+		e := &biz_omiai.ClientEmbedding{Model: "test"}
+		e.SetValues(v)
+		return e
+	}
+	male.Embedding = embed(biz_omiai.Vector{1: 0.6, 2: 0.8})
+	female.Embedding = embed(biz_omiai.Vector{2: 0.6, 3: 0.8})
+	ds = semanticDimension{}.Evaluate(NewPair(female, male))
+	// 相似度 0.48 超过饱和线
+	assert.Equal(t, 100.0, ds.Score)
+	assert.Equal(t, []string{"资料描述相近"}, ds.Tags)
+
+	female.Embedding = embed(biz_omiai.Vector{3: 1})
+	ds = semanticDimension{}.Evaluate(NewPair(male, female))
+	assert.Equal(t, semanticFloor, ds.Score)
+	assert.Empty(t, ds.Tags)
+
+	female.Embedding.Model = "other"
+	ds = semanticDimension{}.Evaluate(NewPair(male, female))
+	assert.Equal(t, neutralScore, ds.Score, "vectors from different models are not comparable")
+}
+
+func TestTagDimension(t *testing.T) {
+	hobby := &biz_omiai.TagGroup{ID: 1, Name: "爱好", Scored: true}
+	risk := &biz_omiai.TagGroup{ID: 2, Name: "风险"}
+	tag := func(id uint64, name string, g *biz_omiai.TagGroup) *biz_omiai.ClientTag {
+		return &biz_omiai.ClientTag{TagID: id, Tag: &biz_omiai.Tag{ID: id, Name: name, GroupID: g.ID, Group: g}}
+	}
+	male := &biz_omiai.Client{ID: 1, Gender: 1}
+	female := &biz_omiai.Client{ID: 2, Gender: 2}
+
+	ds := tagDimension{}.Evaluate(NewPair(male, female))
+	assert.Equal(t, neutralScore, ds.Score, "tags missing")
+
+	// 不参与评分的分组不计入
+	male.TagLinks = []*biz_omiai.ClientTag{tag(1, "爬山", hobby), tag(2, "摄影", hobby), tag(9, "失联", risk)}
+	female.TagLinks = []*biz_omiai.ClientTag{tag(9, "失联", risk), tag(3, "烘焙", hobby)}
+	ds = tagDimension{}.Evaluate(NewPair(male, female))
+	assert.Equal(t, tagFloor, ds.Score)
+
+	female.TagLinks = append(female.TagLinks, tag(1, "爬山", hobby), tag(2, "摄影", hobby))
+	ds = tagDimension{}.Evaluate(NewPair(female, male))
+	assert.Equal(t, 80.0, ds.Score)
+	assert.Equal(t, "共同标签：摄影、爬山", ds.Reason)
+	assert.Equal(t, []string{"兴趣相投"}, ds.Tags)
+}
+
+func TestCompletenessDimension(t *testing.T) {
+	source := &biz_omiai.Client{ID: 1, Gender: 1, Completeness: 40}
+	complete := &biz_omiai.Client{ID: 2, Gender: 2, Completeness: 100}
+	partial := &biz_omiai.Client{ID: 3, Gender: 2, Completeness: 50}
+
+	// 取双方平均：客户本人资料残缺时候选人之间仍有区分
+	assert.Equal(t, 70.0, completenessDimension{}.Evaluate(NewPair(source, complete)).Score)
+	ds := completenessDimension{}.Evaluate(NewPair(source, partial))
+	assert.Equal(t, 45.0, ds.Score)
+	assert.Equal(t, []string{"资料待完善"}, ds.Tags)
+	// 与参数顺序无关
+	assert.Equal(t, ds, completenessDimension{}.Evaluate(NewPair(partial, source)))
+	assert.Equal(t, 100.0, completenessDimension{}.Evaluate(NewPair(&biz_omiai.Client{ID: 5, Gender: 1, Completeness: 100}, complete)).Score)
+
+	// 尚未计算完整度的一方按中性分
+	assert.Equal(t, 75.0, completenessDimension{}.Evaluate(NewPair(&biz_omiai.Client{ID: 4, Gender: 1}, complete)).Score)
+	assert.Equal(t, neutralScore, completenessDimension{}.Evaluate(NewPair(&biz_omiai.Client{ID: 4, Gender: 1}, &biz_omiai.Client{ID: 6, Gender: 2})).Score)
+}
+
+// stubRegions 仅实现 GetFullPath 的行政区划数据
+type stubRegions struct {
+	biz_omiai.ChinaRegionInterface
+	regions map[string]*biz_omiai.ChinaRegion
+	calls   int
+}
+
+func (s *stubRegions) GetFullPath(code string) ([]*biz_omiai.ChinaRegion, error) {
+	s.calls++
+	var path []*biz_omiai.ChinaRegion
+	for r := s.regions[code]; r != nil; r = s.regions[r.ParentCode] {
+		path = append([]*biz_omiai.ChinaRegion{r}, path...)
+	}
+	return path, nil
+}
+
+func TestRegionDimension(t *testing.T) {
+	regions := &stubRegions{regions: map[string]*biz_omiai.ChinaRegion{
+		"110000": {Code: "110000", Level: 1},
+		// 未执行直辖市修复脚本：区县直接挂在省级下
+		"110105": {Code: "110105", ParentCode: "110000", Level: 3},
+		"110108": {Code: "110108", ParentCode: "110000", Level: 3},
+		"420000": {Code: "420000", Level: 1},
+		"420100": {Code: "420100", ParentCode: "420000", Level: 2},
+		// 省直辖县级市，代码规则推断的 429000 并不存在
+		"429004": {Code: "429004", ParentCode: "420000", Level: 2},
+	}}
+	dim := regionDimension{index: NewRegionIndex(regions)}
+
+	chaoyang := &biz_omiai.Client{ID: 1, Gender: 1, WorkDistrictCode: "110105"}
+	haidian := &biz_omiai.Client{ID: 2, Gender: 2, WorkDistrictCode: "110108"}
+	ds := dim.Evaluate(NewPair(chaoyang, haidian))
+	assert.Equal(t, 85.0, ds.Score)
+	assert.Equal(t, []string{"同城"}, ds.Tags)
+
+	// 工作地跨省，房产所在地同区县：取最近的一组
+	haidian.HouseDistrictCode = "110105"
+	haidian.WorkCityCode = "420100"
+	haidian.WorkDistrictCode = ""
+	ds = dim.Evaluate(NewPair(chaoyang, haidian))
+	assert.Equal(t, 100.0, ds.Score)
+	assert.Contains(t, ds.Tags, "同区县")
+
+	wuhan := &biz_omiai.Client{ID: 3, Gender: 2, WorkCityCode: "420100"}
+	xiantao := &biz_omiai.Client{ID: 4, Gender: 1, WorkCityCode: "429004"}
+	ds = dim.Evaluate(NewPair(wuhan, xiantao))
+	assert.Equal(t, 60.0, ds.Score)
+	match := ds.Detail.(*biz_omiai.RegionMatch)
+	assert.Equal(t, "420000", match.Locations[xiantao.ID].ProvinceCode)
+
+	// 跨省：接受迁居减免 60% 的扣分
+	ds = dim.Evaluate(NewPair(chaoyang, wuhan))
+	assert.Equal(t, 25.0, ds.Score)
+	wuhan.Relocation = biz_omiai.RegionPrefAccept
+	ds = dim.Evaluate(NewPair(chaoyang, wuhan))
+	assert.Equal(t, 70.0, ds.Score)
+	assert.Equal(t, "女方接受迁居", ds.Detail.(*biz_omiai.RegionMatch).Relaxed)
+	assert.Contains(t, ds.Tags, "可接受异地")
+
+	// 解析结果缓存，同一代码只查询一次
+	calls := regions.calls
+	dim.Evaluate(NewPair(chaoyang, wuhan))
+	assert.Equal(t, calls, regions.calls)
+
+	// 地域明细同时用于对比详情
+	scorer := NewWeightedScorer(AlgorithmName, nil, DefaultDimensions(NewRegionIndex(regions))...)
+	comp := biz_omiai.NewComparison(chaoyang, wuhan, scorer.Score(chaoyang, wuhan))
+	assert.Equal(t, "跨省", comp.Region["label"])
+	assert.Equal(t, "女方接受迁居", comp.Region["relaxed"])
+}
@@ -0,0 +1,398 @@
+package meeting
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+
+	"github.com/iWuxc/go-wit/log"
+)
+
+// 未设置营业时间的场地或自行约定地点时，推荐时段的范围
+const (
+	defaultSlotOpen  = "09:00"
+	defaultSlotClose = "22:00"
+)
+
+type Service struct {
+	repo      biz_omiai.MeetingInterface
+	intro     biz_omiai.IntroductionInterface
+	match     biz_omiai.MatchInterface
+	reminders biz_omiai.ReminderInterface
+}
+
+func NewService(repo biz_omiai.MeetingInterface, intro biz_omiai.IntroductionInterface,
+	match biz_omiai.MatchInterface, reminders biz_omiai.ReminderInterface) *Service {
+	return &Service{repo: repo, intro: intro, match: match, reminders: reminders}
+}
+
+// CreateVenue 新增场地
+func (s *Service) CreateVenue(ctx context.Context, v *biz_omiai.Venue) error {
+	if err := v.Validate(); err != nil {
+		return err
+	}
+	return s.repo.CreateVenue(ctx, v)
+}
+
+// UpdateVenue 修改场地，已预约的见面不受影响
+func (s *Service) UpdateVenue(ctx context.Context, v *biz_omiai.Venue) error {
+	if err := v.Validate(); err != nil {
+		return err
+	}
+	return s.repo.UpdateVenue(ctx, v)
+}
+
+// SetAvailability 替换客户的每周可约时间，传空列表表示随时可约
+func (s *Service) SetAvailability(ctx context.Context, clientID uint64, list []*biz_omiai.ClientAvailability) error {
+	for _, a := range list {
+		if err := a.Validate(); err != nil {
+			return err
+		}
+	}
+	return s.repo.SetAvailability(ctx, clientID, list)
+}
+
+// Book 预约见面：关联介绍时双方须已同意，预约后介绍进入已约见面；
+// 场地已满、场地未营业或客户时间重叠时不能预约，红娘时间重叠或不在客户可约时间内时 force 为 true 才能预约
+func (s *Service) Book(ctx context.Context, m *biz_omiai.Meeting, force bool) error {
+	if (m.IntroductionID == nil) == (m.MatchRecordID == nil) {
+		return biz_omiai.ErrMeetingSource
+	}
+	var intro *biz_omiai.Introduction
+	if m.IntroductionID != nil {
+		var err error
+		if intro, err = s.intro.Get(ctx, *m.IntroductionID); err != nil {
+			return err
+		}
+		if intro.Status != biz_omiai.IntroStatusBAccepted && intro.Status != biz_omiai.IntroStatusScheduled {
+			return biz_omiai.ErrMeetingIntroStatus
+		}
+		m.ClientAID, m.ClientBID = intro.ClientAID, intro.ClientBID
+		if m.MatchmakerID == 0 {
+			m.MatchmakerID = intro.MatchmakerID
+		}
+	} else {
+		record, err := s.match.Get(ctx, *m.MatchRecordID)
+		if err != nil {
+			return err
+		}
+		if record.Status == biz_omiai.MatchStatusBroken {
+			return biz_omiai.ErrMeetingMatchBroken
+		}
+		m.ClientAID, m.ClientBID = record.MaleClientID, record.FemaleClientID
+	}
+
+	venue, err := s.prepare(ctx, m)
+	if err != nil {
+		return err
+	}
+	m.Status = biz_omiai.MeetingStatusBooked
+	if err := s.repo.Create(ctx, m, s.check(m, venue, force)); err != nil {
+		return err
+	}
+
+	if intro != nil {
+		if _, err := s.intro.Transition(ctx, intro.ID, biz_omiai.IntroStatusScheduled, &biz_omiai.IntroductionChange{
+			Operator:     m.Operator,
+			Remark:       "预约见面",
+			MeetingAt:    &m.StartAt,
+			MeetingPlace: m.Place,
+		}); err != nil {
+			// 介绍已被他人流转时撤销本次预约
+			if ferr := s.repo.Finish(ctx, m.ID, biz_omiai.MeetingStatusCancelled, "介绍状态已变更，预约撤销", m.Operator); ferr != nil {
+				log.WithContext(ctx).Errorf("meeting %d: revert booking err:%v", m.ID, ferr)
+			}
+			return err
+		}
+	}
+	s.remind(ctx, m)
+	return nil
+}
+
+// Reschedule 改约时间或地点，重新检测冲突并生成提醒
+func (s *Service) Reschedule(ctx context.Context, change *biz_omiai.Meeting, force bool) (*biz_omiai.Meeting, error) {
+	m, err := s.repo.Get(ctx, change.ID)
+	if err != nil {
+		return nil, err
+	}
+	if m.Status != biz_omiai.MeetingStatusBooked {
+		return nil, biz_omiai.ErrMeetingStatus
+	}
+	m.VenueID, m.Place, m.StartAt, m.EndAt = change.VenueID, change.Place, change.StartAt, change.EndAt
+	m.Remark, m.Operator = change.Remark, change.Operator
+	m.Venue = nil
+
+	venue, err := s.prepare(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Reschedule(ctx, m, s.check(m, venue, force)); err != nil {
+		return nil, err
+	}
+	m.Venue = venue
+
+	if m.IntroductionID != nil {
+		intro, err := s.intro.Get(ctx, *m.IntroductionID)
+		if err == nil && intro.Status == biz_omiai.IntroStatusScheduled {
+			_, err = s.intro.Transition(ctx, intro.ID, biz_omiai.IntroStatusScheduled, &biz_omiai.IntroductionChange{
+				Operator:     m.Operator,
+				Remark:       "见面改约",
+				MeetingAt:    &m.StartAt,
+				MeetingPlace: m.Place,
+			})
+		}
+		if err != nil {
+			log.WithContext(ctx).Errorf("meeting %d: sync introduction %d err:%v", m.ID, *m.IntroductionID, err)
+		}
+	}
+	if err := s.reminders.CancelByMeeting(m.ID); err != nil {
+		log.WithContext(ctx).Errorf("meeting %d: cancel reminders err:%v", m.ID, err)
+	}
+	s.remind(ctx, m)
+	return m, nil
+}
+
+// Finish 标记见面结果：已见面时介绍进入已见面，保留回访提醒；取消或爽约时撤销未完成的提醒
+func (s *Service) Finish(ctx context.Context, id uint64, status int8, feedback, operator string) error {
+	if status != biz_omiai.MeetingStatusCompleted && status != biz_omiai.MeetingStatusCancelled && status != biz_omiai.MeetingStatusNoShow {
+		return biz_omiai.ErrMeetingStatus
+	}
+	m, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Finish(ctx, id, status, feedback, operator); err != nil {
+		return err
+	}
+
+	if status != biz_omiai.MeetingStatusCompleted {
+		if err := s.reminders.CancelByMeeting(id); err != nil {
+			log.WithContext(ctx).Errorf("meeting %d: cancel reminders err:%v", id, err)
+		}
+		return nil
+	}
+	if m.IntroductionID != nil {
+		intro, err := s.intro.Get(ctx, *m.IntroductionID)
+		if err == nil && intro.Status == biz_omiai.IntroStatusScheduled {
+			_, err = s.intro.Transition(ctx, intro.ID, biz_omiai.IntroStatusMet, &biz_omiai.IntroductionChange{
+				Operator: operator,
+				Remark:   feedback,
+			})
+		}
+		if err != nil {
+			log.WithContext(ctx).Errorf("meeting %d: introduction %d met err:%v", id, *m.IntroductionID, err)
+		}
+	}
+	return nil
+}
+
+// SlotQuery 推荐时段的查询条件
+type SlotQuery struct {
+	ClientIDs    []uint64
+	VenueID      uint64
+	MatchmakerID uint64
+	Date         time.Time
+	Duration     time.Duration
+}
+
+// Slots 某天内双方均可约、场地营业且无任何冲突的时段，按 MeetingSlotStep 间隔推荐
+func (s *Service) Slots(ctx context.Context, q *SlotQuery) ([]*biz_omiai.TimeRange, error) {
+	if q.Duration <= 0 {
+		q.Duration = biz_omiai.DefaultMeetingDuration
+	}
+	open, close := defaultSlotOpen, defaultSlotClose
+	var venue *biz_omiai.Venue
+	if q.VenueID > 0 {
+		v, err := s.repo.GetVenue(ctx, q.VenueID)
+		if err != nil {
+			return nil, err
+		}
+		if !v.IsEnabled {
+			return nil, biz_omiai.ErrVenueDisabled
+		}
+		if v.OpenTime != "" {
+			open, close = v.OpenTime, v.CloseTime
+		}
+		venue = v
+	}
+
+	day := time.Date(q.Date.Year(), q.Date.Month(), q.Date.Day(), 0, 0, 0, 0, q.Date.Location())
+	from, err1 := clockAt(day, open)
+	to, err2 := clockAt(day, close)
+	if err1 != nil || err2 != nil {
+		return nil, biz_omiai.ErrVenueHours
+	}
+	booked, err := s.repo.Overlapping(ctx, from, to, q.VenueID, q.ClientIDs, q.MatchmakerID, 0)
+	if err != nil {
+		return nil, err
+	}
+	avail, err := s.repo.Availability(ctx, q.ClientIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	slots := make([]*biz_omiai.TimeRange, 0)
+	for start := from; !start.Add(q.Duration).After(to); start = start.Add(biz_omiai.MeetingSlotStep) {
+		if start.Before(now) {
+			continue
+		}
+		m := &biz_omiai.Meeting{StartAt: start, EndAt: start.Add(q.Duration), MatchmakerID: q.MatchmakerID}
+		if len(q.ClientIDs) > 0 {
+			m.ClientAID = q.ClientIDs[0]
+		}
+		if len(q.ClientIDs) > 1 {
+			m.ClientBID = q.ClientIDs[1]
+		}
+		if len(conflicts(m, venue, booked, avail)) == 0 {
+			slots = append(slots, &biz_omiai.TimeRange{StartAt: m.StartAt, EndAt: m.EndAt})
+		}
+	}
+	return slots, nil
+}
+
+func clockAt(day time.Time, clock string) (time.Time, error) {
+	if clock == "24:00" {
+		return day.AddDate(0, 0, 1), nil
+	}
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return day.Add(time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute), nil
+}
+
+// prepare 补全结束时间与地点，返回预约的场地
+func (s *Service) prepare(ctx context.Context, m *biz_omiai.Meeting) (*biz_omiai.Venue, error) {
+	if m.EndAt.IsZero() {
+		m.EndAt = m.StartAt.Add(biz_omiai.DefaultMeetingDuration)
+	}
+	if m.StartAt.IsZero() || !m.EndAt.After(m.StartAt) {
+		return nil, biz_omiai.ErrMeetingTime
+	}
+	if m.VenueID == nil || *m.VenueID == 0 {
+		m.VenueID = nil
+		return nil, nil
+	}
+	venue, err := s.repo.GetVenue(ctx, *m.VenueID)
+	if err != nil {
+		return nil, err
+	}
+	if !venue.IsEnabled {
+		return nil, biz_omiai.ErrVenueDisabled
+	}
+	if m.Place == "" {
+		m.Place = venue.Name
+		if venue.Address != "" {
+			m.Place = fmt.Sprintf("%s（%s）", venue.Name, venue.Address)
+		}
+	}
+	return venue, nil
+}
+
+// check 冲突检测，存在不可强制的冲突或未强制时返回 MeetingConflictError；由 repo 在锁定双方客户与场地后调用
+func (s *Service) check(m *biz_omiai.Meeting, venue *biz_omiai.Venue, force bool) biz_omiai.MeetingCheck {
+	return func(booked []*biz_omiai.Meeting, avail map[uint64][]*biz_omiai.ClientAvailability) error {
+		list := conflicts(m, venue, booked, avail)
+		for _, c := range list {
+			if c.Hard || !force {
+				return &biz_omiai.MeetingConflictError{Conflicts: list}
+			}
+		}
+		return nil
+	}
+}
+
+// conflicts 计算 m 与已预约见面、场地营业时间及客户可约时间的冲突，booked 可包含时间不重叠的见面
+func conflicts(m *biz_omiai.Meeting, venue *biz_omiai.Venue, booked []*biz_omiai.Meeting,
+	avail map[uint64][]*biz_omiai.ClientAvailability) []*biz_omiai.MeetingConflict {
+	var list []*biz_omiai.MeetingConflict
+	if venue != nil && !venue.Opens(m.StartAt, m.EndAt) {
+		list = append(list, &biz_omiai.MeetingConflict{
+			Kind:    biz_omiai.ConflictVenueHours,
+			Hard:    true,
+			Message: fmt.Sprintf("场地「%s」营业时间为 %s-%s", venue.Name, venue.OpenTime, venue.CloseTime),
+		})
+	}
+
+	var atVenue int
+	for _, o := range booked {
+		if o.ID == m.ID || !o.Overlaps(m.StartAt, m.EndAt) {
+			continue
+		}
+		conflict := func(kind string, hard bool, clientID uint64, message string) {
+			list = append(list, &biz_omiai.MeetingConflict{
+				Kind: kind, Hard: hard, MeetingID: o.ID, ClientID: clientID,
+				StartAt: &o.StartAt, EndAt: &o.EndAt, Message: message,
+			})
+		}
+		for _, clientID := range []uint64{m.ClientAID, m.ClientBID} {
+			if clientID > 0 && o.HasClient(clientID) {
+				conflict(biz_omiai.ConflictClient, true, clientID,
+					fmt.Sprintf("客户 %d 在 %s 已有见面", clientID, o.StartAt.Format("01-02 15:04")))
+			}
+		}
+		if m.MatchmakerID > 0 && o.MatchmakerID == m.MatchmakerID {
+			conflict(biz_omiai.ConflictMatchmaker, false, 0,
+				fmt.Sprintf("红娘在 %s 已有见面", o.StartAt.Format("01-02 15:04")))
+		}
+		if venue != nil && o.VenueID != nil && *o.VenueID == venue.ID {
+			atVenue++
+		}
+	}
+	// 以重叠见面总数近似同时占用数，宁可少约不超约
+	if venue != nil && atVenue >= venue.Capacity {
+		list = append(list, &biz_omiai.MeetingConflict{
+			Kind:    biz_omiai.ConflictVenue,
+			Hard:    true,
+			Message: fmt.Sprintf("场地「%s」该时段已约满", venue.Name),
+		})
+	}
+
+	for _, clientID := range []uint64{m.ClientAID, m.ClientBID} {
+		if clientID > 0 && !biz_omiai.Available(avail[clientID], m.StartAt, m.EndAt) {
+			list = append(list, &biz_omiai.MeetingConflict{
+				Kind:     biz_omiai.ConflictAvailability,
+				ClientID: clientID,
+				Message:  fmt.Sprintf("不在客户 %d 的可约时间内", clientID),
+			})
+		}
+	}
+	return list
+}
+
+// remind 生成见面前提醒与见面后回访提醒，失败仅记录日志
+func (s *Service) remind(ctx context.Context, m *biz_omiai.Meeting) {
+	names := fmt.Sprintf("客户 %d 与客户 %d", m.ClientAID, m.ClientBID)
+	if full, err := s.repo.Get(ctx, m.ID); err == nil && full.ClientA != nil && full.ClientB != nil {
+		names = fmt.Sprintf("%s 与 %s", full.ClientA.Name, full.ClientB.Name)
+	}
+	at := m.StartAt.Format("01-02 15:04")
+
+	now := time.Now()
+	var tasks []*biz_omiai.ReminderTask
+	for _, before := range biz_omiai.MeetingRemindBefore {
+		scheduled := m.StartAt.Add(-before)
+		if scheduled.Before(now) {
+			continue
+		}
+		tasks = append(tasks, &biz_omiai.ReminderTask{
+			ScheduledAt: scheduled,
+			Content:     fmt.Sprintf("见面提醒：%s 将于 %s 在「%s」见面，请提前与双方确认行程", names, at, m.Place),
+		})
+	}
+	tasks = append(tasks, &biz_omiai.ReminderTask{
+		ScheduledAt: m.EndAt.Add(biz_omiai.MeetingFollowUpDelay),
+		Content:     fmt.Sprintf("见面回访：%s 已于 %s 见面，请回访双方感受并记录见面结果", names, at),
+	})
+	for _, task := range tasks {
+		task.ClientID = int64(m.ClientAID)
+		task.MeetingID = m.ID
+		task.Status = "pending"
+		if err := s.reminders.CreateTask(task); err != nil {
+			log.WithContext(ctx).Errorf("meeting %d: create reminder err:%v", m.ID, err)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package meeting
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/data"
+	"omiai-server/internal/data/omiai"
+	"omiai-server/internal/service/event"
+	"omiai-server/internal/service/matching"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	assert.NoError(t, db.AutoMigrate(&biz_omiai.Client{}, &biz_omiai.PersonalityProfile{}, &biz_omiai.ClientEmbedding{}, &biz_omiai.ClientTag{}, &biz_omiai.Tag{}, &biz_omiai.TagGroup{},
+		&biz_omiai.Introduction{}, &biz_omiai.IntroductionHistory{}, &biz_omiai.MatchRecord{}, &biz_omiai.Recommendation{}, &biz_omiai.ReminderTask{},
+		&biz_omiai.Venue{}, &biz_omiai.ClientAvailability{}, &biz_omiai.Meeting{}))
+
+	for _, id := range []uint64{1, 2, 11, 12} {
+		gender := int8(1)
+		if id > 10 {
+			gender = 2
+		}
+		assert.NoError(t, db.Create(&biz_omiai.Client{ID: id, Name: "客户", Gender: gender, Status: biz_omiai.ClientStatusSingle}).Error)
+	}
+
+	d := &data.DB{DB: db}
+	bus := event.NewBus()
+	scorer := matching.NewScorer(nil, nil)
+	repo := omiai.NewMeetingRepo(d)
+	intros := omiai.NewIntroductionRepo(d, scorer, bus)
+	svc := NewService(repo, intros, omiai.NewMatchRepo(d, scorer, bus), omiai.NewReminderRepo(d))
+
+	// 介绍推进到双方同意，红娘均为 9
+	accepted := func(a, b uint64) uint64 {
+		intro := &biz_omiai.Introduction{ClientAID: a, ClientBID: b, MatchmakerID: 9}
+		assert.NoError(t, intros.Create(ctx, intro, "红娘", nil))
+		for _, to := range []int8{biz_omiai.IntroStatusSentToA, biz_omiai.IntroStatusAAccepted, biz_omiai.IntroStatusSentToB, biz_omiai.IntroStatusBAccepted} {
+			_, err := intros.Transition(ctx, intro.ID, to, &biz_omiai.IntroductionChange{Operator: "红娘"})
+			assert.NoError(t, err)
+		}
+		return intro.ID
+	}
+	intro1, intro2 := accepted(1, 11), accepted(2, 12)
+
+	venue := &biz_omiai.Venue{Name: "咖啡馆", OpenTime: "10:00", CloseTime: "22:00", IsEnabled: true}
+	assert.NoError(t, svc.CreateVenue(ctx, venue))
+	assert.Equal(t, 1, venue.Capacity)
+	assert.ErrorIs(t, svc.CreateVenue(ctx, &biz_omiai.Venue{Name: "x", OpenTime: "22:00", CloseTime: "10:00"}), biz_omiai.ErrVenueHours)
+
+	now := time.Now()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local).AddDate(0, 0, 3)
+	at := func(hour, minute int) time.Time {
+		return day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+	kinds := func(err error) []string {
+		var conflict *biz_omiai.MeetingConflictError
+		if !errors.As(err, &conflict) {
+			return nil
+		}
+		var list []string
+		for _, c := range conflict.Conflicts {
+			list = append(list, c.Kind)
+		}
+		return list
+	}
+
+	assert.ErrorIs(t, svc.Book(ctx, &biz_omiai.Meeting{StartAt: at(14, 0)}, false), biz_omiai.ErrMeetingSource)
+
+	// 预约后介绍进入已约见面，生成两条见面前提醒和一条回访提醒
+	m1 := &biz_omiai.Meeting{IntroductionID: &intro1, VenueID: &venue.ID, StartAt: at(14, 0), Operator: "红娘"}
+	assert.NoError(t, svc.Book(ctx, m1, false))
+	assert.Equal(t, at(15, 30), m1.EndAt)
+	assert.Equal(t, "咖啡馆", m1.Place)
+	assert.Equal(t, uint64(9), m1.MatchmakerID)
+	intro, err := intros.Get(ctx, intro1)
+	assert.NoError(t, err)
+	assert.Equal(t, int8(biz_omiai.IntroStatusScheduled), intro.Status)
+	assert.True(t, intro.MeetingAt.Equal(m1.StartAt))
+	var tasks []*biz_omiai.ReminderTask
+	assert.NoError(t, db.Where("meeting_id = ? AND status = ?", m1.ID, "pending").Order("scheduled_at").Find(&tasks).Error)
+	assert.Len(t, tasks, 3)
+	assert.True(t, tasks[0].ScheduledAt.Equal(at(14, 0).Add(-24*time.Hour)))
+	assert.True(t, tasks[2].ScheduledAt.Equal(at(17, 30)))
+
+	// 客户时间重叠、场地已满、场地未营业均不可强制
+	err = svc.Book(ctx, &biz_omiai.Meeting{IntroductionID: &intro1, StartAt: at(15, 0)}, true)
+	assert.ErrorIs(t, err, biz_omiai.ErrMeetingConflict)
+	assert.Equal(t, []string{biz_omiai.ConflictClient, biz_omiai.ConflictClient, biz_omiai.ConflictMatchmaker}, kinds(err))
+	err = svc.Book(ctx, &biz_omiai.Meeting{IntroductionID: &intro2, VenueID: &venue.ID, StartAt: at(15, 0), MatchmakerID: 8}, true)
+	assert.Equal(t, []string{biz_omiai.ConflictVenue}, kinds(err))
+	err = svc.Book(ctx, &biz_omiai.Meeting{IntroductionID: &intro2, VenueID: &venue.ID, StartAt: at(21, 0), MatchmakerID: 8}, true)
+	assert.Equal(t, []string{biz_omiai.ConflictVenueHours}, kinds(err))
+
+	// 不在可约时间内须强制预约
+	assert.ErrorIs(t, svc.SetAvailability(ctx, 2, []*biz_omiai.ClientAvailability{{Weekday: 7, StartTime: "18:00", EndTime: "22:00"}}), biz_omiai.ErrAvailabilityInvalid)
+	assert.NoError(t, svc.SetAvailability(ctx, 2, []*biz_omiai.ClientAvailability{{Weekday: int8(day.Weekday()), StartTime: "18:00", EndTime: "22:00"}}))
+	m2 := &biz_omiai.Meeting{IntroductionID: &intro2, Place: "公园", StartAt: at(16, 0)}
+	err = svc.Book(ctx, m2, false)
+	assert.Equal(t, []string{biz_omiai.ConflictAvailability}, kinds(err))
+	assert.NoError(t, svc.Book(ctx, m2, true))
+
+	// 10:00-22:00 共 22 个起点，13:00-17:00 的 9 个起点与两场见面重叠
+	slots, err := svc.Slots(ctx, &SlotQuery{ClientIDs: []uint64{1, 11}, VenueID: venue.ID, MatchmakerID: 9, Date: day})
+	assert.NoError(t, err)
+	assert.Len(t, slots, 13)
+	for _, slot := range slots {
+		assert.False(t, m1.Overlaps(slot.StartAt, slot.EndAt))
+		assert.False(t, m2.Overlaps(slot.StartAt, slot.EndAt))
+	}
+
+	// 改约后旧提醒取消，重新生成提醒，介绍同步见面时间
+	_, err = svc.Reschedule(ctx, &biz_omiai.Meeting{ID: m1.ID, VenueID: &venue.ID, StartAt: at(18, 0), Operator: "红娘"}, false)
+	assert.NoError(t, err)
+	var pending, cancelled int64
+	db.Model(&biz_omiai.ReminderTask{}).Where("meeting_id = ? AND status = ?", m1.ID, "pending").Count(&pending)
+	db.Model(&biz_omiai.ReminderTask{}).Where("meeting_id = ? AND status = ?", m1.ID, "cancelled").Count(&cancelled)
+	assert.Equal(t, int64(3), pending)
+	assert.Equal(t, int64(3), cancelled)
+	intro, _ = intros.Get(ctx, intro1)
+	assert.True(t, intro.MeetingAt.Equal(at(18, 0)))
+
+	// 已见面后介绍进入已见面，回访提醒保留；取消后提醒撤销
+	assert.NoError(t, svc.Finish(ctx, m1.ID, biz_omiai.MeetingStatusCompleted, "聊得不错", "红娘"))
+	assert.ErrorIs(t, svc.Finish(ctx, m1.ID, biz_omiai.MeetingStatusCancelled, "", "红娘"), biz_omiai.ErrMeetingStatus)
+	intro, _ = intros.Get(ctx, intro1)
+	assert.Equal(t, int8(biz_omiai.IntroStatusMet), intro.Status)
+	db.Model(&biz_omiai.ReminderTask{}).Where("meeting_id = ? AND status = ?", m1.ID, "pending").Count(&pending)
+	assert.Equal(t, int64(3), pending)
+
+	assert.NoError(t, svc.Finish(ctx, m2.ID, biz_omiai.MeetingStatusCancelled, "", "红娘"))
+	db.Model(&biz_omiai.ReminderTask{}).Where("meeting_id = ? AND status = ?", m2.ID, "pending").Count(&pending)
+	assert.Equal(t, int64(0), pending)
+
+	// 同一客户的并发预约依次检测，只有一个成功
+	var (
+		wg   sync.WaitGroup
+		errs = make([]error, 2)
+	)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = svc.Book(ctx, &biz_omiai.Meeting{IntroductionID: &intro2, Place: "公园", StartAt: at(9, 0), Operator: "红娘"}, true)
+		}(i)
+	}
+	wg.Wait()
+	booked := 0
+	for _, err := range errs {
+		if err == nil {
+			booked++
+			continue
+		}
+		assert.Contains(t, kinds(err), biz_omiai.ConflictClient)
+	}
+	assert.Equal(t, 1, booked)
+}
@@ -0,0 +1,125 @@
+package pair_history
+
+import (
+	"context"
+	"time"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/conf"
+
+	"github.com/iWuxc/go-wit/log"
+)
+
+// DefaultBreakupCooldownDays 未配置 match.breakup_cooldown_days 时的分手冷静期
+const DefaultBreakupCooldownDays = 90
+
+// declineCloseReasons 介绍关闭原因中表示配对不合适的原因，值为是否由 A 方拒绝
+var declineCloseReasons = map[string]bool{
+	biz_omiai.IntroCloseADeclined:  true,
+	biz_omiai.IntroCloseBDeclined:  false,
+	biz_omiai.IntroCloseNotMatched: true,
+}
+
+type Service struct {
+	repo     biz_omiai.PairHistoryInterface
+	cooldown time.Duration
+}
+
+// NewService 创建服务并订阅分手、介绍关闭事件，自动记录负反馈
+func NewService(c *conf.Config, repo biz_omiai.PairHistoryInterface, events biz_omiai.EventBus) *Service {
+	days := DefaultBreakupCooldownDays
+	if c != nil && c.Match != nil && c.Match.BreakupCooldownDays > 0 {
+		days = c.Match.BreakupCooldownDays
+	}
+	s := &Service{repo: repo, cooldown: time.Duration(days) * 24 * time.Hour}
+	events.Subscribe(biz_omiai.EventMatchStatusChanged, s.onMatchStatusChanged)
+	events.Subscribe(biz_omiai.EventIntroductionClosed, s.onIntroductionClosed)
+	return s
+}
+
+// Reject 红娘手动标记候选人：拒绝、屏蔽或暂不考虑至指定日期
+func (s *Service) Reject(ctx context.Context, h *biz_omiai.PairHistory) error {
+	if h.ClientID == h.CandidateID {
+		return biz_omiai.ErrPairSameClient
+	}
+	switch h.Kind {
+	case biz_omiai.PairKindDeclined, biz_omiai.PairKindBlocked:
+		h.ExpiredAt = nil
+	case biz_omiai.PairKindSnoozed:
+		if h.ExpiredAt == nil || !h.ExpiredAt.After(time.Now()) {
+			return biz_omiai.ErrPairExpiredAt
+		}
+	default:
+		return biz_omiai.ErrPairKind
+	}
+	if _, ok := biz_omiai.PairReasonText[h.ReasonCode]; !ok {
+		return biz_omiai.ErrPairReason
+	}
+	h.SourceType = biz_omiai.PairSourceManual
+	h.SourceID = 0
+	return s.repo.Create(ctx, h)
+}
+
+// List 客户相关的全部负反馈记录
+func (s *Service) List(ctx context.Context, clientID uint64) ([]*biz_omiai.PairHistory, error) {
+	return s.repo.List(ctx, clientID)
+}
+
+// Revoke 撤销手动标记，系统根据介绍、分手生成的记录不可撤销
+func (s *Service) Revoke(ctx context.Context, id uint64) error {
+	h, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if h.SourceType != biz_omiai.PairSourceManual {
+		return biz_omiai.ErrPairNotManual
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *Service) onMatchStatusChanged(ctx context.Context, event *biz_omiai.Event) {
+	e, ok := event.Payload.(*biz_omiai.MatchStatusChanged)
+	if !ok || e.NewStatus != biz_omiai.MatchStatusBroken {
+		return
+	}
+	h := &biz_omiai.PairHistory{
+		ClientID:    e.MaleClientID,
+		CandidateID: e.FemaleClientID,
+		Kind:        biz_omiai.PairKindBrokenUp,
+		ReasonCode:  biz_omiai.PairReasonBreakup,
+		Remark:      e.Reason,
+		SourceType:  biz_omiai.PairSourceMatchRecord,
+		SourceID:    e.RecordID,
+		Operator:    e.Operator,
+	}
+	if err := s.repo.RecordBreakup(ctx, h, event.OccurredAt.Add(s.cooldown)); err != nil {
+		log.WithContext(ctx).Errorf("pair_history: record breakup of match %d err:%v", e.RecordID, err)
+	}
+}
+
+func (s *Service) onIntroductionClosed(ctx context.Context, event *biz_omiai.Event) {
+	e, ok := event.Payload.(*biz_omiai.IntroductionClosed)
+	if !ok {
+		return
+	}
+	byA, ok := declineCloseReasons[e.CloseReason]
+	if !ok {
+		return
+	}
+	h := &biz_omiai.PairHistory{
+		ClientID:    e.ClientAID,
+		CandidateID: e.ClientBID,
+		Kind:        biz_omiai.PairKindDeclined,
+		ReasonCode:  e.CloseReason,
+		Remark:      e.Remark,
+		SourceType:  biz_omiai.PairSourceIntroduction,
+		SourceID:    e.IntroductionID,
+		Operator:    e.Operator,
+	}
+	if !byA {
+		h.ClientID, h.CandidateID = e.ClientBID, e.ClientAID
+	}
+	if err := s.repo.Create(ctx, h); err != nil {
+		log.WithContext(ctx).Errorf("pair_history: record introduction %d err:%v", e.IntroductionID, err)
+	}
+}
@@ -0,0 +1,82 @@
+package pair_history
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"omiai-server/internal/biz"
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/conf"
+	"omiai-server/internal/data"
+	"omiai-server/internal/data/omiai"
+	"omiai-server/internal/service/event"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestPairHistory(t *testing.T) {
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	assert.NoError(t, db.AutoMigrate(&biz_omiai.Client{}, &biz_omiai.PairHistory{}))
+
+	// 1 为待推荐的男方，2-5 为女方
+	for i := 1; i <= 5; i++ {
+		gender := int8(2)
+		if i == 1 {
+			gender = 1
+		}
+		assert.NoError(t, db.Create(&biz_omiai.Client{ID: uint64(i), Gender: gender, Status: biz_omiai.ClientStatusSingle}).Error)
+	}
+
+	bus := event.NewBus()
+	svc := NewService(&conf.Config{Match: &conf.Match{BreakupCooldownDays: 30}}, omiai.NewPairHistoryRepo(&data.DB{DB: db}), bus)
+
+	pool := func() []uint64 {
+		clause := &biz.WhereClause{Where: "gender = ?", Args: []interface{}{2}}
+		biz_omiai.AppendPairHistoryFilter(clause, 1, time.Now())
+		var ids []uint64
+		assert.NoError(t, db.Model(&biz_omiai.Client{}).Where(clause.Where, clause.Args...).Order("id").Pluck("id", &ids).Error)
+		return ids
+	}
+	assert.Equal(t, []uint64{2, 3, 4, 5}, pool())
+
+	// 手动拒绝：原因必填，暂不考虑须有未来日期
+	assert.ErrorIs(t, svc.Reject(ctx, &biz_omiai.PairHistory{ClientID: 1, CandidateID: 2, Kind: biz_omiai.PairKindDeclined}), biz_omiai.ErrPairReason)
+	past := time.Now().Add(-time.Hour)
+	assert.ErrorIs(t, svc.Reject(ctx, &biz_omiai.PairHistory{ClientID: 1, CandidateID: 2, Kind: biz_omiai.PairKindSnoozed, ReasonCode: biz_omiai.PairReasonOther, ExpiredAt: &past}), biz_omiai.ErrPairExpiredAt)
+	assert.NoError(t, svc.Reject(ctx, &biz_omiai.PairHistory{ClientID: 1, CandidateID: 2, Kind: biz_omiai.PairKindDeclined, ReasonCode: biz_omiai.PairReasonAge}))
+
+	// 介绍中 B 方拒绝：以 B 为发起方记录，反向同样排除
+	bus.Publish(ctx, biz_omiai.EventIntroductionClosed, &biz_omiai.IntroductionClosed{IntroductionID: 9, ClientAID: 1, ClientBID: 3, CloseReason: biz_omiai.IntroCloseBDeclined})
+	// 联系不上不代表配对不合适
+	bus.Publish(ctx, biz_omiai.EventIntroductionClosed, &biz_omiai.IntroductionClosed{IntroductionID: 10, ClientAID: 1, ClientBID: 5, CloseReason: biz_omiai.IntroCloseUnreachable})
+	assert.Equal(t, []uint64{4, 5}, pool())
+
+	// 2号与其他客户分手后进入冷静期
+	bus.Publish(ctx, biz_omiai.EventMatchStatusChanged, &biz_omiai.MatchStatusChanged{RecordID: 7, MaleClientID: 6, FemaleClientID: 4, OldStatus: biz_omiai.MatchStatusDating, NewStatus: biz_omiai.MatchStatusBroken})
+	assert.Equal(t, []uint64{5}, pool())
+
+	var c4 biz_omiai.Client
+	assert.NoError(t, db.First(&c4, 4).Error)
+	assert.NotNil(t, c4.CooldownUntil)
+	assert.WithinDuration(t, time.Now().AddDate(0, 0, 30), *c4.CooldownUntil, time.Minute)
+
+	list, err := svc.List(ctx, 1)
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+	for _, h := range list {
+		if h.SourceType == biz_omiai.PairSourceIntroduction {
+			assert.Equal(t, uint64(3), h.ClientID)
+			assert.ErrorIs(t, svc.Revoke(ctx, h.ID), biz_omiai.ErrPairNotManual)
+		} else {
+			assert.NoError(t, svc.Revoke(ctx, h.ID))
+		}
+	}
+	assert.Equal(t, []uint64{2, 5}, pool())
+}
@@ -0,0 +1,359 @@
+package party
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"omiai-server/internal/biz"
+	biz_omiai "omiai-server/internal/biz/omiai"
+)
+
+type Service struct {
+	repo   biz_omiai.PartyInterface
+	client biz_omiai.ClientInterface
+	intro  biz_omiai.IntroductionInterface
+	scorer biz_omiai.Scorer
+}
+
+func NewService(repo biz_omiai.PartyInterface, client biz_omiai.ClientInterface, intro biz_omiai.IntroductionInterface, scorer biz_omiai.Scorer) *Service {
+	return &Service{repo: repo, client: client, intro: intro, scorer: scorer}
+}
+
+// Create 创建相亲会，开放报名
+func (s *Service) Create(ctx context.Context, p *biz_omiai.Party) error {
+	if err := normalize(p); err != nil {
+		return err
+	}
+	p.Status = biz_omiai.PartyStatusOpen
+	return s.repo.Create(ctx, p)
+}
+
+// Update 修改相亲会信息，互选开始后不可修改
+func (s *Service) Update(ctx context.Context, p *biz_omiai.Party) error {
+	old, err := s.repo.Get(ctx, p.ID)
+	if err != nil {
+		return err
+	}
+	if old.Status != biz_omiai.PartyStatusOpen && old.Status != biz_omiai.PartyStatusOngoing {
+		return biz_omiai.ErrPartyInvalidTransition
+	}
+	if err := normalize(p); err != nil {
+		return err
+	}
+	return s.repo.Update(ctx, p)
+}
+
+func normalize(p *biz_omiai.Party) error {
+	if p.MutualAction == "" {
+		p.MutualAction = biz_omiai.PartyMutualIntroduction
+	}
+	if p.Capacity > 0 && p.MaleQuota+p.FemaleQuota > p.Capacity {
+		return biz_omiai.ErrPartyQuota
+	}
+	return nil
+}
+
+// Transition 开始活动、进入互选或取消；进入互选前须已生成轮转安排
+func (s *Service) Transition(ctx context.Context, id uint64, to int8) (*biz_omiai.Party, error) {
+	p, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !biz_omiai.CanPartyTransition(p.Status, to) {
+		return nil, biz_omiai.ErrPartyInvalidTransition
+	}
+	if to == biz_omiai.PartyStatusPicking {
+		seats, err := s.repo.Seats(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if len(seats) == 0 {
+			return nil, biz_omiai.ErrPartyNoSchedule
+		}
+	}
+	if err := s.repo.Transition(ctx, id, p.Status, to); err != nil {
+		return nil, err
+	}
+	p.Status = to
+	return p, nil
+}
+
+// SignUp 单身客户报名，名额已满时进入候补；活动当天可现场报名
+func (s *Service) SignUp(ctx context.Context, partyID, clientID uint64, operator string) (*biz_omiai.PartyAttendee, error) {
+	p, err := s.active(ctx, partyID)
+	if err != nil {
+		return nil, err
+	}
+	c, err := s.client.Get(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	if c.Status != biz_omiai.ClientStatusSingle || (c.Gender != 1 && c.Gender != 2) {
+		return nil, biz_omiai.ErrPartyClientUnavailable
+	}
+	a := &biz_omiai.PartyAttendee{ClientID: clientID, Gender: c.Gender, Operator: operator}
+	if err := s.repo.SignUp(ctx, p, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Cancel 取消报名
+func (s *Service) Cancel(ctx context.Context, partyID, clientID uint64, operator string) error {
+	p, err := s.active(ctx, partyID)
+	if err != nil {
+		return err
+	}
+	return s.repo.CancelAttendee(ctx, p, clientID, operator)
+}
+
+// CheckIn 到场签到，候补嘉宾到场也可签到
+func (s *Service) CheckIn(ctx context.Context, partyID, clientID uint64, operator string) (*biz_omiai.PartyAttendee, error) {
+	if _, err := s.active(ctx, partyID); err != nil {
+		return nil, err
+	}
+	return s.repo.CheckIn(ctx, partyID, clientID, operator)
+}
+
+// active 报名中或进行中的相亲会
+func (s *Service) active(ctx context.Context, partyID uint64) (*biz_omiai.Party, error) {
+	p, err := s.repo.Get(ctx, partyID)
+	if err != nil {
+		return nil, err
+	}
+	if p.Status != biz_omiai.PartyStatusOpen && p.Status != biz_omiai.PartyStatusOngoing {
+		return nil, biz_omiai.ErrPartyInvalidTransition
+	}
+	return p, nil
+}
+
+// Schedule 按已签到嘉宾生成轮转安排，覆盖此前的安排；迟到嘉宾签到后可重新生成
+func (s *Service) Schedule(ctx context.Context, partyID uint64) ([]*biz_omiai.PartySeat, error) {
+	p, err := s.repo.Get(ctx, partyID)
+	if err != nil {
+		return nil, err
+	}
+	if p.Status != biz_omiai.PartyStatusOngoing {
+		return nil, biz_omiai.ErrPartyInvalidTransition
+	}
+	attendees, err := s.repo.Attendees(ctx, partyID, biz_omiai.AttendeeStatusCheckedIn)
+	if err != nil {
+		return nil, err
+	}
+	var males, females []uint64
+	for _, a := range attendees {
+		switch a.Gender {
+		case 1:
+			males = append(males, a.ClientID)
+		case 2:
+			females = append(females, a.ClientID)
+		}
+	}
+	if len(males) == 0 || len(females) == 0 {
+		return nil, biz_omiai.ErrPartyNoAttendees
+	}
+
+	seats := Rotate(males, females, p.Rounds)
+	if err := s.repo.ReplaceSeats(ctx, partyID, seats); err != nil {
+		return nil, err
+	}
+	return seats, nil
+}
+
+// SubmitPicks 录入嘉宾的会后选择，覆盖此前的选择，只能选择轮转中见过的嘉宾
+func (s *Service) SubmitPicks(ctx context.Context, partyID, clientID uint64, pickedIDs []uint64) error {
+	p, err := s.repo.Get(ctx, partyID)
+	if err != nil {
+		return err
+	}
+	if p.Status != biz_omiai.PartyStatusPicking {
+		return biz_omiai.ErrPartyInvalidTransition
+	}
+	a, err := s.repo.GetAttendee(ctx, partyID, clientID)
+	if err != nil || a.Status != biz_omiai.AttendeeStatusCheckedIn {
+		return biz_omiai.ErrPartyNotCheckedIn
+	}
+
+	seats, err := s.repo.Seats(ctx, partyID)
+	if err != nil {
+		return err
+	}
+	met := make(map[uint64]bool)
+	for _, seat := range seats {
+		switch clientID {
+		case seat.MaleClientID:
+			met[seat.FemaleClientID] = true
+		case seat.FemaleClientID:
+			met[seat.MaleClientID] = true
+		}
+	}
+	unique := make([]uint64, 0, len(pickedIDs))
+	seen := make(map[uint64]bool, len(pickedIDs))
+	for _, id := range pickedIDs {
+		if seen[id] {
+			continue
+		}
+		if !met[id] {
+			return biz_omiai.ErrPartyPickNotMet
+		}
+		seen[id] = true
+		unique = append(unique, id)
+	}
+	if p.PickLimit > 0 && len(unique) > p.PickLimit {
+		return biz_omiai.ErrPartyPickLimit
+	}
+	return s.repo.ReplacePicks(ctx, partyID, clientID, unique)
+}
+
+// Finalize 结束互选并生成配对：双向选择的配对按相亲会设置发起介绍或生成配对建议，
+// 轮转中见过面但未双向选择的配对记为拒绝，双向选择的配对记为相亲会互选，之后各推荐来源不再推荐。
+// 结束状态、配对记录与配对建议在同一事务内写入，介绍在其后逐对发起
+func (s *Service) Finalize(ctx context.Context, partyID, matchmakerID uint64, operator string) (*biz_omiai.PartyResult, error) {
+	p, err := s.repo.Get(ctx, partyID)
+	if err != nil {
+		return nil, err
+	}
+	if p.Status != biz_omiai.PartyStatusPicking {
+		return nil, biz_omiai.ErrPartyInvalidTransition
+	}
+
+	seats, err := s.repo.Seats(ctx, partyID)
+	if err != nil {
+		return nil, err
+	}
+	picks, err := s.repo.Picks(ctx, partyID)
+	if err != nil {
+		return nil, err
+	}
+	picked := make(map[[2]uint64]bool, len(picks))
+	for _, pick := range picks {
+		picked[[2]uint64{pick.ClientID, pick.PickedID}] = true
+	}
+
+	result := &biz_omiai.PartyResult{Introductions: []uint64{}, Failed: []string{}}
+	var (
+		mutual    [][2]uint64
+		histories []*biz_omiai.PairHistory
+	)
+	seen := make(map[[2]uint64]bool, len(seats))
+	for _, seat := range seats {
+		male, female := seat.MaleClientID, seat.FemaleClientID
+		key := [2]uint64{male, female}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result.Met++
+
+		h := &biz_omiai.PairHistory{
+			ClientID:    male,
+			CandidateID: female,
+			SourceType:  biz_omiai.PairSourceParty,
+			SourceID:    partyID,
+			Operator:    operator,
+		}
+		histories = append(histories, h)
+		malePicked, femalePicked := picked[[2]uint64{male, female}], picked[[2]uint64{female, male}]
+		if malePicked && femalePicked {
+			h.Kind = biz_omiai.PairKindMutual
+			h.Remark = fmt.Sprintf("相亲会「%s」双向选择", p.Title)
+			mutual = append(mutual, key)
+			continue
+		}
+		// 未选择对方的一方记为拒绝方，双方均未选择时以男方为拒绝方
+		h.Kind = biz_omiai.PairKindDeclined
+		h.ReasonCode = biz_omiai.PairReasonNotInterested
+		h.Remark = fmt.Sprintf("相亲会「%s」未双向选择", p.Title)
+		if malePicked {
+			h.ClientID, h.CandidateID = female, male
+		}
+		result.Declined++
+	}
+	result.Mutual = len(mutual)
+
+	var (
+		batch     *biz_omiai.ProposalBatch
+		proposals []*biz_omiai.Proposal
+	)
+	if len(mutual) > 0 && p.MutualAction == biz_omiai.PartyMutualProposal {
+		if batch, proposals, err = s.propose(ctx, mutual, operator); err != nil {
+			return nil, err
+		}
+	}
+	// 比较并更新，重复结束时整体放弃
+	if err := s.repo.Finish(ctx, partyID, histories, batch, proposals); err != nil {
+		return nil, err
+	}
+	if batch != nil {
+		result.ProposalBatch = &batch.ID
+	}
+	if len(mutual) == 0 || batch != nil {
+		return result, nil
+	}
+
+	remark := fmt.Sprintf("相亲会「%s」双向选择", p.Title)
+	for _, pair := range mutual {
+		intro := &biz_omiai.Introduction{
+			ClientAID:    pair[0],
+			ClientBID:    pair[1],
+			MatchmakerID: matchmakerID,
+			Remark:       remark,
+		}
+		if err := s.intro.Create(ctx, intro, operator, nil); err != nil {
+			// 一方已在其他介绍中时跳过，由红娘线下跟进
+			result.Failed = append(result.Failed, fmt.Sprintf("%d-%d: %s", pair[0], pair[1], err.Error()))
+			continue
+		}
+		result.Introductions = append(result.Introductions, intro.ID)
+	}
+	return result, nil
+}
+
+// propose 以双向选择的配对组成一批配对建议，随相亲会结束一并写入，由红娘审核后发起介绍
+func (s *Service) propose(ctx context.Context, mutual [][2]uint64, operator string) (*biz_omiai.ProposalBatch, []*biz_omiai.Proposal, error) {
+	ids := make([]uint64, 0, len(mutual)*2)
+	for _, pair := range mutual {
+		ids = append(ids, pair[0], pair[1])
+	}
+	list, err := s.client.Select(ctx, &biz.WhereClause{Where: "id IN ?", Args: []interface{}{ids}}, nil, 0, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	clients := make(map[uint64]*biz_omiai.Client, len(list))
+	for _, c := range list {
+		if c.Age == 0 {
+			c.Age = c.RealAge()
+		}
+		clients[c.ID] = c
+	}
+
+	proposals := make([]*biz_omiai.Proposal, 0, len(mutual))
+	var total int
+	for _, pair := range mutual {
+		proposal := &biz_omiai.Proposal{
+			MaleClientID:   pair[0],
+			FemaleClientID: pair[1],
+			Status:         biz_omiai.ProposalStatusPending,
+		}
+		if male, female := clients[pair[0]], clients[pair[1]]; male != nil && female != nil {
+			result := s.scorer.Score(male, female)
+			tags, _ := json.Marshal(result.Tags)
+			proposal.Score = result.Score
+			proposal.Tags = string(tags)
+		}
+		proposals = append(proposals, proposal)
+		total += proposal.Score
+	}
+	batch := &biz_omiai.ProposalBatch{
+		Strategy:      biz_omiai.ProposalStrategyParty,
+		Algorithm:     s.scorer.Name(),
+		ClientCount:   len(clients),
+		ProposalCount: len(proposals),
+		AvgScore:      math.Round(float64(total)/float64(len(proposals))*10) / 10,
+		Status:        biz_omiai.ProposalBatchStatusReviewing,
+		Operator:      operator,
+	}
+	return batch, proposals, nil
+}
@@ -0,0 +1,215 @@
+package party
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/data"
+	"omiai-server/internal/data/omiai"
+	"omiai-server/internal/service/event"
+	"omiai-server/internal/service/matching"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestRotate(t *testing.T) {
+	males := []uint64{1, 2, 3}
+	females := []uint64{11, 12, 13, 14, 15}
+	seats := Rotate(males, females, 0)
+	// 女方多，男方固定 3 桌，5 轮后每对恰好相遇一次
+	assert.Len(t, seats, 15)
+	met := map[[2]uint64]int{}
+	busy := map[int]map[uint64]bool{}
+	for _, s := range seats {
+		met[[2]uint64{s.MaleClientID, s.FemaleClientID}]++
+		assert.LessOrEqual(t, s.TableNo, 3)
+		if busy[s.Round] == nil {
+			busy[s.Round] = map[uint64]bool{}
+		}
+		// 同一轮每人只出现一次
+		assert.False(t, busy[s.Round][s.MaleClientID])
+		assert.False(t, busy[s.Round][s.FemaleClientID])
+		busy[s.Round][s.MaleClientID], busy[s.Round][s.FemaleClientID] = true, true
+	}
+	assert.Len(t, met, 15)
+	for _, n := range met {
+		assert.Equal(t, 1, n)
+	}
+
+	// 限制轮数时不重复
+	seats = Rotate(females, males, 2)
+	assert.Len(t, seats, 6)
+	assert.Nil(t, Rotate(nil, females, 0))
+}
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	assert.NoError(t, db.AutoMigrate(&biz_omiai.Client{}, &biz_omiai.PersonalityProfile{}, &biz_omiai.ClientEmbedding{}, &biz_omiai.ClientTag{}, &biz_omiai.Tag{}, &biz_omiai.TagGroup{}, &biz_omiai.PairHistory{},
+		&biz_omiai.Introduction{}, &biz_omiai.IntroductionHistory{}, &biz_omiai.ProposalBatch{}, &biz_omiai.Proposal{},
+		&biz_omiai.Party{}, &biz_omiai.PartyAttendee{}, &biz_omiai.PartySeat{}, &biz_omiai.PartyPick{}))
+
+	// 1、2、3 为男方，11、12 为女方
+	for _, id := range []uint64{1, 2, 3, 11, 12} {
+		gender := int8(1)
+		if id > 10 {
+			gender = 2
+		}
+		assert.NoError(t, db.Create(&biz_omiai.Client{ID: id, Gender: gender, Status: biz_omiai.ClientStatusSingle}).Error)
+	}
+	assert.NoError(t, db.Create(&biz_omiai.Client{ID: 4, Gender: 1, Status: biz_omiai.ClientStatusMatched}).Error)
+
+	d := &data.DB{DB: db}
+	bus := event.NewBus()
+	scorer := matching.NewScorer(nil, nil)
+	repo := omiai.NewPartyRepo(d)
+	pairs := omiai.NewPairHistoryRepo(d)
+	svc := NewService(repo, omiai.NewClientRepo(d, bus), omiai.NewIntroductionRepo(d, scorer, bus), scorer)
+
+	p := &biz_omiai.Party{Title: "周末相亲会", StartAt: time.Now(), Capacity: 4, MaleQuota: 2, FemaleQuota: 2}
+	assert.NoError(t, svc.Create(ctx, p))
+	assert.Equal(t, biz_omiai.PartyMutualIntroduction, p.MutualAction)
+	assert.ErrorIs(t, svc.Create(ctx, &biz_omiai.Party{Capacity: 2, MaleQuota: 2, FemaleQuota: 1}), biz_omiai.ErrPartyQuota)
+
+	// 男方名额 2，第 3 位进入候补；已匹配客户不能报名
+	for _, id := range []uint64{1, 2, 3, 11, 12} {
+		_, err := svc.SignUp(ctx, p.ID, id, "红娘")
+		assert.NoError(t, err)
+	}
+	_, err = svc.SignUp(ctx, p.ID, 4, "红娘")
+	assert.ErrorIs(t, err, biz_omiai.ErrPartyClientUnavailable)
+	_, err = svc.SignUp(ctx, p.ID, 1, "红娘")
+	assert.ErrorIs(t, err, biz_omiai.ErrPartySignedUp)
+	a, err := repo.GetAttendee(ctx, p.ID, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, int8(biz_omiai.AttendeeStatusWaitlist), a.Status)
+
+	// 取消后候补递补
+	assert.NoError(t, svc.Cancel(ctx, p.ID, 2, "红娘"))
+	a, err = repo.GetAttendee(ctx, p.ID, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, int8(biz_omiai.AttendeeStatusSignedUp), a.Status)
+
+	// 未开始不能生成轮转安排，未生成安排不能进入互选
+	_, err = svc.Schedule(ctx, p.ID)
+	assert.ErrorIs(t, err, biz_omiai.ErrPartyInvalidTransition)
+	_, err = svc.Transition(ctx, p.ID, biz_omiai.PartyStatusOngoing)
+	assert.NoError(t, err)
+	_, err = svc.Transition(ctx, p.ID, biz_omiai.PartyStatusPicking)
+	assert.ErrorIs(t, err, biz_omiai.ErrPartyNoSchedule)
+
+	for i, id := range []uint64{1, 3, 11, 12} {
+		a, err := svc.CheckIn(ctx, p.ID, id, "红娘")
+		assert.NoError(t, err)
+		assert.Equal(t, i+1, a.Number)
+	}
+	seats, err := svc.Schedule(ctx, p.ID)
+	assert.NoError(t, err)
+	assert.Len(t, seats, 4)
+	_, err = svc.Transition(ctx, p.ID, biz_omiai.PartyStatusPicking)
+	assert.NoError(t, err)
+
+	// 1 与 11 双向选择，3 选择 12 但 12 未选择
+	assert.ErrorIs(t, svc.SubmitPicks(ctx, p.ID, 2, []uint64{11}), biz_omiai.ErrPartyNotCheckedIn)
+	assert.ErrorIs(t, svc.SubmitPicks(ctx, p.ID, 1, []uint64{3}), biz_omiai.ErrPartyPickNotMet)
+	assert.NoError(t, svc.SubmitPicks(ctx, p.ID, 1, []uint64{11, 11}))
+	assert.NoError(t, svc.SubmitPicks(ctx, p.ID, 11, []uint64{1}))
+	assert.NoError(t, svc.SubmitPicks(ctx, p.ID, 3, []uint64{12}))
+
+	result, err := svc.Finalize(ctx, p.ID, 7, "红娘")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, result.Met)
+	assert.Equal(t, 1, result.Mutual)
+	assert.Len(t, result.Introductions, 1)
+	assert.Equal(t, 3, result.Declined)
+	_, err = svc.Finalize(ctx, p.ID, 7, "红娘")
+	assert.ErrorIs(t, err, biz_omiai.ErrPartyInvalidTransition)
+
+	var intro biz_omiai.Introduction
+	assert.NoError(t, db.First(&intro, result.Introductions[0]).Error)
+	assert.Equal(t, uint64(1), intro.ClientAID)
+	assert.Equal(t, uint64(11), intro.ClientBID)
+
+	// 3 选择了 12，拒绝方为 12
+	history, err := pairs.List(ctx, 3)
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+	for _, h := range history {
+		assert.Equal(t, biz_omiai.PairSourceParty, h.SourceType)
+		if h.CandidateID == 3 {
+			assert.Equal(t, uint64(12), h.ClientID)
+		}
+	}
+	// 双向选择的配对记为相亲会互选
+	history, err = pairs.List(ctx, 1)
+	assert.NoError(t, err)
+	kinds := make(map[uint64]int8, len(history))
+	for _, h := range history {
+		kinds[h.CandidateID] = h.Kind
+	}
+	assert.Equal(t, int8(biz_omiai.PairKindMutual), kinds[11])
+}
+
+func TestFinalizeProposal(t *testing.T) {
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	assert.NoError(t, db.AutoMigrate(&biz_omiai.Client{}, &biz_omiai.PersonalityProfile{}, &biz_omiai.ClientEmbedding{}, &biz_omiai.ClientTag{}, &biz_omiai.Tag{}, &biz_omiai.TagGroup{}, &biz_omiai.PairHistory{},
+		&biz_omiai.ProposalBatch{}, &biz_omiai.Proposal{},
+		&biz_omiai.Party{}, &biz_omiai.PartyAttendee{}, &biz_omiai.PartySeat{}, &biz_omiai.PartyPick{}))
+	assert.NoError(t, db.Create(&biz_omiai.Client{ID: 1, Gender: 1, Status: biz_omiai.ClientStatusSingle}).Error)
+	assert.NoError(t, db.Create(&biz_omiai.Client{ID: 2, Gender: 2, Status: biz_omiai.ClientStatusSingle}).Error)
+
+	d := &data.DB{DB: db}
+	bus := event.NewBus()
+	scorer := matching.NewScorer(nil, nil)
+	repo := omiai.NewPartyRepo(d)
+	proposals := omiai.NewProposalRepo(d)
+	svc := NewService(repo, omiai.NewClientRepo(d, bus), nil, scorer)
+
+	p := &biz_omiai.Party{Title: "读书会", StartAt: time.Now(), MutualAction: biz_omiai.PartyMutualProposal}
+	assert.NoError(t, svc.Create(ctx, p))
+	for _, id := range []uint64{1, 2} {
+		_, err := svc.SignUp(ctx, p.ID, id, "红娘")
+		assert.NoError(t, err)
+	}
+	_, err = svc.Transition(ctx, p.ID, biz_omiai.PartyStatusOngoing)
+	assert.NoError(t, err)
+	for _, id := range []uint64{1, 2} {
+		_, err := svc.CheckIn(ctx, p.ID, id, "红娘")
+		assert.NoError(t, err)
+	}
+	_, err = svc.Schedule(ctx, p.ID)
+	assert.NoError(t, err)
+	_, err = svc.Transition(ctx, p.ID, biz_omiai.PartyStatusPicking)
+	assert.NoError(t, err)
+	assert.NoError(t, svc.SubmitPicks(ctx, p.ID, 1, []uint64{2}))
+	assert.NoError(t, svc.SubmitPicks(ctx, p.ID, 2, []uint64{1}))
+
+	result, err := svc.Finalize(ctx, p.ID, 7, "红娘")
+	assert.NoError(t, err)
+	assert.NotNil(t, result.ProposalBatch)
+	batch, err := proposals.GetBatch(ctx, *result.ProposalBatch)
+	assert.NoError(t, err)
+	assert.Equal(t, biz_omiai.ProposalStrategyParty, batch.Strategy)
+	assert.Equal(t, 1, batch.ProposalCount)
+
+	// 重复结束时整体放弃，不写入配对记录与配对建议
+	err = repo.Finish(ctx, p.ID, []*biz_omiai.PairHistory{{ClientID: 1, CandidateID: 2, Kind: biz_omiai.PairKindMutual}},
+		&biz_omiai.ProposalBatch{Strategy: biz_omiai.ProposalStrategyParty}, []*biz_omiai.Proposal{{MaleClientID: 1, FemaleClientID: 2}})
+	assert.ErrorIs(t, err, biz_omiai.ErrPartyStatusChanged)
+	var histories, batches int64
+	db.Model(&biz_omiai.PairHistory{}).Count(&histories)
+	db.Model(&biz_omiai.ProposalBatch{}).Count(&batches)
+	assert.Equal(t, int64(1), histories)
+	assert.Equal(t, int64(1), batches)
+}
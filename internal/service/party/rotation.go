@@ -0,0 +1,37 @@
+package party
+
+import biz_omiai "omiai-server/internal/biz/omiai"
+
+// Rotate 生成轮转安排：人数较少的一方固定在 1..k 号桌，较多的一方每轮顺移一位，超出桌数的轮空
+// 较多一方人数为 n 时，第 r 轮第 t 桌坐第 (t+r) mod n 位，n 轮内任意两人恰好相遇一次，因此不会重复配对
+// rounds 为 0 或超过 n 时取 n
+func Rotate(males, females []uint64, rounds int) []*biz_omiai.PartySeat {
+	fixed, moving := males, females
+	maleFixed := true
+	if len(females) < len(males) {
+		fixed, moving = females, males
+		maleFixed = false
+	}
+	n := len(moving)
+	if len(fixed) == 0 || n == 0 {
+		return nil
+	}
+	if rounds <= 0 || rounds > n {
+		rounds = n
+	}
+
+	seats := make([]*biz_omiai.PartySeat, 0, rounds*len(fixed))
+	for r := 0; r < rounds; r++ {
+		for t, id := range fixed {
+			other := moving[(t+r)%n]
+			seat := &biz_omiai.PartySeat{Round: r + 1, TableNo: t + 1}
+			if maleFixed {
+				seat.MaleClientID, seat.FemaleClientID = id, other
+			} else {
+				seat.MaleClientID, seat.FemaleClientID = other, id
+			}
+			seats = append(seats, seat)
+		}
+	}
+	return seats
+}
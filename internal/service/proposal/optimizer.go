@@ -0,0 +1,251 @@
+package proposal
+
+import (
+	"sort"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+)
+
+// Pair 求解结果中的一对，Row/Col 为得分矩阵下标
+type Pair struct {
+	Row int
+	Col int
+}
+
+// Solve 在得分矩阵上求解全局配对
+// scores[i][j] < 0 表示不可配对；rowCap/colCap 为每行、每列最多参与的配对数，0 表示不参与
+func Solve(strategy string, scores [][]int, rowCap, colCap []int) ([]Pair, error) {
+	switch strategy {
+	case biz_omiai.ProposalStrategyMaxWeight:
+		return maxWeightRounds(scores, rowCap, colCap), nil
+	case biz_omiai.ProposalStrategyStable:
+		return stableMatching(scores, rowCap, colCap), nil
+	}
+	return nil, biz_omiai.ErrProposalStrategy
+}
+
+// maxWeightRounds 按轮次求最大权一对一匹配：每轮每人至多一对，已选配对不再参与，直到容量用完或无可选配对
+// 相比一次性按总分求解，热门客户不会在同一批次内占满所有名额
+func maxWeightRounds(scores [][]int, rowCap, colCap []int) []Pair {
+	rows, cols := len(scores), len(colCap)
+	rowLeft := append([]int(nil), rowCap...)
+	colLeft := append([]int(nil), colCap...)
+	taken := make(map[Pair]bool)
+
+	var out []Pair
+	for {
+		var activeRows, activeCols []int
+		for i := 0; i < rows; i++ {
+			if rowLeft[i] > 0 {
+				activeRows = append(activeRows, i)
+			}
+		}
+		for j := 0; j < cols; j++ {
+			if colLeft[j] > 0 {
+				activeCols = append(activeCols, j)
+			}
+		}
+		if len(activeRows) == 0 || len(activeCols) == 0 {
+			return out
+		}
+
+		weights := make([][]int, len(activeRows))
+		for a, i := range activeRows {
+			weights[a] = make([]int, len(activeCols))
+			for b, j := range activeCols {
+				weights[a][b] = -1
+				if !taken[Pair{i, j}] {
+					weights[a][b] = scores[i][j]
+				}
+			}
+		}
+
+		round := 0
+		for _, p := range hungarian(weights) {
+			pair := Pair{Row: activeRows[p.Row], Col: activeCols[p.Col]}
+			taken[pair] = true
+			rowLeft[pair.Row]--
+			colLeft[pair.Col]--
+			out = append(out, pair)
+			round++
+		}
+		if round == 0 {
+			return out
+		}
+	}
+}
+
+// hungarian 求最大权一对一匹配（匈牙利算法，带势能的最短增广路），权重 < 0 的配对不会出现在结果中
+func hungarian(weights [][]int) []Pair {
+	n := len(weights)
+	if n == 0 {
+		return nil
+	}
+	m := len(weights[0])
+	transposed := n > m
+	if transposed {
+		t := make([][]int, m)
+		for j := 0; j < m; j++ {
+			t[j] = make([]int, n)
+			for i := 0; i < n; i++ {
+				t[j][i] = weights[i][j]
+			}
+		}
+		weights, n, m = t, m, n
+	}
+
+	// 转为最小费用：不可配对视为得分 0，求解后剔除
+	maxWeight := 0
+	for _, row := range weights {
+		for _, w := range row {
+			if w > maxWeight {
+				maxWeight = w
+			}
+		}
+	}
+	cost := func(i, j int) int {
+		if weights[i][j] < 0 {
+			return maxWeight
+		}
+		return maxWeight - weights[i][j]
+	}
+
+	const inf = int(^uint(0) >> 2)
+	u := make([]int, n+1)
+	v := make([]int, m+1)
+	match := make([]int, m+1) // match[j] 为第 j 列匹配的行（1 起），0 表示未匹配
+	way := make([]int, m+1)
+	for i := 1; i <= n; i++ {
+		match[0] = i
+		j0 := 0
+		minv := make([]int, m+1)
+		used := make([]bool, m+1)
+		for j := range minv {
+			minv[j] = inf
+		}
+		for {
+			used[j0] = true
+			i0, delta, j1 := match[j0], inf, 0
+			for j := 1; j <= m; j++ {
+				if used[j] {
+					continue
+				}
+				cur := cost(i0-1, j-1) - u[i0] - v[j]
+				if cur < minv[j] {
+					minv[j] = cur
+					way[j] = j0
+				}
+				if minv[j] < delta {
+					delta = minv[j]
+					j1 = j
+				}
+			}
+			for j := 0; j <= m; j++ {
+				if used[j] {
+					u[match[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+			j0 = j1
+			if match[j0] == 0 {
+				break
+			}
+		}
+		for j0 != 0 {
+			j1 := way[j0]
+			match[j0] = match[j1]
+			j0 = j1
+		}
+	}
+
+	var out []Pair
+	for j := 1; j <= m; j++ {
+		i := match[j]
+		if i == 0 || weights[i-1][j-1] < 0 {
+			continue
+		}
+		if transposed {
+			out = append(out, Pair{Row: j - 1, Col: i - 1})
+		} else {
+			out = append(out, Pair{Row: i - 1, Col: j - 1})
+		}
+	}
+	sort.Slice(out, func(a, b int) bool {
+		if out[a].Row != out[b].Row {
+			return out[a].Row < out[b].Row
+		}
+		return out[a].Col < out[b].Col
+	})
+	return out
+}
+
+// stableMatching 带容量的稳定匹配（行方发起的延迟接受算法）
+// 行方按得分从高到低依次发起，列方只保留得分最高的 colCap 个，被替换的一方继续发起
+func stableMatching(scores [][]int, rowCap, colCap []int) []Pair {
+	rows, cols := len(scores), len(colCap)
+	prefs := make([][]int, rows)
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			if scores[i][j] >= 0 {
+				prefs[i] = append(prefs[i], j)
+			}
+		}
+		row := scores[i]
+		sort.SliceStable(prefs[i], func(a, b int) bool {
+			return row[prefs[i][a]] > row[prefs[i][b]]
+		})
+	}
+
+	next := make([]int, rows)
+	holding := make([]int, rows)
+	held := make([][]int, cols)
+	queue := make([]int, 0, rows)
+	for i := 0; i < rows; i++ {
+		queue = append(queue, i)
+	}
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		for holding[i] < rowCap[i] && next[i] < len(prefs[i]) {
+			j := prefs[i][next[i]]
+			next[i]++
+			if colCap[j] <= 0 {
+				continue
+			}
+			held[j] = append(held[j], i)
+			holding[i]++
+			if len(held[j]) <= colCap[j] {
+				continue
+			}
+			// 超出容量：剔除得分最低者（同分时剔除后来者）
+			worst := 0
+			for k := 1; k < len(held[j]); k++ {
+				if scores[held[j][k]][j] <= scores[held[j][worst]][j] {
+					worst = k
+				}
+			}
+			dropped := held[j][worst]
+			held[j] = append(held[j][:worst], held[j][worst+1:]...)
+			holding[dropped]--
+			if dropped != i {
+				queue = append(queue, dropped)
+			}
+		}
+	}
+
+	var out []Pair
+	for j := 0; j < cols; j++ {
+		for _, i := range held[j] {
+			out = append(out, Pair{Row: i, Col: j})
+		}
+	}
+	sort.Slice(out, func(a, b int) bool {
+		if out[a].Row != out[b].Row {
+			return out[a].Row < out[b].Row
+		}
+		return out[a].Col < out[b].Col
+	})
+	return out
+}
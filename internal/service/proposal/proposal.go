@@ -0,0 +1,246 @@
+package proposal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"omiai-server/internal/biz"
+	biz_omiai "omiai-server/internal/biz/omiai"
+
+	"github.com/iWuxc/go-wit/log"
+)
+
+type Service struct {
+	repo   biz_omiai.ProposalInterface
+	client biz_omiai.ClientInterface
+	pairs  biz_omiai.PairHistoryInterface
+	intro  biz_omiai.IntroductionInterface
+	scorer biz_omiai.Scorer
+}
+
+func NewService(repo biz_omiai.ProposalInterface, client biz_omiai.ClientInterface, pairs biz_omiai.PairHistoryInterface,
+	intro biz_omiai.IntroductionInterface, scorer biz_omiai.Scorer) *Service {
+	return &Service{repo: repo, client: client, pairs: pairs, intro: intro, scorer: scorer}
+}
+
+// Generate 对单身客户两两评分并全局求解，生成一批待审核的配对建议
+// 已有待审核建议的客户占用名额，名额已满、有效负反馈与冷静期内的客户不参与；
+// 每种性别至多 MaxProposalPool 人，超出时优先最近更新资料的客户
+func (s *Service) Generate(ctx context.Context, opts *biz_omiai.ProposalOptions) (*biz_omiai.ProposalBatch, []*biz_omiai.Proposal, error) {
+	if opts.Strategy == "" {
+		opts.Strategy = biz_omiai.ProposalStrategyMaxWeight
+	}
+	if _, ok := biz_omiai.ProposalStrategyText[opts.Strategy]; !ok {
+		return nil, nil, biz_omiai.ErrProposalStrategy
+	}
+	if opts.CandidateCap <= 0 {
+		opts.CandidateCap = biz_omiai.DefaultProposalCap
+	}
+	if opts.MinScore <= 0 {
+		opts.MinScore = biz_omiai.DefaultProposalMinScore
+	}
+
+	now := time.Now()
+	clients, err := s.client.Select(ctx, &biz.WhereClause{
+		Where:   "status = ? AND (cooldown_until IS NULL OR cooldown_until <= ?)",
+		Args:    []interface{}{biz_omiai.ClientStatusSingle, now},
+		OrderBy: "updated_at desc, id desc",
+	}, nil, 0, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	pending, err := s.repo.PendingCounts(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	excluded, err := s.excludedPairs(ctx, now)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		males, females []*biz_omiai.Client
+		singles        = make(map[int8]bool, 2)
+	)
+	for _, c := range clients {
+		singles[c.Gender] = true
+		if pending[c.ID] >= opts.CandidateCap {
+			continue
+		}
+		if c.Age == 0 {
+			c.Age = c.RealAge()
+		}
+		switch {
+		case c.Gender == 1 && len(males) < biz_omiai.MaxProposalPool:
+			males = append(males, c)
+		case c.Gender == 2 && len(females) < biz_omiai.MaxProposalPool:
+			females = append(females, c)
+		}
+	}
+	if !singles[1] || !singles[2] {
+		return nil, nil, biz_omiai.ErrProposalEmpty
+	}
+
+	capacity := func(list []*biz_omiai.Client) []int {
+		out := make([]int, len(list))
+		for i, c := range list {
+			if left := opts.CandidateCap - pending[c.ID]; left > 0 {
+				out[i] = left
+			}
+		}
+		return out
+	}
+
+	// 得分矩阵：行为男方，列为女方
+	scores := make([][]int, len(males))
+	results := make(map[Pair]*biz_omiai.ScoreResult)
+	for i, m := range males {
+		scores[i] = make([]int, len(females))
+		for j, f := range females {
+			scores[i][j] = -1
+			if excluded[pairKey(m.ID, f.ID)] {
+				continue
+			}
+			if len(biz_omiai.MutualViolationsMode(m, f, opts.RequirementMode)) > 0 {
+				continue
+			}
+			result := s.scorer.Score(m, f)
+			if result.Score < opts.MinScore {
+				continue
+			}
+			scores[i][j] = result.Score
+			results[Pair{Row: i, Col: j}] = result
+		}
+	}
+
+	pairs, err := Solve(opts.Strategy, scores, capacity(males), capacity(females))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	proposals := make([]*biz_omiai.Proposal, 0, len(pairs))
+	var total int
+	for _, p := range pairs {
+		result := results[p]
+		tags, _ := json.Marshal(result.Tags)
+		proposals = append(proposals, &biz_omiai.Proposal{
+			MaleClientID:   males[p.Row].ID,
+			FemaleClientID: females[p.Col].ID,
+			Score:          result.Score,
+			Tags:           string(tags),
+			Status:         biz_omiai.ProposalStatusPending,
+		})
+		total += result.Score
+	}
+
+	batch := &biz_omiai.ProposalBatch{
+		Strategy:      opts.Strategy,
+		Algorithm:     s.scorer.Name(),
+		CandidateCap:  opts.CandidateCap,
+		MinScore:      opts.MinScore,
+		ClientCount:   len(males) + len(females),
+		ProposalCount: len(proposals),
+		Status:        biz_omiai.ProposalBatchStatusReviewing,
+		Operator:      opts.Operator,
+	}
+	if len(proposals) > 0 {
+		batch.AvgScore = math.Round(float64(total)/float64(len(proposals))*10) / 10
+	} else {
+		batch.Status = biz_omiai.ProposalBatchStatusFinished
+	}
+	if err := s.repo.CreateBatch(ctx, batch, proposals); err != nil {
+		return nil, nil, err
+	}
+	return batch, proposals, nil
+}
+
+// Accept 采纳建议：以该配对发起介绍，双方须仍为单身
+func (s *Service) Accept(ctx context.Context, id, matchmakerID uint64, operator, remark string) (*biz_omiai.Introduction, error) {
+	p, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if p.Status != biz_omiai.ProposalStatusPending {
+		return nil, biz_omiai.ErrProposalReviewed
+	}
+
+	intro := &biz_omiai.Introduction{
+		ClientAID:    p.MaleClientID,
+		ClientBID:    p.FemaleClientID,
+		MatchmakerID: matchmakerID,
+		Remark:       remark,
+	}
+	// 配对建议按默认评分器生成，介绍的匹配分与之一致
+	if err := s.intro.Create(ctx, intro, operator, nil); err != nil {
+		return nil, err
+	}
+	err = s.repo.Review(ctx, id, &biz_omiai.ProposalReview{
+		Status:         biz_omiai.ProposalStatusAccepted,
+		IntroductionID: &intro.ID,
+		Operator:       operator,
+		Remark:         remark,
+	})
+	if err != nil {
+		// 建议已被他人驳回，撤回刚发起的介绍
+		if _, closeErr := s.intro.Transition(ctx, intro.ID, biz_omiai.IntroStatusClosed, &biz_omiai.IntroductionChange{
+			Operator:    operator,
+			CloseReason: biz_omiai.IntroCloseCancelled,
+			Remark:      fmt.Sprintf("配对建议 %d 审核冲突", id),
+		}); closeErr != nil {
+			log.WithContext(ctx).Errorf("proposal: close introduction %d err:%v", intro.ID, closeErr)
+		}
+		return nil, err
+	}
+	return intro, nil
+}
+
+// Reject 驳回建议，并记录为拒绝，之后各推荐来源不再推荐该配对
+func (s *Service) Reject(ctx context.Context, id uint64, reasonCode, operator, remark string) error {
+	if _, ok := biz_omiai.PairReasonText[reasonCode]; !ok {
+		return biz_omiai.ErrPairReason
+	}
+	p, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Review(ctx, id, &biz_omiai.ProposalReview{
+		Status:   biz_omiai.ProposalStatusRejected,
+		Operator: operator,
+		Remark:   remark,
+	}); err != nil {
+		return err
+	}
+	return s.pairs.Create(ctx, &biz_omiai.PairHistory{
+		ClientID:    p.MaleClientID,
+		CandidateID: p.FemaleClientID,
+		Kind:        biz_omiai.PairKindDeclined,
+		ReasonCode:  reasonCode,
+		Remark:      remark,
+		SourceType:  biz_omiai.PairSourceProposal,
+		SourceID:    id,
+		Operator:    operator,
+	})
+}
+
+// excludedPairs 有效负反馈涉及的配对，不区分方向
+func (s *Service) excludedPairs(ctx context.Context, now time.Time) (map[[2]uint64]bool, error) {
+	list, err := s.pairs.ListActive(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[[2]uint64]bool, len(list))
+	for _, h := range list {
+		out[pairKey(h.ClientID, h.CandidateID)] = true
+	}
+	return out, nil
+}
+
+func pairKey(a, b uint64) [2]uint64 {
+	if a > b {
+		a, b = b, a
+	}
+	return [2]uint64{a, b}
+}
@@ -0,0 +1,182 @@
+package proposal
+
+import (
+	"context"
+	"testing"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/data"
+	"omiai-server/internal/data/omiai"
+	"omiai-server/internal/service/event"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestSolveMaxWeight(t *testing.T) {
+	// 贪心会先选 (0,0)=90，总分 90+10；全局最优为 (0,1)+(1,0)=85+80
+	scores := [][]int{
+		{90, 85},
+		{80, 10},
+	}
+	pairs, err := Solve(biz_omiai.ProposalStrategyMaxWeight, scores, []int{1, 1}, []int{1, 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []Pair{{0, 1}, {1, 0}}, pairs)
+
+	// 热门列 0 容量为 1：只能出现一次，其余行分到其他列
+	scores = [][]int{
+		{95, 60, -1},
+		{94, -1, 70},
+		{93, 65, 66},
+	}
+	pairs, err = Solve(biz_omiai.ProposalStrategyMaxWeight, scores, []int{1, 1, 1}, []int{1, 1, 1})
+	assert.NoError(t, err)
+	assert.Len(t, pairs, 3)
+	seen := map[int]int{}
+	for _, p := range pairs {
+		assert.GreaterOrEqual(t, scores[p.Row][p.Col], 0)
+		seen[p.Col]++
+	}
+	assert.Equal(t, 1, seen[0])
+
+	// 容量为 2 时按轮次分配，同一对不会重复
+	pairs, err = Solve(biz_omiai.ProposalStrategyMaxWeight, [][]int{{80, 70}, {60, -1}}, []int{2, 2}, []int{2, 2})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []Pair{{0, 0}, {0, 1}, {1, 0}}, pairs)
+
+	_, err = Solve("greedy", scores, nil, nil)
+	assert.ErrorIs(t, err, biz_omiai.ErrProposalStrategy)
+}
+
+func TestSolveStable(t *testing.T) {
+	scores := [][]int{
+		{90, 80, 70},
+		{85, 95, -1},
+		{88, 60, 75},
+	}
+	pairs, err := Solve(biz_omiai.ProposalStrategyStable, scores, []int{1, 1, 1}, []int{1, 1, 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []Pair{{0, 0}, {1, 1}, {2, 2}}, pairs)
+
+	// 稳定性：不存在双方都更偏好彼此的配对
+	partner := map[int]int{}
+	rowOf := map[int]int{}
+	for _, p := range pairs {
+		partner[p.Row] = p.Col
+		rowOf[p.Col] = p.Row
+	}
+	for i := range scores {
+		for j := range scores[i] {
+			if scores[i][j] < 0 || partner[i] == j {
+				continue
+			}
+			rowPrefers := scores[i][j] > scores[i][partner[i]]
+			colPrefers := scores[i][j] > scores[rowOf[j]][j]
+			assert.False(t, rowPrefers && colPrefers, "blocking pair (%d,%d)", i, j)
+		}
+	}
+
+	// 列容量为 0 的不参与
+	pairs, err = Solve(biz_omiai.ProposalStrategyStable, [][]int{{90, 80}}, []int{1}, []int{0, 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []Pair{{0, 1}}, pairs)
+}
+
+// tableScorer 按预设得分表评分
+type tableScorer map[[2]uint64]int
+
+func (s tableScorer) Name() string { return "table" }
+
+func (s tableScorer) Score(client, candidate *biz_omiai.Client) *biz_omiai.ScoreResult {
+	return &biz_omiai.ScoreResult{Score: s[pairKey(client.ID, candidate.ID)], Algorithm: s.Name(), Tags: []string{"测试"}}
+}
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	assert.NoError(t, db.AutoMigrate(&biz_omiai.Client{}, &biz_omiai.PersonalityProfile{}, &biz_omiai.ClientEmbedding{}, &biz_omiai.ClientTag{}, &biz_omiai.Tag{}, &biz_omiai.TagGroup{}, &biz_omiai.PairHistory{},
+		&biz_omiai.Introduction{}, &biz_omiai.IntroductionHistory{}, &biz_omiai.ProposalBatch{}, &biz_omiai.Proposal{}))
+
+	// 1、2 为男方，3、4 为女方；3 是热门客户
+	for i := 1; i <= 4; i++ {
+		gender := int8(2)
+		if i <= 2 {
+			gender = 1
+		}
+		assert.NoError(t, db.Create(&biz_omiai.Client{ID: uint64(i), Gender: gender, Status: biz_omiai.ClientStatusSingle}).Error)
+	}
+	scorer := tableScorer{{1, 3}: 95, {2, 3}: 90, {1, 4}: 70, {2, 4}: 50}
+
+	d := &data.DB{DB: db}
+	bus := event.NewBus()
+	pairs := omiai.NewPairHistoryRepo(d)
+	svc := NewService(omiai.NewProposalRepo(d), omiai.NewClientRepo(d, bus), pairs, omiai.NewIntroductionRepo(d, scorer, bus), scorer)
+
+	batch, proposals, err := svc.Generate(ctx, &biz_omiai.ProposalOptions{CandidateCap: 1, MinScore: 60})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, batch.ClientCount)
+	// 2-4 低于最低分，最优为 1-4 + 2-3
+	assert.Len(t, proposals, 2)
+	assert.Equal(t, 80.0, batch.AvgScore)
+
+	// 待审核建议占用名额，再次生成不会重复推荐
+	_, again, err := svc.Generate(ctx, &biz_omiai.ProposalOptions{CandidateCap: 1, MinScore: 60})
+	assert.NoError(t, err)
+	assert.Empty(t, again)
+
+	var accepted, rejected *biz_omiai.Proposal
+	for _, p := range proposals {
+		if p.MaleClientID == 1 {
+			rejected = p
+		} else {
+			accepted = p
+		}
+	}
+
+	intro, err := svc.Accept(ctx, accepted.ID, 7, "红娘", "")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), intro.ClientAID)
+	_, err = svc.Accept(ctx, accepted.ID, 7, "红娘", "")
+	assert.ErrorIs(t, err, biz_omiai.ErrProposalReviewed)
+
+	assert.ErrorIs(t, svc.Reject(ctx, rejected.ID, "", "红娘", ""), biz_omiai.ErrPairReason)
+	assert.NoError(t, svc.Reject(ctx, rejected.ID, biz_omiai.PairReasonRegion, "红娘", "异地"))
+	history, err := pairs.List(ctx, 1)
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+	assert.Equal(t, biz_omiai.PairSourceProposal, history[0].SourceType)
+
+	var finished biz_omiai.ProposalBatch
+	assert.NoError(t, db.First(&finished, batch.ID).Error)
+	assert.Equal(t, int8(biz_omiai.ProposalBatchStatusFinished), finished.Status)
+}
+
+func TestGenerateRequirements(t *testing.T) {
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	assert.NoError(t, db.AutoMigrate(&biz_omiai.Client{}, &biz_omiai.PersonalityProfile{}, &biz_omiai.ClientEmbedding{}, &biz_omiai.ClientTag{}, &biz_omiai.Tag{}, &biz_omiai.TagGroup{}, &biz_omiai.PairHistory{},
+		&biz_omiai.ProposalBatch{}, &biz_omiai.Proposal{}))
+
+	// 1 要求身高 170 以上：3 差 2cm 在宽松容差内，4 差 10cm 不论哪种模式均排除
+	assert.NoError(t, db.Create(&biz_omiai.Client{ID: 1, Gender: 1, Status: biz_omiai.ClientStatusSingle, PartnerRequirements: `{"min_height":170}`}).Error)
+	assert.NoError(t, db.Create(&biz_omiai.Client{ID: 3, Gender: 2, Height: 168, Status: biz_omiai.ClientStatusSingle}).Error)
+	assert.NoError(t, db.Create(&biz_omiai.Client{ID: 4, Gender: 2, Height: 160, Status: biz_omiai.ClientStatusSingle}).Error)
+	scorer := tableScorer{{1, 3}: 80, {1, 4}: 90}
+	d := &data.DB{DB: db}
+	svc := NewService(omiai.NewProposalRepo(d), omiai.NewClientRepo(d, event.NewBus()), omiai.NewPairHistoryRepo(d), nil, scorer)
+
+	_, proposals, err := svc.Generate(ctx, &biz_omiai.ProposalOptions{CandidateCap: 1, MinScore: 60, RequirementMode: biz_omiai.RequirementStrict})
+	assert.NoError(t, err)
+	assert.Empty(t, proposals)
+	_, proposals, err = svc.Generate(ctx, &biz_omiai.ProposalOptions{CandidateCap: 1, MinScore: 60, RequirementMode: biz_omiai.RequirementRelaxed})
+	assert.NoError(t, err)
+	assert.Len(t, proposals, 1)
+	assert.Equal(t, uint64(3), proposals[0].FemaleClientID)
+}
@@ -0,0 +1,103 @@
+package quality
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+)
+
+// batchSize 全量重算时每批读取的客户数
+const batchSize = 500
+
+// buckets 日报中的完整度区间
+var buckets = [][2]int{{0, 59}, {60, 79}, {80, 99}, {100, 100}}
+
+// Service 资料质量：全量重算完整度并生成日报
+type Service struct {
+	repo   biz_omiai.QualityInterface
+	events biz_omiai.EventBus
+	now    func() time.Time
+}
+
+func NewService(repo biz_omiai.QualityInterface, events biz_omiai.EventBus) *Service {
+	return &Service{repo: repo, events: events, now: time.Now}
+}
+
+// Report 按当前规则重算全部客户的完整度，只写回有变化的客户，汇总保存为当天日报。
+// 资料保存时已同步计算，全量重算用于回填存量数据、规则调整及随日期变化的年龄校验。
+// 完整度参与候选人评分，有变化的客户按资料变更发布事件，使其作为候选人的推荐重新评分
+func (s *Service) Report(ctx context.Context) (*biz_omiai.QualityReport, error) {
+	now := s.now()
+	report := &biz_omiai.QualityReport{ReportDate: now.Format("2006-01-02"), CreatedAt: now}
+	for _, b := range buckets {
+		report.BucketList = append(report.BucketList, &biz_omiai.QualityBucket{Min: b[0], Max: b[1]})
+	}
+	counts := make(map[[2]string]int64)
+	sum := 0
+
+	var afterID uint64
+	for {
+		list, err := s.repo.Batch(ctx, afterID, batchSize)
+		if err != nil {
+			return nil, err
+		}
+		var changed []uint64
+		for _, c := range list {
+			stored, issues := c.Completeness, c.QualityIssues
+			c.SyncQuality(now)
+			if c.Completeness != stored || c.QualityIssues != issues {
+				if err := s.repo.Save(ctx, c); err != nil {
+					return nil, err
+				}
+				if c.Completeness != stored {
+					changed = append(changed, c.ID)
+				}
+			}
+
+			report.Total++
+			sum += c.Completeness
+			if c.Completeness == 100 {
+				report.Complete++
+			}
+			for _, b := range report.BucketList {
+				if c.Completeness >= b.Min && c.Completeness <= b.Max {
+					b.Count++
+				}
+			}
+			for _, issue := range c.QualityIssueList {
+				counts[[2]string{issue.Field, issue.Kind}]++
+			}
+		}
+		if len(changed) > 0 {
+			s.events.Publish(ctx, biz_omiai.EventClientChanged, &biz_omiai.ClientChanged{ClientIDs: changed, Change: biz_omiai.ClientChangeProfile})
+		}
+		if len(list) < batchSize {
+			break
+		}
+		afterID = list[len(list)-1].ID
+	}
+
+	if report.Total > 0 {
+		report.AvgCompleteness = math.Round(float64(sum)/float64(report.Total)*10) / 10
+	}
+	// 按规则顺序汇总后按问题数倒序，便于优先补录影响面最大的字段
+	report.IssueList = make([]*biz_omiai.QualityIssueCount, 0)
+	for _, rule := range biz_omiai.QualityRules {
+		for _, kind := range []string{biz_omiai.QualityMissing, biz_omiai.QualityInvalid} {
+			if n := counts[[2]string{rule.Field, kind}]; n > 0 {
+				report.IssueList = append(report.IssueList, &biz_omiai.QualityIssueCount{Field: rule.Field, Label: rule.Label, Kind: kind, Count: n})
+			}
+		}
+	}
+	sort.SliceStable(report.IssueList, func(i, j int) bool {
+		return report.IssueList[i].Count > report.IssueList[j].Count
+	})
+
+	if err := s.repo.SaveReport(ctx, report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
@@ -0,0 +1,75 @@
+package quality
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/data"
+	"omiai-server/internal/data/omiai"
+	"omiai-server/internal/service/event"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestReport(t *testing.T) {
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	assert.NoError(t, db.AutoMigrate(&biz_omiai.Client{}, &biz_omiai.PersonalityProfile{}, &biz_omiai.ClientEmbedding{}, &biz_omiai.ClientTag{},
+		&biz_omiai.Tag{}, &biz_omiai.TagGroup{}, &biz_omiai.ClientVersion{}, &biz_omiai.QualityReport{}))
+
+	d := &data.DB{DB: db}
+	bus := event.NewBus()
+	var changed []uint64
+	bus.Subscribe(biz_omiai.EventClientChanged, func(ctx context.Context, e *biz_omiai.Event) {
+		changed = append(changed, e.Payload.(*biz_omiai.ClientChanged).ClientIDs...)
+	})
+	clients := omiai.NewClientRepo(d, bus)
+	repo := omiai.NewQualityRepo(d)
+	svc := NewService(repo, bus)
+	svc.now = func() time.Time { return time.Date(2026, 5, 10, 0, 30, 0, 0, time.Local) }
+
+	// 保存时同步计算
+	a := &biz_omiai.Client{Name: "甲", Gender: 2, Phone: "13800000001", Birthday: "1995-03", Height: 0}
+	assert.NoError(t, clients.Create(ctx, a))
+	got, _ := clients.Get(ctx, a.ID)
+	assert.Equal(t, 30, got.Completeness)
+	assert.Len(t, got.DecodeQuality(), 14)
+
+	a.Height = 165
+	assert.NoError(t, clients.Update(ctx, a))
+	got, _ = clients.Get(ctx, a.ID)
+	assert.Equal(t, 38, got.Completeness)
+
+	// 存量数据未计算过，由日报回填，完整度变化的客户发布资料变更事件
+	assert.NoError(t, db.Create(&biz_omiai.Client{Name: "乙", Gender: 1, Birthday: "1990-02-30"}).Error)
+	changed = nil
+	report, err := svc.Report(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), report.Total)
+	assert.Equal(t, 24.0, report.AvgCompleteness)
+	assert.Equal(t, int64(2), report.BucketList[0].Count)
+	assert.Equal(t, "avatar", report.IssueList[0].Field)
+	assert.Equal(t, int64(2), report.IssueList[0].Count)
+
+	var b biz_omiai.Client
+	assert.NoError(t, db.Where("name = ?", "乙").First(&b).Error)
+	assert.Equal(t, 10, b.Completeness)
+	assert.Equal(t, []uint64{b.ID}, changed)
+	assert.Equal(t, biz_omiai.QualityInvalid, b.DecodeQuality()[1].Kind)
+
+	// 同一天重复统计覆盖当天日报
+	_, err = svc.Report(ctx)
+	assert.NoError(t, err)
+	list, total, err := repo.Reports(ctx, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, "2026-05-10", list[0].ReportDate)
+	assert.Len(t, list[0].BucketList, 4)
+}
@@ -0,0 +1,221 @@
+package questionnaire
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+
+	"github.com/google/uuid"
+)
+
+// 李克特量表取值范围
+const (
+	minAnswer = 1
+	maxAnswer = 5
+)
+
+// DefaultExpireDays 填写链接默认有效期
+const DefaultExpireDays = 7
+
+var (
+	ErrSheetNotFound  = errors.New("问卷链接无效")
+	ErrSheetExpired   = errors.New("问卷链接已过期")
+	ErrSheetSubmitted = errors.New("问卷已提交，请勿重复填写")
+	ErrInvalidAnswer  = errors.New("答案有误")
+)
+
+type Service struct {
+	repo   biz_omiai.QuestionnaireInterface
+	events biz_omiai.EventBus
+}
+
+func NewService(repo biz_omiai.QuestionnaireInterface, events biz_omiai.EventBus) *Service {
+	return &Service{repo: repo, events: events}
+}
+
+// Active 获取启用中的测评问卷，题库为空时以内置题库发布第一版
+func (s *Service) Active(ctx context.Context) (*biz_omiai.Questionnaire, error) {
+	q, err := s.repo.GetActive(ctx, biz_omiai.QuestionnaireCodePersonality)
+	if err != nil || q != nil {
+		return q, err
+	}
+	questions, _ := json.Marshal(DefaultQuestions())
+	q = &biz_omiai.Questionnaire{
+		Code:        biz_omiai.QuestionnaireCodePersonality,
+		Title:       "性格与价值观测评",
+		Description: "共24题，请根据真实想法选择 1(非常不同意) - 5(非常同意)",
+		Questions:   string(questions),
+	}
+	if err := s.repo.CreateVersion(ctx, q); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// Issue 为客户发放问卷，返回带 Token 的答卷
+func (s *Service) Issue(ctx context.Context, clientID, questionnaireID, issuedBy uint64, expireDays int) (*biz_omiai.QuestionnaireSheet, error) {
+	var (
+		q   *biz_omiai.Questionnaire
+		err error
+	)
+	if questionnaireID > 0 {
+		q, err = s.repo.Get(ctx, questionnaireID)
+	} else {
+		q, err = s.Active(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if expireDays <= 0 {
+		expireDays = DefaultExpireDays
+	}
+
+	sheet := &biz_omiai.QuestionnaireSheet{
+		QuestionnaireID: q.ID,
+		ClientID:        clientID,
+		Token:           strings.ReplaceAll(uuid.NewString(), "-", ""),
+		Status:          biz_omiai.SheetStatusPending,
+		IssuedBy:        issuedBy,
+		ExpiredAt:       time.Now().AddDate(0, 0, expireDays),
+	}
+	if err := s.repo.CreateSheet(ctx, sheet); err != nil {
+		return nil, err
+	}
+	sheet.Questionnaire = q
+	return sheet, nil
+}
+
+// Open 按 Token 打开待填写的答卷
+func (s *Service) Open(ctx context.Context, token string) (*biz_omiai.QuestionnaireSheet, error) {
+	sheet, err := s.repo.GetSheetByToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if sheet == nil || sheet.Questionnaire == nil {
+		return nil, ErrSheetNotFound
+	}
+	if sheet.Status == biz_omiai.SheetStatusSubmitted {
+		return nil, ErrSheetSubmitted
+	}
+	if time.Now().After(sheet.ExpiredAt) {
+		return nil, ErrSheetExpired
+	}
+	return sheet, nil
+}
+
+// Submit 提交答案，计算并保存客户测评画像
+func (s *Service) Submit(ctx context.Context, token string, answers map[string]int) (*biz_omiai.PersonalityProfile, error) {
+	sheet, err := s.Open(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	scores, err := Score(sheet.Questionnaire.QuestionList(), answers)
+	if err != nil {
+		return nil, fmt.Errorf("%w：%s", ErrInvalidAnswer, err.Error())
+	}
+
+	answersJSON, _ := json.Marshal(answers)
+	scoresJSON, _ := json.Marshal(scores)
+	sheet.Answers = string(answersJSON)
+	profile := &biz_omiai.PersonalityProfile{
+		ClientID:        sheet.ClientID,
+		QuestionnaireID: sheet.QuestionnaireID,
+		Version:         sheet.Questionnaire.Version,
+		SheetID:         sheet.ID,
+		Scores:          string(scoresJSON),
+	}
+	if err := s.repo.SubmitSheet(ctx, sheet, profile); err != nil {
+		return nil, err
+	}
+	// 画像变化影响性格维度评分
+	s.events.Publish(ctx, biz_omiai.EventClientChanged, &biz_omiai.ClientChanged{
+		ClientIDs: []uint64{sheet.ClientID},
+		Change:    biz_omiai.ClientChangeProfile,
+	})
+	return profile, nil
+}
+
+// Score 按维度汇总答案：反向题取 6-x，维度均分线性映射到 0-100
+// 所有题目必须作答且取值 1-5
+func Score(questions []*biz_omiai.Question, answers map[string]int) (map[string]float64, error) {
+	sums := make(map[string]float64)
+	counts := make(map[string]int)
+	for _, q := range questions {
+		v, ok := answers[q.Key]
+		if !ok {
+			return nil, fmt.Errorf("第%s题未作答", q.Key)
+		}
+		if v < minAnswer || v > maxAnswer {
+			return nil, fmt.Errorf("第%s题答案超出范围", q.Key)
+		}
+		if q.Reverse {
+			v = minAnswer + maxAnswer - v
+		}
+		sums[q.Dimension] += float64(v)
+		counts[q.Dimension]++
+	}
+
+	scores := make(map[string]float64, len(sums))
+	for dim, sum := range sums {
+		avg := sum / float64(counts[dim])
+		scores[dim] = math.Round((avg-minAnswer)/(maxAnswer-minAnswer)*1000) / 10
+	}
+	return scores, nil
+}
+
+// ValidateQuestions 校验题目：key 唯一且维度合法
+func ValidateQuestions(questions []*biz_omiai.Question) error {
+	if len(questions) == 0 {
+		return errors.New("题目不能为空")
+	}
+	seen := make(map[string]bool, len(questions))
+	for _, q := range questions {
+		if q.Key == "" || q.Text == "" {
+			return errors.New("题目标识和题干不能为空")
+		}
+		if seen[q.Key] {
+			return fmt.Errorf("题目标识重复: %s", q.Key)
+		}
+		seen[q.Key] = true
+		if _, ok := biz_omiai.ProfileDimensionByKey(q.Dimension); !ok {
+			return fmt.Errorf("题目 %s 的维度不存在: %s", q.Key, q.Dimension)
+		}
+	}
+	return nil
+}
+
+// DefaultQuestions 内置题库，每个维度 2 题，含反向题
+func DefaultQuestions() []*biz_omiai.Question {
+	return []*biz_omiai.Question{
+		{Key: "o1", Text: "我喜欢尝试新鲜事物，比如去没去过的地方旅行", Dimension: "openness"},
+		{Key: "o2", Text: "我更习惯按部就班，不太喜欢改变", Dimension: "openness", Reverse: true},
+		{Key: "c1", Text: "做事之前我通常会提前计划好", Dimension: "conscientiousness"},
+		{Key: "c2", Text: "我经常拖延，事情到最后才做", Dimension: "conscientiousness", Reverse: true},
+		{Key: "e1", Text: "在聚会上我通常很容易和陌生人聊起来", Dimension: "extraversion"},
+		{Key: "e2", Text: "休息日我更愿意一个人待着", Dimension: "extraversion", Reverse: true},
+		{Key: "a1", Text: "发生分歧时我愿意先考虑对方的感受", Dimension: "agreeableness"},
+		{Key: "a2", Text: "我很难原谅别人犯的错", Dimension: "agreeableness", Reverse: true},
+		{Key: "n1", Text: "我的情绪容易因为小事起伏", Dimension: "neuroticism"},
+		{Key: "n2", Text: "遇到压力时我通常能保持冷静", Dimension: "neuroticism", Reverse: true},
+		{Key: "v1", Text: "婚后经常陪伴双方父母很重要", Dimension: "family"},
+		{Key: "v2", Text: "小家庭的事情应该由两个人决定，不需要长辈参与", Dimension: "family", Reverse: true},
+		{Key: "v3", Text: "为了事业发展，我可以接受一段时间聚少离多", Dimension: "career"},
+		{Key: "v4", Text: "工作稳定比升职加薪更重要", Dimension: "career", Reverse: true},
+		{Key: "v5", Text: "只要喜欢，花钱买贵的东西是值得的", Dimension: "consumption"},
+		{Key: "v6", Text: "每个月都应该把一部分收入存起来", Dimension: "consumption", Reverse: true},
+		{Key: "v7", Text: "结婚时彩礼、婚房等传统习俗应该遵守", Dimension: "tradition"},
+		{Key: "v8", Text: "婚礼形式可以简单，两个人开心就好", Dimension: "tradition", Reverse: true},
+		{Key: "r1", Text: "我希望在一年内步入婚姻", Dimension: "marriage_urgency"},
+		{Key: "r2", Text: "我更想先多相处几年再考虑结婚", Dimension: "marriage_urgency", Reverse: true},
+		{Key: "r3", Text: "我希望婚后尽快要孩子", Dimension: "children"},
+		{Key: "r4", Text: "我可以接受婚后不要孩子", Dimension: "children", Reverse: true},
+		{Key: "r5", Text: "我寻找的是可以共度一生的伴侣", Dimension: "long_term"},
+		{Key: "r6", Text: "现阶段我更看重相处的感觉，不急于确定关系", Dimension: "long_term", Reverse: true},
+	}
+}
@@ -0,0 +1,40 @@
+package questionnaire
+
+import (
+	"testing"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScore(t *testing.T) {
+	questions := []*biz_omiai.Question{
+		{Key: "e1", Dimension: "extraversion"},
+		{Key: "e2", Dimension: "extraversion", Reverse: true},
+		{Key: "v1", Dimension: "family"},
+	}
+
+	scores, err := Score(questions, map[string]int{"e1": 5, "e2": 1, "v1": 3})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]float64{"extraversion": 100, "family": 50}, scores)
+
+	_, err = Score(questions, map[string]int{"e1": 5, "e2": 1})
+	assert.Error(t, err, "unanswered question")
+	_, err = Score(questions, map[string]int{"e1": 6, "e2": 1, "v1": 3})
+	assert.Error(t, err, "answer out of range")
+}
+
+func TestDefaultQuestions(t *testing.T) {
+	questions := DefaultQuestions()
+	assert.NoError(t, ValidateQuestions(questions))
+
+	// 内置题库需覆盖全部测评维度
+	covered := make(map[string]bool)
+	for _, q := range questions {
+		covered[q.Dimension] = true
+	}
+	for _, d := range biz_omiai.ProfileDimensions {
+		assert.True(t, covered[d.Key], d.Key)
+	}
+}
@@ -0,0 +1,397 @@
+package search
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+
+	"github.com/iWuxc/go-wit/log"
+)
+
+const (
+	// DefaultLimit 检索默认返回数量
+	DefaultLimit = 500
+	// buildBatch 建索引时每批读取的客户数
+	buildBatch = 500
+	// syncInterval 距上次同步超过该时长时，检索前先补齐其他实例写入的变更
+	syncInterval = 30 * time.Second
+	// syncSkew 增量同步回看的时长，容忍数据库与本机的时钟偏差
+	syncSkew = 5 * time.Second
+)
+
+// fieldWeights 字段名 => 权重
+var fieldWeights = func() map[string]float64 {
+	out := make(map[string]float64, len(biz_omiai.SearchFields))
+	for _, f := range biz_omiai.SearchFields {
+		out[f.Name] = f.Weight
+	}
+	return out
+}()
+
+// Service 客户资料全文检索，进程内倒排索引，中文按单字与二元组切分。
+// 首次检索时全量构建，之后随客户与回访记录的写入事件更新
+type Service struct {
+	repo biz_omiai.SearchInterface
+	now  func() time.Time
+
+	// syncMu 串行化全量构建与增量同步
+	syncMu   sync.Mutex
+	built    bool
+	syncedAt time.Time
+
+	mu       sync.RWMutex
+	docs     map[uint64]*document
+	postings map[string]map[uint64]struct{}
+}
+
+// document 已索引的客户文本，norm 为归一化后的文本，与 raw 按字符一一对应
+type document struct {
+	fields map[string]*field
+	terms  []string
+}
+
+type field struct {
+	raw  []rune
+	norm []rune
+}
+
+// NewService 创建服务并订阅客户资料与回访记录的变更事件
+func NewService(repo biz_omiai.SearchInterface, events biz_omiai.EventBus) *Service {
+	s := &Service{
+		repo:     repo,
+		now:      time.Now,
+		docs:     make(map[uint64]*document),
+		postings: make(map[string]map[uint64]struct{}),
+	}
+	events.Subscribe(biz_omiai.EventClientChanged, s.onClientChanged)
+	events.Subscribe(biz_omiai.EventFollowUpCreated, s.onFollowUpCreated)
+	return s
+}
+
+// Search 按关键词检索客户，空格分隔的多个关键词需全部命中，结果按相关度倒序
+func (s *Service) Search(ctx context.Context, q string, limit int) ([]*biz_omiai.SearchHit, error) {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	parts := splitQuery(q)
+	if len(parts) == 0 {
+		return []*biz_omiai.SearchHit{}, nil
+	}
+	if err := s.ensure(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	candidates, words, idf := s.lookup(parts)
+	hits := make([]*biz_omiai.SearchHit, 0, len(candidates))
+	for id := range candidates {
+		if hit := s.score(id, words, idf); hit != nil {
+			hits = append(hits, hit)
+		}
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ClientID > hits[j].ClientID
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+// Match 按关键词检索全部命中的客户 ID，不计算相关度、不限数量，供分群人数统计与成员刷新使用
+func (s *Service) Match(ctx context.Context, q string) ([]uint64, error) {
+	parts := splitQuery(q)
+	if len(parts) == 0 {
+		return []uint64{}, nil
+	}
+	if err := s.ensure(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	candidates, words, _ := s.lookup(parts)
+	ids := make([]uint64, 0, len(candidates))
+	for id := range candidates {
+		if s.matches(id, words) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+// lookup 由倒排索引取出包含全部关键词切分结果的候选客户及各关键词的 idf，任一关键词无命中时候选为空；调用方须持有读锁
+func (s *Service) lookup(parts []string) (map[uint64]struct{}, [][]rune, []float64) {
+	words := make([][]rune, 0, len(parts))
+	idf := make([]float64, 0, len(parts))
+	var candidates map[uint64]struct{}
+	for _, p := range parts {
+		df := -1
+		for _, t := range queryTerms(p) {
+			ids := s.postings[t]
+			if df < 0 || len(ids) < df {
+				df = len(ids)
+			}
+			candidates = intersect(candidates, ids)
+		}
+		if df <= 0 {
+			return nil, nil, nil
+		}
+		words = append(words, []rune(p))
+		idf = append(idf, math.Log(1+float64(len(s.docs))/float64(df)))
+	}
+	return candidates, words, idf
+}
+
+// matches 每个关键词是否都在某一字段中完整出现
+func (s *Service) matches(id uint64, parts [][]rune) bool {
+	doc := s.docs[id]
+	if doc == nil {
+		return false
+	}
+	for _, p := range parts {
+		found := false
+		for _, f := range doc.fields {
+			if len(findAll(f.norm, p)) > 0 {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// score 每个关键词都须在某一字段中完整出现，得分为各命中字段的 权重 × (1 + ln 词频) × idf 之和
+func (s *Service) score(id uint64, parts [][]rune, idf []float64) *biz_omiai.SearchHit {
+	doc := s.docs[id]
+	if doc == nil {
+		return nil
+	}
+	hit := &biz_omiai.SearchHit{ClientID: id}
+	matched := make(map[string][][2]int)
+	for i, p := range parts {
+		found := false
+		for name, f := range doc.fields {
+			spans := findAll(f.norm, p)
+			if len(spans) == 0 {
+				continue
+			}
+			found = true
+			hit.Score += fieldWeights[name] * (1 + math.Log(float64(len(spans)))) * idf[i]
+			matched[name] = append(matched[name], spans...)
+		}
+		if !found {
+			return nil
+		}
+	}
+	hit.Score = math.Round(hit.Score*1000) / 1000
+	for _, f := range biz_omiai.SearchFields {
+		if spans, ok := matched[f.Name]; ok {
+			hit.Highlights = append(hit.Highlights, &biz_omiai.SearchHighlight{
+				Field:   f.Name,
+				Label:   f.Label,
+				Snippet: snippet(doc.fields[f.Name].raw, spans),
+			})
+		}
+	}
+	return hit
+}
+
+// ensure 首次检索时全量构建索引，之后定期补齐增量
+func (s *Service) ensure(ctx context.Context) error {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+	if !s.built {
+		return s.build(ctx)
+	}
+	if s.now().Sub(s.syncedAt) < syncInterval {
+		return nil
+	}
+	start := s.now()
+	ids, err := s.repo.Changed(ctx, s.syncedAt.Add(-syncSkew))
+	if err != nil {
+		return err
+	}
+	if err := s.refresh(ctx, ids); err != nil {
+		return err
+	}
+	s.syncedAt = start
+	return nil
+}
+
+func (s *Service) build(ctx context.Context) error {
+	start := s.now()
+	docs := make(map[uint64]*document)
+	postings := make(map[string]map[uint64]struct{})
+	var afterID uint64
+	for {
+		list, err := s.repo.Documents(ctx, afterID, buildBatch)
+		if err != nil {
+			return err
+		}
+		for _, d := range list {
+			doc := newDocument(d)
+			docs[d.ClientID] = doc
+			addPostings(postings, d.ClientID, doc)
+			afterID = d.ClientID
+		}
+		if len(list) < buildBatch {
+			break
+		}
+	}
+	s.mu.Lock()
+	s.docs, s.postings = docs, postings
+	s.mu.Unlock()
+	s.built, s.syncedAt = true, start
+	log.WithContext(ctx).Infof("search: index built with %d clients", len(docs))
+	return nil
+}
+
+// Refresh 重新索引指定客户，已删除或进入回收站的客户移出索引；索引尚未构建时跳过
+func (s *Service) Refresh(ctx context.Context, clientIDs []uint64) error {
+	if len(clientIDs) == 0 {
+		return nil
+	}
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+	if !s.built {
+		return nil
+	}
+	return s.refresh(ctx, clientIDs)
+}
+
+func (s *Service) refresh(ctx context.Context, clientIDs []uint64) error {
+	if len(clientIDs) == 0 {
+		return nil
+	}
+	list, err := s.repo.DocumentsByIDs(ctx, clientIDs)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range clientIDs {
+		s.remove(id)
+	}
+	for _, d := range list {
+		doc := newDocument(d)
+		s.docs[d.ClientID] = doc
+		addPostings(s.postings, d.ClientID, doc)
+	}
+	return nil
+}
+
+func (s *Service) remove(id uint64) {
+	doc := s.docs[id]
+	if doc == nil {
+		return
+	}
+	for _, t := range doc.terms {
+		if ids := s.postings[t]; ids != nil {
+			delete(ids, id)
+			if len(ids) == 0 {
+				delete(s.postings, t)
+			}
+		}
+	}
+	delete(s.docs, id)
+}
+
+func (s *Service) onClientChanged(ctx context.Context, event *biz_omiai.Event) {
+	payload, ok := event.Payload.(*biz_omiai.ClientChanged)
+	if !ok {
+		return
+	}
+	if err := s.Refresh(ctx, payload.ClientIDs); err != nil {
+		log.WithContext(ctx).Errorf("search: %s clients %v err:%v", payload.Change, payload.ClientIDs, err)
+	}
+}
+
+func (s *Service) onFollowUpCreated(ctx context.Context, event *biz_omiai.Event) {
+	payload, ok := event.Payload.(*biz_omiai.FollowUpCreated)
+	if !ok {
+		return
+	}
+	if err := s.Refresh(ctx, payload.ClientIDs); err != nil {
+		log.WithContext(ctx).Errorf("search: follow up %d clients %v err:%v", payload.RecordID, payload.ClientIDs, err)
+	}
+}
+
+func newDocument(d *biz_omiai.SearchDocument) *document {
+	doc := &document{fields: make(map[string]*field, len(d.Fields))}
+	terms := make(map[string]struct{})
+	for name, text := range d.Fields {
+		if _, ok := fieldWeights[name]; !ok || text == "" {
+			continue
+		}
+		raw := []rune(text)
+		norm := normalize(raw)
+		doc.fields[name] = &field{raw: raw, norm: norm}
+		for _, t := range indexTerms(norm) {
+			terms[t] = struct{}{}
+		}
+	}
+	doc.terms = make([]string, 0, len(terms))
+	for t := range terms {
+		doc.terms = append(doc.terms, t)
+	}
+	return doc
+}
+
+func addPostings(postings map[string]map[uint64]struct{}, id uint64, doc *document) {
+	for _, t := range doc.terms {
+		ids := postings[t]
+		if ids == nil {
+			ids = make(map[uint64]struct{})
+			postings[t] = ids
+		}
+		ids[id] = struct{}{}
+	}
+}
+
+// intersect 候选集与倒排表取交集，候选集为 nil 表示尚未限定
+func intersect(candidates, ids map[uint64]struct{}) map[uint64]struct{} {
+	out := make(map[uint64]struct{})
+	if candidates == nil {
+		for id := range ids {
+			out[id] = struct{}{}
+		}
+		return out
+	}
+	for id := range candidates {
+		if _, ok := ids[id]; ok {
+			out[id] = struct{}{}
+		}
+	}
+	return out
+}
+
+// splitQuery 归一化后按空白切分关键词并去重
+func splitQuery(q string) []string {
+	seen := make(map[string]struct{})
+	var out []string
+	for _, p := range strings.Fields(string(normalize([]rune(q)))) {
+		if len(queryTerms(p)) == 0 {
+			continue
+		}
+		if _, ok := seen[p]; ok {
+			continue
+		}
+		seen[p] = struct{}{}
+		out = append(out, p)
+	}
+	return out
+}
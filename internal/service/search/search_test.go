@@ -0,0 +1,118 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/data"
+	"omiai-server/internal/data/omiai"
+	"omiai-server/internal/service/event"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestTerms(t *testing.T) {
+	assert.Equal(t, []string{"程", "程序", "序", "序员", "员", "j", "ja", "jav", "java"}, indexTerms(normalize([]rune("程序员，Ｊａｖａ"))))
+	assert.Equal(t, []string{"程序", "序员", "java"}, queryTerms("程序员java"))
+	assert.Equal(t, []string{"程序员", "java"}, splitQuery("  程序员　JAVA 程序员 ，"))
+	long := []rune(strings.Repeat("甲", 30) + "杭州" + strings.Repeat("乙", 50))
+	assert.Equal(t, "…"+strings.Repeat("甲", 20)+"<em>杭州</em>"+strings.Repeat("乙", 38)+"…", snippet(long, [][2]int{{30, 32}}))
+	assert.Equal(t, "在<em>杭州</em>做&lt;产品&gt;", snippet([]rune("在杭州做<产品>"), [][2]int{{1, 3}}))
+}
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	assert.NoError(t, db.AutoMigrate(&biz_omiai.Client{}, &biz_omiai.PersonalityProfile{}, &biz_omiai.ClientEmbedding{}, &biz_omiai.ClientTag{}, &biz_omiai.Tag{}, &biz_omiai.TagGroup{}, &biz_omiai.ClientVersion{},
+		&biz_omiai.MatchRecord{}, &biz_omiai.MatchStatusHistory{}, &biz_omiai.FollowUpRecord{}))
+
+	d := &data.DB{DB: db}
+	bus := event.NewBus()
+	clients := omiai.NewClientRepo(d, bus)
+	matches := omiai.NewMatchRepo(d, nil, bus)
+	svc := NewService(omiai.NewSearchRepo(d), bus)
+
+	a := &biz_omiai.Client{Name: "张伟", Gender: 1, Phone: "13800000001", Profession: "Java 程序员", WorkCity: "杭州", Tags: "爬山"}
+	b := &biz_omiai.Client{Name: "李娜", Gender: 2, Phone: "13800000002", Profession: "教师", Remark: "喜欢程序员，希望对方在杭州工作"}
+	c := &biz_omiai.Client{Name: "王芳", Gender: 2, Phone: "13800000003", Address: "北京"}
+	for _, v := range []*biz_omiai.Client{a, b, c} {
+		assert.NoError(t, clients.Create(ctx, v))
+	}
+
+	// 职业字段权重高于备注，排在前面；多个关键词需全部命中
+	hits, err := svc.Search(ctx, "程序员 杭州", 0)
+	assert.NoError(t, err)
+	assert.Len(t, hits, 2)
+	assert.Equal(t, a.ID, hits[0].ClientID)
+	assert.Equal(t, "profession", hits[0].Highlights[0].Field)
+	assert.Equal(t, "Java <em>程序员</em>", hits[0].Highlights[0].Snippet)
+	hits, _ = svc.Search(ctx, "JAVA", 0)
+	assert.Len(t, hits, 1)
+	hits, _ = svc.Search(ctx, "程序 上海", 0)
+	assert.Empty(t, hits)
+
+	// 标签、资料修改随事件更新索引
+	hits, _ = svc.Search(ctx, "爬山", 0)
+	assert.Len(t, hits, 1)
+	assert.NoError(t, clients.Update(ctx, &biz_omiai.Client{ID: c.ID, Name: "王芳", Profession: "产品经理"}))
+	hits, _ = svc.Search(ctx, "产品", 0)
+	assert.Len(t, hits, 1)
+	assert.Equal(t, c.ID, hits[0].ClientID)
+
+	// 回访记录计入情侣双方
+	record := &biz_omiai.MatchRecord{MaleClientID: a.ID, FemaleClientID: c.ID, Status: biz_omiai.MatchStatusDating}
+	assert.NoError(t, db.Create(record).Error)
+	assert.NoError(t, matches.CreateFollowUp(ctx, &biz_omiai.FollowUpRecord{MatchRecordID: record.ID, Content: "周末一起去看了话剧"}))
+	hits, _ = svc.Search(ctx, "话剧", 0)
+	assert.Len(t, hits, 2)
+	assert.Equal(t, biz_omiai.SearchFieldFollowUp, hits[0].Highlights[0].Field)
+
+	// 进入回收站后不再命中
+	assert.NoError(t, clients.Delete(ctx, b.ID))
+	hits, _ = svc.Search(ctx, "教师", 0)
+	assert.Empty(t, hits)
+
+	// 其他实例的写入在同步间隔后补齐
+	assert.NoError(t, db.Model(&biz_omiai.Client{}).Where("id = ?", a.ID).Updates(map[string]interface{}{"remark": "擅长摄影", "updated_at": time.Now()}).Error)
+	hits, _ = svc.Search(ctx, "摄影", 0)
+	assert.Empty(t, hits)
+	svc.now = func() time.Time { return time.Now().Add(syncInterval) }
+	hits, _ = svc.Search(ctx, "摄影", 0)
+	assert.Len(t, hits, 1)
+}
+
+func TestService_Match(t *testing.T) {
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	assert.NoError(t, db.AutoMigrate(&biz_omiai.Client{}, &biz_omiai.ClientTag{}, &biz_omiai.Tag{}, &biz_omiai.TagGroup{}, &biz_omiai.FollowUpRecord{}, &biz_omiai.MatchRecord{}))
+	svc := NewService(omiai.NewSearchRepo(&data.DB{DB: db}), event.NewBus())
+
+	list := make([]*biz_omiai.Client, 0, DefaultLimit+10)
+	for i := 0; i < DefaultLimit+10; i++ {
+		list = append(list, &biz_omiai.Client{Name: "客户", Gender: 1, Profession: "中学教师"})
+	}
+	list = append(list, &biz_omiai.Client{Name: "客户", Gender: 2, Remark: "教育行业，师范毕业"})
+	assert.NoError(t, db.CreateInBatches(list, 100).Error)
+
+	// 排序检索限制返回数量，分群使用的 Match 返回全部命中且只含完整出现关键词的客户
+	hits, err := svc.Search(ctx, "教师", 0)
+	assert.NoError(t, err)
+	assert.Len(t, hits, DefaultLimit)
+	ids, err := svc.Match(ctx, "教师")
+	assert.NoError(t, err)
+	assert.Len(t, ids, DefaultLimit+10)
+	assert.Equal(t, list[0].ID, ids[0])
+	ids, _ = svc.Match(ctx, "教师 摄影")
+	assert.Empty(t, ids)
+}
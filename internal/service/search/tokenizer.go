@@ -0,0 +1,180 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+const (
+	// maxPrefix 字母数字词按前缀索引的最大长度，更长的关键词按此长度查倒排表
+	maxPrefix = 16
+	// snippetContext 摘要中命中位置前保留的字数
+	snippetContext = 20
+	// snippetLength 摘要的最大字数
+	snippetLength = 60
+)
+
+// normalize 转小写并将全角字符转为半角，逐字转换以保证与原文按下标对应
+func normalize(text []rune) []rune {
+	out := make([]rune, len(text))
+	for i, r := range text {
+		switch {
+		case r == '　':
+			r = ' '
+		case r >= '！' && r <= '～':
+			r -= 0xFEE0
+		}
+		out[i] = unicode.ToLower(r)
+	}
+	return out
+}
+
+func isHan(r rune) bool {
+	return unicode.Is(unicode.Han, r)
+}
+
+func isWord(r rune) bool {
+	return !isHan(r) && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// runs 将文本切成连续的汉字段与字母数字段，标点与空白丢弃
+func runs(text []rune, fn func(run []rune, han bool)) {
+	start := -1
+	han := false
+	flush := func(end int) {
+		if start >= 0 {
+			fn(text[start:end], han)
+			start = -1
+		}
+	}
+	for i, r := range text {
+		switch {
+		case isHan(r):
+			if start >= 0 && !han {
+				flush(i)
+			}
+			if start < 0 {
+				start, han = i, true
+			}
+		case isWord(r):
+			if start >= 0 && han {
+				flush(i)
+			}
+			if start < 0 {
+				start, han = i, false
+			}
+		default:
+			flush(i)
+		}
+	}
+	flush(len(text))
+}
+
+// indexTerms 汉字按单字与相邻二元组切分，字母数字词按前缀切分以支持前缀检索
+func indexTerms(text []rune) []string {
+	var out []string
+	runs(text, func(run []rune, han bool) {
+		if han {
+			for i := range run {
+				out = append(out, string(run[i]))
+				if i+1 < len(run) {
+					out = append(out, string(run[i:i+2]))
+				}
+			}
+			return
+		}
+		for i := 1; i <= len(run) && i <= maxPrefix; i++ {
+			out = append(out, string(run[:i]))
+		}
+	})
+	return out
+}
+
+// queryTerms 关键词查倒排表用的词项：多字汉字段取二元组，单字取单字，字母数字词取前缀
+func queryTerms(q string) []string {
+	var out []string
+	runs([]rune(q), func(run []rune, han bool) {
+		if han {
+			if len(run) == 1 {
+				out = append(out, string(run))
+				return
+			}
+			for i := 0; i+1 < len(run); i++ {
+				out = append(out, string(run[i:i+2]))
+			}
+			return
+		}
+		if len(run) > maxPrefix {
+			run = run[:maxPrefix]
+		}
+		out = append(out, string(run))
+	})
+	return out
+}
+
+// findAll 关键词在文本中出现的全部位置 [起, 止)
+func findAll(text, word []rune) [][2]int {
+	var out [][2]int
+	if len(word) == 0 {
+		return out
+	}
+	for i := 0; i+len(word) <= len(text); i++ {
+		match := true
+		for j, r := range word {
+			if text[i+j] != r {
+				match = false
+				break
+			}
+		}
+		if match {
+			out = append(out, [2]int{i, i + len(word)})
+			i += len(word) - 1
+		}
+	}
+	return out
+}
+
+// snippet 截取首个命中位置附近的原文，命中部分以 <em> 标出
+func snippet(raw []rune, spans [][2]int) string {
+	marked := make([]bool, len(raw))
+	first := len(raw)
+	for _, sp := range spans {
+		if sp[0] < first {
+			first = sp[0]
+		}
+		for i := sp[0]; i < sp[1]; i++ {
+			marked[i] = true
+		}
+	}
+	start := first - snippetContext
+	if start < 0 {
+		start = 0
+	}
+	end := start + snippetLength
+	if end > len(raw) {
+		end = len(raw)
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	for i := start; i < end; {
+		j := i
+		for j < end && marked[j] == marked[i] {
+			j++
+		}
+		text := html.EscapeString(strings.ReplaceAll(string(raw[i:j]), "\n", " "))
+		if marked[i] {
+			b.WriteString("<em>" + text + "</em>")
+		} else {
+			b.WriteString(text)
+		}
+		i = j
+	}
+	if end < len(raw) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
@@ -0,0 +1,187 @@
+package segment
+
+import (
+	"context"
+	"time"
+
+	"omiai-server/internal/biz"
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/service/search"
+
+	"github.com/iWuxc/go-wit/log"
+)
+
+// Service 客户分群：筛选条件按当前数据求值，定时刷新成员快照并发布进出分群事件
+type Service struct {
+	repo      biz_omiai.SegmentInterface
+	tags      biz_omiai.TagInterface
+	search    *search.Service
+	reminders biz_omiai.ReminderInterface
+	templates biz_omiai.TemplateRepo
+	events    biz_omiai.EventBus
+	now       func() time.Time
+}
+
+// NewService 创建服务并订阅分群成员变更事件，按提醒规则为新进入的客户生成提醒
+func NewService(repo biz_omiai.SegmentInterface, tags biz_omiai.TagInterface, search *search.Service, reminders biz_omiai.ReminderInterface,
+	templates biz_omiai.TemplateRepo, events biz_omiai.EventBus) *Service {
+	s := &Service{repo: repo, tags: tags, search: search, reminders: reminders, templates: templates, events: events, now: time.Now}
+	events.Subscribe(biz_omiai.EventSegmentChanged, s.onSegmentChanged)
+	return s
+}
+
+// Clause 将筛选条件转为客户查询条件，供客户列表使用。带关键词时 hits 为按相关度排序的检索结果（至多 search.DefaultLimit 条），命中客户以 id IN 限定
+func (s *Service) Clause(ctx context.Context, f *biz_omiai.ClientFilter) (*biz.WhereClause, []*biz_omiai.SearchHit, error) {
+	clause, ok, err := s.baseClause(ctx, f)
+	if err != nil || !ok || f.Q == "" {
+		return clause, nil, err
+	}
+	hits, err := s.search.Search(ctx, f.Q, search.DefaultLimit)
+	if err != nil {
+		return nil, nil, err
+	}
+	ids := make([]uint64, 0, len(hits))
+	for _, h := range hits {
+		ids = append(ids, h.ClientID)
+	}
+	return matchIDs(clause, ids), hits, nil
+}
+
+// memberClause 分群成员的查询条件，关键词命中的客户不限数量，用于统计人数与刷新成员
+func (s *Service) memberClause(ctx context.Context, f *biz_omiai.ClientFilter) (*biz.WhereClause, error) {
+	clause, ok, err := s.baseClause(ctx, f)
+	if err != nil || !ok || f.Q == "" {
+		return clause, err
+	}
+	ids, err := s.search.Match(ctx, f.Q)
+	if err != nil {
+		return nil, err
+	}
+	return matchIDs(clause, ids), nil
+}
+
+// baseClause 关键词以外的筛选条件，ok 为 false 表示已确定无结果
+func (s *Service) baseClause(ctx context.Context, f *biz_omiai.ClientFilter) (*biz.WhereClause, bool, error) {
+	clause := f.Clause(s.now())
+
+	// 标签按标签字典精确匹配，名称与 ID 可同时传入
+	if f.Tags != "" || len(f.TagIDs) > 0 {
+		tagIDs := f.TagIDs
+		if names := biz_omiai.ParseTags(f.Tags); len(names) > 0 {
+			tags, missing, err := s.tags.Resolve(ctx, names, false)
+			if err != nil {
+				return nil, false, err
+			}
+			// 包含全部标签时任一标签未收录即无结果
+			if len(missing) > 0 && f.TagMode == biz_omiai.TagMatchAll {
+				return matchNone(clause), false, nil
+			}
+			for _, t := range tags {
+				tagIDs = append(tagIDs, t.ID)
+			}
+			if len(tagIDs) == 0 {
+				return matchNone(clause), false, nil
+			}
+		}
+		biz_omiai.AppendTagFilter(clause, tagIDs, f.TagMode)
+	}
+	return clause, true, nil
+}
+
+// matchIDs 以 id IN 限定为检索命中的客户，无命中时无结果
+func matchIDs(clause *biz.WhereClause, ids []uint64) *biz.WhereClause {
+	if len(ids) == 0 {
+		return matchNone(clause)
+	}
+	clause.Where += " AND id IN ?"
+	clause.Args = append(clause.Args, ids)
+	return clause
+}
+
+// Count 分群的实时人数
+func (s *Service) Count(ctx context.Context, seg *biz_omiai.ClientSegment) (int64, error) {
+	clause, err := s.memberClause(ctx, seg.Conditions)
+	if err != nil {
+		return 0, err
+	}
+	return s.repo.Count(ctx, clause)
+}
+
+// ClientIDs 当前属于分群的全部客户，按录入时间倒序，供批量操作、导出与活动邀约使用
+func (s *Service) ClientIDs(ctx context.Context, seg *biz_omiai.ClientSegment) ([]uint64, error) {
+	clause, err := s.memberClause(ctx, seg.Conditions)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ClientIDs(ctx, clause)
+}
+
+// Refresh 按当前数据刷新成员快照，有客户进出时发布 EventSegmentChanged
+func (s *Service) Refresh(ctx context.Context, seg *biz_omiai.ClientSegment) (*biz_omiai.SegmentChanged, error) {
+	ids, err := s.ClientIDs(ctx, seg)
+	if err != nil {
+		return nil, err
+	}
+	entered, left, err := s.repo.SaveMembers(ctx, seg.ID, ids, s.now())
+	if err != nil {
+		return nil, err
+	}
+	change := &biz_omiai.SegmentChanged{SegmentID: seg.ID, Entered: entered, Left: left}
+	if len(entered) > 0 || len(left) > 0 {
+		s.events.Publish(ctx, biz_omiai.EventSegmentChanged, change)
+	}
+	return change, nil
+}
+
+// RefreshAll 刷新全部分群，单个分群失败不影响其余分群，返回刷新成功的数量
+func (s *Service) RefreshAll(ctx context.Context) (int, error) {
+	list, err := s.repo.All(ctx)
+	if err != nil {
+		return 0, err
+	}
+	done := 0
+	for _, seg := range list {
+		if _, err := s.Refresh(ctx, seg); err != nil {
+			log.WithContext(ctx).Errorf("segment: refresh %d err:%v", seg.ID, err)
+			continue
+		}
+		done++
+	}
+	return done, nil
+}
+
+// onSegmentChanged 为新进入分群的客户按 SegmentEnter 规则生成提醒
+func (s *Service) onSegmentChanged(ctx context.Context, event *biz_omiai.Event) {
+	payload, ok := event.Payload.(*biz_omiai.SegmentChanged)
+	if !ok || len(payload.Entered) == 0 {
+		return
+	}
+	rules, err := s.reminders.ListRules()
+	if err != nil {
+		log.WithContext(ctx).Errorf("segment: list reminder rules err:%v", err)
+		return
+	}
+	for _, rule := range rules {
+		if !rule.IsEnabled || rule.TriggerType != biz_omiai.ReminderTriggerSegment || rule.SegmentID != payload.SegmentID {
+			continue
+		}
+		content := rule.Name
+		if rule.TemplateID > 0 {
+			if t, err := s.templates.Get(rule.TemplateID); err == nil && t != nil {
+				content = t.Content
+			}
+		}
+		at := s.now().AddDate(0, 0, rule.DelayDays)
+		for _, id := range payload.Entered {
+			task := &biz_omiai.ReminderTask{ClientID: int64(id), RuleID: rule.ID, Content: content, ScheduledAt: at, Status: "pending"}
+			if err := s.reminders.CreateTask(task); err != nil {
+				log.WithContext(ctx).Errorf("segment: rule %d client %d create reminder err:%v", rule.ID, id, err)
+			}
+		}
+	}
+}
+
+func matchNone(clause *biz.WhereClause) *biz.WhereClause {
+	clause.Where += " AND 1 = 0"
+	return clause
+}
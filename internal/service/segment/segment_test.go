@@ -0,0 +1,90 @@
+package segment
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	biz_omiai "omiai-server/internal/biz/omiai"
+	"omiai-server/internal/data"
+	"omiai-server/internal/data/omiai"
+	"omiai-server/internal/service/event"
+	"omiai-server/internal/service/search"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	assert.NoError(t, db.AutoMigrate(&biz_omiai.Client{}, &biz_omiai.PersonalityProfile{}, &biz_omiai.ClientEmbedding{}, &biz_omiai.ClientTag{}, &biz_omiai.Tag{}, &biz_omiai.TagGroup{}, &biz_omiai.ClientVersion{},
+		&biz_omiai.MatchRecord{}, &biz_omiai.FollowUpRecord{}, &biz_omiai.ClientSegment{}, &biz_omiai.ClientSegmentMember{},
+		&biz_omiai.AutoReminderRule{}, &biz_omiai.ReminderTask{}, &biz_omiai.CommunicationTemplate{}))
+
+	d := &data.DB{DB: db}
+	bus := event.NewBus()
+	clients := omiai.NewClientRepo(d, bus)
+	tags := omiai.NewTagRepo(d, bus)
+	reminders := omiai.NewReminderRepo(d)
+	repo := omiai.NewSegmentRepo(d)
+	svc := NewService(repo, tags, search.NewService(omiai.NewSearchRepo(d), bus), reminders, omiai.NewTemplateRepo(d), bus)
+	now := time.Now()
+
+	a := &biz_omiai.Client{Name: "甲", Gender: 2, Phone: "13800000001", Birthday: now.AddDate(-30, 0, 0).Format("2006-01-02"), Education: 4, WorkCity: "北京", Status: biz_omiai.ClientStatusSingle, Tags: "爬山"}
+	b := &biz_omiai.Client{Name: "乙", Gender: 2, Phone: "13800000002", Birthday: now.AddDate(-31, 0, 0).Format("2006-01-02"), Education: 5, WorkCity: "北京", Status: biz_omiai.ClientStatusSingle, Remark: "喜欢摄影"}
+	young := &biz_omiai.Client{Name: "丙", Gender: 2, Phone: "13800000003", Birthday: now.AddDate(-25, 0, 0).Format("2006-01-02"), Education: 4, WorkCity: "北京", Status: biz_omiai.ClientStatusSingle}
+	man := &biz_omiai.Client{Name: "丁", Gender: 1, Phone: "13800000004", Birthday: now.AddDate(-30, 0, 0).Format("2006-01-02"), Education: 4, WorkCity: "北京", Status: biz_omiai.ClientStatusSingle}
+	for _, c := range []*biz_omiai.Client{a, b, young, man} {
+		assert.NoError(t, clients.Create(ctx, c))
+	}
+	assert.NoError(t, db.Model(&biz_omiai.Client{}).Where("1 = 1").Update("created_at", now.AddDate(0, 0, -60)).Error)
+
+	// 女, 28-33, 本科+, 北京, 单身, 30天未跟进
+	seg := &biz_omiai.ClientSegment{Name: "北京大龄女", OwnerID: 1}
+	seg.SetConditions(&biz_omiai.ClientFilter{Gender: 2, MinAge: 28, MaxAge: 33, Education: 4, WorkCity: "北京", Status: biz_omiai.ClientStatusSingle, NoFollowUpDays: 30})
+	assert.NoError(t, repo.Create(ctx, seg))
+	assert.ErrorIs(t, repo.Create(ctx, &biz_omiai.ClientSegment{Name: "北京大龄女"}), biz_omiai.ErrSegmentExists)
+	seg, err = repo.Get(ctx, seg.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 30, seg.Conditions.NoFollowUpDays)
+
+	n, err := svc.Count(ctx, seg)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	rule := &biz_omiai.AutoReminderRule{Name: "重点跟进", TriggerType: biz_omiai.ReminderTriggerSegment, SegmentID: seg.ID, DelayDays: 1, IsEnabled: true}
+	assert.NoError(t, reminders.CreateRule(rule))
+	change, err := svc.Refresh(ctx, seg)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []uint64{a.ID, b.ID}, change.Entered)
+	tasks, _ := reminders.GetTasksByClient(int64(a.ID))
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, "重点跟进", tasks[0].Content)
+
+	// 有回访后离开分群，再次刷新不重复进入
+	record := &biz_omiai.MatchRecord{MaleClientID: man.ID, FemaleClientID: b.ID, Status: biz_omiai.MatchStatusDating}
+	assert.NoError(t, db.Create(record).Error)
+	assert.NoError(t, db.Create(&biz_omiai.FollowUpRecord{MatchRecordID: record.ID, FollowUpDate: now.AddDate(0, 0, -3), Content: "电话回访"}).Error)
+	change, err = svc.Refresh(ctx, seg)
+	assert.NoError(t, err)
+	assert.Empty(t, change.Entered)
+	assert.Equal(t, []uint64{b.ID}, change.Left)
+	got, _ := repo.Get(ctx, seg.ID)
+	assert.Equal(t, int64(1), got.MemberCount)
+	tasks, _ = reminders.GetTasksByClient(int64(a.ID))
+	assert.Len(t, tasks, 1)
+
+	// 标签与关键词条件
+	ids, err := svc.ClientIDs(ctx, &biz_omiai.ClientSegment{Conditions: &biz_omiai.ClientFilter{Tags: "爬山,钓鱼", TagMode: biz_omiai.TagMatchAll}})
+	assert.NoError(t, err)
+	assert.Empty(t, ids)
+	ids, _ = svc.ClientIDs(ctx, &biz_omiai.ClientSegment{Conditions: &biz_omiai.ClientFilter{Tags: "爬山,钓鱼"}})
+	assert.Equal(t, []uint64{a.ID}, ids)
+	ids, _ = svc.ClientIDs(ctx, &biz_omiai.ClientSegment{Conditions: &biz_omiai.ClientFilter{Q: "摄影", Gender: 2}})
+	assert.Equal(t, []uint64{b.ID}, ids)
+}
@@ -3,6 +3,20 @@ package service
 import (
 	"omiai-server/internal/service/banner"
 	"omiai-server/internal/service/chat_parser"
+	"omiai-server/internal/service/daily_recommendation"
+	"omiai-server/internal/service/duplicate"
+	"omiai-server/internal/service/embedding"
+	"omiai-server/internal/service/event"
+	"omiai-server/internal/service/experiment"
+	"omiai-server/internal/service/matching"
+	"omiai-server/internal/service/meeting"
+	"omiai-server/internal/service/pair_history"
+	"omiai-server/internal/service/party"
+	"omiai-server/internal/service/proposal"
+	"omiai-server/internal/service/quality"
+	"omiai-server/internal/service/questionnaire"
+	"omiai-server/internal/service/search"
+	"omiai-server/internal/service/segment"
 
 	"github.com/google/wire"
 )
@@ -10,4 +24,18 @@ import (
 var ProviderService = wire.NewSet(
 	banner.NewService,
 	chat_parser.NewChatParser,
+	daily_recommendation.NewService,
+	duplicate.NewService,
+	embedding.NewService,
+	event.NewBus,
+	experiment.NewService,
+	matching.NewScorer,
+	meeting.NewService,
+	pair_history.NewService,
+	party.NewService,
+	proposal.NewService,
+	quality.NewService,
+	questionnaire.NewService,
+	search.NewService,
+	segment.NewService,
 )
@@ -1,5 +1,7 @@
 package validates
 
+import biz_omiai "omiai-server/internal/biz/omiai"
+
 type ClientCreateValidate struct {
 	Name                string `json:"name" binding:"required"`
 	Gender              int8   `json:"gender" binding:"required,oneof=1 2"`
@@ -30,6 +32,8 @@ type ClientCreateValidate struct {
 	HouseCityCode       string `json:"house_city_code"`
 	HouseDistrictCode   string `json:"house_district_code"`
 	CarStatus           int8   `json:"car_status" binding:"required"`
+	Relocation          int8   `json:"relocation" binding:"omitempty,oneof=1 2"`    // 是否接受迁居 1接受 2不接受
+	LongDistance        int8   `json:"long_distance" binding:"omitempty,oneof=1 2"` // 是否接受异地恋 1接受 2不接受
 	PartnerRequirements string `json:"partner_requirements" binding:"required"`
 	Remark              string `json:"remark"`
 	Photos              string `json:"photos"`
@@ -66,6 +70,8 @@ type ClientUpdateValidate struct {
 	HouseCityCode       string `json:"house_city_code"`
 	HouseDistrictCode   string `json:"house_district_code"`
 	CarStatus           int8   `json:"car_status"`
+	Relocation          int8   `json:"relocation" binding:"omitempty,oneof=1 2"`
+	LongDistance        int8   `json:"long_distance" binding:"omitempty,oneof=1 2"`
 	PartnerRequirements string `json:"partner_requirements"`
 	Remark              string `json:"remark"`
 	Photos              string `json:"photos"`
@@ -73,28 +79,43 @@ type ClientUpdateValidate struct {
 
 type ClientListValidate struct {
 	Paginate
-	Name       string `json:"name" form:"name"`
-	Phone      string `json:"phone" form:"phone"`
-	Gender     int8   `json:"gender" form:"gender"`
-	MinAge     int    `json:"min_age" form:"min_age"`
-	MaxAge     int    `json:"max_age" form:"max_age"`
-	MinHeight  int    `json:"min_height" form:"min_height"`
-	MaxHeight  int    `json:"max_height" form:"max_height"`
-	MinIncome  int    `json:"min_income" form:"min_income"`
-	Education  int8   `json:"education" form:"education"`
-	Address    string `json:"address" form:"address"`
-	Profession string `json:"profession" form:"profession"`
+	biz_omiai.ClientFilter
+	SegmentID uint64 `json:"segment_id" form:"segment_id"` // 限定在保存的分群内，可与其余条件叠加
 	// Phase 1 新增字段
-	Scope         string `json:"scope" form:"scope"`                   // my | public
-	Status        int8   `json:"status" form:"status"`                 // 1单身 2匹配中...
-	Tags          string `json:"tags" form:"tags"`                     // 标签搜索
-	IsPublic      *bool  `json:"is_public" form:"is_public"`           // 用于管理员管理
-	MaritalStatus int8   `json:"marital_status" form:"marital_status"` // 婚姻状况
-	HouseStatus   int8   `json:"house_status" form:"house_status"`     // 房产情况
-	CarStatus     int8   `json:"car_status" form:"car_status"`         // 车辆情况
-	WorkCity      string `json:"work_city" form:"work_city"`           // 工作城市
+	Scope    string `json:"scope" form:"scope"`         // my | public
+	IsPublic *bool  `json:"is_public" form:"is_public"` // 用于管理员管理
 }
 
 type ClientDetailValidate struct {
 	ID uint64 `uri:"id" binding:"required"`
 }
+
+// ClientMatchValidate 智能匹配查询参数
+type ClientMatchValidate struct {
+	Mode    string `form:"mode" binding:"omitempty,oneof=strict relaxed"` // 择偶要求过滤模式，默认 relaxed
+	Explain bool   `form:"explain"`                                       // 附带各候选人的得分解释
+}
+
+// ClientSimilarValidate 相似客户查询参数
+type ClientSimilarValidate struct {
+	Gender int8 `form:"gender" binding:"omitempty,oneof=1 2"`   // 限定性别，默认不限
+	Limit  int  `form:"limit" binding:"omitempty,min=1,max=50"` // 返回数量，默认 10
+}
+
+// ClientHistoryValidate 资料变更历史查询参数
+type ClientHistoryValidate struct {
+	Paginate
+}
+
+// ClientRestoreValidate 从历史版本恢复资料
+type ClientRestoreValidate struct {
+	Version int      `json:"version" binding:"required,min=1"`
+	Fields  []string `json:"fields"` // 为空表示恢复整个版本
+}
+
+// ClientTrashValidate 回收站客户列表
+type ClientTrashValidate struct {
+	Paginate
+	Name  string `json:"name" form:"name"`
+	Phone string `json:"phone" form:"phone"`
+}
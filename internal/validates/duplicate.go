@@ -0,0 +1,24 @@
+package validates
+
+// Duplicate 疑似重复档案
+
+type DuplicateListValidate struct {
+	Paginate
+	Status   int8   `json:"status" form:"status" binding:"omitempty,oneof=1 2 3"` // 默认待审核
+	ClientID uint64 `json:"client_id" form:"client_id"`
+	MinScore int    `json:"min_score" form:"min_score" binding:"min=0,max=100"`
+}
+
+type DuplicateDetailValidate struct {
+	ID uint64 `uri:"id" binding:"required"`
+}
+
+type DuplicateMergeValidate struct {
+	ID         uint64            `json:"id" binding:"required"`
+	SurvivorID uint64            `json:"survivor_id" binding:"required"` // 保留的档案
+	Fields     map[string]uint64 `json:"fields"`                         // 字段取自哪份档案，未指定时保留档案为空的字段取另一份的值
+}
+
+type DuplicateIgnoreValidate struct {
+	ID uint64 `json:"id" binding:"required"`
+}
@@ -42,6 +42,17 @@ type GetCandidatesValidate struct {
 	ClientID uint64 `uri:"id" binding:"required"`
 }
 
+type CandidateListValidate struct {
+	Paginate
+	MinAge       int    `json:"min_age" form:"min_age"`
+	MaxAge       int    `json:"max_age" form:"max_age"`
+	CityCode     string `json:"city_code" form:"city_code"`
+	MinEducation int8   `json:"min_education" form:"min_education"`
+	Sort         string `json:"sort" form:"sort" binding:"omitempty,oneof=score age height income education"`
+	Order        string `json:"order" form:"order" binding:"omitempty,oneof=asc desc"`
+	Explain      bool   `json:"explain" form:"explain"` // 附带各候选人的得分解释
+}
+
 type CompareValidate struct {
 	ClientID    uint64 `uri:"id" binding:"required"`
 	CandidateID uint64 `uri:"candidateId" binding:"required"`
@@ -57,3 +68,99 @@ type DissolveMatchValidate struct {
 	ClientID uint64 `json:"client_id" binding:"required"`
 	Reason   string `json:"reason" binding:"required"`
 }
+
+// Introduction 介绍流程
+
+type IntroductionCreateValidate struct {
+	ClientAID uint64 `json:"client_a_id" binding:"required"` // 先征询意见的一方
+	ClientBID uint64 `json:"client_b_id" binding:"required"`
+	Remark    string `json:"remark"`
+}
+
+type IntroductionTransitionValidate struct {
+	ID           uint64 `json:"id" binding:"required"`
+	Status       int8   `json:"status" binding:"required,min=2,max=9"`
+	Remark       string `json:"remark"`
+	MeetingAt    string `json:"meeting_at"` // 流转到已约见面(6)时必填
+	MeetingPlace string `json:"meeting_place"`
+	CloseReason  string `json:"close_reason"` // 关闭(9)时必填
+}
+
+type IntroductionListValidate struct {
+	Paginate
+	MatchmakerID uint64 `json:"matchmaker_id" form:"matchmaker_id"` // 0 表示不限
+	ClientID     uint64 `json:"client_id" form:"client_id"`
+	Status       int8   `json:"status" form:"status"`
+	Mine         bool   `json:"mine" form:"mine"`           // 仅看当前红娘负责的
+	OnlyOpen     bool   `json:"only_open" form:"only_open"` // 仅看进行中的
+}
+
+type IntroductionDetailValidate struct {
+	ID uint64 `uri:"id" binding:"required"`
+}
+
+type CandidateRejectValidate struct {
+	Kind       int8   `json:"kind" binding:"required,oneof=1 3 4"` // 1拒绝 3屏蔽 4暂不考虑
+	ReasonCode string `json:"reason_code" binding:"required"`
+	Remark     string `json:"remark" binding:"max=255"`
+	ExpiredAt  string `json:"expired_at"` // 暂不考虑时必填，到期后恢复推荐
+}
+
+type PairHistoryRevokeValidate struct {
+	ID uint64 `uri:"id" binding:"required"`
+}
+
+// Proposal 批量配对建议
+
+type ProposalGenerateValidate struct {
+	Strategy        string `json:"strategy" binding:"omitempty,oneof=max_weight stable"`
+	CandidateCap    int    `json:"candidate_cap" binding:"omitempty,min=1,max=20"` // 每人待审核建议上限，默认 3
+	MinScore        int    `json:"min_score" binding:"omitempty,min=1,max=100"`    // 默认 60
+	RequirementMode string `json:"requirement_mode" binding:"omitempty,oneof=strict relaxed"`
+}
+
+type ProposalBatchDetailValidate struct {
+	Paginate
+	ID     uint64 `uri:"id" binding:"required"`
+	Status int8   `json:"status" form:"status"`
+}
+
+type ProposalAcceptValidate struct {
+	ID     uint64 `json:"id" binding:"required"`
+	Remark string `json:"remark" binding:"max=255"`
+}
+
+type ProposalRejectValidate struct {
+	ID         uint64 `json:"id" binding:"required"`
+	ReasonCode string `json:"reason_code" binding:"required"`
+	Remark     string `json:"remark" binding:"max=255"`
+}
+
+// DailyRecommendation 每日推荐
+
+type DailyListValidate struct {
+	Paginate
+	Date     string `json:"date" form:"date" binding:"omitempty,datetime=2006-01-02"` // 默认今天
+	ClientID uint64 `json:"client_id" form:"client_id"`
+	Status   int8   `json:"status" form:"status" binding:"omitempty,oneof=1 2 3 4"`
+}
+
+type DailyGenerateValidate struct {
+	Count     int `json:"count" binding:"omitempty,min=1,max=20"`       // 每位客户推荐数，默认 3
+	FreshDays int `json:"fresh_days" binding:"omitempty,min=1,max=365"` // 同一候选人再次推荐的间隔天数，默认 30
+}
+
+type DailyAcceptValidate struct {
+	ID     uint64 `json:"id" binding:"required"`
+	Remark string `json:"remark" binding:"max=255"`
+}
+
+type DailySkipValidate struct {
+	ID         uint64 `json:"id" binding:"required"`
+	ReasonCode string `json:"reason_code"` // 填写时记录为拒绝，之后不再推荐该配对
+	Remark     string `json:"remark" binding:"max=255"`
+}
+
+type DailyStatsValidate struct {
+	Days int `json:"days" form:"days" binding:"omitempty,min=1,max=90"` // 默认最近 7 天
+}
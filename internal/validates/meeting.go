@@ -0,0 +1,89 @@
+package validates
+
+// Meeting 见面预约
+
+type VenueSaveValidate struct {
+	ID           uint64 `json:"id"` // 修改时必填
+	Name         string `json:"name" binding:"required,max=128"`
+	Address      string `json:"address" binding:"max=255"`
+	ProvinceCode string `json:"province_code"`
+	CityCode     string `json:"city_code"`
+	DistrictCode string `json:"district_code"`
+	Capacity     int    `json:"capacity" binding:"min=0,max=100"` // 0 按 1 处理
+	OpenTime     string `json:"open_time"`                        // HH:MM，为空表示全天
+	CloseTime    string `json:"close_time"`
+	IsEnabled    *bool  `json:"is_enabled"` // 为空表示启用
+	Remark       string `json:"remark" binding:"max=255"`
+}
+
+type VenueListValidate struct {
+	Paginate
+	Name      string `json:"name" form:"name"`
+	CityCode  string `json:"city_code" form:"city_code"`
+	IsEnabled *bool  `json:"is_enabled" form:"is_enabled"`
+}
+
+type AvailabilityItem struct {
+	Weekday   int8   `json:"weekday" binding:"min=0,max=6"`
+	StartTime string `json:"start_time" binding:"required"`
+	EndTime   string `json:"end_time" binding:"required"`
+}
+
+type AvailabilitySetValidate struct {
+	ClientID uint64              `json:"client_id" binding:"required"`
+	Items    []*AvailabilityItem `json:"items" binding:"dive"` // 为空表示随时可约
+}
+
+type AvailabilityGetValidate struct {
+	ClientID uint64 `form:"client_id" binding:"required"`
+}
+
+type MeetingBookValidate struct {
+	IntroductionID uint64 `json:"introduction_id"` // 与 match_record_id 二选一
+	MatchRecordID  uint64 `json:"match_record_id"`
+	VenueID        uint64 `json:"venue_id"` // 为空表示自行约定地点
+	Place          string `json:"place" binding:"max=255"`
+	StartAt        string `json:"start_at" binding:"required"`
+	EndAt          string `json:"end_at"` // 为空按默认时长
+	Remark         string `json:"remark" binding:"max=255"`
+	Force          bool   `json:"force"` // 忽略红娘时间重叠与客户可约时间
+}
+
+type MeetingRescheduleValidate struct {
+	ID      uint64 `json:"id" binding:"required"`
+	VenueID uint64 `json:"venue_id"`
+	Place   string `json:"place" binding:"max=255"`
+	StartAt string `json:"start_at" binding:"required"`
+	EndAt   string `json:"end_at"`
+	Remark  string `json:"remark" binding:"max=255"`
+	Force   bool   `json:"force"`
+}
+
+type MeetingFinishValidate struct {
+	ID       uint64 `json:"id" binding:"required"`
+	Feedback string `json:"feedback"`
+}
+
+type MeetingListValidate struct {
+	Paginate
+	Status         int8   `json:"status" form:"status" binding:"omitempty,oneof=1 2 3 4"`
+	ClientID       uint64 `json:"client_id" form:"client_id"`
+	VenueID        uint64 `json:"venue_id" form:"venue_id"`
+	IntroductionID uint64 `json:"introduction_id" form:"introduction_id"`
+	MatchRecordID  uint64 `json:"match_record_id" form:"match_record_id"`
+	Mine           bool   `json:"mine" form:"mine"` // 仅看自己负责的
+	StartDate      string `json:"start_date" form:"start_date"`
+	EndDate        string `json:"end_date" form:"end_date"`
+}
+
+type MeetingDetailValidate struct {
+	ID uint64 `uri:"id" binding:"required"`
+}
+
+type MeetingSlotsValidate struct {
+	ClientAID uint64 `form:"client_a_id" binding:"required"`
+	ClientBID uint64 `form:"client_b_id" binding:"required"`
+	VenueID   uint64 `form:"venue_id"`
+	Date      string `form:"date" binding:"required"`         // YYYY-MM-DD
+	Minutes   int    `form:"minutes" binding:"min=0,max=480"` // 0 按默认时长
+}
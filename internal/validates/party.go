@@ -0,0 +1,49 @@
+package validates
+
+// Party 相亲会
+
+type PartySaveValidate struct {
+	ID           uint64 `json:"id"` // 修改时必填
+	Title        string `json:"title" binding:"required,max=128"`
+	Venue        string `json:"venue" binding:"max=255"`
+	StartAt      string `json:"start_at" binding:"required"`
+	EndAt        string `json:"end_at"`
+	Capacity     int    `json:"capacity" binding:"min=0,max=1000"`     // 0 表示不限
+	MaleQuota    int    `json:"male_quota" binding:"min=0,max=500"`    // 0 表示不限
+	FemaleQuota  int    `json:"female_quota" binding:"min=0,max=500"`  // 0 表示不限
+	Rounds       int    `json:"rounds" binding:"min=0,max=100"`        // 0 表示与全部异性各见一次
+	RoundMinutes int    `json:"round_minutes" binding:"min=0,max=120"` // 每轮分钟数
+	PickLimit    int    `json:"pick_limit" binding:"min=0,max=50"`     // 0 表示不限
+	MutualAction string `json:"mutual_action" binding:"omitempty,oneof=introduction proposal"`
+	Remark       string `json:"remark"`
+}
+
+type PartyListValidate struct {
+	Paginate
+	Status int8   `json:"status" form:"status" binding:"omitempty,oneof=1 2 3 4 5"`
+	Title  string `json:"title" form:"title"`
+}
+
+type PartyDetailValidate struct {
+	ID uint64 `uri:"id" binding:"required"`
+}
+
+type PartyTransitionValidate struct {
+	ID     uint64 `json:"id" binding:"required"`
+	Status int8   `json:"status" binding:"required,oneof=2 3 5"` // 2 开始 3 进入互选 5 取消
+}
+
+type PartyAttendeeValidate struct {
+	ID       uint64 `json:"id" binding:"required"`
+	ClientID uint64 `json:"client_id" binding:"required"`
+}
+
+type PartyIDValidate struct {
+	ID uint64 `json:"id" binding:"required"`
+}
+
+type PartyPicksValidate struct {
+	ID        uint64   `json:"id" binding:"required"`
+	ClientID  uint64   `json:"client_id" binding:"required"`
+	PickedIDs []uint64 `json:"picked_ids"` // 为空表示没有心仪对象
+}
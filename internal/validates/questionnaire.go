@@ -0,0 +1,28 @@
+package validates
+
+import biz_omiai "omiai-server/internal/biz/omiai"
+
+type QuestionnaireCreateValidate struct {
+	Code        string                `json:"code"` // 默认 personality
+	Title       string                `json:"title" binding:"required"`
+	Description string                `json:"description"`
+	Questions   []*biz_omiai.Question `json:"questions" binding:"required"`
+}
+
+type QuestionnaireIssueValidate struct {
+	ClientID        uint64 `json:"client_id" binding:"required"`
+	QuestionnaireID uint64 `json:"questionnaire_id"` // 为空时使用启用中的版本
+	ExpireDays      int    `json:"expire_days" binding:"omitempty,min=1,max=90"`
+}
+
+type QuestionnaireTokenValidate struct {
+	Token string `uri:"token" binding:"required"`
+}
+
+type QuestionnaireSubmitValidate struct {
+	Answers map[string]int `json:"answers" binding:"required"` // 题目key => 1-5
+}
+
+type QuestionnaireProfileValidate struct {
+	ClientID uint64 `uri:"client_id" binding:"required"`
+}
@@ -0,0 +1,32 @@
+package validates
+
+import biz_omiai "omiai-server/internal/biz/omiai"
+
+// Segment 保存的客户分群
+
+type SegmentListValidate struct {
+	Paginate
+	Keyword string `json:"keyword" form:"keyword"` // 匹配分群名称
+	Mine    bool   `json:"mine" form:"mine"`       // 仅看自己创建的
+}
+
+type SegmentIDValidate struct {
+	ID uint64 `uri:"id" binding:"required"`
+}
+
+type SegmentCreateValidate struct {
+	Name        string                 `json:"name" binding:"required,max=64"`
+	Description string                 `json:"description" binding:"max=255"`
+	Filter      biz_omiai.ClientFilter `json:"filter"` // 与客户列表的筛选参数一致
+	Shared      bool                   `json:"shared"` // 共享给全部红娘，否则仅创建人与管理员可见
+}
+
+type SegmentUpdateValidate struct {
+	ID uint64 `json:"id" binding:"required"`
+	SegmentCreateValidate
+}
+
+// SegmentPreviewValidate 保存前预览筛选条件的人数
+type SegmentPreviewValidate struct {
+	Filter biz_omiai.ClientFilter `json:"filter"`
+}
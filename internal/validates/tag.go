@@ -0,0 +1,44 @@
+package validates
+
+// Tag 标签分组、标签字典与客户标签
+
+type TagGroupSaveValidate struct {
+	ID        uint64 `json:"id"` // 为空时新建
+	Name      string `json:"name" binding:"required,max=32"`
+	Exclusive bool   `json:"exclusive"` // 单选分组，每位客户只保留一个
+	Scored    bool   `json:"scored"`    // 参与匹配评分的标签重合度
+	Sort      int    `json:"sort"`
+}
+
+type TagListValidate struct {
+	Paginate
+	GroupID *uint64 `json:"group_id" form:"group_id"` // 0 为未分组
+	Keyword string  `json:"keyword" form:"keyword"`   // 匹配标签名与同义词
+}
+
+type TagIDValidate struct {
+	ID uint64 `uri:"id" binding:"required"`
+}
+
+type TagCreateValidate struct {
+	GroupID  uint64   `json:"group_id"`
+	Name     string   `json:"name" binding:"required,max=32"`
+	Synonyms []string `json:"synonyms"`
+	Sort     int      `json:"sort"`
+}
+
+type TagUpdateValidate struct {
+	ID uint64 `json:"id" binding:"required"`
+	TagCreateValidate
+}
+
+type TagMergeValidate struct {
+	SourceID uint64 `json:"source_id" binding:"required"` // 合并后删除
+	TargetID uint64 `json:"target_id" binding:"required,nefield=SourceID"`
+}
+
+// ClientTagSetValidate 替换客户的全部标签，tag_ids 与 names 可同时填写，未收录的名称新建为未分组标签
+type ClientTagSetValidate struct {
+	TagIDs []uint64 `json:"tag_ids"`
+	Names  []string `json:"names"`
+}
@@ -25,6 +25,7 @@ const (
 	UserStyleCode          = 44800 // 用户风格状态码
 	PayCode                = 44900 // 支付相关状态码
 	OrderCode              = 45000 // 订单相关状态码
+	MatchCode              = 45100 // 匹配相关状态码
 
 )
 
@@ -46,3 +47,9 @@ const (
 	DBDeleteCommonError                           // 40013 DB删除错误
 	AuthCommonError                               // 40014 权限错误
 )
+
+// 匹配相关状态码 451XX
+const (
+	MatchInvalidTransition = iota + MatchCode // 45100 情侣状态流转不合法
+	MatchStatusConflict                       // 45101 情侣状态已被他人修改
+)